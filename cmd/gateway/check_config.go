@@ -0,0 +1,376 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/prompt-gateway/internal/alerting"
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/config"
+	"github.com/prompt-gateway/internal/decisiontoken"
+	"github.com/prompt-gateway/internal/tokenizer"
+	"github.com/prompt-gateway/internal/usagereport"
+	"github.com/redis/go-redis/v9"
+)
+
+// schemaCheck is a column whose presence proves a migration was applied
+type schemaCheck struct {
+	migration string
+	table     string
+	column    string
+}
+
+// columnWidth is a minimum character length a migration gave a column
+type columnWidth struct {
+	migration string
+	table     string
+	column    string
+	width     int
+}
+
+// schemaChecks must gain an entry whenever a migration is added
+var schemaChecks = []schemaCheck{
+	{"001_initial.sql", "policies", "pattern_value"},
+	{"001_initial.sql", "audit_logs", "prompt_hash"},
+	{"002_audit_sessions.sql", "audit_logs", "session_id"},
+	{"003_incidents.sql", "incidents", "dedup_key"},
+	{"004_clients.sql", "clients", "trust_adjustment"},
+	{"004_clients.sql", "policies", "tier_actions"},
+	{"005_policy_roles.sql", "policies", "roles"},
+	{"006_policy_client_selectors.sql", "clients", "labels"},
+	{"006_policy_client_selectors.sql", "policies", "applies_to_clients"},
+	{"007_client_replay_protection.sql", "clients", "require_nonce"},
+	{"008_wordlists.sql", "wordlists", "terms"},
+	{"009_policy_scan_scope.sql", "policies", "scan_scope"},
+	{"011_policy_max_input.sql", "policies", "max_input_bytes"},
+	{"012_policy_stemming.sql", "policies", "stem"},
+	{"013_policy_hit_stats.sql", "policies", "last_matched_at"},
+	{"014_audit_origin.sql", "audit_logs", "gateway_version"},
+	{"015_policy_webhook.sql", "policies", "webhook_url"},
+	{"016_blocked_prompts.sql", "blocked_prompts", "simhash"},
+	{"017_policy_capture_constraints.sql", "policies", "capture_constraints"},
+	{"018_policy_ownership.sql", "policies", "review_by"},
+	{"019_policy_tags.sql", "policies", "tags"},
+	{"020_client_default_action.sql", "clients", "default_action"},
+	{"021_benign_corpus.sql", "benign_corpus", "prompts"},
+	{"022_policy_escalations.sql", "policies", "escalations"},
+	{"023_audit_rollups.sql", "audit_policy_rollups", "hits"},
+	{"023_audit_rollups.sql", "audit_rollup_state", "refreshed_at"},
+	{"024_policy_notify_cooldown.sql", "policies", "notify_cooldown_seconds"},
+	{"026_usage_reports.sql", "usage_report_deliveries", "period_start"},
+	{"027_policy_priority.sql", "policies", "priority"},
+	{"028_policy_keyword_match_mode.sql", "policies", "match_mode"},
+	{"030_content_keys.sql", "content_keys", "wrapped_key"},
+	{"030_content_keys.sql", "benign_corpus", "sealed_prompts"},
+}
+
+// columnWidths must gain an entry whenever a migration only widens a column
+var columnWidths = []columnWidth{
+	{"025_audit_hash_width.sql", "audit_logs", "prompt_hash", 128},
+	{"025_audit_hash_width.sql", "blocked_prompts", "prompt_hash", 128},
+}
+
+// checkReport collects check results for printing
+type checkReport struct {
+	failed int
+}
+
+// pass records a successful check
+func (r *checkReport) pass(name, detail string) {
+	fmt.Printf("  ✓ %-22s %s\n", name, detail)
+}
+
+// fail records a failed check
+func (r *checkReport) fail(name string, err error) {
+	r.failed++
+	fmt.Printf("  ✗ %-22s %v\n", name, err)
+}
+
+// runCheckConfig implements `gateway check-config`: it validates configuration,
+// tests connectivity to every dependency and verifies the schema, returning the
+// process exit code (non-zero when anything failed)
+func runCheckConfig(args []string) int {
+	flags := flag.NewFlagSet("check-config", flag.ContinueOnError)
+	envFile := flags.String("env-file", ".env", "env file to load before reading the environment")
+	timeout := flags.Duration("timeout", 5*time.Second, "timeout for each connectivity check")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	fmt.Println("🔎 Checking gateway configuration...")
+	report := &checkReport{}
+
+	if err := godotenv.Load(*envFile); err != nil {
+		fmt.Printf("  - %-22s %s not loaded, using environment only\n", "env file", *envFile)
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		report.fail("config", err)
+		fmt.Println("❌ Configuration invalid, skipping connectivity checks")
+		return 1
+	}
+	report.pass("config", fmt.Sprintf("loaded (port %s, enforcement %s)", cfg.Port, cfg.EnforcementMode))
+
+	checkOptionalConfig(report, cfg)
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		report.fail("postgres", err)
+	} else {
+		defer db.Close()
+		ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+		if err := db.PingContext(ctx); err != nil {
+			report.fail("postgres", err)
+		} else {
+			report.pass("postgres", "connected")
+			checkMigrations(ctx, report, db)
+		}
+		cancel()
+	}
+
+	checkRedis(report, cfg, *timeout)
+
+	httpClient := &http.Client{Timeout: *timeout}
+	checkEndpoint(report, httpClient, "nemo", cfg.NemoEndpoint, map[string]string{"Authorization": "Bearer " + cfg.NemoAPIKey})
+	if cfg.ProxyEnabled {
+		checkEndpoint(report, httpClient, "upstream openai", cfg.OpenAIUpstream, nil)
+		checkEndpoint(report, httpClient, "upstream anthropic", cfg.AnthropicUpstream, nil)
+		checkEndpoint(report, httpClient, "upstream gemini", cfg.GeminiUpstream, nil)
+	}
+
+	if report.failed > 0 {
+		fmt.Printf("❌ %d check(s) failed\n", report.failed)
+		return 1
+	}
+	fmt.Println("✅ All checks passed")
+	return 0
+}
+
+// checkOptionalConfig validates settings that are only parsed when a feature is enabled
+func checkOptionalConfig(report *checkReport, cfg *config.Config) {
+	if cfg.AlertRulesFile != "" {
+		if rules, err := alerting.LoadRules(cfg.AlertRulesFile); err != nil {
+			report.fail("alert rules", err)
+		} else {
+			report.pass("alert rules", fmt.Sprintf("%d rule(s) in %s", len(rules), cfg.AlertRulesFile))
+		}
+	}
+
+	if cfg.ModelPrivacyFile != "" {
+		if privacy, err := analyzer.LoadModelPrivacy(cfg.ModelPrivacyFile); err != nil {
+			report.fail("model privacy", err)
+		} else {
+			report.pass("model privacy", fmt.Sprintf("%d provider(s), %d tenant(s) in %s", len(privacy.Providers), len(privacy.Tenants), cfg.ModelPrivacyFile))
+		}
+	}
+
+	if cfg.ModelRoutesFile != "" {
+		if routes, err := analyzer.LoadModelRoutes(cfg.ModelRoutesFile); err != nil {
+			report.fail("model routes", err)
+		} else {
+			report.pass("model routes", fmt.Sprintf("%d routed model(s) in %s", len(routes.Models), cfg.ModelRoutesFile))
+		}
+	}
+
+	if cfg.TraceEndpoint != "" {
+		if u, err := url.Parse(cfg.TraceEndpoint); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			report.fail("tracing", fmt.Errorf("OTLP endpoint %q must be an http(s) URL", cfg.TraceEndpoint))
+		} else {
+			report.pass("tracing", fmt.Sprintf("exporting %s spans to %s", cfg.TraceServiceName, cfg.TraceEndpoint))
+		}
+	}
+
+	if cfg.DecisionTokenKey != "" {
+		key, err := base64.StdEncoding.DecodeString(cfg.DecisionTokenKey)
+		if err == nil {
+			_, err = decisiontoken.NewSigner(cfg.DecisionTokenAlg, key, time.Duration(cfg.DecisionTokenTTL)*time.Second)
+		}
+		if err != nil {
+			report.fail("decision tokens", err)
+		} else {
+			report.pass("decision tokens", cfg.DecisionTokenAlg)
+		}
+	}
+
+	if cfg.ContentKey != "" {
+		if _, previous, err := contentMasters(cfg); err != nil {
+			report.fail("content keys", err)
+		} else if len(previous) > 0 {
+			report.pass("content keys", fmt.Sprintf("master %s, rotating from %s", cfg.ContentKeyID, cfg.ContentKeyPrevID))
+		} else {
+			report.pass("content keys", "master "+cfg.ContentKeyID)
+		}
+	}
+
+	if cfg.TokenizerVocab != "" {
+		if tok, err := tokenizer.Load(cfg.TokenizerVocab); err != nil {
+			report.fail("tokenizer", err)
+		} else {
+			report.pass("tokenizer", tok.Name())
+		}
+	}
+
+	if cfg.AuditWALDir != "" {
+		if err := checkAuditWAL(cfg.AuditWALDir); err != nil {
+			report.fail("audit WAL", err)
+		} else {
+			report.pass("audit WAL", cfg.AuditWALDir+" writable")
+		}
+	}
+
+	if cfg.PolicySnapshot != "" {
+		if detail, err := checkPolicySnapshot(cfg.PolicySnapshot); err != nil {
+			report.fail("policy snapshot", err)
+		} else {
+			report.pass("policy snapshot", detail)
+		}
+	}
+
+	if cfg.ReportPeriods != "" {
+		if periods, err := usagereport.ParsePeriods(cfg.ReportPeriods); err != nil {
+			report.fail("usage reports", err)
+		} else {
+			report.pass("usage reports", fmt.Sprintf("%v as %s via %s", periods, cfg.ReportFormat, cfg.ReportSink))
+		}
+	}
+
+	if cfg.AdminAPIKey == "" {
+		fmt.Printf("  - %-22s ADMIN_API_KEY unset, admin endpoints disabled\n", "admin")
+	}
+}
+
+// checkPolicySnapshot verifies an existing warm-start snapshot loads and that a
+// new one can be saved next to it
+func checkPolicySnapshot(path string) (string, error) {
+	f, err := os.CreateTemp(filepath.Dir(path), ".check-*")
+	if err != nil {
+		return "", err
+	}
+	f.Close()
+	os.Remove(f.Name())
+
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return path + " not saved yet, directory writable", nil
+	}
+	snapshot, err := cache.NewPolicyCache(nil).LoadSnapshot(path)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%d policies in %s, loaded %s", len(snapshot.Policies), path, snapshot.LoadedAt.Format(time.RFC3339)), nil
+}
+
+// checkAuditWAL verifies the gateway can create files in the audit WAL directory
+func checkAuditWAL(dir string) error {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".check-*")
+	if err != nil {
+		return err
+	}
+	f.Close()
+	return os.Remove(f.Name())
+}
+
+// checkMigrations verifies every migration left its mark on the schema
+func checkMigrations(ctx context.Context, report *checkReport, db *sql.DB) {
+	missing := 0
+	for _, c := range schemaChecks {
+		var exists bool
+		err := db.QueryRowContext(ctx, `
+			SELECT EXISTS (
+				SELECT 1 FROM information_schema.columns
+				WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+			)`, c.table, c.column).Scan(&exists)
+		if err != nil {
+			report.fail("migrations", fmt.Errorf("schema query failed: %w", err))
+			return
+		}
+		if !exists {
+			missing++
+			report.fail("migration "+c.migration, fmt.Errorf("missing %s.%s", c.table, c.column))
+		}
+	}
+	for _, c := range columnWidths {
+		var width int
+		err := db.QueryRowContext(ctx, `
+			SELECT COALESCE(MAX(character_maximum_length), 0) FROM information_schema.columns
+			WHERE table_schema = current_schema() AND table_name = $1 AND column_name = $2
+		`, c.table, c.column).Scan(&width)
+		if err != nil {
+			report.fail("migrations", fmt.Errorf("schema query failed: %w", err))
+			return
+		}
+		if width < c.width {
+			missing++
+			report.fail("migration "+c.migration, fmt.Errorf("%s.%s holds %d characters, want %d", c.table, c.column, width, c.width))
+		}
+	}
+	if missing == 0 {
+		report.pass("migrations", fmt.Sprintf("%d schema checks passed", len(schemaChecks)+len(columnWidths)))
+	}
+}
+
+// checkRedis verifies Redis is reachable with the configured TLS and ACL settings
+func checkRedis(report *checkReport, cfg *config.Config, timeout time.Duration) {
+	opt, err := redisOptions(cfg)
+	if err != nil {
+		report.fail("redis", err)
+		return
+	}
+	rdb := redis.NewClient(opt)
+	defer rdb.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := rdb.Ping(ctx).Err(); err != nil {
+		report.fail("redis", err)
+		return
+	}
+	report.pass("redis", fmt.Sprintf("connected (db %d, tls %v)", opt.DB, opt.TLSConfig != nil))
+}
+
+// checkEndpoint verifies an HTTP dependency answers; any response below 500
+// counts as reachable except rejected credentials
+func checkEndpoint(report *checkReport, client *http.Client, name, url string, headers map[string]string) {
+	if url == "" {
+		report.fail(name, fmt.Errorf("endpoint not configured"))
+		return
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		report.fail(name, err)
+		return
+	}
+	for k, v := range headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		report.fail(name, err)
+		return
+	}
+	resp.Body.Close()
+
+	switch {
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		report.fail(name, fmt.Errorf("credentials rejected (HTTP %d)", resp.StatusCode))
+	case resp.StatusCode >= 500:
+		report.fail(name, fmt.Errorf("unhealthy (HTTP %d)", resp.StatusCode))
+	default:
+		report.pass(name, fmt.Sprintf("reachable (HTTP %d)", resp.StatusCode))
+	}
+}
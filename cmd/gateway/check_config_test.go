@@ -0,0 +1,99 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/internal/config"
+)
+
+func TestSchemaChecksCoverMigrations(t *testing.T) {
+	files, err := filepath.Glob("../../migrations/*.sql")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no migrations found: %v", err)
+	}
+	checked := map[string]bool{}
+	for _, c := range schemaChecks {
+		checked[c.migration] = true
+	}
+	widened := map[string]bool{}
+	for _, c := range columnWidths {
+		widened[c.migration] = true
+	}
+
+	names := map[string]bool{}
+	for _, file := range files {
+		name := filepath.Base(file)
+		names[name] = true
+		sql, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		upper := strings.ToUpper(string(sql))
+		// Data-only migrations (seeded policies, backfills) leave no schema mark
+		if (strings.Contains(upper, "ADD COLUMN") || strings.Contains(upper, "CREATE TABLE")) && !checked[name] {
+			t.Errorf("%s adds schema but has no schemaChecks entry", name)
+		}
+		if strings.Contains(upper, "ALTER COLUMN") && !widened[name] {
+			t.Errorf("%s alters a column but has no columnWidths entry", name)
+		}
+	}
+	for name := range checked {
+		if !names[name] {
+			t.Errorf("schemaChecks names missing migration %s", name)
+		}
+	}
+	for name := range widened {
+		if !names[name] {
+			t.Errorf("columnWidths names missing migration %s", name)
+		}
+	}
+}
+
+func TestCheckOptionalConfig(t *testing.T) {
+	dir := t.TempDir()
+	notDir := filepath.Join(dir, "file")
+	if err := os.WriteFile(notDir, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	report := &checkReport{}
+	checkOptionalConfig(report, &config.Config{AuditWALDir: filepath.Join(dir, "wal"), AdminAPIKey: "key"})
+	if report.failed != 0 {
+		t.Errorf("valid config failed %d check(s)", report.failed)
+	}
+
+	report = &checkReport{}
+	checkOptionalConfig(report, &config.Config{
+		AlertRulesFile: filepath.Join(dir, "missing.json"),
+		TraceEndpoint:  "collector:4318",
+		AuditWALDir:    filepath.Join(notDir, "wal"),
+		ContentKey:     "c2hvcnQ=",
+		ContentKeyID:   "local-1",
+		AdminAPIKey:    "key",
+	})
+	if report.failed != 4 {
+		t.Errorf("invalid config failed %d check(s), want 4 (alert rules, tracing, audit WAL, content keys)", report.failed)
+	}
+}
+
+func TestCheckPolicySnapshot(t *testing.T) {
+	dir := t.TempDir()
+	detail, err := checkPolicySnapshot(filepath.Join(dir, "policies.json"))
+	if err != nil || !strings.Contains(detail, "not saved yet") {
+		t.Errorf("checkPolicySnapshot(missing) = %q, %v, want writable directory", detail, err)
+	}
+
+	corrupt := filepath.Join(dir, "corrupt.json")
+	if err := os.WriteFile(corrupt, []byte("{"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := checkPolicySnapshot(corrupt); err == nil {
+		t.Error("checkPolicySnapshot(corrupt) succeeded")
+	}
+	if _, err := checkPolicySnapshot(filepath.Join(dir, "missing", "policies.json")); err == nil {
+		t.Error("checkPolicySnapshot(missing directory) succeeded")
+	}
+}
@@ -3,120 +3,712 @@ package main
 import (
 	"context"
 	"database/sql"
+	"encoding/base64"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/joho/godotenv"
-	_ "github.com/lib/pq"
+	"github.com/lib/pq"
+	"github.com/prompt-gateway/internal/alerting"
+	"github.com/prompt-gateway/internal/allowlist"
 	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/anomaly"
 	"github.com/prompt-gateway/internal/api"
 	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/breaker"
 	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/chaos"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/cluster"
 	"github.com/prompt-gateway/internal/config"
+	"github.com/prompt-gateway/internal/dbpool"
+	"github.com/prompt-gateway/internal/decisioncache"
+	"github.com/prompt-gateway/internal/decisiontoken"
+	"github.com/prompt-gateway/internal/envelope"
+	"github.com/prompt-gateway/internal/extauthz"
+	"github.com/prompt-gateway/internal/fingerprint"
+	"github.com/prompt-gateway/internal/firehose"
+	"github.com/prompt-gateway/internal/flags"
+	"github.com/prompt-gateway/internal/grpcapi"
+	"github.com/prompt-gateway/internal/guardstream"
+	"github.com/prompt-gateway/internal/incident"
+	"github.com/prompt-gateway/internal/logging"
+	"github.com/prompt-gateway/internal/maintenance"
 	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/internal/notify"
 	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/internal/policylint"
+	"github.com/prompt-gateway/internal/providers"
+	"github.com/prompt-gateway/internal/proxy"
+	"github.com/prompt-gateway/internal/replay"
+	"github.com/prompt-gateway/internal/scheduler"
+	"github.com/prompt-gateway/internal/session"
+	"github.com/prompt-gateway/internal/shadow"
+	"github.com/prompt-gateway/internal/slo"
+	"github.com/prompt-gateway/internal/tenant"
+	"github.com/prompt-gateway/internal/tokenizer"
+	"github.com/prompt-gateway/internal/tracing"
+	"github.com/prompt-gateway/internal/usagereport"
+	"github.com/prompt-gateway/internal/usermessage"
+	"github.com/prompt-gateway/internal/wordlist"
+	"github.com/prompt-gateway/pkg/models"
 	"github.com/redis/go-redis/v9"
+	"google.golang.org/grpc"
 )
 
 func main() {
-	log.Println("🚀 Starting Prompt Analysis Gateway...")
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "check-config" {
+		os.Exit(runCheckConfig(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "policy-diff" {
+		os.Exit(runPolicyDiff(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rotate-keys" {
+		os.Exit(runRotateKeys(os.Args[2:]))
+	}
+
+	slog.Info("Starting Prompt Analysis Gateway")
 
 	// This loads variables from .env into the environment
 	if err := godotenv.Load(); err != nil {
-		log.Println("⚠️  No .env file found, using environment variables")
+		slog.Warn("No .env file found, using environment variables")
 	}
 
 	// 1. Load configuration from environment variables
 	cfg, err := config.Load()
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", "error", err)
+	}
+
+	// Every log line from here on honors LOG_LEVEL and LOG_FORMAT
+	logLevel, err := logging.ParseLevel(cfg.LogLevel)
+	if err != nil {
+		fatal("Invalid LOG_LEVEL", "error", err)
+	}
+	logController := logging.NewController(logLevel, cfg.LogFormat)
+	slog.SetDefault(logController.Logger())
+	slog.Info("Configuration loaded", "port", cfg.Port, "log_level", logLevel, "log_format", cfg.LogFormat)
+
+	// Spans of every request, analyzer matcher, Redis command and Postgres query
+	// are exported over OTLP when a collector endpoint is configured
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		Endpoint:    cfg.TraceEndpoint,
+		ServiceName: cfg.TraceServiceName,
+		Version:     api.Version,
+	})
+	if err != nil {
+		fatal("Failed to set up tracing", "error", err)
+	}
+	defer func() {
+		flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := shutdownTracing(flushCtx); err != nil {
+			slog.Warn("Failed to flush spans", "error", err)
+		}
+	}()
+	if cfg.TraceEndpoint != "" {
+		slog.Info("Tracing enabled", "endpoint", cfg.TraceEndpoint, "service", cfg.TraceServiceName)
 	}
-	log.Printf("✓ Configuration loaded (Port: %s)", cfg.Port)
 
 	// 2. Connect to PostgreSQL
-	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	connector, err := pq.NewConnector(cfg.DatabaseURL)
 	if err != nil {
-		log.Fatalf("Failed to connect to database: %v", err)
+		fatal("Failed to connect to database", "error", err)
 	}
+	db := sql.OpenDB(tracing.WrapConnector(connector))
 	defer db.Close()
 
 	// Configure connection pool
-	// 	db.SetMaxOpenConns(cfg.DBMaxOpenConns) // Max connections from config
+	db.SetMaxOpenConns(cfg.DBMaxOpenConns) // Max connections from config
 	db.SetMaxIdleConns(cfg.DBMaxIdleConns) // Idle connections from config
 	db.SetConnMaxLifetime(5 * time.Minute) // Connection lifetime
 
-	// Test database connection
+	// Test database connection; with a policy snapshot on disk the gateway can
+	// warm-start without it (e.g. during a database maintenance window)
 	if err := db.Ping(); err != nil {
-		log.Fatalf("Failed to ping database: %v", err)
+		if cfg.PolicySnapshot == "" {
+			fatal("Failed to ping database", "error", err)
+		}
+		slog.Warn("Failed to ping database, trying to warm-start", "snapshot", cfg.PolicySnapshot, "error", err)
+	} else {
+		slog.Info("Connected to PostgreSQL")
 	}
-	log.Println("✓ Connected to PostgreSQL")
 
 	// 3. Connect to Redis
-	opt, err := redis.ParseURL(cfg.RedisURL)
+	opt, err := redisOptions(cfg)
 	if err != nil {
-		log.Fatalf("Failed to parse Redis URL: %v", err)
+		fatal("Invalid Redis configuration", "error", err)
 	}
-
-	// Configure connection pool for high throughput
-	opt.PoolSize = cfg.RedisPoolSize
-	opt.MinIdleConns = cfg.RedisMinIdle
-	opt.PoolTimeout = time.Duration(cfg.RedisPoolTimeout) * time.Second
-	opt.MaxRetries = cfg.RedisMaxRetries
-
 	rdb := redis.NewClient(opt)
 	defer rdb.Close()
 
 	// Test Redis connection
 	ctx := context.Background()
 	if err := rdb.Ping(ctx).Err(); err != nil {
-		log.Fatalf("Failed to connect to Redis: %v", err)
+		fatal("Failed to connect to Redis", "error", err)
+	}
+	slog.Info("Connected to Redis", "pool", cfg.RedisPoolSize, "min_idle", cfg.RedisMinIdle, "db", opt.DB, "tls", opt.TLSConfig != nil)
+
+	// Circuit breakers: outages degrade to stale caches and Redis-buffered audits
+	// instead of cascading errors; background probes close them on reconnection
+	breakerCooldown := time.Duration(cfg.BreakerCooldown) * time.Second
+	dbBreaker := breaker.New("postgres", cfg.BreakerThreshold, breakerCooldown)
+	dbBreaker.StartProbe(breakerCooldown, db.PingContext)
+	defer dbBreaker.Stop()
+	redisBreaker := breaker.New("redis", cfg.BreakerThreshold, breakerCooldown)
+	rdb.AddHook(tracing.NewRedisHook())
+	rdb.AddHook(breaker.NewRedisHook(redisBreaker))
+	redisBreaker.StartProbe(breakerCooldown, func(ctx context.Context) error { return rdb.Ping(ctx).Err() })
+	defer redisBreaker.Stop()
+
+	// Chaos mode: injected faults flow through the breakers like real outages
+	var chaosInjector *chaos.Injector
+	if cfg.ChaosEnabled {
+		chaosCfg := chaos.Config{
+			Latency:           time.Duration(cfg.ChaosLatencyMs) * time.Millisecond,
+			LatencyRate:       cfg.ChaosLatencyRate,
+			PostgresErrorRate: cfg.ChaosDBErrorRate,
+			RedisErrorRate:    cfg.ChaosRedisErrRate,
+			ModelTimeoutRate:  cfg.ChaosModelErrRate,
+		}
+		if err := chaosCfg.Validate(); err != nil {
+			fatal("Invalid chaos configuration", "error", err)
+		}
+		chaosInjector = chaos.NewInjector(chaosCfg)
+		dbBreaker.SetFaultInjector(chaosInjector.PostgresFault)
+		rdb.AddHook(chaosInjector.RedisHook())
+		slog.Warn("CHAOS MODE ENABLED — never run this in production",
+			"latency_ms", cfg.ChaosLatencyMs, "latency_rate", cfg.ChaosLatencyRate, "postgres_error_rate", cfg.ChaosDBErrorRate,
+			"redis_error_rate", cfg.ChaosRedisErrRate, "model_timeout_rate", cfg.ChaosModelErrRate)
 	}
-	log.Printf("✓ Connected to Redis (Pool: %d, MinIdle: %d)", cfg.RedisPoolSize, cfg.RedisMinIdle)
 
 	// 4. Initialize dependencies (Dependency Injection)
 	policyRepo := policy.NewRepository(db)
+	policyRepo.SetMaxEnabled(cfg.PolicyLimit)
 	policyCache := cache.NewPolicyCache(policyRepo)
+	policyCache.SetBreaker(dbBreaker)
+	// Policy changes on any replica refresh every replica's cache through Redis pub/sub
+	policyInvalidator := cache.NewInvalidator(rdb)
+	policyInvalidator.Register("", policyCache)
+	if cfg.PolicySnapshot != "" {
+		if snapshot, err := policyCache.LoadSnapshot(cfg.PolicySnapshot); err != nil {
+			slog.Warn("No usable warm-start policy snapshot", "error", err)
+		} else {
+			slog.Info("Warm-start policy snapshot loaded",
+				"policies", len(snapshot.Policies), "hash", snapshot.Hash, "loaded_at", snapshot.LoadedAt.Format(time.RFC3339))
+		}
+	}
 	if err := policyCache.Start(ctx); err != nil {
-		log.Fatalf("Failed to start policy cache: %v", err)
+		fatal("Failed to start policy cache", "error", err)
 	}
 	defer policyCache.Stop()
+	if cfg.PolicySnapshot != "" {
+		// Deferred early so it runs last, once the servers have stopped
+		defer func() {
+			if err := policyCache.SaveSnapshot(cfg.PolicySnapshot); err != nil {
+				slog.Warn("Failed to save policy snapshot", "error", err)
+				return
+			}
+			slog.Info("Policy snapshot saved", "path", cfg.PolicySnapshot)
+		}()
+	}
+	// Stores loaded from Postgres start empty while warm-starting and catch up later
+	warmStart := policyCache.Snapshot().WarmStart
+
+	// Isolated tenants keep their policies and audit logs in their own schema or database
+	var tenantRouter *tenant.Router
+	if cfg.TenantSchemas != "" || cfg.TenantDatabases != "" {
+		schemas, err := tenant.ParseSpec(cfg.TenantSchemas)
+		if err != nil {
+			fatal("Invalid TENANT_SCHEMAS", "error", err)
+		}
+		databases, err := tenant.ParseSpec(cfg.TenantDatabases)
+		if err != nil {
+			fatal("Invalid TENANT_DATABASES", "error", err)
+		}
+		tenantRouter, err = tenant.Open(cfg.DatabaseURL, schemas, databases, tenant.Pool{MaxOpen: cfg.DBMaxOpenConns, MaxIdle: cfg.DBMaxIdleConns})
+		if err != nil {
+			fatal("Failed to open tenant storage", "error", err)
+		}
+		defer tenantRouter.Close()
+		tenantRouter.SetMaxEnabledPolicies(cfg.PolicyLimit)
+		tenantRouter.SetInvalidator(policyInvalidator)
+		if err := tenantRouter.Start(ctx, dbBreaker); err != nil {
+			fatal("Failed to start tenant policy caches", "error", err)
+		}
+		defer tenantRouter.Stop()
+	}
+	policyInvalidator.Start(ctx)
+	defer policyInvalidator.Stop()
+
+	clientRegistry := clients.NewRegistry(clients.NewRepository(db), 5*time.Minute)
+	clientRegistry.SetBreaker(dbBreaker)
+	if err := clientRegistry.Start(ctx); err != nil {
+		if !warmStart {
+			fatal("Failed to start client registry", "error", err)
+		}
+		slog.Warn("Client registry unavailable, clients are anonymous until the database answers", "error", err)
+	}
+	defer clientRegistry.Stop()
+
+	wordlistStore := wordlist.NewStore(wordlist.NewRepository(db), 5*time.Minute)
+	wordlistStore.SetBreaker(dbBreaker)
+	if err := wordlistStore.Start(ctx); err != nil {
+		if !warmStart {
+			fatal("Failed to start wordlist store", "error", err)
+		}
+		slog.Warn("Wordlists unavailable, dictionary policies error until the database answers", "error", err)
+	}
+	defer wordlistStore.Stop()
+
+	// Feature flags: built-in defaults < FEATURE_FLAGS < FEATURE_FLAGS_FILE < Redis hash
+	flagStore := flags.NewStore(rdb, 30*time.Second)
+	if err := flagStore.LoadEnv(cfg.FeatureFlags); err != nil {
+		fatal("Invalid FEATURE_FLAGS", "error", err)
+	}
+	if cfg.FeatureFlagsFile != "" {
+		if err := flagStore.LoadFile(cfg.FeatureFlagsFile); err != nil {
+			fatal("Failed to load feature flags", "error", err)
+		}
+	}
+	flagStore.Start(ctx)
+	defer flagStore.Stop()
 
 	nemoClient := analyzer.NewNemoClient(cfg.NemoAPIKey, cfg.NemoEndpoint, nil)
-	analyzerSvc := analyzer.NewAnalyzer(nemoClient)
+	analyzerSvc := analyzer.NewAnalyzer(chaosInjector.WrapModel(nemoClient))
+	analyzerSvc.SetPatternSource(policyCache)
+	analyzerSvc.SetDictionaries(wordlistStore)
+	analyzerSvc.SetEvasionNormalization(cfg.NormalizeEvasions)
+	analyzerSvc.SetFlags(flagStore)
+	analyzerSvc.SetMatchMode(cfg.MatchMode)
+	analyzerSvc.SetEvaluationTimeout(time.Duration(cfg.PolicyEvalTimeout) * time.Millisecond)
+	policyStats := analyzer.NewPolicyStats(time.Duration(cfg.SlowPolicyMs) * time.Millisecond)
+	analyzerSvc.SetPolicyStats(policyStats)
+	// Model provider outages degrade evaluation to pattern-only instead of failing it;
+	// trial calls after the cooldown restore model policies once the provider is back
+	var modelBreaker *breaker.Breaker
+	if cfg.ModelDegradation {
+		modelBreaker = breaker.New("model", cfg.BreakerThreshold, breakerCooldown)
+		analyzerSvc.SetModelBreaker(modelBreaker)
+	}
+	var tok *tokenizer.Tokenizer
+	if cfg.TokenizerVocab != "" {
+		tok, err = tokenizer.Load(cfg.TokenizerVocab)
+		if err != nil {
+			fatal("Failed to load tokenizer vocabulary", "error", err)
+		}
+	}
+	analyzerSvc.SetTokenizer(tok, cfg.ModelMaxTokens)
+	if cfg.ModelPrivacyFile != "" {
+		privacy, err := analyzer.LoadModelPrivacy(cfg.ModelPrivacyFile)
+		if err != nil {
+			fatal("Failed to load model privacy rules", "error", err)
+		}
+		analyzerSvc.SetModelPrivacy(analyzer.NemoProvider, privacy)
+		slog.Info("Model privacy rules loaded", "path", cfg.ModelPrivacyFile)
+	}
+	if cfg.ModelRoutesFile != "" {
+		routes, err := analyzer.LoadModelRoutes(cfg.ModelRoutesFile)
+		if err != nil {
+			fatal("Failed to load model routes", "error", err)
+		}
+		analyzerSvc.SetModelRoutes(routes)
+		slog.Info("Model routes loaded", "path", cfg.ModelRoutesFile, "models", len(routes.Models))
+	}
+	slog.Info("Token counting configured", "tokenizer", tok.Name(), "model_max_tokens", cfg.ModelMaxTokens)
 
 	// Register Prometheus metrics once during startup
 	metrics.Register()
+	metrics.RegisterDBStats(db)
+	metrics.RegisterRedisPoolStats(rdb)
+
+	// Initialize alert notification sinks (log always, webhook when configured)
+	sinks := []notify.Sink{notify.LogSink{}}
+	if cfg.AlertWebhookURL != "" {
+		sinks = append(sinks, notify.NewWebhookSink(cfg.AlertWebhookURL, nil))
+	}
+	notifier := notify.NewNotifier(1000, sinks...)
+	defer notifier.Close()
 
 	// Initialize Redis audit sync worker (Redis → Postgres for audit logs)
 	syncInterval := time.Duration(cfg.RedisSyncInterval) * time.Second
 	redisCache := cache.NewRedisCache(db, rdb, syncInterval)
+	redisCache.SetBreaker(dbBreaker)
+	if tenantRouter != nil {
+		redisCache.SetTenantRouting(tenantRouter.DB)
+	}
+	redisCache.SetBacklogPolicy(int64(cfg.AuditBacklogWarn), int64(cfg.AuditBacklogCrit), func(level string, queueSize int64) {
+		severity, summary := "low", fmt.Sprintf("audit backlog recovered: %d logs queued in Redis", queueSize)
+		switch level {
+		case "elevated":
+			severity, summary = "medium", fmt.Sprintf("audit backlog elevated: %d logs queued in Redis", queueSize)
+		case "critical":
+			severity, summary = "high", fmt.Sprintf("audit backlog critical: %d logs queued in Redis", queueSize)
+		}
+		notifier.Notify(notify.Event{
+			Type:     "audit.backlog",
+			Severity: severity,
+			Summary:  summary,
+			Details: map[string]interface{}{
+				"level":       level,
+				"queue_size":  queueSize,
+				"instance_id": cfg.InstanceID,
+			},
+		})
+	})
+	// Regions that don't own audit persistence ship their batches to the owner region
+	if cfg.ForwardsAudit() {
+		switch cfg.AuditForwardMode {
+		case audit.ForwardRedis:
+			ownerOpt, err := redis.ParseURL(cfg.AuditForwardURL)
+			if err != nil {
+				fatal("Invalid AUDIT_FORWARD_URL", "error", err)
+			}
+			ownerRDB := redis.NewClient(ownerOpt)
+			defer ownerRDB.Close()
+			redisCache.SetForwarder(audit.NewRedisForwarder(ownerRDB))
+		default:
+			redisCache.SetForwarder(audit.NewHTTPForwarder(cfg.AuditForwardURL, cfg.AuditForwardToken, nil))
+		}
+		slog.Info("Audit persistence forwarded to the owner region", "owner_region", cfg.AuditOwnerRegion, "region", cfg.Region, "mode", cfg.AuditForwardMode)
+	}
 	if err := redisCache.Start(ctx); err != nil {
-		log.Fatalf("Failed to start Redis audit sync: %v", err)
+		fatal("Failed to start Redis audit sync", "error", err)
 	}
 	defer redisCache.Stop() // Ensure graceful shutdown and final sync
 
 	// Initialize async audit logger - writes to Redis, synced by Redis audit worker
 	auditConfig := audit.Config{
-		BufferSize: cfg.AuditBufferSize,
-		Workers:    cfg.AuditWorkers,
+		BufferSize:   cfg.AuditBufferSize,
+		Workers:      cfg.AuditWorkers,
+		BlockTimeout: time.Duration(cfg.AuditBlockMs) * time.Millisecond,
 	}
 	auditLogger := audit.NewLoggerWithConfig(db, rdb, auditConfig)
+	auditLogger.SetBreaker(dbBreaker)
+	if tenantRouter != nil {
+		auditLogger.SetTenantRouting(tenantRouter.DB)
+	}
+	if cfg.ForwardsAudit() {
+		auditLogger.DisableDatabaseFallback()
+	}
+	if cfg.AuditWALDir != "" {
+		auditWAL, err := audit.OpenWAL(cfg.AuditWALDir, int64(cfg.AuditWALMaxMB)<<20)
+		if err != nil {
+			fatal("Failed to open audit WAL", "error", err)
+		}
+		auditLogger.SetWAL(auditWAL)
+	}
 	defer auditLogger.Close() // Ensure graceful shutdown
 
-	log.Printf("✓ Services initialized (Policy cache: in-memory+Postgres refresh, Audit: %d workers→Redis, %d buffer, Redis→Postgres sync: %v)", cfg.AuditWorkers, cfg.AuditBufferSize, syncInterval)
+	slog.Info("Services initialized", "audit_workers", cfg.AuditWorkers, "audit_buffer", cfg.AuditBufferSize,
+		"audit_block_timeout", auditConfig.BlockTimeout, "redis_sync_interval", syncInterval)
 
 	// 5. Create HTTP handler with dependencies
-	handler := api.NewHandler(policyRepo, policyCache, analyzerSvc, auditLogger)
+	auditRepo := audit.NewRepository(db)
+	incidentRepo := incident.NewRepository(db)
+	overrideStore := session.NewOverrideStore(rdb)
+	handler := api.NewHandler(policyRepo, policyCache, analyzerSvc, auditLogger, auditRepo, incidentRepo, clientRegistry, overrideStore)
+	handler.SetWordlists(wordlistStore)
+	handler.SetFlags(flagStore)
+	handler.SetChaos(chaosInjector)
+	handler.SetPolicyStats(policyStats)
+	handler.SetLinter(policylint.New(analyzerSvc.Offline(), cfg.LintBroadPercent))
+	benignRepo := benign.NewRepository(db)
+	contentMaster, previousMasters, err := contentMasters(cfg)
+	if err != nil {
+		fatal("Invalid content master key", "error", err)
+	}
+	if contentMaster != nil {
+		benignRepo.SetKeyring(envelope.NewKeyring(envelope.NewRepository(db), contentMaster, previousMasters...), "")
+		if tenantRouter != nil {
+			tenantRouter.SetContentKeys(contentMaster, previousMasters...)
+		}
+		slog.Info("Retained content encryption enabled", "master_key_id", contentMaster.ID())
+	}
+	handler.SetBenignCorpus(benignRepo)
+	handler.SetChangeGuard(policy.NewChangeGuard(cfg.PolicyWritesRate, cfg.PolicyChangeLimit))
+	handler.SetTokenizer(tok)
+	if cfg.DecisionCacheTTL > 0 {
+		decisions := decisioncache.New(time.Duration(cfg.DecisionCacheTTL)*time.Second, cfg.DecisionCacheSize)
+		handler.SetDecisionCache(decisions)
+		decisionPurges := decisioncache.NewBroadcaster(rdb, decisions)
+		decisionPurges.Start(ctx)
+		defer decisionPurges.Stop()
+		handler.SetDecisionBroadcaster(decisionPurges)
+		slog.Info("Decision cache enabled", "ttl_seconds", cfg.DecisionCacheTTL, "size", cfg.DecisionCacheSize)
+	}
+	hostname, _ := os.Hostname()
+	origin := models.GatewayOrigin{
+		InstanceID:     cfg.InstanceID,
+		Hostname:       hostname,
+		Region:         cfg.Region,
+		Zone:           cfg.Zone,
+		GatewayVersion: api.Version,
+	}
+	if origin.InstanceID == "" {
+		origin.InstanceID = hostname
+	}
+	handler.SetOrigin(origin)
+	handler.SetTenants(tenantRouter)
+	slog.Info("Audit origin", "instance_id", origin.InstanceID, "region", origin.Region, "zone", origin.Zone, "version", origin.GatewayVersion)
+
+	if cfg.AnalyzeSlots > 0 {
+		batchSlots := cfg.BatchSlots
+		if batchSlots <= 0 {
+			batchSlots = max(cfg.AnalyzeSlots/2, 1)
+		}
+		handler.SetScheduler(scheduler.New(cfg.AnalyzeSlots, batchSlots, cfg.BatchQueueLimit))
+		slog.Info("Priority scheduling enabled", "slots", cfg.AnalyzeSlots, "batch_slots", batchSlots, "batch_queue", cfg.BatchQueueLimit)
+	}
+	handler.SetBatchAnalyze(cfg.BatchWorkers, cfg.BatchMaxItems)
+	handler.SetRequestLimits(cfg.MaxPolicies, cfg.MaxMatches, cfg.MaxRedactions)
+	if cfg.UserMessagesFile != "" {
+		catalog, err := usermessage.Load(cfg.UserMessagesFile)
+		if err != nil {
+			fatal("Failed to load user message catalog", "error", err)
+		}
+		handler.SetUserMessages(catalog)
+		slog.Info("User messages loaded", "path", cfg.UserMessagesFile)
+	}
+	handler.SetDBPool(dbpool.New(db, time.Duration(cfg.DBPoolWaitMs)*time.Millisecond))
+
+	// Policy hit counts are batched to Postgres so dead rules can be found
+	hitRecorder := policy.NewHitRecorder(db, time.Minute)
+	hitRecorder.SetBreaker(dbBreaker)
+	hitRecorder.Start(ctx)
+	defer hitRecorder.Stop()
+	handler.AddObserver(hitRecorder)
+	handler.SetHitRecorder(hitRecorder)
+
+	// Remind owners about policies past their review date
+	if cfg.PolicyReviewHours > 0 {
+		reviewReminder := policy.NewReviewReminder(policyCache.Get, notifier, time.Duration(cfg.PolicyReviewHours)*time.Hour)
+		reviewReminder.Start(ctx)
+		defer reviewReminder.Stop()
+	}
+
+	// Track the most frequently blocked prompts to spot payloads reused across clients
+	fingerprints := fingerprint.NewTracker(db, time.Minute, time.Duration(cfg.FingerprintDays)*24*time.Hour)
+	fingerprints.SetBreaker(dbBreaker)
+	fingerprints.Start(ctx)
+	defer fingerprints.Stop()
+	handler.AddObserver(fingerprints)
+	handler.SetFingerprints(fingerprints)
+
+	// Hourly/daily audit roll-ups keep /v1/stats queries off the raw audit_logs table
+	if cfg.RollupInterval > 0 {
+		rollups := audit.NewRollups(db, time.Duration(cfg.RollupInterval)*time.Second, time.Duration(cfg.RollupLookback)*time.Hour)
+		rollups.SetBreaker(dbBreaker)
+		rollups.Start(ctx)
+		defer rollups.Stop()
+		handler.SetRollups(rollups)
+	}
+
+	// Weekly/monthly per-tenant usage reports, built on the roll-ups
+	if cfg.ReportPeriods != "" {
+		periods, err := usagereport.ParsePeriods(cfg.ReportPeriods)
+		if err != nil {
+			fatal("Invalid USAGE_REPORTS", "error", err)
+		}
+		reports := usagereport.NewWorker(db, usageReportSink(cfg), periods, cfg.ReportFormat, time.Hour)
+		reports.SetBreaker(dbBreaker)
+		reports.Start(ctx)
+		defer reports.Stop()
+	}
+
+	if cfg.AnalyzeSLOs != "off" {
+		sloTargets, err := slo.Parse(cfg.AnalyzeSLOs)
+		if err != nil {
+			fatal("Invalid ANALYZE_LATENCY_SLOS", "error", err)
+		}
+		handler.SetSLO(slo.NewTracker(sloTargets))
+		slog.Info("Analyze latency SLOs configured", "slos", cfg.AnalyzeSLOs)
+	}
+	handler.SetReplayGuard(replay.NewGuard(rdb, time.Duration(cfg.ReplayWindow)*time.Second))
+
+	// Known-benign prompts are allowed from an in-memory copy of the Redis allowlist
+	promptAllowlist := allowlist.NewStore(rdb, time.Duration(cfg.AllowlistRefresh)*time.Second)
+	promptAllowlist.Start(ctx)
+	defer promptAllowlist.Stop()
+	handler.SetAllowlist(promptAllowlist)
+
+	hasher, err := audit.NewHasher(cfg.AuditHashAlgo, cfg.AuditHashCanon)
+	if err != nil {
+		fatal("Invalid AUDIT_HASH_ALGORITHM", "error", err)
+	}
+	handler.SetHasher(hasher)
+	slog.Info("Content hashes configured", "algorithm", hasher.Algorithm(), "canonicalize", cfg.AuditHashCanon)
+	if cfg.AuditIngestToken != "" {
+		handler.SetAuditIngest(auditLogger, cfg.AuditIngestToken)
+	}
+
+	// Maintenance mode drains the audit pipeline end to end: logger buffer → Redis → Postgres
+	maintenanceCtl := maintenance.NewController(time.Duration(cfg.DrainTimeout) * time.Second)
+	maintenanceCtl.AddDrainer("audit_buffer", auditLogger)
+	maintenanceCtl.AddDrainer("audit_queue", redisCache)
+	handler.SetMaintenance(maintenanceCtl)
+
+	// Replicas publish their version, policy snapshot and health for GET /v1/cluster
+	startedAt := time.Now()
+	clusterRegistry := cluster.NewRegistry(rdb, time.Duration(cfg.ClusterHeartbeat)*time.Second, func() models.ClusterMember {
+		snapshot := policyCache.Snapshot()
+		member := models.ClusterMember{
+			GatewayOrigin:    origin,
+			Status:           "healthy",
+			StartedAt:        startedAt,
+			PolicyHash:       snapshot.Hash,
+			PolicyCount:      len(snapshot.Policies),
+			PoliciesLoadedAt: snapshot.LoadedAt,
+			Breakers:         make(map[string]string),
+		}
+		for _, b := range []*breaker.Breaker{dbBreaker, redisBreaker, modelBreaker} {
+			if b == nil {
+				continue
+			}
+			member.Breakers[b.Name()] = b.State().String()
+			if b.State() != breaker.Closed {
+				member.Status = "degraded"
+			}
+		}
+		if maintenanceCtl.Enabled() {
+			member.Status = "maintenance"
+		}
+		return member
+	})
+	clusterRegistry.Start(ctx)
+	defer clusterRegistry.Stop()
+	handler.SetCluster(clusterRegistry)
+
+	handler.SetLogLevel(logController)
+
+	if cfg.EnforcementMode == "monitor" {
+		handler.SetMonitorMode(true)
+		slog.Warn("Enforcement mode: monitor (decisions are logged but never enforced)")
+	}
+
+	if cfg.DecisionTokenKey != "" {
+		key, err := base64.StdEncoding.DecodeString(cfg.DecisionTokenKey)
+		if err != nil {
+			fatal("Invalid DECISION_TOKEN_KEY", "error", err)
+		}
+		signer, err := decisiontoken.NewSigner(cfg.DecisionTokenAlg, key, time.Duration(cfg.DecisionTokenTTL)*time.Second)
+		if err != nil {
+			fatal("Failed to initialize decision token signer", "error", err)
+		}
+		handler.SetSigner(signer)
+		slog.Info("Signed decision tokens enabled", "algorithm", cfg.DecisionTokenAlg, "ttl_seconds", cfg.DecisionTokenTTL)
+	}
+
+	// Open incidents for critical matches and triggered alert rules
+	incidentRecorder := incident.NewRecorder(incidentRepo)
+	defer incidentRecorder.Stop()
+	handler.AddObserver(incidentRecorder)
+
+	// Policies declaring a webhook_url notify their owners when they fire, at most
+	// once per notify_cooldown_seconds per client or session (counted in Redis)
+	policyWebhooks := notify.NewPolicyWebhooks(func(id uuid.UUID) (models.Policy, bool) {
+		if p, ok := policyCache.Policy(id); ok {
+			return p, true
+		}
+		return tenantRouter.Policy(id)
+	}, 1000, nil)
+	policyWebhooks.SetCooldowns(notify.NewRedisCooldowns(rdb))
+	defer policyWebhooks.Close()
+	handler.AddObserver(policyWebhooks)
+
+	if cfg.AlertRulesFile != "" {
+		rules, err := alerting.LoadRules(cfg.AlertRulesFile)
+		if err != nil {
+			fatal("Failed to load alert rules", "error", err)
+		}
+		ruleEngine := alerting.NewEngine(rules, notifier)
+		ruleEngine.SetRecorder(incidentRecorder)
+		ruleEngine.SetSessionPinner(overrideStore)
+		ruleEngine.Start()
+		defer ruleEngine.Stop()
+		handler.AddObserver(ruleEngine)
+	}
+
+	if cfg.AnomalyEnabled {
+		anomalyConfig := anomaly.DefaultConfig()
+		anomalyConfig.Window = time.Duration(cfg.AnomalyWindow) * time.Second
+		anomalyConfig.ZScore = cfg.AnomalyZScore
+		detector := anomaly.NewDetector(anomalyConfig, notifier)
+		detector.Start()
+		defer detector.Stop()
+		handler.AddObserver(detector)
+	}
+
+	// Mirror content-free decisions to a data pipeline, independently of audit persistence
+	if cfg.FirehoseURL != "" || cfg.FirehoseStream != "" {
+		var sink firehose.Sink
+		if cfg.FirehoseURL != "" {
+			sink = firehose.NewHTTPSink(cfg.FirehoseURL, cfg.FirehoseToken, nil)
+		} else {
+			sink = firehose.NewRedisStreamSink(rdb, cfg.FirehoseStream, int64(cfg.FirehoseMaxLen))
+		}
+		mirror := firehose.NewMirror(firehose.Config{
+			BufferSize:    cfg.FirehoseBuffer,
+			BatchSize:     cfg.FirehoseBatchSize,
+			FlushInterval: time.Duration(cfg.FirehoseFlushMs) * time.Millisecond,
+			MaxRetries:    cfg.FirehoseRetries,
+		}, sink)
+		mirror.Start()
+		defer mirror.Stop()
+		handler.AddObserver(mirror)
+	}
+
+	// Validate a new deployment against a sample of live traffic before cutover
+	if cfg.ShadowURL != "" {
+		shadowMirror := shadow.NewMirror(shadow.Config{
+			URL:        cfg.ShadowURL,
+			Token:      cfg.ShadowToken,
+			SampleRate: cfg.ShadowSampleRate,
+			BufferSize: cfg.ShadowBuffer,
+			Workers:    cfg.ShadowWorkers,
+			Timeout:    time.Duration(cfg.ShadowTimeoutMs) * time.Millisecond,
+			KeepDiffs:  100,
+		}, nil)
+		shadowMirror.Start()
+		defer shadowMirror.Stop()
+		handler.AddObserver(shadowMirror)
+		handler.SetShadow(shadowMirror)
+	}
 
 	// 6. Set up routes with request timeout
 	requestTimeout := time.Duration(cfg.RequestTimeout) * time.Second
-	mux := api.SetupRoutes(handler, requestTimeout)
-	log.Printf("✓ Routes configured (timeout: %v)", requestTimeout)
+	mux := api.SetupRoutes(handler, requestTimeout, cfg.AdminAPIKey)
+	slog.Info("Routes configured", "timeout", requestTimeout)
+
+	// Optional LLM proxy mode; streams are long-lived so it bypasses the request timeout
+	if cfg.ProxyEnabled {
+		var upstreams []proxy.Upstream
+		for name, rawURL := range map[string]string{
+			"openai":    cfg.OpenAIUpstream,
+			"anthropic": cfg.AnthropicUpstream,
+			"gemini":    cfg.GeminiUpstream,
+		} {
+			baseURL, err := url.Parse(rawURL)
+			if err != nil {
+				fatal("Invalid upstream URL", "provider", name, "error", err)
+			}
+			provider, _ := providers.Lookup(name)
+			upstreams = append(upstreams, proxy.Upstream{Provider: provider, BaseURL: baseURL})
+		}
+		mux.Handle("/proxy/{provider}/{path...}", proxy.NewProxy(handler, upstreams, nil))
+		slog.Info("LLM proxy enabled", "providers", "openai,anthropic,gemini")
+	}
 
 	// 7. Create HTTP server
 	server := &http.Server{
@@ -127,6 +719,57 @@ func main() {
 		IdleTimeout:  60 * time.Second,
 	}
 
+	// Optional Envoy ext_authz gRPC listener sharing the same evaluation core
+	var extAuthzServer *grpc.Server
+	if cfg.ExtAuthzPort != "" {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.ExtAuthzPort))
+		if err != nil {
+			fatal("Failed to listen for ext_authz", "error", err)
+		}
+		extAuthzServer = grpc.NewServer()
+		extauthz.NewServer(handler, extauthz.Config{FailOpen: cfg.ExtAuthzFailOpen, TrustClientHeader: cfg.ExtAuthzTrustHdr}).Register(extAuthzServer)
+		go func() {
+			slog.Info("Envoy ext_authz gRPC server listening", "port", cfg.ExtAuthzPort, "fail_open", cfg.ExtAuthzFailOpen)
+			if err := extAuthzServer.Serve(lis); err != nil {
+				slog.Warn("ext_authz server stopped", "error", err)
+			}
+		}()
+	}
+
+	// Optional gRPC listener streaming cumulative verdicts to agent frameworks
+	var streamServer *grpc.Server
+	if cfg.StreamGRPCPort != "" {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.StreamGRPCPort))
+		if err != nil {
+			fatal("Failed to listen for StreamAnalyze", "error", err)
+		}
+		streamServer = grpc.NewServer()
+		guardstream.NewServer(handler).Register(streamServer)
+		go func() {
+			slog.Info("StreamAnalyze gRPC server listening", "port", cfg.StreamGRPCPort)
+			if err := streamServer.Serve(lis); err != nil {
+				slog.Warn("StreamAnalyze server stopped", "error", err)
+			}
+		}()
+	}
+
+	// Optional gRPC listener serving the analyze and policy APIs through the same handlers
+	var apiServer *grpc.Server
+	if cfg.GRPCPort != "" {
+		lis, err := net.Listen("tcp", fmt.Sprintf(":%s", cfg.GRPCPort))
+		if err != nil {
+			fatal("Failed to listen for gRPC", "error", err)
+		}
+		apiServer = grpc.NewServer()
+		grpcapi.NewServer(mux).Register(apiServer)
+		go func() {
+			slog.Info("AnalyzeService/PolicyService gRPC server listening", "port", cfg.GRPCPort)
+			if err := apiServer.Serve(lis); err != nil {
+				slog.Warn("gRPC server stopped", "error", err)
+			}
+		}()
+	}
+
 	// 8. Set up graceful shutdown
 	// Create channel to listen for OS interrupt signals
 	quit := make(chan os.Signal, 1)
@@ -134,21 +777,33 @@ func main() {
 
 	// Start server in a goroutine so it doesn't block
 	go func() {
-		log.Printf("✓ Server listening on port %s", cfg.Port)
-		log.Println("📡 Endpoints:")
-		log.Println("   POST http://localhost:" + cfg.Port + "/v1/analyze")
-		log.Println("   GET  http://localhost:" + cfg.Port + "/v1/policies")
-		log.Println("   POST http://localhost:" + cfg.Port + "/v1/policies")
-		log.Println("   GET  http://localhost:" + cfg.Port + "/v1/health")
+		endpoints := []string{
+			"POST /v1/analyze",
+			"GET  /v1/policies",
+			"POST /v1/policies",
+			"GET  /v1/sessions/{session_id}",
+			"PUT  /v1/sessions/{session_id}/override",
+			"DEL  /v1/audit",
+			"GET  /v1/incidents",
+			"POST /v1/verify",
+			"PUT  /v1/maintenance",
+			"PUT  /v1/loglevel",
+			"GET  /v1/version",
+			"GET  /v1/health",
+		}
+		if cfg.ProxyEnabled {
+			endpoints = append(endpoints, "ANY  /proxy/{openai|anthropic|gemini}/...")
+		}
+		slog.Info("Server listening", "port", cfg.Port, "endpoints", endpoints)
 
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-			log.Fatalf("Server failed to start: %v", err)
+			fatal("Server failed to start", "error", err)
 		}
 	}()
 
 	// Block until we receive a shutdown signal
 	<-quit
-	log.Println("\n🛑 Shutting down server gracefully...")
+	slog.Info("Shutting down server gracefully")
 
 	// Create a context with timeout for shutdown
 	shutdownCtx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -156,9 +811,59 @@ func main() {
 
 	// Shutdown the HTTP server gracefully
 	if err := server.Shutdown(shutdownCtx); err != nil {
-		log.Printf("Server forced to shutdown: %v", err)
+		slog.Warn("Server forced to shutdown", "error", err)
+	}
+	if extAuthzServer != nil {
+		extAuthzServer.GracefulStop()
 	}
+	if apiServer != nil {
+		apiServer.GracefulStop()
+	}
+	if streamServer != nil {
+		// Streams can stay open indefinitely; cut them off once the shutdown timeout passes
+		stopped := make(chan struct{})
+		go func() {
+			streamServer.GracefulStop()
+			close(stopped)
+		}()
+		select {
+		case <-stopped:
+		case <-shutdownCtx.Done():
+			streamServer.Stop()
+		}
+	}
+
+	slog.Info("Server stopped; background workers finish on deferred cleanup")
+}
+
+// usageReportSink creates the delivery sink selected by USAGE_REPORT_SINK
+func usageReportSink(cfg *config.Config) usagereport.Sink {
+	switch cfg.ReportSink {
+	case "s3":
+		return usagereport.NewS3Sink(usagereport.S3Config{
+			Endpoint:     cfg.ReportS3Endpoint,
+			Bucket:       cfg.ReportS3Bucket,
+			Prefix:       cfg.ReportS3Prefix,
+			Region:       cfg.ReportS3Region,
+			AccessKey:    cfg.AWSAccessKeyID,
+			SecretKey:    cfg.AWSSecretKey,
+			SessionToken: cfg.AWSSessionToken,
+		}, nil)
+	case "email":
+		return usagereport.NewEmailSink(usagereport.EmailConfig{
+			Addr:     cfg.ReportSMTPAddr,
+			Username: cfg.ReportSMTPUser,
+			Password: cfg.ReportSMTPPass,
+			From:     cfg.ReportEmailFrom,
+			To:       strings.Split(cfg.ReportEmailTo, ","),
+		})
+	default:
+		return usagereport.NewWebhookSink(cfg.ReportWebhookURL, cfg.ReportToken, nil)
+	}
+}
 
-	log.Println("✓ Server stopped")
-	log.Println("✓ All background workers will finish on defer cleanup")
+// fatal logs msg at error level and exits, like log.Fatal; deferred cleanup is skipped
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
 }
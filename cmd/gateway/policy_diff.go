@@ -0,0 +1,167 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/policydiff"
+	"github.com/prompt-gateway/internal/wordlist"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// runPolicyDiff implements `gateway policy-diff`: it evaluates a prompt corpus
+// against two policy bundles and reports which decisions would change, returning
+// the process exit code (1 with -fail-on-change when any decision changes)
+func runPolicyDiff(args []string) int {
+	flags := flag.NewFlagSet("policy-diff", flag.ContinueOnError)
+	beforeFile := flags.String("before", "", "policy bundle currently deployed (JSON array, e.g. GET /v1/policies)")
+	afterFile := flags.String("after", "", "policy bundle to compare against")
+	corpusFile := flags.String("corpus", "", "prompt corpus: one prompt or JSON analyze request per line")
+	benignFile := flags.String("benign", "", "benign prompts, one per line, to estimate false positives of added and modified policies on (default: the bundled corpus)")
+	databaseURL := flags.String("database-url", os.Getenv("DATABASE_URL"), "Postgres to load wordlists from for dictionary policies (optional)")
+	normalize := flags.Bool("evasion-normalization", true, "also match de-obfuscated content, as EVASION_NORMALIZATION does")
+	asJSON := flags.Bool("json", false, "print the full report as JSON")
+	failOnChange := flags.Bool("fail-on-change", false, "exit 1 when any decision changes")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if *beforeFile == "" || *afterFile == "" || *corpusFile == "" {
+		fmt.Fprintln(os.Stderr, "policy-diff: -before, -after and -corpus are required")
+		return 2
+	}
+
+	before, err := loadBundle(*beforeFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy-diff: %v\n", err)
+		return 2
+	}
+	after, err := loadBundle(*afterFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy-diff: %v\n", err)
+		return 2
+	}
+	f, err := os.Open(*corpusFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy-diff: %v\n", err)
+		return 2
+	}
+	corpus, err := policydiff.ParseCorpus(f)
+	f.Close()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy-diff: %s: %v\n", *corpusFile, err)
+		return 2
+	}
+
+	benignCorpus := benign.Bundled()
+	if *benignFile != "" {
+		data, err := os.ReadFile(*benignFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "policy-diff: %v\n", err)
+			return 2
+		}
+		prompts, err := benign.Normalize(strings.Split(string(data), "\n"))
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "policy-diff: %s: %v\n", *benignFile, err)
+			return 2
+		}
+		benignCorpus = benign.Corpus{Source: benign.SourceUploaded, Prompts: prompts}
+	}
+
+	a := analyzer.NewAnalyzer(nil)
+	a.SetEvasionNormalization(*normalize)
+	if *databaseURL != "" {
+		if store, err := loadWordlists(*databaseURL); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Wordlists not loaded, dictionary policies will report errors: %v\n", err)
+		} else {
+			a.SetDictionaries(store)
+		}
+	}
+
+	differ := policydiff.New(a, nil)
+	differ.SetBenignCorpus(benignCorpus)
+	report, err := differ.Run(context.Background(), before, after, corpus)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "policy-diff: %v\n", err)
+		return 2
+	}
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	} else {
+		printPolicyDiff(report)
+	}
+	if *failOnChange && report.Changed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// loadBundle reads a JSON array of policies
+func loadBundle(path string) ([]models.Policy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var bundle models.PolicyBundle
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bundle, nil
+}
+
+// loadWordlists reads the managed wordlists once
+func loadWordlists(databaseURL string) (*wordlist.Store, error) {
+	db, err := sql.Open("postgres", databaseURL)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	store := wordlist.NewStore(wordlist.NewRepository(db), time.Hour)
+	if err := store.Refresh(ctx); err != nil {
+		return nil, err
+	}
+	return store, nil
+}
+
+// printPolicyDiff prints the per-policy summary and decision transitions
+func printPolicyDiff(report *models.PolicyDiffReport) {
+	fmt.Printf("📊 %d prompt(s) evaluated, %d decision(s) change\n", report.Evaluated, report.Changed)
+	for _, transition := range slices.Sorted(maps.Keys(report.Transitions)) {
+		fmt.Printf("  %-16s %d\n", transition, report.Transitions[transition])
+	}
+	if len(report.SkippedModelPolicies) > 0 {
+		fmt.Printf("  - model policies skipped: %s\n", strings.Join(report.SkippedModelPolicies, ", "))
+	}
+	if len(report.Policies) == 0 {
+		return
+	}
+	fmt.Println()
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "POLICY\tCHANGE\tBEFORE\tAFTER\tNEW\tGONE\tDECISIONS\tERRORS\tBENIGN/10K")
+	for _, p := range report.Policies {
+		benignRate := "-"
+		if p.BenignMatchesPer10k != nil {
+			benignRate = fmt.Sprintf("%.0f", *p.BenignMatchesPer10k)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t+%d\t-%d\t%d\t%d\t%s\n", p.PolicyName, p.Change,
+			p.MatchesBefore, p.MatchesAfter, p.NewlyMatched, p.NoLongerMatched, p.DecisionsChanged, p.Errors, benignRate)
+	}
+	tw.Flush()
+	if report.BenignCorpus != nil {
+		fmt.Printf("\nBENIGN/10K: matches per 10k prompts of the benign corpus (%s, %d prompts)\n", report.BenignCorpus.Source, report.BenignCorpus.Size)
+	}
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/internal/config"
+)
+
+// redisOptions builds client options from REDIS_URL plus the explicit REDIS_*
+// settings, which take precedence over anything encoded in the URL
+func redisOptions(cfg *config.Config) (*redis.Options, error) {
+	opt, err := redis.ParseURL(cfg.RedisURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Redis URL: %w", err)
+	}
+
+	// Configure connection pool for high throughput
+	opt.PoolSize = cfg.RedisPoolSize
+	opt.MinIdleConns = cfg.RedisMinIdle
+	opt.PoolTimeout = time.Duration(cfg.RedisPoolTimeout) * time.Second
+	opt.MaxRetries = cfg.RedisMaxRetries
+
+	// ACL credentials
+	if cfg.RedisUsername != "" {
+		opt.Username = cfg.RedisUsername
+	}
+	if cfg.RedisPassword != "" {
+		opt.Password = cfg.RedisPassword
+	}
+	if cfg.RedisDB >= 0 {
+		opt.DB = cfg.RedisDB
+	}
+
+	// TLS: rediss:// URLs enable it already; REDIS_TLS forces it for redis:// URLs
+	if cfg.RedisTLS || cfg.RedisTLSCAFile != "" || cfg.RedisTLSCertFile != "" {
+		if opt.TLSConfig == nil {
+			opt.TLSConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		if cfg.RedisTLSServer != "" {
+			opt.TLSConfig.ServerName = cfg.RedisTLSServer
+		}
+		if cfg.RedisTLSCAFile != "" {
+			pem, err := os.ReadFile(cfg.RedisTLSCAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read REDIS_TLS_CA_FILE: %w", err)
+			}
+			pool := x509.NewCertPool()
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("REDIS_TLS_CA_FILE contains no PEM certificates")
+			}
+			opt.TLSConfig.RootCAs = pool
+		}
+		if cfg.RedisTLSCertFile != "" || cfg.RedisTLSKeyFile != "" {
+			cert, err := tls.LoadX509KeyPair(cfg.RedisTLSCertFile, cfg.RedisTLSKeyFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load Redis client certificate: %w", err)
+			}
+			opt.TLSConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	return opt, nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/config"
+	"github.com/prompt-gateway/internal/envelope"
+	"github.com/prompt-gateway/internal/tenant"
+)
+
+// contentMasters returns the master keys of CONTENT_MASTER_KEY and, while a
+// rotation is in progress, CONTENT_MASTER_KEY_PREVIOUS; nil when content is not encrypted
+func contentMasters(cfg *config.Config) (envelope.Master, []envelope.Master, error) {
+	if cfg.ContentKey == "" {
+		return nil, nil, nil
+	}
+	master, err := localMaster("CONTENT_MASTER_KEY", cfg.ContentKeyID, cfg.ContentKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	var previous []envelope.Master
+	if cfg.ContentKeyPrev != "" {
+		prev, err := localMaster("CONTENT_MASTER_KEY_PREVIOUS", cfg.ContentKeyPrevID, cfg.ContentKeyPrev)
+		if err != nil {
+			return nil, nil, err
+		}
+		previous = append(previous, prev)
+	}
+	return master, previous, nil
+}
+
+// localMaster decodes a base64 master key from the named variable
+func localMaster(name, id, encoded string) (*envelope.LocalMaster, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("%s must be base64: %w", name, err)
+	}
+	master, err := envelope.NewLocalMaster(id, key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+	return master, nil
+}
+
+// contentStore is a storage retaining content: the shared one or an isolated
+// tenant's, each keeping its own data keys
+type contentStore struct {
+	tenant  string // "" = shared
+	keyring *envelope.Keyring
+	reseal  func(ctx context.Context) (bool, error)
+}
+
+// newContentStore seals the content of tenant's storage db under master
+func newContentStore(db *sql.DB, tenant string, master envelope.Master, previous []envelope.Master) contentStore {
+	keyring := envelope.NewKeyring(envelope.NewRepository(db), master, previous...)
+	corpus := benign.NewRepository(db)
+	corpus.SetKeyring(keyring, tenant)
+	return contentStore{tenant: tenant, keyring: keyring, reseal: corpus.Reseal}
+}
+
+// runRotateKeys implements `gateway rotate-keys`: it gives the shared storage and
+// every isolated tenant new data keys, reseals their retained content, rewraps
+// data keys wrapped by the previous master key and deletes retired keys nothing
+// is sealed with, returning the process exit code
+func runRotateKeys(args []string) int {
+	flags := flag.NewFlagSet("rotate-keys", flag.ContinueOnError)
+	envFile := flags.String("env-file", ".env", "env file to load before reading the environment")
+	tenants := flags.String("tenants", "", "comma-separated tenants to rotate, \"shared\" for the shared storage (default: all)")
+	rewrapOnly := flags.Bool("rewrap-only", false, "only rewrap data keys with the current master key, keeping the data keys")
+	timeout := flags.Duration("timeout", time.Minute, "timeout for the whole rotation")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+
+	if err := godotenv.Load(*envFile); err != nil && !os.IsNotExist(err) {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 2
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 2
+	}
+	master, previous, err := contentMasters(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 2
+	}
+	if master == nil {
+		fmt.Fprintln(os.Stderr, "rotate-keys: CONTENT_MASTER_KEY is not set, retained content is not encrypted")
+		return 2
+	}
+
+	db, err := sql.Open("postgres", cfg.DatabaseURL)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 1
+	}
+	defer db.Close()
+	stores := []contentStore{newContentStore(db, "", master, previous)}
+
+	schemas, err := tenant.ParseSpec(cfg.TenantSchemas)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: invalid TENANT_SCHEMAS: %v\n", err)
+		return 2
+	}
+	databases, err := tenant.ParseSpec(cfg.TenantDatabases)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: invalid TENANT_DATABASES: %v\n", err)
+		return 2
+	}
+	router, err := tenant.Open(cfg.DatabaseURL, schemas, databases, tenant.Pool{MaxOpen: 2, MaxIdle: 1})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 1
+	}
+	defer router.Close()
+	for _, name := range router.Names() {
+		stores = append(stores, newContentStore(router.DB(name), name, master, previous))
+	}
+
+	stores, err = selectStores(stores, splitTenants(*tenants))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 2
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), *timeout)
+	defer cancel()
+	if err := rotateKeys(ctx, master.ID(), stores, *rewrapOnly); err != nil {
+		fmt.Fprintf(os.Stderr, "rotate-keys: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// splitTenants parses -tenants; "shared" names the shared tenant
+func splitTenants(s string) []string {
+	if s == "" {
+		return nil
+	}
+	var tenants []string
+	for _, t := range strings.Split(s, ",") {
+		t = strings.TrimSpace(t)
+		if t == "shared" {
+			t = ""
+		}
+		tenants = append(tenants, t)
+	}
+	return tenants
+}
+
+// selectStores keeps the stores of tenants, in that order (every store when nil)
+func selectStores(stores []contentStore, tenants []string) ([]contentStore, error) {
+	if tenants == nil {
+		return stores, nil
+	}
+	var selected []contentStore
+	for _, t := range tenants {
+		found := false
+		for _, s := range stores {
+			if s.tenant == t {
+				selected = append(selected, s)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("tenant %s is not isolated (see TENANT_SCHEMAS and TENANT_DATABASES)", tenantName(t))
+		}
+	}
+	return selected, nil
+}
+
+// tenantName prints the shared tenant as "shared"
+func tenantName(tenant string) string {
+	if tenant == "" {
+		return "shared"
+	}
+	return tenant
+}
+
+// rotateKeys runs a rotation in every store: a new data key for its tenant, its
+// retained content resealed, its data keys rewrapped with the current master and
+// its unused retired keys deleted
+func rotateKeys(ctx context.Context, masterKeyID string, stores []contentStore, rewrapOnly bool) error {
+	fmt.Printf("🔑 Rotating content keys (master key %s)...\n", masterKeyID)
+
+	for _, s := range stores {
+		name := tenantName(s.tenant)
+		if !rewrapOnly {
+			id, err := s.keyring.Rotate(ctx, s.tenant)
+			if err != nil {
+				return fmt.Errorf("failed to rotate the data key of tenant %s: %w", name, err)
+			}
+			fmt.Printf("  ✓ %-22s new data key %s\n", name, id)
+
+			resealed, err := s.reseal(ctx)
+			if err != nil {
+				return fmt.Errorf("tenant %s: %w", name, err)
+			}
+			if resealed {
+				fmt.Printf("  ✓ %-22s benign corpus resealed\n", name)
+			}
+		}
+
+		rewrapped, err := s.keyring.Rewrap(ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+		fmt.Printf("  ✓ %-22s %d data key(s) rewrapped with %s\n", name, rewrapped, masterKeyID)
+
+		deleted, err := s.keyring.DeleteRetired(ctx)
+		if err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+		fmt.Printf("  ✓ %-22s %d retired data key(s) deleted\n", name, deleted)
+	}
+	return nil
+}
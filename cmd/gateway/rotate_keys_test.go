@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/base64"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/internal/config"
+)
+
+func TestContentMasters(t *testing.T) {
+	key := base64.StdEncoding.EncodeToString(make([]byte, 32))
+
+	master, previous, err := contentMasters(&config.Config{})
+	if master != nil || previous != nil || err != nil {
+		t.Errorf("contentMasters(unset) = %v, %v, %v, want encryption disabled", master, previous, err)
+	}
+
+	master, previous, err = contentMasters(&config.Config{
+		ContentKey: key, ContentKeyID: "k2", ContentKeyPrev: key, ContentKeyPrevID: "k1",
+	})
+	if err != nil {
+		t.Fatalf("contentMasters() error = %v", err)
+	}
+	if master.ID() != "k2" || len(previous) != 1 || previous[0].ID() != "k1" {
+		t.Errorf("masters = %s, %v, want k2 rotating from k1", master.ID(), previous)
+	}
+
+	tests := []struct {
+		name string
+		cfg  config.Config
+		want string
+	}{
+		{"not base64", config.Config{ContentKey: "not base64!", ContentKeyID: "k1"}, "CONTENT_MASTER_KEY must be base64"},
+		{"short key", config.Config{ContentKey: base64.StdEncoding.EncodeToString(make([]byte, 16)), ContentKeyID: "k1"}, "must be 32 bytes"},
+		{"bad previous key", config.Config{ContentKey: key, ContentKeyID: "k2", ContentKeyPrev: "short", ContentKeyPrevID: "k1"}, "CONTENT_MASTER_KEY_PREVIOUS"},
+	}
+	for _, tt := range tests {
+		if _, _, err := contentMasters(&tt.cfg); err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("%s: contentMasters() error = %v, want it to mention %q", tt.name, err, tt.want)
+		}
+	}
+}
+
+func TestSplitTenants(t *testing.T) {
+	if got := splitTenants(""); got != nil {
+		t.Errorf("splitTenants(\"\") = %v, want nil for every tenant", got)
+	}
+	if got, want := splitTenants("acme, shared,globex"), []string{"acme", "", "globex"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("splitTenants() = %q, want %q", got, want)
+	}
+}
+
+func TestSelectStores(t *testing.T) {
+	stores := []contentStore{{tenant: ""}, {tenant: "acme"}, {tenant: "globex"}}
+
+	all, err := selectStores(stores, nil)
+	if err != nil || len(all) != 3 {
+		t.Errorf("selectStores(nil) = %d stores, %v, want all 3", len(all), err)
+	}
+
+	got, err := selectStores(stores, splitTenants("globex,shared"))
+	if err != nil {
+		t.Fatalf("selectStores() error = %v", err)
+	}
+	if len(got) != 2 || got[0].tenant != "globex" || got[1].tenant != "" {
+		t.Errorf("selectStores(globex,shared) = %+v, want globex then shared", got)
+	}
+
+	if _, err := selectStores(stores, []string{"initech"}); err == nil || !strings.Contains(err.Error(), "initech is not isolated") {
+		t.Errorf("selectStores(initech) error = %v, want it to name the tenant", err)
+	}
+}
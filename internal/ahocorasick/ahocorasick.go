@@ -0,0 +1,162 @@
+// Package ahocorasick finds many terms in a text in a single pass
+package ahocorasick
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Match is an occurrence of a term; offsets are byte positions in the scanned text
+type Match struct {
+	Term  int // Index of the term in the slice passed to New
+	Start int
+	End   int
+}
+
+// node is a state of the automaton
+type node struct {
+	next   map[rune]int32
+	fail   int32
+	output []int32 // Terms ending here, including those reached via fail links
+}
+
+// Matcher is an immutable case-insensitive Aho-Corasick automaton
+// Matching folds each rune with unicode.ToLower, so offsets always refer to
+// the original text
+type Matcher struct {
+	nodes  []node
+	terms  []string
+	depths []int // Term lengths in runes
+}
+
+// New builds a matcher for the given terms; empty terms are ignored
+func New(terms []string) *Matcher {
+	m := &Matcher{nodes: []node{{next: map[rune]int32{}}}, terms: terms, depths: make([]int, len(terms))}
+	for i, term := range terms {
+		if term == "" {
+			continue
+		}
+		m.depths[i] = utf8.RuneCountInString(term)
+		state := int32(0)
+		for _, r := range term {
+			r = unicode.ToLower(r)
+			child, ok := m.nodes[state].next[r]
+			if !ok {
+				child = int32(len(m.nodes))
+				m.nodes = append(m.nodes, node{next: map[rune]int32{}})
+				m.nodes[state].next[r] = child
+			}
+			state = child
+		}
+		m.nodes[state].output = append(m.nodes[state].output, int32(i))
+	}
+	m.link()
+	return m
+}
+
+// link computes fail links breadth-first and merges outputs along them
+func (m *Matcher) link() {
+	queue := make([]int32, 0, len(m.nodes))
+	for _, child := range m.nodes[0].next {
+		queue = append(queue, child)
+	}
+	for len(queue) > 0 {
+		state := queue[0]
+		queue = queue[1:]
+		for r, child := range m.nodes[state].next {
+			fail := m.nodes[state].fail
+			for fail != 0 {
+				if _, ok := m.nodes[fail].next[r]; ok {
+					break
+				}
+				fail = m.nodes[fail].fail
+			}
+			if target, ok := m.nodes[fail].next[r]; ok && target != child {
+				m.nodes[child].fail = target
+			}
+			m.nodes[child].output = append(m.nodes[child].output, m.nodes[m.nodes[child].fail].output...)
+			queue = append(queue, child)
+		}
+	}
+}
+
+// Terms returns the terms the matcher was built from
+func (m *Matcher) Terms() []string {
+	return m.terms
+}
+
+// step advances the automaton by one rune
+func (m *Matcher) step(state int32, r rune) int32 {
+	for {
+		if next, ok := m.nodes[state].next[r]; ok {
+			return next
+		}
+		if state == 0 {
+			return 0
+		}
+		state = m.nodes[state].fail
+	}
+}
+
+// scan walks text, calling visit for every match until it returns false
+func (m *Matcher) scan(text string, visit func(Match) bool) {
+	state := int32(0)
+	// Rune start offsets of the most recent runes, indexed by rune count,
+	// so a match of depth d can be mapped back to its start byte
+	starts := make([]int, 0, 64)
+	for offset := 0; offset < len(text); {
+		r, size := utf8.DecodeRuneInString(text[offset:])
+		starts = append(starts, offset)
+		state = m.step(state, unicode.ToLower(r))
+		offset += size
+		for _, term := range m.nodes[state].output {
+			start := starts[len(starts)-m.depths[term]]
+			if !visit(Match{Term: int(term), Start: start, End: offset}) {
+				return
+			}
+		}
+	}
+}
+
+// FindFirst returns the first match ending earliest in text
+func (m *Matcher) FindFirst(text string) (Match, bool) {
+	var found Match
+	ok := false
+	m.scan(text, func(match Match) bool {
+		found, ok = match, true
+		return false
+	})
+	return found, ok
+}
+
+// FindAll returns every (possibly overlapping) match in text
+func (m *Matcher) FindAll(text string) []Match {
+	var matches []Match
+	m.scan(text, func(match Match) bool {
+		matches = append(matches, match)
+		return true
+	})
+	return matches
+}
+
+// FindTerms returns the index of every term occurring in text, each once, in
+// the order their first occurrence ends. It stops as soon as all terms are found
+func (m *Matcher) FindTerms(text string) []int {
+	remaining := 0
+	for _, depth := range m.depths {
+		if depth > 0 {
+			remaining++
+		}
+	}
+	seen := make([]bool, len(m.terms))
+	var found []int
+	m.scan(text, func(match Match) bool {
+		if !seen[match.Term] {
+			seen[match.Term] = true
+			found = append(found, match.Term)
+			remaining--
+		}
+		return remaining > 0
+	})
+	return found
+}
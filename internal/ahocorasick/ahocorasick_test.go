@@ -0,0 +1,61 @@
+package ahocorasick
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatcher_FindAll(t *testing.T) {
+	m := New([]string{"he", "she", "his", "hers", ""})
+
+	got := m.FindAll("ushers")
+	want := []Match{
+		{Term: 1, Start: 1, End: 4}, // she
+		{Term: 0, Start: 2, End: 4}, // he
+		{Term: 3, Start: 2, End: 6}, // hers
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindAll() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMatcher_CaseInsensitiveOffsets(t *testing.T) {
+	m := New([]string{"project ÆGIS", "straße"})
+
+	text := "Über das Project ægis in der STRASSE und Straße"
+	matches := m.FindAll(text)
+	if len(matches) != 2 {
+		t.Fatalf("FindAll() = %+v, want 2 matches", matches)
+	}
+	if got := text[matches[0].Start:matches[0].End]; got != "Project ægis" {
+		t.Errorf("first match = %q, want %q", got, "Project ægis")
+	}
+	if got := text[matches[1].Start:matches[1].End]; got != "Straße" {
+		t.Errorf("second match = %q, want %q", got, "Straße")
+	}
+}
+
+func TestMatcher_FindFirst(t *testing.T) {
+	m := New([]string{"falcon", "phoenix"})
+
+	if _, ok := m.FindFirst("nothing relevant"); ok {
+		t.Error("FindFirst() matched unrelated text")
+	}
+	match, ok := m.FindFirst("codename PHOENIX, then falcon")
+	if !ok || match.Term != 1 {
+		t.Errorf("FindFirst() = %+v, %v, want term 1", match, ok)
+	}
+}
+
+func TestMatcher_FindTerms(t *testing.T) {
+	m := New([]string{"he", "she", "hers", "", "falcon"})
+
+	// Repeated and overlapping occurrences are reported once per term
+	got := m.FindTerms("ushers and she sells hers")
+	if want := []int{1, 0, 2}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FindTerms() = %v, want %v", got, want)
+	}
+	if got := m.FindTerms("nothing relevant"); len(got) != 0 {
+		t.Errorf("FindTerms() = %v, want none", got)
+	}
+}
@@ -0,0 +1,232 @@
+package alerting
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// ruleKey identifies per-client state for a rule
+type ruleKey struct {
+	rule   string
+	client string
+}
+
+// ruleState tracks the sliding window and cooldown for one rule/client pair
+type ruleState struct {
+	hits       []time.Time // Qualifying decision timestamps inside the window
+	lastFired  time.Time
+	suppressed int // Firings swallowed by the cooldown since lastFired
+}
+
+// EventRecorder persists triggered alerts (e.g. as incident records)
+type EventRecorder interface {
+	RecordEvent(event notify.Event)
+}
+
+// SessionPinner pins a decision for every subsequent request of a session
+type SessionPinner interface {
+	Set(ctx context.Context, override models.SessionOverride, ttl time.Duration) error
+}
+
+// Engine evaluates alert rules continuously against analyze decisions
+type Engine struct {
+	rules    []Rule
+	notifier *notify.Notifier
+	recorder EventRecorder
+	pinner   SessionPinner
+	events   chan models.DecisionEvent
+	state    map[ruleKey]*ruleState // Owned by the worker goroutine
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+	now      func() time.Time
+}
+
+// NewEngine creates a rules engine that routes alerts through notifier
+func NewEngine(rules []Rule, notifier *notify.Notifier) *Engine {
+	return &Engine{
+		rules:    rules,
+		notifier: notifier,
+		events:   make(chan models.DecisionEvent, 10000),
+		state:    make(map[ruleKey]*ruleState),
+		stopCh:   make(chan struct{}),
+		now:      time.Now,
+	}
+}
+
+// SetRecorder registers a recorder that receives every fired alert
+// Must be called before Start
+func (e *Engine) SetRecorder(recorder EventRecorder) {
+	e.recorder = recorder
+}
+
+// SetSessionPinner enables rules with pin_session_seconds to block the
+// triggering session; must be called before Start
+func (e *Engine) SetSessionPinner(pinner SessionPinner) {
+	e.pinner = pinner
+}
+
+// Start launches the background evaluation worker
+func (e *Engine) Start() {
+	e.wg.Add(1)
+	go e.worker()
+	slog.Info("Alert rules engine started", "rules", len(e.rules))
+}
+
+// Observe queues a decision for rule evaluation (non-blocking)
+func (e *Engine) Observe(event models.DecisionEvent) {
+	select {
+	case e.events <- event:
+	default:
+		slog.Warn("Alert rules buffer full, skipping decision")
+	}
+}
+
+// worker evaluates queued decisions and periodically prunes idle state
+func (e *Engine) worker() {
+	defer e.wg.Done()
+
+	pruneTicker := time.NewTicker(time.Minute)
+	defer pruneTicker.Stop()
+
+	for {
+		select {
+		case event := <-e.events:
+			e.evaluate(event)
+		case <-pruneTicker.C:
+			e.prune()
+		case <-e.stopCh:
+			slog.Info("Alert rules engine stopped")
+			return
+		}
+	}
+}
+
+// evaluate checks a single decision against every rule
+func (e *Engine) evaluate(event models.DecisionEvent) {
+	now := e.now()
+
+	for _, rule := range e.rules {
+		if !rule.matches(event) {
+			continue
+		}
+
+		key := ruleKey{rule: rule.Name, client: event.Audit.ClientID}
+		st, ok := e.state[key]
+		if !ok {
+			st = &ruleState{}
+			e.state[key] = st
+		}
+
+		count := 1
+		if rule.Threshold > 1 {
+			st.hits = append(trimBefore(st.hits, now.Add(-rule.Window)), now)
+			count = len(st.hits)
+			if count < rule.Threshold {
+				continue
+			}
+		}
+
+		// Deduplicate: one alert per cooldown period, counting what was suppressed
+		if !st.lastFired.IsZero() && now.Sub(st.lastFired) < rule.Cooldown {
+			st.suppressed++
+			continue
+		}
+
+		e.fire(rule, event, count, st.suppressed)
+		st.lastFired = now
+		st.suppressed = 0
+		st.hits = st.hits[:0]
+	}
+}
+
+// fire emits the alert event for a triggered rule
+func (e *Engine) fire(rule Rule, event models.DecisionEvent, count, suppressed int) {
+	summary := fmt.Sprintf("alert rule %q triggered for client %s", rule.Name, event.Audit.ClientID)
+	if rule.Threshold > 1 {
+		summary = fmt.Sprintf("alert rule %q: %d matching decisions for client %s within %v",
+			rule.Name, count, event.Audit.ClientID, rule.Window)
+	}
+
+	alert := notify.Event{
+		Type:     "rule.triggered",
+		Severity: rule.Severity,
+		ClientID: event.Audit.ClientID,
+		Summary:  summary,
+		Details: map[string]interface{}{
+			"rule":       rule.Name,
+			"count":      count,
+			"suppressed": suppressed,
+			"request_id": event.Audit.RequestID,
+			"action":     event.Audit.ActionTaken,
+			"model":      event.Model,
+		},
+		Sinks:     rule.Sinks,
+		Timestamp: e.now(),
+	}
+
+	if e.notifier != nil {
+		e.notifier.Notify(alert)
+	}
+	if e.recorder != nil {
+		e.recorder.RecordEvent(alert)
+	}
+	if rule.PinSession > 0 && e.pinner != nil && event.Audit.SessionID != "" {
+		e.pinSession(rule, event.Audit.SessionID)
+	}
+}
+
+// pinSession blocks the rest of a session that triggered a rule
+func (e *Engine) pinSession(rule Rule, sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	override := models.SessionOverride{
+		SessionID: sessionID,
+		Action:    "block",
+		Reason:    fmt.Sprintf("alert rule %q triggered", rule.Name),
+		SetBy:     "rule:" + rule.Name,
+	}
+	if err := e.pinner.Set(ctx, override, rule.PinSession); err != nil {
+		slog.Error("Failed to pin session for alert rule", "session_id", sessionID, "rule", rule.Name, "error", err)
+		return
+	}
+	slog.Info("Session blocked by alert rule", "session_id", sessionID, "rule", rule.Name, "duration", rule.PinSession)
+}
+
+// prune drops state for rule/client pairs with no recent activity
+func (e *Engine) prune() {
+	cutoff := e.now().Add(-time.Hour)
+	for key, st := range e.state {
+		last := st.lastFired
+		if n := len(st.hits); n > 0 && st.hits[n-1].After(last) {
+			last = st.hits[n-1]
+		}
+		if last.Before(cutoff) {
+			delete(e.state, key)
+		}
+	}
+}
+
+// trimBefore drops timestamps older than cutoff from the front of a sorted slice
+func trimBefore(hits []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(hits) && hits[i].Before(cutoff) {
+		i++
+	}
+	return hits[i:]
+}
+
+// Stop gracefully stops the background worker
+func (e *Engine) Stop() {
+	e.stopOnce.Do(func() {
+		close(e.stopCh)
+	})
+	e.wg.Wait()
+}
@@ -0,0 +1,106 @@
+package alerting
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+type captureSink struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (c *captureSink) Name() string { return "capture" }
+
+func (c *captureSink) Send(ctx context.Context, event notify.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+	return nil
+}
+
+func TestEngine_ThresholdWithCooldown(t *testing.T) {
+	sink := &captureSink{}
+	notifier := notify.NewNotifier(100, sink)
+
+	rule := Rule{Name: "blocks", Action: "block", Threshold: 3, Window: time.Minute, Cooldown: 10 * time.Minute, Severity: "high"}
+	e := NewEngine([]Rule{rule}, notifier)
+
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	e.now = func() time.Time { return clock }
+
+	block := models.DecisionEvent{Audit: models.AuditLog{ClientID: "c1", ActionTaken: "block"}}
+	allow := models.DecisionEvent{Audit: models.AuditLog{ClientID: "c1", ActionTaken: "allow"}}
+
+	e.evaluate(block)
+	e.evaluate(allow)
+	e.evaluate(block)
+	e.evaluate(block) // third block fires
+	for i := 0; i < 6; i++ {
+		clock = clock.Add(time.Second)
+		e.evaluate(block) // suppressed by cooldown
+	}
+
+	clock = clock.Add(11 * time.Minute)
+	e.evaluate(block)
+	e.evaluate(block)
+	e.evaluate(block) // fires again after cooldown
+	notifier.Close()
+
+	if len(sink.events) != 2 {
+		t.Fatalf("got %d alerts, want 2", len(sink.events))
+	}
+	if got := sink.events[1].Details["suppressed"]; got != 4 {
+		t.Errorf("suppressed = %v, want 4", got)
+	}
+}
+
+func TestRule_MatchesSeverityAndModel(t *testing.T) {
+	rule := Rule{Name: "critical-gpt4", MinSeverity: "critical", Model: "gpt-4", Severity: "critical"}
+
+	critical := models.DecisionEvent{Model: "gpt-4", Matches: []models.PolicyMatch{{Severity: "critical"}}}
+	high := models.DecisionEvent{Model: "gpt-4", Matches: []models.PolicyMatch{{Severity: "high"}}}
+	otherModel := models.DecisionEvent{Model: "claude", Matches: []models.PolicyMatch{{Severity: "critical"}}}
+
+	if !rule.matches(critical) {
+		t.Error("expected critical gpt-4 match to qualify")
+	}
+	if rule.matches(high) {
+		t.Error("expected high severity match not to qualify")
+	}
+	if rule.matches(otherModel) {
+		t.Error("expected other model not to qualify")
+	}
+}
+
+type capturePinner struct {
+	overrides []models.SessionOverride
+}
+
+func (c *capturePinner) Set(ctx context.Context, override models.SessionOverride, ttl time.Duration) error {
+	c.overrides = append(c.overrides, override)
+	return nil
+}
+
+func TestEngine_PinsTriggeringSession(t *testing.T) {
+	rule := Rule{Name: "jailbreak", MinSeverity: "critical", Threshold: 1, Severity: "critical", PinSession: time.Hour}
+	e := NewEngine([]Rule{rule}, nil)
+	pinner := &capturePinner{}
+	e.SetSessionPinner(pinner)
+
+	critical := []models.PolicyMatch{{Severity: "critical"}}
+	e.evaluate(models.DecisionEvent{Audit: models.AuditLog{ClientID: "c1", SessionID: "s1"}, Matches: critical})
+	e.evaluate(models.DecisionEvent{Audit: models.AuditLog{ClientID: "c2"}, Matches: critical}) // no session to pin
+
+	if len(pinner.overrides) != 1 {
+		t.Fatalf("got %d pinned sessions, want 1", len(pinner.overrides))
+	}
+	if got := pinner.overrides[0]; got.SessionID != "s1" || got.Action != "block" {
+		t.Errorf("pinned %+v, want block on s1", got)
+	}
+}
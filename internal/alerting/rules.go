@@ -0,0 +1,113 @@
+package alerting
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Rule is a threshold condition evaluated against the decision stream
+//
+// A rule with Threshold <= 1 fires on every qualifying decision ("any critical
+// match on model X"). Otherwise it fires when a client accumulates Threshold
+// qualifying decisions within Window ("more than N blocks in M minutes").
+type Rule struct {
+	Name        string        `json:"name"`
+	Action      string        `json:"action,omitempty"`       // Decision action to count, e.g. "block" (any when empty)
+	MinSeverity string        `json:"min_severity,omitempty"` // Lowest matched severity to count (any when empty)
+	Model       string        `json:"model,omitempty"`        // Only count decisions for this model (any when empty)
+	Threshold   int           `json:"threshold"`              // Qualifying decisions needed to fire
+	Window      time.Duration `json:"-"`                      // Sliding window for Threshold
+	Cooldown    time.Duration `json:"-"`                      // Minimum time between alerts per client
+	Severity    string        `json:"severity"`               // Severity of the emitted alert
+	Sinks       []string      `json:"sinks,omitempty"`        // Sink names to route to (all when empty)
+	PinSession  time.Duration `json:"-"`                      // Block the triggering session for this long (disabled when zero)
+}
+
+// ruleFile is the on-disk JSON representation of a rule
+type ruleFile struct {
+	Rule
+	WindowSeconds     int `json:"window_seconds"`
+	CooldownSeconds   int `json:"cooldown_seconds"`
+	PinSessionSeconds int `json:"pin_session_seconds"`
+}
+
+// LoadRules reads alert rules from a JSON file containing an array of rules
+func LoadRules(path string) ([]Rule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read alert rules: %w", err)
+	}
+
+	var raw []ruleFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse alert rules: %w", err)
+	}
+
+	rules := make([]Rule, 0, len(raw))
+	for _, r := range raw {
+		rule := r.Rule
+		rule.Window = time.Duration(r.WindowSeconds) * time.Second
+		rule.Cooldown = time.Duration(r.CooldownSeconds) * time.Second
+		rule.PinSession = time.Duration(r.PinSessionSeconds) * time.Second
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("invalid alert rule %q: %w", rule.Name, err)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// validate checks a rule for obvious configuration mistakes
+func (r Rule) validate() error {
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if r.Threshold > 1 && r.Window <= 0 {
+		return fmt.Errorf("window_seconds is required when threshold > 1")
+	}
+	if r.MinSeverity != "" && severityWeight(r.MinSeverity) == 0 {
+		return fmt.Errorf("invalid min_severity: %s", r.MinSeverity)
+	}
+	if severityWeight(r.Severity) == 0 {
+		return fmt.Errorf("invalid severity: must be low, medium, high, or critical")
+	}
+	return nil
+}
+
+// matches reports whether a decision counts towards the rule
+func (r Rule) matches(event models.DecisionEvent) bool {
+	if r.Action != "" && event.Audit.ActionTaken != r.Action {
+		return false
+	}
+	if r.Model != "" && event.Model != r.Model {
+		return false
+	}
+	if r.MinSeverity != "" {
+		highest := 0
+		for _, m := range event.Matches {
+			if w := severityWeight(m.Severity); w > highest {
+				highest = w
+			}
+		}
+		if highest < severityWeight(r.MinSeverity) {
+			return false
+		}
+	}
+	return true
+}
+
+// severityWeight returns numeric weight for severity comparison
+func severityWeight(severity string) int {
+	weights := map[string]int{
+		"low":      1,
+		"medium":   2,
+		"high":     3,
+		"critical": 4,
+	}
+	return weights[severity]
+}
@@ -0,0 +1,153 @@
+// Package allowlist keeps the content hashes of known-benign prompts (fixed system
+// prompts, test fixtures) that are allowed without analysis
+package allowlist
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// redisKey is the Redis set shared by every replica
+const redisKey = "allowlist:prompts"
+
+// Store serves the allowlist from memory; the Redis set is the source of truth and
+// is reloaded periodically so other replicas' changes are picked up
+type Store struct {
+	rdb      *redis.Client
+	interval time.Duration
+
+	mu     sync.RWMutex // Protects hashes
+	hashes map[string]struct{}
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStore creates an allowlist store refreshed every interval
+func NewStore(rdb *redis.Client, interval time.Duration) *Store {
+	return &Store{
+		rdb:      rdb,
+		interval: interval,
+		hashes:   make(map[string]struct{}),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start performs the initial load and starts the refresh worker; a failed load
+// leaves the allowlist empty, so every prompt is analyzed until Redis recovers
+func (s *Store) Start(ctx context.Context) {
+	if err := s.Refresh(ctx); err != nil {
+		slog.Warn("Failed to load prompt allowlist, analyzing every prompt", "error", err)
+	}
+	go s.refreshWorker(ctx)
+	slog.Info("Prompt allowlist initialized", "hashes", s.Len(), "refresh", s.interval)
+}
+
+// refreshWorker reloads the allowlist periodically
+func (s *Store) refreshWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh prompt allowlist, serving stale list", "error", err)
+			}
+		case <-s.stopChan:
+			slog.Info("Prompt allowlist refresh worker stopped")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh reloads the allowlist from Redis
+func (s *Store) Refresh(ctx context.Context) error {
+	members, err := s.rdb.SMembers(ctx, redisKey).Result()
+	if err != nil {
+		return fmt.Errorf("failed to load allowlist: %w", err)
+	}
+	hashes := make(map[string]struct{}, len(members))
+	for _, h := range members {
+		hashes[h] = struct{}{}
+	}
+	s.mu.Lock()
+	s.hashes = hashes
+	s.mu.Unlock()
+	return nil
+}
+
+// Contains reports whether a content hash is allowlisted; a nil store allows nothing
+func (s *Store) Contains(hash string) bool {
+	if s == nil {
+		return false
+	}
+	s.mu.RLock()
+	_, ok := s.hashes[hash]
+	s.mu.RUnlock()
+	return ok
+}
+
+// Len returns the number of allowlisted hashes
+func (s *Store) Len() int {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return len(s.hashes)
+}
+
+// List returns the allowlisted hashes, sorted
+func (s *Store) List() []string {
+	s.mu.RLock()
+	list := make([]string, 0, len(s.hashes))
+	for h := range s.hashes {
+		list = append(list, h)
+	}
+	s.mu.RUnlock()
+	sort.Strings(list)
+	return list
+}
+
+// Add allowlists hashes, returning how many were new
+func (s *Store) Add(ctx context.Context, hashes []string) (int, error) {
+	members := make([]interface{}, len(hashes))
+	for i, h := range hashes {
+		members[i] = h
+	}
+	added, err := s.rdb.SAdd(ctx, redisKey, members...).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to add to allowlist: %w", err)
+	}
+	s.mu.Lock()
+	for _, h := range hashes {
+		s.hashes[h] = struct{}{}
+	}
+	s.mu.Unlock()
+	return int(added), nil
+}
+
+// Remove drops a hash, reporting whether it was allowlisted
+func (s *Store) Remove(ctx context.Context, hash string) (bool, error) {
+	removed, err := s.rdb.SRem(ctx, redisKey, hash).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to remove from allowlist: %w", err)
+	}
+	s.mu.Lock()
+	delete(s.hashes, hash)
+	s.mu.Unlock()
+	return removed > 0, nil
+}
+
+// Stop gracefully stops the refresh worker
+func (s *Store) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
@@ -5,20 +5,62 @@ import (
 	"errors"
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	goaway "github.com/TwiN/go-away"
+	"github.com/prompt-gateway/internal/ahocorasick"
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/internal/flags"
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/internal/tokenizer"
+	"github.com/prompt-gateway/internal/tracing"
 	"github.com/prompt-gateway/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// PatternSource provides matchers precompiled when policies are loaded
+type PatternSource interface {
+	Pattern(source string) (*regexp.Regexp, bool)
+	// RegexSet returns the combined matcher of all loaded regex policies (nil when none)
+	RegexSet() *RegexSet
+	// KeywordSet returns the combined matcher of all loaded keyword policies (nil when none)
+	KeywordSet() *KeywordSet
+}
+
+// DictionarySource resolves wordlist names used by "dictionary" policies
+type DictionarySource interface {
+	Matcher(name string) (*ahocorasick.Matcher, bool)
+}
+
+// Match modes, selected with SetMatchMode
+const (
+	MatchAll   = "all"   // Evaluate every policy and report every match (default)
+	MatchFirst = "first" // Stop at the first match, trading completeness for latency
 )
 
 // Analyzer handles prompt/response analysis against policies
 type Analyzer struct {
 	// Cache compiled regex patterns to avoid recompiling
-	patternCache map[string]*regexp.Regexp
-	mu           sync.RWMutex // Protects patternCache
-	profanityDet *goaway.ProfanityDetector
-	modelClient  ModelClient
+	patternCache  map[string]*regexp.Regexp
+	mu            sync.RWMutex  // Protects patternCache
+	patternSource PatternSource // Optional; consulted before patternCache
+	dictionaries  DictionarySource
+	normalize     bool          // Also match pattern policies against de-obfuscated content
+	firstMatch    bool          // Stop at the first match instead of reporting every one
+	flags         *flags.Store  // Optional; nil keeps every flagged behavior at its default
+	evalTimeout   time.Duration // Upper bound for one Analyze call (0 = none)
+	stats         *PolicyStats  // Optional; records per-policy evaluation time
+	profanityDet  *goaway.ProfanityDetector
+	modelClient   ModelClient
+	modelBreaker  *breaker.Breaker     // Optional; enables degraded evaluation when providers fail
+	tokenizer     *tokenizer.Tokenizer // Optional; nil estimates token counts
+	modelTokens   int                  // Token budget of content sent to models (0 = unlimited)
+	modelProvider string               // Name of the modelClient's provider in privacy
+	privacy       *ModelPrivacy        // Optional; nil sends content to the provider as is
+	routes        *ModelRoutes         // Optional; nil evaluates every language with the policy's model
 }
 
 // NewAnalyzer creates a new Analyzer
@@ -30,45 +72,297 @@ func NewAnalyzer(modelClient ModelClient) *Analyzer {
 	}
 }
 
+// SetPatternSource uses precompiled matchers (e.g. the policy cache snapshot)
+// so the hot path avoids the pattern cache lock
+// Must be called before the analyzer is used
+func (a *Analyzer) SetPatternSource(source PatternSource) {
+	a.patternSource = source
+}
+
+// SetDictionaries enables "dictionary" policies backed by managed wordlists
+// Must be called before the analyzer is used
+func (a *Analyzer) SetDictionaries(source DictionarySource) {
+	a.dictionaries = source
+}
+
+// SetEvasionNormalization makes pattern-based policies also see content with
+// zalgo, spacing, repetition, leetspeak and ROT13 obfuscation undone
+// Must be called before the analyzer is used
+func (a *Analyzer) SetEvasionNormalization(enabled bool) {
+	a.normalize = enabled
+}
+
+// SetMatchMode selects whether Analyze reports every match (MatchAll) or stops
+// at the first one (MatchFirst)
+// Must be called before the analyzer is used
+func (a *Analyzer) SetMatchMode(mode string) {
+	a.firstMatch = mode == MatchFirst
+}
+
+// SetFlags gates analyzer behavior behind feature flags
+// Must be called before the analyzer is used
+func (a *Analyzer) SetFlags(store *flags.Store) {
+	a.flags = store
+}
+
+// SetEvaluationTimeout bounds how long Analyze waits for policies; policies still
+// running at the deadline fail the evaluation with ErrEvaluationTimeout
+// Must be called before the analyzer is used
+func (a *Analyzer) SetEvaluationTimeout(timeout time.Duration) {
+	a.evalTimeout = timeout
+}
+
+// SetPolicyStats records per-policy evaluation time for slow-policy reporting
+// Must be called before the analyzer is used
+func (a *Analyzer) SetPolicyStats(stats *PolicyStats) {
+	a.stats = stats
+}
+
+// Offline returns an analyzer that matches like a (wordlists, evasion normalization,
+// flags, tokenizer) but never calls model providers, times out or records stats,
+// for evaluating policies that aren't live
+func (a *Analyzer) Offline() *Analyzer {
+	offline := NewAnalyzer(nil)
+	offline.dictionaries = a.dictionaries
+	offline.normalize = a.normalize
+	offline.firstMatch = a.firstMatch
+	offline.flags = a.flags
+	offline.tokenizer = a.tokenizer
+	return offline
+}
+
+// ErrEvaluationTimeout is returned when policies are still running at the evaluation deadline
+var ErrEvaluationTimeout = errors.New("policy evaluation deadline exceeded")
+
 // policyResult holds the result of a single policy check
 type policyResult struct {
+	index int // Position of the policy, so matches are reported in policy order
 	match models.PolicyMatch
 	err   error
 	found bool
 }
 
+// matchesOf returns the matches of found results in policy order
+func matchesOf(found []policyResult) []models.PolicyMatch {
+	sort.Slice(found, func(i, j int) bool { return found[i].index < found[j].index })
+	matches := make([]models.PolicyMatch, len(found))
+	for i, r := range found {
+		matches[i] = r.match
+	}
+	return matches
+}
+
+// setCovered reports whether the combined regex scan can decide p: an unscoped,
+// uncapped regex policy whose pattern is in the set
+func setCovered(p models.Policy, set *RegexSet) bool {
+	return p.PatternType == "regex" && !isScoped(p) && p.MaxInputBytes == 0 && set.Contains(p.PatternValue)
+}
+
+// keywordCovered reports whether the combined keyword scan decides p: an
+// unscoped, uncapped, unstemmed substring keyword policy whose keyword is in the set
+func keywordCovered(p models.Policy, set *KeywordSet) bool {
+	return p.PatternType == "keyword" && !p.Stem && !WordBounded(p.MatchMode) && !isScoped(p) && p.MaxInputBytes == 0 && set.Contains(p.PatternValue)
+}
+
 // Analyze checks content against policies and returns matches
-// Uses concurrent goroutines to check all policies in parallel
+// Uses concurrent goroutines to check all policies in parallel; every match is
+// reported in evaluation order (see SortPolicies) unless the analyzer stops at
+// the first (MatchFirst), which is the earliest match in that order whichever
+// check finishes first
 // Assumes policies are already filtered (only enabled ones)
 func (a *Analyzer) Analyze(ctx context.Context, content string, policies []models.Policy) ([]models.PolicyMatch, error) {
 	if len(policies) == 0 {
 		return []models.PolicyMatch{}, nil
 	}
+	policies = inEvaluationOrder(policies)
 
+	ctx, span := tracing.Start(ctx, "analyzer.analyze", attribute.Int("analyzer.policies", len(policies)))
+	defer span.End()
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
+	trace := traceCall(ctx)
+
+	// Pattern matchers scan the original text plus its de-obfuscated variants;
+	// model and profanity detectors get the original (go-away sanitizes itself)
+	normalize := a.normalize && a.flags.Enabled(ctx, flags.EvasionNormalization)
+	scan := content
+	if normalize {
+		_, normalizeSpan := tracing.Start(ctx, "analyzer.normalize")
+		start := time.Now()
+		scan = expandEvasions(content)
+		observeMatcher("normalize", start)
+		normalizeSpan.End()
+	}
+	// Policies limited to code/prose or markup-free text get their own view
+	views := &scopedViews{content: content, normalize: normalize}
+
+	// Regex and keyword policies covered by the precompiled sets are decided by
+	// one scan each; only regexes the scan can't rule out fall through to
+	// per-policy matching, while the keyword scan is exhaustive
+	var set *RegexSet
+	var keywords *KeywordSet
+	if a.patternSource != nil {
+		set = a.patternSource.RegexSet()
+		keywords = a.patternSource.KeywordSet()
+	}
+	var hits map[string]string
+	var keywordHits KeywordHits
+	var found []policyResult
+	for i, policy := range policies {
+		if !policy.Enabled {
+			continue
+		}
+		var matched, via string
+		var hit bool
+		switch {
+		case len(policy.CaptureConstraints) == 0 && setCovered(policy, set):
+			if hits == nil {
+				_, scanSpan := tracing.Start(ctx, "analyzer.regex_scan")
+				start := time.Now()
+				hits = set.Scan(scan)
+				observeMatcher("regex", start)
+				scanSpan.SetAttributes(attribute.Int("analyzer.hits", len(hits)))
+				scanSpan.End()
+			}
+			matched, hit = hits[policy.PatternValue]
+			via = "combined regex scan"
+		case keywordCovered(policy, keywords):
+			if keywordHits == nil {
+				_, scanSpan := tracing.Start(ctx, "analyzer.keyword_scan")
+				start := time.Now()
+				keywordHits = keywords.Scan(scan)
+				observeMatcher("keyword", start)
+				scanSpan.SetAttributes(attribute.Int("analyzer.hits", len(keywordHits)))
+				scanSpan.End()
+			}
+			matched, hit = policy.PatternValue, keywordHits.Hit(policy.PatternValue)
+			via = "combined keyword scan"
+		default:
+			continue
+		}
+		if hit {
+			trace.record(policy, TraceMatched, via, 0, nil)
+			match := models.PolicyMatch{
+				PolicyID:       policy.ID,
+				PolicyName:     policy.Name,
+				Severity:       policy.Severity,
+				MatchedPattern: matched,
+			}
+			a.locate(policy, content, &match)
+			found = append(found, policyResult{index: i, match: match, found: true})
+		}
+	}
+
+	// A first match is final once every policy ordered before it has settled
+	// (been ruled out or checked), so the winner never depends on scheduling
+	settled := make([]bool, len(policies))
+	first, next := len(policies), 0 // Earliest match; policies before next have all settled
+	if a.firstMatch && len(found) > 0 {
+		first = found[0].index
+	}
+	decided := func() bool {
+		for next < first && settled[next] {
+			next++
+		}
+		return first < len(policies) && next >= first
+	}
+	firstMatch := func() []models.PolicyMatch {
+		for _, r := range found {
+			if r.index == first {
+				return []models.PolicyMatch{r.match}
+			}
+		}
+		return nil
+	}
 
 	resultCh := make(chan policyResult, len(policies))
 	var wg sync.WaitGroup
+	var running sync.Map // policy name → whether it uses a model, while its check runs
 	activePolicies := 0
 
-	for _, policy := range policies {
+	for i, policy := range policies {
+		if i >= first {
+			// Policies after a scan's first match can't win (MatchFirst)
+			break
+		}
 		if !policy.Enabled {
+			trace.record(policy, TraceSkipped, "disabled", 0, nil)
+			settled[i] = true
+			continue
+		}
+		if hits != nil && setCovered(policy, set) {
+			// A hit is a match, and an empty scan proves no pattern matches; a pattern
+			// missing from a non-empty scan may overlap a hit, so it is checked alone
+			_, hit := hits[policy.PatternValue]
+			if !hit && len(hits) == 0 {
+				trace.record(policy, TraceNotMatched, "ruled out by combined regex scan", 0, nil)
+				settled[i] = true
+				continue
+			}
+			if hit && len(policy.CaptureConstraints) == 0 {
+				settled[i] = true
+				continue
+			}
+		}
+		if keywordHits != nil && keywordCovered(policy, keywords) {
+			if !keywordHits.Hit(policy.PatternValue) {
+				trace.record(policy, TraceNotMatched, "ruled out by combined keyword scan", 0, nil)
+			}
+			settled[i] = true
 			continue
 		}
 		activePolicies++
 
 		wg.Add(1)
-		go func(p models.Policy) {
+		go func(i int, p models.Policy) {
 			defer wg.Done()
 
 			select {
 			case <-ctx.Done():
+				trace.record(p, TraceSkipped, "evaluation canceled", 0, nil)
 				return
 			default:
 			}
 
-			matched, matchedPattern, err := a.checkPolicyMatch(ctx, p, content)
+			policyContent, policyScan := content, scan
+			if isScoped(p) {
+				policyScan = views.view(p)
+				policyContent = policyScan
+			}
+			// Size-capped policies only see a prefix; variants are rebuilt from it
+			if p.MaxInputBytes > 0 && len(policyContent) > p.MaxInputBytes {
+				metrics.PolicyInputTruncatedTotal.Inc()
+				policyContent = truncateUTF8(policyContent, p.MaxInputBytes)
+				policyScan = policyContent
+				if normalize && !isScoped(p) {
+					policyScan = expandEvasions(policyContent)
+				}
+			}
+
+			checkCtx, note := ctx, (*string)(nil)
+			if trace != nil {
+				checkCtx, note = withTraceNote(ctx)
+			}
+			running.Store(p.Name, UsesModel(p))
+			start := time.Now()
+			matched, matchedPattern, err := a.checkPolicyMatch(checkCtx, p, policyContent, policyScan)
+			elapsed := time.Since(start)
+			a.stats.observe(p, elapsed)
+			running.Delete(p.Name)
+			if trace != nil {
+				switch {
+				case err != nil && ctx.Err() != nil:
+					trace.record(p, TraceSkipped, "evaluation canceled", elapsed, nil)
+				case err != nil:
+					trace.record(p, TraceError, "", elapsed, err)
+				case matched:
+					trace.record(p, TraceMatched, *note, elapsed, nil)
+				case *note != "":
+					trace.record(p, TraceSkipped, *note, elapsed, nil)
+				default:
+					trace.record(p, TraceNotMatched, "", elapsed, nil)
+				}
+			}
 			if err != nil {
 				select {
 				case resultCh <- policyResult{err: fmt.Errorf("error matching policy %s: %w", p.Name, err)}:
@@ -77,27 +371,32 @@ func (a *Analyzer) Analyze(ctx context.Context, content string, policies []model
 				return
 			}
 
-			if !matched {
-				return
-			}
-
-			select {
-			case resultCh <- policyResult{
-				match: models.PolicyMatch{
+			// Non-matches are reported too, so a first match knows when it is final
+			result := policyResult{index: i}
+			if matched {
+				result.match = models.PolicyMatch{
 					PolicyID:       p.ID,
 					PolicyName:     p.Name,
 					Severity:       p.Severity,
 					MatchedPattern: matchedPattern,
-				},
-				found: true,
-			}:
+				}
+				a.locate(p, policyContent, &result.match)
+				result.found = true
+			}
+
+			select {
+			case resultCh <- result:
 			case <-ctx.Done():
 			}
-		}(policy)
+		}(i, policy)
 	}
 
+	if decided() {
+		trace.finish(policies, "stopped at first match")
+		return firstMatch(), nil
+	}
 	if activePolicies == 0 {
-		return []models.PolicyMatch{}, nil
+		return matchesOf(found), nil
 	}
 
 	go func() {
@@ -105,35 +404,122 @@ func (a *Analyzer) Analyze(ctx context.Context, content string, policies []model
 		close(resultCh)
 	}()
 
-	for result := range resultCh {
-		if result.err != nil {
-			cancel()
-			return nil, result.err
-		}
+	var deadline <-chan time.Time
+	if a.evalTimeout > 0 {
+		timer := time.NewTimer(a.evalTimeout)
+		defer timer.Stop()
+		deadline = timer.C
+	}
 
-		if result.found {
+	for {
+		select {
+		case result, ok := <-resultCh:
+			if !ok {
+				return matchesOf(found), nil
+			}
+			if result.err != nil {
+				cancel()
+				trace.finish(policies, "stopped after an error")
+				return nil, result.err
+			}
+			settled[result.index] = true
+			if result.found {
+				found = append(found, result)
+				if a.firstMatch && result.index < first {
+					first = result.index
+				}
+			}
+			if a.firstMatch && decided() {
+				cancel()
+				trace.finish(policies, "stopped at first match")
+				return firstMatch(), nil
+			}
+		case <-deadline:
 			cancel()
-			return []models.PolicyMatch{result.match}, nil
+			var slow []string
+			onlyModels := true
+			running.Range(func(name, usesModel any) bool {
+				slow = append(slow, name.(string))
+				onlyModels = onlyModels && usesModel.(bool)
+				metrics.PolicyTimeoutsTotal.WithLabelValues(name.(string)).Inc()
+				return true
+			})
+			sort.Strings(slow)
+			if trace != nil {
+				for _, p := range policies {
+					if _, ok := running.Load(p.Name); !ok {
+						continue
+					}
+					if a.modelBreaker != nil && onlyModels {
+						trace.record(p, TraceSkipped, "model provider timed out", a.evalTimeout, nil)
+					} else {
+						trace.record(p, TraceError, "", a.evalTimeout, ErrEvaluationTimeout)
+					}
+				}
+				trace.finish(policies, "evaluation timed out")
+			}
+			// A hung provider degrades the evaluation: every other policy has finished,
+			// so the result stands without the model verdicts
+			if a.modelBreaker != nil && len(slow) > 0 && onlyModels {
+				a.modelBreaker.Record(ErrEvaluationTimeout)
+				markDegraded(ctx)
+				return matchesOf(found), nil
+			}
+			return nil, fmt.Errorf("%w after %v (still running: %s)", ErrEvaluationTimeout, a.evalTimeout, strings.Join(slow, ", "))
 		}
 	}
-
-	return []models.PolicyMatch{}, nil
 }
 
 // checkPolicyMatch checks if a single policy matches the content
 // This is a helper method to make the main Analyze function cleaner
-func (a *Analyzer) checkPolicyMatch(ctx context.Context, policy models.Policy, content string) (matched bool, pattern string, err error) {
+// scan is content plus any de-obfuscated variants, used by pattern matchers
+func (a *Analyzer) checkPolicyMatch(ctx context.Context, policy models.Policy, content, scan string) (matched bool, pattern string, err error) {
+	// Composite policies are timed through the checks they combine
+	if policy.PatternType != "composite" {
+		defer observeMatcher(matcherClass(policy.PatternType), time.Now())
+	}
+	ctx, span := tracing.Start(ctx, "analyzer.policy",
+		attribute.String("policy.name", policy.Name),
+		attribute.String("policy.pattern_type", policy.PatternType),
+	)
+	defer func() {
+		span.SetAttributes(attribute.Bool("policy.matched", matched))
+		tracing.End(span, err)
+	}()
+
 	// Check what type of pattern this policy uses
 	switch policy.PatternType {
 	case "regex":
-		return a.matchRegex(policy.PatternValue, content)
+		if len(policy.CaptureConstraints) > 0 {
+			return a.matchConstrainedRegex(policy, scan)
+		}
+		return a.matchRegex(policy.PatternValue, scan)
 	case "keyword":
-		isMatch, matchedText := a.matchKeyword(policy.PatternValue, content)
+		if policy.Stem {
+			isMatch, matchedText := matchStemmedKeyword(policy.PatternValue, scan)
+			return isMatch, matchedText, nil
+		}
+		isMatch, matchedText := a.matchKeyword(policy.PatternValue, policy.MatchMode, scan)
 		return isMatch, matchedText, nil
+	case "dictionary":
+		return a.matchDictionary(policy.PatternValue, scan)
 	case "profanity":
-		return a.matchProfanity(content)
+		return a.matchProfanity(policy.PatternValue, content)
 	case "model":
+		if !a.flags.Enabled(ctx, flags.ModelDetection) {
+			return false, "", nil
+		}
 		return a.matchModel(ctx, policy.PatternValue, content)
+	case "max_tokens":
+		return a.matchMaxTokens(policy.PatternValue, content)
+	case "contextual_number":
+		return a.matchContextualNumber(policy.PatternValue, content)
+	case "role_impersonation":
+		return a.matchRoleImpersonation(scan)
+	case "url_exfiltration":
+		return a.matchExfiltrationURL(content)
+	case "composite":
+		return a.matchComposite(ctx, policy.PatternValue, content, scan)
 	default:
 		return false, "", fmt.Errorf("unknown pattern type: %s", policy.PatternType)
 	}
@@ -141,6 +527,12 @@ func (a *Analyzer) checkPolicyMatch(ctx context.Context, policy models.Policy, c
 
 // getCompiledPattern returns a cached compiled regex or compiles and caches it
 func (a *Analyzer) getCompiledPattern(pattern string) (*regexp.Regexp, error) {
+	if a.patternSource != nil {
+		if re, ok := a.patternSource.Pattern(pattern); ok {
+			return re, nil
+		}
+	}
+
 	// Try to read from cache first (read lock allows multiple concurrent readers)
 	a.mu.RLock()
 	re, exists := a.patternCache[pattern]
@@ -181,8 +573,16 @@ func (a *Analyzer) matchRegex(pattern, content string) (bool, string, error) {
 	return false, "", nil
 }
 
-// matchKeyword checks if content contains a keyword (case-insensitive)
-func (a *Analyzer) matchKeyword(keyword, content string) (bool, string) {
+// matchKeyword checks if content contains a keyword (case-insensitive), as a
+// whole word or at a word's start when mode asks for it
+func (a *Analyzer) matchKeyword(keyword, mode, content string) (bool, string) {
+	if WordBounded(mode) {
+		if len(a.findKeyword(keyword, mode, content, 1)) > 0 {
+			return true, keyword
+		}
+		return false, ""
+	}
+
 	// Convert both to lowercase for case-insensitive matching
 	lowerContent := strings.ToLower(content)
 	lowerKeyword := strings.ToLower(keyword)
@@ -194,21 +594,86 @@ func (a *Analyzer) matchKeyword(keyword, content string) (bool, string) {
 	return false, ""
 }
 
-// matchProfanity checks if content contains profanity using go-away library
-func (a *Analyzer) matchProfanity(content string) (bool, string, error) {
-	if a.profanityDet.IsProfane(content) {
-		return true, "profanity detected", nil
+// matchDictionary checks content against a wordlist (case-insensitive) and
+// returns the first term found
+func (a *Analyzer) matchDictionary(name, content string) (bool, string, error) {
+	matcher, err := a.dictionary(name)
+	if err != nil {
+		return false, "", err
+	}
+	if match, ok := matcher.FindFirst(content); ok {
+		return true, matcher.Terms()[match.Term], nil
 	}
 	return false, "", nil
 }
 
+// dictionary resolves a wordlist matcher by name
+func (a *Analyzer) dictionary(name string) (*ahocorasick.Matcher, error) {
+	if a.dictionaries == nil {
+		return nil, errors.New("wordlists not configured")
+	}
+	matcher, ok := a.dictionaries.Matcher(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown wordlist: %s", name)
+	}
+	return matcher, nil
+}
+
+// redactSpans replaces every (possibly overlapping) match with [REDACTED]
+func redactSpans(content string, matches []ahocorasick.Match) string {
+	if len(matches) == 0 {
+		return content
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Start < matches[j].Start })
+
+	var b strings.Builder
+	last := 0
+	for _, m := range matches {
+		if m.End <= last {
+			continue
+		}
+		if m.Start >= last {
+			b.WriteString(content[last:m.Start])
+			b.WriteString("[REDACTED]")
+		}
+		last = m.End
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
+
 func (a *Analyzer) matchModel(ctx context.Context, modelIdentifier, content string) (bool, string, error) {
 	if a.modelClient == nil {
 		return false, "", errors.New("model client not configured")
 	}
 
-	evaluation, err := a.modelClient.Evaluate(ctx, modelIdentifier, content)
+	// The language is detected on the content as submitted, before minimization
+	model, language := a.routeModel(modelIdentifier, content)
+	ctx, content, allowed := a.prepareModelCall(ctx, content)
+	if !allowed {
+		return false, "", nil
+	}
+	if err := a.modelBreaker.Allow(); err != nil {
+		markDegraded(ctx)
+		return false, "", nil
+	}
+
+	modelCtx, span := tracing.Start(ctx, "analyzer.model",
+		attribute.String("model.id", modelIdentifier),
+		attribute.String("model.routed_id", model),
+		attribute.String("content.language", language))
+	evaluation, err := a.modelClient.Evaluate(modelCtx, model, content)
+	tracing.End(span, err)
+	if ctx.Err() != nil {
+		// Cancelled by a sibling match or the deadline; not a verdict on the provider
+		return false, "", ctx.Err()
+	}
+	a.modelBreaker.Record(err)
 	if err != nil {
+		if a.modelBreaker != nil {
+			markDegraded(ctx)
+			return false, "", nil
+		}
 		return false, "", err
 	}
 
@@ -219,6 +684,12 @@ func (a *Analyzer) matchModel(ctx context.Context, modelIdentifier, content stri
 	return false, "", nil
 }
 
+// KeywordPattern is the case-insensitive regex source used to redact a keyword
+// policy; precompiled matchers are keyed by it
+func KeywordPattern(keyword string) string {
+	return "(?i)" + regexp.QuoteMeta(keyword)
+}
+
 // RedactContent redacts matched patterns from content
 // Used when policy action is "redact"
 func (a *Analyzer) RedactContent(content string, matches []models.PolicyMatch, policies []models.Policy) string {
@@ -233,25 +704,55 @@ func (a *Analyzer) RedactContent(content string, matches []models.PolicyMatch, p
 	// Redact each match
 	for _, match := range matches {
 		policy, exists := policyMap[match.PolicyID.String()]
-		if !exists || policy.Action != "redact" {
+		if !exists || EffectiveAction(policy.Action, match) != "redact" {
 			continue
 		}
+		redacted = a.redactPolicy(redacted, policy)
+	}
 
-		// Replace matched pattern with [REDACTED]
-		if policy.PatternType == "regex" {
-			re, err := a.getCompiledPattern(policy.PatternValue)
-			if err == nil {
-				redacted = re.ReplaceAllString(redacted, "[REDACTED]")
-			}
-		} else if policy.PatternType == "keyword" {
-			// Case-insensitive keyword replacement
-			re := regexp.MustCompile("(?i)" + regexp.QuoteMeta(policy.PatternValue))
+	return redacted
+}
+
+// redactPolicy replaces what one policy matches with [REDACTED]
+func (a *Analyzer) redactPolicy(redacted string, policy models.Policy) string {
+	// Replace matched pattern with [REDACTED]
+	if policy.PatternType == "regex" && len(policy.CaptureConstraints) > 0 {
+		// Only matches whose captures satisfy the constraints are redacted
+		if re, err := a.getCompiledPattern(policy.PatternValue); err == nil {
+			redacted = redactSpans(redacted, constrainedMatches(re, redacted, policy.CaptureConstraints, 0))
+		}
+	} else if policy.PatternType == "regex" {
+		re, err := a.getCompiledPattern(policy.PatternValue)
+		if err == nil {
+			redacted = re.ReplaceAllString(redacted, "[REDACTED]")
+		}
+	} else if policy.PatternType == "keyword" && policy.Stem {
+		redacted = redactSpans(redacted, findStemmed(policy.PatternValue, redacted))
+	} else if policy.PatternType == "keyword" && WordBounded(policy.MatchMode) {
+		// Occurrences inside other words are left alone
+		redacted = redactSpans(redacted, a.findKeyword(policy.PatternValue, policy.MatchMode, redacted, 0))
+	} else if policy.PatternType == "keyword" {
+		// Case-insensitive keyword replacement
+		re, err := a.getCompiledPattern(KeywordPattern(policy.PatternValue))
+		if err == nil {
 			redacted = re.ReplaceAllString(redacted, "[REDACTED]")
-		} else if policy.PatternType == "profanity" {
-			// Censor profanity using go-away
-			redacted = a.profanityDet.Censor(redacted)
+		}
+	} else if policy.PatternType == "dictionary" {
+		if matcher, err := a.dictionary(policy.PatternValue); err == nil {
+			redacted = redactSpans(redacted, matcher.FindAll(redacted))
+		}
+	} else if policy.PatternType == "contextual_number" {
+		redacted = redactContextualNumbers(policy.PatternValue, redacted)
+	} else if policy.PatternType == "profanity" {
+		// Censor profanity using go-away
+		redacted = a.censorProfanity(policy.PatternValue, redacted)
+	} else if policy.PatternType == "composite" {
+		// Redact what every non-negated check of the expression finds
+		if cond, err := condition(policy.PatternValue); err == nil {
+			for _, m := range cond.Matches() {
+				redacted = a.redactPolicy(redacted, conditionPolicy(m))
+			}
 		}
 	}
-
 	return redacted
 }
@@ -0,0 +1,159 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/prompt-gateway/internal/ahocorasick"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// ValidateCaptureConstraints checks that every constraint names a group of
+// pattern and sets at least one valid bound
+func ValidateCaptureConstraints(pattern string, constraints []models.CaptureConstraint) error {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	for _, c := range constraints {
+		if groupIndex(re, c.Group) < 0 {
+			return fmt.Errorf("capture group %q does not exist in pattern", c.Group)
+		}
+		if c.Min == nil && c.Max == nil && c.MinLength == 0 && c.MaxLength == 0 && c.Checksum == "" {
+			return fmt.Errorf("capture group %q has no constraint", c.Group)
+		}
+		if c.Min != nil && c.Max != nil && *c.Min > *c.Max {
+			return fmt.Errorf("capture group %q: min is greater than max", c.Group)
+		}
+		if c.MinLength < 0 || c.MaxLength < 0 || (c.MaxLength > 0 && c.MinLength > c.MaxLength) {
+			return fmt.Errorf("capture group %q: invalid length bounds", c.Group)
+		}
+		if c.Checksum != "" && c.Checksum != "luhn" {
+			return fmt.Errorf("capture group %q: checksum must be luhn", c.Group)
+		}
+	}
+	return nil
+}
+
+// groupIndex resolves a group name or number to its submatch index (-1 if absent)
+func groupIndex(re *regexp.Regexp, group string) int {
+	if n, err := strconv.Atoi(group); err == nil {
+		if n >= 1 && n <= re.NumSubexp() {
+			return n
+		}
+		return -1
+	}
+	if group == "" {
+		return -1
+	}
+	return re.SubexpIndex(group)
+}
+
+// constrainedMatches returns the spans of the regex matches whose captures
+// satisfy every constraint
+func constrainedMatches(re *regexp.Regexp, content string, constraints []models.CaptureConstraint, limit int) []ahocorasick.Match {
+	var out []ahocorasick.Match
+	for _, loc := range re.FindAllStringSubmatchIndex(content, -1) {
+		if capturesHold(re, content, loc, constraints) {
+			out = append(out, ahocorasick.Match{Start: loc[0], End: loc[1]})
+			if limit > 0 && len(out) == limit {
+				break
+			}
+		}
+	}
+	return out
+}
+
+// capturesHold reports whether one match satisfies every constraint; a
+// constrained group that didn't participate in the match fails
+func capturesHold(re *regexp.Regexp, content string, loc []int, constraints []models.CaptureConstraint) bool {
+	for _, c := range constraints {
+		i := groupIndex(re, c.Group)
+		if i < 0 || loc[2*i] < 0 {
+			return false
+		}
+		if !captureHolds(content[loc[2*i]:loc[2*i+1]], c) {
+			return false
+		}
+	}
+	return true
+}
+
+// captureHolds checks one captured value against one constraint
+func captureHolds(value string, c models.CaptureConstraint) bool {
+	length := utf8.RuneCountInString(value)
+	if length < c.MinLength || (c.MaxLength > 0 && length > c.MaxLength) {
+		return false
+	}
+	if c.Min != nil || c.Max != nil {
+		n, ok := parseNumber(value)
+		if !ok || (c.Min != nil && n < *c.Min) || (c.Max != nil && n > *c.Max) {
+			return false
+		}
+	}
+	if c.Checksum == "luhn" && !luhnValid(value) {
+		return false
+	}
+	return true
+}
+
+// parseNumber parses a captured amount, ignoring currency symbols, spaces and
+// thousands separators ("$12,500.00" → 12500)
+func parseNumber(value string) (float64, bool) {
+	cleaned := strings.Map(func(r rune) rune {
+		if r == ',' || r == '_' || unicode.IsSpace(r) || unicode.Is(unicode.Sc, r) {
+			return -1
+		}
+		return r
+	}, value)
+	n, err := strconv.ParseFloat(cleaned, 64)
+	return n, err == nil
+}
+
+// luhnValid reports whether the digits of value (spaces and dashes ignored)
+// pass the Luhn checksum used by card numbers
+func luhnValid(value string) bool {
+	var digits []int
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			digits = append(digits, int(r-'0'))
+		case r == ' ' || r == '-':
+		default:
+			return false
+		}
+	}
+	if len(digits) < 2 {
+		return false
+	}
+	sum := 0
+	for i := range digits {
+		d := digits[len(digits)-1-i]
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// matchConstrainedRegex finds the first regex match whose captures satisfy the
+// policy's constraints
+func (a *Analyzer) matchConstrainedRegex(policy models.Policy, content string) (bool, string, error) {
+	re, err := a.getCompiledPattern(policy.PatternValue)
+	if err != nil {
+		return false, "", err
+	}
+	matches := constrainedMatches(re, content, policy.CaptureConstraints, 1)
+	if len(matches) == 0 {
+		return false, "", nil
+	}
+	return true, content[matches[0].Start:matches[0].End], nil
+}
@@ -0,0 +1,89 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestAnalyzer_CaptureConstraints(t *testing.T) {
+	minAmount := 10000.0
+	transfer := models.Policy{
+		ID:                 uuid.New(),
+		Name:               "large-transfer",
+		PatternType:        "regex",
+		PatternValue:       `(?i)transfer \$?(?P<amount>[\d,]+(?:\.\d+)?)`,
+		Action:             "redact",
+		Enabled:            true,
+		CaptureConstraints: []models.CaptureConstraint{{Group: "amount", Min: &minAmount}},
+	}
+	card := models.Policy{
+		ID:                 uuid.New(),
+		Name:               "card",
+		PatternType:        "regex",
+		PatternValue:       `\b(\d{4}[ -]?\d{4}[ -]?\d{4}[ -]?\d{4})\b`,
+		Enabled:            true,
+		CaptureConstraints: []models.CaptureConstraint{{Group: "1", Checksum: "luhn"}},
+	}
+
+	tests := []struct {
+		name        string
+		policy      models.Policy
+		content     string
+		wantPattern string
+	}{
+		{"amount below range", transfer, "please transfer $9,500 today", ""},
+		{"amount above range", transfer, "please transfer $12,500.00 today", "transfer $12,500.00"},
+		{"later match satisfies", transfer, "transfer 50 then transfer 20000", "transfer 20000"},
+		{"valid card", card, "card 4111 1111 1111 1111", "4111 1111 1111 1111"},
+		{"invalid checksum", card, "order 1234 5678 9012 3456", ""},
+	}
+	a := NewAnalyzer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := a.Analyze(context.Background(), tt.content, []models.Policy{tt.policy})
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			got := ""
+			if len(matches) > 0 {
+				got = matches[0].MatchedPattern
+			}
+			if got != tt.wantPattern {
+				t.Errorf("matched %q, want %q", got, tt.wantPattern)
+			}
+		})
+	}
+
+	content := "transfer $500 and transfer $50,000"
+	matches, _ := a.Analyze(context.Background(), content, []models.Policy{transfer})
+	if got := a.RedactContent(content, matches, []models.Policy{transfer}); got != "transfer $500 and [REDACTED]" {
+		t.Errorf("RedactContent() = %q, want only the large transfer redacted", got)
+	}
+}
+
+func TestValidateCaptureConstraints(t *testing.T) {
+	lo, hi := 10.0, 5.0
+	tests := []struct {
+		name       string
+		constraint models.CaptureConstraint
+		wantErr    bool
+	}{
+		{"named group", models.CaptureConstraint{Group: "amount", MinLength: 2}, false},
+		{"numbered group", models.CaptureConstraint{Group: "1", Checksum: "luhn"}, false},
+		{"missing group", models.CaptureConstraint{Group: "2", MinLength: 1}, true},
+		{"no bound", models.CaptureConstraint{Group: "amount"}, true},
+		{"inverted range", models.CaptureConstraint{Group: "amount", Min: &lo, Max: &hi}, true},
+		{"unknown checksum", models.CaptureConstraint{Group: "amount", Checksum: "crc"}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateCaptureConstraints(`(?P<amount>\d+)`, []models.CaptureConstraint{tt.constraint})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCaptureConstraints() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
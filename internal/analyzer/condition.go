@@ -0,0 +1,342 @@
+package analyzer
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Condition is a parsed "composite" policy expression: pattern checks combined
+// with AND, OR, NOT and parentheses, e.g.
+//
+//	regex:"\b\d{3}-\d{2}-\d{4}\b" AND keyword:"salary" AND NOT dictionary:"hr-allowlist"
+//
+// Every check sees the same content, so a conjunction holds within one message
+type Condition struct {
+	Op       string       // "and", "or", "not" or "match"
+	Operands []*Condition // Sub-conditions of and/or/not
+	Type     string       // Pattern type of a match
+	Value    string       // Pattern value of a match
+}
+
+// conditionTypes lists the pattern types usable in a condition and whether they take a value
+var conditionTypes = map[string]bool{
+	"regex":              true,
+	"keyword":            true,
+	"dictionary":         true,
+	"contextual_number":  true,
+	"model":              true,
+	"profanity":          false,
+	"role_impersonation": false,
+	"url_exfiltration":   false,
+}
+
+// maxConditionDepth bounds nesting so hostile expressions can't exhaust the stack
+const maxConditionDepth = 32
+
+// ParseCondition parses a composite policy expression
+// Operators are case-insensitive; NOT binds tighter than AND, which binds tighter than OR
+func ParseCondition(expr string) (*Condition, error) {
+	tokens, err := tokenizeCondition(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &conditionParser{tokens: tokens}
+	cond, err := p.parseOr(0)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q at position %d", p.tokens[p.pos].text, p.tokens[p.pos].offset)
+	}
+	return cond, nil
+}
+
+// conditionToken is one lexical element of an expression
+type conditionToken struct {
+	kind   string // "word", "string", "(", ")" or ":"
+	text   string
+	offset int
+}
+
+// tokenizeCondition splits an expression into words, quoted strings and punctuation
+func tokenizeCondition(expr string) ([]conditionToken, error) {
+	var tokens []conditionToken
+	for i := 0; i < len(expr); {
+		c := expr[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '(' || c == ')' || c == ':':
+			tokens = append(tokens, conditionToken{kind: string(c), text: string(c), offset: i})
+			i++
+		case c == '"':
+			var b strings.Builder
+			start := i
+			i++
+			for {
+				if i >= len(expr) {
+					return nil, fmt.Errorf("unterminated string at position %d", start)
+				}
+				if expr[i] == '\\' && i+1 < len(expr) && (expr[i+1] == '"' || expr[i+1] == '\\') {
+					b.WriteByte(expr[i+1])
+					i += 2
+					continue
+				}
+				if expr[i] == '"' {
+					i++
+					break
+				}
+				b.WriteByte(expr[i])
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: "string", text: b.String(), offset: start})
+		case c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z'):
+			start := i
+			for i < len(expr) && (expr[i] == '_' || (expr[i] >= 'a' && expr[i] <= 'z') || (expr[i] >= 'A' && expr[i] <= 'Z')) {
+				i++
+			}
+			tokens = append(tokens, conditionToken{kind: "word", text: expr[start:i], offset: start})
+		default:
+			return nil, fmt.Errorf("unexpected character %q at position %d", c, i)
+		}
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty condition")
+	}
+	return tokens, nil
+}
+
+// conditionParser is a recursive-descent parser over condition tokens
+type conditionParser struct {
+	tokens []conditionToken
+	pos    int
+}
+
+// peekWord reports whether the next token is the given operator word
+func (p *conditionParser) peekWord(word string) bool {
+	return p.pos < len(p.tokens) && p.tokens[p.pos].kind == "word" && strings.EqualFold(p.tokens[p.pos].text, word)
+}
+
+// parseOr parses: and ("OR" and)*
+func (p *conditionParser) parseOr(depth int) (*Condition, error) {
+	return p.parseBinary(depth, "or", p.parseAnd)
+}
+
+// parseAnd parses: unary ("AND" unary)*
+func (p *conditionParser) parseAnd(depth int) (*Condition, error) {
+	return p.parseBinary(depth, "and", p.parseUnary)
+}
+
+// parseBinary parses operands joined by op into one n-ary node
+func (p *conditionParser) parseBinary(depth int, op string, operand func(int) (*Condition, error)) (*Condition, error) {
+	first, err := operand(depth)
+	if err != nil {
+		return nil, err
+	}
+	operands := []*Condition{first}
+	for p.peekWord(op) {
+		p.pos++
+		next, err := operand(depth)
+		if err != nil {
+			return nil, err
+		}
+		operands = append(operands, next)
+	}
+	if len(operands) == 1 {
+		return first, nil
+	}
+	return &Condition{Op: op, Operands: operands}, nil
+}
+
+// parseUnary parses: "NOT" unary | "(" or ")" | match
+func (p *conditionParser) parseUnary(depth int) (*Condition, error) {
+	if depth > maxConditionDepth {
+		return nil, fmt.Errorf("condition nested deeper than %d levels", maxConditionDepth)
+	}
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of condition")
+	}
+	tok := p.tokens[p.pos]
+	switch {
+	case p.peekWord("not"):
+		p.pos++
+		operand, err := p.parseUnary(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		return &Condition{Op: "not", Operands: []*Condition{operand}}, nil
+	case tok.kind == "(":
+		p.pos++
+		cond, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if p.pos >= len(p.tokens) || p.tokens[p.pos].kind != ")" {
+			return nil, fmt.Errorf("missing ) for ( at position %d", tok.offset)
+		}
+		p.pos++
+		return cond, nil
+	case tok.kind == "word":
+		return p.parseMatch()
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.offset)
+	}
+}
+
+// parseMatch parses: type [":" string]
+func (p *conditionParser) parseMatch() (*Condition, error) {
+	tok := p.tokens[p.pos]
+	patternType := strings.ToLower(tok.text)
+	needsValue, ok := conditionTypes[patternType]
+	if !ok {
+		return nil, fmt.Errorf("unknown pattern type %q at position %d", tok.text, tok.offset)
+	}
+	p.pos++
+
+	cond := &Condition{Op: "match", Type: patternType}
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == ":" {
+		if p.pos+1 >= len(p.tokens) || p.tokens[p.pos+1].kind != "string" {
+			return nil, fmt.Errorf("expected quoted value after %s: at position %d", patternType, p.tokens[p.pos].offset)
+		}
+		cond.Value = p.tokens[p.pos+1].text
+		p.pos += 2
+	}
+	if needsValue && cond.Value == "" {
+		return nil, fmt.Errorf("%s at position %d needs a quoted value", patternType, tok.offset)
+	}
+	if !needsValue && cond.Value != "" {
+		return nil, fmt.Errorf("%s at position %d takes no value", patternType, tok.offset)
+	}
+	if patternType == "regex" {
+		if _, err := regexp.Compile(cond.Value); err != nil {
+			return nil, fmt.Errorf("invalid regex at position %d: %w", tok.offset, err)
+		}
+	}
+	return cond, nil
+}
+
+// Matches returns the non-negated match conditions, which are what a
+// satisfied condition can have found in the content (used for redaction)
+func (c *Condition) Matches() []*Condition {
+	var out []*Condition
+	var walk func(c *Condition, negated bool)
+	walk = func(c *Condition, negated bool) {
+		switch c.Op {
+		case "match":
+			if !negated {
+				out = append(out, c)
+			}
+		case "not":
+			walk(c.Operands[0], !negated)
+		default:
+			for _, operand := range c.Operands {
+				walk(operand, negated)
+			}
+		}
+	}
+	walk(c, false)
+	return out
+}
+
+// UsesModel reports whether evaluating a policy calls a content-safety model,
+// directly or from any check of a composite expression
+func UsesModel(p models.Policy) bool {
+	if p.PatternType == "model" {
+		return true
+	}
+	if p.PatternType != "composite" {
+		return false
+	}
+	cond, err := condition(p.PatternValue)
+	return err == nil && cond.usesType("model")
+}
+
+// usesType reports whether any check of the condition has the given pattern type
+func (c *Condition) usesType(patternType string) bool {
+	if c.Op == "match" {
+		return c.Type == patternType
+	}
+	for _, operand := range c.Operands {
+		if operand.usesType(patternType) {
+			return true
+		}
+	}
+	return false
+}
+
+// conditionPolicy is the single-pattern policy a match condition checks
+func conditionPolicy(c *Condition) models.Policy {
+	return models.Policy{PatternType: c.Type, PatternValue: c.Value, Enabled: true}
+}
+
+// conditionCache memoizes parsed composite expressions by source
+var conditionCache sync.Map // string → *Condition
+
+// condition returns the parsed expression of a composite policy
+func condition(expr string) (*Condition, error) {
+	if cached, ok := conditionCache.Load(expr); ok {
+		return cached.(*Condition), nil
+	}
+	cond, err := ParseCondition(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid condition: %w", err)
+	}
+	conditionCache.Store(expr, cond)
+	return cond, nil
+}
+
+// matchComposite evaluates a composite policy; the matched text lists what the
+// positive checks found, joined with " + "
+func (a *Analyzer) matchComposite(ctx context.Context, expr, content, scan string) (bool, string, error) {
+	cond, err := condition(expr)
+	if err != nil {
+		return false, "", err
+	}
+	matched, found, err := a.evalCondition(ctx, cond, content, scan)
+	if err != nil || !matched {
+		return false, "", err
+	}
+	return true, strings.Join(found, " + "), nil
+}
+
+// evalCondition evaluates a condition with short-circuiting, returning the
+// matched text of the checks that made it true
+func (a *Analyzer) evalCondition(ctx context.Context, c *Condition, content, scan string) (bool, []string, error) {
+	switch c.Op {
+	case "match":
+		matched, text, err := a.checkPolicyMatch(ctx, conditionPolicy(c), content, scan)
+		if err != nil || !matched {
+			return false, nil, err
+		}
+		return true, []string{text}, nil
+	case "not":
+		matched, _, err := a.evalCondition(ctx, c.Operands[0], content, scan)
+		return !matched && err == nil, nil, err
+	case "and":
+		var found []string
+		for _, operand := range c.Operands {
+			matched, text, err := a.evalCondition(ctx, operand, content, scan)
+			if err != nil || !matched {
+				return false, nil, err
+			}
+			found = append(found, text...)
+		}
+		return true, found, nil
+	default: // "or"
+		for _, operand := range c.Operands {
+			matched, text, err := a.evalCondition(ctx, operand, content, scan)
+			if err != nil {
+				return false, nil, err
+			}
+			if matched {
+				return true, text, nil
+			}
+		}
+		return false, nil, nil
+	}
+}
@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/ahocorasick"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestParseCondition(t *testing.T) {
+	valid := []string{
+		`regex:"\d{3}-\d{2}-\d{4}" AND keyword:"salary"`,
+		`regex:"a" and not (dictionary:"allow" or keyword:"say \"hi\"")`,
+		`profanity OR role_impersonation`,
+		`NOT NOT model:"safety"`,
+	}
+	for _, expr := range valid {
+		if _, err := ParseCondition(expr); err != nil {
+			t.Errorf("ParseCondition(%q) error = %v", expr, err)
+		}
+	}
+
+	invalid := []string{
+		``,
+		`keyword:"a" AND`,
+		`(keyword:"a"`,
+		`keyword:"a" keyword:"b"`,
+		`keyword`,
+		`profanity:"x"`,
+		`composite:"x"`,
+		`regex:"("`,
+		`keyword:"unterminated`,
+		`keyword:"a" & keyword:"b"`,
+	}
+	for _, expr := range invalid {
+		if _, err := ParseCondition(expr); err == nil {
+			t.Errorf("ParseCondition(%q) error = nil, want error", expr)
+		}
+	}
+}
+
+func TestAnalyzer_Composite(t *testing.T) {
+	a := NewAnalyzer(nil)
+	a.SetDictionaries(stubDictionaries{"hr-allowlist": ahocorasick.New([]string{"benefits enrollment"})})
+	policy := models.Policy{
+		ID:           uuid.New(),
+		Name:         "ssn-with-salary",
+		PatternType:  "composite",
+		PatternValue: `regex:"\d{3}-\d{2}-\d{4}" AND (keyword:"salary" OR keyword:"payroll") AND NOT dictionary:"hr-allowlist"`,
+		Action:       "redact",
+		Enabled:      true,
+	}
+
+	tests := []struct {
+		name        string
+		content     string
+		wantPattern string
+	}{
+		{"all conditions hold", "salary for 123-45-6789 is attached", "123-45-6789 + salary"},
+		{"OR branch", "payroll record 123-45-6789", "123-45-6789 + payroll"},
+		{"missing conjunct", "ssn 123-45-6789 only", ""},
+		{"allowlisted", "benefits enrollment: salary 123-45-6789", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := a.Analyze(context.Background(), tt.content, []models.Policy{policy})
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			got := ""
+			if len(matches) > 0 {
+				got = matches[0].MatchedPattern
+			}
+			if got != tt.wantPattern {
+				t.Errorf("matched %q, want %q", got, tt.wantPattern)
+			}
+		})
+	}
+
+	matches, _ := a.Analyze(context.Background(), "salary 123-45-6789", []models.Policy{policy})
+	if redacted := a.RedactContent("salary 123-45-6789", matches, []models.Policy{policy}); redacted != "[REDACTED] [REDACTED]" {
+		t.Errorf("RedactContent() = %q, want both positive checks redacted", redacted)
+	}
+}
+
+func TestUsesModel(t *testing.T) {
+	if !UsesModel(models.Policy{PatternType: "composite", PatternValue: `keyword:"x" AND NOT model:"safety"`}) {
+		t.Error("UsesModel(composite with model check) = false, want true")
+	}
+	if UsesModel(models.Policy{PatternType: "composite", PatternValue: `keyword:"x" OR regex:"y"`}) {
+		t.Error("UsesModel(composite without model check) = true, want false")
+	}
+}
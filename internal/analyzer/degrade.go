@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// EvaluationDegraded is the evaluation mode reported when model policies were
+// skipped because their provider is unavailable
+const EvaluationDegraded = "degraded"
+
+// degradedKey carries the per-request flag set when model policies are skipped
+type degradedKey struct{}
+
+// SetModelBreaker enables degraded evaluation: while the breaker is open, or when
+// a provider call fails, model policies are skipped (treated as not matching)
+// instead of failing the whole evaluation. Trial calls after the cooldown close
+// the breaker once the provider recovers
+// Must be called before the analyzer is used
+func (a *Analyzer) SetModelBreaker(b *breaker.Breaker) {
+	a.modelBreaker = b
+}
+
+// WithDegradation returns a context in which Analyze records skipped model
+// policies, reported afterwards by Degraded
+func WithDegradation(ctx context.Context) context.Context {
+	return context.WithValue(ctx, degradedKey{}, new(atomic.Bool))
+}
+
+// Degraded reports whether any Analyze call made with ctx skipped model policies
+func Degraded(ctx context.Context) bool {
+	flag, ok := ctx.Value(degradedKey{}).(*atomic.Bool)
+	return ok && flag.Load()
+}
+
+// markDegraded records a skipped model policy on the request context
+func markDegraded(ctx context.Context) {
+	if flag, ok := ctx.Value(degradedKey{}).(*atomic.Bool); ok {
+		flag.Store(true)
+	}
+	noteTrace(ctx, "model provider unavailable")
+	metrics.ModelPoliciesSkippedTotal.Inc()
+}
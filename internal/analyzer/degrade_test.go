@@ -0,0 +1,83 @@
+package analyzer
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// countingModelClient fails while down and counts provider calls
+type countingModelClient struct {
+	down  bool
+	calls int
+}
+
+func (c *countingModelClient) Evaluate(ctx context.Context, model string, content string) (ModelEvaluation, error) {
+	c.calls++
+	if c.down {
+		return ModelEvaluation{}, errors.New("provider unavailable")
+	}
+	return ModelEvaluation{Triggered: true, Detail: "unsafe"}, nil
+}
+
+func TestAnalyzer_ModelDegradation(t *testing.T) {
+	client := &countingModelClient{down: true}
+	a := NewAnalyzer(client)
+	a.SetModelBreaker(breaker.New("model_test", 2, 50*time.Millisecond))
+	policies := []models.Policy{
+		{ID: uuid.New(), Name: "safety", PatternType: "model", PatternValue: "m", Enabled: true},
+		{ID: uuid.New(), Name: "secret", PatternType: "keyword", PatternValue: "password", Enabled: true},
+	}
+
+	analyze := func(content string) ([]models.PolicyMatch, bool) {
+		t.Helper()
+		ctx := WithDegradation(context.Background())
+		matches, err := a.Analyze(ctx, content, policies)
+		if err != nil {
+			t.Fatalf("Analyze() error = %v, want degraded result", err)
+		}
+		return matches, Degraded(ctx)
+	}
+
+	// Failures skip the model policy; pattern policies still decide
+	for i := 0; i < 2; i++ {
+		if matches, degraded := analyze("hello"); len(matches) != 0 || !degraded {
+			t.Errorf("Analyze() = %v, degraded %v, want no matches, degraded", matches, degraded)
+		}
+	}
+	if matches, _ := analyze("my password"); len(matches) != 1 || matches[0].PolicyName != "secret" {
+		t.Errorf("Analyze() = %v, want keyword match while degraded", matches)
+	}
+
+	// Open breaker: the provider isn't called at all
+	calls := client.calls
+	analyze("hello")
+	if client.calls != calls {
+		t.Errorf("provider called %d times while breaker open", client.calls-calls)
+	}
+
+	// Provider back: the trial call after the cooldown restores full evaluation
+	client.down = false
+	time.Sleep(60 * time.Millisecond)
+	if matches, degraded := analyze("hello"); len(matches) != 1 || degraded {
+		t.Errorf("Analyze() = %v, degraded %v, want model match after recovery", matches, degraded)
+	}
+}
+
+func TestAnalyzer_ModelDegradationOnTimeout(t *testing.T) {
+	a := NewAnalyzer(slowModelClient{})
+	a.SetEvaluationTimeout(20 * time.Millisecond)
+	a.SetModelBreaker(breaker.New("model_timeout_test", 5, time.Minute))
+	policies := []models.Policy{{ID: uuid.New(), Name: "semantic", PatternType: "model", PatternValue: "m", Enabled: true}}
+
+	ctx := WithDegradation(context.Background())
+	matches, err := a.Analyze(ctx, "hello", policies)
+	if err != nil || len(matches) != 0 || !Degraded(ctx) {
+		t.Errorf("Analyze() = %v, %v, degraded %v, want degraded result", matches, err, Degraded(ctx))
+	}
+}
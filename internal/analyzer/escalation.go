@@ -0,0 +1,80 @@
+package analyzer
+
+import (
+	"fmt"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// actionRank orders the enforcement actions from weakest to strictest
+var actionRank = map[string]int{"log": 1, "redact": 2, "block": 3}
+
+// countable lists the pattern types whose matches can be counted for escalations
+var countable = map[string]bool{"regex": true, "keyword": true, "dictionary": true, "contextual_number": true}
+
+// ValidateEscalations checks a policy's escalation thresholds: each applies at a
+// distinct match count of at least 2 and raises the severity or the action
+func ValidateEscalations(patternType, action string, escalations []models.Escalation) error {
+	if len(escalations) == 0 {
+		return nil
+	}
+	if !countable[patternType] {
+		return fmt.Errorf("escalations are only supported for regex, keyword, dictionary and contextual_number policies")
+	}
+	if action == "allow" {
+		return fmt.Errorf("allow policies cannot have escalations")
+	}
+	validSeverities := map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+	seen := make(map[int]bool, len(escalations))
+	for _, e := range escalations {
+		if e.MinMatches < 2 {
+			return fmt.Errorf("min_matches must be at least 2")
+		}
+		if seen[e.MinMatches] {
+			return fmt.Errorf("duplicate escalation for min_matches %d", e.MinMatches)
+		}
+		seen[e.MinMatches] = true
+		if e.Severity == "" && e.Action == "" {
+			return fmt.Errorf("escalation at %d matches sets neither severity nor action", e.MinMatches)
+		}
+		if e.Severity != "" && !validSeverities[e.Severity] {
+			return fmt.Errorf("escalation at %d matches: severity must be low, medium, high, or critical", e.MinMatches)
+		}
+		if e.Action != "" && actionRank[e.Action] == 0 {
+			return fmt.Errorf("escalation at %d matches: action must be log, block, or redact", e.MinMatches)
+		}
+	}
+	return nil
+}
+
+// EffectiveAction is the action a match enforces: the policy's (tier) action,
+// raised by an escalation the match reached
+func EffectiveAction(action string, match models.PolicyMatch) string {
+	if actionRank[match.Action] > actionRank[action] {
+		return match.Action
+	}
+	return action
+}
+
+// escalate applies the highest escalation threshold reached by count matches
+// of p to match
+func (a *Analyzer) escalate(p models.Policy, count int, match *models.PolicyMatch) {
+	if len(p.Escalations) == 0 {
+		return
+	}
+	match.MatchCount = max(count, 1)
+	var reached *models.Escalation
+	for i, e := range p.Escalations {
+		if e.MinMatches <= match.MatchCount && (reached == nil || e.MinMatches > reached.MinMatches) {
+			reached = &p.Escalations[i]
+		}
+	}
+	if reached == nil {
+		return
+	}
+	if reached.Severity != "" {
+		match.Severity = reached.Severity
+	}
+	// Kept even when weaker than p.Action: a tier override may still lower that
+	match.Action = reached.Action
+}
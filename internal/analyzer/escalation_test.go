@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestAnalyzer_Escalations(t *testing.T) {
+	email := models.Policy{
+		ID:           uuid.New(),
+		Name:         "email",
+		PatternType:  "regex",
+		PatternValue: `[\w.]+@[\w.]+\.\w+`,
+		Severity:     "low",
+		Action:       "log",
+		Enabled:      true,
+		Escalations: []models.Escalation{
+			{MinMatches: 3, Action: "redact"},
+			{MinMatches: 5, Severity: "critical", Action: "block"},
+		},
+	}
+	emails := func(n int) string {
+		var b strings.Builder
+		for i := range n {
+			b.WriteString("user" + string(rune('a'+i)) + "@example.com ")
+		}
+		return b.String()
+	}
+
+	tests := []struct {
+		name         string
+		content      string
+		wantCount    int
+		wantSeverity string
+		wantAction   string
+	}{
+		{"single match", emails(1), 1, "low", ""},
+		{"below first threshold", emails(2), 2, "low", ""},
+		{"first threshold", emails(4), 4, "low", "redact"},
+		{"highest threshold wins", emails(6), 6, "critical", "block"},
+	}
+	a := NewAnalyzer(nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := a.Analyze(context.Background(), tt.content, []models.Policy{email})
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			if len(matches) != 1 {
+				t.Fatalf("got %d matches, want 1", len(matches))
+			}
+			m := matches[0]
+			if m.MatchCount != tt.wantCount || m.Severity != tt.wantSeverity || m.Action != tt.wantAction {
+				t.Errorf("match = count %d, severity %q, action %q; want %d, %q, %q", m.MatchCount, m.Severity, m.Action, tt.wantCount, tt.wantSeverity, tt.wantAction)
+			}
+			want := "log"
+			if tt.wantAction != "" {
+				want = tt.wantAction
+			}
+			if got := EffectiveAction(email.Action, m); got != want {
+				t.Errorf("EffectiveAction() = %q, want %q", got, want)
+			}
+		})
+	}
+
+	// Escalations only raise the action: a block policy stays blocked
+	strict := email
+	strict.Action = "block"
+	matches, err := a.Analyze(context.Background(), emails(3), []models.Policy{strict})
+	if err != nil || len(matches) != 1 || EffectiveAction(strict.Action, matches[0]) != "block" {
+		t.Errorf("block policy matches = %+v, %v; want block", matches, err)
+	}
+
+	keyword := models.Policy{
+		ID: uuid.New(), Name: "secret", PatternType: "keyword", PatternValue: "Secret",
+		Severity: "low", Action: "log", Enabled: true,
+		Escalations: []models.Escalation{{MinMatches: 2, Severity: "high"}},
+	}
+	matches, err = a.Analyze(context.Background(), "secret, SECRET and a secret", []models.Policy{keyword})
+	if err != nil || len(matches) != 1 || matches[0].MatchCount != 3 || matches[0].Severity != "high" {
+		t.Errorf("keyword matches = %+v, %v; want 3 matches at high severity", matches, err)
+	}
+}
+
+func TestValidateEscalations(t *testing.T) {
+	tests := []struct {
+		name        string
+		patternType string
+		action      string
+		escalations []models.Escalation
+		wantErr     bool
+	}{
+		{"none", "model", "block", nil, false},
+		{"valid", "regex", "log", []models.Escalation{{MinMatches: 5, Action: "block"}, {MinMatches: 2, Severity: "high"}}, false},
+		{"uncountable type", "model", "log", []models.Escalation{{MinMatches: 2, Action: "block"}}, true},
+		{"allow policy", "keyword", "allow", []models.Escalation{{MinMatches: 2, Severity: "high"}}, true},
+		{"threshold too low", "regex", "log", []models.Escalation{{MinMatches: 1, Action: "block"}}, true},
+		{"duplicate threshold", "regex", "log", []models.Escalation{{MinMatches: 3, Action: "redact"}, {MinMatches: 3, Action: "block"}}, true},
+		{"no effect", "regex", "log", []models.Escalation{{MinMatches: 3}}, true},
+		{"invalid action", "regex", "log", []models.Escalation{{MinMatches: 3, Action: "allow"}}, true},
+		{"invalid severity", "regex", "log", []models.Escalation{{MinMatches: 3, Severity: "severe"}}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateEscalations(tt.patternType, tt.action, tt.escalations)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateEscalations() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
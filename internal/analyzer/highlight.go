@@ -0,0 +1,75 @@
+package analyzer
+
+import (
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Highlight lays out the occurrences of matches in content as spans and the
+// segments between their boundaries. Match offsets are taken relative to offset and
+// clipped to content, so matches found in a text content is part of (the prompt in
+// "prompt\nresponse") can be split between its parts. Matches without positions
+// are left out
+func Highlight(content string, offset int, matches []models.PolicyMatch, policies []models.Policy) ([]models.MatchSpan, []models.TextSegment) {
+	actions := make(map[uuid.UUID]string, len(policies))
+	for _, p := range policies {
+		actions[p.ID] = p.Action
+	}
+
+	spans := []models.MatchSpan{}
+	for _, match := range matches {
+		occurrences := match.Occurrences
+		if len(occurrences) == 0 && match.Start != nil {
+			occurrences = []models.MatchOccurrence{{Start: *match.Start, End: *match.End}}
+		}
+		for _, o := range occurrences {
+			start, end := max(o.Start-offset, 0), min(o.End-offset, len(content))
+			if start >= end {
+				continue
+			}
+			spans = append(spans, models.MatchSpan{
+				Start:      start,
+				End:        end,
+				PolicyID:   match.PolicyID,
+				PolicyName: match.PolicyName,
+				Severity:   match.Severity,
+				Action:     EffectiveAction(actions[match.PolicyID], match),
+			})
+		}
+	}
+	// Outer spans first, so nested highlights can be drawn on top
+	sort.SliceStable(spans, func(i, j int) bool {
+		if spans[i].Start != spans[j].Start {
+			return spans[i].Start < spans[j].Start
+		}
+		return spans[i].End > spans[j].End
+	})
+	return spans, segments(content, spans)
+}
+
+// segments cuts content at every span boundary and lists the spans covering each piece
+func segments(content string, spans []models.MatchSpan) []models.TextSegment {
+	cuts := []int{0, len(content)}
+	for _, s := range spans {
+		cuts = append(cuts, s.Start, s.End)
+	}
+	sort.Ints(cuts)
+	cuts = slices.Compact(cuts)
+
+	out := make([]models.TextSegment, 0, len(cuts))
+	for i := 0; i+1 < len(cuts); i++ {
+		start, end := cuts[i], cuts[i+1]
+		covering := []int{}
+		for j, s := range spans {
+			if s.Start <= start && end <= s.End {
+				covering = append(covering, j)
+			}
+		}
+		out = append(out, models.TextSegment{Text: content[start:end], Spans: covering})
+	}
+	return out
+}
@@ -0,0 +1,53 @@
+package analyzer
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestHighlight(t *testing.T) {
+	phone := models.Policy{ID: uuid.New(), Name: "phone", PatternType: "regex", PatternValue: `\d{3}-\d{4}`, Severity: "medium", Action: "redact", Enabled: true}
+	call := models.Policy{ID: uuid.New(), Name: "call", PatternType: "keyword", PatternValue: "call 555", Severity: "low", Action: "log", Enabled: true}
+	policies := []models.Policy{phone, call}
+
+	content := "call 555-1234\nor 555-9876"
+	matches, err := NewAnalyzer(nil).Analyze(context.Background(), content, policies)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	spans, segs := Highlight(content, 0, matches, policies)
+	want := []models.MatchSpan{
+		{Start: 0, End: 8, PolicyID: call.ID, PolicyName: "call", Severity: "low", Action: "log"},
+		{Start: 5, End: 13, PolicyID: phone.ID, PolicyName: "phone", Severity: "medium", Action: "redact"},
+		{Start: 17, End: 25, PolicyID: phone.ID, PolicyName: "phone", Severity: "medium", Action: "redact"},
+	}
+	if !reflect.DeepEqual(spans, want) {
+		t.Fatalf("spans = %+v, want %+v", spans, want)
+	}
+	wantSegs := []models.TextSegment{
+		{Text: "call ", Spans: []int{0}},
+		{Text: "555", Spans: []int{0, 1}},
+		{Text: "-1234", Spans: []int{1}},
+		{Text: "\nor ", Spans: []int{}},
+		{Text: "555-9876", Spans: []int{2}},
+	}
+	if !reflect.DeepEqual(segs, wantSegs) {
+		t.Errorf("segments = %+v, want %+v", segs, wantSegs)
+	}
+
+	// The second line alone: offsets are shifted and spans before it dropped
+	spans, segs = Highlight("or 555-9876", 14, matches, policies)
+	if len(spans) != 1 || spans[0].Start != 3 || spans[0].End != 11 || len(segs) != 2 {
+		t.Errorf("spans = %+v, segments = %+v, want the second number only", spans, segs)
+	}
+
+	spans, segs = Highlight("", 0, nil, policies)
+	if spans == nil || segs == nil || len(spans)+len(segs) != 0 {
+		t.Errorf("Highlight() of no content = %v, %v, want empty lists", spans, segs)
+	}
+}
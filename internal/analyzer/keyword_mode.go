@@ -0,0 +1,74 @@
+package analyzer
+
+import (
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/prompt-gateway/internal/ahocorasick"
+)
+
+// Keyword match modes; words are runs of letters and digits, as for stemming
+const (
+	KeywordSubstring    = "substring"     // Anywhere, even inside a word (default)
+	KeywordWholeWord    = "whole_word"    // Only as whole words: "DAN" but not "DANs" or "abundance"
+	KeywordWordBoundary = "word_boundary" // Only at the start of a word: "DANs" but not "abundance"
+)
+
+// WordBounded reports whether a keyword match mode checks word boundaries
+func WordBounded(mode string) bool {
+	return mode == KeywordWholeWord || mode == KeywordWordBoundary
+}
+
+// findKeyword returns the case-insensitive occurrences of keyword in content
+// that respect mode's word boundaries, stopping after limit (0 = all)
+func (a *Analyzer) findKeyword(keyword, mode, content string, limit int) []ahocorasick.Match {
+	if keyword == "" {
+		return nil
+	}
+	re, err := a.getCompiledPattern(KeywordPattern(keyword))
+	if err != nil {
+		return nil
+	}
+	var matches []ahocorasick.Match
+	for offset := 0; offset < len(content); {
+		loc := re.FindStringIndex(content[offset:])
+		if loc == nil {
+			break
+		}
+		start, end := offset+loc[0], offset+loc[1]
+		if !bounded(content, start, end, mode) {
+			// A later occurrence may overlap this one ("aa" in "baaa")
+			_, size := utf8.DecodeRuneInString(content[start:])
+			offset = start + size
+			continue
+		}
+		matches = append(matches, ahocorasick.Match{Start: start, End: end})
+		if limit > 0 && len(matches) == limit {
+			break
+		}
+		offset = end
+	}
+	return matches
+}
+
+// bounded reports whether content[start:end] sits on the word boundaries mode
+// requires. An edge of the keyword that isn't part of a word ("c++", "@admin")
+// is a boundary by itself
+func bounded(content string, start, end int, mode string) bool {
+	first, _ := utf8.DecodeRuneInString(content[start:])
+	before, _ := utf8.DecodeLastRuneInString(content[:start])
+	if start > 0 && isWordChar(first) && isWordChar(before) {
+		return false
+	}
+	if mode != KeywordWholeWord {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(content[:end])
+	after, _ := utf8.DecodeRuneInString(content[end:])
+	return end == len(content) || !isWordChar(last) || !isWordChar(after)
+}
+
+// isWordChar reports whether r is part of a word
+func isWordChar(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,72 @@
+package analyzer
+
+import (
+	"strings"
+
+	"github.com/prompt-gateway/internal/ahocorasick"
+)
+
+// KeywordSet scans content once for the keywords of many keyword policies
+// The keywords share one case-insensitive Aho-Corasick automaton. Unlike a
+// RegexSet scan, the answer is exhaustive: every occurrence of every keyword
+// is seen, so a keyword missing from the hits doesn't occur in the content
+type KeywordSet struct {
+	matcher *ahocorasick.Matcher
+	members map[string]int // Folded keyword → term index in matcher
+}
+
+// NewKeywordSet combines the given keywords, skipping empty and duplicate ones
+// (keywords differing only in case are one entry). Returns nil when none is usable
+func NewKeywordSet(keywords []string) *KeywordSet {
+	set := &KeywordSet{members: make(map[string]int)}
+	var terms []string
+	for _, keyword := range keywords {
+		folded := foldKeyword(keyword)
+		if folded == "" {
+			continue
+		}
+		if _, dup := set.members[folded]; dup {
+			continue
+		}
+		set.members[folded] = len(terms)
+		terms = append(terms, folded)
+	}
+	if len(terms) == 0 {
+		return nil
+	}
+	set.matcher = ahocorasick.New(terms)
+	return set
+}
+
+// Contains reports whether a keyword is part of the set
+func (s *KeywordSet) Contains(keyword string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.members[foldKeyword(keyword)]
+	return ok
+}
+
+// Scan finds the keywords occurring in content in a single pass
+// The result is keyed by folded keyword; look policies up with Hit
+func (s *KeywordSet) Scan(content string) KeywordHits {
+	found := s.matcher.FindTerms(content)
+	hits := make(KeywordHits, len(found))
+	for _, term := range found {
+		hits[s.matcher.Terms()[term]] = true
+	}
+	return hits
+}
+
+// KeywordHits are the keywords a KeywordSet scan found
+type KeywordHits map[string]bool
+
+// Hit reports whether keyword occurred in the scanned content
+func (h KeywordHits) Hit(keyword string) bool {
+	return h[foldKeyword(keyword)]
+}
+
+// foldKeyword lowercases a keyword the way matchKeyword compares it
+func foldKeyword(keyword string) string {
+	return strings.ToLower(keyword)
+}
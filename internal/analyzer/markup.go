@@ -0,0 +1,115 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Markdown/HTML syntax removed by strip_markup
+var (
+	fenceLine      = regexp.MustCompile("^\\s*(```|~~~)")
+	inlineCode     = regexp.MustCompile("`([^`\\n]+)`")
+	htmlTag        = regexp.MustCompile(`</?[a-zA-Z][^>]*>`)
+	markdownImage  = regexp.MustCompile(`!\[([^\]]*)\]\([^)]*\)`)
+	markdownLink   = regexp.MustCompile(`\[([^\]]*)\]\([^)]*\)`)
+	markdownPrefix = regexp.MustCompile(`(?m)^\s{0,3}(#{1,6}\s+|>\s?|[-*+]\s+|\d+[.)]\s+)`)
+	markdownMarks  = regexp.MustCompile(`(\*\*|__|\*|_|~~)(\S(?:.*?\S)?)(\*\*|__|\*|_|~~)`)
+)
+
+// splitCode separates fenced and inline code from the surrounding prose
+// Fence lines themselves belong to neither; an unclosed fence runs to the end
+func splitCode(content string) (code, prose string) {
+	var codeParts, proseLines []string
+	inFence := false
+	for _, line := range strings.Split(content, "\n") {
+		if fenceLine.MatchString(line) {
+			inFence = !inFence
+			continue
+		}
+		if inFence {
+			codeParts = append(codeParts, line)
+			continue
+		}
+		for _, m := range inlineCode.FindAllStringSubmatch(line, -1) {
+			codeParts = append(codeParts, m[1])
+		}
+		proseLines = append(proseLines, inlineCode.ReplaceAllString(line, " "))
+	}
+	return strings.Join(codeParts, "\n"), strings.Join(proseLines, "\n")
+}
+
+// stripMarkup reduces markdown/HTML to its visible text
+func stripMarkup(content string) string {
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		if !fenceLine.MatchString(line) {
+			lines = append(lines, line)
+		}
+	}
+	s := strings.Join(lines, "\n")
+	s = htmlTag.ReplaceAllString(s, "")
+	s = markdownImage.ReplaceAllString(s, "$1")
+	s = markdownLink.ReplaceAllString(s, "$1")
+	s = inlineCode.ReplaceAllString(s, "$1")
+	s = markdownPrefix.ReplaceAllString(s, "")
+	s = markdownMarks.ReplaceAllString(s, "$2")
+	return s
+}
+
+// scopedViews lazily derives, once per Analyze call, the content variants
+// policies with scan_scope/strip_markup ask for
+type scopedViews struct {
+	content   string
+	normalize bool
+	mu        sync.Mutex // Policies are matched concurrently
+	split     bool
+	code      string
+	prose     string
+	views     map[string]string
+}
+
+// isScoped reports whether a policy sees something other than the full content
+func isScoped(p models.Policy) bool {
+	return (p.ScanScope != "" && p.ScanScope != "all") || p.StripMarkup
+}
+
+// view returns the text a scoped policy scans (expanded for evasions when enabled)
+func (v *scopedViews) view(p models.Policy) string {
+	key := p.ScanScope
+	if p.StripMarkup {
+		key += "+strip"
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if text, ok := v.views[key]; ok {
+		return text
+	}
+
+	if !v.split {
+		v.code, v.prose = splitCode(v.content)
+		v.split = true
+	}
+	text := v.content
+	switch p.ScanScope {
+	case "code":
+		text = v.code
+	case "prose":
+		text = v.prose
+	}
+	if p.StripMarkup {
+		text = stripMarkup(text)
+	}
+	if v.normalize {
+		text = expandEvasions(text)
+	}
+
+	if v.views == nil {
+		v.views = make(map[string]string)
+	}
+	v.views[key] = text
+	return text
+}
@@ -21,6 +21,9 @@ type ModelClient interface {
 	Evaluate(ctx context.Context, model string, content string) (ModelEvaluation, error)
 }
 
+// NemoProvider names the NeMo provider in model privacy rules
+const NemoProvider = "nemo"
+
 // NemoClient calls NVIDIA's NeMo Guardrails content safety endpoint.
 type NemoClient struct {
 	apiKey     string
@@ -87,6 +90,19 @@ func (c *NemoClient) Evaluate(ctx context.Context, model string, content string)
 
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", c.apiKey))
+	// Request context the privacy rules let the provider see, for its own tracing
+	if propagated, ok := PropagatedRequest(ctx); ok {
+		for header, value := range map[string]string{
+			"X-Request-ID": propagated.RequestID,
+			"X-Client-ID":  propagated.ClientID,
+			"X-Session-ID": propagated.SessionID,
+			"X-Tenant":     propagated.Tenant,
+		} {
+			if value != "" {
+				req.Header.Set(header, value)
+			}
+		}
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
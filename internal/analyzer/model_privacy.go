@@ -0,0 +1,214 @@
+package analyzer
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// defaultTenant holds the restrictions of clients without a tenant label
+const defaultTenant = "default"
+
+// identifierPattern finds identifiers hashed out of minimized content: email
+// addresses and digit runs of 7+ digits (phone, account and ID numbers)
+var identifierPattern = regexp.MustCompile(`[\w.+-]+@[\w-]+(?:\.[\w-]+)+|\+?\d(?:[\s.-]?\d){6,}`)
+
+// ModelRequest is the request context a model provider call is made for
+type ModelRequest struct {
+	RequestID  string
+	ClientID   string
+	SessionID  string
+	Tenant     string // Empty for clients without a tenant label
+	Attachment bool   // The content comes from an attachment
+}
+
+// modelRequestKey carries the ModelRequest of an Analyze call
+type modelRequestKey struct{}
+
+// propagatedKey carries the request context a provider may see on a model call
+type propagatedKey struct{}
+
+// WithModelRequest attaches the request a model provider call is made for, so
+// privacy rules can be applied and the context propagated
+func WithModelRequest(ctx context.Context, req ModelRequest) context.Context {
+	return context.WithValue(ctx, modelRequestKey{}, req)
+}
+
+// WithAttachment marks the content analyzed with ctx as an attachment's
+func WithAttachment(ctx context.Context) context.Context {
+	req, _ := ctx.Value(modelRequestKey{}).(ModelRequest)
+	req.Attachment = true
+	return WithModelRequest(ctx, req)
+}
+
+// PropagatedRequest returns the request context a ModelClient may forward to its
+// provider: set only when the provider's privacy rules enable propagation, with
+// client and session IDs hashed when identifiers are minimized
+func PropagatedRequest(ctx context.Context) (ModelRequest, bool) {
+	req, ok := ctx.Value(propagatedKey{}).(ModelRequest)
+	return req, ok
+}
+
+// ProviderPrivacy describes how one model provider processes data and how content
+// sent to it is minimized
+type ProviderPrivacy struct {
+	// Data-processing flags tenants can restrict on
+	RetainsData  bool   `json:"retains_data"`
+	TrainsOnData bool   `json:"trains_on_data"`
+	Region       string `json:"region"`
+
+	// Minimization applied to every call
+	HashIdentifiers  bool `json:"hash_identifiers"`
+	StripAttachments bool `json:"strip_attachments"`
+	MaxTokens        int  `json:"max_tokens"` // 0 = MODEL_MAX_TOKENS only
+
+	// PropagateContext forwards the request, client and session IDs
+	PropagateContext bool `json:"propagate_context"`
+}
+
+// TenantPrivacy restricts the model providers one tenant's content reaches
+type TenantPrivacy struct {
+	NoRetention bool     `json:"no_retention"` // Never call providers that retain data
+	NoTraining  bool     `json:"no_training"`  // Never call providers that train on data
+	Regions     []string `json:"regions"`      // Only call providers in these regions (empty = any)
+	// RawContent lists the providers that may see raw content; the others get
+	// minimized content, without attachments (unset = every provider)
+	RawContent []string `json:"raw_content_providers"`
+}
+
+// ModelPrivacy holds the privacy rules of model providers and tenants
+type ModelPrivacy struct {
+	HashSalt  string                     `json:"hash_salt"`
+	Providers map[string]ProviderPrivacy `json:"providers"`
+	Tenants   map[string]TenantPrivacy   `json:"tenants"` // "default" applies to clients without a tenant
+}
+
+// LoadModelPrivacy reads model privacy rules from a JSON file
+func LoadModelPrivacy(path string) (*ModelPrivacy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model privacy rules: %w", err)
+	}
+	return ParseModelPrivacy(data)
+}
+
+// ParseModelPrivacy parses and validates model privacy rules
+func ParseModelPrivacy(data []byte) (*ModelPrivacy, error) {
+	var p ModelPrivacy
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("failed to parse model privacy rules: %w", err)
+	}
+	for name, provider := range p.Providers {
+		if name == "" {
+			return nil, fmt.Errorf("provider name is required")
+		}
+		if provider.MaxTokens < 0 {
+			return nil, fmt.Errorf("provider %s: max_tokens must not be negative", name)
+		}
+	}
+	for name, tenant := range p.Tenants {
+		for _, provider := range tenant.RawContent {
+			if _, ok := p.Providers[provider]; !ok {
+				return nil, fmt.Errorf("tenant %s: unknown provider %q in raw_content_providers", name, provider)
+			}
+		}
+	}
+	return &p, nil
+}
+
+// SetModelPrivacy applies privacy rules to calls to the named model provider
+// Must be called before the analyzer is used
+func (a *Analyzer) SetModelPrivacy(provider string, privacy *ModelPrivacy) {
+	a.modelProvider = provider
+	a.privacy = privacy
+}
+
+// modelPlan is how one model call may be made
+type modelPlan struct {
+	minimize  bool // Hash identifiers out of the content
+	maxTokens int  // Provider token cap (0 = none)
+	propagate *ModelRequest
+}
+
+// plan decides how content analyzed for req may reach provider; a non-empty
+// reason means it must not be sent at all. Without rules content is sent as is
+func (p *ModelPrivacy) plan(provider string, req ModelRequest) (modelPlan, string) {
+	if p == nil {
+		return modelPlan{}, ""
+	}
+	tenantName := req.Tenant
+	if tenantName == "" {
+		tenantName = defaultTenant
+	}
+	tenant := p.Tenants[tenantName]
+	// An undeclared provider is assumed to retain and train on data, anywhere
+	pp, declared := p.Providers[provider]
+	switch {
+	case tenant.NoRetention && (pp.RetainsData || !declared):
+		return modelPlan{}, "retention"
+	case tenant.NoTraining && (pp.TrainsOnData || !declared):
+		return modelPlan{}, "training"
+	case len(tenant.Regions) > 0 && (!declared || !slices.Contains(tenant.Regions, pp.Region)):
+		return modelPlan{}, "region"
+	}
+
+	raw := tenant.RawContent == nil || slices.Contains(tenant.RawContent, provider)
+	if req.Attachment && (pp.StripAttachments || !raw) {
+		return modelPlan{}, "attachment"
+	}
+	plan := modelPlan{minimize: pp.HashIdentifiers || !raw, maxTokens: pp.MaxTokens}
+	if pp.PropagateContext {
+		propagated := req
+		if plan.minimize {
+			propagated.ClientID = p.hash(req.ClientID)
+			propagated.SessionID = p.hash(req.SessionID)
+		}
+		plan.propagate = &propagated
+	}
+	return plan, ""
+}
+
+// minimize replaces the identifiers in content with stable salted hashes, so a
+// provider can still tell repeated identifiers apart without seeing them
+func (p *ModelPrivacy) minimize(content string) string {
+	return identifierPattern.ReplaceAllStringFunc(content, func(id string) string {
+		return "[id:" + p.hash(id) + "]"
+	})
+}
+
+// hash returns a short salted hash of an identifier ("" stays empty)
+func (p *ModelPrivacy) hash(id string) string {
+	if id == "" {
+		return ""
+	}
+	sum := sha256.Sum256([]byte(p.HashSalt + id))
+	return hex.EncodeToString(sum[:6])
+}
+
+// prepareModelCall applies the privacy rules to a model call: it returns the
+// content to send and the context to send it with, or false when the call must
+// be withheld
+func (a *Analyzer) prepareModelCall(ctx context.Context, content string) (context.Context, string, bool) {
+	req, _ := ctx.Value(modelRequestKey{}).(ModelRequest)
+	plan, reason := a.privacy.plan(a.modelProvider, req)
+	if reason != "" {
+		metrics.ModelCallsWithheldTotal.WithLabelValues(a.modelProvider, reason).Inc()
+		noteTrace(ctx, "withheld from model provider: "+reason)
+		return ctx, "", false
+	}
+	if plan.minimize {
+		content = a.privacy.minimize(content)
+	}
+	content = a.modelInput(content, plan.maxTokens)
+	if plan.propagate != nil {
+		ctx = context.WithValue(ctx, propagatedKey{}, *plan.propagate)
+	}
+	return ctx, content, true
+}
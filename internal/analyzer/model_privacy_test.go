@@ -0,0 +1,145 @@
+package analyzer
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+const testPrivacy = `{
+  "hash_salt": "pepper",
+  "providers": {
+    "nemo": {"region": "us", "retains_data": true, "propagate_context": true},
+    "local": {"region": "eu", "hash_identifiers": true, "strip_attachments": true, "propagate_context": true}
+  },
+  "tenants": {
+    "acme": {"raw_content_providers": []},
+    "initech": {"no_retention": true},
+    "globex": {"regions": ["eu"]}
+  }
+}`
+
+// privacyModelClient records what the provider would receive
+type privacyModelClient struct {
+	contents   []string
+	propagated []ModelRequest
+}
+
+func (c *privacyModelClient) Evaluate(ctx context.Context, model string, content string) (ModelEvaluation, error) {
+	c.contents = append(c.contents, content)
+	if req, ok := PropagatedRequest(ctx); ok {
+		c.propagated = append(c.propagated, req)
+	}
+	return ModelEvaluation{}, nil
+}
+
+func TestAnalyzer_ModelPrivacy(t *testing.T) {
+	privacy, err := ParseModelPrivacy([]byte(testPrivacy))
+	if err != nil {
+		t.Fatalf("ParseModelPrivacy() error = %v", err)
+	}
+	policies := []models.Policy{{ID: uuid.New(), Name: "safety", PatternType: "model", PatternValue: "m", Enabled: true}}
+	const content = "mail bob@example.com or call +1 415 555 0100"
+
+	tests := []struct {
+		name        string
+		provider    string
+		req         ModelRequest
+		wantCalled  bool
+		wantRaw     bool
+		wantSession string // Propagated session ID ("" = not propagated)
+	}{
+		{"raw content by default", "nemo", ModelRequest{ClientID: "app", SessionID: "s1"}, true, true, "s1"},
+		{"tenant minimizes for unlisted providers", "nemo", ModelRequest{ClientID: "app", SessionID: "s1", Tenant: "acme"}, true, false, privacy.hash("s1")},
+		{"provider hashes identifiers", "local", ModelRequest{ClientID: "app", SessionID: "s1"}, true, false, privacy.hash("s1")},
+		{"provider strips attachments", "local", ModelRequest{ClientID: "app", Attachment: true}, false, false, ""},
+		{"tenant refuses retention", "nemo", ModelRequest{ClientID: "app", Tenant: "initech"}, false, false, ""},
+		{"tenant restricts regions", "nemo", ModelRequest{ClientID: "app", Tenant: "globex"}, false, false, ""},
+		{"allowed region", "local", ModelRequest{ClientID: "app", Tenant: "globex"}, true, false, ""},
+		{"undeclared provider fails restrictions", "other", ModelRequest{ClientID: "app", Tenant: "initech"}, false, false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &privacyModelClient{}
+			a := NewAnalyzer(client)
+			a.SetModelPrivacy(tt.provider, privacy)
+			ctx := WithModelRequest(context.Background(), tt.req)
+			if _, err := a.Analyze(ctx, content, policies); err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			if called := len(client.contents) == 1; called != tt.wantCalled {
+				t.Fatalf("provider called = %v, want %v", called, tt.wantCalled)
+			}
+			if !tt.wantCalled {
+				return
+			}
+			sent := client.contents[0]
+			if raw := sent == content; raw != tt.wantRaw {
+				t.Errorf("sent %q, want raw %v", sent, tt.wantRaw)
+			}
+			if !tt.wantRaw && (strings.Contains(sent, "bob@example.com") || strings.Contains(sent, "555")) {
+				t.Errorf("minimized content %q still holds identifiers", sent)
+			}
+			if tt.wantSession != "" && (len(client.propagated) != 1 || client.propagated[0].SessionID != tt.wantSession) {
+				t.Errorf("propagated = %+v, want session %q", client.propagated, tt.wantSession)
+			}
+		})
+	}
+}
+
+func TestModelPrivacy_Minimize(t *testing.T) {
+	p := &ModelPrivacy{HashSalt: "pepper"}
+	got := p.minimize("bob@example.com wrote to bob@example.com about order 2024")
+	if strings.Contains(got, "bob@") || !strings.Contains(got, "order 2024") {
+		t.Errorf("minimize() = %q, want emails hashed and short numbers kept", got)
+	}
+	first := strings.Fields(got)[0]
+	if !strings.Contains(got, "to "+first) {
+		t.Errorf("minimize() = %q, want stable hashes for repeated identifiers", got)
+	}
+}
+
+func TestParseModelPrivacy_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"negative max tokens":  `{"providers": {"nemo": {"max_tokens": -1}}}`,
+		"unknown raw provider": `{"providers": {"nemo": {}}, "tenants": {"acme": {"raw_content_providers": ["nmeo"]}}}`,
+		"malformed":            `{"providers": []}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := ParseModelPrivacy([]byte(data)); err == nil {
+				t.Error("ParseModelPrivacy() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestNemoClient_PropagatesContext(t *testing.T) {
+	var headers http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		headers = r.Header
+		w.Write([]byte(`{"choices": [{"message": {"content": "{\"User Safety\": \"safe\"}"}}]}`))
+	}))
+	defer server.Close()
+
+	client := NewNemoClient("key", server.URL, nil)
+	ctx := context.WithValue(context.Background(), propagatedKey{}, ModelRequest{RequestID: "req-1", ClientID: "app"})
+	if _, err := client.Evaluate(ctx, "m", "hello"); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if headers.Get("X-Request-ID") != "req-1" || headers.Get("X-Client-ID") != "app" || headers.Get("X-Session-ID") != "" {
+		t.Errorf("headers = %v, want request and client IDs only", headers)
+	}
+
+	if _, err := client.Evaluate(context.Background(), "m", "hello"); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if headers.Get("X-Request-ID") != "" {
+		t.Errorf("headers = %v, want no context without propagation", headers)
+	}
+}
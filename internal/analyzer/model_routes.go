@@ -0,0 +1,101 @@
+package analyzer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+
+	"github.com/prompt-gateway/internal/langdetect"
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// defaultRouteConfidence is the detection confidence routing needs by default
+const defaultRouteConfidence = 0.5
+
+// languageCodePattern matches ISO 639 language codes
+var languageCodePattern = regexp.MustCompile(`^[a-z]{2,3}$`)
+
+// ModelRoutes sends model evaluations of content in some languages to other
+// guard models, e.g. Japanese traffic of a "model" policy to a Japanese guard
+type ModelRoutes struct {
+	// MinConfidence is the detection confidence needed to reroute; content
+	// detected with less stays on the policy's model (0 = 0.5)
+	MinConfidence float64 `json:"min_confidence"`
+	// Models maps a policy's model to the model used per detected language
+	Models map[string]map[string]string `json:"models"`
+}
+
+// LoadModelRoutes reads language routes of model policies from a JSON file
+func LoadModelRoutes(path string) (*ModelRoutes, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read model routes: %w", err)
+	}
+	return ParseModelRoutes(data)
+}
+
+// ParseModelRoutes parses and validates language routes of model policies
+func ParseModelRoutes(data []byte) (*ModelRoutes, error) {
+	var r ModelRoutes
+	if err := json.Unmarshal(data, &r); err != nil {
+		return nil, fmt.Errorf("failed to parse model routes: %w", err)
+	}
+	if r.MinConfidence < 0 || r.MinConfidence > 1 {
+		return nil, fmt.Errorf("min_confidence must be between 0 and 1")
+	}
+	if r.MinConfidence == 0 {
+		r.MinConfidence = defaultRouteConfidence
+	}
+	for model, languages := range r.Models {
+		if model == "" {
+			return nil, fmt.Errorf("model name is required")
+		}
+		for language, target := range languages {
+			if !languageCodePattern.MatchString(language) {
+				return nil, fmt.Errorf("model %s: invalid language code %q", model, language)
+			}
+			if target == "" {
+				return nil, fmt.Errorf("model %s: no model routed to for %s", model, language)
+			}
+		}
+	}
+	return &r, nil
+}
+
+// SetModelRoutes routes model evaluations by the language of the content
+// Must be called before the analyzer is used
+func (a *Analyzer) SetModelRoutes(routes *ModelRoutes) {
+	a.routes = routes
+}
+
+// route returns the model to evaluate content with instead of model, and the
+// language that decided it; model itself when no route applies
+func (r *ModelRoutes) route(model, content string) (string, string) {
+	if r == nil {
+		return model, ""
+	}
+	languages := r.Models[model]
+	if len(languages) == 0 {
+		return model, ""
+	}
+	detected := langdetect.Detect(content)
+	if detected.Language == "" || detected.Confidence < r.MinConfidence {
+		return model, ""
+	}
+	target, ok := languages[detected.Language]
+	if !ok {
+		return model, detected.Language
+	}
+	return target, detected.Language
+}
+
+// routeModel picks the model a model policy's content is evaluated with, and
+// the detected language ("" when undetermined or no route is configured)
+func (a *Analyzer) routeModel(model, content string) (string, string) {
+	routed, language := a.routes.route(model, content)
+	if routed != model {
+		metrics.ModelRoutesTotal.WithLabelValues(model, language).Inc()
+	}
+	return routed, language
+}
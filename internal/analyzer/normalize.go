@@ -0,0 +1,226 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// Evasion transformations, also used as metric labels
+const (
+	transformZalgo   = "zalgo"
+	transformSpaced  = "spaced"
+	transformRepeat  = "repeated"
+	transformLeet    = "leetspeak"
+	transformROT13   = "rot13"
+	variantSeparator = "\n"
+)
+
+// leetMap undoes common character substitutions inside words
+var leetMap = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l', '+': 't',
+}
+
+// rot13Hints are frequent words; ROT13 decoding is only trusted when it
+// produces more of them than the original text contains
+var rot13Hints = map[string]bool{
+	"the": true, "and": true, "you": true, "your": true, "to": true, "of": true, "is": true,
+	"are": true, "all": true, "ignore": true, "previous": true, "instructions": true,
+	"system": true, "prompt": true, "now": true, "what": true, "how": true, "password": true,
+}
+
+// expandEvasions returns content followed by de-obfuscated variants, so pattern
+// matchers see both what was sent and what it decodes to
+// Each transformation that changes the text is counted in EvasionTransformsTotal
+func expandEvasions(content string) string {
+	variants := []string{content}
+	seen := map[string]bool{content: true}
+	add := func(transform, variant string) {
+		if seen[variant] {
+			return
+		}
+		seen[variant] = true
+		variants = append(variants, variant)
+		metrics.EvasionTransformsTotal.WithLabelValues(transform).Inc()
+	}
+
+	// Transformations build on each other: "1 g n 0 r e" needs spacing and leet undone
+	normalized := content
+	for _, step := range []struct {
+		name string
+		fn   func(string) string
+	}{
+		{transformZalgo, stripCombiningMarks},
+		{transformSpaced, joinSpacedLetters},
+		{transformRepeat, collapseRepeats},
+		{transformLeet, undoLeetspeak},
+	} {
+		if next := step.fn(normalized); next != normalized {
+			metrics.EvasionTransformsTotal.WithLabelValues(step.name).Inc()
+			normalized = next
+		}
+	}
+	if normalized != content {
+		seen[normalized] = true
+		variants = append(variants, normalized)
+	}
+
+	if decoded := rot13(content); hintCount(decoded) > hintCount(content) && hintCount(decoded) >= 2 {
+		add(transformROT13, decoded)
+	}
+
+	return strings.Join(variants, variantSeparator)
+}
+
+// stripCombiningMarks removes combining diacritics (zalgo text stacks dozens of them)
+func stripCombiningMarks(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.Is(unicode.Mn, r) || unicode.Is(unicode.Me, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// joinSpacedLetters collapses runs of three or more single characters separated
+// by one space or punctuation mark: "i g n o r e" / "i.g.n.o.r.e" → "ignore"
+func joinSpacedLetters(s string) string {
+	runes := []rune(s)
+	n := len(runes)
+	isolated := func(j int) bool {
+		return isWordRune(runes[j]) && (j+1 == n || !isWordRune(runes[j+1]))
+	}
+
+	var b strings.Builder
+	for i := 0; i < n; {
+		if i == 0 || !isWordRune(runes[i-1]) {
+			end, count := i, 0
+			for j := i; j < n && isolated(j); j += 2 {
+				count++
+				end = j + 1
+				if j+2 >= n || !isSpacer(runes[j+1]) {
+					break
+				}
+			}
+			if count >= 3 {
+				for _, r := range runes[i:end] {
+					if isWordRune(r) {
+						b.WriteRune(r)
+					}
+				}
+				i = end
+				continue
+			}
+		}
+		b.WriteRune(runes[i])
+		i++
+	}
+	return b.String()
+}
+
+// collapseRepeats reduces runs of three or more identical letters to one: "ignooooore" → "ignore"
+func collapseRepeats(s string) string {
+	runes := []rune(s)
+	var b strings.Builder
+	for i := 0; i < len(runes); {
+		j := i + 1
+		for j < len(runes) && runes[j] == runes[i] {
+			j++
+		}
+		if j-i >= 3 && unicode.IsLetter(runes[i]) {
+			b.WriteRune(runes[i])
+		} else {
+			b.WriteString(string(runes[i:j]))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// undoLeetspeak maps digits and symbols back to letters within words that
+// also contain letters, so plain numbers ("2024", "$5") are left alone
+func undoLeetspeak(s string) string {
+	var b, word strings.Builder
+	flush := func() {
+		b.WriteString(deleetWord(word.String()))
+		word.Reset()
+	}
+	for _, r := range s {
+		if unicode.IsSpace(r) {
+			flush()
+			b.WriteRune(r)
+			continue
+		}
+		word.WriteRune(r)
+	}
+	flush()
+	return b.String()
+}
+
+// deleetWord undoes substitutions in a single word; trailing punctuation
+// ("hello!") is kept as-is
+func deleetWord(word string) string {
+	runes := []rune(word)
+	last := len(runes)
+	for last > 0 && !unicode.IsLetter(runes[last-1]) && !unicode.IsDigit(runes[last-1]) {
+		last--
+	}
+
+	hasLetter, hasLeet := false, false
+	for _, r := range runes[:last] {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+		} else if _, ok := leetMap[r]; ok {
+			hasLeet = true
+		}
+	}
+	if !hasLetter || !hasLeet {
+		return word
+	}
+	for i, r := range runes[:last] {
+		if mapped, ok := leetMap[r]; ok {
+			runes[i] = mapped
+		}
+	}
+	return string(runes)
+}
+
+// rot13 rotates ASCII letters by 13 places
+func rot13(s string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z':
+			return 'a' + (r-'a'+13)%26
+		case r >= 'A' && r <= 'Z':
+			return 'A' + (r-'A'+13)%26
+		}
+		return r
+	}, s)
+}
+
+// hintCount counts frequent English words in s
+func hintCount(s string) int {
+	count := 0
+	for _, word := range strings.FieldsFunc(strings.ToLower(s), func(r rune) bool { return !unicode.IsLetter(r) }) {
+		if rot13Hints[word] {
+			count++
+		}
+	}
+	return count
+}
+
+// isWordRune reports whether r can be part of an obfuscated word (letters, digits, leet symbols)
+func isWordRune(r rune) bool {
+	if unicode.IsLetter(r) || unicode.IsDigit(r) {
+		return true
+	}
+	_, ok := leetMap[r]
+	return ok
+}
+
+// isSpacer reports whether r separates spaced-out letters
+func isSpacer(r rune) bool {
+	return r == ' ' || r == '.' || r == '-' || r == '_' || r == '*'
+}
@@ -0,0 +1,139 @@
+package analyzer
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// contextWindow is how many bytes before and after a number are searched for keywords
+const contextWindow = 40
+
+// defaultContextDigits is the minimum digit count of a custom keyword without one
+const defaultContextDigits = 6
+
+// numberPattern finds digit sequences, allowing single space or dash separators
+// as in "4111 1111 1111 1111" and "123-45-6789"
+var numberPattern = regexp.MustCompile(`\d(?:[ -]?\d)*`)
+
+// builtinContexts are the keywords of a "builtin" contextual_number policy, with
+// the digits a number needs to be sensitive in that context
+const builtinContexts = "card:13,credit:13,debit:13,visa:13,mastercard:13,amex:13," +
+	"ssn:9,social security:9,account:8,acct:8,iban:8,routing:8,cvv:3,cvc:3,security code:3"
+
+// NumberContext is a keyword that makes nearby numbers of at least MinDigits sensitive
+type NumberContext struct {
+	Keyword   string
+	MinDigits int
+	pattern   *regexp.Regexp
+}
+
+// contextCache memoizes parsed contextual_number pattern values
+var contextCache sync.Map // string → []NumberContext
+
+// ParseNumberContexts parses the pattern value of a "contextual_number" policy:
+// "builtin" or comma-separated keywords, each optionally with a minimum digit count
+// ("passport:8,member id"); keywords match at the start of a word, case-insensitively
+func ParseNumberContexts(value string) ([]NumberContext, error) {
+	spec := value
+	if strings.TrimSpace(value) == "builtin" {
+		spec = builtinContexts
+	}
+	var contexts []NumberContext
+	for _, item := range strings.Split(spec, ",") {
+		keyword, digits, hasDigits := strings.Cut(item, ":")
+		keyword = strings.ToLower(strings.TrimSpace(keyword))
+		if keyword == "" {
+			return nil, fmt.Errorf("contextual_number pattern_value must be builtin or comma-separated keywords")
+		}
+		minDigits := defaultContextDigits
+		if hasDigits {
+			n, err := strconv.Atoi(strings.TrimSpace(digits))
+			if err != nil || n <= 0 {
+				return nil, fmt.Errorf("contextual_number keyword %q needs a positive digit count", keyword)
+			}
+			minDigits = n
+		}
+		contexts = append(contexts, NumberContext{
+			Keyword:   keyword,
+			MinDigits: minDigits,
+			pattern:   regexp.MustCompile(`(?i)\b` + regexp.QuoteMeta(keyword)),
+		})
+	}
+	return contexts, nil
+}
+
+// numberContexts returns the parsed keywords of a contextual_number policy
+func numberContexts(value string) ([]NumberContext, error) {
+	if cached, ok := contextCache.Load(value); ok {
+		return cached.([]NumberContext), nil
+	}
+	contexts, err := ParseNumberContexts(value)
+	if err != nil {
+		return nil, err
+	}
+	contextCache.Store(value, contexts)
+	return contexts, nil
+}
+
+// contextualNumber is a digit sequence with a keyword close enough to make it sensitive
+type contextualNumber struct {
+	start, end int
+	digits     int
+	keyword    string
+}
+
+// findContextualNumbers returns the numbers in content that have a keyword within
+// contextWindow bytes and at least that keyword's digit count
+func findContextualNumbers(contexts []NumberContext, content string) []contextualNumber {
+	var found []contextualNumber
+	for _, span := range numberPattern.FindAllStringIndex(content, -1) {
+		digits := 0
+		for _, c := range content[span[0]:span[1]] {
+			if c >= '0' && c <= '9' {
+				digits++
+			}
+		}
+		around := content[max(0, span[0]-contextWindow):min(len(content), span[1]+contextWindow)]
+		for _, c := range contexts {
+			if digits >= c.MinDigits && c.pattern.MatchString(around) {
+				found = append(found, contextualNumber{start: span[0], end: span[1], digits: digits, keyword: c.Keyword})
+				break
+			}
+		}
+	}
+	return found
+}
+
+// matchContextualNumber flags long numbers next to a context keyword; the matched
+// text names the keyword rather than echoing the number
+func (a *Analyzer) matchContextualNumber(value, content string) (bool, string, error) {
+	contexts, err := numberContexts(value)
+	if err != nil {
+		return false, "", err
+	}
+	if found := findContextualNumbers(contexts, content); len(found) > 0 {
+		return true, fmt.Sprintf("%d-digit number near %q", found[0].digits, found[0].keyword), nil
+	}
+	return false, "", nil
+}
+
+// redactContextualNumbers replaces the sensitive numbers with [REDACTED]
+func redactContextualNumbers(value, content string) string {
+	contexts, err := numberContexts(value)
+	if err != nil {
+		return content
+	}
+	found := findContextualNumbers(contexts, content)
+	var b strings.Builder
+	last := 0
+	for _, n := range found {
+		b.WriteString(content[last:n.start])
+		b.WriteString("[REDACTED]")
+		last = n.end
+	}
+	b.WriteString(content[last:])
+	return b.String()
+}
@@ -0,0 +1,71 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestParseNumberContexts(t *testing.T) {
+	contexts, err := ParseNumberContexts("Passport:8, member id")
+	if err != nil {
+		t.Fatalf("ParseNumberContexts() error = %v", err)
+	}
+	if len(contexts) != 2 || contexts[0].Keyword != "passport" || contexts[0].MinDigits != 8 || contexts[1].MinDigits != defaultContextDigits {
+		t.Errorf("ParseNumberContexts() = %+v", contexts)
+	}
+	for _, value := range []string{"", "card,,ssn", "card:0", "card:many"} {
+		if _, err := ParseNumberContexts(value); err == nil {
+			t.Errorf("ParseNumberContexts(%q) error = nil, want error", value)
+		}
+	}
+	if _, err := ParseNumberContexts("builtin"); err != nil {
+		t.Errorf("ParseNumberContexts(builtin) error = %v", err)
+	}
+}
+
+func TestAnalyzer_ContextualNumber(t *testing.T) {
+	a := NewAnalyzer(nil)
+	tests := []struct {
+		name        string
+		content     string
+		wantPattern string
+	}{
+		{"card number", "My card is 4111 1111 1111 1111, expiry next May", `16-digit number near "card"`},
+		{"ssn with dashes", "SSN: 123-45-6789", `9-digit number near "ssn"`},
+		{"cvv is short", "and the CVV is 123", `3-digit number near "cvv"`},
+		{"order number", "Order 4111111111111111 shipped on 2024-05-01", ""},
+		{"timestamp", "The job ran at 1714557600123 and finished", ""},
+		{"too short for the keyword", "card ending in 1111", ""},
+		{"keyword inside a word", "discard 4111111111111111", ""},
+		{"keyword too far away", "Card holder name is on file with us, please wait. Reference 4111111111111111", ""},
+	}
+
+	policy := models.Policy{ID: uuid.New(), Name: "Contextual PII", PatternType: "contextual_number", PatternValue: "builtin", Enabled: true, Severity: "high", Action: "redact"}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := a.Analyze(context.Background(), tt.content, []models.Policy{policy})
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			got := ""
+			if len(matches) > 0 {
+				got = matches[0].MatchedPattern
+			}
+			if got != tt.wantPattern {
+				t.Errorf("matched pattern = %q, want %q", got, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_RedactContextualNumber(t *testing.T) {
+	a := NewAnalyzer(nil)
+	policy := models.Policy{PatternType: "contextual_number", PatternValue: "builtin", Action: "redact"}
+	got := a.redactPolicy("Card 4111-1111-1111-1111 for order 998877665544", policy)
+	if got != "Card [REDACTED] for order 998877665544" {
+		t.Errorf("redactPolicy() = %q", got)
+	}
+}
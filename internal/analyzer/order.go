@@ -0,0 +1,46 @@
+package analyzer
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// severityRank orders severities for evaluation; unknown ones sort last
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// comparePolicies orders policies for evaluation: higher priority first, then
+// more severe, then by name. The ID settles policies sharing a name (e.g. the
+// same policy in two isolated tenants), so the order is total
+func comparePolicies(a, b models.Policy) int {
+	if a.Priority != b.Priority {
+		return cmp.Compare(b.Priority, a.Priority)
+	}
+	if ra, rb := severityRank[a.Severity], severityRank[b.Severity]; ra != rb {
+		return rb - ra
+	}
+	if c := strings.Compare(a.Name, b.Name); c != 0 {
+		return c
+	}
+	return bytes.Compare(a.ID[:], b.ID[:])
+}
+
+// SortPolicies puts policies into evaluation order in place. Analyze reports
+// matches in this order and MatchFirst returns the earliest match in it, so
+// sorting once when policies load spares every call the work
+func SortPolicies(policies []models.Policy) {
+	slices.SortStableFunc(policies, comparePolicies)
+}
+
+// inEvaluationOrder returns policies when already sorted, or a sorted copy
+func inEvaluationOrder(policies []models.Policy) []models.Policy {
+	if slices.IsSortedFunc(policies, comparePolicies) {
+		return policies
+	}
+	sorted := slices.Clone(policies)
+	SortPolicies(sorted)
+	return sorted
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestSortPolicies(t *testing.T) {
+	policies := []models.Policy{
+		{Name: "b-low", Severity: "low"},
+		{Name: "z-critical", Severity: "critical"},
+		{Name: "a-low", Severity: "low"},
+		{Name: "pinned", Severity: "low", Priority: 10},
+		{Name: "demoted", Severity: "critical", Priority: -1},
+	}
+	SortPolicies(policies)
+
+	var names []string
+	for _, p := range policies {
+		names = append(names, p.Name)
+	}
+	if got := strings.Join(names, ","); got != "pinned,z-critical,a-low,b-low,demoted" {
+		t.Errorf("SortPolicies() = %s, want priority, then severity, then name", got)
+	}
+}
+
+// delayedModelClient flags everything, after a delay
+type delayedModelClient struct{ delay time.Duration }
+
+func (c delayedModelClient) Evaluate(ctx context.Context, model string, content string) (ModelEvaluation, error) {
+	select {
+	case <-time.After(c.delay):
+		return ModelEvaluation{Triggered: true, Detail: model}, nil
+	case <-ctx.Done():
+		return ModelEvaluation{}, ctx.Err()
+	}
+}
+
+func TestAnalyzer_FirstMatchFollowsEvaluationOrder(t *testing.T) {
+	policies := []models.Policy{
+		{ID: uuid.New(), Name: "fast", PatternType: "keyword", PatternValue: "secret", Severity: "high", Enabled: true},
+		{ID: uuid.New(), Name: "slow", PatternType: "model", PatternValue: "guard", Severity: "high", Priority: 1, Enabled: true},
+	}
+	a := NewAnalyzer(delayedModelClient{delay: 30 * time.Millisecond})
+	a.SetMatchMode(MatchFirst)
+
+	// The keyword check finishes first, but the higher-priority model policy wins
+	matches, err := a.Analyze(context.Background(), "my secret", policies)
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(matches) != 1 || matches[0].PolicyName != "slow" {
+		t.Errorf("Analyze() = %+v, want the slow, higher-priority match", matches)
+	}
+
+	// The input order doesn't matter
+	reversed := []models.Policy{policies[1], policies[0]}
+	all, err := NewAnalyzer(delayedModelClient{}).Analyze(context.Background(), "my secret", reversed)
+	if err != nil || len(all) != 2 || all[0].PolicyName != "slow" || all[1].PolicyName != "fast" {
+		t.Errorf("Analyze() = %+v, %v; want slow then fast", all, err)
+	}
+}
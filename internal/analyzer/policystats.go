@@ -0,0 +1,144 @@
+package analyzer
+
+import (
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// minSlowSamples avoids flagging a policy on a handful of cold evaluations
+const minSlowSamples = 20
+
+// policyStat accumulates evaluation timings for one policy
+type policyStat struct {
+	name        string
+	patternType string
+	count       int64
+	total       time.Duration
+	max         time.Duration
+	slow        bool // Already reported as slow
+}
+
+// PolicyStats records per-policy evaluation time and reports policies whose
+// average exceeds a threshold so their owners can fix them
+type PolicyStats struct {
+	threshold time.Duration
+
+	mu    sync.Mutex // Protects stats
+	stats map[uuid.UUID]*policyStat
+}
+
+// NewPolicyStats creates a recorder flagging policies averaging above threshold
+func NewPolicyStats(threshold time.Duration) *PolicyStats {
+	return &PolicyStats{threshold: threshold, stats: make(map[uuid.UUID]*policyStat)}
+}
+
+// matcherClass groups pattern types by the kind of work their matcher does, the
+// class label of gateway_analyzer_matcher_duration_seconds
+func matcherClass(patternType string) string {
+	switch patternType {
+	case "regex", "contextual_number", "role_impersonation", "url_exfiltration":
+		return "regex"
+	case "keyword", "dictionary":
+		return "keyword"
+	case "max_tokens":
+		return "tokens"
+	default:
+		return patternType // "profanity", "model"
+	}
+}
+
+// observeMatcher records time spent in one matcher class since start
+func observeMatcher(class string, start time.Time) {
+	metrics.AnalyzerMatcherDuration.WithLabelValues(class).Observe(time.Since(start).Seconds())
+}
+
+// observe records one evaluation; a nil recorder ignores it
+func (s *PolicyStats) observe(p models.Policy, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	st, ok := s.stats[p.ID]
+	if !ok {
+		st = &policyStat{}
+		s.stats[p.ID] = st
+	}
+	st.name, st.patternType = p.Name, p.PatternType
+	st.count++
+	st.total += d
+	if d > st.max {
+		st.max = d
+	}
+	avg := st.total / time.Duration(st.count)
+	becameSlow := !st.slow && st.count >= minSlowSamples && avg > s.threshold
+	if becameSlow {
+		st.slow = true
+	}
+	slowCount := 0
+	for _, other := range s.stats {
+		if other.slow {
+			slowCount++
+		}
+	}
+	s.mu.Unlock()
+
+	if becameSlow {
+		metrics.SlowPolicies.Set(float64(slowCount))
+		slog.Warn("Policy is slow", "policy", p.Name, "pattern_type", p.PatternType, "average", avg, "evaluations", st.count, "threshold", s.threshold)
+	}
+}
+
+// Slow returns policies averaging above the threshold, slowest first
+func (s *PolicyStats) Slow() []models.SlowPolicy {
+	if s == nil {
+		return []models.SlowPolicy{}
+	}
+	s.mu.Lock()
+	slow := make([]models.SlowPolicy, 0)
+	for id, st := range s.stats {
+		if st.count < minSlowSamples {
+			continue
+		}
+		avg := st.total / time.Duration(st.count)
+		if avg <= s.threshold {
+			continue
+		}
+		slow = append(slow, models.SlowPolicy{
+			PolicyID:    id,
+			PolicyName:  st.name,
+			PatternType: st.patternType,
+			Evaluations: st.count,
+			AvgMs:       millis(avg),
+			MaxMs:       millis(st.max),
+		})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(slow, func(i, j int) bool { return slow[i].AvgMs > slow[j].AvgMs })
+	return slow
+}
+
+// millis converts a duration to milliseconds rounded to microseconds
+func millis(d time.Duration) float64 {
+	return math.Round(float64(d)/float64(time.Microsecond)) / 1000
+}
+
+// truncateUTF8 cuts s to at most n bytes without splitting a rune
+func truncateUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
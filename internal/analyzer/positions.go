@@ -0,0 +1,95 @@
+package analyzer
+
+import (
+	"github.com/prompt-gateway/internal/ahocorasick"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// maxOccurrences caps the occurrences reported per match, so a pattern repeated
+// throughout a large document doesn't bloat the response
+const maxOccurrences = 100
+
+// locate finds p's occurrences in content once: it records their byte offsets on
+// match for redaction UIs and applies p's escalations by their count. Scoped
+// policies see a rewritten view of content, so their offsets would point nowhere
+// and only the count is kept
+func (a *Analyzer) locate(p models.Policy, content string, match *models.PolicyMatch) {
+	if !countable[p.PatternType] {
+		return
+	}
+	found := a.occurrences(p, content)
+	a.escalate(p, len(found), match)
+	// Nothing found in content means the match came from an evasion variant
+	if len(found) == 0 || isScoped(p) {
+		return
+	}
+	start, end := found[0].Start, found[0].End
+	match.Start, match.End = &start, &end
+	match.Occurrences = found[:min(len(found), maxOccurrences)]
+}
+
+// occurrences returns the spans of p's matches in content, as redaction would find
+// them; de-obfuscated variants are not searched, so evasions can't inflate the count
+func (a *Analyzer) occurrences(p models.Policy, content string) []models.MatchOccurrence {
+	switch p.PatternType {
+	case "regex":
+		re, err := a.getCompiledPattern(p.PatternValue)
+		if err != nil {
+			return nil
+		}
+		if len(p.CaptureConstraints) > 0 {
+			return spans(constrainedMatches(re, content, p.CaptureConstraints, 0))
+		}
+		return indexSpans(re.FindAllStringIndex(content, -1))
+	case "keyword":
+		if p.Stem {
+			return spans(findStemmed(p.PatternValue, content))
+		}
+		if WordBounded(p.MatchMode) {
+			return spans(a.findKeyword(p.PatternValue, p.MatchMode, content, 0))
+		}
+		if p.PatternValue == "" {
+			return nil
+		}
+		re, err := a.getCompiledPattern(KeywordPattern(p.PatternValue))
+		if err != nil {
+			return nil
+		}
+		return indexSpans(re.FindAllStringIndex(content, -1))
+	case "dictionary":
+		matcher, err := a.dictionary(p.PatternValue)
+		if err != nil {
+			return nil
+		}
+		return spans(matcher.FindAll(content))
+	case "contextual_number":
+		contexts, err := numberContexts(p.PatternValue)
+		if err != nil {
+			return nil
+		}
+		var out []models.MatchOccurrence
+		for _, n := range findContextualNumbers(contexts, content) {
+			out = append(out, models.MatchOccurrence{Start: n.start, End: n.end})
+		}
+		return out
+	}
+	return nil
+}
+
+// spans converts matcher results to occurrences
+func spans(matches []ahocorasick.Match) []models.MatchOccurrence {
+	out := make([]models.MatchOccurrence, len(matches))
+	for i, m := range matches {
+		out[i] = models.MatchOccurrence{Start: m.Start, End: m.End}
+	}
+	return out
+}
+
+// indexSpans converts regexp index pairs to occurrences
+func indexSpans(locs [][]int) []models.MatchOccurrence {
+	out := make([]models.MatchOccurrence, len(locs))
+	for i, loc := range locs {
+		out[i] = models.MatchOccurrence{Start: loc[0], End: loc[1]}
+	}
+	return out
+}
@@ -0,0 +1,141 @@
+package analyzer
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	goaway "github.com/TwiN/go-away"
+)
+
+// ProfanityCategories lists profanity categories from most to least severe
+// A profanity policy's pattern_value selects categories ("builtin" matches all)
+var ProfanityCategories = []string{"slur", "sexual", "insult", "profanity", "mild"}
+
+// profanityAll is the pattern_value matching every category
+const profanityAll = "builtin"
+
+// profanityTerms assigns go-away's dictionary to categories; terms missing here
+// (e.g. added by a library upgrade) fall into "profanity"
+var profanityTerms = map[string][]string{
+	"slur": {"coon", "dyke", "fag", "fudgepacker", "nigga", "nigger", "niggu", "queer", "retard"},
+	"sexual": {"anal", "anus", "ballsack", "balls", "blowjob", "boner", "boob", "choad", "clitoris",
+		"cock", "cum", "dick", "dildo", "fellate", "fellatio", "felching", "flange", "gyat", "horny",
+		"incest", "jizz", "labia", "masturbat", "muff", "naked", "nipple", "nips", "nude", "pedophile",
+		"penis", "porn", "prostitut", "pube", "pussie", "pussy", "rape", "rapist", "rimjob", "scrotum",
+		"sex", "spunk", "suckmy", "tits", "tittie", "titty", "vagina", "wank"},
+	"insult": {"asshole", "bastard", "biatch", "bitch", "btch", "cunt", "douchebag", "dumbass", "hoe",
+		"jerk", "nazi", "prick", "slut", "twat", "whore"},
+	"mild": {"arse", "ass", "bollock", "bollok", "bugger", "butt", "crap", "feck", "gtfo", "poop", "turd"},
+}
+
+// categoryDetectors holds one go-away detector per category, in severity order
+var categoryDetectors = buildCategoryDetectors()
+
+// profanityCategory is a detector restricted to one category's terms
+type profanityCategory struct {
+	name     string
+	detector *goaway.ProfanityDetector
+}
+
+// buildCategoryDetectors splits go-away's dictionary by category
+func buildCategoryDetectors() []profanityCategory {
+	categoryOf := make(map[string]string)
+	for category, terms := range profanityTerms {
+		for _, term := range terms {
+			categoryOf[term] = category
+		}
+	}
+	profanities := make(map[string][]string)
+	falseNegatives := make(map[string][]string)
+	for _, term := range goaway.DefaultProfanities {
+		category := categoryOf[term]
+		if category == "" {
+			category = "profanity"
+		}
+		profanities[category] = append(profanities[category], term)
+	}
+	for _, term := range goaway.DefaultFalseNegatives {
+		category := categoryOf[term]
+		if category == "" {
+			category = "profanity"
+		}
+		falseNegatives[category] = append(falseNegatives[category], term)
+	}
+
+	detectors := make([]profanityCategory, 0, len(ProfanityCategories))
+	for _, category := range ProfanityCategories {
+		// Other categories' whole words are false positives here, so "asshole" isn't mild for containing "ass"
+		falsePositives := slices.Clone(goaway.DefaultFalsePositives)
+		for other, terms := range falseNegatives {
+			if other != category {
+				falsePositives = append(falsePositives, terms...)
+			}
+		}
+		detector := goaway.NewProfanityDetector().WithSanitizeLeetSpeak(true).WithSanitizeSpecialCharacters(true).
+			WithCustomDictionary(profanities[category], falsePositives, falseNegatives[category])
+		detectors = append(detectors, profanityCategory{name: category, detector: detector})
+	}
+	return detectors
+}
+
+// ParseProfanityCategories validates a profanity policy's pattern_value: "builtin"
+// or a comma-separated list of categories
+func ParseProfanityCategories(value string) ([]string, error) {
+	if strings.TrimSpace(value) == profanityAll {
+		return nil, nil
+	}
+	var categories []string
+	for _, c := range strings.Split(value, ",") {
+		c = strings.ToLower(strings.TrimSpace(c))
+		if !slices.Contains(ProfanityCategories, c) {
+			return nil, fmt.Errorf("unknown profanity category %q: use builtin or any of %s", c, strings.Join(ProfanityCategories, ", "))
+		}
+		categories = append(categories, c)
+	}
+	return categories, nil
+}
+
+// profanityDetectors returns the detectors a pattern_value selects; values that
+// don't parse (including a composite check's empty value) select every category
+func profanityDetectors(value string) []profanityCategory {
+	categories, err := ParseProfanityCategories(value)
+	if err != nil || len(categories) == 0 {
+		return categoryDetectors
+	}
+	selected := make([]profanityCategory, 0, len(categories))
+	for _, d := range categoryDetectors {
+		if slices.Contains(categories, d.name) {
+			selected = append(selected, d)
+		}
+	}
+	return selected
+}
+
+// matchProfanity checks content for profanity in the categories value selects,
+// reporting the most severe category found and its word as "category:word"
+func (a *Analyzer) matchProfanity(value, content string) (bool, string, error) {
+	detectors := profanityDetectors(value)
+	// One pass over the whole dictionary clears clean content before the per-category scans
+	if len(detectors) == len(categoryDetectors) && !a.profanityDet.IsProfane(content) {
+		return false, "", nil
+	}
+	for _, d := range detectors {
+		if word := d.detector.ExtractProfanity(content); word != "" {
+			return true, d.name + ":" + word, nil
+		}
+	}
+	return false, "", nil
+}
+
+// censorProfanity masks the words of the categories value selects
+func (a *Analyzer) censorProfanity(value, content string) string {
+	detectors := profanityDetectors(value)
+	if len(detectors) == len(categoryDetectors) {
+		return a.profanityDet.Censor(content)
+	}
+	for _, d := range detectors {
+		content = d.detector.Censor(content)
+	}
+	return content
+}
@@ -0,0 +1,79 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestParseProfanityCategories(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"builtin", nil, false},
+		{"slur", []string{"slur"}, false},
+		{" Sexual, insult ", []string{"sexual", "insult"}, false},
+		{"slur,violence", nil, true},
+		{"", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseProfanityCategories(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProfanityCategories(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			}
+			if strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("ParseProfanityCategories(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_ProfanityCategories(t *testing.T) {
+	a := NewAnalyzer(nil)
+	tests := []struct {
+		name        string
+		value       string
+		content     string
+		wantPattern string
+	}{
+		{"builtin reports the word", "builtin", "You are such a b4st4rd", "insult:bastard"},
+		{"most severe category wins", "builtin", "What crap, you bastard", "insult:bastard"},
+		{"mild only", "builtin", "Crap, I forgot the meeting", "mild:crap"},
+		{"category filter ignores others", "slur", "What crap, you bastard", ""},
+		{"whole insult is not mild", "mild", "Don't be an asshole", ""},
+		{"clean content", "builtin", "This is a perfectly clean and professional message", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := models.Policy{ID: uuid.New(), Name: "Profanity", PatternType: "profanity", PatternValue: tt.value, Enabled: true, Severity: "medium", Action: "block"}
+			matches, err := a.Analyze(context.Background(), tt.content, []models.Policy{policy})
+			if err != nil {
+				t.Fatalf("Analyze() error = %v", err)
+			}
+			got := ""
+			if len(matches) > 0 {
+				got = matches[0].MatchedPattern
+			}
+			if got != tt.wantPattern {
+				t.Errorf("matched pattern = %q, want %q", got, tt.wantPattern)
+			}
+		})
+	}
+}
+
+func TestAnalyzer_RedactProfanityCategories(t *testing.T) {
+	a := NewAnalyzer(nil)
+	policy := models.Policy{PatternType: "profanity", PatternValue: "insult", Action: "redact"}
+	got := a.redactPolicy("What crap, you bastard", policy)
+	if got != "What crap, you *******" {
+		t.Errorf("redactPolicy() = %q, want only the insult censored", got)
+	}
+}
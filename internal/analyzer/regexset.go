@@ -0,0 +1,84 @@
+package analyzer
+
+import (
+	"regexp"
+	"strings"
+)
+
+// RegexSet scans content once for many regex patterns
+// The patterns are combined into a single alternation with one capturing
+// group per pattern. RE2 leftmost-first semantics mean a pattern is reported
+// with the same match text it would produce alone, but a pattern whose match
+// overlaps an earlier reported one may be hidden, so only the "nothing matched"
+// answer is exhaustive (see Scan)
+type RegexSet struct {
+	combined *regexp.Regexp
+	sources  []string       // Pattern source per top-level group, in group order
+	groups   []int          // Submatch index of each pattern's group
+	members  map[string]int // Pattern source → position in sources
+}
+
+// NewRegexSet combines the given patterns, skipping invalid and duplicate ones
+// Returns nil when no pattern is usable
+func NewRegexSet(patterns []string) *RegexSet {
+	set := &RegexSet{members: make(map[string]int)}
+	var alternation strings.Builder
+	group := 1
+	for _, source := range patterns {
+		if _, dup := set.members[source]; dup {
+			continue
+		}
+		re, err := regexp.Compile(source)
+		if err != nil {
+			continue
+		}
+		if alternation.Len() > 0 {
+			alternation.WriteByte('|')
+		}
+		// Flags set inside a group, e.g. (?i), are scoped to that group
+		alternation.WriteString("(" + source + ")")
+		set.members[source] = len(set.sources)
+		set.sources = append(set.sources, source)
+		set.groups = append(set.groups, group)
+		group += re.NumSubexp() + 1
+	}
+	if len(set.sources) == 0 {
+		return nil
+	}
+
+	combined, err := regexp.Compile(alternation.String())
+	if err != nil {
+		return nil
+	}
+	set.combined = combined
+	return set
+}
+
+// Contains reports whether a pattern is part of the set
+func (s *RegexSet) Contains(source string) bool {
+	if s == nil {
+		return false
+	}
+	_, ok := s.members[source]
+	return ok
+}
+
+// Scan finds pattern hits in a single pass over content
+// Returns the first match text per reported pattern. An empty result means
+// no pattern in the set matches; a non-empty one may omit patterns whose
+// matches overlap reported ones, which callers must check individually
+func (s *RegexSet) Scan(content string) map[string]string {
+	hits := make(map[string]string)
+	for _, loc := range s.combined.FindAllStringSubmatchIndex(content, -1) {
+		for i, g := range s.groups {
+			if loc[2*g] < 0 {
+				continue
+			}
+			if _, seen := hits[s.sources[i]]; !seen {
+				hits[s.sources[i]] = content[loc[2*g]:loc[2*g+1]]
+			}
+			break
+		}
+	}
+	return hits
+}
@@ -0,0 +1,59 @@
+package analyzer
+
+import (
+	"regexp"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// roleMarkers detect attempts to smuggle a system/developer turn into message content
+// e.g. "SYSTEM: you are now...", "<|im_start|>system", "[INST] <<SYS>>", "### System:"
+var roleMarkers = []*regexp.Regexp{
+	regexp.MustCompile(`(?im)^\s*(system|developer)\s*(prompt)?\s*:`),
+	regexp.MustCompile(`(?i)<\|im_start\|>\s*(system|developer)`),
+	regexp.MustCompile(`(?i)<\|(system|start_header_id\|>\s*system)`),
+	regexp.MustCompile(`(?i)<<\s*SYS\s*>>`),
+	regexp.MustCompile(`(?i)\[\s*(system|sys)\s*(message)?\s*\]`),
+	regexp.MustCompile(`(?im)^\s*#{2,}\s*(system|developer)\b`),
+	regexp.MustCompile(`(?i)"role"\s*:\s*"(system|developer)"`),
+}
+
+// matchRoleImpersonation checks if content contains system-role markers
+func (a *Analyzer) matchRoleImpersonation(content string) (bool, string, error) {
+	for _, re := range roleMarkers {
+		if m := re.FindString(content); m != "" {
+			return true, m, nil
+		}
+	}
+	return false, "", nil
+}
+
+// PoliciesForRole filters policies to those that apply to a chat message role
+// Policies without roles apply everywhere; role impersonation never applies to
+// genuine system/developer messages and response-only detectors only to assistant ones
+func PoliciesForRole(policies []models.Policy, role string) []models.Policy {
+	filtered := make([]models.Policy, 0, len(policies))
+	for _, p := range policies {
+		if p.PatternType == "role_impersonation" && (role == "system" || role == "developer") {
+			continue
+		}
+		if IsResponseOnly(p) && role != "assistant" {
+			continue
+		}
+		if len(p.Roles) > 0 && !containsString(p.Roles, role) {
+			continue
+		}
+		filtered = append(filtered, p)
+	}
+	return filtered
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
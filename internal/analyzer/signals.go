@@ -0,0 +1,100 @@
+package analyzer
+
+import (
+	"math"
+	"strings"
+	"unicode"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// imperativeVerbs open instructions typical of prompt injection and commands
+var imperativeVerbs = map[string]bool{
+	"ignore": true, "forget": true, "disregard": true, "pretend": true, "act": true,
+	"tell": true, "give": true, "show": true, "write": true, "print": true,
+	"reveal": true, "output": true, "list": true, "say": true, "repeat": true,
+	"translate": true, "do": true, "make": true, "stop": true, "answer": true,
+	"respond": true, "bypass": true, "override": true, "execute": true, "run": true,
+	"send": true, "delete": true, "return": true, "explain": true, "describe": true,
+}
+
+// ComputeSignals derives stylometric features from text in a single pass over
+// its characters plus one over its tokens
+func ComputeSignals(text string) models.Signals {
+	var (
+		length, letters, upper, nonASCII int
+		counts                           = make(map[rune]int)
+	)
+	for _, r := range text {
+		length++
+		counts[r]++
+		if r > unicode.MaxASCII {
+			nonASCII++
+		}
+		if unicode.IsLetter(r) {
+			letters++
+			if unicode.IsUpper(r) {
+				upper++
+			}
+		}
+	}
+
+	signals := models.Signals{Length: length}
+	if length == 0 {
+		return signals
+	}
+
+	for _, c := range counts {
+		p := float64(c) / float64(length)
+		signals.Entropy -= p * math.Log2(p)
+	}
+	signals.NonASCIIFraction = float64(nonASCII) / float64(length)
+	if letters > 0 {
+		signals.UppercaseRatio = float64(upper) / float64(letters)
+	}
+
+	tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	signals.WordCount = len(tokens)
+	if len(tokens) > 0 {
+		unique := make(map[string]struct{}, len(tokens))
+		for _, t := range tokens {
+			unique[t] = struct{}{}
+		}
+		signals.RepeatedTokenRatio = 1 - float64(len(unique))/float64(len(tokens))
+	}
+
+	sentences, imperative := 0, 0
+	for _, sentence := range strings.FieldsFunc(text, func(r rune) bool {
+		return r == '.' || r == '!' || r == '?' || r == '\n' || r == ';'
+	}) {
+		words := strings.Fields(sentence)
+		if len(words) == 0 {
+			continue
+		}
+		sentences++
+		first := strings.ToLower(strings.TrimFunc(words[0], func(r rune) bool { return !unicode.IsLetter(r) }))
+		if first == "please" && len(words) > 1 {
+			first = strings.ToLower(strings.TrimFunc(words[1], func(r rune) bool { return !unicode.IsLetter(r) }))
+		}
+		if imperativeVerbs[first] {
+			imperative++
+		}
+	}
+	if sentences > 0 {
+		signals.ImperativeDensity = float64(imperative) / float64(sentences)
+	}
+
+	signals.Entropy = round3(signals.Entropy)
+	signals.UppercaseRatio = round3(signals.UppercaseRatio)
+	signals.RepeatedTokenRatio = round3(signals.RepeatedTokenRatio)
+	signals.NonASCIIFraction = round3(signals.NonASCIIFraction)
+	signals.ImperativeDensity = round3(signals.ImperativeDensity)
+	return signals
+}
+
+// round3 keeps signals readable in JSON
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
@@ -0,0 +1,148 @@
+package analyzer
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/prompt-gateway/internal/ahocorasick"
+)
+
+// irregularForms maps distinctive irregular verb forms to their base so compounds
+// like "jailbroken" stem to "jailbreak". Short forms that commonly end unrelated
+// words ("sent", "told") are left out on purpose
+var irregularForms = []struct{ form, base string }{
+	{"forgotten", "forget"},
+	{"written", "write"},
+	{"broken", "break"},
+	{"stolen", "steal"},
+	{"spoken", "speak"},
+	{"hidden", "hide"},
+	{"chosen", "choose"},
+	{"driven", "drive"},
+	{"forgot", "forget"},
+	{"taken", "take"},
+	{"broke", "break"},
+	{"stole", "steal"},
+	{"wrote", "write"},
+	{"spoke", "speak"},
+	{"chose", "choose"},
+	{"drove", "drive"},
+}
+
+// Stem reduces a word to a crude stem so morphological variants compare equal
+// ("jailbreaking", "jailbreaks", "jailbroken" → "jailbreak"). It is a light
+// suffix stripper, not a linguistic lemmatizer: stems are only ever compared with
+// other stems, so consistency matters more than producing real words
+func Stem(word string) string {
+	w := strings.ToLower(word)
+	if len(w) <= 3 {
+		return w
+	}
+
+	for _, irr := range irregularForms {
+		if w == irr.form || (strings.HasSuffix(w, irr.form) && len(w)-len(irr.form) >= 4) {
+			w = w[:len(w)-len(irr.form)] + irr.base
+			break
+		}
+	}
+
+	switch {
+	case strings.HasSuffix(w, "ies") && len(w) > 4:
+		w = w[:len(w)-3] + "y"
+	case strings.HasSuffix(w, "sses"):
+		w = w[:len(w)-2]
+	case strings.HasSuffix(w, "s") && !strings.HasSuffix(w, "ss") && !strings.HasSuffix(w, "us") && len(w) > 3:
+		w = w[:len(w)-1]
+	}
+
+	for _, suffix := range []string{"ing", "ed", "er", "ly"} {
+		if strings.HasSuffix(w, suffix) {
+			rest := w[:len(w)-len(suffix)]
+			if len(rest) >= 3 && hasVowel(rest) {
+				w = undouble(rest)
+			}
+			break
+		}
+	}
+
+	if len(w) > 4 && strings.HasSuffix(w, "e") {
+		w = w[:len(w)-1]
+	}
+	return w
+}
+
+// hasVowel reports whether s contains an ASCII vowel (or y)
+func hasVowel(s string) bool {
+	return strings.ContainsAny(s, "aeiouy")
+}
+
+// undouble drops a doubled final consonant ("stopp" → "stop") except l, s and z
+func undouble(s string) string {
+	n := len(s)
+	if n >= 2 && s[n-1] == s[n-2] && !strings.ContainsRune("aeioulsz", rune(s[n-1])) {
+		return s[:n-1]
+	}
+	return s
+}
+
+// stemToken is a stemmed word with its byte span in the original text
+type stemToken struct {
+	stem       string
+	start, end int
+}
+
+// stemTokens splits text into letter/digit words and stems each one
+func stemTokens(text string) []stemToken {
+	var tokens []stemToken
+	start := -1
+	for i, r := range text {
+		word := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if word && start < 0 {
+			start = i
+		}
+		if !word && start >= 0 {
+			tokens = append(tokens, stemToken{stem: Stem(text[start:i]), start: start, end: i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		tokens = append(tokens, stemToken{stem: Stem(text[start:]), start: start, end: len(text)})
+	}
+	return tokens
+}
+
+// findStemmed returns the spans of content whose stemmed words equal the
+// stemmed words of phrase, in order
+func findStemmed(phrase, content string) []ahocorasick.Match {
+	want := stemTokens(phrase)
+	if len(want) == 0 {
+		return nil
+	}
+	tokens := stemTokens(content)
+
+	var matches []ahocorasick.Match
+	for i := 0; i+len(want) <= len(tokens); i++ {
+		ok := true
+		for j := range want {
+			if tokens[i+j].stem != want[j].stem {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			matches = append(matches, ahocorasick.Match{Start: tokens[i].start, End: tokens[i+len(want)-1].end})
+			i += len(want) - 1
+		}
+	}
+	return matches
+}
+
+// matchStemmedKeyword matches a keyword against content word by word after
+// stemming both, returning the first matching text as written
+func matchStemmedKeyword(keyword, content string) (bool, string) {
+	matches := findStemmed(keyword, content)
+	if len(matches) == 0 {
+		return false, ""
+	}
+	return true, content[matches[0].Start:matches[0].End]
+}
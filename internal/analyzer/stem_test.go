@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestStem(t *testing.T) {
+	groups := [][]string{
+		{"jailbreak", "jailbreaks", "jailbreaking", "jailbroken", "jailbroke", "Jailbreaker"},
+		{"ignore", "ignored", "ignores", "ignoring"},
+		{"policy", "policies"},
+		{"stop", "stopped", "stopping"},
+		{"bypass", "bypassed", "bypassing", "bypasses"},
+	}
+	for _, group := range groups {
+		want := Stem(group[0])
+		for _, word := range group[1:] {
+			if got := Stem(word); got != want {
+				t.Errorf("Stem(%q) = %q, want %q (stem of %q)", word, got, want, group[0])
+			}
+		}
+	}
+
+	if Stem("token") == Stem("taken") {
+		t.Error("Stem() should not rewrite irregular forms inside unrelated words")
+	}
+}
+
+func TestAnalyzer_StemmedKeyword(t *testing.T) {
+	a := NewAnalyzer(nil)
+	policy := models.Policy{ID: uuid.New(), Name: "jb", PatternType: "keyword", PatternValue: "jailbreak prompt", Action: "redact", Enabled: true, Stem: true}
+
+	content := "They shared jailbroken prompts, and more Jailbreaking Prompts later."
+	matches, err := a.Analyze(context.Background(), content, []models.Policy{policy})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(matches) != 1 || matches[0].MatchedPattern != "jailbroken prompts" {
+		t.Fatalf("Analyze() = %+v, want match on %q", matches, "jailbroken prompts")
+	}
+
+	got := a.RedactContent(content, matches, []models.Policy{policy})
+	want := "They shared [REDACTED], and more [REDACTED] later."
+	if got != want {
+		t.Errorf("RedactContent() = %q, want %q", got, want)
+	}
+
+	if matches, _ := a.Analyze(context.Background(), "a prompt about jailbreaks", []models.Policy{policy}); len(matches) != 0 {
+		t.Errorf("Analyze() = %v, want words out of order not to match", matches)
+	}
+}
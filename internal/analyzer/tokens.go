@@ -0,0 +1,55 @@
+package analyzer
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/internal/tokenizer"
+)
+
+// SetTokenizer counts tokens for "max_tokens" policies with tok (nil estimates)
+// and, when modelMaxTokens > 0, truncates content to that many tokens before it is
+// sent to a content-safety model
+// Must be called before the analyzer is used
+func (a *Analyzer) SetTokenizer(tok *tokenizer.Tokenizer, modelMaxTokens int) {
+	a.tokenizer = tok
+	a.modelTokens = modelMaxTokens
+}
+
+// ParseMaxTokens parses the pattern value of a "max_tokens" policy
+func ParseMaxTokens(value string) (int, error) {
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("max_tokens pattern_value must be a positive integer")
+	}
+	return limit, nil
+}
+
+// matchMaxTokens matches content holding more tokens than the policy allows
+func (a *Analyzer) matchMaxTokens(value, content string) (bool, string, error) {
+	limit, err := ParseMaxTokens(value)
+	if err != nil {
+		return false, "", err
+	}
+	if count := a.tokenizer.Count(content); count > limit {
+		return true, fmt.Sprintf("%d tokens > %d", count, limit), nil
+	}
+	return false, "", nil
+}
+
+// modelInput returns content cut to the model token budget
+func (a *Analyzer) modelInput(content string, providerLimit int) string {
+	limit := a.modelTokens
+	if providerLimit > 0 && (limit <= 0 || providerLimit < limit) {
+		limit = providerLimit
+	}
+	if limit <= 0 {
+		return content
+	}
+	truncated, cut := a.tokenizer.Truncate(content, limit)
+	if cut {
+		metrics.ModelInputTruncatedTotal.Inc()
+	}
+	return truncated
+}
@@ -0,0 +1,51 @@
+package analyzer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// recordingModelClient remembers the content it was asked to evaluate
+type recordingModelClient struct {
+	content string
+}
+
+func (r *recordingModelClient) Evaluate(ctx context.Context, model string, content string) (ModelEvaluation, error) {
+	r.content = content
+	return ModelEvaluation{}, nil
+}
+
+func TestAnalyzer_MaxTokens(t *testing.T) {
+	a := NewAnalyzer(nil)
+	policy := models.Policy{ID: uuid.New(), Name: "budget", PatternType: "max_tokens", PatternValue: "5", Action: "block", Enabled: true}
+
+	matches, err := a.Analyze(context.Background(), "short prompt", []models.Policy{policy})
+	if err != nil || len(matches) != 0 {
+		t.Fatalf("Analyze(short) = %v, %v, want no match", matches, err)
+	}
+	matches, err = a.Analyze(context.Background(), "a considerably longer prompt than allowed", []models.Policy{policy})
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("Analyze(long) = %v, %v, want one match", matches, err)
+	}
+	if !strings.HasSuffix(matches[0].MatchedPattern, "tokens > 5") {
+		t.Errorf("MatchedPattern = %q, want token count", matches[0].MatchedPattern)
+	}
+}
+
+func TestAnalyzer_ModelTokenLimit(t *testing.T) {
+	model := &recordingModelClient{}
+	a := NewAnalyzer(model)
+	a.SetTokenizer(nil, 2)
+	policy := models.Policy{ID: uuid.New(), Name: "safety", PatternType: "model", PatternValue: "safety", Enabled: true}
+
+	if _, err := a.Analyze(context.Background(), "hello wonderful world", []models.Policy{policy}); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if model.content != "hello" {
+		t.Errorf("model saw %q, want content cut to 2 tokens", model.content)
+	}
+}
@@ -0,0 +1,154 @@
+package analyzer
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Trace statuses
+const (
+	TraceMatched    = "matched"
+	TraceNotMatched = "not_matched"
+	TraceSkipped    = "skipped"
+	TraceError      = "error"
+)
+
+// Trace collects a per-policy record of every Analyze call made with its
+// context, for debug responses
+type Trace struct {
+	mu      sync.Mutex
+	entries []models.PolicyTrace
+}
+
+type traceKey struct{}
+type traceTargetKey struct{}
+type traceNoteKey struct{}
+
+// WithTrace returns a context in which Analyze records each policy evaluation
+func WithTrace(ctx context.Context) (context.Context, *Trace) {
+	t := &Trace{entries: []models.PolicyTrace{}}
+	return context.WithValue(ctx, traceKey{}, t), t
+}
+
+// WithTraceTarget labels the entries of Analyze calls made with ctx with the part
+// of the request being analyzed (e.g. "messages[2]"); a no-op when not tracing
+func WithTraceTarget(ctx context.Context, target string) context.Context {
+	if ctx.Value(traceKey{}) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, traceTargetKey{}, target)
+}
+
+// TraceTarget returns the label set by WithTraceTarget
+func TraceTarget(ctx context.Context) string {
+	target, _ := ctx.Value(traceTargetKey{}).(string)
+	return target
+}
+
+// Skip records a policy that was left out before analysis, e.g. one that doesn't
+// apply to the client; a nil Trace ignores it
+func (t *Trace) Skip(p models.Policy, target, reason string) {
+	if t == nil {
+		return
+	}
+	t.add(models.PolicyTrace{
+		PolicyID:    p.ID,
+		PolicyName:  p.Name,
+		PatternType: p.PatternType,
+		Target:      target,
+		Status:      TraceSkipped,
+		Reason:      reason,
+	})
+}
+
+// Entries returns the recorded evaluations in the order they finished
+func (t *Trace) Entries() []models.PolicyTrace {
+	if t == nil {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	out := make([]models.PolicyTrace, len(t.entries))
+	copy(out, t.entries)
+	return out
+}
+
+func (t *Trace) add(entry models.PolicyTrace) {
+	t.mu.Lock()
+	t.entries = append(t.entries, entry)
+	t.mu.Unlock()
+}
+
+// callTrace records the policies of one Analyze call, each at most once
+type callTrace struct {
+	trace  *Trace
+	target string
+
+	mu       sync.Mutex
+	recorded map[uuid.UUID]bool
+}
+
+// traceCall starts recording an Analyze call, or returns nil when ctx isn't traced
+func traceCall(ctx context.Context) *callTrace {
+	t, ok := ctx.Value(traceKey{}).(*Trace)
+	if !ok {
+		return nil
+	}
+	return &callTrace{trace: t, target: TraceTarget(ctx), recorded: make(map[uuid.UUID]bool)}
+}
+
+// record adds a policy's outcome unless one was already recorded for this call
+func (c *callTrace) record(p models.Policy, status, reason string, d time.Duration, err error) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	if c.recorded[p.ID] {
+		c.mu.Unlock()
+		return
+	}
+	c.recorded[p.ID] = true
+	c.mu.Unlock()
+
+	entry := models.PolicyTrace{
+		PolicyID:    p.ID,
+		PolicyName:  p.Name,
+		PatternType: p.PatternType,
+		Target:      c.target,
+		Status:      status,
+		Reason:      reason,
+		DurationUs:  d.Microseconds(),
+	}
+	if err != nil {
+		entry.Error = err.Error()
+	}
+	c.trace.add(entry)
+}
+
+// finish records every policy of the call not yet accounted for as skipped
+func (c *callTrace) finish(policies []models.Policy, reason string) {
+	if c == nil {
+		return
+	}
+	for _, p := range policies {
+		c.record(p, TraceSkipped, reason, 0, nil)
+	}
+}
+
+// withTraceNote gives one policy check a place to explain a non-match, e.g. a
+// model policy skipped because its provider is down
+func withTraceNote(ctx context.Context) (context.Context, *string) {
+	note := new(string)
+	return context.WithValue(ctx, traceNoteKey{}, note), note
+}
+
+// noteTrace explains the current policy's outcome when it is being traced
+func noteTrace(ctx context.Context, reason string) {
+	if note, ok := ctx.Value(traceNoteKey{}).(*string); ok {
+		*note = reason
+	}
+}
@@ -0,0 +1,67 @@
+package analyzer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestAnalyzer_Trace(t *testing.T) {
+	client := &countingModelClient{down: true}
+	a := NewAnalyzer(client)
+	a.SetModelBreaker(breaker.New("trace_test", 5, time.Minute))
+	policies := []models.Policy{
+		{ID: uuid.New(), Name: "secret", PatternType: "keyword", PatternValue: "password", Enabled: true},
+		{ID: uuid.New(), Name: "email", PatternType: "regex", PatternValue: `\w+@\w+\.com`, Enabled: true},
+		{ID: uuid.New(), Name: "retired", PatternType: "keyword", PatternValue: "legacy", Enabled: false},
+		{ID: uuid.New(), Name: "safety", PatternType: "model", PatternValue: "m", Enabled: true},
+	}
+
+	ctx, trace := WithTrace(WithDegradation(context.Background()))
+	if _, err := a.Analyze(WithTraceTarget(ctx, "messages[0]"), "hello there", policies); err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+
+	want := map[string]struct{ status, reason string }{
+		"secret":  {TraceNotMatched, ""},
+		"email":   {TraceNotMatched, ""},
+		"retired": {TraceSkipped, "disabled"},
+		"safety":  {TraceSkipped, "model provider unavailable"},
+	}
+	entries := trace.Entries()
+	if len(entries) != len(want) {
+		t.Fatalf("Entries() = %+v, want %d entries", entries, len(want))
+	}
+	for _, e := range entries {
+		w := want[e.PolicyName]
+		if e.Status != w.status || e.Reason != w.reason || e.Target != "messages[0]" {
+			t.Errorf("entry %s = %s/%q on %s, want %s/%q on messages[0]", e.PolicyName, e.Status, e.Reason, e.Target, w.status, w.reason)
+		}
+	}
+
+	// With a match, every policy is still accounted for once
+	ctx, trace = WithTrace(context.Background())
+	matches, err := a.Analyze(ctx, "my password", policies[:3])
+	if err != nil || len(matches) != 1 {
+		t.Fatalf("Analyze() = %v, %v, want one match", matches, err)
+	}
+	seen := map[string]string{}
+	for _, e := range trace.Entries() {
+		if _, dup := seen[e.PolicyName]; dup {
+			t.Errorf("policy %s traced twice", e.PolicyName)
+		}
+		seen[e.PolicyName] = e.Status
+	}
+	if seen["secret"] != TraceMatched || len(seen) != 3 {
+		t.Errorf("statuses = %v, want secret matched and 3 entries", seen)
+	}
+
+	// Untraced contexts record nothing
+	if got := WithTraceTarget(context.Background(), "prompt"); TraceTarget(got) != "" {
+		t.Errorf("WithTraceTarget() without a trace set target %q", TraceTarget(got))
+	}
+}
@@ -0,0 +1,235 @@
+package anomaly
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Config holds detector tuning parameters
+type Config struct {
+	Window        time.Duration // Length of one observation window
+	ZScore        float64       // Deviation (in std devs) that counts as anomalous
+	WarmupWindows int           // Windows of history required before alerting
+	BufferSize    int           // Size of the decision event channel
+}
+
+// DefaultConfig returns sensible defaults for the anomaly detector
+func DefaultConfig() Config {
+	return Config{
+		Window:        time.Minute,
+		ZScore:        3.0,
+		WarmupWindows: 10,
+		BufferSize:    10000,
+	}
+}
+
+// ewmaAlpha weights the most recent window when updating baselines
+const ewmaAlpha = 0.1
+
+// baseline tracks an exponentially weighted mean and variance
+type baseline struct {
+	mean     float64
+	variance float64
+	samples  int
+}
+
+// update folds a new observation into the baseline
+func (b *baseline) update(x float64) {
+	if b.samples == 0 {
+		b.mean = x
+		b.samples = 1
+		return
+	}
+	diff := x - b.mean
+	incr := ewmaAlpha * diff
+	b.mean += incr
+	b.variance = (1 - ewmaAlpha) * (b.variance + diff*incr)
+	b.samples++
+}
+
+// zscore returns how many standard deviations x is above the mean
+// A small floor on the deviation keeps perfectly flat baselines from alerting on noise
+func (b *baseline) zscore(x, minStdDev float64) float64 {
+	std := math.Max(math.Sqrt(b.variance), minStdDev)
+	return (x - b.mean) / std
+}
+
+// clientState holds the current window and baselines for one client
+type clientState struct {
+	requests      int
+	violations    int
+	latencySum    int
+	violationRate baseline
+	latency       baseline
+	seenPolicies  map[uuid.UUID]bool
+	idleWindows   int
+}
+
+// Detector watches the decision stream for per-client behavior changes
+type Detector struct {
+	config   Config
+	notifier *notify.Notifier
+	events   chan models.DecisionEvent
+	clients  map[string]*clientState // Owned by the worker goroutine
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewDetector creates a Detector that reports to notifier
+func NewDetector(config Config, notifier *notify.Notifier) *Detector {
+	return &Detector{
+		config:   config,
+		notifier: notifier,
+		events:   make(chan models.DecisionEvent, config.BufferSize),
+		clients:  make(map[string]*clientState),
+		stopCh:   make(chan struct{}),
+	}
+}
+
+// Start launches the background evaluation worker
+func (d *Detector) Start() {
+	d.wg.Add(1)
+	go d.worker()
+	slog.Info("Anomaly detector started", "window", d.config.Window, "z_score", d.config.ZScore)
+}
+
+// Observe queues a decision for analysis (non-blocking)
+func (d *Detector) Observe(event models.DecisionEvent) {
+	select {
+	case d.events <- event:
+	default:
+		// Detector is best-effort; never slow down the request path
+	}
+}
+
+// worker consumes decisions and closes windows on every tick
+func (d *Detector) worker() {
+	defer d.wg.Done()
+
+	ticker := time.NewTicker(d.config.Window)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case event := <-d.events:
+			d.record(event)
+		case <-ticker.C:
+			d.closeWindow()
+		case <-d.stopCh:
+			slog.Info("Anomaly detector stopped")
+			return
+		}
+	}
+}
+
+// record adds a decision to the client's current window
+func (d *Detector) record(event models.DecisionEvent) {
+	clientID := event.Audit.ClientID
+	state, ok := d.clients[clientID]
+	if !ok {
+		state = &clientState{seenPolicies: make(map[uuid.UUID]bool)}
+		d.clients[clientID] = state
+	}
+
+	state.requests++
+	state.latencySum += event.Audit.LatencyMs
+	if event.Audit.ActionTaken != "allow" {
+		state.violations++
+	}
+
+	warmedUp := state.violationRate.samples >= d.config.WarmupWindows
+	for _, match := range event.Matches {
+		if state.seenPolicies[match.PolicyID] {
+			continue
+		}
+		state.seenPolicies[match.PolicyID] = true
+		if warmedUp {
+			d.emit(notify.Event{
+				Type:     "anomaly.novel_pattern",
+				Severity: match.Severity,
+				ClientID: clientID,
+				Summary:  fmt.Sprintf("client %s triggered policy %q for the first time", clientID, match.PolicyName),
+				Details: map[string]interface{}{
+					"policy_id":   match.PolicyID,
+					"policy_name": match.PolicyName,
+					"request_id":  event.Audit.RequestID,
+				},
+			})
+		}
+	}
+}
+
+// closeWindow compares each client's window with its baseline and folds it in
+func (d *Detector) closeWindow() {
+	for clientID, state := range d.clients {
+		if state.requests == 0 {
+			state.idleWindows++
+			// Forget clients that have gone quiet to bound memory
+			if state.idleWindows > 60 {
+				delete(d.clients, clientID)
+			}
+			continue
+		}
+		state.idleWindows = 0
+
+		rate := float64(state.violations) / float64(state.requests)
+		avgLatency := float64(state.latencySum) / float64(state.requests)
+
+		if state.violationRate.samples >= d.config.WarmupWindows {
+			if z := state.violationRate.zscore(rate, 0.02); z >= d.config.ZScore {
+				d.emit(notify.Event{
+					Type:     "anomaly.violation_rate",
+					Severity: "high",
+					ClientID: clientID,
+					Summary:  fmt.Sprintf("client %s violation rate %.1f%% vs baseline %.1f%%", clientID, rate*100, state.violationRate.mean*100),
+					Details: map[string]interface{}{
+						"rate":     rate,
+						"baseline": state.violationRate.mean,
+						"z_score":  z,
+						"requests": state.requests,
+					},
+				})
+			}
+			if z := state.latency.zscore(avgLatency, 1); z >= d.config.ZScore {
+				d.emit(notify.Event{
+					Type:     "anomaly.latency",
+					Severity: "medium",
+					ClientID: clientID,
+					Summary:  fmt.Sprintf("client %s average latency %.0fms vs baseline %.0fms", clientID, avgLatency, state.latency.mean),
+					Details: map[string]interface{}{
+						"latency_ms":  avgLatency,
+						"baseline_ms": state.latency.mean,
+						"z_score":     z,
+					},
+				})
+			}
+		}
+
+		state.violationRate.update(rate)
+		state.latency.update(avgLatency)
+		state.requests, state.violations, state.latencySum = 0, 0, 0
+	}
+}
+
+// emit forwards an event to the notifier if one is configured
+func (d *Detector) emit(event notify.Event) {
+	if d.notifier != nil {
+		d.notifier.Notify(event)
+	}
+}
+
+// Stop gracefully stops the background worker
+func (d *Detector) Stop() {
+	d.stopOnce.Do(func() {
+		close(d.stopCh)
+	})
+	d.wg.Wait()
+}
@@ -0,0 +1,91 @@
+package anomaly
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// captureSink records delivered events for assertions
+type captureSink struct {
+	mu     sync.Mutex
+	events []notify.Event
+}
+
+func (c *captureSink) Name() string { return "capture" }
+
+func (c *captureSink) Send(ctx context.Context, event notify.Event) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.events = append(c.events, event)
+	return nil
+}
+
+func (c *captureSink) types() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	types := make([]string, len(c.events))
+	for i, e := range c.events {
+		types[i] = e.Type
+	}
+	return types
+}
+
+func decision(clientID, action string, matches ...models.PolicyMatch) models.DecisionEvent {
+	return models.DecisionEvent{
+		Audit:   models.AuditLog{ClientID: clientID, ActionTaken: action, LatencyMs: 5},
+		Matches: matches,
+	}
+}
+
+func TestDetector_ViolationRateSpike(t *testing.T) {
+	sink := &captureSink{}
+	notifier := notify.NewNotifier(100, sink)
+	d := NewDetector(Config{ZScore: 3, WarmupWindows: 5}, notifier)
+
+	// Build a quiet baseline: 1 violation in 20 requests per window
+	for w := 0; w < 10; w++ {
+		for i := 0; i < 19; i++ {
+			d.record(decision("client-a", "allow"))
+		}
+		d.record(decision("client-a", "block"))
+		d.closeWindow()
+	}
+
+	// Sudden spike: every request is blocked
+	for i := 0; i < 20; i++ {
+		d.record(decision("client-a", "block"))
+	}
+	d.closeWindow()
+	notifier.Close()
+
+	got := sink.types()
+	if len(got) != 1 || got[0] != "anomaly.violation_rate" {
+		t.Errorf("events = %v, want [anomaly.violation_rate]", got)
+	}
+}
+
+func TestDetector_NovelPatternAfterWarmup(t *testing.T) {
+	sink := &captureSink{}
+	notifier := notify.NewNotifier(100, sink)
+	d := NewDetector(Config{ZScore: 3, WarmupWindows: 2}, notifier)
+
+	known := models.PolicyMatch{PolicyID: uuid.New(), PolicyName: "known", Severity: "low"}
+	for w := 0; w < 3; w++ {
+		d.record(decision("client-a", "log", known))
+		d.closeWindow()
+	}
+
+	d.record(decision("client-a", "log", known))
+	d.record(decision("client-a", "block", models.PolicyMatch{PolicyID: uuid.New(), PolicyName: "new", Severity: "high"}))
+	notifier.Close()
+
+	got := sink.types()
+	if len(got) != 1 || got[0] != "anomaly.novel_pattern" {
+		t.Errorf("events = %v, want [anomaly.novel_pattern]", got)
+	}
+}
@@ -0,0 +1,144 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"html"
+	"mime"
+	"regexp"
+	"strings"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/jsonpath"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// maxAttachments bounds the attachments evaluated for one request
+const maxAttachments = 32
+
+// Attachment content kinds, chosen from the declared MIME type
+const (
+	attachmentText   = "text"   // Scanned as-is and redactable
+	attachmentMarkup = "markup" // HTML/XML: tags stripped, entities decoded before scanning
+	attachmentJSON   = "json"   // String fields scanned individually, matches tagged with their path
+)
+
+// markupTag matches HTML/XML tags, comments and declarations
+var markupTag = regexp.MustCompile(`(?s)<!--.*?-->|<[!?/]?[A-Za-z][^>]*>`)
+
+// attachmentKind maps a declared MIME type to how its content is scanned
+// Only textual types are accepted: binary files must be extracted to text first
+func attachmentKind(mimeType string) (string, error) {
+	mediaType, _, err := mime.ParseMediaType(mimeType)
+	if err != nil {
+		return "", fmt.Errorf("invalid mime_type %q", mimeType)
+	}
+	switch {
+	case mediaType == "text/html" || mediaType == "application/xhtml+xml" ||
+		mediaType == "text/xml" || mediaType == "application/xml" || strings.HasSuffix(mediaType, "+xml"):
+		return attachmentMarkup, nil
+	case mediaType == "application/json" || strings.HasSuffix(mediaType, "+json"):
+		return attachmentJSON, nil
+	case strings.HasPrefix(mediaType, "text/"),
+		mediaType == "application/yaml", mediaType == "application/x-yaml",
+		mediaType == "application/sql", mediaType == "application/x-ndjson":
+		return attachmentText, nil
+	default:
+		return "", fmt.Errorf("unsupported mime_type %q: send a plain-text extract", mimeType)
+	}
+}
+
+// validateAttachments checks attachments declare a supported MIME type and, for
+// JSON ones, carry valid JSON
+func validateAttachments(attachments []models.Attachment) error {
+	if len(attachments) > maxAttachments {
+		return fmt.Errorf("at most %d attachments are allowed", maxAttachments)
+	}
+	for i, att := range attachments {
+		kind, err := attachmentKind(att.MimeType)
+		if err != nil {
+			return fmt.Errorf("attachments[%d]: %v", i, err)
+		}
+		if kind == attachmentJSON && !json.Valid([]byte(att.Content)) {
+			return fmt.Errorf("attachments[%d]: content is not valid JSON", i)
+		}
+	}
+	return nil
+}
+
+// stripMarkup returns the text of an HTML/XML document
+func stripMarkup(content string) string {
+	return html.UnescapeString(markupTag.ReplaceAllString(content, " "))
+}
+
+// analyzeAttachments evaluates each attachment with the policies for user content
+// and returns per-attachment verdicts plus all matches tagged with their index
+func (h *Handler) analyzeAttachments(ctx context.Context, attachments []models.Attachment, policies []models.Policy) ([]models.AttachmentVerdict, []models.PolicyMatch, error) {
+	userPolicies := analyzer.PoliciesForRole(policies, "user")
+	verdicts := make([]models.AttachmentVerdict, len(attachments))
+	allMatches := make([]models.PolicyMatch, 0, len(attachments))
+
+	for i, att := range attachments {
+		kind, _ := attachmentKind(att.MimeType)
+		ctx := analyzer.WithAttachment(analyzer.WithTraceTarget(ctx, fmt.Sprintf("attachments[%d]", i)))
+		var (
+			matches []models.PolicyMatch
+			err     error
+		)
+		switch kind {
+		case attachmentJSON:
+			var leaves []jsonpath.Leaf
+			leaves, err = selectDocumentFields(json.RawMessage(att.Content), nil, nil)
+			if err == nil {
+				matches, err = h.analyzeFields(ctx, leaves, userPolicies)
+			}
+		case attachmentMarkup:
+			matches, err = h.analyzer.Analyze(ctx, stripMarkup(att.Content), userPolicies)
+		default:
+			matches, err = h.analyzer.Analyze(ctx, att.Content, userPolicies)
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("attachment %d: %w", i, err)
+		}
+
+		action, allowed, _ := resolveDecision(matches, userPolicies)
+		verdict := models.AttachmentVerdict{
+			Index:             i,
+			Filename:          att.Filename,
+			MimeType:          att.MimeType,
+			Allowed:           allowed,
+			Action:            action,
+			TriggeredPolicies: matches,
+		}
+		if len(matches) > 0 && kind == attachmentText {
+			if redacted := h.redact(ctx, att.Content, matches, userPolicies); redacted != att.Content {
+				verdict.RedactedContent = redacted
+			}
+		}
+		verdicts[i] = verdict
+
+		for _, m := range matches {
+			index := i
+			m.AttachmentIndex = &index
+			allMatches = append(allMatches, m)
+		}
+	}
+
+	return verdicts, allMatches, nil
+}
+
+// attachmentsText returns the content of all attachments, for hashing and signals
+func attachmentsText(attachments []models.Attachment) string {
+	var b strings.Builder
+	for _, att := range attachments {
+		b.WriteString("attachment ")
+		b.WriteString(att.Filename)
+		b.WriteString(" (")
+		b.WriteString(att.MimeType)
+		b.WriteString("): ")
+		b.WriteString(att.Content)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/scheduler"
+	"github.com/prompt-gateway/internal/usermessage"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Batch analyze limits used until SetBatchAnalyze is called
+const (
+	defaultBatchWorkers  = 8
+	defaultBatchMaxItems = 100
+)
+
+// SetBatchAnalyze bounds POST /v1/analyze/batch: workers items of one batch are
+// evaluated concurrently and at most maxItems are accepted per batch
+func (h *Handler) SetBatchAnalyze(workers, maxItems int) {
+	h.batchWorkers = workers
+	h.batchItems = maxItems
+}
+
+// HandleAnalyzeBatch evaluates several analyze requests in one call
+// POST /v1/analyze/batch
+// Items run on a bounded worker pool and default to batch priority. A failed item
+// doesn't fail the batch: its result carries the error /v1/analyze would return
+func (h *Handler) HandleAnalyzeBatch(w http.ResponseWriter, r *http.Request) {
+	startTime := time.Now()
+
+	var req models.BatchAnalyzeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		slog.ErrorContext(r.Context(), "Error decoding JSON", "error", err)
+		respondDecodeError(w, err, "Invalid request body", models.ErrorDetail{Reason: err.Error()})
+		return
+	}
+	maxItems := h.batchItems
+	if maxItems <= 0 {
+		maxItems = defaultBatchMaxItems
+	}
+	if len(req.Items) == 0 {
+		respondErrorCode(w, http.StatusBadRequest, codeInvalidRequest, "items is required", models.ErrorDetail{Field: "items", Reason: "empty"})
+		return
+	}
+	if len(req.Items) > maxItems {
+		respondErrorCode(w, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("at most %d items per batch", maxItems), models.ErrorDetail{Field: "items", Reason: fmt.Sprintf("%d items", len(req.Items))})
+		return
+	}
+	// A batch is refused as a whole rather than failing item by item
+	if h.maintenance != nil && h.maintenance.Enabled() {
+		respondMaintenance(w, h.maintenance.RetryAfter())
+		return
+	}
+
+	for i := range req.Items {
+		item := &req.Items[i]
+		if item.Priority == "" {
+			item.Priority = scheduler.PriorityBatch
+		}
+		if item.CacheControl == "" {
+			item.CacheControl = r.Header.Get("Cache-Control")
+		}
+		if item.Locale == "" {
+			item.Locale = usermessage.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+		}
+	}
+
+	results := h.evaluateBatch(r.Context(), req.Items)
+	response := models.BatchAnalyzeResponse{Results: results}
+	for _, result := range results {
+		if result.Error != nil {
+			response.Failed++
+		}
+	}
+	response.LatencyMs = time.Since(startTime).Milliseconds()
+	respondJSON(w, http.StatusOK, response)
+}
+
+// evaluateBatch evaluates items on at most h.batchWorkers goroutines and returns
+// their results in item order
+func (h *Handler) evaluateBatch(ctx context.Context, items []models.AnalyzeRequest) []models.BatchAnalyzeResult {
+	workers := h.batchWorkers
+	if workers <= 0 {
+		workers = defaultBatchWorkers
+	}
+	results := make([]models.BatchAnalyzeResult, len(items))
+	next := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results[i] = h.evaluateBatchItem(ctx, i, items[i])
+			}
+		}()
+	}
+	for i := range items {
+		next <- i
+	}
+	close(next)
+	wg.Wait()
+	return results
+}
+
+// evaluateBatchItem evaluates one item of a batch
+func (h *Handler) evaluateBatchItem(ctx context.Context, index int, item models.AnalyzeRequest) models.BatchAnalyzeResult {
+	response, err := h.Evaluate(ctx, item)
+	if err != nil {
+		status, apiErr := EvaluateFailure(ctx, err)
+		return models.BatchAnalyzeResult{Index: index, Status: status, Error: &apiErr}
+	}
+	return models.BatchAnalyzeResult{Index: index, Status: http.StatusOK, Result: response}
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// maxRequestBodyBytes caps the JSON request bodies decodeJSON buffers
+const maxRequestBodyBytes = 10 << 20
+
+// maxPooledBufferSize keeps unusually large bodies from pinning memory in the pool
+const maxPooledBufferSize = 64 << 10
+
+// bufferPool recycles request and response body buffers across requests
+var bufferPool = sync.Pool{
+	New: func() interface{} { return new(bytes.Buffer) },
+}
+
+// getBuffer takes an empty buffer from the pool
+func getBuffer() *bytes.Buffer {
+	buf := bufferPool.Get().(*bytes.Buffer)
+	buf.Reset()
+	return buf
+}
+
+// putBuffer returns a buffer to the pool unless it grew too large
+func putBuffer(buf *bytes.Buffer) {
+	if buf.Cap() > maxPooledBufferSize {
+		return
+	}
+	bufferPool.Put(buf)
+}
+
+// decodeJSON reads the request body into a pooled buffer and decodes it into v
+// Decoded values never alias the buffer (strings and RawMessage are copied)
+// Bodies over maxRequestBodyBytes fail with *http.MaxBytesError before they are
+// buffered, and the body must be a single JSON value: trailing data after it is
+// rejected rather than ignored
+func decodeJSON(r *http.Request, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if _, err := buf.ReadFrom(http.MaxBytesReader(nil, r.Body, maxRequestBodyBytes)); err != nil {
+		return err
+	}
+	if buf.Len() == 0 {
+		return io.EOF
+	}
+	return json.Unmarshal(buf.Bytes(), v)
+}
+
+// respondDecodeError answers a body decodeJSON rejected: 413 when it was over
+// maxRequestBodyBytes, otherwise 400 with message and details
+func respondDecodeError(w http.ResponseWriter, err error, message string, details ...models.ErrorDetail) {
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		respondError(w, http.StatusRequestEntityTooLarge, "Request body exceeds "+strconv.Itoa(maxRequestBodyBytes)+" bytes")
+		return
+	}
+	respondErrorCode(w, http.StatusBadRequest, codeInvalidRequest, message, details...)
+}
+
+// encodeJSON writes v as JSON through a pooled buffer
+// Encoding fully before writing also lets us set Content-Length
+func encodeJSON(w http.ResponseWriter, status int, v interface{}) error {
+	buf := getBuffer()
+	defer putBuffer(buf)
+
+	if err := json.NewEncoder(buf).Encode(v); err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		return err
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(buf.Len()))
+	w.WriteHeader(status)
+	_, err := w.Write(buf.Bytes())
+	return err
+}
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodeJSON(t *testing.T) {
+	decode := func(body string) (map[string]interface{}, error) {
+		var v map[string]interface{}
+		err := decodeJSON(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &v)
+		return v, err
+	}
+
+	if v, err := decode(`{"prompt": "hi"}`); err != nil || v["prompt"] != "hi" {
+		t.Errorf("decode(valid) = %v, %v", v, err)
+	}
+	if _, err := decode(""); !errors.Is(err, io.EOF) {
+		t.Errorf("decode(empty) error = %v, want io.EOF", err)
+	}
+	// Unlike json.Decoder, a second value after the body is an error, not ignored
+	if _, err := decode(`{"prompt": "hi"} {"prompt": "smuggled"}`); err == nil {
+		t.Error("decode(trailing value) succeeded, want error")
+	}
+	if _, err := decode(`{"prompt": "hi"}garbage`); err == nil {
+		t.Error("decode(trailing garbage) succeeded, want error")
+	}
+	if _, err := decode(`{"prompt": "hi"}` + "\n"); err != nil {
+		t.Errorf("decode(trailing newline) error = %v", err)
+	}
+
+	huge := `{"prompt": "` + strings.Repeat("a", maxRequestBodyBytes) + `"}`
+	var tooLarge *http.MaxBytesError
+	if _, err := decode(huge); !errors.As(err, &tooLarge) {
+		t.Errorf("decode(oversized) error = %v, want *http.MaxBytesError", err)
+	}
+}
+
+func TestDecodeJSON_ValuesOutliveBuffer(t *testing.T) {
+	var v struct {
+		Prompt   string          `json:"prompt"`
+		Document json.RawMessage `json:"document"`
+	}
+	body := `{"prompt": "hello", "document": {"a": 1}}`
+	if err := decodeJSON(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(body)), &v); err != nil {
+		t.Fatalf("decodeJSON() error = %v", err)
+	}
+
+	// The next request reuses the pooled buffer
+	buf := getBuffer()
+	buf.WriteString(strings.Repeat("x", len(body)))
+	putBuffer(buf)
+
+	if v.Prompt != "hello" || string(v.Document) != `{"a": 1}` {
+		t.Errorf("decoded = %q, %s after buffer reuse", v.Prompt, v.Document)
+	}
+}
+
+func TestBufferPool(t *testing.T) {
+	buf := getBuffer()
+	buf.WriteString("leftover")
+	putBuffer(buf)
+	if got := getBuffer(); got.Len() != 0 {
+		t.Errorf("getBuffer() holds %q, want empty", got.String())
+	}
+
+	// Oversized buffers are dropped instead of pinning memory in the pool
+	big := bytes.NewBuffer(make([]byte, 0, maxPooledBufferSize+1))
+	putBuffer(big)
+	for i := 0; i < 10; i++ {
+		if getBuffer() == big {
+			t.Fatal("getBuffer() returned a buffer over maxPooledBufferSize")
+		}
+	}
+}
+
+func TestEncodeJSON(t *testing.T) {
+	rec := httptest.NewRecorder()
+	if err := encodeJSON(rec, http.StatusCreated, map[string]string{"status": "ok"}); err != nil {
+		t.Fatalf("encodeJSON() error = %v", err)
+	}
+	if rec.Code != http.StatusCreated || rec.Body.String() != "{\"status\":\"ok\"}\n" {
+		t.Errorf("encodeJSON() = %d %q", rec.Code, rec.Body)
+	}
+	if got := rec.Header().Get("Content-Length"); got != "16" {
+		t.Errorf("Content-Length = %q, want 16", got)
+	}
+}
+
+func TestHandleAnalyze_BodyTooLarge(t *testing.T) {
+	h, _ := newTestHandler(t)
+	body := `{"client_id": "svc", "prompt": "` + strings.Repeat("a", maxRequestBodyBytes) + `"}`
+	if rec := serve(h, "", http.MethodPost, "/v1/analyze", body, nil); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized analyze = %d, want 413", rec.Code)
+	}
+	if rec := serve(h, "", http.MethodPost, "/v1/analyze", `{"client_id": "svc", "prompt": "hi"} {}`, nil); rec.Code != http.StatusBadRequest {
+		t.Errorf("analyze with trailing data = %d, want 400", rec.Code)
+	}
+}
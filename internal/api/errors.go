@@ -0,0 +1,107 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/prompt-gateway/internal/dbpool"
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Error codes returned in the error envelope
+const (
+	codeInvalidRequest   = "invalid_request"
+	codeUnauthorized     = "unauthorized"
+	codeForbidden        = "forbidden"
+	codeNotFound         = "not_found"
+	codeMethodNotAllowed = "method_not_allowed"
+	codeConflict         = "conflict"
+	codePayloadTooLarge  = "payload_too_large"
+	codeReplayedRequest  = "replayed_request"
+	codeRateLimited      = "rate_limited"
+	codeInternal         = "internal_error"
+	codeUnavailable      = "unavailable"
+	codeMaintenance      = "maintenance"
+	codeTimeout          = "timeout"
+	codeStorageBusy      = "storage_busy"
+	codeConfirmRequired  = "confirmation_required"
+)
+
+// defaultRetryAfter is sent with 429/503 responses that don't set their own
+const defaultRetryAfter = 5
+
+// statusCodes maps HTTP statuses to their default error code
+var statusCodes = map[int]string{
+	http.StatusBadRequest:            codeInvalidRequest,
+	http.StatusUnauthorized:          codeUnauthorized,
+	http.StatusForbidden:             codeForbidden,
+	http.StatusNotFound:              codeNotFound,
+	http.StatusMethodNotAllowed:      codeMethodNotAllowed,
+	http.StatusConflict:              codeConflict,
+	http.StatusRequestEntityTooLarge: codePayloadTooLarge,
+	http.StatusTooManyRequests:       codeRateLimited,
+	http.StatusInternalServerError:   codeInternal,
+	http.StatusServiceUnavailable:    codeUnavailable,
+	http.StatusGatewayTimeout:        codeTimeout,
+}
+
+// respondError sends an error envelope with the status's default code
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondErrorCode(w, status, statusCode(status), message)
+}
+
+// statusCode returns the default error code of status: its statusCodes entry, or
+// its snake_cased status text (502 -> bad_gateway) so no status is misreported
+// as internal_error. Statuses without a text fall back to their class
+func statusCode(status int) string {
+	if code, ok := statusCodes[status]; ok {
+		return code
+	}
+	if text := http.StatusText(status); text != "" {
+		return strings.Map(func(r rune) rune {
+			switch {
+			case r == ' ' || r == '-':
+				return '_'
+			case r == '\'':
+				return -1
+			}
+			return unicode.ToLower(r)
+		}, text)
+	}
+	if status >= 500 {
+		return codeInternal
+	}
+	return codeInvalidRequest
+}
+
+// respondErrorCode sends an error envelope with an explicit code and optional details
+// Capacity errors (429/503) always carry Retry-After so clients can back off
+func respondErrorCode(w http.ResponseWriter, status int, code, message string, details ...models.ErrorDetail) {
+	if (status == http.StatusTooManyRequests || status == http.StatusServiceUnavailable) && w.Header().Get("Retry-After") == "" {
+		w.Header().Set("Retry-After", strconv.Itoa(defaultRetryAfter))
+	}
+	respondJSON(w, status, models.ErrorResponse{Error: models.APIError{
+		Code:      code,
+		Message:   message,
+		RequestID: w.Header().Get("X-Request-ID"),
+		Details:   details,
+	}})
+}
+
+// respondStorageError answers a failed Postgres call: 503 storage busy when the
+// connection pool stayed exhausted, 504 when the request timed out, otherwise status
+func respondStorageError(w http.ResponseWriter, r *http.Request, status int, message string) {
+	switch {
+	case dbpool.Busy(r.Context()):
+		metrics.DBPoolBusyTotal.Inc()
+		respondErrorCode(w, http.StatusServiceUnavailable, codeStorageBusy, "Storage busy, retry shortly")
+	case r.Context().Err() == context.DeadlineExceeded:
+		respondError(w, http.StatusGatewayTimeout, "Request timeout")
+	default:
+		respondError(w, status, message)
+	}
+}
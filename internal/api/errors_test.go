@@ -0,0 +1,102 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestHandleIngestAudit_PayloadTooLarge(t *testing.T) {
+	h, _ := newTestHandler(t)
+	h.SetAuditIngest(&audit.Logger{}, "token")
+
+	entry := `{"request_id":"6f1c1c1e-8a0e-4c1b-9b7a-0d6c1d0f0a01","client_id":"svc"}`
+	body := `{"entries":[` + strings.Repeat(entry+",", maxIngestEntries) + entry + `]}`
+	rec := serve(h, "", http.MethodPost, "/v1/audit/ingest", body, http.Header{"Authorization": {"Bearer token"}})
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("ingest of %d entries = %d, want 413", maxIngestEntries+1, rec.Code)
+	}
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode error envelope: %v", err)
+	}
+	if resp.Error.Code != codePayloadTooLarge {
+		t.Errorf("code = %q, want %q", resp.Error.Code, codePayloadTooLarge)
+	}
+}
+
+func TestStatusCode(t *testing.T) {
+	tests := []struct {
+		status int
+		want   string
+	}{
+		{http.StatusBadRequest, codeInvalidRequest},
+		{http.StatusNotFound, codeNotFound},
+		{http.StatusTooManyRequests, codeRateLimited},
+		{http.StatusInternalServerError, codeInternal},
+		{http.StatusGatewayTimeout, codeTimeout},
+		{http.StatusBadGateway, "bad_gateway"},
+		{http.StatusUnprocessableEntity, "unprocessable_entity"},
+		{http.StatusPreconditionRequired, "precondition_required"},
+		{http.StatusTeapot, "im_a_teapot"},
+		{499, codeInvalidRequest},
+		{599, codeInternal},
+	}
+	for _, tt := range tests {
+		if got := statusCode(tt.status); got != tt.want {
+			t.Errorf("statusCode(%d) = %q, want %q", tt.status, got, tt.want)
+		}
+	}
+}
+
+func TestRespondError_Envelope(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		retryAfter string // Preset Retry-After header
+		wantRetry  string
+	}{
+		{"client error", http.StatusBadRequest, "", ""},
+		{"rate limited", http.StatusTooManyRequests, "", "5"},
+		{"unavailable keeps its own retry", http.StatusServiceUnavailable, "30", "30"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			rec.Header().Set("X-Request-ID", "req-1")
+			if tt.retryAfter != "" {
+				rec.Header().Set("Retry-After", tt.retryAfter)
+			}
+			respondErrorCode(rec, tt.status, "some_code", "went wrong", models.ErrorDetail{Field: "nonce", Reason: "reused"})
+
+			if rec.Code != tt.status {
+				t.Errorf("status = %d, want %d", rec.Code, tt.status)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetry {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetry)
+			}
+			var resp models.ErrorResponse
+			if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode error envelope: %v", err)
+			}
+			want := models.APIError{Code: "some_code", Message: "went wrong", RequestID: "req-1",
+				Details: []models.ErrorDetail{{Field: "nonce", Reason: "reused"}}}
+			if !reflect.DeepEqual(resp.Error, want) {
+				t.Errorf("error = %+v, want %+v", resp.Error, want)
+			}
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	respondError(rec, http.StatusBadGateway, "upstream failed")
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil || resp.Error.Code != "bad_gateway" {
+		t.Errorf("respondError(502) = %s, want code bad_gateway", rec.Body)
+	}
+}
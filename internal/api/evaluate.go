@@ -0,0 +1,934 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/decisioncache"
+	"github.com/prompt-gateway/internal/fingerprint"
+	"github.com/prompt-gateway/internal/flags"
+	"github.com/prompt-gateway/internal/jsonpath"
+	"github.com/prompt-gateway/internal/logging"
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/internal/replay"
+	"github.com/prompt-gateway/internal/scheduler"
+	"github.com/prompt-gateway/internal/tenant"
+	"github.com/prompt-gateway/internal/tracing"
+	"github.com/prompt-gateway/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// ErrInvalidRequest wraps validation failures of an analyze request
+var ErrInvalidRequest = errors.New("invalid request")
+
+// maxNonceLength bounds the Redis key size of a remembered nonce
+const maxNonceLength = 128
+
+// invalidRequest builds an ErrInvalidRequest with a client-facing message
+func invalidRequest(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidRequest, fmt.Sprintf(format, args...))
+}
+
+// Evaluate runs the full analyze pipeline for a request: validation, policy
+// evaluation, decision, audit logging and observer notification.
+// It is transport-agnostic so HTTP and other frontends share the same core.
+func (h *Handler) Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	startTime := time.Now()
+	if req.ClientID != "" {
+		ctx = logging.With(ctx, "client_id", req.ClientID)
+	}
+	ctx, span := tracing.Start(ctx, "gateway.evaluate", attribute.String("client.id", req.ClientID))
+	defer span.End()
+
+	// Refuse new work during maintenance; admitted work is tracked so it can drain
+	if h.maintenance != nil {
+		if err := h.maintenance.Begin(); err != nil {
+			return nil, err
+		}
+		defer h.maintenance.End()
+	}
+	h.chaos.Delay(ctx)
+
+	// Validate request
+	if req.ClientID == "" {
+		return nil, invalidRequest("client_id is required")
+	}
+	if req.Prompt == "" && len(req.Messages) == 0 && len(req.Document) == 0 && len(req.Attachments) == 0 {
+		return nil, invalidRequest("prompt, messages, document or attachments is required")
+	}
+	if err := validateMessages(req.Messages); err != nil {
+		return nil, invalidRequest("%v", err)
+	}
+	if err := validateAttachments(req.Attachments); err != nil {
+		return nil, invalidRequest("%v", err)
+	}
+	if !scheduler.ValidPriority(req.Priority) {
+		return nil, invalidRequest("priority must be interactive or batch")
+	}
+	directive, err := decisioncache.ParseDirective(req.CacheControl)
+	if err != nil {
+		return nil, invalidRequest("cache_control: %v", err)
+	}
+
+	// Wait for an evaluation slot; interactive requests are admitted ahead of batch ones
+	release, err := h.scheduler.Acquire(ctx, req.Priority)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	// Feature flag rollouts bucket on the client so it sees consistent behavior
+	ctx = flags.WithSubject(ctx, req.ClientID)
+
+	// Replays are rejected before anything is evaluated or audited
+	client, _ := h.clients.Get(req.ClientID)
+	if err := h.checkReplay(ctx, req, client); err != nil {
+		return nil, err
+	}
+
+	// A decision pinned for the session short-circuits evaluation entirely
+	override := h.sessionOverride(ctx, sessionIDOf(req))
+	if override != nil && override.Action == "block" {
+		response := &models.AnalyzeResponse{
+			Allowed:           false,
+			Action:            "block",
+			TriggeredPolicies: []models.PolicyMatch{},
+			Override:          override,
+		}
+		h.recordDecision(ctx, req, response, "", startTime)
+		return response, nil
+	}
+
+	// Known-benign prompts (fixed system prompts, test fixtures) skip analysis
+	if h.promptAllowlisted(req) {
+		metrics.AllowlistHitsTotal.WithLabelValues("prompt").Inc()
+		response := &models.AnalyzeResponse{
+			Allowed:           true,
+			Action:            "allow",
+			TriggeredPolicies: []models.PolicyMatch{},
+			Allowlisted:       true,
+		}
+		h.recordDecision(ctx, req, response, "", startTime)
+		return response, nil
+	}
+
+	// Get policies from in-memory cache (background refreshed from Postgres)
+	// narrowed to this client (applies_to_clients selectors, trust-tier actions)
+	// Isolated tenants are evaluated against their own policy set
+	policySet := h.policyCache
+	tenantStore, isolated := h.tenants.Get(tenant.Of(client))
+	if isolated {
+		policySet = tenantStore.Cache
+	}
+	policies := effectivePolicies(policySet.Get(), client)
+	span.SetAttributes(attribute.Int("policy_cache.policies", len(policies)), attribute.Bool("tenant.isolated", isolated))
+	// Allow policies are evaluated separately, only for default-block clients, so a
+	// matching allow policy can never stop evaluation before a block policy matches
+	policies, allowPolicies := splitAllowPolicies(policies)
+	defaultBlock := client.DefaultAction == clients.DefaultBlock
+	policyHash := ""
+	if snapshot := policySet.Snapshot(); snapshot != nil {
+		policyHash = snapshot.Hash
+	}
+
+	// Identical requests are answered from the decision cache while it is fresh
+	// enough for the caller; pinned sessions always take the override path
+	// Debug requests are always evaluated so the trace reflects this request
+	var trace *analyzer.Trace
+	if debug, _ := ctx.Value(debugKey).(bool); debug {
+		ctx, trace = analyzer.WithTrace(ctx)
+		for _, p := range policySet.Get() {
+			if !policy.AppliesTo(p, client) {
+				trace.Skip(p, "", "not applicable to client")
+			}
+		}
+		if !defaultBlock {
+			for _, p := range allowPolicies {
+				trace.Skip(p, "", "allow policy: client default action is allow")
+			}
+		}
+	}
+	// Clients with huge policy sets only get their most severe policies evaluated
+	policies, overLimit := limitPolicies(policies, h.policyLimit)
+	if trace != nil {
+		for _, p := range overLimit {
+			trace.Skip(p, "", "request policy limit")
+		}
+	}
+	cacheKey := ""
+	if h.decisions != nil && override == nil {
+		cacheKey = decisionCacheKey(req, client)
+		subject := decisioncache.Subject{ClientID: req.ClientID, SessionID: sessionIDOf(req)}
+		if entry, ok := h.decisions.Get(cacheKey, subject); ok && trace == nil && directive.Usable(entry, policyHash, time.Now()) {
+			response := cloneResponse(entry.Response)
+			if h.restoreRedactions(withRedactionBudget(ctx, h.redactLimit), req, response, policies) {
+				metrics.DecisionCacheTotal.WithLabelValues("hit").Inc()
+				response.Cache = &models.CacheStatus{Hit: true, AgeSeconds: int64(time.Since(entry.StoredAt).Seconds())}
+				// A max-age hit may predate the live snapshot; report the one that decided
+				response.PolicyHash = entry.PolicyHash
+				_, _, severity := resolveDecision(response.TriggeredPolicies, policies)
+				h.recordDecision(ctx, req, response, severity, startTime)
+				return limitMatches(response, h.matchLimit), nil
+			}
+		}
+	}
+
+	// Model policies whose provider is down are skipped rather than failing the request
+	ctx = analyzer.WithDegradation(ctx)
+	ctx = withRedactionBudget(ctx, h.redactLimit)
+	// Model providers see the request context their privacy rules allow
+	requestIDStr, _ := ctx.Value(requestIDKey).(string)
+	ctx = analyzer.WithModelRequest(ctx, analyzer.ModelRequest{
+		RequestID: requestIDStr,
+		ClientID:  req.ClientID,
+		SessionID: sessionIDOf(req),
+		Tenant:    tenant.Of(client),
+	})
+
+	// Batch work gives up slow model calls while interactive traffic is waiting
+	var deferred []string
+	if req.Priority == scheduler.PriorityBatch && h.scheduler.UnderLoad() {
+		all := policies
+		policies, deferred = deferModelPolicies(policies)
+		if len(deferred) > 0 {
+			metrics.ModelPoliciesDeferredTotal.Inc()
+		}
+		if trace != nil {
+			for _, p := range all {
+				if analyzer.UsesModel(p) {
+					trace.Skip(p, "", "deferred: batch request under load")
+				}
+			}
+		}
+	}
+
+	var (
+		matches           []models.PolicyMatch
+		messageResults    []models.MessageVerdict
+		attachmentResults []models.AttachmentVerdict
+		signalText        string // Everything scanned, for stylometric signals
+	)
+	switch {
+	case len(req.Messages) > 0:
+		// Chat format: evaluate each message with role-aware policies
+		messageResults, matches, err = h.analyzeMessages(ctx, req.Messages, policies)
+		texts := make([]string, len(req.Messages))
+		for i, msg := range req.Messages {
+			texts[i] = msg.AnalyzableContent()
+		}
+		signalText = strings.Join(texts, "\n")
+	case len(req.Document) > 0:
+		// Structured payload: evaluate the selected string fields
+		leaves, selErr := selectDocumentFields(req.Document, req.IncludePaths, req.ExcludePaths)
+		if selErr != nil {
+			return nil, invalidRequest("%v", selErr)
+		}
+		general, _ := analyzer.SplitResponseOnly(policies)
+		matches, err = h.analyzeFields(ctx, leaves, general)
+		texts := make([]string, len(leaves))
+		for i, leaf := range leaves {
+			texts[i] = leaf.Value
+		}
+		signalText = strings.Join(texts, "\n")
+	case req.Prompt == "" && req.Response == "":
+		// Attachments only, evaluated below
+	default:
+		// Combine prompt and response for analysis
+		contentToAnalyze := req.Prompt
+		if req.Response != "" {
+			contentToAnalyze += "\n" + req.Response
+		}
+		// Response-only detectors (e.g. exfiltration URLs) must not fire on the prompt
+		target := "prompt"
+		if req.Response != "" {
+			target = "prompt+response"
+		}
+		general, responseOnly := analyzer.SplitResponseOnly(policies)
+		matches, err = h.analyzer.Analyze(analyzer.WithTraceTarget(ctx, target), contentToAnalyze, general)
+		if err == nil && req.Response != "" && len(responseOnly) > 0 {
+			var responseMatches []models.PolicyMatch
+			responseMatches, err = h.analyzer.Analyze(analyzer.WithTraceTarget(ctx, "response"), req.Response, responseOnly)
+			matches = append(matches, responseMatches...)
+		}
+		signalText = contentToAnalyze
+	}
+	if err == nil && len(req.Attachments) > 0 {
+		// Attachments are evaluated on their own, like user messages
+		var attachmentMatches []models.PolicyMatch
+		attachmentResults, attachmentMatches, err = h.analyzeAttachments(ctx, req.Attachments, policies)
+		matches = append(matches, attachmentMatches...)
+		for _, att := range req.Attachments {
+			if signalText != "" {
+				signalText += "\n"
+			}
+			signalText += att.Content
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, match := range matches {
+		metrics.AnalyzerMatchesTotal.WithLabelValues(match.Severity).Inc()
+	}
+
+	// Determine action based on triggered policies
+	action, allowed, severity := resolveDecision(matches, policies)
+
+	// Clients on an allowlist model are blocked unless an allow policy matches
+	// Allow matches are reported apart: they explain the decision but aren't violations
+	var allowedBy []models.PolicyMatch
+	defaultBlocked := false
+	if defaultBlock && action != "block" {
+		allowedBy, err = h.analyzer.Analyze(analyzer.WithTraceTarget(ctx, "allow"), signalText, allowPolicies)
+		if err != nil {
+			return nil, err
+		}
+		if len(allowedBy) == 0 {
+			action, allowed, defaultBlocked = "block", false, true
+			allowedBy = nil
+		}
+	}
+
+	// Redact content if needed
+	redactedPrompt := ""
+	if len(matches) > 0 && req.Prompt != "" {
+		redactedPrompt = h.redact(ctx, req.Prompt, matches, policies)
+	}
+	// Content whose redactions didn't all fit in the budget must not pass partially redacted
+	var overflow models.LimitOverflow
+	if len(overLimit) > 0 {
+		overflow.PoliciesSkipped = len(overLimit)
+		metrics.RequestLimitsTotal.WithLabelValues("policies").Inc()
+	}
+	if skipped := redactionsSkipped(ctx); skipped > 0 {
+		overflow.RedactionsSkipped = skipped
+		metrics.RequestLimitsTotal.WithLabelValues("redactions").Inc()
+		action, allowed = "block", false
+	}
+
+	// Create response
+	response := &models.AnalyzeResponse{
+		Allowed:           allowed,
+		Action:            action,
+		TriggeredPolicies: matches,
+		RedactedPrompt:    redactedPrompt,
+		MessageResults:    messageResults,
+		AttachmentResults: attachmentResults,
+		DeferredPolicies:  deferred,
+		AllowedBy:         allowedBy,
+		DefaultBlocked:    defaultBlocked,
+		PolicyHash:        policyHash,
+	}
+	if overflow != (models.LimitOverflow{}) {
+		response.Overflow = &overflow
+	}
+	degraded := analyzer.Degraded(ctx)
+	if degraded {
+		response.EvaluationMode = analyzer.EvaluationDegraded
+		metrics.DegradedEvaluationsTotal.Inc()
+	}
+	promptText, responseText := auditContent(req)
+	response.Tokens = &models.TokenCounts{
+		Prompt:   h.tokenizer.Count(promptText),
+		Response: h.tokenizer.Count(responseText),
+		Encoding: h.tokenizer.Name(),
+	}
+	metrics.AnalyzeTokens.WithLabelValues("prompt").Observe(float64(response.Tokens.Prompt))
+	if responseText != "" {
+		metrics.AnalyzeTokens.WithLabelValues("response").Observe(float64(response.Tokens.Response))
+	}
+	if h.flags.Enabled(ctx, flags.StylometricSignals) {
+		signals := analyzer.ComputeSignals(signalText)
+		response.Signals = &signals
+	}
+	if cacheKey != "" {
+		response.Cache = &models.CacheStatus{}
+		result := "miss"
+		if directive.NoCache {
+			result = "bypass"
+		}
+		metrics.DecisionCacheTotal.WithLabelValues(result).Inc()
+		// Decisions missing deferred or skipped model policies are incomplete, so not reused
+		if !directive.NoStore && len(deferred) == 0 && !degraded {
+			subject := decisioncache.Subject{ClientID: req.ClientID, SessionID: sessionIDOf(req)}
+			h.decisions.Put(cacheKey, subject, cachedResponse(*response), policyHash)
+		}
+	}
+	if override != nil && override.Action == "allow" {
+		// Operator-pinned allow: decision is forced, matches are still reported and audited
+		response.Allowed = true
+		response.Action = "log"
+		response.Override = override
+	}
+
+	if trace != nil {
+		response.Trace = trace.Entries()
+	}
+
+	h.recordDecision(ctx, req, response, severity, startTime)
+	return limitMatches(response, h.matchLimit), nil
+}
+
+// decisionCacheKey identifies requests that must get the same decision: the same
+// content from the same client, with the trust tier, labels and default action its
+// decision depends on
+func decisionCacheKey(req models.AnalyzeRequest, client models.Client) string {
+	key, _ := json.Marshal(struct {
+		ClientID      string
+		Tier          string
+		Labels        map[string]string
+		DefaultAction string
+		Prompt        string
+		Response      string
+		Messages      []models.ChatMessage
+		Document      json.RawMessage
+		IncludePaths  []string
+		ExcludePaths  []string
+		Attachments   []models.Attachment
+	}{req.ClientID, client.TrustTier, client.Labels, client.DefaultAction, req.Prompt, req.Response, req.Messages,
+		req.Document, req.IncludePaths, req.ExcludePaths, req.Attachments})
+	return audit.HashContent(string(key))
+}
+
+// cloneResponse copies a response deeply enough that recording one copy (monitor
+// mode rewrites per-message verdicts) leaves the other untouched
+func cloneResponse(response models.AnalyzeResponse) *models.AnalyzeResponse {
+	response.MessageResults = slices.Clone(response.MessageResults)
+	response.AttachmentResults = slices.Clone(response.AttachmentResults)
+	response.RequestID = uuid.Nil
+	response.DecisionToken = ""
+	response.MonitorOnly = false
+	response.UserMessage = ""
+	return &response
+}
+
+// cachedResponse copies a response for the decision cache without the content it
+// carries: redacted prompts, messages and attachments are recomputed from the
+// request on a hit, so the cache holds decisions and match metadata only
+func cachedResponse(response models.AnalyzeResponse) models.AnalyzeResponse {
+	cached := cloneResponse(response)
+	cached.RedactedPrompt = ""
+	for i := range cached.MessageResults {
+		cached.MessageResults[i].RedactedContent = ""
+	}
+	for i := range cached.AttachmentResults {
+		cached.AttachmentResults[i].RedactedContent = ""
+	}
+	return *cached
+}
+
+// restoreRedactions redacts the request's content the way the cached decision in
+// response says. False when a policy that matched is gone from policies (a
+// max-age hit on older policies), as its matches can no longer be redacted
+func (h *Handler) restoreRedactions(ctx context.Context, req models.AnalyzeRequest, response *models.AnalyzeResponse, policies []models.Policy) bool {
+	known := make(map[uuid.UUID]bool, len(policies))
+	for _, p := range policies {
+		known[p.ID] = true
+	}
+	for _, m := range response.TriggeredPolicies {
+		if !known[m.PolicyID] {
+			return false
+		}
+	}
+
+	matches := response.TriggeredPolicies
+	if len(matches) > 0 && req.Prompt != "" {
+		response.RedactedPrompt = h.redact(ctx, req.Prompt, matches, policies)
+	}
+	for i, verdict := range response.MessageResults {
+		if len(verdict.TriggeredPolicies) == 0 || verdict.Index >= len(req.Messages) {
+			continue
+		}
+		content := req.Messages[verdict.Index].Content
+		if redacted := h.redact(ctx, content, verdict.TriggeredPolicies, policies); content != "" && redacted != content {
+			response.MessageResults[i].RedactedContent = redacted
+		}
+	}
+	for i, verdict := range response.AttachmentResults {
+		if len(verdict.TriggeredPolicies) == 0 || verdict.Index >= len(req.Attachments) {
+			continue
+		}
+		att := req.Attachments[verdict.Index]
+		if kind, _ := attachmentKind(att.MimeType); kind != attachmentText {
+			continue
+		}
+		if redacted := h.redact(ctx, att.Content, verdict.TriggeredPolicies, policies); redacted != att.Content {
+			response.AttachmentResults[i].RedactedContent = redacted
+		}
+	}
+	return true
+}
+
+// splitAllowPolicies separates allow policies from the enforcing ones, sharing the
+// input slice when there are none
+func splitAllowPolicies(policies []models.Policy) ([]models.Policy, []models.Policy) {
+	if !slices.ContainsFunc(policies, func(p models.Policy) bool { return p.Action == "allow" }) {
+		return policies, nil
+	}
+	enforcing := make([]models.Policy, 0, len(policies))
+	var allow []models.Policy
+	for _, p := range policies {
+		if p.Action == "allow" {
+			allow = append(allow, p)
+		} else {
+			enforcing = append(enforcing, p)
+		}
+	}
+	return enforcing, allow
+}
+
+// deferModelPolicies removes model-backed policies, returning the rest and the
+// names of those removed
+func deferModelPolicies(policies []models.Policy) ([]models.Policy, []string) {
+	kept := make([]models.Policy, 0, len(policies))
+	var deferred []string
+	for _, p := range policies {
+		if analyzer.UsesModel(p) {
+			deferred = append(deferred, p.Name)
+			continue
+		}
+		kept = append(kept, p)
+	}
+	return kept, deferred
+}
+
+// promptAllowlisted reports whether the request is a bare prompt whose content hash
+// is allowlisted; anything sent alongside it is untrusted and must be analyzed
+func (h *Handler) promptAllowlisted(req models.AnalyzeRequest) bool {
+	if h.allowlist == nil || req.Prompt == "" || req.Response != "" || len(req.Messages) > 0 || len(req.Document) > 0 || len(req.Attachments) > 0 {
+		return false
+	}
+	return h.allowlist.Contains(h.hasher.Hash(req.Prompt))
+}
+
+// checkReplay enforces nonce + timestamp replay protection
+// Clients flagged require_nonce must send both; other clients may opt in per request
+func (h *Handler) checkReplay(ctx context.Context, req models.AnalyzeRequest, client models.Client) error {
+	if req.Nonce == "" {
+		if client.RequireNonce {
+			return invalidRequest("nonce and timestamp are required for this client")
+		}
+		return nil
+	}
+	if len(req.Nonce) > maxNonceLength {
+		return invalidRequest("nonce must be at most %d characters", maxNonceLength)
+	}
+	if req.Timestamp == 0 {
+		return invalidRequest("timestamp is required with a nonce")
+	}
+	if h.replay == nil {
+		return nil
+	}
+
+	err := h.replay.Check(ctx, req.ClientID, req.Nonce, req.Timestamp)
+	if errors.Is(err, replay.ErrStale) {
+		return invalidRequest("%v", err)
+	}
+	return err
+}
+
+// recordDecision stamps the response with its request ID, latency and decision
+// token, then writes the audit entry and notifies observers. severity is the
+// highest matched severity from resolveDecision ("" without matches)
+// In monitor mode the computed action is audited and counted as-is but the caller
+// is always allowed through
+func (h *Handler) recordDecision(ctx context.Context, req models.AnalyzeRequest, response *models.AnalyzeResponse, severity string, startTime time.Time) {
+	mode := "enforce"
+	if h.monitorOnly {
+		mode = "monitor"
+		response.Allowed = true
+		response.MonitorOnly = true
+		for i := range response.MessageResults {
+			response.MessageResults[i].Allowed = true
+		}
+		for i := range response.AttachmentResults {
+			response.AttachmentResults[i].Allowed = true
+		}
+	}
+	if severity == "" {
+		severity = "none"
+	}
+	outcome := decisionOutcome(req, response)
+	metrics.DecisionsTotal.WithLabelValues(outcome, severity, mode).Inc()
+	response.UserMessage = h.userMessages.Render(req.Locale, outcome, response)
+
+	// Calculate latency
+	response.LatencyMs = time.Since(startTime).Milliseconds()
+
+	// Get request ID from context (created in middleware), or mint one for
+	// callers that don't go through the HTTP middleware
+	requestIDStr, _ := ctx.Value(requestIDKey).(string)
+	requestID, err := uuid.Parse(requestIDStr)
+	if err != nil {
+		requestID = uuid.New()
+		ctx = logging.With(ctx, "request_id", requestID.String())
+	}
+	response.RequestID = requestID
+
+	if h.logLevel != nil {
+		policyNames := make([]string, len(response.TriggeredPolicies))
+		for i, m := range response.TriggeredPolicies {
+			policyNames[i] = m.PolicyName
+		}
+		h.logLevel.Debug(ctx, req.ClientID, "analyze decision",
+			"session_id", sessionIDOf(req), "action", response.Action, "allowed", response.Allowed, "mode", mode,
+			"policies", policyNames, "prompt_length", len(req.Prompt), "latency_ms", response.LatencyMs)
+	}
+
+	// Sign the decision so downstream services can verify it without trusting the caller
+	if h.signer != nil {
+		token, err := h.signer.Sign(response)
+		if err != nil {
+			slog.WarnContext(ctx, "Failed to sign decision", "error", err)
+		}
+		response.DecisionToken = token
+	}
+
+	// Log audit entry
+	matches := response.TriggeredPolicies
+	policyIDs := make([]uuid.UUID, len(matches))
+	for i, m := range matches {
+		policyIDs[i] = m.PolicyID
+	}
+
+	promptContent, responseContent := auditContent(req)
+	auditEntry := models.AuditLog{
+		ID:                uuid.New(),
+		RequestID:         requestID,
+		ClientID:          req.ClientID,
+		SessionID:         sessionIDOf(req),
+		PromptHash:        h.hasher.Hash(promptContent),
+		ResponseHash:      h.hasher.Hash(responseContent),
+		PoliciesTriggered: policyIDs,
+		ActionTaken:       response.Action,
+		LatencyMs:         int(response.LatencyMs),
+		CreatedAt:         time.Now(),
+		GatewayOrigin:     h.origin,
+	}
+	if client, ok := h.clients.Get(req.ClientID); ok {
+		if _, isolated := h.tenants.Get(tenant.Of(client)); isolated {
+			auditEntry.Tenant = tenant.Of(client)
+		}
+	}
+
+	// Log audit entry asynchronously (fire-and-forget); the span shows time spent
+	// waiting on a full buffer or falling back to a synchronous write
+	_, auditSpan := tracing.Start(ctx, "audit.enqueue")
+	tracing.End(auditSpan, h.auditLog.Log(auditEntry))
+
+	// Notify background observers (anomaly detection, alerting)
+	if len(h.observers) > 0 {
+		event := models.DecisionEvent{Audit: auditEntry, Matches: matches, Response: response, Request: &req}
+		if auditEntry.ActionTaken == "block" {
+			event.PromptSimhash = fingerprint.Simhash(promptContent)
+		}
+		if req.Context != nil {
+			event.Model = req.Context.Model
+		}
+		for _, observer := range h.observers {
+			observer.Observe(event)
+		}
+	}
+}
+
+// sessionOverride returns the decision pinned for the request's session, if any
+// Override lookups fail open: a Redis outage must not take down analysis
+func (h *Handler) sessionOverride(ctx context.Context, sessionID string) *models.SessionOverride {
+	if h.overrides == nil || sessionID == "" {
+		return nil
+	}
+	override, err := h.overrides.Get(ctx, sessionID)
+	if err != nil {
+		slog.WarnContext(ctx, "Session override lookup failed", "session_id", sessionID, "error", err)
+		return nil
+	}
+	return override
+}
+
+// sessionIDOf returns the request's session ID, if any
+func sessionIDOf(req models.AnalyzeRequest) string {
+	if req.Context == nil {
+		return ""
+	}
+	return req.Context.SessionID
+}
+
+// analyzeMessages evaluates each chat message against the policies for its role
+// and returns per-message verdicts plus all matches tagged with their message index
+func (h *Handler) analyzeMessages(ctx context.Context, messages []models.ChatMessage, policies []models.Policy) ([]models.MessageVerdict, []models.PolicyMatch, error) {
+	verdicts := make([]models.MessageVerdict, len(messages))
+	allMatches := make([]models.PolicyMatch, 0, len(messages))
+
+	for i, msg := range messages {
+		rolePolicies := analyzer.PoliciesForRole(policies, msg.Role)
+		content := msg.AnalyzableContent()
+		if h.allowlist.Contains(h.hasher.Hash(content)) {
+			metrics.AllowlistHitsTotal.WithLabelValues("message").Inc()
+			verdicts[i] = models.MessageVerdict{
+				Index:             i,
+				Role:              msg.Role,
+				Allowed:           true,
+				Action:            "allow",
+				TriggeredPolicies: []models.PolicyMatch{},
+				Allowlisted:       true,
+			}
+			continue
+		}
+
+		msgCtx := analyzer.WithTraceTarget(ctx, fmt.Sprintf("messages[%d]", i))
+		matches, err := h.analyzer.Analyze(msgCtx, content, rolePolicies)
+		if err != nil {
+			return nil, nil, fmt.Errorf("message %d: %w", i, err)
+		}
+
+		action, allowed, _ := resolveDecision(matches, rolePolicies)
+		verdict := models.MessageVerdict{
+			Index:             i,
+			Role:              msg.Role,
+			Allowed:           allowed,
+			Action:            action,
+			TriggeredPolicies: matches,
+		}
+		if len(matches) > 0 && msg.Content != "" {
+			if redacted := h.redact(ctx, msg.Content, matches, rolePolicies); redacted != msg.Content {
+				verdict.RedactedContent = redacted
+			}
+		}
+		verdicts[i] = verdict
+
+		for _, m := range matches {
+			index := i
+			m.MessageIndex = &index
+			allMatches = append(allMatches, m)
+		}
+	}
+
+	return verdicts, allMatches, nil
+}
+
+// analyzeFields evaluates each selected document field and tags matches with its path
+func (h *Handler) analyzeFields(ctx context.Context, leaves []jsonpath.Leaf, policies []models.Policy) ([]models.PolicyMatch, error) {
+	allMatches := make([]models.PolicyMatch, 0, len(leaves))
+	for _, leaf := range leaves {
+		if leaf.Value == "" {
+			continue
+		}
+		fieldCtx := analyzer.WithTraceTarget(ctx, analyzer.TraceTarget(ctx)+leaf.Path)
+		matches, err := h.analyzer.Analyze(fieldCtx, leaf.Value, policies)
+		if err != nil {
+			return nil, fmt.Errorf("field %s: %w", leaf.Path, err)
+		}
+		for _, m := range matches {
+			m.FieldPath = leaf.Path
+			allMatches = append(allMatches, m)
+		}
+	}
+	return allMatches, nil
+}
+
+// resolveDecision determines the action for a set of matches
+// Returns the action, whether the request is allowed, and the highest matched severity
+func resolveDecision(matches []models.PolicyMatch, policies []models.Policy) (string, bool, string) {
+	action := "allow"
+	allowed := true
+	highestSeverity := ""
+
+	for _, match := range matches {
+		// Find the policy to get its action
+		for _, p := range policies {
+			if p.ID == match.PolicyID {
+				if analyzer.EffectiveAction(p.Action, match) == "block" {
+					action = "block"
+					allowed = false
+				}
+				// Track highest severity
+				if highestSeverity == "" || severityWeight(match.Severity) > severityWeight(highestSeverity) {
+					highestSeverity = match.Severity
+				}
+				break
+			}
+		}
+	}
+
+	return action, allowed, highestSeverity
+}
+
+// selectDocumentFields returns the document's string fields covered by an include
+// selector (all fields when none given) and not covered by an exclude selector
+func selectDocumentFields(document json.RawMessage, include, exclude []string) ([]jsonpath.Leaf, error) {
+	var doc interface{}
+	if err := json.Unmarshal(document, &doc); err != nil {
+		return nil, fmt.Errorf("invalid document: %v", err)
+	}
+
+	compile := func(selectors []string) ([]*jsonpath.Path, error) {
+		paths := make([]*jsonpath.Path, 0, len(selectors))
+		for _, sel := range selectors {
+			p, err := jsonpath.Compile(sel)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, p)
+		}
+		return paths, nil
+	}
+	includes, err := compile(include)
+	if err != nil {
+		return nil, err
+	}
+	excludes, err := compile(exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	covered := func(paths []*jsonpath.Path, leaf jsonpath.Leaf) bool {
+		for _, p := range paths {
+			if p.Covers(leaf.Steps) {
+				return true
+			}
+		}
+		return false
+	}
+
+	selected := make([]jsonpath.Leaf, 0)
+	for _, leaf := range jsonpath.Leaves(doc) {
+		if len(includes) > 0 && !covered(includes, leaf) {
+			continue
+		}
+		if covered(excludes, leaf) {
+			continue
+		}
+		selected = append(selected, leaf)
+	}
+	return selected, nil
+}
+
+// validateMessages checks chat messages have a known role
+func validateMessages(messages []models.ChatMessage) error {
+	validRoles := map[string]bool{"system": true, "developer": true, "user": true, "assistant": true, "tool": true}
+	for i, msg := range messages {
+		if !validRoles[msg.Role] {
+			return fmt.Errorf("messages[%d].role must be one of: system, developer, user, assistant, tool", i)
+		}
+	}
+	return nil
+}
+
+// auditContent returns the prompt and response text hashed into the audit log
+// For chat requests assistant turns count as response, everything else as prompt
+// Attachments are part of the prompt
+func auditContent(req models.AnalyzeRequest) (string, string) {
+	prompt, response := requestContent(req)
+	if len(req.Attachments) > 0 {
+		prompt += "\n" + attachmentsText(req.Attachments)
+	}
+	return prompt, response
+}
+
+// requestContent returns the prompt and response text of the request body itself
+func requestContent(req models.AnalyzeRequest) (string, string) {
+	if len(req.Document) > 0 && len(req.Messages) == 0 {
+		return string(req.Document), req.Response
+	}
+	if len(req.Messages) == 0 {
+		return req.Prompt, req.Response
+	}
+
+	var prompt, response strings.Builder
+	for _, msg := range req.Messages {
+		target := &prompt
+		if msg.Role == "assistant" {
+			target = &response
+		}
+		target.WriteString(msg.Role)
+		target.WriteString(": ")
+		target.WriteString(msg.AnalyzableContent())
+		target.WriteString("\n")
+	}
+	return prompt.String(), response.String()
+}
+
+// effectivePolicies narrows the cached policies to those whose applies_to_clients
+// selectors match the client, then applies tier_actions overrides for its trust tier
+// The cached slice is only copied when a selector excludes a policy or an override applies
+func effectivePolicies(policies []models.Policy, client models.Client) []models.Policy {
+	var resolved []models.Policy
+	for i, p := range policies {
+		applies := policy.AppliesTo(p, client)
+		action, override := p.TierActions[client.TrustTier]
+		override = override && action != p.Action
+		if resolved == nil {
+			if applies && !override {
+				continue
+			}
+			resolved = make([]models.Policy, 0, len(policies))
+			resolved = append(resolved, policies[:i]...)
+		}
+		if !applies {
+			continue
+		}
+		if override {
+			p.Action = action
+		}
+		resolved = append(resolved, p)
+	}
+	if resolved == nil {
+		return policies
+	}
+	return resolved
+}
+
+// decisionOutcome classifies a response to req for decision metrics
+// Allowed responses with matches are split into redact (content was rewritten) and log
+func decisionOutcome(req models.AnalyzeRequest, response *models.AnalyzeResponse) string {
+	if response.Action != "allow" || len(response.TriggeredPolicies) == 0 {
+		return response.Action
+	}
+	if Redacted(req, response) {
+		return "redact"
+	}
+	return "log"
+}
+
+// Redacted reports whether response rewrote any of req's content. The action is
+// only ever allow or block, so callers forwarding content detect redaction here
+// RedactedPrompt is set whenever the prompt matched, so it counts only when it differs
+func Redacted(req models.AnalyzeRequest, response *models.AnalyzeResponse) bool {
+	if response.RedactedPrompt != "" && response.RedactedPrompt != req.Prompt {
+		return true
+	}
+	for _, verdict := range response.MessageResults {
+		if verdict.RedactedContent != "" {
+			return true
+		}
+	}
+	for _, verdict := range response.AttachmentResults {
+		if verdict.RedactedContent != "" {
+			return true
+		}
+	}
+	return false
+}
+
+// severityWeight returns numeric weight for severity comparison
+func severityWeight(severity string) int {
+	weights := map[string]int{
+		"low":      1,
+		"medium":   2,
+		"high":     3,
+		"critical": 4,
+	}
+	return weights[severity]
+}
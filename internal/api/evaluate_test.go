@@ -0,0 +1,204 @@
+package api
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/prompt-gateway/internal/decisioncache"
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/internal/usermessage"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestEvaluate_DecisionCacheRecomputesRedactions(t *testing.T) {
+	h, _ := newTestHandler(t, models.CreatePolicyRequest{
+		Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "redact",
+	})
+	decisions := decisioncache.New(time.Minute, 10)
+	h.SetDecisionCache(decisions)
+
+	req := models.AnalyzeRequest{ClientID: "svc", Prompt: "my ssn is 123-45-6789"}
+	first, err := h.Evaluate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if first.Cache != nil && first.Cache.Hit {
+		t.Fatal("first Evaluate() was a cache hit")
+	}
+	if first.RedactedPrompt == "" || first.RedactedPrompt == req.Prompt {
+		t.Fatalf("RedactedPrompt = %q, want the ssn redacted", first.RedactedPrompt)
+	}
+
+	if decisions.Len() != 1 {
+		t.Fatalf("cache Len() = %d, want 1", decisions.Len())
+	}
+	client, _ := h.clients.Get(req.ClientID)
+	entry, ok := decisions.Get(decisionCacheKey(req, client), decisioncache.Subject{})
+	if !ok || entry.Response.RedactedPrompt != "" {
+		t.Errorf("cached RedactedPrompt = %q, %v, want none", entry.Response.RedactedPrompt, ok)
+	}
+
+	second, err := h.Evaluate(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if second.Cache == nil || !second.Cache.Hit {
+		t.Fatal("second Evaluate() missed the cache")
+	}
+	if second.RedactedPrompt != first.RedactedPrompt {
+		t.Errorf("hit RedactedPrompt = %q, want %q", second.RedactedPrompt, first.RedactedPrompt)
+	}
+}
+
+func TestDecisionOutcome(t *testing.T) {
+	match := []models.PolicyMatch{{PolicyName: "ssn", Severity: "high"}}
+	tests := []struct {
+		name     string
+		response models.AnalyzeResponse
+		want     string
+	}{
+		{"block", models.AnalyzeResponse{Action: "block", TriggeredPolicies: match}, "block"},
+		{"allow without matches", models.AnalyzeResponse{Action: "allow"}, "allow"},
+		{"logged match", models.AnalyzeResponse{Action: "allow", TriggeredPolicies: match}, "log"},
+		{"unchanged prompt", models.AnalyzeResponse{Action: "allow", TriggeredPolicies: match, RedactedPrompt: "my ssn"}, "log"},
+		{"redacted prompt", models.AnalyzeResponse{Action: "allow", TriggeredPolicies: match, RedactedPrompt: "my [REDACTED]"}, "redact"},
+		{"redacted message", models.AnalyzeResponse{
+			Action: "allow", TriggeredPolicies: match,
+			MessageResults: []models.MessageVerdict{{}, {RedactedContent: "[REDACTED]"}},
+		}, "redact"},
+		{"redacted attachment", models.AnalyzeResponse{
+			Action: "allow", TriggeredPolicies: match,
+			AttachmentResults: []models.AttachmentVerdict{{RedactedContent: "[REDACTED]"}},
+		}, "redact"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decisionOutcome(models.AnalyzeRequest{Prompt: "my ssn"}, &tt.response); got != tt.want {
+				t.Errorf("decisionOutcome() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvaluate_DecisionMetricLabels(t *testing.T) {
+	h, _ := newTestHandler(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "critical", Action: "block"},
+		models.CreatePolicyRequest{Name: "email", PatternType: "regex", PatternValue: `\w+@\w+\.com`, Severity: "medium", Action: "redact"},
+		models.CreatePolicyRequest{Name: "greeting", PatternType: "regex", PatternValue: `hello`, Severity: "low", Action: "log"},
+	)
+	tests := []struct {
+		prompt            string
+		outcome, severity string
+	}{
+		{"nothing to see", "allow", "none"},
+		{"hello there", "log", "low"},
+		{"mail bob@example.com", "redact", "medium"},
+		{"hello bob@example.com, ssn 123-45-6789", "block", "critical"},
+	}
+	for _, tt := range tests {
+		counter := metrics.DecisionsTotal.WithLabelValues(tt.outcome, tt.severity, "enforce")
+		before := testutil.ToFloat64(counter)
+		if _, err := h.Evaluate(context.Background(), models.AnalyzeRequest{ClientID: "svc", Prompt: tt.prompt}); err != nil {
+			t.Fatalf("Evaluate(%q) error = %v", tt.prompt, err)
+		}
+		if got := testutil.ToFloat64(counter) - before; got != 1 {
+			t.Errorf("Evaluate(%q) counted %v decisions{%s,%s}, want 1", tt.prompt, got, tt.outcome, tt.severity)
+		}
+	}
+}
+
+func TestEvaluate_MonitorMode(t *testing.T) {
+	h, sink := newTestHandler(t, models.CreatePolicyRequest{
+		Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block",
+	})
+	h.SetMonitorMode(true)
+
+	counter := metrics.DecisionsTotal.WithLabelValues("block", "high", "monitor")
+	before := testutil.ToFloat64(counter)
+	resp, err := h.Evaluate(context.Background(), models.AnalyzeRequest{
+		ClientID: "svc",
+		Messages: []models.ChatMessage{{Role: "user", Content: "ssn 123-45-6789"}},
+	})
+	if err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if !resp.Allowed || !resp.MonitorOnly {
+		t.Errorf("Evaluate() allowed = %v, monitor_only = %v, want allowed in monitor mode", resp.Allowed, resp.MonitorOnly)
+	}
+	if resp.Action != "block" || len(resp.TriggeredPolicies) != 1 {
+		t.Errorf("Evaluate() action = %q with %d matches, want the block decision reported", resp.Action, len(resp.TriggeredPolicies))
+	}
+	if len(resp.MessageResults) != 1 || !resp.MessageResults[0].Allowed || resp.MessageResults[0].Action != "block" {
+		t.Errorf("MessageResults = %+v, want an allowed block verdict", resp.MessageResults)
+	}
+	if got := testutil.ToFloat64(counter) - before; got != 1 {
+		t.Errorf("counted %v decisions{block,high,monitor}, want 1", got)
+	}
+	if entries := sink.Entries(); len(entries) != 1 || entries[0].ActionTaken != "block" {
+		t.Errorf("audit entries = %+v, want one block", entries)
+	}
+}
+
+func TestEvaluate_StampsOrigin(t *testing.T) {
+	h, sink := newTestHandler(t)
+	origin := models.GatewayOrigin{InstanceID: "gw-1", Hostname: "host-a", Region: "eu-west-1", Zone: "eu-west-1b", GatewayVersion: Version}
+	h.SetOrigin(origin)
+
+	if _, err := h.Evaluate(context.Background(), models.AnalyzeRequest{ClientID: "svc", Prompt: "hello"}); err != nil {
+		t.Fatalf("Evaluate() error = %v", err)
+	}
+	if entries := sink.Entries(); len(entries) != 1 || entries[0].GatewayOrigin != origin {
+		t.Errorf("audit entries = %+v, want one stamped with %+v", entries, origin)
+	}
+}
+
+func TestEvaluate_UserMessage(t *testing.T) {
+	h, _ := newTestHandler(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "redact"},
+		models.CreatePolicyRequest{Name: "email", PatternType: "regex", PatternValue: `\w+@\w+\.com`, Severity: "medium", Action: "redact"},
+		models.CreatePolicyRequest{Name: "secrets", PatternType: "keyword", PatternValue: "password", Severity: "critical", Action: "block"},
+		models.CreatePolicyRequest{Name: "internal", PatternType: "keyword", PatternValue: "roadmap", Severity: "low", Action: "log"},
+	)
+	catalog, err := usermessage.Parse([]byte(`{
+  "default_locale": "en",
+  "locales": {
+    "en": {
+      "actions": {"block": "Sorry, we can't help with that.", "redact": "Some details were removed."},
+      "policies": {"ssn": {"redact": "Social Security numbers were removed."}}
+    },
+    "de": {"actions": {"block": "Dabei können wir leider nicht helfen."}}
+  }
+}`))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	h.SetUserMessages(catalog)
+
+	tests := []struct {
+		name string
+		req  models.AnalyzeRequest
+		want string
+	}{
+		{"allowed", models.AnalyzeRequest{Prompt: "hello"}, ""},
+		{"logged", models.AnalyzeRequest{Prompt: "the roadmap"}, ""},
+		{"blocked", models.AnalyzeRequest{Prompt: "my password is hunter2"}, "Sorry, we can't help with that."},
+		{"redacted prompt", models.AnalyzeRequest{Prompt: "mail bob@example.com"}, "Some details were removed."},
+		{"policy message", models.AnalyzeRequest{Prompt: "my ssn is 123-45-6789"}, "Social Security numbers were removed."},
+		{"redacted message", models.AnalyzeRequest{Messages: []models.ChatMessage{{Role: "user", Content: "mail bob@example.com"}}}, "Some details were removed."},
+		{"locale falls back to default", models.AnalyzeRequest{Prompt: "mail bob@example.com", Locale: "de-CH"}, "Some details were removed."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.req.ClientID = "svc"
+			resp, err := h.Evaluate(context.Background(), tt.req)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if resp.UserMessage != tt.want {
+				t.Errorf("UserMessage = %q (action %q), want %q", resp.UserMessage, resp.Action, tt.want)
+			}
+		})
+	}
+}
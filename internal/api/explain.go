@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/flags"
+	"github.com/prompt-gateway/internal/scheduler"
+	"github.com/prompt-gateway/internal/tenant"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// HandleExplain annotates content with the spans the client's policies match,
+// for highlighting in UIs
+// POST /v1/analyze/explain
+// Explanations are not decisions: nothing is audited, cached, mirrored or counted
+func (h *Handler) HandleExplain(w http.ResponseWriter, r *http.Request) {
+	var req models.ExplainRequest
+	if err := decodeJSON(r, &req); err != nil {
+		slog.ErrorContext(r.Context(), "Error decoding JSON", "error", err)
+		respondDecodeError(w, err, "Invalid request body", models.ErrorDetail{Reason: err.Error()})
+		return
+	}
+
+	response, err := h.Explain(r.Context(), req)
+	if err != nil {
+		status, apiErr := EvaluateFailure(r.Context(), err)
+		respondErrorCode(w, status, apiErr.Code, apiErr.Message, apiErr.Details...)
+		return
+	}
+	if response.PolicyHash != "" {
+		w.Header().Set("X-Policy-Hash", response.PolicyHash)
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// Explain evaluates content like Evaluate, against the policies applying to the
+// client, and lays the matches out per text. Model policies are skipped: their
+// verdicts have no spans and a UI shouldn't run up provider calls
+func (h *Handler) Explain(ctx context.Context, req models.ExplainRequest) (*models.ExplainResponse, error) {
+	if req.ClientID == "" {
+		return nil, invalidRequest("client_id is required")
+	}
+	if req.Prompt == "" && len(req.Messages) == 0 {
+		return nil, invalidRequest("prompt or messages is required")
+	}
+	if err := validateMessages(req.Messages); err != nil {
+		return nil, invalidRequest("%v", err)
+	}
+
+	// Explanations wait behind enforcement traffic
+	release, err := h.scheduler.Acquire(ctx, scheduler.PriorityBatch)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+	ctx = flags.WithSubject(ctx, req.ClientID)
+
+	client, _ := h.clients.Get(req.ClientID)
+	policySet := h.policyCache
+	if tenantStore, isolated := h.tenants.Get(tenant.Of(client)); isolated {
+		policySet = tenantStore.Cache
+	}
+	// Allow policies only lift default-block decisions; they mark nothing to highlight
+	policies, _ := splitAllowPolicies(effectivePolicies(policySet.Get(), client))
+
+	response := &models.ExplainResponse{Texts: []models.ExplainedText{}, Unlocated: []models.PolicyMatch{}}
+	if snapshot := policySet.Snapshot(); snapshot != nil {
+		response.PolicyHash = snapshot.Hash
+	}
+	located := make([]models.Policy, 0, len(policies))
+	for _, p := range policies {
+		if analyzer.UsesModel(p) {
+			response.Skipped = append(response.Skipped, p.Name)
+			continue
+		}
+		located = append(located, p)
+	}
+
+	// The offline analyzer records no policy timings and never calls a provider
+	explainer := h.analyzer.Offline()
+	var matches []models.PolicyMatch
+	if len(req.Messages) > 0 {
+		for i, msg := range req.Messages {
+			rolePolicies := analyzer.PoliciesForRole(located, msg.Role)
+			content := msg.AnalyzableContent()
+			found, err := explainer.Analyze(ctx, content, rolePolicies)
+			if err != nil {
+				return nil, fmt.Errorf("message %d: %w", i, err)
+			}
+			index := i
+			text := models.ExplainedText{Source: "message", MessageIndex: &index, Role: msg.Role, Content: content}
+			text.Spans, text.Segments = analyzer.Highlight(content, 0, found, rolePolicies)
+			response.Texts = append(response.Texts, text)
+			for _, m := range found {
+				m.MessageIndex = &index
+				matches = append(matches, m)
+			}
+		}
+	} else {
+		// Prompt and response are analyzed together, as Evaluate does, and the
+		// spans split between them; response-only detectors see the response alone
+		content := req.Prompt
+		if req.Response != "" {
+			content += "\n" + req.Response
+		}
+		responseOffset := len(req.Prompt) + 1
+		general, responseOnly := analyzer.SplitResponseOnly(located)
+		matches, err = explainer.Analyze(ctx, content, general)
+		if err != nil {
+			return nil, err
+		}
+		if req.Response != "" && len(responseOnly) > 0 {
+			found, err := explainer.Analyze(ctx, req.Response, responseOnly)
+			if err != nil {
+				return nil, err
+			}
+			for _, m := range found {
+				matches = append(matches, shiftMatch(m, responseOffset))
+			}
+		}
+
+		prompt := models.ExplainedText{Source: "prompt", Content: req.Prompt}
+		prompt.Spans, prompt.Segments = analyzer.Highlight(req.Prompt, 0, matches, located)
+		response.Texts = append(response.Texts, prompt)
+		if req.Response != "" {
+			text := models.ExplainedText{Source: "response", Content: req.Response}
+			text.Spans, text.Segments = analyzer.Highlight(req.Response, responseOffset, matches, located)
+			response.Texts = append(response.Texts, text)
+		}
+	}
+
+	for _, m := range matches {
+		if m.Start == nil {
+			response.Unlocated = append(response.Unlocated, m)
+		}
+	}
+	response.Action, _, _ = resolveDecision(matches, policies)
+	return response, nil
+}
+
+// shiftMatch moves a match's byte offsets by shift
+func shiftMatch(match models.PolicyMatch, shift int) models.PolicyMatch {
+	if match.Start == nil {
+		return match
+	}
+	start, end := *match.Start+shift, *match.End+shift
+	match.Start, match.End = &start, &end
+	occurrences := make([]models.MatchOccurrence, len(match.Occurrences))
+	for i, o := range match.Occurrences {
+		occurrences[i] = models.MatchOccurrence{Start: o.Start + shift, End: o.End + shift}
+	}
+	match.Occurrences = occurrences
+	return match
+}
@@ -2,229 +2,1746 @@ package api
 
 import (
 	"context"
-	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"math"
 	"net/http"
+	"runtime"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/allowlist"
 	"github.com/prompt-gateway/internal/analyzer"
 	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/internal/benign"
 	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/chaos"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/cluster"
+	"github.com/prompt-gateway/internal/dbpool"
+	"github.com/prompt-gateway/internal/decisioncache"
+	"github.com/prompt-gateway/internal/decisiontoken"
+	"github.com/prompt-gateway/internal/fingerprint"
+	"github.com/prompt-gateway/internal/flags"
+	"github.com/prompt-gateway/internal/incident"
+	"github.com/prompt-gateway/internal/logging"
+	"github.com/prompt-gateway/internal/maintenance"
 	"github.com/prompt-gateway/internal/metrics"
 	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/internal/policydiff"
+	"github.com/prompt-gateway/internal/policylint"
+	"github.com/prompt-gateway/internal/replay"
+	"github.com/prompt-gateway/internal/scheduler"
+	"github.com/prompt-gateway/internal/session"
+	"github.com/prompt-gateway/internal/shadow"
+	"github.com/prompt-gateway/internal/slo"
+	"github.com/prompt-gateway/internal/tenant"
+	"github.com/prompt-gateway/internal/tokenizer"
+	"github.com/prompt-gateway/internal/usermessage"
+	"github.com/prompt-gateway/internal/wordlist"
 	"github.com/prompt-gateway/pkg/models"
 )
 
+// DecisionObserver receives every completed analyze decision
+// Implementations must not block; they run on the request path
+type DecisionObserver interface {
+	Observe(event models.DecisionEvent)
+}
+
+// Version is the gateway release reported by /v1/health and /v1/version
+const Version = "1.0.0"
+
 // Handler holds dependencies for HTTP handlers
 type Handler struct {
-	policyRepo  *policy.Repository
-	policyCache *cache.PolicyCache
-	analyzer    *analyzer.Analyzer
-	auditLog    *audit.Logger
+	policyRepo   policy.Store
+	policyCache  *cache.PolicyCache
+	analyzer     *analyzer.Analyzer
+	auditLog     audit.Sink
+	auditRepo    *audit.Repository
+	incidentRepo *incident.Repository
+	clients      *clients.Registry
+	overrides    *session.OverrideStore
+	signer       *decisiontoken.Signer // Optional; nil disables decision tokens
+	replay       *replay.Guard         // Optional; nil accepts nonces without tracking them
+	monitorOnly  bool                  // Compute and log decisions but never enforce them
+	maintenance  *maintenance.Controller
+	wordlists    *wordlist.Store
+	logLevel     *logging.Controller // Optional; nil disables per-request debug logging
+	flags        *flags.Store        // Optional; nil keeps every flagged behavior at its default
+	chaos        *chaos.Injector     // Optional; nil never injects latency
+	policyStats  *analyzer.PolicyStats
+	hits         *policy.HitRecorder  // Optional; adds unflushed hit counts to the policy list
+	dbPool       *dbpool.Pool         // Optional; nil lets requests wait on Postgres until their timeout
+	scheduler    *scheduler.Scheduler // Optional; nil admits every analyze request immediately
+	origin       models.GatewayOrigin // Stamped on every audit entry
+	tenants      *tenant.Router       // Optional; nil keeps every tenant in the shared storage
+	fingerprints *fingerprint.Tracker // Optional; nil disables the blocked prompt analytics
+	rollups      *audit.Rollups       // Optional; nil disables GET /v1/stats
+	slo          *slo.Tracker         // Optional; nil skips latency SLO accounting
+	cluster      *cluster.Registry    // Optional; nil disables the fleet view
+	tokenizer    *tokenizer.Tokenizer // Optional; nil estimates reported token counts
+	decisions    *decisioncache.Cache // Optional; nil evaluates every request
+	shadow       *shadow.Mirror       // Optional; nil disables GET /v1/shadow
+	allowlist    *allowlist.Store     // Optional; nil analyzes every prompt
+	hasher       *audit.Hasher        // Optional; nil hashes raw content with SHA-256
+	auditIngest  *audit.Logger        // Optional; nil disables POST /v1/audit/ingest
+	linter       *policylint.Linter   // Optional; nil lints with the default thresholds
+	benignRepo   *benign.Repository   // Optional; nil always uses the bundled benign corpus
+	changeGuard  *policy.ChangeGuard  // Optional; nil never throttles policy writes
+	userMessages *usermessage.Catalog // Optional; nil never sets user_message
+	batchWorkers int                  // Concurrent evaluations per batch analyze request (0 = default)
+	batchItems   int                  // Items accepted per batch analyze request (0 = default)
+	policyLimit  int                  // Policies evaluated per analyze request (0 = unlimited)
+	matchLimit   int                  // Matches per triggered_policies list and occurrences per match (0 = unlimited)
+	redactLimit  int                  // Redaction passes per analyze request (0 = unlimited)
+	ingestToken  string
+	purges       *decisioncache.Broadcaster // Optional; nil purges only this replica's decision cache
+	observers    []DecisionObserver
 }
 
 // NewHandler creates a new Handler with all dependencies
-func NewHandler(policyRepo *policy.Repository, policyCache *cache.PolicyCache, analyzer *analyzer.Analyzer, auditLog *audit.Logger) *Handler {
+func NewHandler(policyRepo policy.Store, policyCache *cache.PolicyCache, analyzer *analyzer.Analyzer, auditLog audit.Sink, auditRepo *audit.Repository, incidentRepo *incident.Repository, clientRegistry *clients.Registry, overrides *session.OverrideStore) *Handler {
 	return &Handler{
-		policyRepo:  policyRepo,
-		policyCache: policyCache,
-		analyzer:    analyzer,
-		auditLog:    auditLog,
+		policyRepo:   policyRepo,
+		policyCache:  policyCache,
+		analyzer:     analyzer,
+		auditLog:     auditLog,
+		auditRepo:    auditRepo,
+		incidentRepo: incidentRepo,
+		clients:      clientRegistry,
+		overrides:    overrides,
 	}
 }
 
+// SetSigner enables signed decision tokens on analyze responses
+func (h *Handler) SetSigner(signer *decisiontoken.Signer) {
+	h.signer = signer
+}
+
+// SetReplayGuard enables nonce tracking for replay protection
+func (h *Handler) SetReplayGuard(guard *replay.Guard) {
+	h.replay = guard
+}
+
+// SetWordlists enables the wordlist management endpoints
+func (h *Handler) SetWordlists(store *wordlist.Store) {
+	h.wordlists = store
+}
+
+// SetMonitorMode switches between enforcing decisions and only observing them
+func (h *Handler) SetMonitorMode(monitorOnly bool) {
+	h.monitorOnly = monitorOnly
+}
+
+// SetMaintenance enables the maintenance mode endpoints and request gating
+func (h *Handler) SetMaintenance(controller *maintenance.Controller) {
+	h.maintenance = controller
+}
+
+// SetLogLevel enables the runtime log level endpoints and per-request debug logging
+func (h *Handler) SetLogLevel(controller *logging.Controller) {
+	h.logLevel = controller
+}
+
+// SetFlags gates handler behavior behind feature flags and reports them in /v1/version
+func (h *Handler) SetFlags(store *flags.Store) {
+	h.flags = store
+}
+
+// SetChaos injects artificial latency into analyze requests (resilience testing only)
+func (h *Handler) SetChaos(injector *chaos.Injector) {
+	h.chaos = injector
+}
+
+// SetHitRecorder reports unflushed policy hits in the policy list
+func (h *Handler) SetHitRecorder(recorder *policy.HitRecorder) {
+	h.hits = recorder
+}
+
+// SetTenants routes isolated tenants' policies and audit logs to their own storage
+func (h *Handler) SetTenants(router *tenant.Router) {
+	h.tenants = router
+}
+
+// SetFingerprints enables the blocked prompt analytics endpoint
+func (h *Handler) SetFingerprints(tracker *fingerprint.Tracker) {
+	h.fingerprints = tracker
+}
+
+// SetSLO counts analyze requests against latency SLO targets
+func (h *Handler) SetSLO(tracker *slo.Tracker) {
+	h.slo = tracker
+}
+
+// SetCluster enables the fleet view of registered replicas
+func (h *Handler) SetCluster(registry *cluster.Registry) {
+	h.cluster = registry
+}
+
+// SetShadow enables the report of decisions diffed against the shadow gateway
+func (h *Handler) SetShadow(mirror *shadow.Mirror) {
+	h.shadow = mirror
+}
+
+// SetAllowlist allows prompts whose content hash is allowlisted without analysis
+func (h *Handler) SetAllowlist(store *allowlist.Store) {
+	h.allowlist = store
+}
+
+// SetHasher hashes audited and allowlisted content with hasher
+func (h *Handler) SetHasher(hasher *audit.Hasher) {
+	h.hasher = hasher
+}
+
+// SetTokenizer counts the tokens reported on analyze responses with tok
+func (h *Handler) SetTokenizer(tok *tokenizer.Tokenizer) {
+	h.tokenizer = tok
+}
+
+// SetDecisionCache answers repeated identical analyze requests from cache
+func (h *Handler) SetDecisionCache(decisions *decisioncache.Cache) {
+	h.decisions = decisions
+}
+
+// SetDecisionBroadcaster announces erasures so every replica purges its decision cache
+func (h *Handler) SetDecisionBroadcaster(purges *decisioncache.Broadcaster) {
+	h.purges = purges
+}
+
+// SetOrigin sets the deployment identity recorded on audit entries
+func (h *Handler) SetOrigin(origin models.GatewayOrigin) {
+	h.origin = origin
+}
+
+// SetScheduler limits concurrent evaluations, serving interactive requests before batch ones
+func (h *Handler) SetScheduler(s *scheduler.Scheduler) {
+	h.scheduler = s
+}
+
+// SetDBPool bounds how long Postgres-backed requests wait on a saturated pool
+func (h *Handler) SetDBPool(pool *dbpool.Pool) {
+	h.dbPool = pool
+}
+
+// SetPolicyStats enables slow-policy reporting
+func (h *Handler) SetPolicyStats(stats *analyzer.PolicyStats) {
+	h.policyStats = stats
+}
+
+// SetLinter configures the checks of POST /v1/policies/lint
+func (h *Handler) SetLinter(linter *policylint.Linter) {
+	h.linter = linter
+}
+
+// SetBenignCorpus stores uploaded benign corpora in repo
+func (h *Handler) SetBenignCorpus(repo *benign.Repository) {
+	h.benignRepo = repo
+}
+
+// SetChangeGuard throttles policy writes and guards against mass changes
+func (h *Handler) SetChangeGuard(guard *policy.ChangeGuard) {
+	h.changeGuard = guard
+}
+
+// SetUserMessages renders end-user explanations of blocks and redactions from catalog
+func (h *Handler) SetUserMessages(catalog *usermessage.Catalog) {
+	h.userMessages = catalog
+}
+
+// AddObserver registers a background consumer of analyze decisions
+// Must be called before the server starts handling requests
+func (h *Handler) AddObserver(observer DecisionObserver) {
+	h.observers = append(h.observers, observer)
+}
+
 // HandleAnalyze analyzes prompt/response against security policies
 // POST /v1/analyze
 func (h *Handler) HandleAnalyze(w http.ResponseWriter, r *http.Request) {
-	startTime := time.Now()
-
 	// Parse JSON request body
 	// In Go: We need to decode manually
 	var req models.AnalyzeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Printf("Error decoding JSON: %v", err)
-		respondError(w, http.StatusBadRequest, fmt.Sprintf("Invalid request body: %v", err))
+	if err := decodeJSON(r, &req); err != nil {
+		slog.ErrorContext(r.Context(), "Error decoding JSON", "error", err)
+		respondDecodeError(w, err, "Invalid request body", models.ErrorDetail{Reason: err.Error()})
 		return
 	}
+	if req.CacheControl == "" {
+		req.CacheControl = r.Header.Get("Cache-Control")
+	}
+	if req.Locale == "" {
+		req.Locale = usermessage.FromAcceptLanguage(r.Header.Get("Accept-Language"))
+	}
 
-	// Validate request
-	if req.ClientID == "" {
-		respondError(w, http.StatusBadRequest, "client_id is required")
+	response, err := h.Evaluate(r.Context(), req)
+	if err != nil {
+		if errors.Is(err, maintenance.ErrActive) {
+			respondMaintenance(w, h.maintenance.RetryAfter())
+			return
+		}
+		status, apiErr := EvaluateFailure(r.Context(), err)
+		respondErrorCode(w, status, apiErr.Code, apiErr.Message, apiErr.Details...)
 		return
 	}
-	if req.Prompt == "" {
-		respondError(w, http.StatusBadRequest, "prompt is required")
-		return
+
+	if response.PolicyHash != "" {
+		w.Header().Set("X-Policy-Hash", response.PolicyHash)
+	}
+	if response.Cache != nil {
+		w.Header().Set("Age", strconv.FormatInt(response.Cache.AgeSeconds, 10))
+		if response.Cache.Hit {
+			w.Header().Set("X-Decision-Cache", "HIT")
+		} else {
+			w.Header().Set("X-Decision-Cache", "MISS")
+		}
 	}
 
-	// Get policies from in-memory cache (background refreshed from Postgres)
-	policies := h.policyCache.Get()
+	// Send JSON response
+	respondJSON(w, http.StatusOK, response)
+}
 
-	// Combine prompt and response for analysis
-	contentToAnalyze := req.Prompt
-	if req.Response != "" {
-		contentToAnalyze += "\n" + req.Response
+// EvaluateFailure maps an Evaluate error to the HTTP status and error it is answered
+// with; the gRPC stream reports the same errors
+func EvaluateFailure(ctx context.Context, err error) (int, models.APIError) {
+	switch {
+	case errors.Is(err, ErrInvalidRequest):
+		return http.StatusBadRequest, models.APIError{Code: codeInvalidRequest, Message: strings.TrimPrefix(err.Error(), ErrInvalidRequest.Error()+": ")}
+	case errors.Is(err, replay.ErrReplayed):
+		return http.StatusConflict, models.APIError{
+			Code:    codeReplayedRequest,
+			Message: "Replayed request: nonce already used",
+			Details: []models.ErrorDetail{{Field: "nonce", Reason: err.Error()}},
+		}
+	case errors.Is(err, maintenance.ErrActive):
+		return http.StatusServiceUnavailable, models.APIError{Code: codeMaintenance, Message: "Gateway is in maintenance mode"}
+	case errors.Is(err, scheduler.ErrQueueFull):
+		return http.StatusServiceUnavailable, models.APIError{Code: codeUnavailable, Message: "Batch queue full, retry later"}
+	case errors.Is(err, analyzer.ErrEvaluationTimeout):
+		slog.WarnContext(ctx, "Policy evaluation timed out", "error", err)
+		return http.StatusGatewayTimeout, models.APIError{Code: codeTimeout, Message: "Policy evaluation timed out"}
 	}
+	slog.ErrorContext(ctx, "Error analyzing content", "error", err)
+	// Check if request timed out
+	if ctx.Err() == context.DeadlineExceeded {
+		return http.StatusGatewayTimeout, models.APIError{Code: codeTimeout, Message: "Request timeout"}
+	}
+	return http.StatusInternalServerError, models.APIError{Code: codeInternal, Message: "Analysis failed"}
+}
 
-	// Analyze content against policies
-	matches, err := h.analyzer.Analyze(r.Context(), contentToAnalyze, policies)
-	if err != nil {
-		log.Printf("Error analyzing content: %v", err)
-		// Check if request timed out
-		if r.Context().Err() == context.DeadlineExceeded {
-			respondError(w, http.StatusGatewayTimeout, "Request timeout")
-		} else {
-			respondError(w, http.StatusInternalServerError, "Analysis failed")
-		}
+// HandleListPolicies returns all active policies
+// GET /v1/policies
+// ?tenant=name lists an isolated tenant's policies
+// ?unmatched_days=N lists only policies that haven't matched in the last N days
+// ?review_overdue=true lists only policies past their review_by date
+func (h *Handler) HandleListPolicies(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
 		return
 	}
+	_, policyCache := h.policyStorage(store)
 
-	for _, match := range matches {
-		metrics.AnalyzerMatchesTotal.WithLabelValues(match.Severity).Inc()
+	// Get policies from in-memory cache (background refreshed from Postgres)
+	policies := policyCache.Get()
+	if h.hits != nil {
+		policies = h.hits.Overlay(policies)
 	}
 
-	// Determine action based on triggered policies
-	action := "allow"
-	allowed := true
-	highestSeverity := ""
-
-	for _, match := range matches {
-		// Find the policy to get its action
+	if raw := r.URL.Query().Get("unmatched_days"); raw != "" {
+		days, err := strconv.Atoi(raw)
+		if err != nil || days < 0 {
+			respondError(w, http.StatusBadRequest, "unmatched_days must be a non-negative integer")
+			return
+		}
+		cutoff := time.Now().AddDate(0, 0, -days)
+		unmatched := make([]models.Policy, 0)
 		for _, p := range policies {
-			if p.ID == match.PolicyID {
-				if p.Action == "block" {
-					action = "block"
-					allowed = false
-				}
-				// Track highest severity
-				if highestSeverity == "" || severityWeight(match.Severity) > severityWeight(highestSeverity) {
-					highestSeverity = match.Severity
+			if p.LastMatchedAt == nil || p.LastMatchedAt.Before(cutoff) {
+				unmatched = append(unmatched, p)
+			}
+		}
+		policies = unmatched
+	}
+	if raw := r.URL.Query().Get("review_overdue"); raw != "" {
+		overdueOnly, err := strconv.ParseBool(raw)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "review_overdue must be true or false")
+			return
+		}
+		if overdueOnly {
+			now := time.Now()
+			overdue := make([]models.Policy, 0)
+			for _, p := range policies {
+				if policy.ReviewOverdue(p, now) {
+					overdue = append(overdue, p)
 				}
-				break
 			}
+			policies = overdue
 		}
 	}
 
-	// Redact content if needed
-	redactedPrompt := ""
-	if len(matches) > 0 {
-		redactedPrompt = h.analyzer.RedactContent(req.Prompt, matches, policies)
+	respondJSON(w, http.StatusOK, policies)
+}
+
+// HandleCreatePolicy creates a new security policy
+// POST /v1/policies
+// ?tenant=name creates it in an isolated tenant's storage
+func (h *Handler) HandleCreatePolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
 	}
 
-	// Calculate latency
-	latencyMs := time.Since(startTime).Milliseconds()
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	policyRepo, policyCache := h.policyStorage(store)
+	guardKey, ok := h.guardPolicyWrite(w, r, store, 1)
+	if !ok {
+		return
+	}
 
-	// Get request ID from context (created in middleware)
-	requestIDStr, _ := r.Context().Value(requestIDKey).(string)
-	requestID, _ := uuid.Parse(requestIDStr)
-	// Create response
-	response := models.AnalyzeResponse{
-		RequestID:         requestID,
-		Allowed:           allowed,
-		Action:            action,
-		TriggeredPolicies: matches,
-		RedactedPrompt:    redactedPrompt,
-		LatencyMs:         latencyMs,
+	// Create policy directly in Postgres
+	created, err := policyRepo.Create(r.Context(), req)
+	if err != nil {
+		respondPolicyError(w, r, err)
+		return
 	}
+	h.changeGuard.Record(guardKey, 1)
 
-	// Log audit entry
-	policyIDs := make([]uuid.UUID, len(matches))
-	for i, m := range matches {
-		policyIDs[i] = m.PolicyID
+	// Refresh in-memory cache so new policy is available for subsequent requests
+	if err := policyCache.Invalidate(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "Failed to refresh policy cache", "error", err)
 	}
 
-	auditEntry := models.AuditLog{
-		ID:                uuid.New(),
-		RequestID:         requestID,
-		ClientID:          req.ClientID,
-		PromptHash:        audit.HashContent(req.Prompt),
-		ResponseHash:      audit.HashContent(req.Response),
-		PoliciesTriggered: policyIDs,
-		ActionTaken:       action,
-		LatencyMs:         int(latencyMs),
-		CreatedAt:         time.Now(),
+	respondJSON(w, http.StatusCreated, created)
+}
+
+// HandleLintPolicy reports problems with a policy definition without creating it:
+// whether it is valid, and best-practice warnings such as matching too much of
+// a benign prompt corpus or duplicating an existing policy
+// POST /v1/policies/lint
+// ?tenant=name checks duplicates against an isolated tenant's policies
+func (h *Handler) HandleLintPolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.CreatePolicyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
 	}
 
-	// Log audit entry asynchronously (fire-and-forget)
-	h.auditLog.Log(auditEntry)
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	_, policyCache := h.policyStorage(store)
 
-	// Send JSON response
-	respondJSON(w, http.StatusOK, response)
+	report, err := h.policyLinter().Lint(r.Context(), req, policyCache.Get(), h.benignCorpus(r.Context(), store))
+	if err != nil {
+		respondError(w, http.StatusGatewayTimeout, "Request timeout")
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
 }
 
-// HandleListPolicies returns all active policies
-// GET /v1/policies
-func (h *Handler) HandleListPolicies(w http.ResponseWriter, r *http.Request) {
-	// Get policies from in-memory cache (background refreshed from Postgres)
-	policies := h.policyCache.Get()
-	respondJSON(w, http.StatusOK, policies)
+// HandleTestPolicy runs a candidate policy definition over sample content and
+// reports whether it would match each sample, without creating it
+// POST /v1/policies/test
+func (h *Handler) HandleTestPolicy(w http.ResponseWriter, r *http.Request) {
+	var req models.PolicyTestRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+	if len(req.Samples) == 0 || len(req.Samples) > policylint.MaxTestSamples {
+		respondError(w, http.StatusBadRequest, fmt.Sprintf("samples must hold 1 to %d entries", policylint.MaxTestSamples))
+		return
+	}
+
+	result, err := h.policyLinter().Test(r.Context(), req.Policy, req.Samples)
+	if errors.Is(err, policylint.ErrModelPolicy) {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusGatewayTimeout, "Request timeout")
+		return
+	}
+	respondJSON(w, http.StatusOK, result)
 }
 
-// HandleCreatePolicy creates a new security policy
-// POST /v1/policies
-func (h *Handler) HandleCreatePolicy(w http.ResponseWriter, r *http.Request) {
-	var req models.CreatePolicyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		respondError(w, http.StatusBadRequest, "Invalid request body")
+// policyLinter returns the configured linter, or one with the default thresholds
+func (h *Handler) policyLinter() *policylint.Linter {
+	if h.linter == nil {
+		return policylint.New(h.analyzer.Offline(), policylint.DefaultBroadPercent)
+	}
+	return h.linter
+}
+
+// HandleBulkUpdatePolicies enables, disables or re-grades every policy carrying a
+// tag and/or owned by a team in one transaction
+// PATCH /v1/policies?tag=experimental&team=name
+// ?tenant=name updates an isolated tenant's policies
+func (h *Handler) HandleBulkUpdatePolicies(w http.ResponseWriter, r *http.Request) {
+	var req models.BulkPolicyUpdate
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+	filter := policy.BulkFilter{Tag: r.URL.Query().Get("tag"), Team: r.URL.Query().Get("team")}
+	if err := policy.ValidateBulkUpdate(filter, req); err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 
-	// Create policy directly in Postgres
-	policy, err := h.policyRepo.Create(r.Context(), req)
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	policyRepo, policyCache := h.policyStorage(store)
+	guardKey, ok := h.guardPolicyWrite(w, r, store, 1)
+	if !ok {
+		return
+	}
+	filter.MaxChanges = max(h.policyChangeBudget(r, guardKey), 0)
+
+	result, err := policyRepo.BulkUpdate(r.Context(), filter, req)
+	if errors.Is(err, policy.ErrPolicyLimit) {
+		respondError(w, http.StatusConflict, err.Error())
+		return
+	}
+	if errors.Is(err, policy.ErrTooManyChanges) {
+		metrics.PolicyWritesRejectedTotal.WithLabelValues(codeConfirmRequired).Inc()
+		respondErrorCode(w, http.StatusPreconditionRequired, codeConfirmRequired, err.Error()+"; repeat with ?confirm=true")
+		return
+	}
 	if err != nil {
-		log.Printf("Error creating policy: %v", err)
-		// Check if request timed out
-		if r.Context().Err() == context.DeadlineExceeded {
-			respondError(w, http.StatusGatewayTimeout, "Request timeout")
-		} else {
-			respondError(w, http.StatusBadRequest, err.Error())
+		slog.ErrorContext(r.Context(), "Error bulk updating policies", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to update policies")
+		return
+	}
+	slog.InfoContext(r.Context(), "Bulk policy update", "tag", filter.Tag, "team", filter.Team, "matched", result.Matched, "changed", result.Changed)
+	h.changeGuard.Record(guardKey, result.Changed)
+
+	if result.Changed > 0 {
+		if err := policyCache.Invalidate(r.Context()); err != nil {
+			slog.WarnContext(r.Context(), "Failed to refresh policy cache", "error", err)
+		}
+	}
+
+	respondJSON(w, http.StatusOK, result)
+}
+
+// HandleGetPolicy returns a policy, enabled or not
+// GET /v1/policies/{id}
+// ?tenant=name reads an isolated tenant's policy
+func (h *Handler) HandleGetPolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid policy ID")
+		return
+	}
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	policyRepo, _ := h.policyStorage(store)
+
+	p, err := policyRepo.GetByID(r.Context(), id)
+	if err != nil {
+		respondPolicyError(w, r, err)
+		return
+	}
+	if h.hits != nil {
+		*p = h.hits.Overlay([]models.Policy{*p})[0]
+	}
+	respondJSON(w, http.StatusOK, p)
+}
+
+// HandleUpdatePolicy replaces a policy's definition (PUT, same body as create) or
+// changes only the fields present in the body (PATCH); the policy keeps its ID,
+// enabled state and hit statistics
+// PUT|PATCH /v1/policies/{id}
+// ?tenant=name updates an isolated tenant's policy
+func (h *Handler) HandleUpdatePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid policy ID")
+		return
+	}
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	policyRepo, policyCache := h.policyStorage(store)
+	guardKey, ok := h.guardPolicyWrite(w, r, store, 1)
+	if !ok {
+		return
+	}
+
+	var req models.CreatePolicyRequest
+	if r.Method == http.MethodPatch {
+		// Fields missing from the body keep their current values
+		current, err := policyRepo.GetByID(r.Context(), id)
+		if err != nil {
+			respondPolicyError(w, r, err)
+			return
 		}
+		req = policy.RequestOf(*current)
+	}
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
 		return
 	}
 
-	// Refresh in-memory cache so new policy is available for subsequent requests
-	if err := h.policyCache.Invalidate(r.Context()); err != nil {
-		log.Printf("⚠️  Failed to refresh policy cache: %v", err)
+	updated, err := policyRepo.Update(r.Context(), id, req)
+	if err != nil {
+		respondPolicyError(w, r, err)
+		return
 	}
+	slog.InfoContext(r.Context(), "Updated policy", "policy", updated.Name, "policy_id", updated.ID)
+	h.changeGuard.Record(guardKey, 1)
 
-	respondJSON(w, http.StatusCreated, policy)
+	if err := policyCache.Invalidate(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "Failed to refresh policy cache", "error", err)
+	}
+
+	respondJSON(w, http.StatusOK, updated)
 }
 
-// HandleHealth returns service health status
-// GET /v1/health
-func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
-	response := models.HealthResponse{
-		Status:    "healthy",
-		Timestamp: time.Now(),
-		Version:   "1.0.0",
+// HandleEnablePolicy switches a policy on, within the enabled policy cap
+// POST /v1/policies/{id}/enable
+// ?tenant=name targets an isolated tenant's policy
+func (h *Handler) HandleEnablePolicy(w http.ResponseWriter, r *http.Request) {
+	h.setPolicyEnabled(w, r, true)
+}
+
+// HandleDisablePolicy switches a policy off without deleting it
+// POST /v1/policies/{id}/disable
+// ?tenant=name targets an isolated tenant's policy
+func (h *Handler) HandleDisablePolicy(w http.ResponseWriter, r *http.Request) {
+	h.setPolicyEnabled(w, r, false)
+}
+
+// setPolicyEnabled toggles the policy named by the request path
+func (h *Handler) setPolicyEnabled(w http.ResponseWriter, r *http.Request, enabled bool) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid policy ID")
+		return
+	}
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	policyRepo, policyCache := h.policyStorage(store)
+	guardKey, ok := h.guardPolicyWrite(w, r, store, 1)
+	if !ok {
+		return
 	}
 
-	respondJSON(w, http.StatusOK, response)
+	updated, err := policyRepo.SetEnabled(r.Context(), id, enabled)
+	if err != nil {
+		respondPolicyError(w, r, err)
+		return
+	}
+	h.changeGuard.Record(guardKey, 1)
+
+	if err := policyCache.Invalidate(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "Failed to refresh policy cache", "error", err)
+	}
+
+	respondJSON(w, http.StatusOK, updated)
 }
 
-// Helper functions
+// HandleDeletePolicy removes a policy
+// DELETE /v1/policies/{id}
+// ?tenant=name deletes an isolated tenant's policy
+func (h *Handler) HandleDeletePolicy(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid policy ID")
+		return
+	}
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	policyRepo, policyCache := h.policyStorage(store)
+	guardKey, ok := h.guardPolicyWrite(w, r, store, 1)
+	if !ok {
+		return
+	}
 
-// respondJSON sends a JSON response
-func respondJSON(w http.ResponseWriter, status int, data interface{}) {
-	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(status)
-	json.NewEncoder(w).Encode(data)
+	if err := policyRepo.Delete(r.Context(), id); err != nil {
+		respondPolicyError(w, r, err)
+		return
+	}
+	slog.InfoContext(r.Context(), "Deleted policy", "policy_id", id)
+	h.changeGuard.Record(guardKey, 1)
+
+	if err := policyCache.Invalidate(r.Context()); err != nil {
+		slog.WarnContext(r.Context(), "Failed to refresh policy cache", "error", err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxDiffCorpus bounds the corpus of one policy diff request; larger corpora are
+// diffed offline with `gateway policy-diff`
+const maxDiffCorpus = 10000
+
+// HandleDiffPolicies reports which decisions on a corpus would change between two
+// policy bundles (before defaults to the live policies), summarized per policy
+// POST /v1/policies/diff?tenant=
+func (h *Handler) HandleDiffPolicies(w http.ResponseWriter, r *http.Request) {
+	var req models.PolicyDiffRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body", models.ErrorDetail{Reason: err.Error()})
+		return
+	}
+	if len(req.Corpus) == 0 {
+		respondError(w, http.StatusBadRequest, "corpus is required")
+		return
+	}
+	if len(req.Corpus) > maxDiffCorpus {
+		respondError(w, http.StatusBadRequest, "corpus must have at most "+strconv.Itoa(maxDiffCorpus)+" entries")
+		return
+	}
+
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	before := []models.Policy(req.Before)
+	if req.Before == nil {
+		_, policyCache := h.policyStorage(store)
+		before = policyCache.Get()
+	}
+
+	differ := policydiff.New(h.analyzer.Offline(), func(id string) models.Client {
+		if c, ok := h.clients.Get(id); ok {
+			return c
+		}
+		return models.Client{ID: id}
+	})
+	differ.SetBenignCorpus(h.benignCorpus(r.Context(), store))
+	report, err := differ.Run(r.Context(), before, req.After, req.Corpus)
+	if err != nil {
+		if r.Context().Err() != nil {
+			respondError(w, http.StatusGatewayTimeout, "Request timeout")
+			return
+		}
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, report)
 }
 
-// respondError sends an error response
-func respondError(w http.ResponseWriter, status int, message string) {
-	respondJSON(w, status, map[string]string{"error": message})
+// benignCorpus returns the benign corpus uploaded to store (nil = shared), or the
+// bundled one when none was uploaded or it can't be loaded
+func (h *Handler) benignCorpus(ctx context.Context, store *tenant.Storage) benign.Corpus {
+	repo := h.benignStorage(store)
+	if repo == nil {
+		return benign.Bundled()
+	}
+	corpus, err := repo.Load(ctx)
+	if err != nil {
+		if !errors.Is(err, benign.ErrNotUploaded) {
+			slog.WarnContext(ctx, "Failed to load benign corpus, using the bundled one", "error", err)
+		}
+		return benign.Bundled()
+	}
+	return corpus
 }
 
-// severityWeight returns numeric weight for severity comparison
-func severityWeight(severity string) int {
-	weights := map[string]int{
-		"low":      1,
-		"medium":   2,
-		"high":     3,
-		"critical": 4,
+// HandleGetBenignCorpus describes the benign corpus lints and diffs estimate false positives on
+// GET /v1/corpus/benign?tenant=
+func (h *Handler) HandleGetBenignCorpus(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
 	}
-	return weights[severity]
+	respondJSON(w, http.StatusOK, h.benignCorpus(r.Context(), store).Info())
+}
+
+// HandleUploadBenignCorpus replaces the bundled benign corpus with prompts typical
+// of this deployment's traffic
+// PUT /v1/corpus/benign?tenant=
+func (h *Handler) HandleUploadBenignCorpus(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	repo := h.benignStorage(store)
+	if repo == nil {
+		respondError(w, http.StatusNotFound, "benign corpus uploads are not enabled")
+		return
+	}
+	var req models.UploadBenignCorpusRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+	prompts, err := benign.Normalize(req.Prompts)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	corpus, err := repo.Replace(r.Context(), prompts)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error saving benign corpus", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to save benign corpus")
+		return
+	}
+	slog.InfoContext(r.Context(), "Benign corpus replaced", "prompts", len(prompts))
+	respondJSON(w, http.StatusOK, corpus.Info())
+}
+
+// HandleDeleteBenignCorpus removes the uploaded corpus, reverting to the bundled one
+// DELETE /v1/corpus/benign?tenant=
+func (h *Handler) HandleDeleteBenignCorpus(w http.ResponseWriter, r *http.Request) {
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	repo := h.benignStorage(store)
+	if repo == nil {
+		respondError(w, http.StatusNotFound, benign.ErrNotUploaded.Error())
+		return
+	}
+	err := repo.Delete(r.Context())
+	if errors.Is(err, benign.ErrNotUploaded) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting benign corpus", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to delete benign corpus")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleSlowPolicies lists policies whose average evaluation time exceeds the slow threshold
+// GET /v1/policies/slow
+func (h *Handler) HandleSlowPolicies(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.policyStats.Slow())
+}
+
+// HandleBlockedPrompts lists the most frequently blocked prompt fingerprints
+// GET /v1/analytics/blocked-prompts?since=&limit=&group=simhash
+func (h *Handler) HandleBlockedPrompts(w http.ResponseWriter, r *http.Request) {
+	if h.fingerprints == nil {
+		respondError(w, http.StatusNotFound, "blocked prompt tracking is not enabled")
+		return
+	}
+	q := r.URL.Query()
+	var since time.Time
+	if v := q.Get("since"); v != "" {
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "since must be an RFC 3339 timestamp")
+			return
+		}
+		since = t
+	}
+	limit := 50
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+	group := false
+	switch q.Get("group") {
+	case "", "hash":
+	case "simhash":
+		group = true
+	default:
+		respondError(w, http.StatusBadRequest, "group must be hash or simhash")
+		return
+	}
+
+	prompts, err := h.fingerprints.Top(r.Context(), since, limit, group)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error querying blocked prompts", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to query blocked prompts")
+		return
+	}
+	respondJSON(w, http.StatusOK, prompts)
+}
+
+// HandleSessionTimeline returns the ordered analyze decisions for a session
+// GET /v1/sessions/{session_id}
+func (h *Handler) HandleSessionTimeline(w http.ResponseWriter, r *http.Request) {
+	sessionID := r.PathValue("session_id")
+	if sessionID == "" {
+		respondError(w, http.StatusBadRequest, "session_id is required")
+		return
+	}
+
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	decisions, err := h.auditStorage(store).ListBySession(r.Context(), sessionID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading session timeline", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to load session timeline")
+		return
+	}
+
+	if len(decisions) == 0 {
+		respondError(w, http.StatusNotFound, "session not found")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.SessionTimelineResponse{
+		SessionID: sessionID,
+		Count:     len(decisions),
+		Decisions: decisions,
+	})
+}
+
+// HandleVerifyToken lets downstream services check a decision token
+// POST /v1/verify
+func (h *Handler) HandleVerifyToken(w http.ResponseWriter, r *http.Request) {
+	if h.signer == nil {
+		respondError(w, http.StatusNotFound, "Decision tokens are disabled")
+		return
+	}
+
+	var req models.VerifyTokenRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid JSON")
+		return
+	}
+	if req.Token == "" {
+		respondError(w, http.StatusBadRequest, "token is required")
+		return
+	}
+
+	claims, err := h.signer.Verify(req.Token)
+	if err != nil {
+		resp := models.VerifyTokenResponse{Valid: false, Error: err.Error()}
+		if errors.Is(err, decisiontoken.ErrExpired) {
+			resp.Claims = claims
+		}
+		respondJSON(w, http.StatusOK, resp)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.VerifyTokenResponse{Valid: true, Claims: claims})
+}
+
+// HandlePublicKeys publishes the decision token verification keys as a JWKS
+// GET /v1/keys
+func (h *Handler) HandlePublicKeys(w http.ResponseWriter, r *http.Request) {
+	if h.signer == nil {
+		respondError(w, http.StatusNotFound, "Decision tokens are disabled")
+		return
+	}
+
+	w.Header().Set("Cache-Control", "public, max-age=300")
+	respondJSON(w, http.StatusOK, h.signer.PublicKeys())
+}
+
+// HandleGetSessionOverride returns the decision pinned for a session
+// GET /v1/sessions/{session_id}/override
+func (h *Handler) HandleGetSessionOverride(w http.ResponseWriter, r *http.Request) {
+	override, err := h.overrides.Get(r.Context(), r.PathValue("session_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading session override", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to load session override")
+		return
+	}
+	if override == nil {
+		respondError(w, http.StatusNotFound, "no override for session")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, override)
+}
+
+// HandleSetSessionOverride pins a decision for every request of a session until the TTL expires
+// PUT /v1/sessions/{session_id}/override
+func (h *Handler) HandleSetSessionOverride(w http.ResponseWriter, r *http.Request) {
+	var req models.SetSessionOverrideRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid JSON")
+		return
+	}
+
+	if req.Action != "block" && req.Action != "allow" {
+		respondError(w, http.StatusBadRequest, "action must be one of: block, allow")
+		return
+	}
+	if req.TTLSeconds <= 0 {
+		respondError(w, http.StatusBadRequest, "ttl_seconds must be positive")
+		return
+	}
+
+	override := models.SessionOverride{
+		SessionID: r.PathValue("session_id"),
+		Action:    req.Action,
+		Reason:    req.Reason,
+		SetBy:     req.SetBy,
+	}
+	if err := h.overrides.Set(r.Context(), override, time.Duration(req.TTLSeconds)*time.Second); err != nil {
+		slog.ErrorContext(r.Context(), "Error setting session override", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to set session override")
+		return
+	}
+
+	slog.InfoContext(r.Context(), "Session pinned", "session_id", override.SessionID, "action", req.Action,
+		"ttl_seconds", req.TTLSeconds, "set_by", req.SetBy, "reason", req.Reason)
+	h.HandleGetSessionOverride(w, r)
+}
+
+// HandleDeleteSessionOverride lifts a pinned session decision
+// DELETE /v1/sessions/{session_id}/override
+func (h *Handler) HandleDeleteSessionOverride(w http.ResponseWriter, r *http.Request) {
+	existed, err := h.overrides.Delete(r.Context(), r.PathValue("session_id"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting session override", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to delete session override")
+		return
+	}
+	if !existed {
+		respondError(w, http.StatusNotFound, "no override for session")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleListAudit queries persisted audit entries, newest first
+// GET /v1/audit?client_id=&session_id=&action=&instance_id=&hostname=&region=&zone=&version=&prompt_hash=&since=&until=&limit=&tenant=
+func (h *Handler) HandleListAudit(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := models.AuditFilter{
+		ClientID:       q.Get("client_id"),
+		SessionID:      q.Get("session_id"),
+		Action:         q.Get("action"),
+		InstanceID:     q.Get("instance_id"),
+		Hostname:       q.Get("hostname"),
+		Region:         q.Get("region"),
+		Zone:           q.Get("zone"),
+		GatewayVersion: q.Get("version"),
+		PromptHash:     q.Get("prompt_hash"),
+		Limit:          100,
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, name+" must be an RFC 3339 timestamp")
+				return
+			}
+			*dst = t
+		}
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		filter.Limit = n
+	}
+
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+	entries, err := h.auditStorage(store).List(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error querying audit logs", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to query audit logs")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, entries)
+}
+
+// HandleEraseAudit purges all audit data for a data subject (right to erasure)
+// DELETE /v1/audit?client_id=...&session_id=...[&tenant=...]
+func (h *Handler) HandleEraseAudit(w http.ResponseWriter, r *http.Request) {
+	clientID := r.URL.Query().Get("client_id")
+	sessionID := r.URL.Query().Get("session_id")
+	if clientID == "" && sessionID == "" {
+		respondError(w, http.StatusBadRequest, "client_id or session_id is required")
+		return
+	}
+	store, ok := h.tenantStorage(r)
+	if !ok {
+		respondError(w, http.StatusNotFound, "unknown tenant")
+		return
+	}
+
+	// Purge the Redis queue first so the sync worker can't re-insert rows after the delete
+	pendingDeleted, err := h.auditLog.PurgePending(r.Context(), func(entry models.AuditLog) bool {
+		return (clientID == "" || entry.ClientID == clientID) &&
+			(sessionID == "" || entry.SessionID == sessionID)
+	})
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error purging pending audit logs", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to purge pending audit logs")
+		return
+	}
+
+	deleted, err := h.auditStorage(store).DeleteBySubject(r.Context(), clientID, sessionID)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting audit logs", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to delete audit logs")
+		return
+	}
+	// Roll-ups only cover the shared audit table and have no session dimension
+	if store == nil && sessionID == "" {
+		if err := h.rollups.EraseClient(r.Context(), clientID); err != nil {
+			slog.ErrorContext(r.Context(), "Error deleting audit roll-ups", "error", err)
+			respondStorageError(w, r, http.StatusInternalServerError, "Failed to delete audit roll-ups")
+			return
+		}
+	}
+
+	// Cached decisions keep match metadata of the subject's requests, on every replica
+	cached := h.decisions.Purge(clientID, sessionID)
+	if err := h.purges.Publish(r.Context(), clientID, sessionID); err != nil {
+		slog.ErrorContext(r.Context(), "Error purging cached decisions on other replicas", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to purge cached decisions on other replicas")
+		return
+	}
+
+	report := models.ErasureReport{
+		ClientID:               clientID,
+		SessionID:              sessionID,
+		AuditLogsDeleted:       deleted,
+		PendingLogsDeleted:     pendingDeleted,
+		CachedDecisionsDeleted: cached,
+		CompletedAt:            time.Now(),
+	}
+	slog.InfoContext(r.Context(), "Audit erasure completed",
+		"client_id", clientID, "session_id", sessionID, "persisted", deleted, "pending", pendingDeleted, "cached", cached)
+
+	respondJSON(w, http.StatusOK, report)
+}
+
+// HandleListIncidents returns incidents, optionally filtered by status
+// GET /v1/incidents?status=open&limit=100
+func (h *Handler) HandleListIncidents(w http.ResponseWriter, r *http.Request) {
+	status := r.URL.Query().Get("status")
+	limit := 100
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 1000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 1000")
+			return
+		}
+		limit = n
+	}
+
+	incidents, err := h.incidentRepo.List(r.Context(), status, limit)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error listing incidents", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to list incidents")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, incidents)
+}
+
+// HandleCreateIncident manually opens an incident
+// POST /v1/incidents
+func (h *Handler) HandleCreateIncident(w http.ResponseWriter, r *http.Request) {
+	var req models.CreateIncidentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	inc, err := h.incidentRepo.Create(r.Context(), req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error creating incident", "error", err)
+		respondStorageError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusCreated, inc)
+}
+
+// HandleGetIncident returns an incident with its linked audit entries
+// GET /v1/incidents/{id}
+func (h *Handler) HandleGetIncident(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid incident ID")
+		return
+	}
+
+	inc, err := h.incidentRepo.GetByID(r.Context(), id)
+	if err != nil {
+		respondIncidentError(w, r, err)
+		return
+	}
+
+	entries, err := h.auditRepo.ListByRequestIDs(r.Context(), inc.AuditRequestIDs)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error loading incident audit entries", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to load linked audit entries")
+		return
+	}
+
+	respondJSON(w, http.StatusOK, models.IncidentDetail{Incident: *inc, AuditEntries: entries})
+}
+
+// HandleUpdateIncident acknowledges, resolves, reopens or annotates an incident
+// PATCH /v1/incidents/{id}
+func (h *Handler) HandleUpdateIncident(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid incident ID")
+		return
+	}
+
+	var req models.UpdateIncidentRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	inc, err := h.incidentRepo.Update(r.Context(), id, req)
+	if err != nil {
+		respondIncidentError(w, r, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, inc)
+}
+
+// HandleDeleteIncident removes an incident
+// DELETE /v1/incidents/{id}
+func (h *Handler) HandleDeleteIncident(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(r.PathValue("id"))
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Invalid incident ID")
+		return
+	}
+
+	if err := h.incidentRepo.Delete(r.Context(), id); err != nil {
+		respondIncidentError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// tenantStorage resolves the isolated storage an admin request targets: the tenant
+// query parameter, else the tenant of the client_id parameter. It returns nil for the
+// shared storage, and false when an explicitly named tenant isn't isolated
+func (h *Handler) tenantStorage(r *http.Request) (*tenant.Storage, bool) {
+	name := r.URL.Query().Get("tenant")
+	if name == "" {
+		if c, ok := h.clients.Get(r.URL.Query().Get("client_id")); ok {
+			store, _ := h.tenants.Get(tenant.Of(c))
+			return store, true
+		}
+		return nil, true
+	}
+	store, ok := h.tenants.Get(name)
+	return store, ok
+}
+
+// policyStorage returns the policy repository and cache of store (nil = shared)
+func (h *Handler) policyStorage(store *tenant.Storage) (policy.Store, *cache.PolicyCache) {
+	if store == nil {
+		return h.policyRepo, h.policyCache
+	}
+	return store.Policies, store.Cache
+}
+
+// auditStorage returns the audit repository of store (nil = shared)
+func (h *Handler) auditStorage(store *tenant.Storage) *audit.Repository {
+	if store == nil {
+		return h.auditRepo
+	}
+	return store.Audit
+}
+
+// benignStorage returns the benign corpus repository of store (nil = shared)
+func (h *Handler) benignStorage(store *tenant.Storage) *benign.Repository {
+	if store == nil {
+		return h.benignRepo
+	}
+	return store.Benign
+}
+
+// guardPolicyWrite applies the change guard to a write of store's policies: it
+// throttles write requests and requires ?confirm=true once n more changed policies
+// would exceed the per-minute budget. It returns the store's guard key, and false
+// when it already replied
+func (h *Handler) guardPolicyWrite(w http.ResponseWriter, r *http.Request, store *tenant.Storage, n int) (string, bool) {
+	key := ""
+	if store != nil {
+		key = store.Name
+	}
+	if wait, ok := h.changeGuard.Allow(key); !ok {
+		metrics.PolicyWritesRejectedTotal.WithLabelValues(codeRateLimited).Inc()
+		w.Header().Set("Retry-After", strconv.Itoa(max(int(math.Ceil(wait.Seconds())), 1)))
+		respondError(w, http.StatusTooManyRequests, "Too many policy writes, retry later")
+		return key, false
+	}
+	if budget := h.policyChangeBudget(r, key); budget >= 0 && n > budget {
+		metrics.PolicyWritesRejectedTotal.WithLabelValues(codeConfirmRequired).Inc()
+		respondErrorCode(w, http.StatusPreconditionRequired, codeConfirmRequired, "Too many policies changed in the last minute; repeat with ?confirm=true")
+		return key, false
+	}
+	return key, true
+}
+
+// policyChangeBudget returns how many more policies a write may change without
+// confirmation (-1 = unlimited, as for confirmed writes)
+func (h *Handler) policyChangeBudget(r *http.Request, key string) int {
+	if confirmed, _ := strconv.ParseBool(r.URL.Query().Get("confirm")); confirmed {
+		return -1
+	}
+	return h.changeGuard.Budget(key)
+}
+
+// respondPolicyError maps policy repository errors to HTTP responses; anything
+// but a known error is a storage failure whose details stay in the logs
+func respondPolicyError(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, policy.ErrNotFound):
+		respondError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, policy.ErrPolicyLimit):
+		respondError(w, http.StatusConflict, err.Error())
+	case errors.Is(err, policy.ErrInvalid):
+		respondError(w, http.StatusBadRequest, err.Error())
+	default:
+		slog.ErrorContext(r.Context(), "Error handling policy", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to store policy")
+	}
+}
+
+// respondIncidentError maps incident repository errors to HTTP responses
+func respondIncidentError(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, incident.ErrNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	slog.ErrorContext(r.Context(), "Error handling incident", "error", err)
+	respondStorageError(w, r, http.StatusBadRequest, err.Error())
+}
+
+// HandleListClients returns all registered clients with trust scores
+// GET /v1/clients
+func (h *Handler) HandleListClients(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.clients.List())
+}
+
+// HandleGetClient returns a client's trust score and tier
+// GET /v1/clients/{id}
+func (h *Handler) HandleGetClient(w http.ResponseWriter, r *http.Request) {
+	c, ok := h.clients.Get(r.PathValue("id"))
+	if !ok {
+		respondError(w, http.StatusNotFound, clients.ErrNotFound.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, c)
+}
+
+// HandleUpsertClient registers a client or updates verification/manual trust adjustment
+// PUT /v1/clients/{id}
+func (h *Handler) HandleUpsertClient(w http.ResponseWriter, r *http.Request) {
+	var req models.UpsertClientRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	c, err := h.clients.Upsert(r.Context(), r.PathValue("id"), req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error upserting client", "error", err)
+		respondStorageError(w, r, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	respondJSON(w, http.StatusOK, c)
+}
+
+// HandleListWordlists returns all wordlists with term counts
+// GET /v1/wordlists
+func (h *Handler) HandleListWordlists(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.wordlists.List())
+}
+
+// HandleGetWordlist returns a wordlist with its terms
+// GET /v1/wordlists/{name}
+func (h *Handler) HandleGetWordlist(w http.ResponseWriter, r *http.Request) {
+	wl, ok := h.wordlists.Get(r.PathValue("name"))
+	if !ok {
+		respondError(w, http.StatusNotFound, wordlist.ErrNotFound.Error())
+		return
+	}
+	respondJSON(w, http.StatusOK, wl)
+}
+
+// HandleUpsertWordlist creates or replaces a wordlist
+// PUT /v1/wordlists/{name}
+func (h *Handler) HandleUpsertWordlist(w http.ResponseWriter, r *http.Request) {
+	var req models.UpsertWordlistRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid request body")
+		return
+	}
+
+	name := r.PathValue("name")
+	req, err := wordlist.Normalize(name, req)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	wl, err := h.wordlists.Upsert(r.Context(), name, req)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error upserting wordlist", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to save wordlist")
+		return
+	}
+
+	wl.Terms = nil
+	respondJSON(w, http.StatusOK, wl)
+}
+
+// HandleDeleteWordlist removes a wordlist
+// Dictionary policies referencing it fail evaluation until it is recreated
+// DELETE /v1/wordlists/{name}
+func (h *Handler) HandleDeleteWordlist(w http.ResponseWriter, r *http.Request) {
+	err := h.wordlists.Delete(r.Context(), r.PathValue("name"))
+	if errors.Is(err, wordlist.ErrNotFound) {
+		respondError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error deleting wordlist", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to delete wordlist")
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// HandleVersion reports the gateway version and effective feature flags
+// GET /v1/version
+func (h *Handler) HandleVersion(w http.ResponseWriter, r *http.Request) {
+	response := models.VersionResponse{
+		Version:   Version,
+		GoVersion: runtime.Version(),
+		Flags:     []models.FeatureFlag{},
+	}
+	if h.flags != nil {
+		response.Flags = h.flags.List()
+	}
+	respondJSON(w, http.StatusOK, response)
+}
+
+// HandleHealth returns service health status
+// GET /v1/health
+func (h *Handler) HandleHealth(w http.ResponseWriter, r *http.Request) {
+	response := models.HealthResponse{
+		Status:    "healthy",
+		Timestamp: time.Now(),
+		Version:   Version,
+	}
+
+	// Report not-ready during maintenance so load balancers stop routing to us
+	if h.maintenance != nil && h.maintenance.Enabled() {
+		status := h.maintenance.Status()
+		response.Status = "maintenance"
+		response.Maintenance = &status
+		respondJSON(w, http.StatusServiceUnavailable, response)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, response)
+}
+
+// HandleCluster reports every registered replica with its version, policy
+// snapshot and health, and whether the fleet is consistent
+// GET /v1/cluster
+func (h *Handler) HandleCluster(w http.ResponseWriter, r *http.Request) {
+	if h.cluster == nil {
+		respondError(w, http.StatusNotFound, "cluster registry is not enabled")
+		return
+	}
+	state, err := h.cluster.State(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error reading cluster registry", "error", err)
+		respondError(w, http.StatusServiceUnavailable, "Failed to read cluster registry")
+		return
+	}
+	respondJSON(w, http.StatusOK, state)
+}
+
+// HandleShadow reports how decisions of the shadow gateway compare to this one's
+// GET /v1/shadow
+func (h *Handler) HandleShadow(w http.ResponseWriter, r *http.Request) {
+	if h.shadow == nil {
+		respondError(w, http.StatusNotFound, "shadow traffic mirroring is not enabled")
+		return
+	}
+	respondJSON(w, http.StatusOK, h.shadow.Status())
+}
+
+// HandleListAllowlist lists the allowlisted prompt hashes
+// GET /v1/allowlist
+func (h *Handler) HandleListAllowlist(w http.ResponseWriter, r *http.Request) {
+	if h.allowlist == nil {
+		respondError(w, http.StatusNotFound, "prompt allowlist is not enabled")
+		return
+	}
+	hashes := h.allowlist.List()
+	respondJSON(w, http.StatusOK, models.AllowlistResponse{Hashes: hashes, Count: len(hashes)})
+}
+
+// HandleAddAllowlist allowlists prompts, given verbatim or as content hashes
+// POST /v1/allowlist
+func (h *Handler) HandleAddAllowlist(w http.ResponseWriter, r *http.Request) {
+	if h.allowlist == nil {
+		respondError(w, http.StatusNotFound, "prompt allowlist is not enabled")
+		return
+	}
+	var req models.AllowlistRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid JSON")
+		return
+	}
+	if len(req.Prompts) == 0 && len(req.Hashes) == 0 {
+		respondError(w, http.StatusBadRequest, "prompts or hashes is required")
+		return
+	}
+
+	hashes := make([]string, 0, len(req.Prompts)+len(req.Hashes))
+	for i, prompt := range req.Prompts {
+		if prompt == "" {
+			respondError(w, http.StatusBadRequest, "prompts["+strconv.Itoa(i)+"] is empty")
+			return
+		}
+		hashes = append(hashes, h.hasher.Hash(prompt))
+	}
+	for i, hash := range req.Hashes {
+		if !h.hasher.Valid(hash) {
+			respondError(w, http.StatusBadRequest, "hashes["+strconv.Itoa(i)+"] must be a lowercase hex "+h.hasher.Algorithm()+" digest")
+			return
+		}
+		hashes = append(hashes, hash)
+	}
+
+	added, err := h.allowlist.Add(r.Context(), hashes)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating prompt allowlist", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to update allowlist")
+		return
+	}
+	slog.InfoContext(r.Context(), "Prompt hashes allowlisted", "hashes", len(hashes), "new", added)
+	list := h.allowlist.List()
+	respondJSON(w, http.StatusOK, models.AllowlistResponse{Hashes: list, Count: len(list), Added: added})
+}
+
+// HandleDeleteAllowlist removes a hash from the allowlist
+// DELETE /v1/allowlist/{hash}
+func (h *Handler) HandleDeleteAllowlist(w http.ResponseWriter, r *http.Request) {
+	if h.allowlist == nil {
+		respondError(w, http.StatusNotFound, "prompt allowlist is not enabled")
+		return
+	}
+	removed, err := h.allowlist.Remove(r.Context(), r.PathValue("hash"))
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error updating prompt allowlist", "error", err)
+		respondError(w, http.StatusInternalServerError, "Failed to update allowlist")
+		return
+	}
+	if !removed {
+		respondError(w, http.StatusNotFound, "hash is not allowlisted")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// maxIngestEntries bounds one forwarded audit batch
+const maxIngestEntries = 5000
+
+// SetAuditIngest accepts audit batches forwarded by other regions, authenticated
+// with token, and queues them on logger's Redis for this region's sync worker
+func (h *Handler) SetAuditIngest(logger *audit.Logger, token string) {
+	h.auditIngest = logger
+	h.ingestToken = token
+}
+
+// HandleIngestAudit queues audit entries forwarded by a region that doesn't own
+// audit persistence
+// POST /v1/audit/ingest
+func (h *Handler) HandleIngestAudit(w http.ResponseWriter, r *http.Request) {
+	if h.auditIngest == nil {
+		respondError(w, http.StatusNotFound, "audit ingest is not enabled")
+		return
+	}
+	withAdminAuth(h.ingestAudit, h.ingestToken)(w, r)
+}
+
+// ingestAudit handles an authenticated ingest request
+func (h *Handler) ingestAudit(w http.ResponseWriter, r *http.Request) {
+	var req models.AuditIngestRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid JSON")
+		return
+	}
+	if len(req.Entries) == 0 {
+		respondError(w, http.StatusBadRequest, "entries is required")
+		return
+	}
+	if len(req.Entries) > maxIngestEntries {
+		respondError(w, http.StatusRequestEntityTooLarge, "at most "+strconv.Itoa(maxIngestEntries)+" entries per request")
+		return
+	}
+	for i, entry := range req.Entries {
+		if entry.RequestID == uuid.Nil || entry.ClientID == "" {
+			respondError(w, http.StatusBadRequest, "entries["+strconv.Itoa(i)+"] needs request_id and client_id")
+			return
+		}
+	}
+
+	// A failure makes the forwarding region keep the batch and retry it
+	if err := h.auditIngest.Ingest(r.Context(), req.Entries); err != nil {
+		slog.ErrorContext(r.Context(), "Error queueing forwarded audit logs", "error", err)
+		respondError(w, http.StatusServiceUnavailable, "Failed to queue audit logs")
+		return
+	}
+	respondJSON(w, http.StatusAccepted, models.AuditIngestResponse{Accepted: len(req.Entries)})
+}
+
+// HandleGetMaintenance reports maintenance mode and drain progress
+// GET /v1/maintenance
+func (h *Handler) HandleGetMaintenance(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.maintenance.Status())
+}
+
+// HandleSetMaintenance enters or leaves maintenance mode
+// PUT /v1/maintenance
+func (h *Handler) HandleSetMaintenance(w http.ResponseWriter, r *http.Request) {
+	var req models.SetMaintenanceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid JSON")
+		return
+	}
+	if req.RetryAfterSeconds < 0 {
+		respondError(w, http.StatusBadRequest, "retry_after_seconds must not be negative")
+		return
+	}
+
+	if req.Enabled {
+		retryAfter := req.RetryAfterSeconds
+		if retryAfter == 0 {
+			retryAfter = 60
+		}
+		h.maintenance.Enable(retryAfter)
+	} else {
+		h.maintenance.Disable()
+	}
+
+	respondJSON(w, http.StatusOK, h.maintenance.Status())
+}
+
+// Client debug scopes default to 15 minutes and are capped at a day so a
+// forgotten scope can't keep verbose logging on indefinitely
+const (
+	defaultDebugDuration = 900
+	maxDebugDuration     = 86400
+)
+
+// HandleGetLogLevel reports the log level and clients under scoped debug logging
+// GET /v1/loglevel
+func (h *Handler) HandleGetLogLevel(w http.ResponseWriter, r *http.Request) {
+	respondJSON(w, http.StatusOK, h.logLevel.Status())
+}
+
+// HandleSetLogLevel changes the log level and/or toggles debug logging for one client
+// PUT /v1/loglevel
+func (h *Handler) HandleSetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req models.SetLogLevelRequest
+	if err := decodeJSON(r, &req); err != nil {
+		respondDecodeError(w, err, "Invalid JSON")
+		return
+	}
+	if req.Level == "" && req.ClientID == "" {
+		respondError(w, http.StatusBadRequest, "level or client_id is required")
+		return
+	}
+	if req.DurationSeconds < 0 || req.DurationSeconds > maxDebugDuration {
+		respondError(w, http.StatusBadRequest, "duration_seconds must be between 0 and "+strconv.Itoa(maxDebugDuration))
+		return
+	}
+
+	if req.Level != "" {
+		level, err := logging.ParseLevel(req.Level)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		h.logLevel.SetLevel(level)
+		slog.InfoContext(r.Context(), "Log level set", "level", req.Level)
+	}
+
+	if req.ClientID != "" {
+		if req.Disable {
+			h.logLevel.DisableClient(req.ClientID)
+			slog.InfoContext(r.Context(), "Debug logging disabled for client", "client_id", req.ClientID)
+		} else {
+			duration := req.DurationSeconds
+			if duration == 0 {
+				duration = defaultDebugDuration
+			}
+			expiry := h.logLevel.EnableClient(req.ClientID, time.Duration(duration)*time.Second)
+			slog.InfoContext(r.Context(), "Debug logging enabled for client", "client_id", req.ClientID, "until", expiry.Format(time.RFC3339))
+		}
+	}
+
+	respondJSON(w, http.StatusOK, h.logLevel.Status())
+}
+
+// Helper functions
+
+// respondJSON sends a JSON response
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := encodeJSON(w, status, data); err != nil {
+		slog.Error("Error encoding JSON response", "error", err)
+	}
+}
+
+// respondMaintenance rejects work while the gateway is in maintenance mode
+func respondMaintenance(w http.ResponseWriter, retryAfter int) {
+	w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+	respondErrorCode(w, http.StatusServiceUnavailable, codeMaintenance, "Gateway is in maintenance mode")
 }
@@ -0,0 +1,366 @@
+package api
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/decisioncache"
+	"github.com/prompt-gateway/internal/logging"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// memPolicies is an in-memory policy.Store
+type memPolicies struct {
+	mu       sync.Mutex
+	policies []models.Policy
+}
+
+func (m *memPolicies) List(ctx context.Context) ([]models.Policy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	enabled := make([]models.Policy, 0, len(m.policies))
+	for _, p := range m.policies {
+		if p.Enabled {
+			enabled = append(enabled, p)
+		}
+	}
+	return enabled, nil
+}
+
+func (m *memPolicies) GetByID(ctx context.Context, id uuid.UUID) (*models.Policy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, p := range m.policies {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, policy.ErrNotFound
+}
+
+func (m *memPolicies) Create(ctx context.Context, req models.CreatePolicyRequest) (*models.Policy, error) {
+	if err := policy.ValidateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	p := models.Policy{
+		ID:           uuid.New(),
+		Name:         req.Name,
+		PatternType:  req.PatternType,
+		PatternValue: req.PatternValue,
+		Severity:     req.Severity,
+		Action:       req.Action,
+		Priority:     req.Priority,
+		ScanScope:    "all",
+		MatchMode:    analyzer.KeywordSubstring,
+		Enabled:      true,
+		Source:       policy.SourceManual,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+	}
+	m.mu.Lock()
+	m.policies = append(m.policies, p)
+	m.mu.Unlock()
+	return &p, nil
+}
+
+func (m *memPolicies) Update(ctx context.Context, id uuid.UUID, req models.CreatePolicyRequest) (*models.Policy, error) {
+	if err := policy.ValidateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	return m.change(id, func(p *models.Policy) {
+		p.Name, p.PatternValue, p.Action = req.Name, req.PatternValue, req.Action
+	})
+}
+
+func (m *memPolicies) SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) (*models.Policy, error) {
+	return m.change(id, func(p *models.Policy) { p.Enabled = enabled })
+}
+
+func (m *memPolicies) Delete(ctx context.Context, id uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i, p := range m.policies {
+		if p.ID == id {
+			m.policies = append(m.policies[:i], m.policies[i+1:]...)
+			return nil
+		}
+	}
+	return policy.ErrNotFound
+}
+
+func (m *memPolicies) BulkUpdate(ctx context.Context, filter policy.BulkFilter, update models.BulkPolicyUpdate) (*models.BulkPolicyUpdateResult, error) {
+	return &models.BulkPolicyUpdateResult{}, nil
+}
+
+func (m *memPolicies) change(id uuid.UUID, fn func(p *models.Policy)) (*models.Policy, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range m.policies {
+		if m.policies[i].ID == id {
+			fn(&m.policies[i])
+			p := m.policies[i]
+			return &p, nil
+		}
+	}
+	return nil, policy.ErrNotFound
+}
+
+// memAudit is an in-memory audit.Sink
+type memAudit struct {
+	mu      sync.Mutex
+	entries []models.AuditLog
+}
+
+func (s *memAudit) Log(entry models.AuditLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, entry)
+	return nil
+}
+
+func (s *memAudit) PurgePending(ctx context.Context, match func(models.AuditLog) bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	var n int64
+	for _, e := range s.entries {
+		if match(e) {
+			n++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.entries = kept
+	return n, nil
+}
+
+func (s *memAudit) Entries() []models.AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AuditLog(nil), s.entries...)
+}
+
+// newTestHandler returns a handler enforcing policies, backed by in-memory fakes
+func newTestHandler(t *testing.T, policies ...models.CreatePolicyRequest) (*Handler, *memAudit) {
+	t.Helper()
+	store := &memPolicies{}
+	for _, req := range policies {
+		if _, err := store.Create(context.Background(), req); err != nil {
+			t.Fatalf("Create(%q) error = %v", req.Name, err)
+		}
+	}
+	policyCache := cache.NewPolicyCache(store)
+	if err := policyCache.Invalidate(context.Background()); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	analyzerSvc := analyzer.NewAnalyzer(nil)
+	analyzerSvc.SetPatternSource(policyCache)
+	sink := &memAudit{}
+	return NewHandler(store, policyCache, analyzerSvc, sink, nil, nil, clients.NewRegistry(nil, time.Minute), nil), sink
+}
+
+// stubDB is a database/sql connector that records statements instead of running
+// them: every exec affects `affected` rows and every query returns no rows
+type stubDB struct {
+	mu       sync.Mutex
+	affected int64
+	execs    []stubExec
+}
+
+// stubExec is a statement run against a stubDB
+type stubExec struct {
+	query string
+	args  []driver.NamedValue
+}
+
+// openStubDB returns a *sql.DB backed by a stubDB
+func openStubDB(t *testing.T, affected int64) (*sql.DB, *stubDB) {
+	t.Helper()
+	stub := &stubDB{affected: affected}
+	db := sql.OpenDB(stub)
+	t.Cleanup(func() { db.Close() })
+	return db, stub
+}
+
+// Execs returns the statements run so far
+func (s *stubDB) Execs() []stubExec {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]stubExec(nil), s.execs...)
+}
+
+func (s *stubDB) Connect(ctx context.Context) (driver.Conn, error) { return stubConn{s}, nil }
+func (s *stubDB) Driver() driver.Driver                            { return nil }
+
+type stubConn struct{ db *stubDB }
+
+func (c stubConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("stubdb: prepared statements are not supported")
+}
+func (c stubConn) Close() error { return nil }
+func (c stubConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("stubdb: transactions are not supported")
+}
+
+func (c stubConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.mu.Lock()
+	defer c.db.mu.Unlock()
+	c.db.execs = append(c.db.execs, stubExec{query: query, args: args})
+	return driver.RowsAffected(c.db.affected), nil
+}
+
+func (c stubConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return stubRows{}, nil
+}
+
+type stubRows struct{}
+
+func (stubRows) Columns() []string              { return nil }
+func (stubRows) Close() error                   { return nil }
+func (stubRows) Next(dest []driver.Value) error { return io.EOF }
+
+// serve sends a request through the gateway's routes
+func serve(h *Handler, adminKey, method, target, body string, header http.Header) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	rec := httptest.NewRecorder()
+	SetupRoutes(h, time.Second, adminKey).ServeHTTP(rec, req)
+	return rec
+}
+
+func TestHandleEraseAudit_PurgesDecisionCache(t *testing.T) {
+	h, sink := newTestHandler(t, models.CreatePolicyRequest{
+		Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block",
+	})
+	db, stub := openStubDB(t, 2)
+	h.auditRepo = audit.NewRepository(db)
+	decisions := decisioncache.New(time.Minute, 10)
+	h.SetDecisionCache(decisions)
+
+	for _, req := range []models.AnalyzeRequest{
+		{ClientID: "a", Prompt: "ssn 123-45-6789"},
+		{ClientID: "a", Prompt: "hello"},
+		{ClientID: "b", Prompt: "hello"},
+	} {
+		if _, err := h.Evaluate(context.Background(), req); err != nil {
+			t.Fatalf("Evaluate() error = %v", err)
+		}
+	}
+
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+	if rec := serve(h, "secret", http.MethodDelete, "/v1/audit?client_id=a", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Fatalf("DELETE without key = %d, want 401", rec.Code)
+	}
+	rec := serve(h, "secret", http.MethodDelete, "/v1/audit?client_id=a", "", auth)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("DELETE /v1/audit = %d: %s", rec.Code, rec.Body)
+	}
+	var report models.ErasureReport
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode report: %v", err)
+	}
+	if report.AuditLogsDeleted != 2 || report.PendingLogsDeleted != 2 || report.CachedDecisionsDeleted != 2 {
+		t.Errorf("report = %+v, want 2 persisted, 2 pending and 2 cached deleted", report)
+	}
+	if decisions.Len() != 1 {
+		t.Errorf("cache Len() = %d, want client b's decision only", decisions.Len())
+	}
+	if entries := sink.Entries(); len(entries) != 1 || entries[0].ClientID != "b" {
+		t.Errorf("pending entries = %+v, want client b's only", entries)
+	}
+	if execs := stub.Execs(); len(execs) != 1 || !strings.Contains(execs[0].query, "DELETE FROM audit_logs") {
+		t.Errorf("statements = %+v, want one audit delete", execs)
+	}
+}
+
+func TestHandleSetLogLevel(t *testing.T) {
+	h, _ := newTestHandler(t)
+	controller := logging.NewController(slog.LevelInfo, logging.FormatText)
+	h.SetLogLevel(controller)
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"empty", `{}`, http.StatusBadRequest},
+		{"bad level", `{"level":"verbose"}`, http.StatusBadRequest},
+		{"duration too long", `{"client_id":"acme","duration_seconds":86401}`, http.StatusBadRequest},
+		{"level", `{"level":"warn"}`, http.StatusOK},
+		{"client scope", `{"client_id":"acme","duration_seconds":60}`, http.StatusOK},
+	}
+	for _, tt := range tests {
+		if rec := serve(h, "secret", http.MethodPut, "/v1/loglevel", tt.body, auth); rec.Code != tt.want {
+			t.Errorf("%s: PUT /v1/loglevel = %d, want %d: %s", tt.name, rec.Code, tt.want, rec.Body)
+		}
+	}
+	if controller.Level() != slog.LevelWarn || !controller.ClientDebug("acme") {
+		t.Errorf("level = %v, acme debug = %v, want warn with acme scoped", controller.Level(), controller.ClientDebug("acme"))
+	}
+
+	rec := serve(h, "secret", http.MethodPut, "/v1/loglevel", `{"client_id":"acme","disable":true}`, auth)
+	var status models.LogLevelStatus
+	if err := json.Unmarshal(rec.Body.Bytes(), &status); err != nil {
+		t.Fatalf("decode status: %v", err)
+	}
+	if status.Level != "warn" || len(status.DebugClients) != 0 {
+		t.Errorf("status = %+v, want warn with no debug clients", status)
+	}
+	if rec := serve(h, "secret", http.MethodGet, "/v1/loglevel", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /v1/loglevel without key = %d, want 401", rec.Code)
+	}
+}
+
+func TestHandleListAudit(t *testing.T) {
+	h, _ := newTestHandler(t)
+	db, _ := openStubDB(t, 0)
+	h.auditRepo = audit.NewRepository(db)
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+
+	tests := []struct {
+		query string
+		want  int
+	}{
+		{"?region=eu-west-1&instance_id=gw-1&version=1.4.0", http.StatusOK},
+		{"?since=2024-01-01T00:00:00Z&until=2024-02-01T00:00:00Z", http.StatusOK},
+		{"?since=yesterday", http.StatusBadRequest},
+		{"?limit=0", http.StatusBadRequest},
+		{"?limit=1001", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		rec := serve(h, "secret", http.MethodGet, "/v1/audit"+tt.query, "", auth)
+		if rec.Code != tt.want {
+			t.Errorf("GET /v1/audit%s = %d, want %d: %s", tt.query, rec.Code, tt.want, rec.Body)
+			continue
+		}
+		if tt.want == http.StatusOK && strings.TrimSpace(rec.Body.String()) != "[]" {
+			t.Errorf("GET /v1/audit%s body = %s, want []", tt.query, rec.Body)
+		}
+	}
+	if rec := serve(h, "secret", http.MethodGet, "/v1/audit", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("GET /v1/audit without key = %d, want 401", rec.Code)
+	}
+}
@@ -0,0 +1,179 @@
+package api
+
+import (
+	"context"
+	"slices"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// redactionBudgetKey holds the *redactionBudget of an analyze request
+const redactionBudgetKey ctxKey = "redaction_budget"
+
+// SetRequestLimits caps the shape of every analyze request so pathological inputs
+// can't produce megabyte responses (0 = unlimited): at most maxPolicies policies
+// are evaluated, the most severe first; every triggered_policies list returns at
+// most maxMatches matches, each with at most maxMatches occurrences; and at most
+// maxRedactions redaction passes (one per redacting policy and text) are applied
+func (h *Handler) SetRequestLimits(maxPolicies, maxMatches, maxRedactions int) {
+	h.policyLimit = maxPolicies
+	h.matchLimit = maxMatches
+	h.redactLimit = maxRedactions
+}
+
+// limitPolicies keeps the max most severe policies (all when max is 0), in their
+// original order, and returns the rest
+func limitPolicies(policies []models.Policy, max int) ([]models.Policy, []models.Policy) {
+	if max <= 0 || len(policies) <= max {
+		return policies, nil
+	}
+	keep := mostSevere(len(policies), max, func(i int) string { return policies[i].Severity })
+	kept := make([]models.Policy, 0, max)
+	skipped := make([]models.Policy, 0, len(policies)-max)
+	for i, p := range policies {
+		if keep[i] {
+			kept = append(kept, p)
+		} else {
+			skipped = append(skipped, p)
+		}
+	}
+	return kept, skipped
+}
+
+// mostSevere marks the max most severe of n items; among equally severe ones the
+// earlier win
+func mostSevere(n, max int, severity func(int) string) []bool {
+	ranked := make([]int, n)
+	for i := range ranked {
+		ranked[i] = i
+	}
+	slices.SortStableFunc(ranked, func(a, b int) int {
+		return severityWeight(severity(b)) - severityWeight(severity(a))
+	})
+	keep := make([]bool, n)
+	for _, i := range ranked[:max] {
+		keep[i] = true
+	}
+	return keep
+}
+
+// redactionBudget counts the redaction passes left to an analyze request
+// Requests evaluate their texts sequentially, so it needs no locking
+type redactionBudget struct {
+	remaining int
+	skipped   int
+}
+
+// withRedactionBudget limits the redactions of the request to max (0 = unlimited)
+func withRedactionBudget(ctx context.Context, max int) context.Context {
+	if max <= 0 {
+		return ctx
+	}
+	return context.WithValue(ctx, redactionBudgetKey, &redactionBudget{remaining: max})
+}
+
+// redactionsSkipped returns the redaction passes the request's budget refused
+func redactionsSkipped(ctx context.Context) int {
+	if budget, ok := ctx.Value(redactionBudgetKey).(*redactionBudget); ok {
+		return budget.skipped
+	}
+	return 0
+}
+
+// redact rewrites content for the redacting matches the request's budget allows
+func (h *Handler) redact(ctx context.Context, content string, matches []models.PolicyMatch, policies []models.Policy) string {
+	budget, ok := ctx.Value(redactionBudgetKey).(*redactionBudget)
+	if !ok {
+		return h.analyzer.RedactContent(content, matches, policies)
+	}
+	actions := make(map[string]string, len(policies))
+	for _, p := range policies {
+		actions[p.ID.String()] = p.Action
+	}
+	allowed := make([]models.PolicyMatch, 0, len(matches))
+	for _, m := range matches {
+		action, exists := actions[m.PolicyID.String()]
+		if exists && analyzer.EffectiveAction(action, m) == "redact" {
+			if budget.remaining == 0 {
+				budget.skipped++
+				continue
+			}
+			budget.remaining--
+		}
+		allowed = append(allowed, m)
+	}
+	return h.analyzer.RedactContent(content, allowed, policies)
+}
+
+// limitMatches returns response with every triggered_policies list cut to the
+// most severe max matches and every match to max occurrences, counting what was
+// left out in its overflow. The response is copied rather than modified: the
+// recorded decision and its observers keep every match
+func limitMatches(response *models.AnalyzeResponse, max int) *models.AnalyzeResponse {
+	if max <= 0 {
+		return response
+	}
+	overflow := models.LimitOverflow{}
+	if response.Overflow != nil {
+		overflow = *response.Overflow
+	}
+	before := overflow
+	limited := *response
+	limited.TriggeredPolicies = limitMatchList(response.TriggeredPolicies, max, &overflow)
+	if len(response.MessageResults) > 0 {
+		limited.MessageResults = slices.Clone(response.MessageResults)
+		for i := range limited.MessageResults {
+			limited.MessageResults[i].TriggeredPolicies = limitMatchList(limited.MessageResults[i].TriggeredPolicies, max, &overflow)
+		}
+	}
+	if len(response.AttachmentResults) > 0 {
+		limited.AttachmentResults = slices.Clone(response.AttachmentResults)
+		for i := range limited.AttachmentResults {
+			limited.AttachmentResults[i].TriggeredPolicies = limitMatchList(limited.AttachmentResults[i].TriggeredPolicies, max, &overflow)
+		}
+	}
+	if overflow == before {
+		return response
+	}
+	if overflow.MatchesOmitted > before.MatchesOmitted {
+		metrics.RequestLimitsTotal.WithLabelValues("matches").Inc()
+	}
+	if overflow.OccurrencesOmitted > before.OccurrencesOmitted {
+		metrics.RequestLimitsTotal.WithLabelValues("occurrences").Inc()
+	}
+	limited.Overflow = &overflow
+	return &limited
+}
+
+// limitMatchList cuts matches to the max most severe, in their original order, and
+// each match to its first max occurrences
+func limitMatchList(matches []models.PolicyMatch, max int, overflow *models.LimitOverflow) []models.PolicyMatch {
+	if len(matches) > max {
+		keep := mostSevere(len(matches), max, func(i int) string { return matches[i].Severity })
+		kept := make([]models.PolicyMatch, 0, max)
+		for i, m := range matches {
+			if keep[i] {
+				kept = append(kept, m)
+			}
+		}
+		overflow.MatchesOmitted += len(matches) - max
+		matches = kept
+	}
+	var limited []models.PolicyMatch
+	for i, m := range matches {
+		if len(m.Occurrences) <= max {
+			continue
+		}
+		if limited == nil {
+			limited = slices.Clone(matches)
+		}
+		overflow.OccurrencesOmitted += len(m.Occurrences) - max
+		limited[i].Occurrences = m.Occurrences[:max:max]
+	}
+	if limited != nil {
+		return limited
+	}
+	return matches
+}
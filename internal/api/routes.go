@@ -2,14 +2,22 @@ package api
 
 import (
 	"context"
-	"log"
+	"crypto/subtle"
+	"log/slog"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prompt-gateway/internal/logging"
 	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/internal/tracing"
+	"github.com/prompt-gateway/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 )
 
 // ctxKey is a custom type for context keys to avoid collisions
@@ -18,6 +26,7 @@ type ctxKey string
 // Context keys
 const (
 	requestIDKey ctxKey = "request_id"
+	debugKey     ctxKey = "debug"
 )
 
 // statusWriter wraps http.ResponseWriter to record the final status code.
@@ -40,33 +49,294 @@ func (w *statusWriter) Write(b []byte) (int, error) {
 
 // SetupRoutes configures all HTTP routes
 // In Go: We manually register routes with a ServeMux (router)
-func SetupRoutes(handler *Handler, requestTimeout time.Duration) *http.ServeMux {
+// Privileged routes are additionally guarded by adminAPIKey
+func SetupRoutes(handler *Handler, requestTimeout time.Duration, adminAPIKey string) *http.ServeMux {
 	mux := http.NewServeMux()
 
 	// Register routes with timeout middleware
-	mux.HandleFunc("/v1/analyze", withMiddleware(handler.HandleAnalyze, requestTimeout, "POST"))
-	mux.HandleFunc("/v1/policies", withMiddleware(policiesHandler(handler), requestTimeout, "GET", "POST"))
+	mux.HandleFunc("/v1/analyze", withMiddleware(handler.withSLO(withDebugTrace(handler.HandleAnalyze, adminAPIKey)), requestTimeout, "POST"))
+	mux.HandleFunc("/v1/analyze/batch", withMiddleware(handler.HandleAnalyzeBatch, requestTimeout, "POST"))
+	mux.HandleFunc("/v1/analyze/explain", withMiddleware(handler.HandleExplain, requestTimeout, "POST"))
+	mux.HandleFunc("/v1/policies", withMiddleware(handler.withDBPool(policiesHandler(handler, adminAPIKey)), requestTimeout, "GET", "POST", "PATCH"))
+	mux.HandleFunc("/v1/policies/{id}", withMiddleware(handler.withDBPool(policyHandler(handler, adminAPIKey)), requestTimeout, "GET", "PUT", "PATCH", "DELETE"))
+	mux.HandleFunc("/v1/policies/{id}/enable", withMiddleware(withAdminAuth(handler.withDBPool(handler.HandleEnablePolicy), adminAPIKey), requestTimeout, "POST"))
+	mux.HandleFunc("/v1/policies/{id}/disable", withMiddleware(withAdminAuth(handler.withDBPool(handler.HandleDisablePolicy), adminAPIKey), requestTimeout, "POST"))
+	mux.HandleFunc("/v1/policies/lint", withMiddleware(handler.HandleLintPolicy, requestTimeout, "POST"))
+	mux.HandleFunc("/v1/policies/test", withMiddleware(handler.HandleTestPolicy, requestTimeout, "POST"))
+	mux.HandleFunc("/v1/corpus/benign", withMiddleware(withAdminAuth(handler.withDBPool(benignCorpusHandler(handler)), adminAPIKey), requestTimeout, "GET", "PUT", "DELETE"))
+	mux.HandleFunc("/v1/policies/slow", withMiddleware(withAdminAuth(handler.HandleSlowPolicies, adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/policies/diff", withMiddleware(withAdminAuth(handler.HandleDiffPolicies, adminAPIKey), requestTimeout, "POST"))
+	mux.HandleFunc("/v1/sessions/{session_id}", withMiddleware(withAdminAuth(handler.withDBPool(handler.HandleSessionTimeline), adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/sessions/{session_id}/override", withMiddleware(withAdminAuth(sessionOverrideHandler(handler), adminAPIKey), requestTimeout, "GET", "PUT", "DELETE"))
+	mux.HandleFunc("/v1/audit", withMiddleware(withAdminAuth(handler.withDBPool(auditHandler(handler)), adminAPIKey), requestTimeout, "GET", "DELETE"))
+	mux.HandleFunc("/v1/audit/ingest", withMiddleware(handler.HandleIngestAudit, requestTimeout, "POST"))
+	mux.HandleFunc("/v1/incidents", withMiddleware(withAdminAuth(handler.withDBPool(incidentsHandler(handler)), adminAPIKey), requestTimeout, "GET", "POST"))
+	mux.HandleFunc("/v1/incidents/{id}", withMiddleware(withAdminAuth(handler.withDBPool(incidentHandler(handler)), adminAPIKey), requestTimeout, "GET", "PATCH", "DELETE"))
+	mux.HandleFunc("/v1/stats", withMiddleware(withAdminAuth(handler.withDBPool(handler.HandleStats), adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/stats/policies", withMiddleware(withAdminAuth(handler.withDBPool(handler.HandlePolicyStats), adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/analytics/blocked-prompts", withMiddleware(withAdminAuth(handler.withDBPool(handler.HandleBlockedPrompts), adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/clients", withMiddleware(withAdminAuth(handler.HandleListClients, adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/clients/{id}", withMiddleware(withAdminAuth(handler.withDBPool(clientHandler(handler)), adminAPIKey), requestTimeout, "GET", "PUT"))
+	mux.HandleFunc("/v1/wordlists", withMiddleware(withAdminAuth(handler.HandleListWordlists, adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/wordlists/{name}", withMiddleware(withAdminAuth(handler.withDBPool(wordlistHandler(handler)), adminAPIKey), requestTimeout, "GET", "PUT", "DELETE"))
+	mux.HandleFunc("/v1/allowlist", withMiddleware(withAdminAuth(allowlistHandler(handler), adminAPIKey), requestTimeout, "GET", "POST"))
+	mux.HandleFunc("/v1/allowlist/{hash}", withMiddleware(withAdminAuth(handler.HandleDeleteAllowlist, adminAPIKey), requestTimeout, "DELETE"))
+	mux.HandleFunc("/v1/verify", withMiddleware(handler.HandleVerifyToken, requestTimeout, "POST"))
+	mux.HandleFunc("/v1/keys", withMiddleware(handler.HandlePublicKeys, requestTimeout, "GET"))
+	mux.HandleFunc("/v1/cluster", withMiddleware(withAdminAuth(handler.HandleCluster, adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/shadow", withMiddleware(withAdminAuth(handler.HandleShadow, adminAPIKey), requestTimeout, "GET"))
+	mux.HandleFunc("/v1/maintenance", withMiddleware(withAdminAuth(maintenanceHandler(handler), adminAPIKey), requestTimeout, "GET", "PUT"))
+	mux.HandleFunc("/v1/loglevel", withMiddleware(withAdminAuth(logLevelHandler(handler), adminAPIKey), requestTimeout, "GET", "PUT"))
+	mux.HandleFunc("/v1/version", withMiddleware(handler.HandleVersion, requestTimeout, "GET"))
 	mux.HandleFunc("/v1/health", withMiddleware(handler.HandleHealth, requestTimeout, "GET"))
-	mux.Handle("/metrics", promhttp.Handler())
+	// OpenMetrics is negotiated so scrapers can collect latency exemplars
+	mux.Handle("/metrics", promhttp.InstrumentMetricHandler(prometheus.DefaultRegisterer,
+		promhttp.HandlerFor(prometheus.DefaultGatherer, promhttp.HandlerOpts{EnableOpenMetrics: true})))
 
 	return mux
 }
 
-// policiesHandler routes GET/POST to appropriate handlers
+// withSLO counts each request against the latency SLO targets
+func (h *Handler) withSLO(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		handler(sw, r)
+		h.slo.Observe(time.Since(start), sw.status)
+	}
+}
+
+// policiesHandler routes GET/POST/PATCH to appropriate handlers
 // Go's http.ServeMux doesn't support method-based routing natively
-func policiesHandler(h *Handler) http.HandlerFunc {
+// Creates and bulk updates are privileged, like single-policy writes: only listing stays open
+func policiesHandler(h *Handler, adminAPIKey string) http.HandlerFunc {
+	create := withAdminAuth(h.HandleCreatePolicy, adminAPIKey)
+	bulkUpdate := withAdminAuth(h.HandleBulkUpdatePolicies, adminAPIKey)
 	return func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			h.HandleListPolicies(w, r)
 		case http.MethodPost:
-			h.HandleCreatePolicy(w, r)
+			create(w, r)
+		case http.MethodPatch:
+			bulkUpdate(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// policyHandler routes single-policy requests
+// Updates and deletes are privileged, like bulk updates: reads stay open
+func policyHandler(h *Handler, adminAPIKey string) http.HandlerFunc {
+	update := withAdminAuth(h.HandleUpdatePolicy, adminAPIKey)
+	remove := withAdminAuth(h.HandleDeletePolicy, adminAPIKey)
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetPolicy(w, r)
+		case http.MethodPut, http.MethodPatch:
+			update(w, r)
+		case http.MethodDelete:
+			remove(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// sessionOverrideHandler routes pinned session decision requests
+func sessionOverrideHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetSessionOverride(w, r)
+		case http.MethodPut:
+			h.HandleSetSessionOverride(w, r)
+		case http.MethodDelete:
+			h.HandleDeleteSessionOverride(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// auditHandler routes audit query and erasure requests
+func auditHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleListAudit(w, r)
+		case http.MethodDelete:
+			h.HandleEraseAudit(w, r)
 		default:
 			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
 		}
 	}
 }
 
+// incidentsHandler routes collection-level incident requests
+func incidentsHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleListIncidents(w, r)
+		case http.MethodPost:
+			h.HandleCreateIncident(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// incidentHandler routes single-incident requests
+func incidentHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetIncident(w, r)
+		case http.MethodPatch:
+			h.HandleUpdateIncident(w, r)
+		case http.MethodDelete:
+			h.HandleDeleteIncident(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// clientHandler routes single-client requests
+func clientHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetClient(w, r)
+		case http.MethodPut:
+			h.HandleUpsertClient(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// benignCorpusHandler routes benign corpus requests
+func benignCorpusHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetBenignCorpus(w, r)
+		case http.MethodPut:
+			h.HandleUploadBenignCorpus(w, r)
+		case http.MethodDelete:
+			h.HandleDeleteBenignCorpus(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// wordlistHandler routes single-wordlist requests
+func wordlistHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetWordlist(w, r)
+		case http.MethodPut:
+			h.HandleUpsertWordlist(w, r)
+		case http.MethodDelete:
+			h.HandleDeleteWordlist(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// allowlistHandler routes prompt allowlist requests
+func allowlistHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleListAllowlist(w, r)
+		case http.MethodPost:
+			h.HandleAddAllowlist(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// maintenanceHandler routes maintenance mode requests
+func maintenanceHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetMaintenance(w, r)
+		case http.MethodPut:
+			h.HandleSetMaintenance(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// logLevelHandler routes runtime log level requests
+func logLevelHandler(h *Handler) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			h.HandleGetLogLevel(w, r)
+		case http.MethodPut:
+			h.HandleSetLogLevel(w, r)
+		default:
+			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+		}
+	}
+}
+
+// withDBPool bounds the request's wait for a Postgres connection when the pool is
+// saturated, so it fails with 503 storage busy instead of hanging until the timeout
+func (h *Handler) withDBPool(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := h.dbPool.Bound(r.Context())
+		defer cancel()
+		handler(w, r.WithContext(ctx))
+	}
+}
+
+// withAdminAuth rejects requests that don't present the admin API key
+// Privileged endpoints are disabled entirely when no key is configured
+func withAdminAuth(handler http.HandlerFunc, adminAPIKey string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if adminAPIKey == "" {
+			respondError(w, http.StatusForbidden, "Admin endpoints are disabled")
+			return
+		}
+
+		provided := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(adminAPIKey)) != 1 {
+			respondError(w, http.StatusUnauthorized, "Unauthorized")
+			return
+		}
+
+		handler(w, r)
+	}
+}
+
+// withDebugTrace lets ?debug=true requests through with a per-policy evaluation
+// trace; the trace exposes policy internals, so it needs the admin API key
+func withDebugTrace(handler http.HandlerFunc, adminAPIKey string) http.HandlerFunc {
+	traced := withAdminAuth(func(w http.ResponseWriter, r *http.Request) {
+		handler(w, r.WithContext(context.WithValue(r.Context(), debugKey, true)))
+	}, adminAPIKey)
+	return func(w http.ResponseWriter, r *http.Request) {
+		if debug, _ := strconv.ParseBool(r.URL.Query().Get("debug")); debug {
+			traced(w, r)
+			return
+		}
+		handler(w, r)
+	}
+}
+
 // withMiddleware wraps a handler with timeout, logging and request validation
 func withMiddleware(handler http.HandlerFunc, timeout time.Duration, allowedMethods ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
@@ -78,13 +348,23 @@ func withMiddleware(handler http.HandlerFunc, timeout time.Duration, allowedMeth
 		ctx, cancel := context.WithTimeout(r.Context(), timeout)
 		defer cancel() // Ensure context is cancelled to free resources
 
-		// Store request ID in context so handlers can access it
+		// Store request ID in context so handlers and their log lines can access it
 		ctx = context.WithValue(ctx, requestIDKey, requestID)
+		ctx = logging.With(ctx, "request_id", requestID)
+		// Label by route pattern so path parameters don't explode metric cardinality
+		route := r.URL.Path
+		if r.Pattern != "" {
+			route = r.Pattern
+		}
+		// Join the caller's trace so spans, logs and latency exemplars link to it
+		ctx, span := tracing.StartRequest(r.WithContext(ctx), r.Method+" "+route)
+		defer span.End()
+		spanContext, traced := tracing.FromContext(ctx)
 		r = r.WithContext(ctx)
 		// Add CORS headers (for browser-based clients)
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		// Handle preflight requests
 		if r.Method == http.MethodOptions {
@@ -102,13 +382,15 @@ func withMiddleware(handler http.HandlerFunc, timeout time.Duration, allowedMeth
 		}
 
 		if !methodAllowed {
-			respondError(w, http.StatusMethodNotAllowed, "Method not allowed")
+			w.Header().Set("Allow", strings.Join(allowedMethods, ", "))
+			respondErrorCode(w, http.StatusMethodNotAllowed, codeMethodNotAllowed, "Method not allowed",
+				models.ErrorDetail{Field: "method", Reason: "allowed: " + strings.Join(allowedMethods, ", ")})
 			return
 		}
 
 		// Log request
 		start := time.Now()
-		log.Printf("[%s] %s %s - Started (timeout: %v)", requestID, r.Method, r.URL.Path, timeout)
+		slog.InfoContext(ctx, "Request started", "method", r.Method, "path", r.URL.Path, "timeout", timeout)
 
 		sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
 
@@ -118,14 +400,24 @@ func withMiddleware(handler http.HandlerFunc, timeout time.Duration, allowedMeth
 
 		statusCode := sw.status
 		elapsed := time.Since(start)
-		metrics.HTTPRequestsTotal.WithLabelValues(r.Method, r.URL.Path, strconv.Itoa(statusCode)).Inc()
-		metrics.HTTPRequestDuration.WithLabelValues(r.Method, r.URL.Path).Observe(elapsed.Seconds())
+		span.SetAttributes(attribute.String("http.route", route), attribute.Int("http.response.status_code", statusCode))
+		if statusCode >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(statusCode))
+		}
+		metrics.HTTPRequestsTotal.WithLabelValues(r.Method, route, strconv.Itoa(statusCode)).Inc()
+		// Sampled traces are attached as exemplars, so a slow bucket links to one
+		duration := metrics.HTTPRequestDuration.WithLabelValues(r.Method, route)
+		if traced && spanContext.Sampled {
+			duration.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed.Seconds(), prometheus.Labels{"trace_id": spanContext.TraceID})
+		} else {
+			duration.Observe(elapsed.Seconds())
+		}
 
 		// Check if context timed out after handler completes
 		if ctx.Err() == context.DeadlineExceeded {
-			log.Printf("[%s] %s %s - Timeout after %v", requestID, r.Method, r.URL.Path, elapsed)
+			slog.WarnContext(ctx, "Request timed out", "method", r.Method, "path", r.URL.Path, "duration", elapsed)
 		} else {
-			log.Printf("[%s] %s %s - Completed in %v (status=%d)", requestID, r.Method, r.URL.Path, elapsed, statusCode)
+			slog.InfoContext(ctx, "Request completed", "method", r.Method, "path", r.URL.Path, "status", statusCode, "duration", elapsed)
 		}
 	}
 }
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestPolicyRoutes_AdminAuth(t *testing.T) {
+	h, _ := newTestHandler(t, models.CreatePolicyRequest{
+		Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block",
+	})
+	policies, _ := h.policyRepo.List(context.Background())
+	path := "/v1/policies/" + policies[0].ID.String()
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+
+	writes := []struct {
+		method, target, body string
+	}{
+		{http.MethodPost, "/v1/policies", `{"name":"email","pattern_type":"regex","pattern_value":"\\w+@\\w+\\.com","severity":"medium","action":"redact"}`},
+		{http.MethodPut, path, `{"name":"ssn","pattern_type":"regex","pattern_value":"\\d{9}","severity":"high","action":"block"}`},
+		{http.MethodPatch, path, `{"action":"log"}`},
+		{http.MethodPost, path + "/disable", `{}`},
+		{http.MethodPost, path + "/enable", `{}`},
+		{http.MethodDelete, path, ""},
+	}
+	for _, w := range writes {
+		if rec := serve(h, "", w.method, w.target, w.body, auth); rec.Code != http.StatusForbidden {
+			t.Errorf("%s %s with admin disabled = %d, want 403", w.method, w.target, rec.Code)
+		}
+		if rec := serve(h, "secret", w.method, w.target, w.body, nil); rec.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s without key = %d, want 401", w.method, w.target, rec.Code)
+		}
+	}
+
+	if rec := serve(h, "secret", http.MethodGet, path, "", nil); rec.Code != http.StatusOK {
+		t.Errorf("GET %s without key = %d, want 200", path, rec.Code)
+	}
+	for _, w := range writes {
+		rec := serve(h, "secret", w.method, w.target, w.body, auth)
+		if rec.Code >= 300 {
+			t.Errorf("%s %s with key = %d: %s", w.method, w.target, rec.Code, rec.Body)
+		}
+	}
+}
+
+func TestSessionTimeline_AdminAuth(t *testing.T) {
+	h, _ := newTestHandler(t)
+	db, _ := openStubDB(t, 0)
+	h.auditRepo = audit.NewRepository(db)
+
+	if rec := serve(h, "", http.MethodGet, "/v1/sessions/s1", "", nil); rec.Code != http.StatusForbidden {
+		t.Errorf("timeline with admin disabled = %d, want 403", rec.Code)
+	}
+	if rec := serve(h, "secret", http.MethodGet, "/v1/sessions/s1", "", nil); rec.Code != http.StatusUnauthorized {
+		t.Errorf("timeline without key = %d, want 401", rec.Code)
+	}
+	// The stub database has no decisions for the session
+	rec := serve(h, "secret", http.MethodGet, "/v1/sessions/s1", "", http.Header{"Authorization": {"Bearer secret"}})
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("timeline with key = %d: %s, want 404", rec.Code, rec.Body)
+	}
+}
+
+func TestPolicyRoutes_Errors(t *testing.T) {
+	h, _ := newTestHandler(t)
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+
+	rec := serve(h, "secret", http.MethodPost, "/v1/policies", `{"pattern_type":"keyword","pattern_value":"x","severity":"high","action":"block"}`, auth)
+	if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), "name is required") {
+		t.Errorf("create without a name = %d: %s, want 400 naming the problem", rec.Code, rec.Body)
+	}
+
+	h.policyRepo = failingPolicies{&memPolicies{}}
+	rec = serve(h, "secret", http.MethodDelete, "/v1/policies/"+uuid.NewString(), "", auth)
+	if rec.Code != http.StatusInternalServerError || strings.Contains(rec.Body.String(), "connection refused") {
+		t.Errorf("delete with storage down = %d: %s, want 500 without the storage error", rec.Code, rec.Body)
+	}
+}
+
+// failingPolicies fails every delete as an unreachable database would
+type failingPolicies struct {
+	*memPolicies
+}
+
+func (failingPolicies) Delete(ctx context.Context, id uuid.UUID) error {
+	return errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")
+}
+
+func TestBenignCorpusRoutes_UnknownTenant(t *testing.T) {
+	h, _ := newTestHandler(t)
+	auth := http.Header{"Authorization": {"Bearer secret"}}
+	for _, method := range []string{http.MethodGet, http.MethodPut, http.MethodDelete} {
+		rec := serve(h, "secret", method, "/v1/corpus/benign?tenant=initech", `{"prompts":["hello"]}`, auth)
+		if rec.Code != http.StatusNotFound || !strings.Contains(rec.Body.String(), "unknown tenant") {
+			t.Errorf("%s corpus of an unknown tenant = %d: %s, want 404", method, rec.Code, rec.Body)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Default stats ranges when since is not given
+const (
+	defaultHourlyStatsRange = 24 * time.Hour
+	defaultDailyStatsRange  = 30 * 24 * time.Hour
+)
+
+// SetRollups enables the /v1/stats endpoints, served from the audit roll-ups
+func (h *Handler) SetRollups(rollups *audit.Rollups) {
+	h.rollups = rollups
+}
+
+// HandleStats returns decision counts per client and action by hour or day
+// GET /v1/stats?granularity=hour|day&since=&until=&client_id=&action=&limit=
+func (h *Handler) HandleStats(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.statsFilter(w, r)
+	if !ok {
+		return
+	}
+	buckets, err := h.rollups.Stats(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error querying audit roll-ups", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to query stats")
+		return
+	}
+	refreshed, err := h.rollups.RefreshedAt(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error querying audit roll-up state", "error", err)
+	}
+	respondJSON(w, http.StatusOK, models.StatsResponse{Granularity: filter.Granularity, RefreshedAt: refreshed, Buckets: buckets})
+}
+
+// HandlePolicyStats returns how often each policy triggered by hour or day
+// GET /v1/stats/policies?granularity=hour|day&since=&until=&client_id=&action=&policy_id=&limit=
+func (h *Handler) HandlePolicyStats(w http.ResponseWriter, r *http.Request) {
+	filter, ok := h.statsFilter(w, r)
+	if !ok {
+		return
+	}
+	if v := r.URL.Query().Get("policy_id"); v != "" {
+		id, err := uuid.Parse(v)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, "policy_id must be a UUID")
+			return
+		}
+		filter.PolicyID = id
+	}
+	buckets, err := h.rollups.PolicyStats(r.Context(), filter)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error querying policy roll-ups", "error", err)
+		respondStorageError(w, r, http.StatusInternalServerError, "Failed to query policy stats")
+		return
+	}
+	refreshed, err := h.rollups.RefreshedAt(r.Context())
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Error querying audit roll-up state", "error", err)
+	}
+	respondJSON(w, http.StatusOK, models.PolicyStatsResponse{Granularity: filter.Granularity, RefreshedAt: refreshed, Buckets: buckets})
+}
+
+// statsFilter parses the query parameters shared by the stats endpoints,
+// replying and returning false when they are invalid
+func (h *Handler) statsFilter(w http.ResponseWriter, r *http.Request) (models.StatsFilter, bool) {
+	if h.rollups == nil {
+		respondError(w, http.StatusNotFound, "audit roll-ups are not enabled")
+		return models.StatsFilter{}, false
+	}
+	q := r.URL.Query()
+	filter := models.StatsFilter{
+		Granularity: q.Get("granularity"),
+		ClientID:    q.Get("client_id"),
+		Action:      q.Get("action"),
+		Limit:       1000,
+	}
+	span := defaultHourlyStatsRange
+	switch filter.Granularity {
+	case "", audit.GranularityHour:
+		filter.Granularity = audit.GranularityHour
+	case audit.GranularityDay:
+		span = defaultDailyStatsRange
+	default:
+		respondError(w, http.StatusBadRequest, "granularity must be hour or day")
+		return filter, false
+	}
+	for name, dst := range map[string]*time.Time{"since": &filter.Since, "until": &filter.Until} {
+		if v := q.Get(name); v != "" {
+			t, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				respondError(w, http.StatusBadRequest, name+" must be an RFC 3339 timestamp")
+				return filter, false
+			}
+			*dst = t
+		}
+	}
+	if filter.Until.IsZero() {
+		filter.Until = time.Now()
+	}
+	if filter.Since.IsZero() {
+		filter.Since = filter.Until.Add(-span)
+	}
+	if !filter.Since.Before(filter.Until) {
+		respondError(w, http.StatusBadRequest, "since must be before until")
+		return filter, false
+	}
+	if v := q.Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n <= 0 || n > 10000 {
+			respondError(w, http.StatusBadRequest, "limit must be between 1 and 10000")
+			return filter, false
+		}
+		filter.Limit = n
+	}
+	return filter, true
+}
@@ -0,0 +1,124 @@
+package audit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// Forwarding transports, selected by AUDIT_FORWARD_MODE
+const (
+	ForwardAPI   = "api"
+	ForwardRedis = "redis"
+)
+
+// forwardChunk bounds the entries sent in one request to the owner region
+const forwardChunk = 1000
+
+// HTTPForwarder sends audit batches to the owner region's POST /v1/audit/ingest
+type HTTPForwarder struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// NewHTTPForwarder creates a forwarder to the gateway at baseURL, authenticating
+// with the owner's AUDIT_INGEST_TOKEN
+func NewHTTPForwarder(baseURL, token string, httpClient *http.Client) *HTTPForwarder {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &HTTPForwarder{url: strings.TrimRight(baseURL, "/") + "/v1/audit/ingest", token: token, httpClient: httpClient}
+}
+
+// Name identifies the forwarder in logs
+func (f *HTTPForwarder) Name() string { return "api" }
+
+// Forward posts entries in chunks; a failed chunk fails the batch, so the caller
+// re-sends chunks that were already accepted (delivery is at least once)
+func (f *HTTPForwarder) Forward(ctx context.Context, entries []models.AuditLog) error {
+	for start := 0; start < len(entries); start += forwardChunk {
+		chunk := entries[start:min(start+forwardChunk, len(entries))]
+		if err := f.post(ctx, chunk); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// post sends one chunk
+func (f *HTTPForwarder) post(ctx context.Context, entries []models.AuditLog) error {
+	body, err := json.Marshal(models.AuditIngestRequest{Entries: entries})
+	if err != nil {
+		return fmt.Errorf("failed to encode audit batch: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create audit forward request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.token)
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("audit forward request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("owner region returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RedisForwarder pushes audit batches onto the owner region's Redis audit queue,
+// which the owner's sync worker persists like its own entries
+type RedisForwarder struct {
+	rdb *redis.Client
+}
+
+// NewRedisForwarder creates a forwarder writing to the owner's Redis
+func NewRedisForwarder(rdb *redis.Client) *RedisForwarder {
+	return &RedisForwarder{rdb: rdb}
+}
+
+// Name identifies the forwarder in logs
+func (f *RedisForwarder) Name() string { return "redis" }
+
+// Forward queues entries on the owner's Redis
+func (f *RedisForwarder) Forward(ctx context.Context, entries []models.AuditLog) error {
+	return pushEntries(ctx, f.rdb, entries)
+}
+
+// pushEntries queues entries for the Redis→Postgres sync in one round trip
+func pushEntries(ctx context.Context, rdb *redis.Client, entries []models.AuditLog) error {
+	if len(entries) == 0 {
+		return nil
+	}
+	values := make([]interface{}, len(entries))
+	for i, entry := range entries {
+		data, err := json.Marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal audit log: %w", err)
+		}
+		values[i] = data
+	}
+	pipe := rdb.TxPipeline()
+	pipe.LPush(ctx, auditLogsKey, values...)
+	pipe.Expire(ctx, auditLogsKey, auditLogTTL)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to queue audit logs: %w", err)
+	}
+	return nil
+}
+
+// Ingest queues entries forwarded by another region for this region's sync worker
+func (l *Logger) Ingest(ctx context.Context, entries []models.AuditLog) error {
+	return pushEntries(ctx, l.rdb, entries)
+}
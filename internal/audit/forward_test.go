@@ -0,0 +1,58 @@
+package audit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestHTTPForwarder_Forward(t *testing.T) {
+	var batches []int
+	fail := false
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/audit/ingest" || r.Header.Get("Authorization") != "Bearer ingest-secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		if fail {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		var req models.AuditIngestRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		batches = append(batches, len(req.Entries))
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	entries := make([]models.AuditLog, forwardChunk+1)
+	for i := range entries {
+		entries[i] = walEntry("remote")
+	}
+
+	f := NewHTTPForwarder(server.URL+"/", "ingest-secret", server.Client())
+	if err := f.Forward(context.Background(), entries); err != nil {
+		t.Fatalf("Forward() error = %v", err)
+	}
+	if len(batches) != 2 || batches[0] != forwardChunk || batches[1] != 1 {
+		t.Errorf("owner received batches %v, want [%d 1]", batches, forwardChunk)
+	}
+
+	// The owner rejecting a batch must fail the forward so the caller keeps it
+	fail = true
+	if err := f.Forward(context.Background(), entries[:1]); err == nil {
+		t.Error("Forward() succeeded while the owner returned 503")
+	}
+	wrongToken := NewHTTPForwarder(server.URL, "wrong", server.Client())
+	fail = false
+	if err := wrongToken.Forward(context.Background(), entries[:1]); err == nil {
+		t.Error("Forward() succeeded with a rejected token")
+	}
+}
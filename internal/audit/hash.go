@@ -0,0 +1,85 @@
+package audit
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/text/unicode/norm"
+	"lukechampine.com/blake3"
+)
+
+// Content hash algorithms
+const (
+	HashSHA256 = "sha256"
+	HashSHA512 = "sha512"
+	HashBLAKE3 = "blake3" // 256-bit output
+)
+
+// Hasher hashes prompt and response content for audit entries and the prompt
+// allowlist. Content is never stored, so its hash is what ties entries together
+type Hasher struct {
+	algorithm    string
+	canonicalize bool
+}
+
+// NewHasher creates a Hasher for algorithm (sha256, sha512 or blake3). With
+// canonicalize, content is normalized before hashing (see Canonicalize)
+func NewHasher(algorithm string, canonicalize bool) (*Hasher, error) {
+	switch algorithm {
+	case HashSHA256, HashSHA512, HashBLAKE3:
+	default:
+		return nil, fmt.Errorf("unknown hash algorithm %q (want sha256, sha512 or blake3)", algorithm)
+	}
+	return &Hasher{algorithm: algorithm, canonicalize: canonicalize}, nil
+}
+
+// Algorithm is the hasher's algorithm; a nil Hasher uses sha256
+func (h *Hasher) Algorithm() string {
+	if h == nil {
+		return HashSHA256
+	}
+	return h.algorithm
+}
+
+// Hash returns the lowercase hex digest of content; a nil Hasher hashes raw
+// content with SHA-256, as HashContent
+func (h *Hasher) Hash(content string) string {
+	if h == nil {
+		return HashContent(content)
+	}
+	if h.canonicalize {
+		content = Canonicalize(content)
+	}
+	switch h.algorithm {
+	case HashSHA512:
+		sum := sha512.Sum512([]byte(content))
+		return hex.EncodeToString(sum[:])
+	case HashBLAKE3:
+		sum := blake3.Sum256([]byte(content))
+		return hex.EncodeToString(sum[:])
+	}
+	return HashContent(content)
+}
+
+// Valid reports whether s is a lowercase hex digest of the hasher's algorithm
+func (h *Hasher) Valid(s string) bool {
+	size := sha256.Size
+	if h.Algorithm() == HashSHA512 {
+		size = sha512.Size
+	}
+	if len(s) != 2*size || strings.ToLower(s) != s {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
+
+// Canonicalize puts content in Unicode NFC and collapses every run of whitespace
+// (line breaks, tabs, non-breaking spaces) into one space, trimming both ends, so
+// the same logical prompt hashes identically however a client encoded or spaced it
+func Canonicalize(content string) string {
+	return strings.Join(strings.Fields(norm.NFC.String(content)), " ")
+}
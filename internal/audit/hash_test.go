@@ -0,0 +1,88 @@
+package audit
+
+import "testing"
+
+func TestHasher_Hash(t *testing.T) {
+	tests := []struct {
+		algorithm string
+		want      string
+	}{
+		{HashSHA256, "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{HashSHA512, "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"},
+		{HashBLAKE3, "ea8f163db38682925e4491c5e58d4bb3506ef8c14eb78a86e908c5624a67200f"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.algorithm, func(t *testing.T) {
+			h, err := NewHasher(tt.algorithm, false)
+			if err != nil {
+				t.Fatalf("NewHasher() error = %v", err)
+			}
+			if got := h.Hash("hello"); got != tt.want {
+				t.Errorf("Hash() = %s, want %s", got, tt.want)
+			}
+			if !h.Valid(tt.want) {
+				t.Errorf("Valid(%s) = false, want true", tt.want)
+			}
+		})
+	}
+
+	var disabled *Hasher
+	if disabled.Hash("hello") != HashContent("hello") || disabled.Algorithm() != HashSHA256 {
+		t.Error("nil Hasher must hash raw content with SHA-256")
+	}
+	if _, err := NewHasher("md5", false); err == nil {
+		t.Error("NewHasher(md5) succeeded, want an error")
+	}
+}
+
+func TestHasher_Canonicalize(t *testing.T) {
+	h, err := NewHasher(HashSHA256, true)
+	if err != nil {
+		t.Fatalf("NewHasher() error = %v", err)
+	}
+	want := h.Hash("Résumé the report, please")
+	for _, variant := range []string{
+		"  Résumé the report,\r\n\tplease\n",
+		"Re\u0301sume\u0301 the report, please", // Decomposed accents
+		"Résumé the report,  please",
+	} {
+		if got := h.Hash(variant); got != want {
+			t.Errorf("Hash(%q) = %s, want the canonical hash %s", variant, got, want)
+		}
+	}
+	if h.Hash("Résumé the report, please!") == want {
+		t.Error("different prompts must hash differently")
+	}
+
+	raw, _ := NewHasher(HashSHA256, false)
+	if raw.Hash(" hello") == raw.Hash("hello") {
+		t.Error("without canonicalization, whitespace must change the hash")
+	}
+}
+
+func TestHasher_Valid(t *testing.T) {
+	sha256, _ := NewHasher(HashSHA256, false)
+	sha512, _ := NewHasher(HashSHA512, false)
+	tests := []struct {
+		name   string
+		hasher *Hasher
+		hash   string
+		want   bool
+	}{
+		{"audit prompt hash", sha256, HashContent("You are a helpful assistant."), true},
+		{"uppercase", sha256, "E3B0C44298FC1C149AFBF4C8996FB92427AE41E4649B934CA495991B7852B855", false},
+		{"too short", sha256, "e3b0c442", false},
+		{"not hex", sha256, "z3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", false},
+		{"empty", sha256, "", false},
+		{"sha256 digest for sha512", sha512, HashContent("You are a helpful assistant."), false},
+		{"nil hasher", nil, HashContent("You are a helpful assistant."), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hasher.Valid(tt.hash); got != tt.want {
+				t.Errorf("Valid(%q) = %v, want %v", tt.hash, got, tt.want)
+			}
+		})
+	}
+}
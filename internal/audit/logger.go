@@ -5,12 +5,16 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/internal/metrics"
 	"github.com/prompt-gateway/pkg/models"
 	"github.com/redis/go-redis/v9"
 )
@@ -20,20 +24,47 @@ const (
 	auditLogTTL  = 30 * time.Minute // Keep audit logs in Redis for 30 min
 )
 
+// Sink receives audit entries from the analyze path
+// Logger is the Redis/Postgres implementation
+type Sink interface {
+	Log(entry models.AuditLog) error
+	// PurgePending removes queued entries that haven't reached durable storage yet
+	PurgePending(ctx context.Context, match func(models.AuditLog) bool) (int64, error)
+}
+
 // Logger handles audit log persistence via Redis with async Postgres sync
 type Logger struct {
 	db         *sql.DB
 	rdb        *redis.Client
-	logChannel chan models.AuditLog // Buffered channel for async logging
-	stopCh     chan struct{}        // Signal to stop workers
-	wg         sync.WaitGroup       // Wait for workers to finish
-	workers    int                  // Number of background workers
+	logChannel chan models.AuditLog        // Buffered channel for async logging
+	stopCh     chan struct{}               // Signal to stop workers
+	wg         sync.WaitGroup              // Wait for workers to finish
+	workers    int                         // Number of background workers
+	pending    atomic.Int64                // Entries queued or being written
+	dbBreaker  *breaker.Breaker            // Optional; guards the direct Postgres fallback
+	tenantDB   func(tenant string) *sql.DB // Optional; routes tenants with isolated storage
+	wal        *WAL                        // Optional; keeps entries neither Redis nor Postgres accepted
+	noDirectDB bool                        // Another region owns persistence; never write Postgres directly
+	blockFor   time.Duration               // How long Log waits for buffer space before writing synchronously
+
+	// Entries are stored under a read lock; an erasure takes the write lock to wait
+	// out writes in flight, then holds matching entries back until its purge is done
+	erasureMu   sync.RWMutex
+	erasures    map[int]func(models.AuditLog) bool
+	nextErasure int
 }
 
+// ErrDropped is returned by Log when an entry could be neither queued nor stored
+var ErrDropped = errors.New("audit entry dropped")
+
+// walReplayInterval is how often spilled entries are offered back to Redis/Postgres
+const walReplayInterval = 10 * time.Second
+
 // Config holds logger configuration
 type Config struct {
-	BufferSize int // Size of the buffered channel
-	Workers    int // Number of concurrent workers
+	BufferSize   int           // Size of the buffered channel
+	Workers      int           // Number of concurrent workers
+	BlockTimeout time.Duration // How long Log waits for a full buffer to free up (0 writes synchronously at once)
 }
 
 // DefaultConfig returns sensible defaults for async logging
@@ -57,6 +88,7 @@ func NewLoggerWithConfig(db *sql.DB, rdb *redis.Client, config Config) *Logger {
 		logChannel: make(chan models.AuditLog, config.BufferSize),
 		stopCh:     make(chan struct{}),
 		workers:    config.Workers,
+		blockFor:   config.BlockTimeout,
 	}
 	
 	// Start background workers
@@ -65,44 +97,74 @@ func NewLoggerWithConfig(db *sql.DB, rdb *redis.Client, config Config) *Logger {
 	return logger
 }
 
+// SetBreaker guards the direct Postgres fallback with a circuit breaker
+// Redis access is guarded by the client-wide hook
+func (l *Logger) SetBreaker(dbBreaker *breaker.Breaker) {
+	l.dbBreaker = dbBreaker
+}
+
+// SetTenantRouting writes the direct-fallback entries of tenants with isolated
+// storage to the database returned by tenantDB (nil keeps the default database)
+func (l *Logger) SetTenantRouting(tenantDB func(tenant string) *sql.DB) {
+	l.tenantDB = tenantDB
+}
+
+// SetWAL spills entries that neither Redis nor Postgres accepts to wal, and replays
+// them (including any left by a previous process) once either recovers
+func (l *Logger) SetWAL(wal *WAL) {
+	l.wal = wal
+	l.wg.Add(1)
+	go l.walReplayWorker()
+	slog.Info("Audit disk WAL enabled", "dir", wal.dir, "to_replay", wal.Len())
+}
+
+// DisableDatabaseFallback stops entries Redis rejects from being written to Postgres
+// directly, for regions that forward audit persistence to an owner region; such
+// entries go to the disk WAL instead
+func (l *Logger) DisableDatabaseFallback() {
+	l.noDirectDB = true
+}
+
 // startWorkers launches background goroutines to process logs
 func (l *Logger) startWorkers() {
 	for i := 0; i < l.workers; i++ {
 		l.wg.Add(1)
 		go l.worker(i + 1) // Worker IDs start from 1
 	}
-	log.Printf("✓ Started %d audit log workers", l.workers)
+	slog.Info("Started audit log workers", "workers", l.workers)
 }
 
 // worker is a background goroutine that processes audit log entries
 func (l *Logger) worker(id int) {
 	defer l.wg.Done()
 	
-	log.Printf("Audit worker #%d started", id)
+	slog.Debug("Audit worker started", "worker", id)
 	
 	for {
 		select {
 		case entry := <-l.logChannel:
-			// Write to Redis instead of Postgres
-			if err := l.writeToRedis(entry); err != nil {
-				log.Printf("Worker #%d failed to write audit log to Redis: %v", id, err)
-				// Fallback: try writing directly to Postgres
-				if err := l.writeToDatabase(entry); err != nil {
-					log.Printf("Worker #%d failed to write audit log to Postgres: %v", id, err)
-				}
-			}
+			l.store(id, entry)
+			l.pending.Add(-1)
 			
 		case <-l.stopCh:
 			// Drain remaining logs before stopping
-			log.Printf("Worker #%d draining remaining logs...", id)
+			slog.Debug("Audit worker draining remaining logs", "worker", id)
 			for {
 				select {
 				case entry := <-l.logChannel:
-					if err := l.writeToRedis(entry); err != nil {
-						log.Printf("Worker #%d failed to write audit log to Redis during shutdown: %v", id, err)
+					l.erasureMu.RLock()
+					if !l.erased(entry) {
+						if err := l.writeToRedis(entry); err != nil {
+							slog.Error("Failed to write audit log to Redis during shutdown", "worker", id, "request_id", entry.RequestID, "error", err)
+							if !l.spill(entry) {
+								l.drop(entry, "shutdown", err)
+							}
+						}
 					}
+					l.erasureMu.RUnlock()
+					l.pending.Add(-1)
 				default:
-					log.Printf("Worker #%d stopped", id)
+					slog.Debug("Audit worker stopped", "worker", id)
 					return
 				}
 			}
@@ -110,21 +172,143 @@ func (l *Logger) worker(id int) {
 	}
 }
 
-// Log sends an audit entry to the background workers (non-blocking)
-// This method returns immediately without waiting for Redis write
+// store writes a dequeued entry to Redis, falling back to Postgres and then the
+// disk WAL, unless an erasure in progress covers it
+func (l *Logger) store(id int, entry models.AuditLog) {
+	l.erasureMu.RLock()
+	defer l.erasureMu.RUnlock()
+	if l.erased(entry) {
+		return
+	}
+
+	// Write to Redis instead of Postgres
+	if err := l.writeToRedis(entry); err != nil {
+		slog.Warn("Failed to write audit log to Redis", "worker", id, "request_id", entry.RequestID, "error", err)
+		// Fallback: try writing directly to Postgres
+		if l.noDirectDB {
+			if !l.spill(entry) {
+				l.drop(entry, "storage_failed", err)
+			}
+		} else if err := l.writeToDatabase(entry); err != nil {
+			slog.Error("Failed to write audit log to Postgres", "worker", id, "request_id", entry.RequestID, "error", err)
+			if !l.spill(entry) {
+				l.drop(entry, "storage_failed", err)
+			}
+		}
+	}
+}
+
+// erased reports whether an erasure in progress covers entry; the caller holds erasureMu
+func (l *Logger) erased(entry models.AuditLog) bool {
+	for _, match := range l.erasures {
+		if match(entry) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log sends an audit entry to the background workers
+// It returns immediately unless the buffer is full: then it waits up to the
+// configured block timeout for space, writes synchronously to Redis, spills to the
+// disk WAL, and finally drops the entry, returning ErrDropped
 func (l *Logger) Log(entry models.AuditLog) error {
+	l.pending.Add(1)
 	select {
 	case l.logChannel <- entry:
 		// Successfully queued for background processing
 		return nil
 	default:
-		// Channel is full - this is a backpressure situation
-		// Write synchronously to Redis to avoid dropping the audit entry
-		log.Println("⚠️  Audit log buffer full, writing synchronously to Redis")
-		return l.writeToRedis(entry)
+	}
+
+	// Compliance-critical deployments trade request latency for fewer synchronous writes
+	if l.blockFor > 0 {
+		timer := time.NewTimer(l.blockFor)
+		defer timer.Stop()
+		select {
+		case l.logChannel <- entry:
+			metrics.AuditBufferWaitsTotal.WithLabelValues("queued").Inc()
+			return nil
+		case <-timer.C:
+			metrics.AuditBufferWaitsTotal.WithLabelValues("timeout").Inc()
+		}
+	}
+
+	l.pending.Add(-1)
+	l.erasureMu.RLock()
+	defer l.erasureMu.RUnlock()
+	if l.erased(entry) {
+		return nil
+	}
+	// Channel is full - this is a backpressure situation
+	// Write synchronously to Redis to avoid dropping the audit entry
+	slog.Warn("Audit log buffer full, writing synchronously to Redis", "request_id", entry.RequestID, "client_id", entry.ClientID)
+	err := l.writeToRedis(entry)
+	if err == nil || l.spill(entry) {
+		return nil
+	}
+	l.drop(entry, "buffer_full", err)
+	return fmt.Errorf("%w: %v", ErrDropped, err)
+}
+
+// drop counts and reports an entry that is lost for reason, so losses are never silent
+func (l *Logger) drop(entry models.AuditLog, reason string, err error) {
+	metrics.AuditDroppedTotal.WithLabelValues(reason).Inc()
+	slog.Error("Audit entry dropped", "reason", reason, "request_id", entry.RequestID, "client_id", entry.ClientID, "error", err)
+}
+
+// spill appends an entry storage rejected to the disk WAL, reporting whether it was kept
+func (l *Logger) spill(entry models.AuditLog) bool {
+	if l.wal == nil {
+		return false
+	}
+	if err := l.wal.Append(entry); err != nil {
+		metrics.AuditWALTotal.WithLabelValues("dropped").Inc()
+		slog.Warn("Disk WAL rejected audit entry", "request_id", entry.RequestID, "error", err)
+		return false
+	}
+	metrics.AuditWALTotal.WithLabelValues("spilled").Inc()
+	return true
+}
+
+// walReplayWorker periodically replays spilled entries until the logger closes
+func (l *Logger) walReplayWorker() {
+	defer l.wg.Done()
+	ticker := time.NewTicker(walReplayInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if l.wal.Len() > 0 {
+				l.replayWAL()
+			}
+		case <-l.stopCh:
+			return
+		}
 	}
 }
 
+// replayWAL writes spilled entries back to Redis, or Postgres while Redis is down
+func (l *Logger) replayWAL() error {
+	replayed, err := l.wal.Replay(func(entry models.AuditLog) error {
+		l.erasureMu.RLock()
+		defer l.erasureMu.RUnlock()
+		if l.erased(entry) {
+			return nil
+		}
+		err := l.writeToRedis(entry)
+		if err == nil || l.noDirectDB {
+			return err
+		}
+		return l.writeToDatabase(entry)
+	})
+	if replayed > 0 {
+		slog.Info("Replayed audit entries from the disk WAL", "replayed", replayed, "left", l.wal.Len())
+	}
+	return err
+}
+
 // writeToRedis writes audit log to Redis list (will be synced to Postgres later)
 func (l *Logger) writeToRedis(entry models.AuditLog) error {
 	ctx := context.Background()
@@ -152,9 +336,10 @@ func (l *Logger) writeToDatabase(entry models.AuditLog) error {
 
 	query := `
 		INSERT INTO audit_logs (
-			request_id, client_id, prompt_hash, response_hash,
-			policies_triggered, action_taken, latency_ms
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			request_id, client_id, session_id, prompt_hash, response_hash,
+			policies_triggered, action_taken, latency_ms,
+			instance_id, hostname, region, zone, gateway_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
 	// Convert UUID slice to PostgreSQL array
@@ -163,17 +348,31 @@ func (l *Logger) writeToDatabase(entry models.AuditLog) error {
 		policyIDs[i] = id.String()
 	}
 
-	_, err := l.db.ExecContext(
-		ctx, query,
-		entry.RequestID,
-		entry.ClientID,
-		entry.PromptHash,
-		entry.ResponseHash,
-		pq.Array(policyIDs), // pq.Array to handle array in case multiple actions are taken
-		entry.ActionTaken,
-		entry.LatencyMs,
-	)
-
+	err := l.dbBreaker.Do(func() error {
+		db := l.db
+		if entry.Tenant != "" && l.tenantDB != nil {
+			if tenantDB := l.tenantDB(entry.Tenant); tenantDB != nil {
+				db = tenantDB
+			}
+		}
+		_, err := db.ExecContext(
+			ctx, query,
+			entry.RequestID,
+			entry.ClientID,
+			NullSession(entry.SessionID),
+			entry.PromptHash,
+			entry.ResponseHash,
+			pq.Array(policyIDs), // pq.Array to handle array in case multiple actions are taken
+			entry.ActionTaken,
+			entry.LatencyMs,
+			entry.InstanceID,
+			entry.Hostname,
+			entry.Region,
+			entry.Zone,
+			entry.GatewayVersion,
+		)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to log audit entry: %w", err)
 	}
@@ -181,10 +380,113 @@ func (l *Logger) writeToDatabase(entry models.AuditLog) error {
 	return nil
 }
 
+// PurgePending removes entries matching the predicate from the in-memory buffer,
+// the disk WAL and the Redis queue before they are synced to Postgres. Matching
+// entries being written when it starts are waited for and purged too, and new
+// ones are held back until it returns. Returns the number of entries removed.
+func (l *Logger) PurgePending(ctx context.Context, match func(models.AuditLog) bool) (int64, error) {
+	l.erasureMu.Lock()
+	if l.erasures == nil {
+		l.erasures = make(map[int]func(models.AuditLog) bool)
+	}
+	erasure := l.nextErasure
+	l.nextErasure++
+	l.erasures[erasure] = match
+	l.erasureMu.Unlock()
+	defer func() {
+		l.erasureMu.Lock()
+		delete(l.erasures, erasure)
+		l.erasureMu.Unlock()
+	}()
+
+	removed := l.purgeBuffered(match)
+	if l.wal != nil {
+		n, err := l.wal.Purge(match)
+		if err != nil {
+			return removed, fmt.Errorf("failed to purge audit WAL: %w", err)
+		}
+		removed += n
+	}
+
+	pending, err := l.rdb.LRange(ctx, auditLogsKey, 0, -1).Result()
+	if err != nil {
+		return removed, fmt.Errorf("failed to read pending audit logs: %w", err)
+	}
+
+	for _, data := range pending {
+		var entry models.AuditLog
+		if err := json.Unmarshal([]byte(data), &entry); err != nil {
+			continue // Skip bad JSON, sync worker drops it anyway
+		}
+		if !match(entry) {
+			continue
+		}
+
+		n, err := l.rdb.LRem(ctx, auditLogsKey, 0, data).Result()
+		if err != nil {
+			return removed, fmt.Errorf("failed to remove pending audit log: %w", err)
+		}
+		removed += n
+	}
+
+	return removed, nil
+}
+
+// purgeBuffered drops matching entries from the in-memory buffer; the others are
+// queued again, or stored directly if the buffer filled up meanwhile
+func (l *Logger) purgeBuffered(match func(models.AuditLog) bool) int64 {
+	var removed int64
+	var kept []models.AuditLog
+drain:
+	for n := len(l.logChannel); n > 0; n-- {
+		select {
+		case entry := <-l.logChannel:
+			if match(entry) {
+				removed++
+				l.pending.Add(-1)
+			} else {
+				kept = append(kept, entry)
+			}
+		default:
+			break drain
+		}
+	}
+	for _, entry := range kept {
+		select {
+		case l.logChannel <- entry:
+		default:
+			l.store(0, entry)
+			l.pending.Add(-1)
+		}
+	}
+	return removed
+}
+
+// Flush waits until every queued entry has been written to Redis, then replays the
+// disk WAL
+func (l *Logger) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for l.pending.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return fmt.Errorf("audit buffer not drained (%d pending): %w", l.pending.Load(), ctx.Err())
+		}
+	}
+	if l.wal != nil && l.wal.Len() > 0 {
+		if err := l.replayWAL(); err != nil {
+			return fmt.Errorf("audit WAL not replayed (%d entries): %w", l.wal.Len(), err)
+		}
+	}
+	return nil
+}
+
 // Close gracefully shuts down the logger
 // It stops accepting new logs and waits for workers to finish
 func (l *Logger) Close() error {
-	log.Println("Shutting down audit logger...")
+	slog.Info("Shutting down audit logger")
 	
 	// Signal workers to stop
 	close(l.stopCh)
@@ -192,7 +494,14 @@ func (l *Logger) Close() error {
 	// Wait for all workers to finish processing
 	l.wg.Wait()
 	
-	log.Println("✓ Audit logger stopped gracefully")
+	// Entries still spilled are replayed by the next process
+	if l.wal != nil {
+		if err := l.wal.Close(); err != nil {
+			slog.Warn("Failed to close audit WAL", "error", err)
+		}
+	}
+
+	slog.Info("Audit logger stopped gracefully")
 	return nil
 }
 
@@ -0,0 +1,146 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// unreachableRedis fails every command at once
+func unreachableRedis(t *testing.T) *redis.Client {
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestLogger_DropsWhenBufferFullAndRedisDown(t *testing.T) {
+	// No workers, so the single buffer slot stays taken
+	l := NewLoggerWithConfig(nil, unreachableRedis(t), Config{BufferSize: 1})
+	defer l.Close()
+	if err := l.Log(walEntry("a")); err != nil {
+		t.Fatalf("Log() into a free buffer = %v", err)
+	}
+
+	before := testutil.ToFloat64(metrics.AuditDroppedTotal.WithLabelValues("buffer_full"))
+	if err := l.Log(walEntry("b")); !errors.Is(err, ErrDropped) {
+		t.Fatalf("Log() into a full buffer with Redis down = %v, want ErrDropped", err)
+	}
+	if got := testutil.ToFloat64(metrics.AuditDroppedTotal.WithLabelValues("buffer_full")) - before; got != 1 {
+		t.Errorf("buffer_full drops = %v, want 1", got)
+	}
+}
+
+func TestLogger_SpillsToWALInsteadOfDropping(t *testing.T) {
+	wal, err := OpenWAL(t.TempDir(), 0)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+	l := NewLoggerWithConfig(nil, unreachableRedis(t), Config{BufferSize: 1})
+	l.SetWAL(wal)
+	defer l.Close()
+
+	l.Log(walEntry("a"))
+	if err := l.Log(walEntry("b")); err != nil {
+		t.Fatalf("Log() with a WAL = %v, want the entry spilled", err)
+	}
+	if wal.Len() != 1 {
+		t.Errorf("WAL holds %d entries, want 1", wal.Len())
+	}
+}
+
+func TestLogger_BlockingModeWaitsForSpace(t *testing.T) {
+	l := NewLoggerWithConfig(nil, unreachableRedis(t), Config{BufferSize: 1, BlockTimeout: 2 * time.Second})
+	defer l.Close()
+	l.Log(walEntry("a"))
+
+	// A worker frees the slot while the second entry waits
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		<-l.logChannel
+		l.pending.Add(-1)
+	}()
+	if err := l.Log(walEntry("b")); err != nil {
+		t.Fatalf("Log() = %v, want the entry queued once space freed up", err)
+	}
+	if got := (<-l.logChannel).ClientID; got != "b" {
+		t.Errorf("queued entry = %q, want b", got)
+	}
+	l.pending.Add(-1)
+}
+
+func TestLogger_PurgePendingDrainsBuffer(t *testing.T) {
+	// No workers, so queued entries stay in the buffer
+	l := NewLoggerWithConfig(nil, unreachableRedis(t), Config{BufferSize: 4})
+	defer l.Close()
+	for _, clientID := range []string{"a", "b", "a"} {
+		l.Log(walEntry(clientID))
+	}
+
+	// Redis is down, so the queue purge fails once the buffer was purged
+	removed, err := l.PurgePending(context.Background(), func(entry models.AuditLog) bool { return entry.ClientID == "a" })
+	if err == nil || removed != 2 {
+		t.Fatalf("PurgePending() = %d, %v, want 2 buffered entries removed and the Redis error", removed, err)
+	}
+	if n := len(l.logChannel); n != 1 {
+		t.Fatalf("buffer holds %d entries, want 1", n)
+	}
+	if got := (<-l.logChannel).ClientID; got != "b" {
+		t.Errorf("buffered entry = %q, want b", got)
+	}
+	l.pending.Add(-1)
+	if got := l.pending.Load(); got != 0 {
+		t.Errorf("pending = %d, want 0 once the purged entries are gone", got)
+	}
+	if len(l.erasures) != 0 {
+		t.Errorf("%d erasures still hold entries back after PurgePending returned", len(l.erasures))
+	}
+}
+
+func TestLogger_BlockingModeIsBounded(t *testing.T) {
+	l := NewLoggerWithConfig(nil, unreachableRedis(t), Config{BufferSize: 1, BlockTimeout: 30 * time.Millisecond})
+	defer l.Close()
+	l.Log(walEntry("a"))
+
+	start := time.Now()
+	if err := l.Log(walEntry("b")); !errors.Is(err, ErrDropped) {
+		t.Fatalf("Log() = %v, want ErrDropped after the wait", err)
+	}
+	if waited := time.Since(start); waited < 30*time.Millisecond {
+		t.Errorf("Log() returned after %v, want it to wait the block timeout first", waited)
+	}
+}
+
+func TestLogger_WriteToDatabaseRecordsOrigin(t *testing.T) {
+	db, rec := openRecordingDB(t)
+	l := NewLoggerWithConfig(db, nil, Config{})
+	defer l.Close()
+
+	entry := walEntry("a")
+	entry.GatewayOrigin = models.GatewayOrigin{
+		InstanceID: "gw-1", Hostname: "host-a", Region: "eu-west-1", Zone: "eu-west-1b", GatewayVersion: "1.4.0",
+	}
+	if err := l.writeToDatabase(entry); err != nil {
+		t.Fatalf("writeToDatabase() error = %v", err)
+	}
+
+	stmts := rec.Stmts()
+	if len(stmts) != 1 || !strings.Contains(stmts[0].query, "instance_id, hostname, region, zone, gateway_version") {
+		t.Fatalf("statements = %+v, want one insert with the origin columns", stmts)
+	}
+	got := stmts[0].args[8:]
+	want := []any{"gw-1", "host-a", "eu-west-1", "eu-west-1b", "1.4.0"}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("origin args = %v, want %v", got, want)
+			break
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// auditColumns selects every audit_logs column in scanAuditLog order
+const auditColumns = `id, request_id, COALESCE(client_id, ''), COALESCE(session_id, ''),
+		       COALESCE(prompt_hash, ''), COALESCE(response_hash, ''),
+		       COALESCE(policies_triggered, '{}'), COALESCE(action_taken, ''),
+		       COALESCE(latency_ms, 0), created_at,
+		       COALESCE(instance_id, ''), COALESCE(hostname, ''), COALESCE(region, ''),
+		       COALESCE(zone, ''), COALESCE(gateway_version, '')`
+
+// NullSession is the session_id column value of an audit entry: NULL when the
+// request had no session, so the partial session index only covers real sessions
+func NullSession(sessionID string) sql.NullString {
+	return sql.NullString{String: sessionID, Valid: sessionID != ""}
+}
+
+// maxListLimit caps how many entries one List call returns
+const maxListLimit = 1000
+
+// Repository handles read access to persisted audit logs
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new audit Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ListBySession returns the audit entries of a session in chronological order
+// Entries still buffered in Redis appear once the sync worker has flushed them
+func (r *Repository) ListBySession(ctx context.Context, sessionID string) ([]models.AuditLog, error) {
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_logs
+		WHERE session_id = $1
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query session audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditLog, 0)
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+
+	return entries, nil
+}
+
+// List returns audit entries matching filter, newest first
+func (r *Repository) List(ctx context.Context, filter models.AuditFilter) ([]models.AuditLog, error) {
+	limit := filter.Limit
+	if limit <= 0 || limit > maxListLimit {
+		limit = maxListLimit
+	}
+
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_logs
+		WHERE ($1 = '' OR client_id = $1)
+		  AND ($2 = '' OR session_id = $2)
+		  AND ($3 = '' OR action_taken = $3)
+		  AND ($4 = '' OR instance_id = $4)
+		  AND ($5 = '' OR hostname = $5)
+		  AND ($6 = '' OR region = $6)
+		  AND ($7 = '' OR zone = $7)
+		  AND ($8 = '' OR gateway_version = $8)
+		  AND ($9 = '' OR prompt_hash = $9)
+		  AND ($10::timestamptz IS NULL OR created_at >= $10)
+		  AND ($11::timestamptz IS NULL OR created_at < $11)
+		ORDER BY created_at DESC, id DESC
+		LIMIT $12
+	`
+
+	rows, err := r.db.QueryContext(ctx, query,
+		filter.ClientID, filter.SessionID, filter.Action,
+		filter.InstanceID, filter.Hostname, filter.Region, filter.Zone, filter.GatewayVersion,
+		filter.PromptHash, nullTime(filter.Since), nullTime(filter.Until), limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.AuditLog, 0)
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+
+	return entries, nil
+}
+
+// nullTime maps the zero time to NULL
+func nullTime(t time.Time) sql.NullTime {
+	return sql.NullTime{Time: t, Valid: !t.IsZero()}
+}
+
+// ListByRequestIDs returns the audit entries for the given request IDs
+func (r *Repository) ListByRequestIDs(ctx context.Context, requestIDs []uuid.UUID) ([]models.AuditLog, error) {
+	entries := make([]models.AuditLog, 0, len(requestIDs))
+	if len(requestIDs) == 0 {
+		return entries, nil
+	}
+
+	ids := make([]string, len(requestIDs))
+	for i, id := range requestIDs {
+		ids[i] = id.String()
+	}
+
+	query := `
+		SELECT ` + auditColumns + `
+		FROM audit_logs
+		WHERE request_id = ANY($1::uuid[])
+		ORDER BY created_at ASC, id ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit logs: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		entry, err := scanAuditLog(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating audit logs: %w", err)
+	}
+
+	return entries, nil
+}
+
+// scanAuditLog maps a single audit_logs row to a model
+func scanAuditLog(rows *sql.Rows) (models.AuditLog, error) {
+	var entry models.AuditLog
+	err := rows.Scan(
+		&entry.ID, &entry.RequestID, &entry.ClientID, &entry.SessionID,
+		&entry.PromptHash, &entry.ResponseHash, pq.Array(&entry.PoliciesTriggered),
+		&entry.ActionTaken, &entry.LatencyMs, &entry.CreatedAt,
+		&entry.InstanceID, &entry.Hostname, &entry.Region, &entry.Zone, &entry.GatewayVersion,
+	)
+	if err != nil {
+		return models.AuditLog{}, fmt.Errorf("failed to scan audit log: %w", err)
+	}
+	return entry, nil
+}
+
+// DeleteBySubject permanently removes audit entries for a client and/or session
+// At least one of clientID or sessionID must be set; both narrows the match
+func (r *Repository) DeleteBySubject(ctx context.Context, clientID, sessionID string) (int64, error) {
+	if clientID == "" && sessionID == "" {
+		return 0, fmt.Errorf("client_id or session_id is required")
+	}
+
+	query := `
+		DELETE FROM audit_logs
+		WHERE ($1 = '' OR client_id = $1)
+		  AND ($2 = '' OR session_id = $2)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, clientID, sessionID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete audit logs: %w", err)
+	}
+
+	deleted, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to count deleted audit logs: %w", err)
+	}
+
+	return deleted, nil
+}
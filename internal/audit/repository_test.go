@@ -0,0 +1,134 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// recordingDB is a database/sql connector that records statements instead of
+// running them: execs affect one row and queries return no rows
+type recordingDB struct {
+	mu    sync.Mutex
+	stmts []recordedStmt
+}
+
+// recordedStmt is a statement run against a recordingDB
+type recordedStmt struct {
+	query string
+	args  []any
+}
+
+// openRecordingDB returns a *sql.DB backed by a recordingDB
+func openRecordingDB(t *testing.T) (*sql.DB, *recordingDB) {
+	t.Helper()
+	rec := &recordingDB{}
+	db := sql.OpenDB(rec)
+	t.Cleanup(func() { db.Close() })
+	return db, rec
+}
+
+// Stmts returns the statements run so far
+func (d *recordingDB) Stmts() []recordedStmt {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return append([]recordedStmt(nil), d.stmts...)
+}
+
+func (d *recordingDB) record(query string, args []driver.NamedValue) {
+	values := make([]any, len(args))
+	for i, a := range args {
+		values[i] = a.Value
+	}
+	d.mu.Lock()
+	d.stmts = append(d.stmts, recordedStmt{query: query, args: values})
+	d.mu.Unlock()
+}
+
+func (d *recordingDB) Connect(ctx context.Context) (driver.Conn, error) { return recordingConn{d}, nil }
+func (d *recordingDB) Driver() driver.Driver                            { return nil }
+
+type recordingConn struct{ db *recordingDB }
+
+func (c recordingConn) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (c recordingConn) Close() error { return nil }
+func (c recordingConn) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+
+func (c recordingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	c.db.record(query, args)
+	return driver.RowsAffected(1), nil
+}
+
+func (c recordingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.db.record(query, args)
+	return emptyRows{}, nil
+}
+
+type emptyRows struct{}
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func TestNullSession(t *testing.T) {
+	if got := NullSession(""); got.Valid {
+		t.Errorf("NullSession(\"\") = %+v, want NULL", got)
+	}
+	if got := NullSession("s1"); got != (sql.NullString{String: "s1", Valid: true}) {
+		t.Errorf("NullSession(s1) = %+v, want s1", got)
+	}
+}
+
+func TestRepository_ListFiltersByOrigin(t *testing.T) {
+	db, rec := openRecordingDB(t)
+	repo := NewRepository(db)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	entries, err := repo.List(context.Background(), models.AuditFilter{
+		InstanceID: "gw-1", Region: "eu-west-1", GatewayVersion: "1.4.0", Since: since,
+	})
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if entries == nil || len(entries) != 0 {
+		t.Errorf("List() = %v, want an empty, non-nil slice", entries)
+	}
+
+	stmts := rec.Stmts()
+	if len(stmts) != 1 {
+		t.Fatalf("ran %d statements, want 1", len(stmts))
+	}
+	for _, col := range []string{"instance_id", "hostname", "region", "zone", "gateway_version"} {
+		if !strings.Contains(stmts[0].query, col) {
+			t.Errorf("query does not select or filter %s", col)
+		}
+	}
+	args := stmts[0].args
+	if len(args) != 12 {
+		t.Fatalf("query args = %v, want 12", args)
+	}
+	if args[3] != "gw-1" || args[4] != "" || args[5] != "eu-west-1" || args[6] != "" || args[7] != "1.4.0" {
+		t.Errorf("origin args = %v, want gw-1, '', eu-west-1, '', 1.4.0", args[3:8])
+	}
+	if got, ok := args[9].(time.Time); !ok || !got.Equal(since) {
+		t.Errorf("since = %v, want %v", args[9], since)
+	}
+	if args[10] != nil {
+		t.Errorf("until = %v, want NULL for an open range", args[10])
+	}
+	if args[11] != int64(maxListLimit) {
+		t.Errorf("limit = %v, want the %d cap when unset", args[11], maxListLimit)
+	}
+}
@@ -0,0 +1,353 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+const (
+	// Roll-up granularities
+	GranularityHour = "hour"
+	GranularityDay  = "day"
+
+	// rollupLockKey is the advisory lock held while refreshing, so replicas
+	// sharing a database take turns instead of racing on the same buckets
+	rollupLockKey = 0x61756469745f7275
+	// rollupChunk bounds the raw audit rows aggregated by one statement
+	rollupChunk = 24 * time.Hour
+	// maxStatsLimit caps how many buckets one stats query returns
+	maxStatsLimit = 10000
+)
+
+// window is a [from, to) range of hourly buckets re-aggregated in one statement
+type window struct {
+	from, to time.Time
+}
+
+// Rollups maintains hourly and daily audit roll-ups by client, action and policy
+// Hourly buckets are re-aggregated from audit_logs for the last lookback hours on
+// every refresh, so entries synced late from Redis are still counted; daily
+// buckets are summed from the hourly ones
+type Rollups struct {
+	db       *sql.DB
+	interval time.Duration
+	lookback time.Duration
+	breaker  *breaker.Breaker // Optional; while open, refreshes are skipped
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewRollups creates a roll-up worker refreshing every interval and
+// re-aggregating the last lookback of audit entries
+func NewRollups(db *sql.DB, interval, lookback time.Duration) *Rollups {
+	return &Rollups{
+		db:       db,
+		interval: interval,
+		lookback: lookback,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetBreaker guards refreshes and queries with a circuit breaker
+// Must be called before Start
+func (r *Rollups) SetBreaker(dbBreaker *breaker.Breaker) {
+	r.breaker = dbBreaker
+}
+
+// Start runs the refresh worker; the first refresh backfills buckets missing
+// since the last one (or since the oldest audit entry)
+func (r *Rollups) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh audit roll-ups, retrying next interval", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-r.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Audit roll-up worker started", "refresh", r.interval, "lookback", r.lookback)
+}
+
+// Stop stops the worker
+func (r *Rollups) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		r.wg.Wait()
+	})
+}
+
+// Refresh re-aggregates the hourly buckets since the last refreshed one (at most
+// lookback ago) and the days they belong to, in one transaction. It is a no-op
+// while another replica holds the refresh lock
+func (r *Rollups) Refresh(ctx context.Context) error {
+	return r.breaker.Do(func() error {
+		tx, err := r.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var locked bool
+		if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, int64(rollupLockKey)).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var last sql.NullTime
+		err = tx.QueryRowContext(ctx, `SELECT MAX(bucket) FROM audit_rollups WHERE granularity = $1`, GranularityHour).Scan(&last)
+		if err != nil {
+			return err
+		}
+		if !last.Valid {
+			if err := tx.QueryRowContext(ctx, `SELECT MIN(created_at)::timestamptz FROM audit_logs`).Scan(&last); err != nil {
+				return err
+			}
+		}
+
+		windows := refreshWindows(last.Time, time.Now(), r.lookback)
+		for _, w := range windows {
+			if err := refreshHours(ctx, tx, w); err != nil {
+				return fmt.Errorf("hourly roll-up from %s: %w", w.from.Format(time.RFC3339), err)
+			}
+		}
+		days := window{from: truncateDay(windows[0].from), to: truncateDay(windows[len(windows)-1].to.Add(-time.Nanosecond)).Add(24 * time.Hour)}
+		if err := refreshDays(ctx, tx, days); err != nil {
+			return fmt.Errorf("daily roll-up: %w", err)
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO audit_rollup_state (name, refreshed_at) VALUES ('audit', NOW())
+			ON CONFLICT (name) DO UPDATE SET refreshed_at = EXCLUDED.refreshed_at
+		`)
+		if err != nil {
+			return err
+		}
+		return tx.Commit()
+	})
+}
+
+// refreshWindows splits the hourly buckets to re-aggregate into chunks: from the
+// last refreshed bucket, or lookback ago when that is earlier, through the
+// current hour. A zero last (nothing to backfill) starts lookback ago
+func refreshWindows(last, now time.Time, lookback time.Duration) []window {
+	from := now.Add(-lookback)
+	if !last.IsZero() && last.Before(from) {
+		from = last
+	}
+	from = from.Truncate(time.Hour)
+	end := now.Truncate(time.Hour).Add(time.Hour)
+
+	var windows []window
+	for start := from; start.Before(end); start = start.Add(rollupChunk) {
+		to := start.Add(rollupChunk)
+		if to.After(end) {
+			to = end
+		}
+		windows = append(windows, window{from: start, to: to})
+	}
+	return windows
+}
+
+// truncateDay returns the start of t's UTC day
+func truncateDay(t time.Time) time.Time {
+	return t.UTC().Truncate(24 * time.Hour)
+}
+
+// refreshHours rebuilds the hourly buckets of w from audit_logs
+func refreshHours(ctx context.Context, tx *sql.Tx, w window) error {
+	statements := []string{
+		`DELETE FROM audit_rollups WHERE granularity = 'hour' AND bucket >= $1 AND bucket < $2`,
+		`DELETE FROM audit_policy_rollups WHERE granularity = 'hour' AND bucket >= $1 AND bucket < $2`,
+		`INSERT INTO audit_rollups (granularity, bucket, client_id, action, requests, latency_ms_total)
+		 SELECT 'hour', date_trunc('hour', created_at)::timestamptz, COALESCE(client_id, ''),
+		        COALESCE(action_taken, ''), COUNT(*), COALESCE(SUM(latency_ms), 0)
+		 FROM audit_logs
+		 WHERE created_at >= $1::timestamptz AND created_at < $2::timestamptz
+		 GROUP BY 2, 3, 4`,
+		`INSERT INTO audit_policy_rollups (granularity, bucket, policy_id, client_id, action, hits)
+		 SELECT 'hour', date_trunc('hour', created_at)::timestamptz, policy_id, COALESCE(client_id, ''),
+		        COALESCE(action_taken, ''), COUNT(*)
+		 FROM audit_logs, unnest(policies_triggered) AS policy_id
+		 WHERE created_at >= $1::timestamptz AND created_at < $2::timestamptz
+		 GROUP BY 2, 3, 4, 5`,
+	}
+	return execAll(ctx, tx, statements, w)
+}
+
+// refreshDays rebuilds the daily buckets of w from the hourly ones
+func refreshDays(ctx context.Context, tx *sql.Tx, w window) error {
+	statements := []string{
+		`DELETE FROM audit_rollups WHERE granularity = 'day' AND bucket >= $1 AND bucket < $2`,
+		`DELETE FROM audit_policy_rollups WHERE granularity = 'day' AND bucket >= $1 AND bucket < $2`,
+		`INSERT INTO audit_rollups (granularity, bucket, client_id, action, requests, latency_ms_total)
+		 SELECT 'day', date_trunc('day', bucket, 'UTC'), client_id, action, SUM(requests), SUM(latency_ms_total)
+		 FROM audit_rollups
+		 WHERE granularity = 'hour' AND bucket >= $1 AND bucket < $2
+		 GROUP BY 2, 3, 4`,
+		`INSERT INTO audit_policy_rollups (granularity, bucket, policy_id, client_id, action, hits)
+		 SELECT 'day', date_trunc('day', bucket, 'UTC'), policy_id, client_id, action, SUM(hits)
+		 FROM audit_policy_rollups
+		 WHERE granularity = 'hour' AND bucket >= $1 AND bucket < $2
+		 GROUP BY 2, 3, 4, 5`,
+	}
+	return execAll(ctx, tx, statements, w)
+}
+
+// execAll runs statements parameterized by a window's bounds
+func execAll(ctx context.Context, tx *sql.Tx, statements []string, w window) error {
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt, w.from, w.to); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EraseClient deletes a client's roll-ups, after its audit entries were erased
+func (r *Rollups) EraseClient(ctx context.Context, clientID string) error {
+	if r == nil || clientID == "" {
+		return nil
+	}
+	return r.breaker.Do(func() error {
+		for _, table := range []string{"audit_rollups", "audit_policy_rollups"} {
+			if _, err := r.db.ExecContext(ctx, `DELETE FROM `+table+` WHERE client_id = $1`, clientID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// RefreshedAt returns when the roll-ups were last refreshed (nil before the first refresh)
+func (r *Rollups) RefreshedAt(ctx context.Context) (*time.Time, error) {
+	var refreshed sql.NullTime
+	err := r.breaker.Do(func() error {
+		err := r.db.QueryRowContext(ctx, `SELECT refreshed_at FROM audit_rollup_state WHERE name = 'audit'`).Scan(&refreshed)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		return err
+	})
+	if err != nil || !refreshed.Valid {
+		return nil, err
+	}
+	return &refreshed.Time, nil
+}
+
+// Stats returns the decision counts per client and action in filter's buckets,
+// oldest first
+func (r *Rollups) Stats(ctx context.Context, filter models.StatsFilter) ([]models.StatsBucket, error) {
+	query := `
+		SELECT bucket, client_id, action, requests, latency_ms_total
+		FROM audit_rollups
+		WHERE granularity = $1 AND bucket >= $2 AND bucket < $3
+		  AND ($4 = '' OR client_id = $4)
+		  AND ($5 = '' OR action = $5)
+		ORDER BY bucket, client_id, action
+		LIMIT $6
+	`
+
+	buckets := make([]models.StatsBucket, 0)
+	err := r.breaker.Do(func() error {
+		rows, err := r.db.QueryContext(ctx, query, filter.Granularity, filter.Since, filter.Until,
+			filter.ClientID, filter.Action, statsLimit(filter.Limit))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		buckets = buckets[:0]
+		for rows.Next() {
+			var b models.StatsBucket
+			var latency int64
+			if err := rows.Scan(&b.Bucket, &b.ClientID, &b.Action, &b.Requests, &latency); err != nil {
+				return err
+			}
+			if b.Requests > 0 {
+				b.AvgLatencyMs = float64(latency) / float64(b.Requests)
+			}
+			buckets = append(buckets, b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit roll-ups: %w", err)
+	}
+	return buckets, nil
+}
+
+// PolicyStats returns how often each policy triggered per client and action in
+// filter's buckets, oldest first
+func (r *Rollups) PolicyStats(ctx context.Context, filter models.StatsFilter) ([]models.PolicyStatsBucket, error) {
+	query := `
+		SELECT r.bucket, r.policy_id, COALESCE(p.name, ''), r.client_id, r.action, r.hits
+		FROM audit_policy_rollups r
+		LEFT JOIN policies p ON p.id = r.policy_id
+		WHERE r.granularity = $1 AND r.bucket >= $2 AND r.bucket < $3
+		  AND ($4 = '' OR r.client_id = $4)
+		  AND ($5 = '' OR r.action = $5)
+		  AND ($6::uuid IS NULL OR r.policy_id = $6)
+		ORDER BY r.bucket, r.hits DESC, r.policy_id, r.client_id, r.action
+		LIMIT $7
+	`
+	var policyID *uuid.UUID
+	if filter.PolicyID != uuid.Nil {
+		policyID = &filter.PolicyID
+	}
+
+	buckets := make([]models.PolicyStatsBucket, 0)
+	err := r.breaker.Do(func() error {
+		rows, err := r.db.QueryContext(ctx, query, filter.Granularity, filter.Since, filter.Until,
+			filter.ClientID, filter.Action, policyID, statsLimit(filter.Limit))
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		buckets = buckets[:0]
+		for rows.Next() {
+			var b models.PolicyStatsBucket
+			if err := rows.Scan(&b.Bucket, &b.PolicyID, &b.PolicyName, &b.ClientID, &b.Action, &b.Hits); err != nil {
+				return err
+			}
+			buckets = append(buckets, b)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query policy roll-ups: %w", err)
+	}
+	return buckets, nil
+}
+
+// statsLimit clamps a requested bucket count
+func statsLimit(limit int) int {
+	if limit <= 0 || limit > maxStatsLimit {
+		return maxStatsLimit
+	}
+	return limit
+}
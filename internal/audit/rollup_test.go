@@ -0,0 +1,44 @@
+package audit
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRefreshWindows(t *testing.T) {
+	now := time.Date(2026, 3, 10, 14, 25, 0, 0, time.UTC)
+	hour := func(day, h int) time.Time { return time.Date(2026, 3, day, h, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name string
+		last time.Time
+		want []window
+	}{
+		{"nothing to backfill", time.Time{}, []window{{hour(10, 11), hour(10, 15)}}},
+		{"recent refresh keeps the lookback", hour(10, 14), []window{{hour(10, 11), hour(10, 15)}}},
+		{"catches up in daily chunks", hour(8, 20).Add(30 * time.Minute), []window{
+			{hour(8, 20), hour(9, 20)},
+			{hour(9, 20), hour(10, 15)},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := refreshWindows(tt.last, now, 3*time.Hour)
+			if len(got) != len(tt.want) {
+				t.Fatalf("refreshWindows() = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if !got[i].from.Equal(tt.want[i].from) || !got[i].to.Equal(tt.want[i].to) {
+					t.Errorf("window %d = %v, want %v", i, got[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTruncateDay(t *testing.T) {
+	local := time.Date(2026, 3, 10, 1, 30, 0, 0, time.FixedZone("CET", 3600))
+	if got, want := truncateDay(local), time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("truncateDay() = %v, want %v (the UTC day)", got, want)
+	}
+}
@@ -0,0 +1,282 @@
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// walSuffix names WAL segment files; names sort in write order
+const walSuffix = ".wal"
+
+// ErrWALFull is returned when spilling an entry would exceed the WAL's size bound
+var ErrWALFull = errors.New("audit WAL is full")
+
+// WAL is a bounded write-ahead log on local disk for audit entries that neither
+// Redis nor Postgres accepted. Entries are appended as JSON lines to segment files
+// and replayed in order once storage recovers; segments left by a previous process
+// are replayed too, so a restart during an incident loses nothing
+type WAL struct {
+	dir      string
+	maxBytes int64
+
+	replayMu sync.Mutex // Serializes Replay and Purge, which rewrite sealed segments
+
+	mu      sync.Mutex // Protects the fields below
+	active  *os.File   // Segment receiving appends; nil until the next spill
+	last    int64      // Timestamp naming the newest segment
+	size    int64      // Bytes across all segments
+	entries int64      // Entries across all segments
+}
+
+// OpenWAL opens (creating if needed) the WAL in dir, bounded to maxBytes on disk
+func OpenWAL(dir string, maxBytes int64) (*WAL, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create audit WAL directory: %w", err)
+	}
+	w := &WAL{dir: dir, maxBytes: maxBytes}
+	segments, err := w.segments()
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range segments {
+		lines, err := readSegment(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, line := range lines {
+			w.size += int64(len(line)) + 1
+		}
+		w.entries += int64(len(lines))
+	}
+	metrics.AuditWALEntries.Set(float64(w.entries))
+	return w, nil
+}
+
+// Append durably writes an entry, failing with ErrWALFull past the size bound
+func (w *WAL) Append(entry models.AuditLog) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit log: %w", err)
+	}
+	data = append(data, '\n')
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.maxBytes > 0 && w.size+int64(len(data)) > w.maxBytes {
+		return ErrWALFull
+	}
+	if w.active == nil {
+		// Timestamps name segments; bump past the last one so names stay unique and ordered
+		w.last = max(time.Now().UnixNano(), w.last+1)
+		path := filepath.Join(w.dir, fmt.Sprintf("%020d%s", w.last, walSuffix))
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY|os.O_APPEND, 0o600)
+		if err != nil {
+			return fmt.Errorf("failed to create audit WAL segment: %w", err)
+		}
+		w.active = f
+	}
+	if _, err := w.active.Write(data); err != nil {
+		return fmt.Errorf("failed to write audit WAL: %w", err)
+	}
+	if err := w.active.Sync(); err != nil {
+		return fmt.Errorf("failed to sync audit WAL: %w", err)
+	}
+	w.size += int64(len(data))
+	w.entries++
+	metrics.AuditWALEntries.Set(float64(w.entries))
+	return nil
+}
+
+// Len returns the number of entries waiting to be replayed
+func (w *WAL) Len() int64 {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.entries
+}
+
+// Replay hands entries to write, oldest first, removing each one write accepts.
+// It stops at the first error, keeping that entry and everything after it
+func (w *WAL) Replay(write func(models.AuditLog) error) (int, error) {
+	w.replayMu.Lock()
+	defer w.replayMu.Unlock()
+
+	segments, err := w.seal()
+	if err != nil {
+		return 0, err
+	}
+	replayed := 0
+	for _, path := range segments {
+		lines, err := readSegment(path)
+		if err != nil {
+			return replayed, err
+		}
+		for i, line := range lines {
+			var entry models.AuditLog
+			if err := json.Unmarshal(line, &entry); err != nil {
+				// A torn write from a crash; nothing left to recover
+				slog.Warn("Skipping corrupt audit WAL entry", "file", filepath.Base(path), "error", err)
+				metrics.AuditWALTotal.WithLabelValues("corrupt").Inc()
+				w.consumed(line)
+				continue
+			}
+			if err := write(entry); err != nil {
+				if rewriteErr := writeSegment(path, lines[i:]); rewriteErr != nil {
+					return replayed, rewriteErr
+				}
+				return replayed, err
+			}
+			replayed++
+			metrics.AuditWALTotal.WithLabelValues("replayed").Inc()
+			w.consumed(line)
+		}
+		if err := os.Remove(path); err != nil {
+			return replayed, fmt.Errorf("failed to remove replayed audit WAL segment: %w", err)
+		}
+	}
+	return replayed, nil
+}
+
+// Purge removes entries matching the predicate, so deleted data isn't replayed later
+func (w *WAL) Purge(match func(models.AuditLog) bool) (int64, error) {
+	w.replayMu.Lock()
+	defer w.replayMu.Unlock()
+
+	segments, err := w.seal()
+	if err != nil {
+		return 0, err
+	}
+	var removed int64
+	for _, path := range segments {
+		lines, err := readSegment(path)
+		if err != nil {
+			return removed, err
+		}
+		kept := lines[:0:0]
+		for _, line := range lines {
+			var entry models.AuditLog
+			if json.Unmarshal(line, &entry) == nil && match(entry) {
+				removed++
+				w.consumed(line)
+				continue
+			}
+			kept = append(kept, line)
+		}
+		if len(kept) == len(lines) {
+			continue
+		}
+		if err := writeSegment(path, kept); err != nil {
+			return removed, err
+		}
+	}
+	return removed, nil
+}
+
+// Close closes the active segment; its entries are replayed by the next process
+func (w *WAL) Close() error {
+	_, err := w.seal()
+	return err
+}
+
+// seal closes the active segment so it can be rewritten, returning every segment
+func (w *WAL) seal() ([]string, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.active != nil {
+		err := w.active.Close()
+		w.active = nil
+		if err != nil {
+			return nil, fmt.Errorf("failed to close audit WAL segment: %w", err)
+		}
+	}
+	return w.segments()
+}
+
+// segments lists the segment files, oldest first
+func (w *WAL) segments() ([]string, error) {
+	files, err := os.ReadDir(w.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit WAL directory: %w", err)
+	}
+	var paths []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), walSuffix) {
+			paths = append(paths, filepath.Join(w.dir, f.Name()))
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// consumed accounts for an entry that left the WAL
+func (w *WAL) consumed(line []byte) {
+	w.mu.Lock()
+	w.size -= int64(len(line)) + 1
+	w.entries--
+	metrics.AuditWALEntries.Set(float64(w.entries))
+	w.mu.Unlock()
+}
+
+// readSegment returns the non-empty lines of a segment
+func readSegment(path string) ([][]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read audit WAL segment: %w", err)
+	}
+	var lines [][]byte
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(data)+1)
+	for scanner.Scan() {
+		if line := scanner.Bytes(); len(line) > 0 {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+// writeSegment atomically replaces a segment with lines, removing it when empty
+func writeSegment(path string, lines [][]byte) error {
+	if len(lines) == 0 {
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("failed to remove audit WAL segment: %w", err)
+		}
+		return nil
+	}
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to rewrite audit WAL segment: %w", err)
+	}
+	buf := bufio.NewWriter(f)
+	for _, line := range lines {
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	if err := buf.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to rewrite audit WAL segment: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to sync audit WAL segment: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to rewrite audit WAL segment: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("failed to replace audit WAL segment: %w", err)
+	}
+	return nil
+}
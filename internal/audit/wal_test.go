@@ -0,0 +1,144 @@
+package audit
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func walEntry(clientID string) models.AuditLog {
+	return models.AuditLog{RequestID: uuid.New(), ClientID: clientID, ActionTaken: "allow"}
+}
+
+func TestWAL_ReplayStopsAtFailureAndSurvivesRestart(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+	for _, client := range []string{"a", "b", "c"} {
+		if err := w.Append(walEntry(client)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+
+	// Storage accepts one entry, then goes down again
+	var written []string
+	down := errors.New("storage down")
+	replayed, err := w.Replay(func(e models.AuditLog) error {
+		if len(written) == 1 {
+			return down
+		}
+		written = append(written, e.ClientID)
+		return nil
+	})
+	if !errors.Is(err, down) || replayed != 1 || w.Len() != 2 {
+		t.Fatalf("Replay() = %d, %v with %d left, want 1, storage down with 2 left", replayed, err, w.Len())
+	}
+
+	// A spill after the failed replay lands in a newer segment
+	if err := w.Append(walEntry("d")); err != nil {
+		t.Fatalf("Append() error = %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	// The next process replays what is left, oldest first
+	w, err = OpenWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenWAL() after restart error = %v", err)
+	}
+	if w.Len() != 3 {
+		t.Fatalf("Len() after restart = %d, want 3", w.Len())
+	}
+	replayed, err = w.Replay(func(e models.AuditLog) error {
+		written = append(written, e.ClientID)
+		return nil
+	})
+	if err != nil || replayed != 3 {
+		t.Fatalf("Replay() = %d, %v, want 3, nil", replayed, err)
+	}
+	want := []string{"a", "b", "c", "d"}
+	for i := range want {
+		if written[i] != want[i] {
+			t.Fatalf("replay order = %v, want %v", written, want)
+		}
+	}
+	if files, _ := os.ReadDir(dir); len(files) != 0 || w.Len() != 0 {
+		t.Errorf("WAL not empty after replay: %d files, %d entries", len(files), w.Len())
+	}
+}
+
+func TestWAL_Bounded(t *testing.T) {
+	w, err := OpenWAL(t.TempDir(), 300)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+	var appended int
+	for {
+		err := w.Append(walEntry("client"))
+		if errors.Is(err, ErrWALFull) {
+			break
+		}
+		if err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+		appended++
+	}
+	if appended == 0 || w.Len() != int64(appended) {
+		t.Fatalf("appended %d, Len() = %d", appended, w.Len())
+	}
+
+	// Replaying frees space for new spills
+	if _, err := w.Replay(func(models.AuditLog) error { return nil }); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if err := w.Append(walEntry("client")); err != nil {
+		t.Errorf("Append() after replay error = %v", err)
+	}
+}
+
+func TestWAL_PurgeAndCorruptEntries(t *testing.T) {
+	dir := t.TempDir()
+	w, err := OpenWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+	for _, client := range []string{"erase-me", "keep", "erase-me"} {
+		if err := w.Append(walEntry(client)); err != nil {
+			t.Fatalf("Append() error = %v", err)
+		}
+	}
+	w.Close()
+
+	// A crash mid-write leaves a torn line behind
+	segment := filepath.Join(dir, "99999999999999999999"+walSuffix)
+	if err := os.WriteFile(segment, []byte(`{"request_id": "torn`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	w, err = OpenWAL(dir, 0)
+	if err != nil {
+		t.Fatalf("OpenWAL() error = %v", err)
+	}
+
+	removed, err := w.Purge(func(e models.AuditLog) bool { return e.ClientID == "erase-me" })
+	if err != nil || removed != 2 {
+		t.Fatalf("Purge() = %d, %v, want 2, nil", removed, err)
+	}
+
+	var written []string
+	if _, err := w.Replay(func(e models.AuditLog) error {
+		written = append(written, e.ClientID)
+		return nil
+	}); err != nil {
+		t.Fatalf("Replay() error = %v", err)
+	}
+	if len(written) != 1 || written[0] != "keep" || w.Len() != 0 {
+		t.Errorf("replayed %v with %d left, want [keep] and an empty WAL", written, w.Len())
+	}
+}
@@ -0,0 +1,102 @@
+// Package benign holds a corpus of ordinary prompts that no policy should flag.
+// Policy lints and diffs evaluate candidate policies against it to estimate
+// their false-positive rate before they are enabled
+package benign
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Corpus sources
+const (
+	SourceBundled  = "bundled"
+	SourceUploaded = "uploaded"
+)
+
+// Upload limits
+const (
+	MaxPrompts      = 10000
+	MaxPromptLength = 16384
+)
+
+//go:embed benign.txt
+var bundled string
+
+// Corpus is the set of benign prompts estimates are computed on
+type Corpus struct {
+	Source    string
+	Prompts   []string
+	UpdatedAt *time.Time // When an uploaded corpus was last replaced
+}
+
+// Bundled returns the corpus shipped with the gateway
+func Bundled() Corpus {
+	var prompts []string
+	for _, line := range strings.Split(bundled, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			prompts = append(prompts, line)
+		}
+	}
+	return Corpus{Source: SourceBundled, Prompts: prompts}
+}
+
+// Info describes the corpus without its prompts
+func (c Corpus) Info() models.BenignCorpusInfo {
+	return models.BenignCorpusInfo{Source: c.Source, Size: len(c.Prompts), UpdatedAt: c.UpdatedAt}
+}
+
+// Normalize trims prompts, drops blank and repeated ones and enforces the upload limits
+func Normalize(prompts []string) ([]string, error) {
+	seen := make(map[string]bool, len(prompts))
+	out := make([]string, 0, len(prompts))
+	for _, p := range prompts {
+		p = strings.TrimSpace(p)
+		if p == "" || seen[p] {
+			continue
+		}
+		if len(p) > MaxPromptLength {
+			return nil, fmt.Errorf("prompts must be at most %d bytes", MaxPromptLength)
+		}
+		seen[p] = true
+		out = append(out, p)
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("prompts is required")
+	}
+	if len(out) > MaxPrompts {
+		return nil, fmt.Errorf("a corpus may have at most %d prompts", MaxPrompts)
+	}
+	return out, nil
+}
+
+// Per10k scales a match count on size prompts to matches per 10,000 prompts
+func Per10k(matches, size int) float64 {
+	if size == 0 {
+		return 0
+	}
+	return 10000 * float64(matches) / float64(size)
+}
+
+// Match evaluates p on every prompt of the corpus and returns the prompts it
+// matched; prompts the policy fails to evaluate count as non-matches
+func (c Corpus) Match(ctx context.Context, a *analyzer.Analyzer, p models.Policy) ([]string, error) {
+	policies := []models.Policy{p}
+	var matched []string
+	for _, prompt := range c.Prompts {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		matches, err := a.Analyze(ctx, prompt, policies)
+		if err == nil && len(matches) > 0 {
+			matched = append(matched, prompt)
+		}
+	}
+	return matched, nil
+}
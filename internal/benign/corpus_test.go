@@ -0,0 +1,48 @@
+package benign
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestBundled(t *testing.T) {
+	c := Bundled()
+	if c.Source != SourceBundled || len(c.Prompts) < 100 {
+		t.Fatalf("Bundled() = %s with %d prompts, want at least 100 bundled prompts", c.Source, len(c.Prompts))
+	}
+	if _, err := Normalize(c.Prompts); err != nil {
+		t.Errorf("bundled corpus fails upload validation: %v", err)
+	}
+}
+
+func TestNormalize(t *testing.T) {
+	got, err := Normalize([]string{" hello ", "", "hello", "world"})
+	if err != nil || strings.Join(got, "|") != "hello|world" {
+		t.Errorf("Normalize() = %q, %v, want [hello world]", got, err)
+	}
+	if _, err := Normalize([]string{" ", ""}); err == nil {
+		t.Error("Normalize() of blank prompts error = nil, want error")
+	}
+	if _, err := Normalize([]string{strings.Repeat("a", MaxPromptLength+1)}); err == nil {
+		t.Error("Normalize() of an oversized prompt error = nil, want error")
+	}
+}
+
+func TestCorpus_Match(t *testing.T) {
+	c := Corpus{Source: SourceUploaded, Prompts: []string{"How do I bake bread?", "Bread or rice?", "Plan a trip"}}
+	p := models.Policy{Name: "bread", PatternType: "keyword", PatternValue: "bread", Severity: "low", Action: "log", Enabled: true}
+	matched, err := c.Match(context.Background(), analyzer.NewAnalyzer(nil), p)
+	if err != nil || len(matched) != 2 {
+		t.Fatalf("Match() = %q, %v, want 2 prompts", matched, err)
+	}
+	if rate := Per10k(len(matched), len(c.Prompts)); int(rate) != 6666 {
+		t.Errorf("Per10k() = %v, want 6666.67", rate)
+	}
+	if Per10k(0, 0) != 0 {
+		t.Error("Per10k() of an empty corpus != 0")
+	}
+}
@@ -0,0 +1,160 @@
+package benign
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/prompt-gateway/internal/envelope"
+)
+
+// ErrNotUploaded is returned when no corpus was uploaded, so the bundled one applies
+var ErrNotUploaded = errors.New("no benign corpus uploaded")
+
+// ErrNoKeyring is returned when loading a sealed corpus without a master key
+var ErrNoKeyring = errors.New("benign corpus is encrypted but CONTENT_MASTER_KEY is not configured")
+
+// Repository stores the uploaded corpus of the shared storage or of one isolated
+// tenant, shared by every replica
+type Repository struct {
+	db      *sql.DB
+	keyring *envelope.Keyring // Optional; nil stores prompts in plaintext
+	tenant  string            // Tenant whose data key seals the corpus ("" = shared)
+}
+
+// NewRepository creates a new benign corpus Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// SetKeyring seals uploaded prompts with tenant's data key ("" = shared)
+func (r *Repository) SetKeyring(keyring *envelope.Keyring, tenant string) {
+	r.keyring = keyring
+	r.tenant = tenant
+}
+
+// Load returns the uploaded corpus, opening it when it was sealed
+func (r *Repository) Load(ctx context.Context) (Corpus, error) {
+	c := Corpus{Source: SourceUploaded}
+	var updatedAt time.Time
+	var keyID uuid.NullUUID
+	var sealed []byte
+	err := r.db.QueryRowContext(ctx, `SELECT prompts, key_id, sealed_prompts, updated_at FROM benign_corpus`).
+		Scan(pq.Array(&c.Prompts), &keyID, &sealed, &updatedAt)
+	if err == sql.ErrNoRows {
+		return Corpus{}, ErrNotUploaded
+	}
+	if err != nil {
+		return Corpus{}, fmt.Errorf("failed to load benign corpus: %w", err)
+	}
+	if keyID.Valid {
+		if c.Prompts, err = r.open(ctx, keyID.UUID, sealed); err != nil {
+			return Corpus{}, err
+		}
+	}
+	c.UpdatedAt = &updatedAt
+	return c, nil
+}
+
+// Replace stores prompts as the corpus, replacing any earlier upload
+func (r *Repository) Replace(ctx context.Context, prompts []string) (Corpus, error) {
+	plain, keyID, sealed, err := r.seal(ctx, prompts)
+	if err != nil {
+		return Corpus{}, err
+	}
+
+	query := `
+		INSERT INTO benign_corpus (id, prompts, key_id, sealed_prompts)
+		VALUES (TRUE, $1, $2, $3)
+		ON CONFLICT (id) DO UPDATE SET
+			prompts = $1,
+			key_id = $2,
+			sealed_prompts = $3,
+			updated_at = NOW()
+		RETURNING updated_at
+	`
+
+	var updatedAt time.Time
+	if err := r.db.QueryRowContext(ctx, query, pq.Array(plain), keyID, sealed).Scan(&updatedAt); err != nil {
+		return Corpus{}, fmt.Errorf("failed to save benign corpus: %w", err)
+	}
+	return Corpus{Source: SourceUploaded, Prompts: prompts, UpdatedAt: &updatedAt}, nil
+}
+
+// Reseal seals the uploaded corpus again with its tenant's active data key,
+// after a key rotation or to encrypt a corpus uploaded before a master key was
+// configured. It reports whether a corpus was resealed
+func (r *Repository) Reseal(ctx context.Context) (bool, error) {
+	if r.keyring == nil {
+		return false, ErrNoKeyring
+	}
+	c, err := r.Load(ctx)
+	if errors.Is(err, ErrNotUploaded) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	_, keyID, sealed, err := r.seal(ctx, c.Prompts)
+	if err != nil {
+		return false, err
+	}
+	if _, err := r.db.ExecContext(ctx,
+		`UPDATE benign_corpus SET prompts = '{}', key_id = $1, sealed_prompts = $2`, keyID, sealed,
+	); err != nil {
+		return false, fmt.Errorf("failed to reseal benign corpus: %w", err)
+	}
+	return true, nil
+}
+
+// Delete removes the uploaded corpus, reverting to the bundled one
+func (r *Repository) Delete(ctx context.Context) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM benign_corpus`)
+	if err != nil {
+		return fmt.Errorf("failed to delete benign corpus: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotUploaded
+	}
+	return nil
+}
+
+// seal returns the columns storing prompts: the prompts themselves without a
+// keyring, otherwise no plaintext and the prompts sealed under the tenant's
+// data key
+func (r *Repository) seal(ctx context.Context, prompts []string) ([]string, uuid.NullUUID, []byte, error) {
+	if r.keyring == nil {
+		return prompts, uuid.NullUUID{}, nil, nil
+	}
+	data, err := json.Marshal(prompts)
+	if err != nil {
+		return nil, uuid.NullUUID{}, nil, err
+	}
+	keyID, sealed, err := r.keyring.Seal(ctx, r.tenant, data)
+	if err != nil {
+		return nil, uuid.NullUUID{}, nil, fmt.Errorf("failed to seal benign corpus: %w", err)
+	}
+	return []string{}, uuid.NullUUID{UUID: keyID, Valid: true}, sealed, nil
+}
+
+// open decrypts a sealed corpus
+func (r *Repository) open(ctx context.Context, keyID uuid.UUID, sealed []byte) ([]string, error) {
+	if r.keyring == nil {
+		return nil, ErrNoKeyring
+	}
+	data, err := r.keyring.Open(ctx, keyID, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open benign corpus sealed with data key %s: %w", keyID, err)
+	}
+	var prompts []string
+	if err := json.Unmarshal(data, &prompts); err != nil {
+		return nil, fmt.Errorf("failed to decode benign corpus: %w", err)
+	}
+	return prompts, nil
+}
@@ -0,0 +1,188 @@
+// Package breaker implements health-aware circuit breakers for backing stores
+// so transient Postgres/Redis outages degrade gracefully instead of cascading
+package breaker
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// ErrOpen is returned without calling the dependency while the breaker is open
+var ErrOpen = errors.New("circuit breaker open")
+
+// State of a breaker, exported as the gateway_circuit_breaker_state gauge value
+type State int
+
+const (
+	Closed   State = iota // Calls flow normally
+	HalfOpen              // Cooldown elapsed, trial calls decide whether to close
+	Open                  // Calls are rejected with ErrOpen
+)
+
+// String returns the state name used in logs
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case HalfOpen:
+		return "half_open"
+	default:
+		return "open"
+	}
+}
+
+// Breaker opens after threshold consecutive failures and lets trial calls
+// through once cooldown has elapsed. A nil *Breaker is valid and never trips.
+type Breaker struct {
+	name      string
+	threshold int
+	cooldown  time.Duration
+
+	mu       sync.Mutex // Protects the fields below
+	state    State
+	failures int
+	openedAt time.Time
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	now      func() time.Time
+	fault    func() error // Optional fault injection (chaos mode)
+}
+
+// New creates a closed breaker
+func New(name string, threshold int, cooldown time.Duration) *Breaker {
+	b := &Breaker{
+		name:      name,
+		threshold: threshold,
+		cooldown:  cooldown,
+		stopCh:    make(chan struct{}),
+		now:       time.Now,
+	}
+	metrics.CircuitBreakerState.WithLabelValues(name).Set(float64(Closed))
+	return b
+}
+
+// Name returns the dependency name the breaker guards
+func (b *Breaker) Name() string {
+	return b.name
+}
+
+// Allow reports whether a call may proceed
+func (b *Breaker) Allow() error {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == Open {
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			metrics.CircuitBreakerRejections.WithLabelValues(b.name).Inc()
+			return ErrOpen
+		}
+		b.setState(HalfOpen)
+	}
+	return nil
+}
+
+// Record feeds the outcome of a call; nil means the dependency is healthy
+// Callers should pass nil for "expected" errors like sql.ErrNoRows or redis.Nil
+func (b *Breaker) Record(err error) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		b.failures = 0
+		if b.state != Closed {
+			slog.Info("Circuit closed, connection recovered", "dependency", b.name)
+			b.setState(Closed)
+		}
+		return
+	}
+
+	b.failures++
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		slog.Warn("Circuit opened", "dependency", b.name, "failures", b.failures, "error", err)
+		b.openedAt = b.now()
+		b.setState(Open)
+	}
+}
+
+// SetFaultInjector makes Do fail with the injector's error instead of calling
+// the dependency whenever it returns one. Must be called before the breaker is used
+func (b *Breaker) SetFaultInjector(fault func() error) {
+	b.fault = fault
+}
+
+// Do runs fn if the breaker allows it and records the result
+func (b *Breaker) Do(fn func() error) error {
+	if err := b.Allow(); err != nil {
+		return err
+	}
+	if b != nil && b.fault != nil {
+		if err := b.fault(); err != nil {
+			b.Record(err)
+			return err
+		}
+	}
+	err := fn()
+	b.Record(err)
+	return err
+}
+
+// State returns the current state
+func (b *Breaker) State() State {
+	if b == nil {
+		return Closed
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.state
+}
+
+// setState transitions and updates the gauge; caller holds mu
+func (b *Breaker) setState(state State) {
+	b.state = state
+	metrics.CircuitBreakerState.WithLabelValues(b.name).Set(float64(state))
+}
+
+// StartProbe pings the dependency every interval while the breaker is not
+// closed, closing it as soon as a ping succeeds (background reconnection)
+func (b *Breaker) StartProbe(interval time.Duration, ping func(ctx context.Context) error) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if b.State() == Closed {
+					continue
+				}
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				err := ping(ctx)
+				cancel()
+				if err == nil {
+					b.Record(nil)
+				}
+			case <-b.stopCh:
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the background probe
+func (b *Breaker) Stop() {
+	b.stopOnce.Do(func() {
+		close(b.stopCh)
+	})
+}
@@ -0,0 +1,66 @@
+package breaker
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestBreaker_OpensAndRecovers(t *testing.T) {
+	b := New("test", 3, time.Minute)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return clock }
+
+	failure := errors.New("connection refused")
+	for i := 0; i < 3; i++ {
+		if err := b.Do(func() error { return failure }); !errors.Is(err, failure) {
+			t.Fatalf("call %d error = %v, want dependency error", i, err)
+		}
+	}
+	if b.State() != Open {
+		t.Fatalf("state = %v after threshold failures, want open", b.State())
+	}
+
+	called := false
+	if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, ErrOpen) || called {
+		t.Fatalf("open breaker error = %v (called: %v), want ErrOpen without calling", err, called)
+	}
+
+	// After cooldown a failing trial re-opens immediately
+	clock = clock.Add(time.Minute)
+	b.Do(func() error { return failure })
+	if b.State() != Open {
+		t.Fatalf("state = %v after failed trial, want open", b.State())
+	}
+
+	clock = clock.Add(time.Minute)
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Fatalf("trial call error = %v", err)
+	}
+	if b.State() != Closed {
+		t.Errorf("state = %v after successful trial, want closed", b.State())
+	}
+}
+
+func TestBreaker_NilIsPassThrough(t *testing.T) {
+	var b *Breaker
+	if err := b.Do(func() error { return nil }); err != nil {
+		t.Errorf("nil breaker Do() error = %v", err)
+	}
+}
+
+func TestBreaker_FaultInjector(t *testing.T) {
+	b := New("test", 2, time.Minute)
+	injected := errors.New("injected")
+	b.SetFaultInjector(func() error { return injected })
+
+	for i := 0; i < 2; i++ {
+		called := false
+		if err := b.Do(func() error { called = true; return nil }); !errors.Is(err, injected) || called {
+			t.Fatalf("call %d error = %v (called: %v), want injected fault without calling", i, err, called)
+		}
+	}
+	if b.State() != Open {
+		t.Errorf("state = %v after injected failures, want open", b.State())
+	}
+}
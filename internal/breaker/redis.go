@@ -0,0 +1,86 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisHook guards every command of a go-redis client with a breaker
+// Register it with rdb.AddHook so all Redis access shares one breaker
+type RedisHook struct {
+	breaker *Breaker
+}
+
+// NewRedisHook creates a go-redis hook backed by b
+func NewRedisHook(b *Breaker) *RedisHook {
+	return &RedisHook{breaker: b}
+}
+
+// DialHook implements redis.Hook
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		err := next(ctx, cmd)
+		h.record(ctx, err)
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.breaker.Allow(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		err := next(ctx, cmds)
+		h.record(ctx, err)
+		return err
+	}
+}
+
+// record feeds the outcome of a command to the breaker. A command the caller
+// gave up on (client disconnect, short request deadline) says nothing about
+// Redis and is not recorded at all: a success would close a half-open breaker
+// that hasn't been tried. go-redis' own dial/read/write timeouts fail the
+// command as net errors while ctx is still live, so they do count
+func (h *RedisHook) record(ctx context.Context, err error) {
+	if err != nil && ctx.Err() != nil {
+		return
+	}
+	h.breaker.Record(connectionError(err))
+}
+
+// connectionError filters out replies that don't indicate an unhealthy server
+func connectionError(err error) error {
+	if err == nil || errors.Is(err, redis.Nil) {
+		return nil
+	}
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		// Cancellation by a context other than the command's (e.g. a pool wait)
+		return nil
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) || errors.Is(err, redis.ErrClosed) {
+		return err
+	}
+	// Server-side errors (WRONGTYPE, ...) mean Redis answered
+	var redisErr redis.Error
+	if errors.As(err, &redisErr) {
+		return nil
+	}
+	return err
+}
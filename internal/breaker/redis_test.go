@@ -0,0 +1,71 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// timeoutError is a net.Error timing out, as go-redis' read timeout fails a command
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+var _ net.Error = timeoutError{}
+
+func TestRedisHook_CountsOnlyServerFailures(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	expired, cancelExpired := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancelExpired()
+
+	tests := []struct {
+		name     string
+		ctx      context.Context
+		err      error
+		wantOpen bool
+	}{
+		{"read timeout", context.Background(), timeoutError{}, true},
+		{"connection closed", context.Background(), redis.ErrClosed, true},
+		{"key missing", context.Background(), redis.Nil, false},
+		{"client disconnected", cancelled, context.Canceled, false},
+		{"request deadline", expired, context.DeadlineExceeded, false},
+		{"request deadline cut the read", expired, timeoutError{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := New("redis-test", 1, time.Minute)
+			process := NewRedisHook(b).ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+				return tt.err
+			})
+			process(tt.ctx, redis.NewStringCmd(tt.ctx, "get", "k"))
+			if open := b.State() == Open; open != tt.wantOpen {
+				t.Errorf("breaker open = %v, want %v", open, tt.wantOpen)
+			}
+		})
+	}
+}
+
+func TestRedisHook_CancelledTrialKeepsBreakerHalfOpen(t *testing.T) {
+	b := New("redis-test", 1, time.Minute)
+	clock := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	b.now = func() time.Time { return clock }
+	b.Record(errors.New("connection refused"))
+	clock = clock.Add(time.Minute)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	process := NewRedisHook(b).ProcessHook(func(ctx context.Context, cmd redis.Cmder) error {
+		return context.Canceled
+	})
+	process(ctx, redis.NewStringCmd(ctx, "get", "k"))
+	if b.State() != HalfOpen {
+		t.Errorf("state = %v after a cancelled trial, want half-open", b.State())
+	}
+}
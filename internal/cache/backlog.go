@@ -0,0 +1,102 @@
+package cache
+
+import (
+	"context"
+	"log/slog"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// Audit backlog levels; each speeds up the sync worker further
+const (
+	backlogNormal = iota
+	backlogElevated
+	backlogCritical
+)
+
+// backlogLevelNames are the level names used in logs and warning events
+var backlogLevelNames = [...]string{"normal", "elevated", "critical"}
+
+// defaultSyncBatchSize is the number of audit logs popped per sync at the normal level
+const defaultSyncBatchSize = 10000
+
+// backlogLevel returns the level for a queue of the given size (thresholds of 0 are
+// disabled). Levels rise as soon as a threshold is crossed but only back off once the
+// queue has drained below half the threshold that raised them, so a queue hovering
+// around a threshold doesn't flap
+func backlogLevel(current int, queue, warn, critical int64) int {
+	level := backlogNormal
+	switch {
+	case critical > 0 && queue >= critical:
+		level = backlogCritical
+	case warn > 0 && queue >= warn:
+		level = backlogElevated
+	}
+	if level >= current {
+		return level
+	}
+	threshold := warn
+	if current == backlogCritical {
+		threshold = critical
+	}
+	if queue >= threshold/2 {
+		return current
+	}
+	return level
+}
+
+// syncSettings returns the sync interval and batch size for a backlog level:
+// elevated syncs 4x as often with double batches, critical every second with 5x batches
+func syncSettings(level int, interval time.Duration) (time.Duration, int) {
+	switch level {
+	case backlogElevated:
+		return max(interval/4, time.Second), 2 * defaultSyncBatchSize
+	case backlogCritical:
+		return time.Second, 5 * defaultSyncBatchSize
+	default:
+		return interval, defaultSyncBatchSize
+	}
+}
+
+// SetBacklogPolicy accelerates the sync worker while the Redis audit queue holds at
+// least warn (elevated) or critical entries; 0 disables a threshold. onChange, when
+// non-nil, is called with the new level name and queue size on every level change
+// Must be called before Start
+func (rc *RedisCache) SetBacklogPolicy(warn, critical int64, onChange func(level string, queueSize int64)) {
+	rc.backlogWarn = warn
+	rc.backlogCrit = critical
+	rc.onBacklog = onChange
+}
+
+// adjustBacklog re-evaluates the backlog level after a sync and retunes the worker
+// Only called from the sync worker goroutine
+func (rc *RedisCache) adjustBacklog(ctx context.Context) {
+	if rc.backlogWarn <= 0 && rc.backlogCrit <= 0 {
+		return
+	}
+	queueSize, err := rc.rdb.LLen(ctx, "audit_logs:pending").Result()
+	if err != nil {
+		return
+	}
+	level := backlogLevel(rc.backlogLevel, queueSize, rc.backlogWarn, rc.backlogCrit)
+	if level == rc.backlogLevel {
+		return
+	}
+	previous := rc.backlogLevel
+	rc.backlogLevel = level
+	interval, batchSize := syncSettings(level, rc.syncInterval)
+	rc.batchSize.Store(int64(batchSize))
+	rc.syncTicker.Reset(interval)
+	metrics.AuditBacklogLevel.Set(float64(level))
+
+	name := backlogLevelNames[level]
+	if level > previous {
+		slog.Warn("Audit backlog level raised", "level", name, "queued", queueSize, "interval", interval, "batch_size", batchSize)
+	} else {
+		slog.Info("Audit backlog level lowered", "level", name, "queued", queueSize, "interval", interval, "batch_size", batchSize)
+	}
+	if rc.onBacklog != nil {
+		rc.onBacklog(name, queueSize)
+	}
+}
@@ -0,0 +1,48 @@
+package cache
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBacklogLevel(t *testing.T) {
+	tests := []struct {
+		name    string
+		current int
+		queue   int64
+		want    int
+	}{
+		{"below warn", backlogNormal, 999, backlogNormal},
+		{"warn crossed", backlogNormal, 1000, backlogElevated},
+		{"critical crossed", backlogNormal, 5000, backlogCritical},
+		{"elevated holds above half warn", backlogElevated, 600, backlogElevated},
+		{"elevated recovers", backlogElevated, 400, backlogNormal},
+		{"critical holds above half critical", backlogCritical, 3000, backlogCritical},
+		{"critical steps down", backlogCritical, 2000, backlogElevated},
+		{"critical recovers fully", backlogCritical, 10, backlogNormal},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := backlogLevel(tt.current, tt.queue, 1000, 5000); got != tt.want {
+				t.Errorf("backlogLevel() = %s, want %s", backlogLevelNames[got], backlogLevelNames[tt.want])
+			}
+		})
+	}
+
+	if got := backlogLevel(backlogNormal, 1_000_000, 0, 0); got != backlogNormal {
+		t.Errorf("backlogLevel() with thresholds disabled = %s, want normal", backlogLevelNames[got])
+	}
+}
+
+func TestSyncSettings(t *testing.T) {
+	interval, batch := syncSettings(backlogElevated, 2*time.Minute)
+	if interval != 30*time.Second || batch != 2*defaultSyncBatchSize {
+		t.Errorf("elevated = (%v, %d), want (30s, %d)", interval, batch, 2*defaultSyncBatchSize)
+	}
+	if interval, _ := syncSettings(backlogElevated, 2*time.Second); interval != time.Second {
+		t.Errorf("elevated interval = %v, want at least 1s", interval)
+	}
+	if interval, batch := syncSettings(backlogNormal, time.Minute); interval != time.Minute || batch != defaultSyncBatchSize {
+		t.Errorf("normal = (%v, %d), want unchanged", interval, batch)
+	}
+}
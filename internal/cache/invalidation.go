@@ -0,0 +1,114 @@
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// InvalidationChannel is the Redis channel replicas announce policy changes on
+const InvalidationChannel = "policy:invalidate"
+
+// invalidation is the message published after a replica changed policies
+type invalidation struct {
+	Scope  string `json:"scope"`  // "" for the shared policies, else the isolated tenant
+	Origin string `json:"origin"` // Publishing process, which already refreshed
+}
+
+// Invalidator keeps the policy caches of every replica in sync: an invalidation
+// on one replica is published on InvalidationChannel, and the other replicas
+// refresh the same cache immediately instead of at their next periodic reload.
+// Messages missed while Redis is unreachable are caught up by that reload
+type Invalidator struct {
+	rdb    *redis.Client
+	origin string
+
+	mu     sync.RWMutex // Protects caches
+	caches map[string]*PolicyCache
+
+	pubsub   *redis.PubSub
+	stopOnce sync.Once
+}
+
+// NewInvalidator creates an invalidator publishing and subscribing on rdb
+func NewInvalidator(rdb *redis.Client) *Invalidator {
+	return &Invalidator{
+		rdb:    rdb,
+		origin: uuid.NewString(),
+		caches: make(map[string]*PolicyCache),
+	}
+}
+
+// Register syncs a cache across replicas under scope ("" for the shared
+// policies, the tenant name for an isolated tenant's)
+func (inv *Invalidator) Register(scope string, pc *PolicyCache) {
+	inv.mu.Lock()
+	inv.caches[scope] = pc
+	inv.mu.Unlock()
+	pc.invalidator, pc.scope = inv, scope
+}
+
+// Start subscribes to InvalidationChannel; go-redis resubscribes after reconnects
+func (inv *Invalidator) Start(ctx context.Context) {
+	inv.pubsub = inv.rdb.Subscribe(ctx, InvalidationChannel)
+	go inv.listen(ctx, inv.pubsub.Channel())
+	slog.Info("Policy cache invalidation subscribed", "channel", InvalidationChannel)
+}
+
+func (inv *Invalidator) listen(ctx context.Context, messages <-chan *redis.Message) {
+	for msg := range messages {
+		inv.handle(ctx, msg.Payload)
+	}
+	slog.Info("Policy cache invalidation listener stopped")
+}
+
+// handle refreshes the cache another replica invalidated
+func (inv *Invalidator) handle(ctx context.Context, payload string) {
+	var msg invalidation
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		slog.Warn("Ignoring malformed policy invalidation", "payload", payload, "error", err)
+		return
+	}
+	if msg.Origin == inv.origin {
+		return
+	}
+	inv.mu.RLock()
+	pc, ok := inv.caches[msg.Scope]
+	inv.mu.RUnlock()
+	if !ok {
+		return
+	}
+	if err := pc.refresh(ctx); err != nil {
+		slog.Warn("Failed to refresh policy cache after a remote invalidation, serving stale policies", "scope", msg.Scope, "error", err)
+		return
+	}
+	slog.Info("Policy cache refreshed after a remote invalidation", "scope", msg.Scope, "policies", len(pc.Get()))
+}
+
+// publish announces that the cache of scope was invalidated; a failure only
+// delays the other replicas until their periodic reload
+func (inv *Invalidator) publish(ctx context.Context, scope string) {
+	if inv == nil {
+		return
+	}
+	payload, err := json.Marshal(invalidation{Scope: scope, Origin: inv.origin})
+	if err != nil {
+		return
+	}
+	if err := inv.rdb.Publish(ctx, InvalidationChannel, payload).Err(); err != nil {
+		slog.WarnContext(ctx, "Failed to publish policy invalidation, other replicas refresh on their next reload", "error", err)
+	}
+}
+
+// Stop unsubscribes; the listener exits once the subscription is closed
+func (inv *Invalidator) Stop() {
+	inv.stopOnce.Do(func() {
+		if inv.pubsub != nil {
+			inv.pubsub.Close()
+		}
+	})
+}
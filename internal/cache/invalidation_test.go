@@ -0,0 +1,45 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestInvalidator_Handle(t *testing.T) {
+	ctx := context.Background()
+	shared := &listStore{}
+	acme := &listStore{}
+	sharedCache, acmeCache := NewPolicyCache(shared), NewPolicyCache(acme)
+
+	inv := NewInvalidator(nil)
+	inv.Register("", sharedCache)
+	inv.Register("acme", acmeCache)
+
+	// Another replica created a policy of tenant acme
+	acme.policies = []models.Policy{{ID: uuid.New(), Name: "secret", PatternType: "keyword", PatternValue: "password", Enabled: true}}
+	shared.policies = acme.policies
+	inv.handle(ctx, `{"scope":"acme","origin":"replica-2"}`)
+	if len(acmeCache.Get()) != 1 {
+		t.Errorf("acme cache has %d policies, want the refreshed one", len(acmeCache.Get()))
+	}
+	if len(sharedCache.Get()) != 0 {
+		t.Error("an invalidation of acme must not refresh the shared cache")
+	}
+
+	// This replica's own messages were handled by Invalidate already
+	inv.handle(ctx, `{"scope":"","origin":"`+inv.origin+`"}`)
+	if len(sharedCache.Get()) != 0 {
+		t.Error("own invalidation refreshed the cache again")
+	}
+
+	inv.handle(ctx, `not json`)
+	inv.handle(ctx, `{"scope":"unknown","origin":"replica-2"}`)
+	inv.handle(ctx, `{"scope":"","origin":"replica-2"}`)
+	if len(sharedCache.Get()) != 1 {
+		t.Errorf("shared cache has %d policies, want the refreshed one", len(sharedCache.Get()))
+	}
+}
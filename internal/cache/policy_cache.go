@@ -2,47 +2,157 @@ package cache
 
 import (
 	"context"
-	"log"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"log/slog"
+	"regexp"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/breaker"
 	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/internal/tracing"
 	"github.com/prompt-gateway/pkg/models"
+	"go.opentelemetry.io/otel/attribute"
 )
 
+// refreshInterval is how often policies are reloaded from Postgres
+const refreshInterval = 10 * time.Minute
+
+// Snapshot is an immutable view of the loaded policies
+// It is replaced wholesale on refresh and must never be modified by readers
+type Snapshot struct {
+	Policies   []models.Policy
+	Patterns   map[string]*regexp.Regexp // Precompiled regex and keyword matchers, keyed by pattern source
+	RegexSet   *analyzer.RegexSet        // All enabled regex policies combined for single-pass scanning
+	KeywordSet *analyzer.KeywordSet      // All enabled keyword policies combined into one Aho-Corasick automaton
+	Hash       string                    // Fingerprint of the policy definitions, equal across replicas in sync
+	LoadedAt   time.Time
+	// WarmStart is set for a snapshot loaded from disk at startup, until the first
+	// successful refresh from Postgres replaces it
+	WarmStart bool
+}
+
 // PolicyCache provides an in-memory cache for policies with automatic refresh
+// Readers load the current snapshot lock-free; refresh swaps in a new one
 type PolicyCache struct {
-	repo          *policy.Repository
-	policies      []models.Policy
-	mu            sync.RWMutex // Protects policies slice
+	repo          policy.Store
+	snapshot      atomic.Pointer[Snapshot]
 	refreshTicker *time.Ticker
 	stopChan      chan struct{}
 	refreshOnce   sync.Once
+	dbBreaker     *breaker.Breaker // Optional; while open, the last snapshot is served
+	invalidator   *Invalidator     // Optional; nil keeps invalidations local to this replica
+	scope         string           // Name of the cache on the invalidation channel
 }
 
 // NewPolicyCache creates a new policy cache
-func NewPolicyCache(repo *policy.Repository) *PolicyCache {
-	return &PolicyCache{
+func NewPolicyCache(repo policy.Store) *PolicyCache {
+	pc := &PolicyCache{
 		repo:     repo,
-		policies: make([]models.Policy, 0),
 		stopChan: make(chan struct{}),
 	}
+	pc.snapshot.Store(newSnapshot(make([]models.Policy, 0)))
+	return pc
+}
+
+// newSnapshot precompiles the matchers of the given policies and puts them in
+// evaluation order. Invalid regexes are left out; the analyzer reports them
+// when evaluated
+func newSnapshot(policies []models.Policy) *Snapshot {
+	analyzer.SortPolicies(policies)
+	patterns := make(map[string]*regexp.Regexp)
+	var regexSources, keywords []string
+	for _, p := range policies {
+		var source string
+		switch p.PatternType {
+		case "regex":
+			source = p.PatternValue
+			// Size-capped policies must scan their own truncated input
+			if p.Enabled && p.MaxInputBytes == 0 {
+				regexSources = append(regexSources, source)
+			}
+		case "keyword":
+			source = analyzer.KeywordPattern(p.PatternValue)
+			// Stemmed and word-bounded keywords don't match every occurrence
+			if p.Enabled && p.MaxInputBytes == 0 && !p.Stem && !analyzer.WordBounded(p.MatchMode) {
+				keywords = append(keywords, p.PatternValue)
+			}
+		default:
+			continue
+		}
+		if _, ok := patterns[source]; ok {
+			continue
+		}
+		re, err := regexp.Compile(source)
+		if err != nil {
+			slog.Warn("Policy has an invalid pattern", "policy", p.Name, "error", err)
+			continue
+		}
+		patterns[source] = re
+	}
+	return &Snapshot{
+		Policies:   policies,
+		Patterns:   patterns,
+		RegexSet:   analyzer.NewRegexSet(regexSources),
+		KeywordSet: analyzer.NewKeywordSet(keywords),
+		Hash:       snapshotHash(policies),
+		LoadedAt:   time.Now(),
+	}
+}
+
+// snapshotHash fingerprints policy definitions independent of load order,
+// ignoring hit telemetry that changes between reloads
+func snapshotHash(policies []models.Policy) string {
+	sorted := make([]models.Policy, len(policies))
+	copy(sorted, policies)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].ID.String() < sorted[j].ID.String() })
+	for i := range sorted {
+		sorted[i].HitCount, sorted[i].LastMatchedAt = 0, nil
+	}
+	data, err := json.Marshal(sorted)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:8])
+}
+
+// SetBreaker guards policy reloads with a circuit breaker
+// Must be called before Start
+func (pc *PolicyCache) SetBreaker(dbBreaker *breaker.Breaker) {
+	pc.dbBreaker = dbBreaker
 }
 
 // Start initializes the cache and starts the background refresh worker
-// It performs an initial load and then refreshes every 10 minutes
+// It performs an initial load and then refreshes every 10 minutes. After
+// LoadSnapshot, a failed initial load is tolerated: the warm-start snapshot is
+// served and Postgres retried every 30 seconds until it answers
 func (pc *PolicyCache) Start(ctx context.Context) error {
+	interval := refreshInterval
+
 	// Initial load
 	if err := pc.refresh(ctx); err != nil {
-		return err
+		if !pc.Snapshot().WarmStart {
+			return err
+		}
+		slog.Warn("Policy database unavailable, serving policies from the warm-start snapshot", "policies", len(pc.Get()), "error", err)
+		interval = warmRetryInterval
+	} else {
+		slog.Info("Policy cache initialized", "policies", len(pc.Get()))
 	}
-	log.Printf("✓ Policy cache initialized with %d policies", len(pc.policies))
 
 	// Start background refresh worker
 	pc.refreshOnce.Do(func() {
-		pc.refreshTicker = time.NewTicker(10 * time.Minute)
+		pc.refreshTicker = time.NewTicker(interval)
 		go pc.refreshWorker(ctx)
-		log.Println("✓ Policy cache refresh worker started (interval: 10 minutes)")
+		slog.Info("Policy cache refresh worker started", "interval", interval)
 	})
 
 	return nil
@@ -54,49 +164,88 @@ func (pc *PolicyCache) refreshWorker(ctx context.Context) {
 		select {
 		case <-pc.refreshTicker.C:
 			if err := pc.refresh(ctx); err != nil {
-				log.Printf("⚠️  Failed to refresh policy cache: %v", err)
+				slog.Warn("Failed to refresh policy cache, serving stale policies", "error", err)
 			} else {
-				log.Printf("✓ Policy cache refreshed: %d policies loaded", len(pc.policies))
+				slog.Debug("Policy cache refreshed", "policies", len(pc.Get()))
+				// Back to the regular interval after a warm start's retries
+				pc.refreshTicker.Reset(refreshInterval)
 			}
 		case <-pc.stopChan:
 			pc.refreshTicker.Stop()
-			log.Println("✓ Policy cache refresh worker stopped")
+			slog.Info("Policy cache refresh worker stopped")
 			return
 		}
 	}
 }
 
 // refresh fetches policies from the database and updates the cache
-func (pc *PolicyCache) refresh(ctx context.Context) error {
-	policies, err := pc.repo.List(ctx)
+func (pc *PolicyCache) refresh(ctx context.Context) (err error) {
+	ctx, span := tracing.Start(ctx, "policy_cache.refresh", attribute.String("policy_cache.scope", pc.scope))
+	defer func() { tracing.End(span, err) }()
+
+	var policies []models.Policy
+	err = pc.dbBreaker.Do(func() error {
+		var err error
+		policies, err = pc.repo.List(ctx)
+		return err
+	})
 	if err != nil {
 		return err
 	}
 
-	// Update cache with write lock
-	pc.mu.Lock()
-	pc.policies = policies
-	pc.mu.Unlock()
+	// Compile outside the hot path, then publish atomically
+	_, compileSpan := tracing.Start(ctx, "policy_cache.compile", attribute.Int("policy_cache.policies", len(policies)))
+	pc.snapshot.Store(newSnapshot(policies))
+	compileSpan.End()
 
 	return nil
 }
 
 // Get returns all cached policies (thread-safe)
+// The slice is shared with other readers and must not be modified
 func (pc *PolicyCache) Get() []models.Policy {
-	pc.mu.RLock()
-	defer pc.mu.RUnlock()
+	return pc.snapshot.Load().Policies
+}
+
+// Snapshot returns the current immutable policy snapshot
+func (pc *PolicyCache) Snapshot() *Snapshot {
+	return pc.snapshot.Load()
+}
+
+// RegexSet returns the combined matcher of the loaded regex policies
+func (pc *PolicyCache) RegexSet() *analyzer.RegexSet {
+	return pc.snapshot.Load().RegexSet
+}
+
+// KeywordSet returns the combined matcher of the loaded keyword policies
+func (pc *PolicyCache) KeywordSet() *analyzer.KeywordSet {
+	return pc.snapshot.Load().KeywordSet
+}
 
-	// Return a copy to prevent external modifications
-	result := make([]models.Policy, len(pc.policies))
-	copy(result, pc.policies)
-	return result
+// Pattern returns the precompiled matcher for a pattern source, if loaded
+func (pc *PolicyCache) Pattern(source string) (*regexp.Regexp, bool) {
+	re, ok := pc.snapshot.Load().Patterns[source]
+	return re, ok
+}
+
+// Policy returns a cached policy by ID
+func (pc *PolicyCache) Policy(id uuid.UUID) (models.Policy, bool) {
+	for _, p := range pc.snapshot.Load().Policies {
+		if p.ID == id {
+			return p, true
+		}
+	}
+	return models.Policy{}, false
 }
 
 // Invalidate forces an immediate cache refresh
-// Useful when policies are created/updated/deleted
+// Useful when policies are created/updated/deleted; with an Invalidator the
+// other replicas are told to refresh too, even if this refresh failed
 func (pc *PolicyCache) Invalidate(ctx context.Context) error {
-	log.Println("🔄 Invalidating policy cache...")
-	return pc.refresh(ctx)
+	slog.InfoContext(ctx, "Invalidating policy cache")
+	err := pc.refresh(ctx)
+	pc.invalidator.publish(ctx, pc.scope)
+	return err
 }
 
 // Stop gracefully stops the background refresh worker
@@ -5,11 +5,14 @@ import (
 	"database/sql"
 	"encoding/json"
 	"fmt"
-	"log"
+	"log/slog"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/lib/pq"
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/internal/breaker"
 	"github.com/prompt-gateway/internal/metrics"
 	"github.com/prompt-gateway/pkg/models"
 	"github.com/redis/go-redis/v9"
@@ -23,16 +26,52 @@ type RedisCache struct {
 	stopChan     chan struct{}
 	stopOnce     sync.Once
 	syncInterval time.Duration
+	dbBreaker    *breaker.Breaker            // Optional; while open, logs stay buffered in Redis
+	tenantDB     func(tenant string) *sql.DB // Optional; routes tenants with isolated storage
+	batchSize    atomic.Int64                // Audit logs popped per sync, raised while backlogged
+	backlogWarn  int64                       // Queue size that raises the elevated level (0 = disabled)
+	backlogCrit  int64                       // Queue size that raises the critical level (0 = disabled)
+	backlogLevel int                         // Current backlog level, owned by the sync worker
+	onBacklog    func(level string, queueSize int64)
+	forwarder    AuditForwarder // Optional; another region owns audit persistence
+}
+
+// AuditForwarder ships synced audit batches to the region that owns audit persistence
+type AuditForwarder interface {
+	Name() string
+	Forward(ctx context.Context, entries []models.AuditLog) error
 }
 
 // NewRedisCache creates a new RedisCache focused on audit log syncing.
 func NewRedisCache(db *sql.DB, rdb *redis.Client, syncInterval time.Duration) *RedisCache {
-	return &RedisCache{
+	rc := &RedisCache{
 		db:           db,
 		rdb:          rdb,
 		stopChan:     make(chan struct{}),
 		syncInterval: syncInterval,
 	}
+	rc.batchSize.Store(defaultSyncBatchSize)
+	return rc
+}
+
+// SetBreaker guards Postgres writes with a circuit breaker.
+// Must be called before Start.
+func (rc *RedisCache) SetBreaker(dbBreaker *breaker.Breaker) {
+	rc.dbBreaker = dbBreaker
+}
+
+// SetTenantRouting writes entries of tenants with isolated storage to the database
+// returned by tenantDB (nil keeps the default database)
+// Must be called before Start
+func (rc *RedisCache) SetTenantRouting(tenantDB func(tenant string) *sql.DB) {
+	rc.tenantDB = tenantDB
+}
+
+// SetForwarder sends synced batches to the owner region through f instead of
+// writing them to Postgres, keeping cross-region database writes off this region
+// Must be called before Start
+func (rc *RedisCache) SetForwarder(f AuditForwarder) {
+	rc.forwarder = f
 }
 
 // Start begins the background worker that periodically syncs audit logs
@@ -44,7 +83,14 @@ func (rc *RedisCache) Start(ctx context.Context) error {
 
 	rc.syncTicker = time.NewTicker(rc.syncInterval)
 	go rc.syncWorker(ctx)
-	log.Printf("✓ Redis→Postgres audit sync worker started (interval: %v)", rc.syncInterval)
+	if rc.forwarder != nil {
+		slog.Info("Audit forwarding worker started", "interval", rc.syncInterval, "via", rc.forwarder.Name())
+	} else {
+		slog.Info("Redis→Postgres audit sync worker started", "interval", rc.syncInterval)
+	}
+	if rc.backlogWarn > 0 || rc.backlogCrit > 0 {
+		slog.Info("Audit backlog alarms enabled", "elevated", rc.backlogWarn, "critical", rc.backlogCrit)
+	}
 
 	return nil
 }
@@ -55,23 +101,24 @@ func (rc *RedisCache) syncWorker(ctx context.Context) {
 		select {
 		case <-rc.syncTicker.C:
 			if err := rc.syncAuditLogsToPostgres(ctx); err != nil {
-				log.Printf(" Failed to sync audit logs to Postgres: %v", err)
+				slog.Error("Failed to sync audit logs to Postgres", "error", err)
 			}
+			rc.adjustBacklog(ctx)
 		case <-rc.stopChan:
 			if rc.syncTicker != nil {
 				rc.syncTicker.Stop()
 			}
 			// Best effort final sync
 			if err := rc.syncAuditLogsToPostgres(ctx); err != nil {
-				log.Printf("Failed to perform final audit log sync to Postgres: %v", err)
+				slog.Error("Failed to perform final audit log sync to Postgres", "error", err)
 			}
-			log.Println("✓ Redis→Postgres audit sync worker stopped")
+			slog.Info("Redis→Postgres audit sync worker stopped")
 			return
 		case <-ctx.Done():
 			if rc.syncTicker != nil {
 				rc.syncTicker.Stop()
 			}
-			log.Println("✓ Redis→Postgres audit sync worker stopped (context cancelled)")
+			slog.Info("Redis→Postgres audit sync worker stopped (context cancelled)")
 			return
 		}
 	}
@@ -82,16 +129,24 @@ func (rc *RedisCache) syncAuditLogsToPostgres(ctx context.Context) error {
 	// Check queue size before syncing
 	queueSize, err := rc.rdb.LLen(ctx, "audit_logs:pending").Result()
 	if err != nil {
-		log.Printf(" Failed to get audit log queue size: %v", err)
+		slog.Warn("Failed to get audit log queue size", "error", err)
 	} else {
 		metrics.AuditQueueLength.Set(float64(queueSize))
 		if queueSize > 0 {
-			log.Printf(" Audit log queue size: %d logs pending", queueSize)
+			slog.Debug("Audit log queue size", "pending", queueSize)
+		}
+	}
+
+	// Leave logs buffered in Redis while Postgres is known to be down
+	if rc.forwarder == nil {
+		if err := rc.dbBreaker.Allow(); err != nil {
+			slog.Warn("Postgres unavailable, keeping audit logs buffered in Redis")
+			return nil
 		}
 	}
 
-	// Get batch of audit logs from Redis list (up to 10K at a time)
-	batchSize := int64(10000)
+	// Get batch of audit logs from Redis list (10K at a time, more while backlogged)
+	batchSize := rc.batchSize.Load()
 
 	// Pop logs from the right side of the list (FIFO order) - REMOVES from Redis!
 	logs, err := rc.rdb.RPopCount(ctx, "audit_logs:pending", int(batchSize)).Result()
@@ -104,7 +159,8 @@ func (rc *RedisCache) syncAuditLogsToPostgres(ctx context.Context) error {
 		return fmt.Errorf("failed to read audit logs from Redis: %w", err)
 	}
 
-	log.Printf("Syncing %d audit logs from Redis to Postgres...", len(logs))
+	metrics.AuditSyncBatchSize.Observe(float64(len(logs)))
+	start := time.Now()
 	remaining := queueSize - int64(len(logs))
 	if remaining < 0 {
 		remaining = 0
@@ -113,58 +169,156 @@ func (rc *RedisCache) syncAuditLogsToPostgres(ctx context.Context) error {
 
 	// Parse all logs first
 	entries := make([]models.AuditLog, 0, len(logs))
-	failedLogs := make([]string, 0)
+	raw := make([]string, 0, len(logs))
 
 	for _, logData := range logs {
 		var entry models.AuditLog
 		if err := json.Unmarshal([]byte(logData), &entry); err != nil {
-			log.Printf("Failed to unmarshal audit log: %v", err)
+			slog.Error("Failed to unmarshal audit log", "error", err)
+			metrics.AuditDroppedTotal.WithLabelValues("unmarshal").Inc()
 			continue // Skip bad JSON
 		}
 		entries = append(entries, entry)
+		raw = append(raw, logData)
 	}
 
 	if len(entries) == 0 {
 		return nil
 	}
 
+	if rc.forwarder != nil {
+		return rc.forwardBatch(ctx, entries, raw, start)
+	}
+
+	slog.Debug("Syncing audit logs from Redis to Postgres", "count", len(entries))
+	// Tenants with isolated storage get their own batch against their own database
+	for _, batch := range groupByTenant(entries, raw) {
+		rc.writeBatch(ctx, rc.dbFor(batch.tenant), batch.entries, batch.raw, start)
+	}
+	return nil
+}
+
+// forwardBatch sends a batch to the owner region, putting it back at the oldest end
+// of the queue when the owner can't be reached so the next sync retries it first
+func (rc *RedisCache) forwardBatch(ctx context.Context, entries []models.AuditLog, raw []string, start time.Time) error {
+	if err := rc.forwarder.Forward(ctx, entries); err != nil {
+		metrics.AuditForwardedTotal.WithLabelValues("failed").Add(float64(len(entries)))
+		values := make([]interface{}, len(raw))
+		for i := range raw {
+			values[len(raw)-1-i] = raw[i]
+		}
+		if err := rc.rdb.RPush(ctx, "audit_logs:pending", values...).Err(); err != nil {
+			slog.Error("Failed to re-queue audit logs", "error", err)
+			metrics.AuditDroppedTotal.WithLabelValues("requeue_failed").Add(float64(len(raw)))
+		} else {
+			metrics.AuditRequeuedTotal.Add(float64(len(raw)))
+		}
+		return fmt.Errorf("failed to forward %d audit logs via %s: %w", len(entries), rc.forwarder.Name(), err)
+	}
+
+	metrics.AuditForwardedTotal.WithLabelValues("sent").Add(float64(len(entries)))
+	metrics.AuditSyncDuration.WithLabelValues("forward").Observe(time.Since(start).Seconds())
+	metrics.AuditLastSyncTimestamp.SetToCurrentTime()
+	slog.Info("Forwarded audit logs to the owner region", "count", len(entries), "via", rc.forwarder.Name())
+	return nil
+}
+
+// tenantBatch is the part of a sync batch destined for one tenant's database
+type tenantBatch struct {
+	tenant  string
+	entries []models.AuditLog
+	raw     []string
+}
+
+// groupByTenant splits entries (and their raw JSON) by tenant, keeping order
+func groupByTenant(entries []models.AuditLog, raw []string) []*tenantBatch {
+	var batches []*tenantBatch
+	index := make(map[string]*tenantBatch)
+	for i, entry := range entries {
+		b, ok := index[entry.Tenant]
+		if !ok {
+			b = &tenantBatch{tenant: entry.Tenant}
+			index[entry.Tenant] = b
+			batches = append(batches, b)
+		}
+		b.entries = append(b.entries, entry)
+		b.raw = append(b.raw, raw[i])
+	}
+	return batches
+}
+
+// dbFor returns the database holding a tenant's audit logs
+func (rc *RedisCache) dbFor(tenant string) *sql.DB {
+	if tenant != "" && rc.tenantDB != nil {
+		if db := rc.tenantDB(tenant); db != nil {
+			return db
+		}
+	}
+	return rc.db
+}
+
+// writeBatch bulk-inserts entries into db, falling back to individual inserts
+// and re-queuing whatever still fails
+func (rc *RedisCache) writeBatch(ctx context.Context, db *sql.DB, entries []models.AuditLog, raw []string, start time.Time) {
+	failedLogs := make([]string, 0)
+
 	// Use bulk COPY for maximum performance
-	if err := rc.bulkWriteAuditLogs(ctx, entries); err != nil {
-		log.Printf("Bulk insert failed: %v, falling back to individual inserts", err)
+	if err := rc.bulkWriteAuditLogs(ctx, db, entries); err != nil {
+		slog.Warn("Bulk insert failed, falling back to individual inserts", "error", err)
+		metrics.AuditBulkInsertFailures.Inc()
 
 		// Fallback: individual inserts with retry logic
 		syncCount := 0
+		var lastErr error
 		for i, entry := range entries {
-			if err := rc.writeAuditLogToPostgres(ctx, entry); err != nil {
-				log.Printf("Failed to write audit log to Postgres: %v", err)
-				failedLogs = append(failedLogs, logs[i])
+			if err := rc.writeAuditLogToPostgres(ctx, db, entry); err != nil {
+				slog.Error("Failed to write audit log to Postgres", "error", err)
+				metrics.AuditFallbackInserts.WithLabelValues("failure").Inc()
+				failedLogs = append(failedLogs, raw[i])
+				lastErr = err
 				continue
 			}
+			metrics.AuditFallbackInserts.WithLabelValues("success").Inc()
 			syncCount++
 		}
+		// Only a batch where nothing could be written counts against Postgres health
+		if syncCount == 0 {
+			rc.dbBreaker.Record(lastErr)
+		} else {
+			rc.dbBreaker.Record(nil)
+		}
 
 		// Re-push failed logs back to Redis for retry
 		if len(failedLogs) > 0 {
 			for _, logData := range failedLogs {
 				if err := rc.rdb.LPush(ctx, "audit_logs:pending", logData).Err(); err != nil {
-					log.Printf("Failed to re-queue audit log: %v", err)
+					slog.Error("Failed to re-queue audit log", "error", err)
+					metrics.AuditDroppedTotal.WithLabelValues("requeue_failed").Inc()
+					continue
 				}
+				metrics.AuditRequeuedTotal.Inc()
 			}
-			log.Printf("Re-queued %d failed audit logs for retry", len(failedLogs))
+			slog.Warn("Re-queued failed audit logs for retry", "count", len(failedLogs))
 		}
 
-		log.Printf("✓ Synced %d/%d audit logs to Postgres (fallback mode)", syncCount, len(entries))
-		return nil
+		metrics.AuditSyncDuration.WithLabelValues("fallback").Observe(time.Since(start).Seconds())
+		if syncCount > 0 {
+			metrics.AuditLastSyncTimestamp.SetToCurrentTime()
+		}
+		slog.Info("Synced audit logs to Postgres (fallback mode)", "synced", syncCount, "total", len(entries))
+		return
 	}
 
-	log.Printf("✓ Bulk synced %d audit logs to Postgres", len(entries))
-	return nil
+	rc.dbBreaker.Record(nil)
+	metrics.AuditSyncDuration.WithLabelValues("bulk").Observe(time.Since(start).Seconds())
+	metrics.AuditLastSyncTimestamp.SetToCurrentTime()
+	slog.Info("Bulk synced audit logs to Postgres", "count", len(entries))
 }
 
 // bulkWriteAuditLogs uses PostgreSQL COPY for high-performance bulk inserts
-func (rc *RedisCache) bulkWriteAuditLogs(ctx context.Context, entries []models.AuditLog) error {
+func (rc *RedisCache) bulkWriteAuditLogs(ctx context.Context, db *sql.DB, entries []models.AuditLog) error {
 	// Begin transaction
-	tx, err := rc.db.BeginTx(ctx, nil)
+	tx, err := db.BeginTx(ctx, nil)
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
@@ -175,11 +329,17 @@ func (rc *RedisCache) bulkWriteAuditLogs(ctx context.Context, entries []models.A
 		"audit_logs",
 		"request_id",
 		"client_id",
+		"session_id",
 		"prompt_hash",
 		"response_hash",
 		"policies_triggered",
 		"action_taken",
 		"latency_ms",
+		"instance_id",
+		"hostname",
+		"region",
+		"zone",
+		"gateway_version",
 	))
 	if err != nil {
 		return fmt.Errorf("failed to prepare COPY statement: %w", err)
@@ -198,11 +358,17 @@ func (rc *RedisCache) bulkWriteAuditLogs(ctx context.Context, entries []models.A
 			ctx,
 			entry.RequestID,
 			entry.ClientID,
+			audit.NullSession(entry.SessionID),
 			entry.PromptHash,
 			entry.ResponseHash,
 			pq.Array(policyIDs),
 			entry.ActionTaken,
 			entry.LatencyMs,
+			entry.InstanceID,
+			entry.Hostname,
+			entry.Region,
+			entry.Zone,
+			entry.GatewayVersion,
 		)
 		if err != nil {
 			return fmt.Errorf("failed to add row to COPY: %w", err)
@@ -228,12 +394,13 @@ func (rc *RedisCache) bulkWriteAuditLogs(ctx context.Context, entries []models.A
 }
 
 // writeAuditLogToPostgres writes a single audit log to Postgres (fallback only)
-func (rc *RedisCache) writeAuditLogToPostgres(ctx context.Context, entry models.AuditLog) error {
+func (rc *RedisCache) writeAuditLogToPostgres(ctx context.Context, db *sql.DB, entry models.AuditLog) error {
 	query := `
 		INSERT INTO audit_logs (
-			request_id, client_id, prompt_hash, response_hash,
-			policies_triggered, action_taken, latency_ms
-		) VALUES ($1, $2, $3, $4, $5, $6, $7)
+			request_id, client_id, session_id, prompt_hash, response_hash,
+			policies_triggered, action_taken, latency_ms,
+			instance_id, hostname, region, zone, gateway_version
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
 	`
 
 	// Convert UUID slice to string slice for PostgreSQL array
@@ -242,15 +409,21 @@ func (rc *RedisCache) writeAuditLogToPostgres(ctx context.Context, entry models.
 		policyIDs[i] = id.String()
 	}
 
-	_, err := rc.db.ExecContext(
+	_, err := db.ExecContext(
 		ctx, query,
 		entry.RequestID,
 		entry.ClientID,
+		audit.NullSession(entry.SessionID),
 		entry.PromptHash,
 		entry.ResponseHash,
 		pq.Array(policyIDs),
 		entry.ActionTaken,
 		entry.LatencyMs,
+		entry.InstanceID,
+		entry.Hostname,
+		entry.Region,
+		entry.Zone,
+		entry.GatewayVersion,
 	)
 
 	if err != nil {
@@ -260,6 +433,25 @@ func (rc *RedisCache) writeAuditLogToPostgres(ctx context.Context, entry models.
 	return nil
 }
 
+// Flush syncs every queued audit log to Postgres without waiting for the next tick.
+func (rc *RedisCache) Flush(ctx context.Context) error {
+	for {
+		queueSize, err := rc.rdb.LLen(ctx, "audit_logs:pending").Result()
+		if err != nil {
+			return fmt.Errorf("failed to get audit log queue size: %w", err)
+		}
+		if queueSize == 0 {
+			return nil
+		}
+		if err := rc.syncAuditLogsToPostgres(ctx); err != nil {
+			return err
+		}
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("audit queue not drained (%d pending): %w", queueSize, err)
+		}
+	}
+}
+
 // Stop gracefully stops the background worker.
 func (rc *RedisCache) Stop() {
 	rc.stopOnce.Do(func() {
@@ -0,0 +1,108 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// insertOnlyDB accepts single-row inserts (failing them with err, if set) but no
+// transactions, so every bulk COPY fails and writeBatch takes the fallback path
+type insertOnlyDB struct{ err error }
+
+func (d insertOnlyDB) Connect(ctx context.Context) (driver.Conn, error) { return d, nil }
+func (d insertOnlyDB) Driver() driver.Driver                            { return nil }
+func (d insertOnlyDB) Prepare(query string) (driver.Stmt, error) {
+	return nil, errors.New("prepare not supported")
+}
+func (d insertOnlyDB) Close() error { return nil }
+func (d insertOnlyDB) Begin() (driver.Tx, error) {
+	return nil, errors.New("transactions not supported")
+}
+func (d insertOnlyDB) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	if d.err != nil {
+		return nil, d.err
+	}
+	return driver.RowsAffected(1), nil
+}
+
+// histogramCount returns the number of observations of a histogram
+func histogramCount(t *testing.T, h prometheus.Observer) uint64 {
+	t.Helper()
+	var m dto.Metric
+	if err := h.(prometheus.Metric).Write(&m); err != nil {
+		t.Fatal(err)
+	}
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestWriteBatch_FallbackMetrics(t *testing.T) {
+	entries := []models.AuditLog{{ClientID: "a"}, {ClientID: "b"}}
+	raw := []string{`{"client_id":"a"}`, `{"client_id":"b"}`}
+
+	db := sql.OpenDB(insertOnlyDB{})
+	defer db.Close()
+	rc := NewRedisCache(db, nil, time.Second)
+
+	bulkFailures := testutil.ToFloat64(metrics.AuditBulkInsertFailures)
+	inserted := testutil.ToFloat64(metrics.AuditFallbackInserts.WithLabelValues("success"))
+	batches := histogramCount(t, metrics.AuditSyncDuration.WithLabelValues("fallback"))
+	metrics.AuditLastSyncTimestamp.Set(0)
+
+	rc.writeBatch(context.Background(), db, entries, raw, time.Now())
+
+	if got := testutil.ToFloat64(metrics.AuditBulkInsertFailures) - bulkFailures; got != 1 {
+		t.Errorf("bulk insert failures = %v, want 1", got)
+	}
+	if got := testutil.ToFloat64(metrics.AuditFallbackInserts.WithLabelValues("success")) - inserted; got != 2 {
+		t.Errorf("fallback inserts{success} = %v, want 2", got)
+	}
+	if got := histogramCount(t, metrics.AuditSyncDuration.WithLabelValues("fallback")) - batches; got != 1 {
+		t.Errorf("fallback sync durations observed = %d, want 1", got)
+	}
+	if testutil.ToFloat64(metrics.AuditLastSyncTimestamp) == 0 {
+		t.Error("last sync timestamp not set after a successful fallback")
+	}
+}
+
+func TestWriteBatch_DropsWhenRequeueFails(t *testing.T) {
+	entries := []models.AuditLog{{ClientID: "a"}, {ClientID: "b"}}
+	raw := []string{`{"client_id":"a"}`, `{"client_id":"b"}`}
+
+	db := sql.OpenDB(insertOnlyDB{err: errors.New("connection reset")})
+	defer db.Close()
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1, DialTimeout: 100 * time.Millisecond})
+	defer rdb.Close()
+	rc := NewRedisCache(db, rdb, time.Second)
+
+	failed := testutil.ToFloat64(metrics.AuditFallbackInserts.WithLabelValues("failure"))
+	dropped := testutil.ToFloat64(metrics.AuditDroppedTotal.WithLabelValues("requeue_failed"))
+	requeued := testutil.ToFloat64(metrics.AuditRequeuedTotal)
+	metrics.AuditLastSyncTimestamp.Set(0)
+
+	rc.writeBatch(context.Background(), db, entries, raw, time.Now())
+
+	if got := testutil.ToFloat64(metrics.AuditFallbackInserts.WithLabelValues("failure")) - failed; got != 2 {
+		t.Errorf("fallback inserts{failure} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.AuditDroppedTotal.WithLabelValues("requeue_failed")) - dropped; got != 2 {
+		t.Errorf("dropped{requeue_failed} = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.AuditRequeuedTotal) - requeued; got != 0 {
+		t.Errorf("requeued = %v, want 0 with Redis down", got)
+	}
+	if testutil.ToFloat64(metrics.AuditLastSyncTimestamp) != 0 {
+		t.Error("last sync timestamp set although nothing was written")
+	}
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// snapshotFileVersion is bumped whenever the snapshot file format changes; files
+// of another version are ignored
+const snapshotFileVersion = 1
+
+// warmRetryInterval is how often a warm-started cache retries Postgres until its
+// first successful refresh
+const warmRetryInterval = 30 * time.Second
+
+// snapshotFile is the on-disk form of a policy snapshot
+type snapshotFile struct {
+	Version  int             `json:"version"`
+	Hash     string          `json:"hash"`
+	LoadedAt time.Time       `json:"loaded_at"` // When the policies were loaded from Postgres
+	SavedAt  time.Time       `json:"saved_at"`
+	Patterns []string        `json:"patterns"` // Pattern sources that compiled, checked on load
+	Policies []models.Policy `json:"policies"`
+}
+
+// SaveSnapshot writes the current policy snapshot to path so the next start can
+// serve it before Postgres is reachable. The file is replaced atomically
+func (pc *PolicyCache) SaveSnapshot(path string) error {
+	snapshot := pc.snapshot.Load()
+	file := snapshotFile{
+		Version:  snapshotFileVersion,
+		Hash:     snapshot.Hash,
+		LoadedAt: snapshot.LoadedAt,
+		SavedAt:  time.Now(),
+		Patterns: make([]string, 0, len(snapshot.Patterns)),
+		Policies: snapshot.Policies,
+	}
+	for source := range snapshot.Patterns {
+		file.Patterns = append(file.Patterns, source)
+	}
+	sort.Strings(file.Patterns)
+
+	data, err := json.Marshal(file)
+	if err != nil {
+		return fmt.Errorf("failed to encode policy snapshot: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write policy snapshot: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write policy snapshot: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write policy snapshot: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write policy snapshot: %w", err)
+	}
+	return nil
+}
+
+// LoadSnapshot serves the policies saved at path until the first successful
+// refresh; Start then tolerates Postgres being unreachable. A file whose policies
+// no longer hash or compile to what was saved is rejected
+func (pc *PolicyCache) LoadSnapshot(path string) (*Snapshot, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy snapshot: %w", err)
+	}
+	var file snapshotFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse policy snapshot: %w", err)
+	}
+	if file.Version != snapshotFileVersion {
+		return nil, fmt.Errorf("policy snapshot has version %d, want %d", file.Version, snapshotFileVersion)
+	}
+
+	snapshot := newSnapshot(file.Policies)
+	if snapshot.Hash != file.Hash {
+		return nil, fmt.Errorf("policy snapshot hash %s does not match its policies (%s)", file.Hash, snapshot.Hash)
+	}
+	if len(snapshot.Patterns) != len(file.Patterns) {
+		return nil, fmt.Errorf("policy snapshot compiled %d patterns, saved with %d", len(snapshot.Patterns), len(file.Patterns))
+	}
+	for _, source := range file.Patterns {
+		if _, ok := snapshot.Patterns[source]; !ok {
+			return nil, fmt.Errorf("policy snapshot pattern %q no longer compiles", source)
+		}
+	}
+	snapshot.LoadedAt = file.LoadedAt
+	snapshot.WarmStart = true
+
+	pc.snapshot.Store(snapshot)
+	return snapshot, nil
+}
@@ -0,0 +1,104 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// listStore serves List from memory, failing while err is set
+type listStore struct {
+	policy.Store
+	policies []models.Policy
+	err      error
+}
+
+func (s *listStore) List(ctx context.Context) ([]models.Policy, error) {
+	return s.policies, s.err
+}
+
+func TestPolicyCache_WarmStart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	policies := []models.Policy{
+		{ID: uuid.New(), Name: "ssn", PatternType: "regex", PatternValue: `\b\d{3}-\d{2}-\d{4}\b`, Enabled: true},
+		{ID: uuid.New(), Name: "secret", PatternType: "keyword", PatternValue: "password", Enabled: true},
+	}
+
+	live := NewPolicyCache(&listStore{policies: policies})
+	if err := live.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer live.Stop()
+	if err := live.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	// Postgres is down at the next start: the saved snapshot is served
+	down := &listStore{err: errors.New("connection refused")}
+	warm := NewPolicyCache(down)
+	if err := warm.Start(context.Background()); err == nil {
+		t.Fatal("Start() without a snapshot succeeded, want the database error")
+	}
+	snapshot, err := warm.LoadSnapshot(path)
+	if err != nil {
+		t.Fatalf("LoadSnapshot() error = %v", err)
+	}
+	if err := warm.Start(context.Background()); err != nil {
+		t.Fatalf("Start() after LoadSnapshot error = %v", err)
+	}
+	defer warm.Stop()
+	if !snapshot.WarmStart || snapshot.Hash != live.Snapshot().Hash || len(warm.Get()) != 2 {
+		t.Errorf("snapshot = %+v, want the saved policies", snapshot)
+	}
+	if _, ok := warm.Pattern(`\b\d{3}-\d{2}-\d{4}\b`); !ok || warm.RegexSet() == nil {
+		t.Error("warm-start snapshot has no compiled patterns")
+	}
+	if !snapshot.LoadedAt.Equal(live.Snapshot().LoadedAt) {
+		t.Errorf("LoadedAt = %v, want when the policies were loaded from Postgres", snapshot.LoadedAt)
+	}
+
+	// The first successful refresh replaces it
+	down.err, down.policies = nil, policies[:1]
+	if err := warm.Invalidate(context.Background()); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	if warm.Snapshot().WarmStart || len(warm.Get()) != 1 {
+		t.Errorf("snapshot after refresh = %+v, want the live policies", warm.Snapshot())
+	}
+}
+
+func TestPolicyCache_LoadSnapshotRejectsTampering(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "policies.json")
+	pc := NewPolicyCache(&listStore{policies: []models.Policy{{ID: uuid.New(), Name: "secret", PatternType: "keyword", PatternValue: "password", Enabled: true}}})
+	if err := pc.Start(context.Background()); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer pc.Stop()
+	if err := pc.SaveSnapshot(path); err != nil {
+		t.Fatalf("SaveSnapshot() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{
+		"edited policies": strings.Replace(string(data), `"password"`, `"passw0rd"`, 1),
+		"truncated file":  string(data[:len(data)/2]),
+	} {
+		if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := NewPolicyCache(&listStore{}).LoadSnapshot(path); err == nil {
+			t.Errorf("LoadSnapshot() of %s succeeded, want an error", name)
+		}
+	}
+}
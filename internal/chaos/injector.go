@@ -0,0 +1,181 @@
+// Package chaos injects artificial latency and dependency failures so fail-open /
+// fail-closed behavior and alerting can be exercised in staging without breaking
+// real infrastructure. It must never be enabled in production
+package chaos
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// ErrInjected marks every failure produced by the injector
+var ErrInjected = errors.New("chaos: injected fault")
+
+// modelHang bounds how long an injected model timeout blocks when the caller's
+// context has no earlier deadline
+const modelHang = 10 * time.Second
+
+// Config holds per-fault injection rates in [0, 1]
+type Config struct {
+	Latency           time.Duration // Delay added to a fraction of analyze requests
+	LatencyRate       float64
+	PostgresErrorRate float64 // Fraction of breaker-guarded Postgres calls that fail
+	RedisErrorRate    float64 // Fraction of Redis commands that fail
+	ModelTimeoutRate  float64 // Fraction of model-provider calls that time out
+}
+
+// Validate checks that every rate is a probability
+func (c Config) Validate() error {
+	rates := map[string]float64{
+		"latency":       c.LatencyRate,
+		"postgres":      c.PostgresErrorRate,
+		"redis":         c.RedisErrorRate,
+		"model_timeout": c.ModelTimeoutRate,
+	}
+	for name, rate := range rates {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("chaos %s rate must be between 0 and 1", name)
+		}
+	}
+	if c.Latency < 0 {
+		return fmt.Errorf("chaos latency must not be negative")
+	}
+	return nil
+}
+
+// Injector decides, per call, whether to inject a fault
+// A nil *Injector is valid and never injects anything
+type Injector struct {
+	cfg Config
+}
+
+// NewInjector creates an injector with the given rates
+func NewInjector(cfg Config) *Injector {
+	return &Injector{cfg: cfg}
+}
+
+// roll returns true with probability rate and counts the injection
+func (i *Injector) roll(fault string, rate float64) bool {
+	if i == nil || rate <= 0 || rand.Float64() >= rate {
+		return false
+	}
+	metrics.ChaosInjectionsTotal.WithLabelValues(fault).Inc()
+	return true
+}
+
+// Delay sleeps for the configured latency on a fraction of calls
+// It returns early if ctx is done
+func (i *Injector) Delay(ctx context.Context) {
+	if !i.roll("latency", i.latencyRate()) {
+		return
+	}
+	timer := time.NewTimer(i.cfg.Latency)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
+
+// latencyRate is zero when no latency is configured
+func (i *Injector) latencyRate() float64 {
+	if i == nil || i.cfg.Latency == 0 {
+		return 0
+	}
+	return i.cfg.LatencyRate
+}
+
+// PostgresFault fails a fraction of Postgres calls; install it with
+// breaker.SetFaultInjector so injected errors trip the breaker like real ones
+func (i *Injector) PostgresFault() error {
+	if i != nil && i.roll("postgres", i.cfg.PostgresErrorRate) {
+		return fmt.Errorf("%w: postgres unavailable", ErrInjected)
+	}
+	return nil
+}
+
+// RedisHook fails a fraction of Redis commands. Add it after the breaker hook
+// so the breaker observes injected failures
+func (i *Injector) RedisHook() redis.Hook {
+	return redisHook{injector: i}
+}
+
+// redisHook injects connection-style failures into go-redis
+type redisHook struct {
+	injector *Injector
+}
+
+// DialHook implements redis.Hook
+func (h redisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook
+func (h redisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		if err := h.fault(); err != nil {
+			cmd.SetErr(err)
+			return err
+		}
+		return next(ctx, cmd)
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (h redisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		if err := h.fault(); err != nil {
+			for _, cmd := range cmds {
+				cmd.SetErr(err)
+			}
+			return err
+		}
+		return next(ctx, cmds)
+	}
+}
+
+// fault rolls once per command or pipeline
+func (h redisHook) fault() error {
+	if h.injector.roll("redis", h.injector.cfg.RedisErrorRate) {
+		return fmt.Errorf("%w: redis connection reset", ErrInjected)
+	}
+	return nil
+}
+
+// WrapModel makes a fraction of model-provider calls hang until the caller's
+// deadline (at most modelHang) and fail with context.DeadlineExceeded
+func (i *Injector) WrapModel(client analyzer.ModelClient) analyzer.ModelClient {
+	if i == nil || i.cfg.ModelTimeoutRate <= 0 {
+		return client
+	}
+	return &modelClient{next: client, injector: i}
+}
+
+// modelClient injects provider timeouts in front of a real model client
+type modelClient struct {
+	next     analyzer.ModelClient
+	injector *Injector
+}
+
+// Evaluate implements analyzer.ModelClient
+func (m *modelClient) Evaluate(ctx context.Context, model string, content string) (analyzer.ModelEvaluation, error) {
+	if !m.injector.roll("model_timeout", m.injector.cfg.ModelTimeoutRate) {
+		return m.next.Evaluate(ctx, model, content)
+	}
+
+	timer := time.NewTimer(modelHang)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+	return analyzer.ModelEvaluation{}, fmt.Errorf("%w: model provider timeout: %w", ErrInjected, context.DeadlineExceeded)
+}
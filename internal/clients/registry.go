@@ -0,0 +1,192 @@
+package clients
+
+import (
+	"context"
+	"log/slog"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Trust tiers used by policy tier_actions
+const (
+	TierTrusted   = "trusted"
+	TierStandard  = "standard"
+	TierUntrusted = "untrusted"
+	TierAnonymous = "anonymous" // Client is not in the registry
+)
+
+// Client default actions, applied when no policy matched
+const (
+	DefaultAllow = "allow"
+	DefaultBlock = "block" // Allowlist model: only requests matching an allow policy pass
+)
+
+// statsDays is the audit history window used for violation rates
+const statsDays = 30
+
+// Score derives a 0-100 trust score from verification status, historical
+// violation rate and manual adjustment
+func Score(c models.Client) float64 {
+	score := 50.0
+	if c.Verified {
+		score += 30
+	}
+	if c.RequestCount > 0 {
+		violationRate := float64(c.ViolationCount) / float64(c.RequestCount)
+		score -= violationRate * 60
+	}
+	score += c.TrustAdjustment
+	return math.Max(0, math.Min(100, score))
+}
+
+// Tier maps a trust score to a trust tier
+func Tier(score float64) string {
+	switch {
+	case score >= 70:
+		return TierTrusted
+	case score >= 40:
+		return TierStandard
+	default:
+		return TierUntrusted
+	}
+}
+
+// Registry keeps scored clients in memory with periodic refresh
+type Registry struct {
+	repo     *Repository
+	clients  map[string]models.Client
+	mu       sync.RWMutex // Protects clients map
+	interval time.Duration
+	stopChan chan struct{}
+	stopOnce sync.Once
+	breaker  *breaker.Breaker // Optional; while open, the last scores are served
+}
+
+// NewRegistry creates a client registry refreshed every interval
+func NewRegistry(repo *Repository, interval time.Duration) *Registry {
+	return &Registry{
+		repo:     repo,
+		clients:  make(map[string]models.Client),
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetBreaker guards registry reloads with a circuit breaker
+// Must be called before Start
+func (r *Registry) SetBreaker(dbBreaker *breaker.Breaker) {
+	r.breaker = dbBreaker
+}
+
+// Start performs the initial load and starts the refresh worker
+// The worker runs even when the initial load fails, so a caller tolerating the
+// error (e.g. a warm start) picks up clients once the database answers
+func (r *Registry) Start(ctx context.Context) error {
+	go r.refreshWorker(ctx)
+	if err := r.Refresh(ctx); err != nil {
+		return err
+	}
+	slog.Info("Client registry initialized", "clients", len(r.clients), "refresh", r.interval)
+	return nil
+}
+
+// refreshWorker reloads scores periodically
+func (r *Registry) refreshWorker(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := r.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh client registry, serving stale scores", "error", err)
+			}
+		case <-r.stopChan:
+			slog.Info("Client registry refresh worker stopped")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh reloads clients and recomputes trust scores
+func (r *Registry) Refresh(ctx context.Context) error {
+	var list []models.Client
+	err := r.breaker.Do(func() error {
+		var err error
+		list, err = r.repo.ListWithStats(ctx, statsDays)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	clients := make(map[string]models.Client, len(list))
+	for _, c := range list {
+		c.TrustScore = Score(c)
+		c.TrustTier = Tier(c.TrustScore)
+		clients[c.ID] = c
+	}
+
+	r.mu.Lock()
+	r.clients = clients
+	r.mu.Unlock()
+
+	return nil
+}
+
+// Get returns a scored client; unregistered clients are anonymous
+func (r *Registry) Get(id string) (models.Client, bool) {
+	r.mu.RLock()
+	c, ok := r.clients[id]
+	r.mu.RUnlock()
+
+	if !ok {
+		return models.Client{ID: id, TrustTier: TierAnonymous, DefaultAction: DefaultAllow}, false
+	}
+	return c, true
+}
+
+// TierFor returns the trust tier for a client ID
+func (r *Registry) TierFor(id string) string {
+	c, _ := r.Get(id)
+	return c.TrustTier
+}
+
+// List returns all registered clients sorted by ID
+func (r *Registry) List() []models.Client {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	list := make([]models.Client, 0, len(r.clients))
+	for _, c := range r.clients {
+		list = append(list, c)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+	return list
+}
+
+// Upsert registers or updates a client and rescores the registry
+func (r *Registry) Upsert(ctx context.Context, id string, req models.UpsertClientRequest) (models.Client, error) {
+	if err := r.repo.Upsert(ctx, id, req); err != nil {
+		return models.Client{}, err
+	}
+	if err := r.Refresh(ctx); err != nil {
+		return models.Client{}, err
+	}
+	c, _ := r.Get(id)
+	return c, nil
+}
+
+// Stop gracefully stops the refresh worker
+func (r *Registry) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+	})
+}
@@ -0,0 +1,99 @@
+package clients
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestScore(t *testing.T) {
+	tests := []struct {
+		name   string
+		client models.Client
+		want   float64
+	}{
+		{"new client", models.Client{}, 50},
+		{"verified", models.Client{Verified: true}, 80},
+		{"violations", models.Client{RequestCount: 100, ViolationCount: 50}, 20},
+		{"adjusted", models.Client{Verified: true, TrustAdjustment: -15}, 65},
+		{"clamped high", models.Client{Verified: true, TrustAdjustment: 50}, 100},
+		{"clamped low", models.Client{RequestCount: 10, ViolationCount: 10, TrustAdjustment: -20}, 0},
+	}
+	for _, tt := range tests {
+		if got := Score(tt.client); got != tt.want {
+			t.Errorf("Score(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTier(t *testing.T) {
+	tests := []struct {
+		score float64
+		want  string
+	}{
+		{100, TierTrusted},
+		{70, TierTrusted},
+		{69.9, TierStandard},
+		{40, TierStandard},
+		{39.9, TierUntrusted},
+		{0, TierUntrusted},
+	}
+	for _, tt := range tests {
+		if got := Tier(tt.score); got != tt.want {
+			t.Errorf("Tier(%v) = %q, want %q", tt.score, got, tt.want)
+		}
+	}
+}
+
+func TestRegistry_GetAndList(t *testing.T) {
+	r := NewRegistry(nil, time.Minute)
+	r.clients = map[string]models.Client{
+		"b": {ID: "b", TrustTier: TierStandard},
+		"a": {ID: "a", TrustTier: TierTrusted},
+	}
+
+	if c, ok := r.Get("a"); !ok || c.TrustTier != TierTrusted {
+		t.Errorf("Get(a) = %+v, %v, want trusted", c, ok)
+	}
+	c, ok := r.Get("unknown")
+	if ok || c.ID != "unknown" || c.TrustTier != TierAnonymous || c.DefaultAction != DefaultAllow {
+		t.Errorf("Get(unknown) = %+v, %v, want anonymous allow", c, ok)
+	}
+	if tier := r.TierFor("unknown"); tier != TierAnonymous {
+		t.Errorf("TierFor(unknown) = %q, want anonymous", tier)
+	}
+	if list := r.List(); len(list) != 2 || list[0].ID != "a" || list[1].ID != "b" {
+		t.Errorf("List() = %+v, want a, b", list)
+	}
+}
+
+func TestRegistry_RefreshKeepsStaleScoresWhileBreakerOpen(t *testing.T) {
+	r := NewRegistry(nil, time.Minute)
+	r.clients = map[string]models.Client{"a": {ID: "a", TrustTier: TierTrusted}}
+	b := breaker.New("clients_test", 1, time.Hour)
+	b.Record(errors.New("connection refused"))
+	r.SetBreaker(b)
+
+	if err := r.Refresh(context.Background()); !errors.Is(err, breaker.ErrOpen) {
+		t.Errorf("Refresh() error = %v, want breaker.ErrOpen", err)
+	}
+	if c, ok := r.Get("a"); !ok || c.TrustTier != TierTrusted {
+		t.Errorf("Get(a) = %+v, %v after failed refresh, want the stale client", c, ok)
+	}
+}
+
+func TestRepository_UpsertValidates(t *testing.T) {
+	// Invalid requests are rejected before the database is touched
+	repo := NewRepository(nil)
+	deny := "deny"
+	if err := repo.Upsert(context.Background(), "", models.UpsertClientRequest{}); err == nil {
+		t.Error("Upsert without id succeeded")
+	}
+	if err := repo.Upsert(context.Background(), "svc", models.UpsertClientRequest{DefaultAction: &deny}); err == nil {
+		t.Error("Upsert with default_action deny succeeded")
+	}
+}
@@ -0,0 +1,113 @@
+package clients
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// ErrNotFound is returned when a client is not registered
+var ErrNotFound = errors.New("client not found")
+
+// Repository handles client registry data access
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new clients Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// ListWithStats returns all registered clients with decision counts from the
+// last statsDays days of audit logs. Trust scores are filled in by the caller.
+func (r *Repository) ListWithStats(ctx context.Context, statsDays int) ([]models.Client, error) {
+	query := `
+		SELECT c.id, COALESCE(c.name, ''), c.verified, c.trust_adjustment, c.require_nonce, c.default_action,
+		       COALESCE(s.requests, 0), COALESCE(s.violations, 0),
+		       c.labels, c.created_at, c.updated_at
+		FROM clients c
+		LEFT JOIN (
+			SELECT client_id,
+			       COUNT(*) AS requests,
+			       COUNT(*) FILTER (WHERE action_taken <> 'allow') AS violations
+			FROM audit_logs
+			WHERE created_at > NOW() - make_interval(days => $1)
+			GROUP BY client_id
+		) s ON s.client_id = c.id
+		ORDER BY c.id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, statsDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query clients: %w", err)
+	}
+	defer rows.Close()
+
+	clients := make([]models.Client, 0)
+	for rows.Next() {
+		var c models.Client
+		var labels []byte
+		err := rows.Scan(
+			&c.ID, &c.Name, &c.Verified, &c.TrustAdjustment, &c.RequireNonce, &c.DefaultAction,
+			&c.RequestCount, &c.ViolationCount,
+			&labels, &c.CreatedAt, &c.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan client: %w", err)
+		}
+		if err := json.Unmarshal(labels, &c.Labels); err != nil {
+			return nil, fmt.Errorf("invalid labels for client %s: %w", c.ID, err)
+		}
+		clients = append(clients, c)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating clients: %w", err)
+	}
+
+	return clients, nil
+}
+
+// Upsert registers a client or updates the provided fields of an existing one
+func (r *Repository) Upsert(ctx context.Context, id string, req models.UpsertClientRequest) error {
+	if id == "" {
+		return fmt.Errorf("client id is required")
+	}
+	if req.DefaultAction != nil && *req.DefaultAction != DefaultAllow && *req.DefaultAction != DefaultBlock {
+		return fmt.Errorf("default_action must be allow or block")
+	}
+
+	// NULL labels leave existing labels untouched
+	var labels []byte
+	if req.Labels != nil {
+		var err error
+		if labels, err = json.Marshal(req.Labels); err != nil {
+			return fmt.Errorf("invalid labels: %w", err)
+		}
+	}
+
+	query := `
+		INSERT INTO clients (id, name, verified, trust_adjustment, labels, require_nonce, default_action)
+		VALUES ($1, NULLIF($2, ''), COALESCE($3, false), COALESCE($4, 0), COALESCE($5::jsonb, '{}'), COALESCE($6, false), COALESCE($7, 'allow'))
+		ON CONFLICT (id) DO UPDATE SET
+			name = COALESCE(NULLIF($2, ''), clients.name),
+			verified = COALESCE($3, clients.verified),
+			trust_adjustment = COALESCE($4, clients.trust_adjustment),
+			labels = COALESCE($5::jsonb, clients.labels),
+			require_nonce = COALESCE($6, clients.require_nonce),
+			default_action = COALESCE($7, clients.default_action),
+			updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, id, req.Name, req.Verified, req.TrustAdjustment, labels, req.RequireNonce, req.DefaultAction)
+	if err != nil {
+		return fmt.Errorf("failed to upsert client: %w", err)
+	}
+
+	return nil
+}
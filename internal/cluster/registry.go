@@ -0,0 +1,156 @@
+// Package cluster keeps a Redis registry of gateway replicas so fleet state
+// (versions, policy snapshots, health) can be inspected from any replica
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// registryKey is the Redis hash of replica ID → JSON ClusterMember
+const registryKey = "gateway_cluster"
+
+// Heartbeats missed before a replica is reported stale, and before it is
+// removed from the registry
+const (
+	staleHeartbeats  = 3
+	expireHeartbeats = 20
+)
+
+// Registry publishes this replica's state on every heartbeat and reads the fleet's
+type Registry struct {
+	rdb      *redis.Client
+	interval time.Duration
+	report   func() models.ClusterMember // Current state of this replica
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewRegistry creates a registry publishing report() every interval
+func NewRegistry(rdb *redis.Client, interval time.Duration, report func() models.ClusterMember) *Registry {
+	return &Registry{
+		rdb:      rdb,
+		interval: interval,
+		report:   report,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start registers this replica and runs the heartbeat worker
+// Registry failures are logged, never fatal: the registry is diagnostic only
+func (r *Registry) Start(ctx context.Context) {
+	if err := r.heartbeat(ctx); err != nil {
+		slog.Warn("Failed to register in cluster registry", "error", err)
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.heartbeat(ctx); err != nil {
+					slog.Warn("Cluster heartbeat failed", "error", err)
+				}
+			case <-r.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Cluster registry started", "heartbeat", r.interval)
+}
+
+// heartbeat publishes the current state of this replica
+func (r *Registry) heartbeat(ctx context.Context) error {
+	member := r.report()
+	member.LastSeen = time.Now()
+	data, err := json.Marshal(member)
+	if err != nil {
+		return fmt.Errorf("failed to encode member: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, r.interval)
+	defer cancel()
+	return r.rdb.HSet(ctx, registryKey, member.InstanceID, data).Err()
+}
+
+// State lists the registered replicas, removing those silent for too long
+func (r *Registry) State(ctx context.Context) (models.ClusterState, error) {
+	entries, err := r.rdb.HGetAll(ctx, registryKey).Result()
+	if err != nil {
+		return models.ClusterState{}, fmt.Errorf("failed to read cluster registry: %w", err)
+	}
+
+	now := time.Now()
+	members := make([]models.ClusterMember, 0, len(entries))
+	var expired []string
+	for id, data := range entries {
+		var m models.ClusterMember
+		if err := json.Unmarshal([]byte(data), &m); err != nil {
+			slog.Warn("Invalid cluster registry entry", "instance_id", id, "error", err)
+			expired = append(expired, id)
+			continue
+		}
+		if now.Sub(m.LastSeen) > expireHeartbeats*r.interval {
+			expired = append(expired, id)
+			continue
+		}
+		members = append(members, m)
+	}
+	if len(expired) > 0 {
+		if err := r.rdb.HDel(ctx, registryKey, expired...).Err(); err != nil {
+			slog.Warn("Failed to remove expired cluster members", "error", err)
+		}
+	}
+	return summarize(members, now, staleHeartbeats*r.interval), nil
+}
+
+// summarize marks members silent for longer than staleAfter as stale and
+// compares the policy snapshots and versions of the live ones
+func summarize(members []models.ClusterMember, now time.Time, staleAfter time.Duration) models.ClusterState {
+	state := models.ClusterState{
+		Members:      members,
+		PolicyHashes: make(map[string]int),
+		Versions:     make(map[string]int),
+	}
+	for i := range state.Members {
+		m := &state.Members[i]
+		if now.Sub(m.LastSeen) > staleAfter {
+			m.Status = "stale"
+			continue
+		}
+		state.PolicyHashes[m.PolicyHash]++
+		state.Versions[m.GatewayVersion]++
+	}
+	sort.Slice(state.Members, func(i, j int) bool { return state.Members[i].InstanceID < state.Members[j].InstanceID })
+	state.Consistent = len(state.PolicyHashes) <= 1 && len(state.Versions) <= 1
+	return state
+}
+
+// Stop stops the heartbeat and deregisters this replica
+func (r *Registry) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		r.wg.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		if err := r.rdb.HDel(ctx, registryKey, r.report().InstanceID).Err(); err != nil {
+			slog.Warn("Failed to deregister from cluster registry", "error", err)
+		}
+	})
+}
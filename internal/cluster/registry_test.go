@@ -0,0 +1,43 @@
+package cluster
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestSummarize(t *testing.T) {
+	now := time.Now()
+	member := func(id, hash, version string, lastSeen time.Duration) models.ClusterMember {
+		return models.ClusterMember{
+			GatewayOrigin: models.GatewayOrigin{InstanceID: id, GatewayVersion: version},
+			Status:        "healthy",
+			PolicyHash:    hash,
+			LastSeen:      now.Add(-lastSeen),
+		}
+	}
+
+	state := summarize([]models.ClusterMember{
+		member("b", "h1", "1.0.0", time.Second),
+		member("a", "h1", "1.0.0", 2*time.Second),
+		member("c", "h0", "0.9.0", time.Hour),
+	}, now, time.Minute)
+	if !state.Consistent {
+		t.Error("Consistent = false, want true: the only divergent member is stale")
+	}
+	if state.Members[0].InstanceID != "a" || state.Members[2].Status != "stale" {
+		t.Errorf("members = %+v, want sorted with c stale", state.Members)
+	}
+	if state.PolicyHashes["h1"] != 2 || state.PolicyHashes["h0"] != 0 {
+		t.Errorf("PolicyHashes = %v, want only live members counted", state.PolicyHashes)
+	}
+
+	state = summarize([]models.ClusterMember{
+		member("a", "h1", "1.0.0", time.Second),
+		member("b", "h2", "1.0.0", time.Second),
+	}, now, time.Minute)
+	if state.Consistent {
+		t.Error("Consistent = true with two policy snapshots, want false")
+	}
+}
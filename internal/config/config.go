@@ -3,6 +3,7 @@ package config
 import (
 	"fmt"
 	"os"
+	"strconv"
 )
 
 // Config holds application configuration
@@ -11,18 +12,145 @@ type Config struct {
 	DatabaseURL       string
 	RedisURL          string
 	LogLevel          string
+	LogFormat         string  // Log output: text or json
+	TraceEndpoint     string  // OTLP/HTTP collector endpoint spans are exported to (tracing is off when empty)
+	TraceServiceName  string  // service.name reported with exported spans
 	AuditBufferSize   int // Audit logger buffer size
 	AuditWorkers      int // Number of audit log workers
+	AuditBlockMs      int     // Milliseconds an audit entry waits for a full buffer before the synchronous fallback (0 = no wait)
 	DBMaxOpenConns    int // Maximum number of open database connections
 	DBMaxIdleConns    int // Maximum number of idle database connections
+	DBPoolWaitMs      int     // Max wait for a connection when the pool is saturated (0 waits until the request timeout)
 	RequestTimeout    int // Request timeout in seconds
 	RedisPoolSize     int // Maximum number of Redis connections in pool
 	RedisMinIdle      int // Minimum number of idle Redis connections
 	RedisPoolTimeout  int // Redis pool timeout in seconds
-	RedisMaxRetries   int    // Maximum number of retries for Redis commands
-	RedisSyncInterval int    // Redis to Postgres sync interval in seconds
-	NemoAPIKey        string // NVIDIA NeMo API Key
-	NemoEndpoint      string // NVIDIA NeMo API Endpoint
+	RedisMaxRetries   int     // Maximum number of retries for Redis commands
+	RedisSyncInterval int     // Redis to Postgres sync interval in seconds
+	AuditBacklogWarn  int     // Queued audit logs that speed up the sync worker (0 = disabled)
+	AuditBacklogCrit  int     // Queued audit logs that sync every second with large batches
+	AuditWALDir       string  // Directory spilling audit entries while Redis and Postgres are down (disabled when empty)
+	AuditWALMaxMB     int     // Disk space bound of the audit WAL
+	AuditOwnerRegion  string  // Region persisting audit logs to Postgres (every region persists its own when empty)
+	AuditForwardMode  string  // How non-owner regions ship audit batches to the owner: api or redis
+	AuditForwardURL   string  // Owner gateway base URL (api) or owner Redis URL (redis)
+	AuditForwardToken string  // Bearer token sent to the owner's ingest endpoint (api mode)
+	AuditIngestToken  string  // Token accepted by POST /v1/audit/ingest on the owner (disabled when empty)
+	AuditHashAlgo     string  // Content hash of audit entries and the prompt allowlist: sha256, sha512 or blake3
+	AuditHashCanon    bool    // Normalize Unicode and whitespace before hashing content
+	RedisUsername     string  // Redis ACL user (overrides REDIS_URL)
+	RedisPassword     string  // Redis password (overrides REDIS_URL)
+	RedisDB           int     // Redis database index (-1 uses REDIS_URL's)
+	RedisTLS          bool    // Use TLS even for redis:// URLs
+	RedisTLSCAFile    string  // PEM bundle of CAs trusted for the Redis server (private CA)
+	RedisTLSCertFile  string  // Client certificate for mutual TLS
+	RedisTLSKeyFile   string  // Client key for mutual TLS
+	RedisTLSServer    string  // Expected server name when it differs from the URL host
+	NemoAPIKey        string  // NVIDIA NeMo API Key
+	NemoEndpoint      string  // NVIDIA NeMo API Endpoint
+	ModelDegradation  bool    // Skip model policies while their provider is down instead of failing
+	EnforcementMode   string  // "enforce" or "monitor" (decisions computed and logged, never enforced)
+	NormalizeEvasions bool    // Match pattern policies against de-obfuscated content too
+	BreakerThreshold  int     // Consecutive Postgres/Redis/model failures that open a circuit
+	BreakerCooldown   int     // Seconds before an open circuit lets a trial call through
+	DrainTimeout      int     // Seconds to wait for in-flight work and audit buffers to drain
+	ReplayWindow      int     // Seconds a request timestamp may deviate from gateway time
+	AdminAPIKey       string  // Shared secret for privileged endpoints (disabled when empty)
+	AlertWebhookURL   string  // Destination for alert events (log-only when empty)
+	AlertRulesFile    string  // Path to a JSON file of alert rules (disabled when empty)
+	UserMessagesFile  string  // Path to a JSON catalog of end-user messages (user_message disabled when empty)
+	ExtAuthzPort      string  // gRPC port for the Envoy ext_authz filter (disabled when empty)
+	ExtAuthzFailOpen  bool    // Allow traffic through Envoy when evaluation fails
+	ExtAuthzTrustHdr  bool    // Let the x-client-id header override the peer's mTLS principal
+	StreamGRPCPort    string  // gRPC port for the StreamAnalyze continuous guarding stream (disabled when empty)
+	GRPCPort          string  // gRPC port for AnalyzeService and PolicyService (disabled when empty)
+	DecisionTokenAlg  string  // "EdDSA" or "HS256"
+	DecisionTokenKey  string  // Base64 Ed25519 seed or HMAC secret (tokens disabled when empty)
+	DecisionTokenTTL  int     // Token lifetime in seconds
+	ProxyEnabled      bool    // Serve /proxy/{provider}/... in front of LLM APIs
+	OpenAIUpstream    string  // Base URL for OpenAI-compatible upstreams
+	AnthropicUpstream string  // Base URL for the Anthropic Messages API
+	GeminiUpstream    string  // Base URL for the Google Gemini API
+	AnomalyEnabled    bool    // Enable the background anomaly detector
+	AnomalyWindow     int     // Anomaly detector window in seconds
+	AnomalyZScore     float64 // Standard deviations above baseline that trigger an alert
+	FeatureFlags      string  // Comma-separated name=on|off|percentage rollouts
+	FeatureFlagsFile  string  // Path to a JSON array of feature flags (disabled when empty)
+	PolicyEvalTimeout int     // Milliseconds one evaluation may wait for policies (0 = no limit)
+	MatchMode         string  // "all" reports every matching policy, "first" stops at the first match
+	SlowPolicyMs      int     // Average evaluation time above which a policy is reported slow
+	LintBroadPercent  float64 // Percent of the benign corpus a linted policy may match before it is reported broad
+	PolicyReviewHours int     // Hours between reminders for policies past review_by (0 = off)
+	PolicyLimit       int     // Enabled policies allowed per tenant (0 = unlimited)
+	PolicyWritesRate  int     // Policy write requests per tenant per minute (0 = unlimited)
+	PolicyChangeLimit int     // Policies changed per tenant per minute without ?confirm=true (0 = unlimited)
+	PolicySnapshot    string  // File the policy snapshot is saved to on shutdown and warm-started from (disabled when empty)
+	TokenizerVocab    string  // tiktoken vocabulary file (token counts are estimated when empty)
+	ModelMaxTokens    int     // Tokens of content sent to model policies (0 = unlimited)
+	ModelPrivacyFile  string  // Path to JSON model provider privacy rules (content sent as is when empty)
+	ModelRoutesFile   string  // Path to JSON per-language guard model routes (no routing when empty)
+	DecisionCacheTTL  int     // Seconds an analyze decision may be reused (0 = no cache)
+	DecisionCacheSize int     // Maximum number of cached decisions
+	ChaosEnabled      bool    // Inject faults for resilience testing (staging only)
+	ChaosLatencyMs    int     // Artificial latency added to analyze requests
+	ChaosLatencyRate  float64 // Fraction of analyze requests delayed
+	ChaosDBErrorRate  float64 // Fraction of Postgres calls failed
+	ChaosRedisErrRate float64 // Fraction of Redis commands failed
+	ChaosModelErrRate float64 // Fraction of model-provider calls timed out
+	FirehoseURL       string  // HTTP endpoint receiving mirrored decisions as NDJSON
+	FirehoseToken     string  // Bearer token sent to FirehoseURL
+	FirehoseStream    string  // Redis stream receiving mirrored decisions instead of HTTP
+	FirehoseMaxLen    int     // Approximate maximum length of the Redis stream
+	FirehoseBuffer    int     // Decisions queued before new ones are dropped
+	FirehoseBatchSize int     // Decisions per delivery
+	FirehoseFlushMs   int     // Maximum wait before a partial batch is delivered
+	FirehoseRetries   int     // Delivery retries before a batch is dropped
+	ShadowURL         string  // Base URL of a gateway receiving mirrored analyze requests (disabled when empty)
+	ShadowToken       string  // Bearer token sent to ShadowURL
+	ShadowSampleRate  float64 // Fraction of analyze requests mirrored to ShadowURL
+	ShadowBuffer      int     // Mirrored requests queued before new ones are dropped
+	ShadowWorkers     int     // Concurrent requests to ShadowURL
+	ShadowTimeoutMs   int     // Timeout of one mirrored request
+	AnalyzeSlots      int     // Concurrent evaluations (0 = unlimited, no priority scheduling)
+	BatchSlots        int     // Slots batch-priority requests may hold (0 = half of AnalyzeSlots)
+	BatchQueueLimit   int     // Batch requests allowed to wait before new ones are rejected
+	BatchWorkers      int     // Items of one POST /v1/analyze/batch evaluated concurrently
+	BatchMaxItems     int     // Items accepted per POST /v1/analyze/batch
+	MaxPolicies       int     // Policies evaluated per analyze request, most severe first (0 = unlimited)
+	MaxMatches        int     // Matches per triggered_policies list and occurrences per match (0 = unlimited)
+	MaxRedactions     int     // Redaction passes per analyze request; past it the request is blocked (0 = unlimited)
+	InstanceID        string  // Gateway instance recorded on audit entries (defaults to the hostname)
+	Region            string  // Deployment region recorded on audit entries
+	Zone              string  // Deployment zone recorded on audit entries
+	TenantSchemas     string  // tenant=schema pairs isolated in their own schema of DATABASE_URL
+	TenantDatabases   string  // tenant=dsn pairs isolated in their own database
+	FingerprintDays   int     // Days a blocked prompt fingerprint is kept after it was last seen
+	RollupInterval    int     // Seconds between audit roll-up refreshes (0 = off, no /v1/stats)
+	RollupLookback    int     // Hours of audit entries re-aggregated on every roll-up refresh
+	ClusterHeartbeat  int     // Seconds between cluster registry heartbeats
+	AllowlistRefresh  int     // Seconds between reloads of the prompt allowlist from Redis
+	AnalyzeSLOs       string  // threshold_ms:objective latency SLO targets for /v1/analyze ("off" disables)
+	ReportPeriods     string  // Comma-separated tenant usage report periods: weekly, monthly (disabled when empty)
+	ReportFormat      string  // Usage report format: json or csv
+	ReportSink        string  // Usage report delivery: webhook, s3 or email
+	ReportWebhookURL  string  // Endpoint receiving usage reports (webhook sink)
+	ReportToken       string  // Bearer token sent to ReportWebhookURL
+	ReportS3Bucket    string  // Bucket receiving usage reports (s3 sink)
+	ReportS3Prefix    string  // Key prefix of usage report objects
+	ReportS3Region    string  // Region of ReportS3Bucket
+	ReportS3Endpoint  string  // S3-compatible endpoint (AWS for ReportS3Region when empty)
+	AWSAccessKeyID    string  // Access key of the s3 sink
+	AWSSecretKey      string  // Secret key of the s3 sink
+	AWSSessionToken   string  // Session token of temporary s3 sink credentials
+	ReportSMTPAddr    string  // SMTP server host:port (email sink)
+	ReportSMTPUser    string  // SMTP PLAIN auth user (no auth when empty)
+	ReportSMTPPass    string  // SMTP PLAIN auth password
+	ReportEmailFrom   string  // Sender of usage report emails
+	ReportEmailTo     string  // Comma-separated recipients of usage report emails
+	ContentKey        string  // Base64 32-byte master key wrapping the data keys of retained content (plaintext when empty)
+	ContentKeyID      string  // Name of ContentKey, stored with every data key it wraps
+	ContentKeyPrev    string  // Previous master key, kept until `gateway rotate-keys` rewrapped its data keys
+	ContentKeyPrevID  string  // Name of ContentKeyPrev
 }
 
 // Load reads configuration from environment variables
@@ -32,18 +160,145 @@ func Load() (*Config, error) {
 		DatabaseURL:      getEnv("DATABASE_URL", ""),
 		RedisURL:         getEnv("REDIS_URL", ""),
 		LogLevel:         getEnv("LOG_LEVEL", "debug"),
+		LogFormat:         getEnv("LOG_FORMAT", "text"),
+		TraceEndpoint:     getEnv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT", getEnv("OTEL_EXPORTER_OTLP_ENDPOINT", "")),
+		TraceServiceName:  getEnv("OTEL_SERVICE_NAME", "prompt-gateway"),
 		AuditBufferSize:  getEnvAsInt("AUDIT_BUFFER_SIZE", 1000),
 		AuditWorkers:     getEnvAsInt("AUDIT_WORKERS", 5),
+		AuditBlockMs:      getEnvAsInt("AUDIT_BLOCK_TIMEOUT_MS", 0),
 		DBMaxOpenConns:   getEnvAsInt("DB_MAX_OPEN_CONNS", 20),
 		DBMaxIdleConns:   getEnvAsInt("DB_MAX_IDLE_CONNS", 20),
+		DBPoolWaitMs:      getEnvAsInt("DB_POOL_WAIT_TIMEOUT_MS", 2000),
 		RequestTimeout:   getEnvAsInt("REQUEST_TIMEOUT", 300),
 		RedisPoolSize:     getEnvAsInt("REDIS_POOL_SIZE", 100),
 		RedisMinIdle:      getEnvAsInt("REDIS_MIN_IDLE", 20),
 		RedisPoolTimeout:  getEnvAsInt("REDIS_POOL_TIMEOUT", 4),
 		RedisMaxRetries:   getEnvAsInt("REDIS_MAX_RETRIES", 3),
 		RedisSyncInterval: getEnvAsInt("REDIS_SYNC_INTERVAL", 120),
+		AuditBacklogWarn:  getEnvAsInt("AUDIT_BACKLOG_WARN", 50000),
+		AuditBacklogCrit:  getEnvAsInt("AUDIT_BACKLOG_CRITICAL", 200000),
+		AuditWALDir:       getEnv("AUDIT_WAL_DIR", ""),
+		AuditWALMaxMB:     getEnvAsInt("AUDIT_WAL_MAX_MB", 1024),
+		AuditOwnerRegion:  getEnv("AUDIT_OWNER_REGION", ""),
+		AuditForwardMode:  getEnv("AUDIT_FORWARD_MODE", "api"),
+		AuditForwardURL:   getEnv("AUDIT_FORWARD_URL", ""),
+		AuditForwardToken: getEnv("AUDIT_FORWARD_TOKEN", ""),
+		AuditIngestToken:  getEnv("AUDIT_INGEST_TOKEN", ""),
+		AuditHashAlgo:     getEnv("AUDIT_HASH_ALGORITHM", "sha256"),
+		AuditHashCanon:    getEnvAsBool("AUDIT_HASH_CANONICALIZE", false),
+		RedisUsername:     getEnv("REDIS_USERNAME", ""),
+		RedisPassword:     getEnv("REDIS_PASSWORD", ""),
+		RedisDB:           getEnvAsInt("REDIS_DB", -1),
+		RedisTLS:          getEnvAsBool("REDIS_TLS", false),
+		RedisTLSCAFile:    getEnv("REDIS_TLS_CA_FILE", ""),
+		RedisTLSCertFile:  getEnv("REDIS_TLS_CERT_FILE", ""),
+		RedisTLSKeyFile:   getEnv("REDIS_TLS_KEY_FILE", ""),
+		RedisTLSServer:    getEnv("REDIS_TLS_SERVER_NAME", ""),
 		NemoAPIKey:        getEnv("NVIDIA_NEMO_API", ""),
 		NemoEndpoint:      getEnv("NVIDIA_NEMO_ENDPOINT", ""),
+		ModelDegradation:  getEnvAsBool("MODEL_DEGRADATION", true),
+		EnforcementMode:   getEnv("ENFORCEMENT_MODE", "enforce"),
+		NormalizeEvasions: getEnvAsBool("EVASION_NORMALIZATION", true),
+		BreakerThreshold:  getEnvAsInt("BREAKER_FAILURE_THRESHOLD", 5),
+		BreakerCooldown:   getEnvAsInt("BREAKER_COOLDOWN", 10),
+		DrainTimeout:      getEnvAsInt("MAINTENANCE_DRAIN_TIMEOUT", 120),
+		ReplayWindow:      getEnvAsInt("REPLAY_WINDOW", 300),
+		AdminAPIKey:       getEnv("ADMIN_API_KEY", ""),
+		AlertWebhookURL:   getEnv("ALERT_WEBHOOK_URL", ""),
+		AlertRulesFile:    getEnv("ALERT_RULES_FILE", ""),
+		UserMessagesFile:  getEnv("USER_MESSAGE_CATALOG", ""),
+		ExtAuthzPort:      getEnv("EXT_AUTHZ_PORT", ""),
+		ExtAuthzFailOpen:  getEnvAsBool("EXT_AUTHZ_FAIL_OPEN", false),
+		ExtAuthzTrustHdr:  getEnvAsBool("EXT_AUTHZ_TRUST_CLIENT_HEADER", false),
+		StreamGRPCPort:    getEnv("STREAM_GRPC_PORT", ""),
+		GRPCPort:          getEnv("GRPC_PORT", ""),
+		DecisionTokenAlg:  getEnv("DECISION_TOKEN_ALG", "EdDSA"),
+		DecisionTokenKey:  getEnv("DECISION_TOKEN_KEY", ""),
+		DecisionTokenTTL:  getEnvAsInt("DECISION_TOKEN_TTL", 300),
+		ProxyEnabled:      getEnvAsBool("PROXY_ENABLED", false),
+		OpenAIUpstream:    getEnv("OPENAI_UPSTREAM_URL", "https://api.openai.com"),
+		AnthropicUpstream: getEnv("ANTHROPIC_UPSTREAM_URL", "https://api.anthropic.com"),
+		GeminiUpstream:    getEnv("GEMINI_UPSTREAM_URL", "https://generativelanguage.googleapis.com"),
+		AnomalyEnabled:    getEnvAsBool("ANOMALY_DETECTION_ENABLED", false),
+		AnomalyWindow:     getEnvAsInt("ANOMALY_WINDOW", 60),
+		AnomalyZScore:     getEnvAsFloat("ANOMALY_ZSCORE", 3.0),
+		FeatureFlags:      getEnv("FEATURE_FLAGS", ""),
+		FeatureFlagsFile:  getEnv("FEATURE_FLAGS_FILE", ""),
+		PolicyEvalTimeout: getEnvAsInt("POLICY_EVAL_TIMEOUT_MS", 0),
+		MatchMode:         getEnv("MATCH_MODE", "all"),
+		SlowPolicyMs:      getEnvAsInt("SLOW_POLICY_THRESHOLD_MS", 5),
+		LintBroadPercent:  getEnvAsFloat("LINT_BROAD_MATCH_PERCENT", 1.0),
+		PolicyReviewHours: getEnvAsInt("POLICY_REVIEW_INTERVAL", 24),
+		PolicyLimit:       getEnvAsInt("MAX_ENABLED_POLICIES", 1000),
+		PolicyWritesRate:  getEnvAsInt("POLICY_WRITES_PER_MINUTE", 60),
+		PolicyChangeLimit: getEnvAsInt("POLICY_MAX_CHANGES_PER_MINUTE", 50),
+		PolicySnapshot:    getEnv("POLICY_SNAPSHOT_FILE", ""),
+		TokenizerVocab:    getEnv("TOKENIZER_VOCAB_FILE", ""),
+		ModelMaxTokens:    getEnvAsInt("MODEL_MAX_TOKENS", 0),
+		ModelPrivacyFile:  getEnv("MODEL_PRIVACY_FILE", ""),
+		ModelRoutesFile:   getEnv("MODEL_ROUTES_FILE", ""),
+		DecisionCacheTTL:  getEnvAsInt("DECISION_CACHE_TTL", 0),
+		DecisionCacheSize: getEnvAsInt("DECISION_CACHE_SIZE", 10000),
+		ChaosEnabled:      getEnvAsBool("CHAOS_ENABLED", false),
+		ChaosLatencyMs:    getEnvAsInt("CHAOS_LATENCY_MS", 500),
+		ChaosLatencyRate:  getEnvAsFloat("CHAOS_LATENCY_RATE", 0),
+		ChaosDBErrorRate:  getEnvAsFloat("CHAOS_POSTGRES_ERROR_RATE", 0),
+		ChaosRedisErrRate: getEnvAsFloat("CHAOS_REDIS_ERROR_RATE", 0),
+		ChaosModelErrRate: getEnvAsFloat("CHAOS_MODEL_TIMEOUT_RATE", 0),
+		FirehoseURL:       getEnv("FIREHOSE_URL", ""),
+		FirehoseToken:     getEnv("FIREHOSE_AUTH_TOKEN", ""),
+		FirehoseStream:    getEnv("FIREHOSE_REDIS_STREAM", ""),
+		FirehoseMaxLen:    getEnvAsInt("FIREHOSE_STREAM_MAXLEN", 1000000),
+		FirehoseBuffer:    getEnvAsInt("FIREHOSE_BUFFER_SIZE", 10000),
+		FirehoseBatchSize: getEnvAsInt("FIREHOSE_BATCH_SIZE", 200),
+		FirehoseFlushMs:   getEnvAsInt("FIREHOSE_FLUSH_MS", 500),
+		FirehoseRetries:   getEnvAsInt("FIREHOSE_MAX_RETRIES", 3),
+		ShadowURL:         getEnv("SHADOW_GATEWAY_URL", ""),
+		ShadowToken:       getEnv("SHADOW_GATEWAY_TOKEN", ""),
+		ShadowSampleRate:  getEnvAsFloat("SHADOW_SAMPLE_RATE", 0.01),
+		ShadowBuffer:      getEnvAsInt("SHADOW_BUFFER_SIZE", 1000),
+		ShadowWorkers:     getEnvAsInt("SHADOW_WORKERS", 4),
+		ShadowTimeoutMs:   getEnvAsInt("SHADOW_TIMEOUT_MS", 5000),
+		AnalyzeSlots:      getEnvAsInt("ANALYZE_CONCURRENCY", 0),
+		BatchSlots:        getEnvAsInt("BATCH_CONCURRENCY", 0),
+		BatchQueueLimit:   getEnvAsInt("BATCH_QUEUE_LIMIT", 1000),
+		BatchWorkers:      getEnvAsInt("BATCH_ANALYZE_WORKERS", 8),
+		BatchMaxItems:     getEnvAsInt("BATCH_ANALYZE_MAX_ITEMS", 100),
+		MaxPolicies:       getEnvAsInt("MAX_POLICIES_PER_REQUEST", 0),
+		MaxMatches:        getEnvAsInt("MAX_MATCHES_PER_REQUEST", 1000),
+		MaxRedactions:     getEnvAsInt("MAX_REDACTIONS_PER_REQUEST", 0),
+		InstanceID:        getEnv("GATEWAY_INSTANCE_ID", ""),
+		Region:            getEnv("GATEWAY_REGION", getEnv("AWS_REGION", "")),
+		Zone:              getEnv("GATEWAY_ZONE", ""),
+		TenantSchemas:     getEnv("TENANT_SCHEMAS", ""),
+		TenantDatabases:   getEnv("TENANT_DATABASES", ""),
+		FingerprintDays:   getEnvAsInt("BLOCKED_PROMPT_RETENTION_DAYS", 30),
+		RollupInterval:    getEnvAsInt("AUDIT_ROLLUP_INTERVAL_SECONDS", 300),
+		RollupLookback:    getEnvAsInt("AUDIT_ROLLUP_LOOKBACK_HOURS", 3),
+		ClusterHeartbeat:  getEnvAsInt("CLUSTER_HEARTBEAT_SECONDS", 15),
+		AllowlistRefresh:  getEnvAsInt("ALLOWLIST_REFRESH_SECONDS", 30),
+		AnalyzeSLOs:       getEnv("ANALYZE_LATENCY_SLOS", "250:0.99,1000:0.999"),
+		ReportPeriods:     getEnv("USAGE_REPORTS", ""),
+		ReportFormat:      getEnv("USAGE_REPORT_FORMAT", "json"),
+		ReportSink:        getEnv("USAGE_REPORT_SINK", "webhook"),
+		ReportWebhookURL:  getEnv("USAGE_REPORT_WEBHOOK_URL", ""),
+		ReportToken:       getEnv("USAGE_REPORT_WEBHOOK_TOKEN", ""),
+		ReportS3Bucket:    getEnv("USAGE_REPORT_S3_BUCKET", ""),
+		ReportS3Prefix:    getEnv("USAGE_REPORT_S3_PREFIX", "usage-reports/"),
+		ReportS3Region:    getEnv("USAGE_REPORT_S3_REGION", getEnv("AWS_REGION", "")),
+		ReportS3Endpoint:  getEnv("USAGE_REPORT_S3_ENDPOINT", ""),
+		AWSAccessKeyID:    getEnv("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretKey:      getEnv("AWS_SECRET_ACCESS_KEY", ""),
+		AWSSessionToken:   getEnv("AWS_SESSION_TOKEN", ""),
+		ReportSMTPAddr:    getEnv("USAGE_REPORT_SMTP_ADDR", ""),
+		ReportSMTPUser:    getEnv("USAGE_REPORT_SMTP_USERNAME", ""),
+		ReportSMTPPass:    getEnv("USAGE_REPORT_SMTP_PASSWORD", ""),
+		ReportEmailFrom:   getEnv("USAGE_REPORT_EMAIL_FROM", ""),
+		ReportEmailTo:     getEnv("USAGE_REPORT_EMAIL_TO", ""),
+		ContentKey:        getEnv("CONTENT_MASTER_KEY", ""),
+		ContentKeyID:      getEnv("CONTENT_MASTER_KEY_ID", "local-1"),
+		ContentKeyPrev:    getEnv("CONTENT_MASTER_KEY_PREVIOUS", ""),
+		ContentKeyPrevID:  getEnv("CONTENT_MASTER_KEY_PREVIOUS_ID", ""),
 	}
 
 	// Validate required fields
@@ -56,10 +311,107 @@ func Load() (*Config, error) {
 	if config.NemoAPIKey == "" {
 		return nil, fmt.Errorf("NVIDIA_NEMO_API is required")
 	}
+	if config.EnforcementMode != "enforce" && config.EnforcementMode != "monitor" {
+		return nil, fmt.Errorf("ENFORCEMENT_MODE must be enforce or monitor")
+	}
+	if config.MatchMode != "all" && config.MatchMode != "first" {
+		return nil, fmt.Errorf("MATCH_MODE must be all or first")
+	}
+	if config.FirehoseURL != "" && config.FirehoseStream != "" {
+		return nil, fmt.Errorf("set only one of FIREHOSE_URL and FIREHOSE_REDIS_STREAM")
+	}
+	if config.ShadowSampleRate < 0 || config.ShadowSampleRate > 1 {
+		return nil, fmt.Errorf("SHADOW_SAMPLE_RATE must be between 0 and 1")
+	}
+	if config.LintBroadPercent <= 0 || config.LintBroadPercent > 100 {
+		return nil, fmt.Errorf("LINT_BROAD_MATCH_PERCENT must be above 0 and at most 100")
+	}
+	if config.PolicyWritesRate < 0 || config.PolicyChangeLimit < 0 {
+		return nil, fmt.Errorf("POLICY_WRITES_PER_MINUTE and POLICY_MAX_CHANGES_PER_MINUTE must not be negative")
+	}
+	if config.StreamGRPCPort != "" && (config.StreamGRPCPort == config.Port || config.StreamGRPCPort == config.ExtAuthzPort) {
+		return nil, fmt.Errorf("STREAM_GRPC_PORT must differ from PORT and EXT_AUTHZ_PORT")
+	}
+	if config.GRPCPort != "" && (config.GRPCPort == config.Port || config.GRPCPort == config.ExtAuthzPort || config.GRPCPort == config.StreamGRPCPort) {
+		return nil, fmt.Errorf("GRPC_PORT must differ from PORT, EXT_AUTHZ_PORT and STREAM_GRPC_PORT")
+	}
+	if config.BatchWorkers <= 0 || config.BatchMaxItems <= 0 {
+		return nil, fmt.Errorf("BATCH_ANALYZE_WORKERS and BATCH_ANALYZE_MAX_ITEMS must be positive")
+	}
+	if config.MaxPolicies < 0 || config.MaxMatches < 0 || config.MaxRedactions < 0 {
+		return nil, fmt.Errorf("MAX_POLICIES_PER_REQUEST, MAX_MATCHES_PER_REQUEST and MAX_REDACTIONS_PER_REQUEST must not be negative")
+	}
+	if config.RollupInterval < 0 || config.RollupLookback <= 0 {
+		return nil, fmt.Errorf("AUDIT_ROLLUP_INTERVAL_SECONDS must not be negative and AUDIT_ROLLUP_LOOKBACK_HOURS must be positive")
+	}
+	if config.AuditBlockMs < 0 {
+		return nil, fmt.Errorf("AUDIT_BLOCK_TIMEOUT_MS must not be negative")
+	}
+	if config.AllowlistRefresh <= 0 {
+		return nil, fmt.Errorf("ALLOWLIST_REFRESH_SECONDS must be positive")
+	}
+	if config.LogFormat != "text" && config.LogFormat != "json" {
+		return nil, fmt.Errorf("LOG_FORMAT must be text or json")
+	}
+	if config.AuditHashAlgo != "sha256" && config.AuditHashAlgo != "sha512" && config.AuditHashAlgo != "blake3" {
+		return nil, fmt.Errorf("AUDIT_HASH_ALGORITHM must be sha256, sha512 or blake3")
+	}
+	if config.ReportPeriods != "" {
+		if config.RollupInterval == 0 {
+			return nil, fmt.Errorf("USAGE_REPORTS requires the audit roll-ups (AUDIT_ROLLUP_INTERVAL_SECONDS > 0)")
+		}
+		if config.ReportFormat != "json" && config.ReportFormat != "csv" {
+			return nil, fmt.Errorf("USAGE_REPORT_FORMAT must be json or csv")
+		}
+		switch config.ReportSink {
+		case "webhook":
+			if config.ReportWebhookURL == "" {
+				return nil, fmt.Errorf("USAGE_REPORT_WEBHOOK_URL is required with USAGE_REPORT_SINK=webhook")
+			}
+		case "s3":
+			if config.ReportS3Bucket == "" || config.ReportS3Region == "" || config.AWSAccessKeyID == "" || config.AWSSecretKey == "" {
+				return nil, fmt.Errorf("USAGE_REPORT_SINK=s3 requires USAGE_REPORT_S3_BUCKET, a region, AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY")
+			}
+		case "email":
+			if config.ReportSMTPAddr == "" || config.ReportEmailFrom == "" || config.ReportEmailTo == "" {
+				return nil, fmt.Errorf("USAGE_REPORT_SINK=email requires USAGE_REPORT_SMTP_ADDR, USAGE_REPORT_EMAIL_FROM and USAGE_REPORT_EMAIL_TO")
+			}
+		default:
+			return nil, fmt.Errorf("USAGE_REPORT_SINK must be webhook, s3 or email")
+		}
+	}
+	if config.ContentKeyPrev != "" {
+		if config.ContentKey == "" {
+			return nil, fmt.Errorf("CONTENT_MASTER_KEY_PREVIOUS requires CONTENT_MASTER_KEY")
+		}
+		if config.ContentKeyPrevID == "" || config.ContentKeyPrevID == config.ContentKeyID {
+			return nil, fmt.Errorf("CONTENT_MASTER_KEY_PREVIOUS_ID must name the previous master key and differ from CONTENT_MASTER_KEY_ID")
+		}
+	}
+	if config.AuditOwnerRegion != "" && config.Region == "" {
+		return nil, fmt.Errorf("AUDIT_OWNER_REGION requires GATEWAY_REGION")
+	}
+	if config.ForwardsAudit() {
+		if config.AuditForwardMode != "api" && config.AuditForwardMode != "redis" {
+			return nil, fmt.Errorf("AUDIT_FORWARD_MODE must be api or redis")
+		}
+		if config.AuditForwardURL == "" {
+			return nil, fmt.Errorf("AUDIT_FORWARD_URL is required when %s does not own audit persistence", config.Region)
+		}
+		if config.AuditForwardMode == "api" && config.AuditForwardToken == "" {
+			return nil, fmt.Errorf("AUDIT_FORWARD_TOKEN is required with AUDIT_FORWARD_MODE=api")
+		}
+	}
 
 	return config, nil
 }
 
+// ForwardsAudit reports whether this region ships audit logs to the owner region
+// instead of writing them to Postgres
+func (c *Config) ForwardsAudit() bool {
+	return c.AuditOwnerRegion != "" && c.Region != c.AuditOwnerRegion
+}
+
 // getEnv reads an environment variable with a default fallback
 func getEnv(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
@@ -78,3 +430,23 @@ func getEnvAsInt(key string, defaultValue int) int {
 	}
 	return defaultValue
 }
+
+// getEnvAsBool reads an environment variable as boolean with a default fallback
+func getEnvAsBool(key string, defaultValue bool) bool {
+	if value := os.Getenv(key); value != "" {
+		if boolValue, err := strconv.ParseBool(value); err == nil {
+			return boolValue
+		}
+	}
+	return defaultValue
+}
+
+// getEnvAsFloat reads an environment variable as float with a default fallback
+func getEnvAsFloat(key string, defaultValue float64) float64 {
+	if value := os.Getenv(key); value != "" {
+		if floatValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return floatValue
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,123 @@
+package config
+
+import "testing"
+
+// setRequiredEnv sets the variables Load refuses to start without
+func setRequiredEnv(t *testing.T) {
+	t.Helper()
+	t.Setenv("DATABASE_URL", "postgres://localhost/gateway")
+	t.Setenv("REDIS_URL", "redis://localhost:6379")
+	t.Setenv("NVIDIA_NEMO_API", "test-key")
+}
+
+func TestLoad_RedisDefaults(t *testing.T) {
+	setRequiredEnv(t)
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.RedisDB != -1 {
+		t.Errorf("RedisDB = %d, want -1 so REDIS_URL's database is kept", cfg.RedisDB)
+	}
+	if cfg.RedisUsername != "" || cfg.RedisPassword != "" || cfg.RedisTLS {
+		t.Errorf("username, password, tls = %q, %q, %v, want unset", cfg.RedisUsername, cfg.RedisPassword, cfg.RedisTLS)
+	}
+}
+
+func TestLoad_RedisSettings(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("REDIS_USERNAME", "gateway")
+	t.Setenv("REDIS_PASSWORD", "secret")
+	t.Setenv("REDIS_DB", "4")
+	t.Setenv("REDIS_TLS", "true")
+	t.Setenv("REDIS_TLS_CA_FILE", "/etc/redis/ca.pem")
+	t.Setenv("REDIS_TLS_CERT_FILE", "/etc/redis/client.pem")
+	t.Setenv("REDIS_TLS_KEY_FILE", "/etc/redis/client.key")
+	t.Setenv("REDIS_TLS_SERVER_NAME", "redis.internal")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	want := Config{
+		RedisUsername:    "gateway",
+		RedisPassword:    "secret",
+		RedisDB:          4,
+		RedisTLS:         true,
+		RedisTLSCAFile:   "/etc/redis/ca.pem",
+		RedisTLSCertFile: "/etc/redis/client.pem",
+		RedisTLSKeyFile:  "/etc/redis/client.key",
+		RedisTLSServer:   "redis.internal",
+	}
+	got := Config{
+		RedisUsername:    cfg.RedisUsername,
+		RedisPassword:    cfg.RedisPassword,
+		RedisDB:          cfg.RedisDB,
+		RedisTLS:         cfg.RedisTLS,
+		RedisTLSCAFile:   cfg.RedisTLSCAFile,
+		RedisTLSCertFile: cfg.RedisTLSCertFile,
+		RedisTLSKeyFile:  cfg.RedisTLSKeyFile,
+		RedisTLSServer:   cfg.RedisTLSServer,
+	}
+	if got != want {
+		t.Errorf("Load() redis settings = %+v, want %+v", got, want)
+	}
+}
+
+func TestLoad_RequiresRedisURL(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("REDIS_URL", "")
+
+	if _, err := Load(); err == nil || err.Error() != "REDIS_URL is required" {
+		t.Errorf("Load() error = %v, want REDIS_URL is required", err)
+	}
+}
+
+func TestLoad_Origin(t *testing.T) {
+	setRequiredEnv(t)
+	t.Setenv("GATEWAY_INSTANCE_ID", "gw-1")
+	t.Setenv("GATEWAY_ZONE", "eu-west-1b")
+	t.Setenv("AWS_REGION", "eu-west-1")
+
+	cfg, err := Load()
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.InstanceID != "gw-1" || cfg.Region != "eu-west-1" || cfg.Zone != "eu-west-1b" {
+		t.Errorf("instance, region, zone = %q, %q, %q, want gw-1, eu-west-1 from AWS_REGION, eu-west-1b", cfg.InstanceID, cfg.Region, cfg.Zone)
+	}
+
+	t.Setenv("GATEWAY_REGION", "us-east-1")
+	if cfg, err = Load(); err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if cfg.Region != "us-east-1" {
+		t.Errorf("Region = %q, want GATEWAY_REGION to win over AWS_REGION", cfg.Region)
+	}
+}
+
+func TestLoad_ContentKeyRotation(t *testing.T) {
+	tests := []struct {
+		name              string
+		key, prev, prevID string
+		wantErr           bool
+	}{
+		{"no keys", "", "", "", false},
+		{"rotating", "bmV3", "b2xk", "local-0", false},
+		{"previous without current", "", "b2xk", "local-0", true},
+		{"previous without id", "bmV3", "b2xk", "", true},
+		{"previous with the current id", "bmV3", "b2xk", "local-1", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setRequiredEnv(t)
+			t.Setenv("CONTENT_MASTER_KEY", tt.key)
+			t.Setenv("CONTENT_MASTER_KEY_PREVIOUS", tt.prev)
+			t.Setenv("CONTENT_MASTER_KEY_PREVIOUS_ID", tt.prevID)
+			if _, err := Load(); (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
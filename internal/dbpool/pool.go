@@ -0,0 +1,48 @@
+// Package dbpool bounds how long requests wait on a saturated Postgres connection
+// pool, so they fail fast with "storage busy" instead of hanging until the request timeout
+package dbpool
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// ErrBusy is the context cause when a request gave up waiting for a connection
+var ErrBusy = errors.New("storage busy: database connection pool exhausted")
+
+// Pool watches a connection pool's stats
+type Pool struct {
+	db      *sql.DB
+	maxWait time.Duration
+}
+
+// New creates a watcher allowing requests to wait up to maxWait for a busy pool
+func New(db *sql.DB, maxWait time.Duration) *Pool {
+	return &Pool{db: db, maxWait: maxWait}
+}
+
+// Saturated reports whether every connection the pool may open is in use
+func (p *Pool) Saturated() bool {
+	stats := p.db.Stats()
+	return stats.MaxOpenConnections > 0 && stats.InUse >= stats.MaxOpenConnections
+}
+
+// Bound returns ctx limited to the maximum wait when the pool is saturated, with
+// ErrBusy as the cancellation cause; otherwise ctx is only made cancellable.
+// A nil pool never bounds
+func (p *Pool) Bound(ctx context.Context) (context.Context, context.CancelFunc) {
+	if p == nil || p.maxWait <= 0 || !p.Saturated() {
+		return context.WithCancel(ctx)
+	}
+	metrics.DBPoolSaturatedTotal.Inc()
+	return context.WithTimeoutCause(ctx, p.maxWait, ErrBusy)
+}
+
+// Busy reports whether ctx ended because the pool stayed saturated past the wait
+func Busy(ctx context.Context) bool {
+	return errors.Is(context.Cause(ctx), ErrBusy)
+}
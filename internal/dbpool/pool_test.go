@@ -0,0 +1,32 @@
+package dbpool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestBusy(t *testing.T) {
+	ctx, cancel := context.WithTimeoutCause(context.Background(), time.Millisecond, ErrBusy)
+	defer cancel()
+	<-ctx.Done()
+	if !Busy(ctx) {
+		t.Error("Busy() = false for a context cancelled with ErrBusy, want true")
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), time.Millisecond)
+	defer cancel()
+	<-ctx.Done()
+	if Busy(ctx) {
+		t.Error("Busy() = true for a plain request timeout, want false")
+	}
+}
+
+func TestBound_NilPool(t *testing.T) {
+	var p *Pool
+	ctx, cancel := p.Bound(context.Background())
+	defer cancel()
+	if _, ok := ctx.Deadline(); ok {
+		t.Error("nil pool Bound() set a deadline, want none")
+	}
+}
@@ -0,0 +1,94 @@
+package decisioncache
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+// PurgeChannel is the Redis channel replicas announce decision purges on
+const PurgeChannel = "decisions:purge"
+
+// purge is the message published after a replica erased a data subject
+type purge struct {
+	ClientID  string `json:"client_id,omitempty"`
+	SessionID string `json:"session_id,omitempty"`
+	Origin    string `json:"origin"` // Publishing process, which already purged its cache
+}
+
+// Broadcaster keeps erasures effective on every replica: a purge on one replica
+// is published on PurgeChannel, and the other replicas drop the subject's
+// decisions from their own cache instead of serving them until they expire
+type Broadcaster struct {
+	rdb    *redis.Client
+	cache  *Cache
+	origin string
+
+	pubsub   *redis.PubSub
+	stopOnce sync.Once
+}
+
+// NewBroadcaster creates a broadcaster purging cache on rdb's announcements
+func NewBroadcaster(rdb *redis.Client, cache *Cache) *Broadcaster {
+	return &Broadcaster{rdb: rdb, cache: cache, origin: uuid.NewString()}
+}
+
+// Start subscribes to PurgeChannel; go-redis resubscribes after reconnects
+func (b *Broadcaster) Start(ctx context.Context) {
+	b.pubsub = b.rdb.Subscribe(ctx, PurgeChannel)
+	go b.listen(b.pubsub.Channel())
+	slog.Info("Decision cache purges subscribed", "channel", PurgeChannel)
+}
+
+func (b *Broadcaster) listen(messages <-chan *redis.Message) {
+	for msg := range messages {
+		b.handle(msg.Payload)
+	}
+	slog.Info("Decision cache purge listener stopped")
+}
+
+// handle drops the decisions of a subject another replica erased
+func (b *Broadcaster) handle(payload string) {
+	var msg purge
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		slog.Warn("Ignoring malformed decision cache purge", "payload", payload, "error", err)
+		return
+	}
+	if msg.Origin == b.origin {
+		return
+	}
+	if n := b.cache.Purge(msg.ClientID, msg.SessionID); n > 0 {
+		slog.Info("Decision cache purged after a remote erasure", "client_id", msg.ClientID, "session_id", msg.SessionID, "purged", n)
+	}
+}
+
+// Publish announces that the decisions of a client, a session or a client's
+// session were purged here. Unlike a policy invalidation nothing catches a
+// missed purge up before the decisions expire, so the failure is returned
+func (b *Broadcaster) Publish(ctx context.Context, clientID, sessionID string) error {
+	if b == nil {
+		return nil
+	}
+	payload, err := json.Marshal(purge{ClientID: clientID, SessionID: sessionID, Origin: b.origin})
+	if err != nil {
+		return err
+	}
+	if err := b.rdb.Publish(ctx, PurgeChannel, payload).Err(); err != nil {
+		return fmt.Errorf("failed to publish decision cache purge: %w", err)
+	}
+	return nil
+}
+
+// Stop unsubscribes; the listener exits once the subscription is closed
+func (b *Broadcaster) Stop() {
+	b.stopOnce.Do(func() {
+		if b.pubsub != nil {
+			b.pubsub.Close()
+		}
+	})
+}
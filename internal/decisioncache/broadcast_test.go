@@ -0,0 +1,36 @@
+package decisioncache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestBroadcaster_Handle(t *testing.T) {
+	c := New(time.Minute, 10)
+	c.Put("a1", Subject{ClientID: "a", SessionID: "s1"}, models.AnalyzeResponse{}, "h1")
+	c.Put("b1", Subject{ClientID: "b"}, models.AnalyzeResponse{}, "h1")
+	b := NewBroadcaster(nil, c)
+
+	// This replica's own purges were applied before publishing
+	b.handle(`{"client_id":"a","origin":"` + b.origin + `"}`)
+	if c.Len() != 2 {
+		t.Errorf("own purge dropped decisions, %d left, want 2", c.Len())
+	}
+
+	b.handle(`not json`)
+	b.handle(`{"origin":"replica-2"}`)
+	if c.Len() != 2 {
+		t.Errorf("%d decisions left after malformed and empty purges, want 2", c.Len())
+	}
+
+	// Another replica erased session s1
+	b.handle(`{"session_id":"s1","origin":"replica-2"}`)
+	if _, ok := c.Get("a1", Subject{}); ok {
+		t.Error("a1 survived a remote erasure of its session")
+	}
+	if _, ok := c.Get("b1", Subject{}); !ok {
+		t.Error("b1 was dropped by the erasure of another subject")
+	}
+}
@@ -0,0 +1,192 @@
+// Package decisioncache remembers recent analyze decisions so identical requests
+// can be answered without re-running every policy
+package decisioncache
+
+import (
+	"container/list"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Entry is a cached decision and the policy snapshot it was made against
+type Entry struct {
+	Response   models.AnalyzeResponse
+	PolicyHash string
+	StoredAt   time.Time
+	ClientID   string
+	Sessions   map[string]bool // Sessions the decision was made or reused for, for erasure
+}
+
+// Subject is the client and session a decision is stored or reused for
+type Subject struct {
+	ClientID  string
+	SessionID string
+}
+
+// Cache is a size-bounded LRU of decisions, each kept for at most ttl
+// A nil Cache never hits and stores nothing
+type Cache struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // key → element holding *cacheItem
+	order   *list.List               // Most recently used first
+}
+
+// cacheItem is a list element value
+type cacheItem struct {
+	key   string
+	entry Entry
+}
+
+// New creates a cache keeping up to maxEntries decisions for ttl
+func New(ttl time.Duration, maxEntries int) *Cache {
+	return &Cache{
+		ttl:        ttl,
+		maxEntries: maxEntries,
+		now:        time.Now,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the decision stored under key unless it has expired, noting the
+// session it is reused for so erasing that session drops it too
+func (c *Cache) Get(key string, subject Subject) (Entry, bool) {
+	if c == nil {
+		return Entry{}, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return Entry{}, false
+	}
+	item := el.Value.(*cacheItem)
+	if c.now().Sub(item.entry.StoredAt) > c.ttl {
+		c.order.Remove(el)
+		delete(c.entries, key)
+		return Entry{}, false
+	}
+	c.order.MoveToFront(el)
+	if subject.SessionID != "" && !item.entry.Sessions[subject.SessionID] {
+		// Copy on write: entries returned earlier share the old set
+		sessions := make(map[string]bool, len(item.entry.Sessions)+1)
+		for s := range item.entry.Sessions {
+			sessions[s] = true
+		}
+		sessions[subject.SessionID] = true
+		item.entry.Sessions = sessions
+	}
+	return item.entry, true
+}
+
+// Put stores a decision under key, evicting the least recently used one when full
+func (c *Cache) Put(key string, subject Subject, response models.AnalyzeResponse, policyHash string) {
+	if c == nil {
+		return
+	}
+	entry := Entry{Response: response, PolicyHash: policyHash, StoredAt: c.now(), ClientID: subject.ClientID}
+	if subject.SessionID != "" {
+		entry.Sessions = map[string]bool{subject.SessionID: true}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cacheItem).entry = entry
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&cacheItem{key: key, entry: entry})
+	for c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheItem).key)
+	}
+}
+
+// Purge drops the decisions of a client, of a session, or of a client's session
+// when both are given, and returns how many were dropped
+func (c *Cache) Purge(clientID, sessionID string) int {
+	if c == nil || (clientID == "" && sessionID == "") {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	purged := 0
+	for el := c.order.Front(); el != nil; {
+		next := el.Next()
+		item := el.Value.(*cacheItem)
+		if (clientID == "" || item.entry.ClientID == clientID) && (sessionID == "" || item.entry.Sessions[sessionID]) {
+			c.order.Remove(el)
+			delete(c.entries, item.key)
+			purged++
+		}
+		el = next
+	}
+	return purged
+}
+
+// Len returns the number of cached decisions
+func (c *Cache) Len() int {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
+
+// Directive is a parsed Cache-Control request option
+type Directive struct {
+	NoCache bool          // Re-evaluate instead of answering from the cache
+	NoStore bool          // Don't cache the new decision
+	MaxAge  time.Duration // Oldest acceptable decision, even from older policies (<0 = unset)
+}
+
+// ParseDirective parses Cache-Control-style directives, e.g. "no-cache" or
+// "max-age=30"; unknown directives are ignored as in HTTP
+func ParseDirective(value string) (Directive, error) {
+	d := Directive{MaxAge: -1}
+	for _, part := range strings.Split(value, ",") {
+		name, arg, _ := strings.Cut(strings.TrimSpace(part), "=")
+		switch strings.ToLower(name) {
+		case "no-cache":
+			d.NoCache = true
+		case "no-store":
+			d.NoStore = true
+		case "max-age":
+			seconds, err := strconv.Atoi(strings.Trim(arg, `"`))
+			if err != nil || seconds < 0 {
+				return Directive{}, fmt.Errorf("max-age must be a non-negative number of seconds")
+			}
+			d.MaxAge = time.Duration(seconds) * time.Second
+		}
+	}
+	return d, nil
+}
+
+// Usable reports whether a cached decision may answer a request with this
+// directive: by default only decisions made against the current policies are
+// reused; max-age also accepts decisions from older policies up to that age
+func (d Directive) Usable(entry Entry, policyHash string, now time.Time) bool {
+	if d.NoCache {
+		return false
+	}
+	age := now.Sub(entry.StoredAt)
+	if d.MaxAge >= 0 {
+		return age <= d.MaxAge
+	}
+	return entry.PolicyHash == policyHash
+}
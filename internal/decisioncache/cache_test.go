@@ -0,0 +1,97 @@
+package decisioncache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestCache_GetPut(t *testing.T) {
+	now := time.Unix(1000, 0)
+	c := New(time.Minute, 2)
+	c.now = func() time.Time { return now }
+
+	c.Put("a", Subject{}, models.AnalyzeResponse{Action: "block"}, "h1")
+	c.Put("b", Subject{}, models.AnalyzeResponse{Action: "allow"}, "h1")
+	if _, ok := c.Get("a", Subject{}); !ok {
+		t.Fatal("Get(a) missed")
+	}
+	c.Put("c", Subject{}, models.AnalyzeResponse{Action: "log"}, "h1") // evicts b, the least recently used
+	if _, ok := c.Get("b", Subject{}); ok {
+		t.Error("Get(b) hit after eviction")
+	}
+	if entry, ok := c.Get("a", Subject{}); !ok || entry.Response.Action != "block" {
+		t.Errorf("Get(a) = %+v, %v, want block", entry, ok)
+	}
+
+	now = now.Add(2 * time.Minute)
+	if _, ok := c.Get("a", Subject{}); ok {
+		t.Error("Get(a) hit after ttl")
+	}
+	if c.Len() != 1 {
+		t.Errorf("Len() = %d, want 1", c.Len())
+	}
+
+	var disabled *Cache
+	disabled.Put("a", Subject{}, models.AnalyzeResponse{}, "h1")
+	if _, ok := disabled.Get("a", Subject{}); ok {
+		t.Error("nil cache hit")
+	}
+}
+
+func TestCache_Purge(t *testing.T) {
+	c := New(time.Minute, 10)
+	c.Put("a1", Subject{ClientID: "a", SessionID: "s1"}, models.AnalyzeResponse{}, "h1")
+	c.Put("a2", Subject{ClientID: "a", SessionID: "s2"}, models.AnalyzeResponse{}, "h1")
+	c.Put("b1", Subject{ClientID: "b"}, models.AnalyzeResponse{}, "h1")
+	// Reusing b1's decision in session s3 ties it to that session too
+	c.Get("b1", Subject{ClientID: "b", SessionID: "s3"})
+
+	if n := c.Purge("", "s3"); n != 1 {
+		t.Errorf("Purge(session s3) = %d, want 1", n)
+	}
+	if _, ok := c.Get("b1", Subject{}); ok {
+		t.Error("b1 survived the erasure of a session it was reused for")
+	}
+	if n := c.Purge("a", "s2"); n != 1 {
+		t.Errorf("Purge(a, s2) = %d, want 1", n)
+	}
+	if n := c.Purge("a", ""); n != 1 {
+		t.Errorf("Purge(client a) = %d, want 1", n)
+	}
+	if n := c.Purge("", ""); n != 0 || c.Len() != 0 {
+		t.Errorf("Purge() = %d with %d left, want 0 and an empty cache", n, c.Len())
+	}
+}
+
+func TestDirective(t *testing.T) {
+	now := time.Unix(1000, 0)
+	entry := Entry{PolicyHash: "old", StoredAt: now.Add(-20 * time.Second)}
+
+	tests := []struct {
+		value string
+		hash  string
+		want  bool
+	}{
+		{"", "old", true},
+		{"", "new", false},
+		{"no-cache", "old", false},
+		{"max-age=30", "new", true},
+		{"max-age=10", "old", false},
+		{"private, max-age=\"60\"", "new", true},
+	}
+	for _, tt := range tests {
+		d, err := ParseDirective(tt.value)
+		if err != nil {
+			t.Fatalf("ParseDirective(%q) error = %v", tt.value, err)
+		}
+		if got := d.Usable(entry, tt.hash, now); got != tt.want {
+			t.Errorf("ParseDirective(%q).Usable(policies %s) = %v, want %v", tt.value, tt.hash, got, tt.want)
+		}
+	}
+
+	if _, err := ParseDirective("max-age=soon"); err == nil {
+		t.Error("ParseDirective(max-age=soon) error = nil, want error")
+	}
+}
@@ -0,0 +1,219 @@
+// Package decisiontoken issues and verifies signed, JWT-compatible tokens that
+// let downstream services prove a prompt passed the gateway
+package decisiontoken
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Supported signing algorithms
+const (
+	AlgEdDSA = "EdDSA" // Ed25519; downstream verifies with the published public key
+	AlgHS256 = "HS256" // HMAC-SHA256; downstream must share the secret or call /v1/verify
+)
+
+// Verification errors
+var (
+	ErrMalformed        = errors.New("malformed token")
+	ErrInvalidSignature = errors.New("invalid token signature")
+	ErrExpired          = errors.New("token expired")
+)
+
+// b64 is the unpadded base64url encoding used by JWTs
+var b64 = base64.RawURLEncoding
+
+// Claims is the token payload
+type Claims struct {
+	RequestID  uuid.UUID   `json:"rid"`
+	Allowed    bool        `json:"allowed"`
+	Action     string      `json:"action"`
+	Policies   []uuid.UUID `json:"pol"`
+	PolicyHash string      `json:"ph,omitempty"` // Policy snapshot that produced the decision
+	IssuedAt   int64       `json:"iat"`
+	ExpiresAt  int64       `json:"exp"`
+}
+
+// header is the JOSE header of a token
+type header struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ"`
+	Kid string `json:"kid"`
+}
+
+// Signer signs decisions and verifies tokens it issued
+type Signer struct {
+	alg        string
+	kid        string
+	ttl        time.Duration
+	secret     []byte             // HS256
+	privateKey ed25519.PrivateKey // EdDSA
+	publicKey  ed25519.PublicKey  // EdDSA
+	now        func() time.Time
+}
+
+// NewSigner creates a signer
+// For EdDSA key is a 32-byte Ed25519 seed, for HS256 the shared secret
+func NewSigner(alg string, key []byte, ttl time.Duration) (*Signer, error) {
+	if ttl <= 0 {
+		return nil, fmt.Errorf("token ttl must be positive")
+	}
+
+	s := &Signer{alg: alg, ttl: ttl, now: time.Now}
+	var kidSource []byte
+	switch alg {
+	case AlgEdDSA:
+		if len(key) != ed25519.SeedSize {
+			return nil, fmt.Errorf("EdDSA key must be a %d-byte seed, got %d bytes", ed25519.SeedSize, len(key))
+		}
+		s.privateKey = ed25519.NewKeyFromSeed(key)
+		s.publicKey = s.privateKey.Public().(ed25519.PublicKey)
+		kidSource = s.publicKey
+	case AlgHS256:
+		if len(key) < 32 {
+			return nil, fmt.Errorf("HS256 secret must be at least 32 bytes")
+		}
+		s.secret = key
+		kidSource = key
+	default:
+		return nil, fmt.Errorf("unsupported token algorithm %q: must be %s or %s", alg, AlgEdDSA, AlgHS256)
+	}
+
+	sum := sha256.Sum256(kidSource)
+	s.kid = hex.EncodeToString(sum[:8])
+	return s, nil
+}
+
+// Sign issues a token for an analyze decision
+func (s *Signer) Sign(resp *models.AnalyzeResponse) (string, error) {
+	now := s.now()
+	policies := make([]uuid.UUID, len(resp.TriggeredPolicies))
+	for i, m := range resp.TriggeredPolicies {
+		policies[i] = m.PolicyID
+	}
+
+	headerJSON, err := json.Marshal(header{Alg: s.alg, Typ: "JWT", Kid: s.kid})
+	if err != nil {
+		return "", err
+	}
+	claimsJSON, err := json.Marshal(Claims{
+		RequestID:  resp.RequestID,
+		Allowed:    resp.Allowed,
+		Action:     resp.Action,
+		Policies:   policies,
+		PolicyHash: resp.PolicyHash,
+		IssuedAt:   now.Unix(),
+		ExpiresAt:  now.Add(s.ttl).Unix(),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := b64.EncodeToString(headerJSON) + "." + b64.EncodeToString(claimsJSON)
+	return signingInput + "." + b64.EncodeToString(s.signature([]byte(signingInput))), nil
+}
+
+// Verify checks a token's signature and expiry and returns its claims
+func (s *Signer) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+
+	headerJSON, err := b64.DecodeString(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var h header
+	if err := json.Unmarshal(headerJSON, &h); err != nil {
+		return nil, ErrMalformed
+	}
+	// Never let the token pick the algorithm
+	if h.Alg != s.alg || h.Kid != s.kid {
+		return nil, ErrInvalidSignature
+	}
+
+	sig, err := b64.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	signingInput := []byte(parts[0] + "." + parts[1])
+	if !s.validSignature(signingInput, sig) {
+		return nil, ErrInvalidSignature
+	}
+
+	claimsJSON, err := b64.DecodeString(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	var claims Claims
+	if err := json.Unmarshal(claimsJSON, &claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if s.now().Unix() >= claims.ExpiresAt {
+		return &claims, ErrExpired
+	}
+
+	return &claims, nil
+}
+
+// signature computes the raw signature over the signing input
+func (s *Signer) signature(input []byte) []byte {
+	if s.alg == AlgEdDSA {
+		return ed25519.Sign(s.privateKey, input)
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write(input)
+	return mac.Sum(nil)
+}
+
+// validSignature checks sig against the signing input
+func (s *Signer) validSignature(input, sig []byte) bool {
+	if s.alg == AlgEdDSA {
+		return ed25519.Verify(s.publicKey, input, sig)
+	}
+	return subtle.ConstantTimeCompare(s.signature(input), sig) == 1
+}
+
+// JWK is a public key in JSON Web Key format
+type JWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+}
+
+// JWKS is a JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// PublicKeys returns the verification keys that can be published
+// HS256 secrets are never published, so the set is empty in that mode
+func (s *Signer) PublicKeys() JWKS {
+	if s.alg != AlgEdDSA {
+		return JWKS{Keys: []JWK{}}
+	}
+	return JWKS{Keys: []JWK{{
+		Kty: "OKP",
+		Crv: "Ed25519",
+		X:   b64.EncodeToString(s.publicKey),
+		Kid: s.kid,
+		Alg: AlgEdDSA,
+		Use: "sig",
+	}}}
+}
@@ -0,0 +1,65 @@
+package decisiontoken
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestSigner_RoundTrip(t *testing.T) {
+	resp := &models.AnalyzeResponse{
+		RequestID:         uuid.New(),
+		Allowed:           false,
+		Action:            "block",
+		TriggeredPolicies: []models.PolicyMatch{{PolicyID: uuid.New()}},
+		PolicyHash:        "3f2a9c",
+	}
+
+	for _, alg := range []string{AlgEdDSA, AlgHS256} {
+		t.Run(alg, func(t *testing.T) {
+			s, err := NewSigner(alg, bytes.Repeat([]byte{7}, 32), time.Minute)
+			if err != nil {
+				t.Fatalf("NewSigner() error = %v", err)
+			}
+
+			token, err := s.Sign(resp)
+			if err != nil {
+				t.Fatalf("Sign() error = %v", err)
+			}
+			claims, err := s.Verify(token)
+			if err != nil {
+				t.Fatalf("Verify() error = %v", err)
+			}
+			if claims.RequestID != resp.RequestID || claims.Allowed || claims.Action != "block" || len(claims.Policies) != 1 || claims.PolicyHash != "3f2a9c" {
+				t.Errorf("unexpected claims %+v", claims)
+			}
+
+			// Flip the allowed claim without re-signing
+			parts := strings.Split(token, ".")
+			forged := strings.Replace(string(mustDecode(t, parts[1])), `"allowed":false`, `"allowed":true`, 1)
+			parts[1] = b64.EncodeToString([]byte(forged))
+			if _, err := s.Verify(strings.Join(parts, ".")); !errors.Is(err, ErrInvalidSignature) {
+				t.Errorf("Verify(forged) error = %v, want ErrInvalidSignature", err)
+			}
+
+			s.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+			if _, err := s.Verify(token); !errors.Is(err, ErrExpired) {
+				t.Errorf("Verify(expired) error = %v, want ErrExpired", err)
+			}
+		})
+	}
+}
+
+func mustDecode(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := b64.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
@@ -0,0 +1,303 @@
+// Package envelope seals content the gateway retains with per-tenant data keys,
+// themselves wrapped by a master key, so one tenant's leaked data key exposes no
+// other tenant's content and rotating the master key never rewrites content
+package envelope
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// dataKeySize is the length of AES-256 data and local master keys
+const dataKeySize = 32
+
+// Keyring errors
+var (
+	ErrNoKey         = errors.New("data key not found")
+	ErrUnknownMaster = errors.New("data key is wrapped by an unconfigured master key")
+	ErrSealed        = errors.New("sealed content is corrupt or was sealed with another key")
+)
+
+// Master wraps and unwraps data keys. LocalMaster holds the master key in
+// process memory; a KMS client implementing Master keeps it out of the gateway
+type Master interface {
+	// ID names the master key; it is stored with every key it wraps
+	ID() string
+	Wrap(ctx context.Context, dataKey []byte) ([]byte, error)
+	Unwrap(ctx context.Context, wrapped []byte) ([]byte, error)
+}
+
+// DataKey is a tenant's data key as stored: only its wrapped form is persisted
+type DataKey struct {
+	ID          uuid.UUID
+	Tenant      string // "" for the shared tenant
+	Wrapped     []byte
+	MasterKeyID string
+	CreatedAt   time.Time
+	RetiredAt   *time.Time // Set once a newer key took over; still opens content it sealed
+}
+
+// Store persists data keys
+type Store interface {
+	// Active returns the tenant's current key, or ErrNoKey
+	Active(ctx context.Context, tenant string) (*DataKey, error)
+	// Get returns a key by ID, or ErrNoKey
+	Get(ctx context.Context, id uuid.UUID) (*DataKey, error)
+	// Insert stores a new active key; with retire, the tenant's active key is
+	// retired first, otherwise an existing active key is kept and key is dropped
+	Insert(ctx context.Context, key DataKey, retire bool) error
+	// List returns every key, oldest first
+	List(ctx context.Context) ([]DataKey, error)
+	// Rewrap replaces a key's wrapped form
+	Rewrap(ctx context.Context, id uuid.UUID, wrapped []byte, masterKeyID string) error
+	// DeleteRetired deletes retired keys no row is sealed with anymore
+	DeleteRetired(ctx context.Context) (int, error)
+}
+
+// Keyring seals and opens content with per-tenant data keys
+type Keyring struct {
+	store   Store
+	master  Master            // Wraps new data keys
+	masters map[string]Master // Every configured master, by ID
+
+	mu   sync.Mutex
+	keys map[uuid.UUID]cipher.AEAD // Unwrapped data keys
+}
+
+// NewKeyring creates a keyring wrapping new data keys with master. previous
+// masters only unwrap keys that were wrapped before the master key was rotated
+func NewKeyring(store Store, master Master, previous ...Master) *Keyring {
+	masters := map[string]Master{master.ID(): master}
+	for _, m := range previous {
+		if _, ok := masters[m.ID()]; !ok {
+			masters[m.ID()] = m
+		}
+	}
+	return &Keyring{
+		store:   store,
+		master:  master,
+		masters: masters,
+		keys:    make(map[uuid.UUID]cipher.AEAD),
+	}
+}
+
+// MasterKeyID returns the ID of the master key wrapping new data keys
+func (k *Keyring) MasterKeyID() string {
+	return k.master.ID()
+}
+
+// Seal encrypts plaintext with the tenant's active data key, creating the key on
+// first use, and returns the key's ID to store alongside the sealed content
+func (k *Keyring) Seal(ctx context.Context, tenant string, plaintext []byte) (uuid.UUID, []byte, error) {
+	key, err := k.store.Active(ctx, tenant)
+	if errors.Is(err, ErrNoKey) {
+		if _, err = k.create(ctx, tenant, false); err == nil {
+			// Another replica may have created the tenant's key first
+			key, err = k.store.Active(ctx, tenant)
+		}
+	}
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+	aead, err := k.unwrap(ctx, key)
+	if err != nil {
+		return uuid.Nil, nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(plaintext)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return uuid.Nil, nil, err
+	}
+	return key.ID, aead.Seal(nonce, nonce, plaintext, key.ID[:]), nil
+}
+
+// Open decrypts content sealed with the given data key, retired or not
+func (k *Keyring) Open(ctx context.Context, keyID uuid.UUID, sealed []byte) ([]byte, error) {
+	aead := k.cached(keyID)
+	if aead == nil {
+		key, err := k.store.Get(ctx, keyID)
+		if err != nil {
+			return nil, err
+		}
+		if aead, err = k.unwrap(ctx, key); err != nil {
+			return nil, err
+		}
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, ErrSealed
+	}
+	plaintext, err := aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], keyID[:])
+	if err != nil {
+		return nil, ErrSealed
+	}
+	return plaintext, nil
+}
+
+// Rotate gives the tenant a new active data key and retires its current one;
+// content sealed earlier still opens until it is resealed and the retired key
+// deleted (see DeleteRetired)
+func (k *Keyring) Rotate(ctx context.Context, tenant string) (uuid.UUID, error) {
+	return k.create(ctx, tenant, true)
+}
+
+// Rewrap wraps every data key not wrapped by the current master with it, so a
+// previous master key can be removed. Content is not touched
+func (k *Keyring) Rewrap(ctx context.Context) (int, error) {
+	keys, err := k.store.List(ctx)
+	if err != nil {
+		return 0, err
+	}
+	rewrapped := 0
+	for _, key := range keys {
+		if key.MasterKeyID == k.master.ID() {
+			continue
+		}
+		raw, err := k.unwrapRaw(ctx, &key)
+		if err != nil {
+			return rewrapped, fmt.Errorf("data key %s: %w", key.ID, err)
+		}
+		wrapped, err := k.master.Wrap(ctx, raw)
+		if err != nil {
+			return rewrapped, fmt.Errorf("failed to wrap data key %s: %w", key.ID, err)
+		}
+		if err := k.store.Rewrap(ctx, key.ID, wrapped, k.master.ID()); err != nil {
+			return rewrapped, err
+		}
+		rewrapped++
+	}
+	return rewrapped, nil
+}
+
+// DeleteRetired destroys retired data keys that no stored content is sealed
+// with anymore, returning how many were deleted
+func (k *Keyring) DeleteRetired(ctx context.Context) (int, error) {
+	deleted, err := k.store.DeleteRetired(ctx)
+	if err != nil {
+		return 0, err
+	}
+	// Forget unwrapped keys so deleted ones are no longer held in memory
+	k.mu.Lock()
+	clear(k.keys)
+	k.mu.Unlock()
+	return deleted, nil
+}
+
+// create generates and stores a new data key for tenant
+func (k *Keyring) create(ctx context.Context, tenant string, retire bool) (uuid.UUID, error) {
+	raw := make([]byte, dataKeySize)
+	if _, err := rand.Read(raw); err != nil {
+		return uuid.Nil, err
+	}
+	wrapped, err := k.master.Wrap(ctx, raw)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to wrap data key: %w", err)
+	}
+	key := DataKey{ID: uuid.New(), Tenant: tenant, Wrapped: wrapped, MasterKeyID: k.master.ID()}
+	if err := k.store.Insert(ctx, key, retire); err != nil {
+		return uuid.Nil, err
+	}
+	return key.ID, nil
+}
+
+// cached returns an unwrapped data key, or nil
+func (k *Keyring) cached(id uuid.UUID) cipher.AEAD {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return k.keys[id]
+}
+
+// unwrap returns the cipher of a stored data key, caching it
+func (k *Keyring) unwrap(ctx context.Context, key *DataKey) (cipher.AEAD, error) {
+	if aead := k.cached(key.ID); aead != nil {
+		return aead, nil
+	}
+	raw, err := k.unwrapRaw(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(raw)
+	if err != nil {
+		return nil, err
+	}
+	k.mu.Lock()
+	k.keys[key.ID] = aead
+	k.mu.Unlock()
+	return aead, nil
+}
+
+// unwrapRaw unwraps a stored data key with the master that wrapped it
+func (k *Keyring) unwrapRaw(ctx context.Context, key *DataKey) ([]byte, error) {
+	master, ok := k.masters[key.MasterKeyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownMaster, key.MasterKeyID)
+	}
+	raw, err := master.Unwrap(ctx, key.Wrapped)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unwrap data key %s: %w", key.ID, err)
+	}
+	return raw, nil
+}
+
+// LocalMaster is a master key held in process memory (CONTENT_MASTER_KEY)
+type LocalMaster struct {
+	id   string
+	aead cipher.AEAD
+}
+
+// NewLocalMaster creates a master from a 32-byte AES-256 key
+func NewLocalMaster(id string, key []byte) (*LocalMaster, error) {
+	if id == "" {
+		return nil, errors.New("master key ID is required")
+	}
+	if len(key) != dataKeySize {
+		return nil, fmt.Errorf("master key must be %d bytes, got %d", dataKeySize, len(key))
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalMaster{id: id, aead: aead}, nil
+}
+
+// ID names the master key
+func (m *LocalMaster) ID() string {
+	return m.id
+}
+
+// Wrap encrypts a data key, bound to the master key's ID
+func (m *LocalMaster) Wrap(ctx context.Context, dataKey []byte) ([]byte, error) {
+	nonce := make([]byte, m.aead.NonceSize(), m.aead.NonceSize()+len(dataKey)+m.aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return m.aead.Seal(nonce, nonce, dataKey, []byte(m.id)), nil
+}
+
+// Unwrap decrypts a data key wrapped by this master
+func (m *LocalMaster) Unwrap(ctx context.Context, wrapped []byte) ([]byte, error) {
+	if len(wrapped) < m.aead.NonceSize() {
+		return nil, ErrSealed
+	}
+	dataKey, err := m.aead.Open(nil, wrapped[:m.aead.NonceSize()], wrapped[m.aead.NonceSize():], []byte(m.id))
+	if err != nil {
+		return nil, ErrSealed
+	}
+	return dataKey, nil
+}
+
+// newAEAD returns AES-256-GCM with key
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,241 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// memStore is an in-memory Store; inUse marks keys that sealed content references
+type memStore struct {
+	mu    sync.Mutex
+	keys  []DataKey
+	inUse map[uuid.UUID]bool
+}
+
+func (s *memStore) Active(ctx context.Context, tenant string) (*DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.Tenant == tenant && k.RetiredAt == nil {
+			return &k, nil
+		}
+	}
+	return nil, ErrNoKey
+}
+
+func (s *memStore) Get(ctx context.Context, id uuid.UUID) (*DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, k := range s.keys {
+		if k.ID == id {
+			return &k, nil
+		}
+	}
+	return nil, ErrNoKey
+}
+
+func (s *memStore) Insert(ctx context.Context, key DataKey, retire bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].Tenant != key.Tenant || s.keys[i].RetiredAt != nil {
+			continue
+		}
+		if !retire {
+			return nil
+		}
+		now := time.Now()
+		s.keys[i].RetiredAt = &now
+	}
+	key.CreatedAt = time.Now()
+	s.keys = append(s.keys, key)
+	return nil
+}
+
+func (s *memStore) List(ctx context.Context) ([]DataKey, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]DataKey(nil), s.keys...), nil
+}
+
+func (s *memStore) Rewrap(ctx context.Context, id uuid.UUID, wrapped []byte, masterKeyID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := range s.keys {
+		if s.keys[i].ID == id {
+			s.keys[i].Wrapped, s.keys[i].MasterKeyID = wrapped, masterKeyID
+			return nil
+		}
+	}
+	return ErrNoKey
+}
+
+func (s *memStore) DeleteRetired(ctx context.Context) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.keys[:0]
+	deleted := 0
+	for _, k := range s.keys {
+		if k.RetiredAt != nil && !s.inUse[k.ID] {
+			deleted++
+			continue
+		}
+		kept = append(kept, k)
+	}
+	s.keys = kept
+	return deleted, nil
+}
+
+// testMaster returns a local master whose key is id repeated
+func testMaster(t *testing.T, id string) *LocalMaster {
+	t.Helper()
+	m, err := NewLocalMaster(id, bytes.Repeat([]byte{id[len(id)-1]}, dataKeySize))
+	if err != nil {
+		t.Fatalf("NewLocalMaster() error = %v", err)
+	}
+	return m
+}
+
+func TestKeyring_SealOpen(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	k := NewKeyring(store, testMaster(t, "m1"))
+
+	acmeKey, sealed, err := k.Seal(ctx, "acme", []byte("retained prompt"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	if bytes.Contains(sealed, []byte("retained prompt")) {
+		t.Fatal("sealed content contains the plaintext")
+	}
+	got, err := k.Open(ctx, acmeKey, sealed)
+	if err != nil || string(got) != "retained prompt" {
+		t.Fatalf("Open() = %q, %v, want the plaintext", got, err)
+	}
+
+	again, _, err := k.Seal(ctx, "acme", []byte("another"))
+	if err != nil || again != acmeKey {
+		t.Errorf("second Seal() used key %s, %v, want the tenant's active key %s", again, err, acmeKey)
+	}
+	globexKey, _, err := k.Seal(ctx, "globex", []byte("other tenant"))
+	if err != nil || globexKey == acmeKey {
+		t.Errorf("Seal(globex) used key %s, %v, want a key of its own", globexKey, err)
+	}
+
+	// A fresh keyring has nothing cached and unwraps the stored key
+	if got, err := NewKeyring(store, testMaster(t, "m1")).Open(ctx, acmeKey, sealed); err != nil || string(got) != "retained prompt" {
+		t.Errorf("Open() on a new keyring = %q, %v", got, err)
+	}
+	if _, err := k.Open(ctx, globexKey, sealed); !errors.Is(err, ErrSealed) {
+		t.Errorf("Open() with another tenant's key = %v, want ErrSealed", err)
+	}
+	tampered := append([]byte(nil), sealed...)
+	tampered[len(tampered)-1] ^= 1
+	if _, err := k.Open(ctx, acmeKey, tampered); !errors.Is(err, ErrSealed) {
+		t.Errorf("Open() of tampered content = %v, want ErrSealed", err)
+	}
+	if _, err := k.Open(ctx, uuid.New(), sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Open() with an unknown key = %v, want ErrNoKey", err)
+	}
+}
+
+func TestKeyring_Rotate(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{inUse: map[uuid.UUID]bool{}}
+	k := NewKeyring(store, testMaster(t, "m1"))
+
+	oldKey, sealed, err := k.Seal(ctx, "acme", []byte("before rotation"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+	store.inUse[oldKey] = true
+
+	newKey, err := k.Rotate(ctx, "acme")
+	if err != nil {
+		t.Fatalf("Rotate() error = %v", err)
+	}
+	if active, _ := store.Active(ctx, "acme"); active == nil || active.ID != newKey || newKey == oldKey {
+		t.Fatalf("active key = %+v, want the rotated key %s", active, newKey)
+	}
+	if got, err := k.Open(ctx, oldKey, sealed); err != nil || string(got) != "before rotation" {
+		t.Errorf("Open() with the retired key = %q, %v, want it to still open", got, err)
+	}
+	if id, _, _ := k.Seal(ctx, "acme", []byte("after rotation")); id != newKey {
+		t.Errorf("Seal() after rotation used %s, want %s", id, newKey)
+	}
+
+	// The retired key is kept while content references it
+	if n, err := k.DeleteRetired(ctx); err != nil || n != 0 {
+		t.Errorf("DeleteRetired() = %d, %v, want the referenced key kept", n, err)
+	}
+	delete(store.inUse, oldKey)
+	if n, err := k.DeleteRetired(ctx); err != nil || n != 1 {
+		t.Errorf("DeleteRetired() = %d, %v, want 1", n, err)
+	}
+	if _, err := k.Open(ctx, oldKey, sealed); !errors.Is(err, ErrNoKey) {
+		t.Errorf("Open() with a deleted key = %v, want ErrNoKey", err)
+	}
+}
+
+func TestKeyring_Rewrap(t *testing.T) {
+	ctx := context.Background()
+	store := &memStore{}
+	old := testMaster(t, "m1")
+	keyID, sealed, err := NewKeyring(store, old).Seal(ctx, "acme", []byte("content"))
+	if err != nil {
+		t.Fatalf("Seal() error = %v", err)
+	}
+
+	// Without the previous master the key cannot be unwrapped
+	if _, err := NewKeyring(store, testMaster(t, "m2")).Open(ctx, keyID, sealed); !errors.Is(err, ErrUnknownMaster) {
+		t.Errorf("Open() without the wrapping master = %v, want ErrUnknownMaster", err)
+	}
+
+	rotating := NewKeyring(store, testMaster(t, "m2"), old)
+	if n, err := rotating.Rewrap(ctx); err != nil || n != 1 {
+		t.Fatalf("Rewrap() = %d, %v, want 1", n, err)
+	}
+	if n, err := rotating.Rewrap(ctx); err != nil || n != 0 {
+		t.Errorf("second Rewrap() = %d, %v, want 0", n, err)
+	}
+
+	// The previous master can now be dropped; content is unchanged
+	got, err := NewKeyring(store, testMaster(t, "m2")).Open(ctx, keyID, sealed)
+	if err != nil || string(got) != "content" {
+		t.Errorf("Open() after rewrap = %q, %v", got, err)
+	}
+}
+
+func TestLocalMaster(t *testing.T) {
+	if _, err := NewLocalMaster("m1", make([]byte, 16)); err == nil {
+		t.Error("NewLocalMaster() accepted a 16-byte key")
+	}
+	if _, err := NewLocalMaster("", make([]byte, dataKeySize)); err == nil {
+		t.Error("NewLocalMaster() accepted an empty ID")
+	}
+
+	ctx := context.Background()
+	m := testMaster(t, "m1")
+	wrapped, err := m.Wrap(ctx, []byte("data key"))
+	if err != nil {
+		t.Fatalf("Wrap() error = %v", err)
+	}
+	if got, err := m.Unwrap(ctx, wrapped); err != nil || string(got) != "data key" {
+		t.Errorf("Unwrap() = %q, %v", got, err)
+	}
+
+	// The same key material under another ID does not unwrap it
+	renamed, _ := NewLocalMaster("m1-renamed", bytes.Repeat([]byte{'1'}, dataKeySize))
+	if _, err := renamed.Unwrap(ctx, wrapped); !errors.Is(err, ErrSealed) {
+		t.Errorf("Unwrap() under another ID = %v, want ErrSealed", err)
+	}
+	if _, err := m.Unwrap(ctx, []byte("short")); !errors.Is(err, ErrSealed) {
+		t.Errorf("Unwrap() of a truncated key = %v, want ErrSealed", err)
+	}
+}
@@ -0,0 +1,166 @@
+package envelope
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// foreignKeyViolation is the Postgres error code of deleting a referenced row
+const foreignKeyViolation = "23503"
+
+// Repository stores data keys in the content_keys table. Tables of sealed
+// content reference content_keys(key_id), so a key still in use cannot be deleted
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new data key Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const keyColumns = `key_id, tenant, wrapped_key, master_key_id, created_at, retired_at`
+
+// scanKey maps a single content_keys row
+func scanKey(row interface{ Scan(dest ...any) error }) (*DataKey, error) {
+	var key DataKey
+	var retiredAt sql.NullTime
+	if err := row.Scan(&key.ID, &key.Tenant, &key.Wrapped, &key.MasterKeyID, &key.CreatedAt, &retiredAt); err != nil {
+		return nil, err
+	}
+	if retiredAt.Valid {
+		key.RetiredAt = &retiredAt.Time
+	}
+	return &key, nil
+}
+
+// Active returns the tenant's current key
+func (r *Repository) Active(ctx context.Context, tenant string) (*DataKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM content_keys WHERE tenant = $1 AND retired_at IS NULL`, tenant)
+	key, err := scanKey(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key: %w", err)
+	}
+	return key, nil
+}
+
+// Get returns a key by ID
+func (r *Repository) Get(ctx context.Context, id uuid.UUID) (*DataKey, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+keyColumns+` FROM content_keys WHERE key_id = $1`, id)
+	key, err := scanKey(row)
+	if err == sql.ErrNoRows {
+		return nil, ErrNoKey
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load data key: %w", err)
+	}
+	return key, nil
+}
+
+// Insert stores a new active key, retiring the tenant's current one first when
+// retire is set; otherwise a concurrently created active key wins
+func (r *Repository) Insert(ctx context.Context, key DataKey, retire bool) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if retire {
+		if _, err := tx.ExecContext(ctx,
+			`UPDATE content_keys SET retired_at = NOW() WHERE tenant = $1 AND retired_at IS NULL`, key.Tenant,
+		); err != nil {
+			return fmt.Errorf("failed to retire data key: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, `
+		INSERT INTO content_keys (key_id, tenant, wrapped_key, master_key_id)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant) WHERE retired_at IS NULL DO NOTHING
+	`, key.ID, key.Tenant, key.Wrapped, key.MasterKeyID); err != nil {
+		return fmt.Errorf("failed to store data key: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit data key: %w", err)
+	}
+	return nil
+}
+
+// List returns every key, oldest first
+func (r *Repository) List(ctx context.Context) ([]DataKey, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+keyColumns+` FROM content_keys ORDER BY created_at ASC, key_id ASC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query data keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make([]DataKey, 0)
+	for rows.Next() {
+		key, err := scanKey(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan data key: %w", err)
+		}
+		keys = append(keys, *key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating data keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Rewrap replaces a key's wrapped form after the master key was rotated
+func (r *Repository) Rewrap(ctx context.Context, id uuid.UUID, wrapped []byte, masterKeyID string) error {
+	result, err := r.db.ExecContext(ctx,
+		`UPDATE content_keys SET wrapped_key = $2, master_key_id = $3 WHERE key_id = $1`, id, wrapped, masterKeyID)
+	if err != nil {
+		return fmt.Errorf("failed to rewrap data key: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNoKey
+	}
+	return nil
+}
+
+// DeleteRetired deletes retired keys one by one, keeping those sealed content
+// still references
+func (r *Repository) DeleteRetired(ctx context.Context) (int, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT key_id FROM content_keys WHERE retired_at IS NOT NULL`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to query retired data keys: %w", err)
+	}
+	var retired []uuid.UUID
+	for rows.Next() {
+		var id uuid.UUID
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to scan data key: %w", err)
+		}
+		retired = append(retired, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("error iterating data keys: %w", err)
+	}
+
+	deleted := 0
+	for _, id := range retired {
+		_, err := r.db.ExecContext(ctx, `DELETE FROM content_keys WHERE key_id = $1`, id)
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation {
+			continue
+		}
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete data key: %w", err)
+		}
+		deleted++
+	}
+	return deleted, nil
+}
@@ -0,0 +1,183 @@
+// Package extauthz implements Envoy's external authorization gRPC API so the
+// gateway can run as an ext_authz filter on LLM egress traffic
+package extauthz
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/prompt-gateway/internal/api"
+	"github.com/prompt-gateway/internal/providers"
+	"github.com/prompt-gateway/internal/replay"
+	"github.com/prompt-gateway/internal/usermessage"
+	"github.com/prompt-gateway/pkg/models"
+	"google.golang.org/genproto/googleapis/rpc/status"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Evaluator is the policy evaluation core shared with the HTTP API
+type Evaluator interface {
+	Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error)
+}
+
+// Config controls how Envoy requests are mapped and how failures are handled
+type Config struct {
+	ClientIDHeader string // Header carrying the client ID (lowercase, Envoy normalizes)
+	// TrustClientHeader lets the header override the peer's mTLS principal; by
+	// default the header only identifies peers without one
+	TrustClientHeader bool
+	FailOpen          bool // Allow traffic when evaluation fails
+}
+
+// Server implements envoy.service.auth.v3.Authorization
+type Server struct {
+	authv3.UnimplementedAuthorizationServer
+	evaluator Evaluator
+	config    Config
+}
+
+// NewServer creates an ext_authz server backed by evaluator
+func NewServer(evaluator Evaluator, config Config) *Server {
+	if config.ClientIDHeader == "" {
+		config.ClientIDHeader = "x-client-id"
+	}
+	return &Server{evaluator: evaluator, config: config}
+}
+
+// Register attaches the Authorization service to a gRPC server
+func (s *Server) Register(grpcServer *grpc.Server) {
+	authv3.RegisterAuthorizationServer(grpcServer, s)
+}
+
+// Check evaluates the buffered request body of an LLM API call
+// Envoy must be configured with with_request_body so the body is forwarded
+func (s *Server) Check(ctx context.Context, req *authv3.CheckRequest) (*authv3.CheckResponse, error) {
+	httpReq := req.GetAttributes().GetRequest().GetHttp()
+	body := httpReq.GetRawBody()
+	if len(body) == 0 {
+		body = []byte(httpReq.GetBody())
+	}
+	if len(body) == 0 {
+		// Nothing to inspect (GET, health checks, ...)
+		return allow(nil), nil
+	}
+
+	analyzeReq := models.AnalyzeRequest{
+		ClientID: s.clientID(req),
+		Context:  &models.RequestContext{SessionID: httpReq.GetHeaders()["x-session-id"]},
+		Nonce:    httpReq.GetHeaders()["x-guardrails-nonce"],
+		Locale:   usermessage.FromAcceptLanguage(httpReq.GetHeaders()["accept-language"]),
+	}
+	analyzeReq.Timestamp, _ = strconv.ParseInt(httpReq.GetHeaders()["x-guardrails-timestamp"], 10, 64)
+	if parsed, err := providers.Detect(httpReq.GetPath()).ParseRequest(httpReq.GetPath(), body); err == nil {
+		analyzeReq.Prompt = parsed.Prompt
+		analyzeReq.Messages = parsed.Messages
+		analyzeReq.Context.Model = parsed.Model
+	} else if json.Valid(body) {
+		// Unknown JSON shape: scan every string field
+		analyzeReq.Document = json.RawMessage(body)
+	} else {
+		analyzeReq.Prompt = string(body)
+	}
+
+	resp, err := s.evaluator.Evaluate(ctx, analyzeReq)
+	if errors.Is(err, replay.ErrReplayed) {
+		// Replays are rejected regardless of fail-open
+		return deny(typev3.StatusCode_Conflict, codes.AlreadyExists, map[string]string{"error": "replayed request"}), nil
+	}
+	if err != nil {
+		slog.Error("ext_authz evaluation failed", "method", httpReq.GetMethod(), "path", httpReq.GetPath(), "error", err)
+		if s.config.FailOpen {
+			return allow(nil), nil
+		}
+		return deny(typev3.StatusCode_ServiceUnavailable, codes.Unavailable, map[string]string{"error": "guardrails evaluation failed"}), nil
+	}
+
+	if !resp.Allowed {
+		return deny(typev3.StatusCode_Forbidden, codes.PermissionDenied, resp), nil
+	}
+	if api.Redacted(analyzeReq, resp) {
+		// ext_authz can't rewrite the body, so the unredacted call must not go through
+		return deny(typev3.StatusCode_Forbidden, codes.PermissionDenied, resp), nil
+	}
+	return allow(resp), nil
+}
+
+// clientID resolves the client from the peer's mTLS principal, falling back to
+// the configured header for peers without one. The header is set by the caller,
+// so it only overrides a principal when TrustClientHeader is set
+func (s *Server) clientID(req *authv3.CheckRequest) string {
+	attrs := req.GetAttributes()
+	principal := attrs.GetSource().GetPrincipal()
+	if principal != "" && !s.config.TrustClientHeader {
+		return principal
+	}
+	if id := attrs.GetRequest().GetHttp().GetHeaders()[s.config.ClientIDHeader]; id != "" {
+		return id
+	}
+	if principal != "" {
+		return principal
+	}
+	return "envoy"
+}
+
+// allow builds an OK response annotated with the decision
+func allow(resp *models.AnalyzeResponse) *authv3.CheckResponse {
+	ok := &authv3.OkHttpResponse{}
+	if resp != nil {
+		ok.Headers = decisionHeaders(resp)
+	}
+	return &authv3.CheckResponse{
+		Status:       &status.Status{Code: int32(codes.OK)},
+		HttpResponse: &authv3.CheckResponse_OkResponse{OkResponse: ok},
+	}
+}
+
+// deny builds a denied response with a JSON body
+func deny(httpStatus typev3.StatusCode, code codes.Code, payload interface{}) *authv3.CheckResponse {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		body = []byte(`{"error":"request blocked"}`)
+	}
+
+	headers := []*corev3.HeaderValueOption{header("content-type", "application/json")}
+	if resp, ok := payload.(*models.AnalyzeResponse); ok {
+		headers = append(headers, decisionHeaders(resp)...)
+	}
+
+	return &authv3.CheckResponse{
+		Status: &status.Status{Code: int32(code)},
+		HttpResponse: &authv3.CheckResponse_DeniedResponse{DeniedResponse: &authv3.DeniedHttpResponse{
+			Status:  &typev3.HttpStatus{Code: httpStatus},
+			Headers: headers,
+			Body:    string(body),
+		}},
+	}
+}
+
+// decisionHeaders exposes the decision to the upstream/downstream
+func decisionHeaders(resp *models.AnalyzeResponse) []*corev3.HeaderValueOption {
+	headers := []*corev3.HeaderValueOption{
+		header("x-guardrails-request-id", resp.RequestID.String()),
+		header("x-guardrails-action", resp.Action),
+	}
+	if resp.PolicyHash != "" {
+		headers = append(headers, header("x-guardrails-policy-hash", resp.PolicyHash))
+	}
+	return headers
+}
+
+// header builds an overwrite-style header option
+func header(key, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header:       &corev3.HeaderValue{Key: key, Value: value},
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+	}
+}
@@ -0,0 +1,186 @@
+package extauthz
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	authv3 "github.com/envoyproxy/go-control-plane/envoy/service/auth/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/api"
+	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/replay"
+	"github.com/prompt-gateway/pkg/guardrailstest"
+	"github.com/prompt-gateway/pkg/models"
+	"google.golang.org/grpc/codes"
+)
+
+// stubEvaluator returns a fixed decision and records the request it was asked about
+type stubEvaluator struct {
+	resp *models.AnalyzeResponse
+	err  error
+	got  models.AnalyzeRequest
+}
+
+func (e *stubEvaluator) Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	e.got = req
+	return e.resp, e.err
+}
+
+// checkRequest builds an Envoy check request for a POST body
+func checkRequest(body, principal string, headers map[string]string) *authv3.CheckRequest {
+	return &authv3.CheckRequest{Attributes: &authv3.AttributeContext{
+		Source: &authv3.AttributeContext_Peer{Principal: principal},
+		Request: &authv3.AttributeContext_Request{Http: &authv3.AttributeContext_HttpRequest{
+			Method:  "POST",
+			Path:    "/v1/chat/completions",
+			Headers: headers,
+			Body:    body,
+		}},
+	}}
+}
+
+const chatBody = `{"model": "gpt-4o", "messages": [{"role": "user", "content": "hello"}]}`
+
+func TestServer_Check(t *testing.T) {
+	tests := []struct {
+		name       string
+		resp       *models.AnalyzeResponse
+		err        error
+		failOpen   bool
+		body       string
+		wantCode   codes.Code
+		wantStatus typev3.StatusCode // HTTP status of a denial
+	}{
+		{"allow", &models.AnalyzeResponse{Allowed: true, Action: "log"}, nil, false, chatBody, codes.OK, 0},
+		{"deny", &models.AnalyzeResponse{Allowed: false, Action: "block"}, nil, false, chatBody, codes.PermissionDenied, typev3.StatusCode_Forbidden},
+		{"fail closed", nil, errors.New("database down"), false, chatBody, codes.Unavailable, typev3.StatusCode_ServiceUnavailable},
+		{"fail open", nil, errors.New("database down"), true, chatBody, codes.OK, 0},
+		{"replay rejected even when failing open", nil, replay.ErrReplayed, true, chatBody, codes.AlreadyExists, typev3.StatusCode_Conflict},
+		{"empty body skips evaluation", nil, errors.New("not called"), false, "", codes.OK, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServer(&stubEvaluator{resp: tt.resp, err: tt.err}, Config{FailOpen: tt.failOpen})
+			resp, err := s.Check(context.Background(), checkRequest(tt.body, "", nil))
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got := codes.Code(resp.GetStatus().GetCode()); got != tt.wantCode {
+				t.Fatalf("Check() code = %v, want %v", got, tt.wantCode)
+			}
+			if tt.wantStatus != 0 {
+				if got := resp.GetDeniedResponse().GetStatus().GetCode(); got != tt.wantStatus {
+					t.Errorf("Check() HTTP status = %v, want %v", got, tt.wantStatus)
+				}
+			} else if resp.GetOkResponse() == nil {
+				t.Error("Check() returned no OK response")
+			}
+		})
+	}
+}
+
+func TestServer_CheckDeniesRedactions(t *testing.T) {
+	repo := guardrailstest.NewPolicyRepository()
+	if _, err := repo.Create(context.Background(), models.CreatePolicyRequest{
+		Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "redact",
+	}); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	policyCache := cache.NewPolicyCache(repo)
+	if err := policyCache.Invalidate(context.Background()); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	analyzerSvc := analyzer.NewAnalyzer(nil)
+	analyzerSvc.SetPatternSource(policyCache)
+	s := NewServer(api.NewHandler(repo, policyCache, analyzerSvc, guardrailstest.NewAuditSink(), nil, nil, clients.NewRegistry(nil, time.Minute), nil), Config{})
+
+	tests := []struct {
+		name     string
+		body     string
+		wantCode codes.Code
+	}{
+		{"clean message", chatBody, codes.OK},
+		// ext_authz can't rewrite the body, so a redacted call must not pass unmodified
+		{"redacted message", `{"model": "gpt-4o", "messages": [{"role": "user", "content": "my ssn is 123-45-6789"}]}`, codes.PermissionDenied},
+		{"redacted prompt", `{"model": "gpt-3.5-turbo-instruct", "prompt": "my ssn is 123-45-6789"}`, codes.PermissionDenied},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := s.Check(context.Background(), checkRequest(tt.body, "", nil))
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got := codes.Code(resp.GetStatus().GetCode()); got != tt.wantCode {
+				t.Errorf("Check() code = %v, want %v", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestServer_CheckHeaders(t *testing.T) {
+	s := NewServer(&stubEvaluator{resp: &models.AnalyzeResponse{Allowed: true, Action: "log", PolicyHash: "abc"}}, Config{})
+	resp, err := s.Check(context.Background(), checkRequest(chatBody, "", nil))
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	headers := map[string]string{}
+	for _, h := range resp.GetOkResponse().GetHeaders() {
+		headers[h.GetHeader().GetKey()] = h.GetHeader().GetValue()
+	}
+	if headers["x-guardrails-action"] != "log" || headers["x-guardrails-policy-hash"] != "abc" {
+		t.Errorf("OK headers = %v, want the action and policy hash", headers)
+	}
+}
+
+func TestServer_ClientID(t *testing.T) {
+	const principal = "spiffe://mesh/ns/prod/sa/billing"
+	tests := []struct {
+		name      string
+		trust     bool
+		principal string
+		header    string
+		want      string
+	}{
+		{"principal beats a claimed header", false, principal, "trusted-admin", principal},
+		{"header names peers without a principal", false, "", "billing", "billing"},
+		{"trusted header overrides the principal", true, principal, "billing", "billing"},
+		{"trusted header falls back to the principal", true, principal, "", principal},
+		{"neither", false, "", "", "envoy"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator := &stubEvaluator{resp: &models.AnalyzeResponse{Allowed: true, Action: "log"}}
+			s := NewServer(evaluator, Config{TrustClientHeader: tt.trust})
+			headers := map[string]string{}
+			if tt.header != "" {
+				headers["x-client-id"] = tt.header
+			}
+			if _, err := s.Check(context.Background(), checkRequest(chatBody, tt.principal, headers)); err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if evaluator.got.ClientID != tt.want {
+				t.Errorf("client = %q, want %q", evaluator.got.ClientID, tt.want)
+			}
+		})
+	}
+}
+
+func TestServer_ParsesChatBody(t *testing.T) {
+	evaluator := &stubEvaluator{resp: &models.AnalyzeResponse{Allowed: true, Action: "log"}}
+	s := NewServer(evaluator, Config{})
+	headers := map[string]string{"x-session-id": "s1", "x-guardrails-nonce": "n1", "x-guardrails-timestamp": "1700000000"}
+	if _, err := s.Check(context.Background(), checkRequest(chatBody, "", headers)); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	got := evaluator.got
+	if len(got.Messages) != 1 || got.Messages[0].Content != "hello" || got.Context.Model != "gpt-4o" {
+		t.Errorf("request = %+v, want the parsed chat message and model", got)
+	}
+	if got.Context.SessionID != "s1" || got.Nonce != "n1" || got.Timestamp != 1700000000 {
+		t.Errorf("request = %+v, want the session and replay headers", got)
+	}
+}
@@ -0,0 +1,57 @@
+// Package fingerprint tracks the prompts the gateway blocks most often, by exact
+// hash and by simhash, so payloads reused across clients stand out
+package fingerprint
+
+import (
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// Simhash returns a 64-bit locality-sensitive fingerprint of text: prompts that
+// differ in a few words get hashes a few bits apart, unlike SHA-256
+// Features are lowercase word bigrams (single words for one-word prompts)
+func Simhash(text string) uint64 {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return 0
+	}
+
+	features := words
+	if len(words) > 1 {
+		features = make([]string, len(words)-1)
+		for i := range features {
+			features[i] = words[i] + " " + words[i+1]
+		}
+	}
+
+	var weights [64]int
+	for _, f := range features {
+		h := fnv.New64a()
+		h.Write([]byte(f))
+		sum := h.Sum64()
+		for bit := 0; bit < 64; bit++ {
+			if sum&(1<<bit) != 0 {
+				weights[bit]++
+			} else {
+				weights[bit]--
+			}
+		}
+	}
+
+	var out uint64
+	for bit, w := range weights {
+		if w > 0 {
+			out |= 1 << bit
+		}
+	}
+	return out
+}
+
+// Distance is the number of differing bits between two simhashes
+func Distance(a, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
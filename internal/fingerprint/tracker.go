@@ -0,0 +1,316 @@
+package fingerprint
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+const (
+	// NearDuplicateBits is the simhash distance within which prompts are grouped
+	NearDuplicateBits = 3
+	// groupScanLimit bounds how many distinct hashes are considered when grouping
+	groupScanLimit = 1000
+	// maxClientIDs bounds the client IDs listed per fingerprint
+	maxClientIDs = 20
+)
+
+// key identifies one blocked prompt seen from one client
+type key struct {
+	hash     string
+	clientID string
+}
+
+// sighting is the not-yet-persisted count of one key
+type sighting struct {
+	simhash     uint64
+	count       int64
+	first, last time.Time
+}
+
+// Tracker counts blocked prompts in memory and periodically upserts them into
+// the blocked_prompts table, pruning fingerprints unseen for the retention window
+type Tracker struct {
+	db        *sql.DB
+	interval  time.Duration
+	retention time.Duration
+	breaker   *breaker.Breaker // Optional; while open, sightings keep accumulating in memory
+
+	mu      sync.Mutex // Protects pending
+	pending map[key]sighting
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewTracker creates a tracker flushing to Postgres every interval and keeping
+// fingerprints seen within retention
+func NewTracker(db *sql.DB, interval, retention time.Duration) *Tracker {
+	return &Tracker{
+		db:        db,
+		interval:  interval,
+		retention: retention,
+		pending:   make(map[key]sighting),
+		stopChan:  make(chan struct{}),
+	}
+}
+
+// SetBreaker guards flushes and queries with a circuit breaker
+// Must be called before Start
+func (t *Tracker) SetBreaker(dbBreaker *breaker.Breaker) {
+	t.breaker = dbBreaker
+}
+
+// Observe counts blocked decisions (implements api.DecisionObserver)
+func (t *Tracker) Observe(event models.DecisionEvent) {
+	if event.Audit.ActionTaken != "block" || event.Audit.PromptHash == "" {
+		return
+	}
+	now := event.Audit.CreatedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	k := key{hash: event.Audit.PromptHash, clientID: event.Audit.ClientID}
+	t.mu.Lock()
+	s, ok := t.pending[k]
+	if !ok {
+		s = sighting{simhash: event.PromptSimhash, first: now}
+	}
+	s.count++
+	if now.After(s.last) {
+		s.last = now
+	}
+	t.pending[k] = s
+	t.mu.Unlock()
+}
+
+// Start runs the flush worker
+func (t *Tracker) Start(ctx context.Context) {
+	t.wg.Add(1)
+	go func() {
+		defer t.wg.Done()
+		ticker := time.NewTicker(t.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := t.Flush(ctx); err != nil {
+					slog.Warn("Failed to flush blocked prompt fingerprints, retrying next interval", "error", err)
+				}
+			case <-t.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Blocked prompt tracker started", "flush", t.interval, "retention", t.retention)
+}
+
+// Flush upserts pending sightings in one statement and prunes expired rows; on
+// failure the sightings are merged back so nothing is lost
+func (t *Tracker) Flush(ctx context.Context) error {
+	t.mu.Lock()
+	batch := t.pending
+	t.pending = make(map[key]sighting)
+	t.mu.Unlock()
+
+	err := t.breaker.Do(func() error {
+		if len(batch) > 0 {
+			if err := t.upsert(ctx, batch); err != nil {
+				return err
+			}
+		}
+		_, err := t.db.ExecContext(ctx, `DELETE FROM blocked_prompts WHERE last_seen < $1`, time.Now().Add(-t.retention))
+		return err
+	})
+	if err != nil {
+		t.mu.Lock()
+		for k, s := range batch {
+			p, ok := t.pending[k]
+			if !ok {
+				p = s
+			} else {
+				p.count += s.count
+				if s.first.Before(p.first) {
+					p.first = s.first
+				}
+				if s.last.After(p.last) {
+					p.last = s.last
+				}
+			}
+			t.pending[k] = p
+		}
+		t.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// upsert adds a batch of sightings to blocked_prompts
+func (t *Tracker) upsert(ctx context.Context, batch map[key]sighting) error {
+	hashes := make([]string, 0, len(batch))
+	clientIDs := make([]string, 0, len(batch))
+	simhashes := make([]int64, 0, len(batch))
+	counts := make([]int64, 0, len(batch))
+	firsts := make([]string, 0, len(batch))
+	lasts := make([]string, 0, len(batch))
+	for k, s := range batch {
+		hashes = append(hashes, k.hash)
+		clientIDs = append(clientIDs, k.clientID)
+		simhashes = append(simhashes, int64(s.simhash))
+		counts = append(counts, s.count)
+		firsts = append(firsts, s.first.UTC().Format(time.RFC3339Nano))
+		lasts = append(lasts, s.last.UTC().Format(time.RFC3339Nano))
+	}
+
+	query := `
+		INSERT INTO blocked_prompts (prompt_hash, client_id, simhash, block_count, first_seen, last_seen)
+		SELECT * FROM unnest($1::text[], $2::text[], $3::bigint[], $4::bigint[], $5::timestamptz[], $6::timestamptz[])
+		ON CONFLICT (prompt_hash, client_id) DO UPDATE
+		SET block_count = blocked_prompts.block_count + EXCLUDED.block_count,
+			first_seen = LEAST(blocked_prompts.first_seen, EXCLUDED.first_seen),
+			last_seen = GREATEST(blocked_prompts.last_seen, EXCLUDED.last_seen)
+	`
+	_, err := t.db.ExecContext(ctx, query,
+		pq.Array(hashes), pq.Array(clientIDs), pq.Array(simhashes),
+		pq.Array(counts), pq.Array(firsts), pq.Array(lasts))
+	return err
+}
+
+// Top returns the most frequently blocked prompts seen since the given time,
+// by exact hash or, with group, merging near-duplicates by simhash
+func (t *Tracker) Top(ctx context.Context, since time.Time, limit int, group bool) ([]models.BlockedPrompt, error) {
+	scan := limit
+	if group {
+		scan = groupScanLimit
+	}
+	query := `
+		SELECT prompt_hash, MIN(simhash), SUM(block_count), COUNT(*),
+			(array_agg(client_id ORDER BY block_count DESC))[1:$3],
+			MIN(first_seen), MAX(last_seen)
+		FROM blocked_prompts
+		WHERE last_seen >= $1
+		GROUP BY prompt_hash
+		ORDER BY SUM(block_count) DESC, MAX(last_seen) DESC
+		LIMIT $2
+	`
+
+	var prompts []fingerprinted
+	err := t.breaker.Do(func() error {
+		rows, err := t.db.QueryContext(ctx, query, since, scan, maxClientIDs)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		prompts = prompts[:0]
+		for rows.Next() {
+			var p fingerprinted
+			var simhash int64
+			if err := rows.Scan(&p.PromptHash, &simhash, &p.BlockCount, &p.ClientCount,
+				pq.Array(&p.ClientIDs), &p.FirstSeen, &p.LastSeen); err != nil {
+				return err
+			}
+			p.simhash = uint64(simhash)
+			prompts = append(prompts, p)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query blocked prompts: %w", err)
+	}
+
+	if group {
+		prompts = groupNearDuplicates(prompts, NearDuplicateBits)
+	}
+	if len(prompts) > limit {
+		prompts = prompts[:limit]
+	}
+	out := make([]models.BlockedPrompt, len(prompts))
+	for i, p := range prompts {
+		out[i] = p.BlockedPrompt
+		out[i].Simhash = fmt.Sprintf("%016x", p.simhash)
+	}
+	return out, nil
+}
+
+// fingerprinted is a blocked prompt with its numeric simhash
+type fingerprinted struct {
+	models.BlockedPrompt
+	simhash uint64
+}
+
+// groupNearDuplicates merges prompts within maxDistance bits of a more frequent
+// prompt into it, keeping the most frequent prompt's hash as the representative
+// Client counts of merged groups are lower bounds: only listed IDs are deduplicated
+func groupNearDuplicates(prompts []fingerprinted, maxDistance int) []fingerprinted {
+	var groups []fingerprinted
+	for _, p := range prompts {
+		merged := false
+		for i := range groups {
+			g := &groups[i]
+			if Distance(g.simhash, p.simhash) > maxDistance {
+				continue
+			}
+			g.BlockCount += p.BlockCount
+			g.Variants++
+			if p.FirstSeen.Before(g.FirstSeen) {
+				g.FirstSeen = p.FirstSeen
+			}
+			if p.LastSeen.After(g.LastSeen) {
+				g.LastSeen = p.LastSeen
+			}
+			for _, id := range p.ClientIDs {
+				if len(g.ClientIDs) < maxClientIDs && !contains(g.ClientIDs, id) {
+					g.ClientIDs = append(g.ClientIDs, id)
+				}
+			}
+			g.ClientCount = max(g.ClientCount, p.ClientCount, len(g.ClientIDs))
+			merged = true
+			break
+		}
+		if !merged {
+			p.Variants = 1
+			p.ClientIDs = append([]string(nil), p.ClientIDs...)
+			groups = append(groups, p)
+		}
+	}
+	sort.SliceStable(groups, func(i, j int) bool { return groups[i].BlockCount > groups[j].BlockCount })
+	return groups
+}
+
+// contains reports whether ids includes id
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Stop stops the worker and flushes what is left
+func (t *Tracker) Stop() {
+	t.stopOnce.Do(func() {
+		close(t.stopChan)
+		t.wg.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := t.Flush(ctx); err != nil {
+			slog.Error("Failed to flush blocked prompt fingerprints on shutdown", "error", err)
+		}
+	})
+}
@@ -0,0 +1,203 @@
+// Package firehose mirrors every analyze decision, stripped of content, to an
+// external pipeline in near-real-time, independently of audit persistence
+package firehose
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Sink delivers a batch of decision records to an external destination
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, records []models.DecisionRecord) error
+}
+
+// Config controls batching and backpressure
+type Config struct {
+	BufferSize    int           // Records queued before new ones are dropped
+	BatchSize     int           // Records per delivery
+	FlushInterval time.Duration // Maximum time a partial batch waits
+	MaxRetries    int           // Delivery attempts after the first before a batch is dropped
+}
+
+// Mirror queues decisions and delivers them in batches from a single worker.
+// The request path never waits on the sink: when it falls behind the queue fills
+// and new records are dropped and counted, while failed batches are retried with backoff
+type Mirror struct {
+	config  Config
+	sink    Sink
+	records chan models.DecisionRecord
+	timeout time.Duration
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewMirror creates a mirror delivering to sink
+func NewMirror(config Config, sink Sink) *Mirror {
+	if config.BatchSize <= 0 {
+		config.BatchSize = 1
+	}
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = time.Second
+	}
+	return &Mirror{
+		config:   config,
+		sink:     sink,
+		records:  make(chan models.DecisionRecord, config.BufferSize),
+		timeout:  10 * time.Second,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Observe queues a decision for mirroring (implements api.DecisionObserver)
+func (m *Mirror) Observe(event models.DecisionEvent) {
+	select {
+	case m.records <- NewRecord(event):
+		metrics.FirehoseQueueLength.Set(float64(len(m.records)))
+	default:
+		metrics.FirehoseRecordsTotal.WithLabelValues("dropped").Inc()
+	}
+}
+
+// NewRecord builds the content-free record for a decision
+func NewRecord(event models.DecisionEvent) models.DecisionRecord {
+	record := models.DecisionRecord{
+		RequestID:    event.Audit.RequestID,
+		ClientID:     event.Audit.ClientID,
+		SessionID:    event.Audit.SessionID,
+		Model:        event.Model,
+		Action:       event.Audit.ActionTaken,
+		Policies:     make([]models.DecisionRecordMatch, len(event.Matches)),
+		PromptHash:   event.Audit.PromptHash,
+		ResponseHash: event.Audit.ResponseHash,
+		LatencyMs:    int64(event.Audit.LatencyMs),
+		Timestamp:    event.Audit.CreatedAt,
+	}
+	for i, m := range event.Matches {
+		record.Policies[i] = models.DecisionRecordMatch{
+			PolicyID:        m.PolicyID,
+			PolicyName:      m.PolicyName,
+			Severity:        m.Severity,
+			MessageIndex:    m.MessageIndex,
+			FieldPath:       m.FieldPath,
+			AttachmentIndex: m.AttachmentIndex,
+		}
+	}
+
+	if resp := event.Response; resp != nil {
+		record.Allowed = resp.Allowed
+		record.MonitorOnly = resp.MonitorOnly
+		record.Signals = resp.Signals
+		record.PolicyHash = resp.PolicyHash
+		record.Redacted = resp.RedactedPrompt != ""
+		for _, msg := range resp.MessageResults {
+			if msg.RedactedContent != "" {
+				record.Redacted = true
+			}
+		}
+		for _, att := range resp.AttachmentResults {
+			if att.RedactedContent != "" {
+				record.Redacted = true
+			}
+		}
+		if resp.Override != nil {
+			record.Override = resp.Override.Action
+		}
+	}
+	return record
+}
+
+// Start runs the delivery worker
+func (m *Mirror) Start() {
+	m.wg.Add(1)
+	go m.worker()
+	slog.Info("Decision firehose started", "sink", m.sink.Name(), "batch", m.config.BatchSize, "flush", m.config.FlushInterval)
+}
+
+// worker batches queued records and delivers them by size or interval
+func (m *Mirror) worker() {
+	defer m.wg.Done()
+
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+
+	batch := make([]models.DecisionRecord, 0, m.config.BatchSize)
+	flush := func() {
+		if len(batch) > 0 {
+			m.deliver(batch)
+			batch = batch[:0]
+		}
+		metrics.FirehoseQueueLength.Set(float64(len(m.records)))
+	}
+
+	for {
+		select {
+		case record := <-m.records:
+			batch = append(batch, record)
+			if len(batch) >= m.config.BatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		case <-m.stopChan:
+			// Drain what is queued before stopping
+			for {
+				select {
+				case record := <-m.records:
+					batch = append(batch, record)
+					if len(batch) >= m.config.BatchSize {
+						flush()
+					}
+				default:
+					flush()
+					slog.Info("Decision firehose stopped")
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends a batch, retrying with exponential backoff; while it retries new
+// records keep queuing, so a slow sink turns into drops rather than request latency
+func (m *Mirror) deliver(batch []models.DecisionRecord) {
+	backoff := 100 * time.Millisecond
+	for attempt := 0; ; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), m.timeout)
+		err := m.sink.Send(ctx, batch)
+		cancel()
+		if err == nil {
+			metrics.FirehoseRecordsTotal.WithLabelValues("sent").Add(float64(len(batch)))
+			return
+		}
+		if attempt >= m.config.MaxRetries {
+			slog.Warn("Firehose delivery failed, dropping records", "sink", m.sink.Name(), "records", len(batch), "error", err)
+			metrics.FirehoseRecordsTotal.WithLabelValues("failed").Add(float64(len(batch)))
+			return
+		}
+
+		select {
+		case <-time.After(backoff):
+			backoff *= 2
+		case <-m.stopChan:
+			// Shutting down: one last attempt without waiting
+			attempt = m.config.MaxRetries - 1
+		}
+	}
+}
+
+// Stop delivers queued records and stops the worker
+func (m *Mirror) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		m.wg.Wait()
+	})
+}
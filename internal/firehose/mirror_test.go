@@ -0,0 +1,104 @@
+package firehose
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+type fakeSink struct {
+	mu      sync.Mutex
+	batches [][]models.DecisionRecord
+	fail    int // Fail this many sends before succeeding
+}
+
+func (s *fakeSink) Name() string { return "fake" }
+
+func (s *fakeSink) Send(ctx context.Context, records []models.DecisionRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.fail > 0 {
+		s.fail--
+		return errors.New("unavailable")
+	}
+	s.batches = append(s.batches, append([]models.DecisionRecord(nil), records...))
+	return nil
+}
+
+func (s *fakeSink) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := 0
+	for _, b := range s.batches {
+		n += len(b)
+	}
+	return n
+}
+
+func event(action string) models.DecisionEvent {
+	return models.DecisionEvent{
+		Audit:   models.AuditLog{RequestID: uuid.New(), ClientID: "svc", ActionTaken: action, PromptHash: "abc", CreatedAt: time.Now()},
+		Matches: []models.PolicyMatch{{PolicyID: uuid.New(), PolicyName: "ssn", Severity: "high", MatchedPattern: "123-45-6789"}},
+		Response: &models.AnalyzeResponse{
+			Allowed:        true,
+			Action:         action,
+			RedactedPrompt: "my ssn is [REDACTED]",
+		},
+	}
+}
+
+func TestNewRecord_OmitsContent(t *testing.T) {
+	record := NewRecord(event("redact"))
+	if !record.Redacted || !record.Allowed || record.Action != "redact" || len(record.Policies) != 1 {
+		t.Errorf("NewRecord() = %+v, want allowed redact with one policy", record)
+	}
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		t.Fatalf("Marshal() error = %v", err)
+	}
+	for _, content := range []string{"123-45-6789", "REDACTED", "my ssn"} {
+		if strings.Contains(string(data), content) {
+			t.Errorf("record JSON %s contains content %q", data, content)
+		}
+	}
+}
+
+func TestMirror_BatchesAndRetries(t *testing.T) {
+	sink := &fakeSink{fail: 1}
+	m := NewMirror(Config{BufferSize: 100, BatchSize: 2, FlushInterval: time.Hour, MaxRetries: 2}, sink)
+	m.Start()
+
+	for i := 0; i < 5; i++ {
+		m.Observe(event("allow"))
+	}
+	m.Stop()
+
+	if n := sink.count(); n != 5 {
+		t.Errorf("delivered %d records, want 5", n)
+	}
+}
+
+func TestMirror_DropsWhenFull(t *testing.T) {
+	sink := &fakeSink{}
+	m := NewMirror(Config{BufferSize: 2, BatchSize: 10, FlushInterval: time.Hour}, sink)
+
+	// Not started: the queue fills and further decisions are dropped without blocking
+	for i := 0; i < 5; i++ {
+		m.Observe(event("allow"))
+	}
+	m.Start()
+	m.Stop()
+
+	if n := sink.count(); n != 2 {
+		t.Errorf("delivered %d records, want the 2 that fit in the buffer", n)
+	}
+}
@@ -0,0 +1,101 @@
+package firehose
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// HTTPSink POSTs batches as newline-delimited JSON, one record per line
+type HTTPSink struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// NewHTTPSink creates a sink posting to url, with token as a bearer credential when set
+func NewHTTPSink(url, token string, httpClient *http.Client) *HTTPSink {
+	client := httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &HTTPSink{url: url, token: token, httpClient: client}
+}
+
+// Name identifies the sink in logs
+func (s *HTTPSink) Name() string { return "http" }
+
+// Send posts the batch
+func (s *HTTPSink) Send(ctx context.Context, records []models.DecisionRecord) error {
+	var body bytes.Buffer
+	enc := json.NewEncoder(&body)
+	for _, record := range records {
+		if err := enc.Encode(record); err != nil {
+			return fmt.Errorf("failed to encode decision record: %w", err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, &body)
+	if err != nil {
+		return fmt.Errorf("failed to create firehose request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("firehose request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("firehose endpoint returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// RedisStreamSink appends records to a Redis stream, trimmed to roughly maxLen
+// entries, for consumers that read with XREAD or consumer groups
+type RedisStreamSink struct {
+	rdb    *redis.Client
+	stream string
+	maxLen int64
+}
+
+// NewRedisStreamSink creates a sink appending to stream
+func NewRedisStreamSink(rdb *redis.Client, stream string, maxLen int64) *RedisStreamSink {
+	return &RedisStreamSink{rdb: rdb, stream: stream, maxLen: maxLen}
+}
+
+// Name identifies the sink in logs
+func (s *RedisStreamSink) Name() string { return "redis:" + s.stream }
+
+// Send appends the batch in one pipeline; each entry has a single "decision" JSON field
+func (s *RedisStreamSink) Send(ctx context.Context, records []models.DecisionRecord) error {
+	pipe := s.rdb.Pipeline()
+	for _, record := range records {
+		data, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to encode decision record: %w", err)
+		}
+		pipe.XAdd(ctx, &redis.XAddArgs{
+			Stream: s.stream,
+			MaxLen: s.maxLen,
+			Approx: true,
+			Values: map[string]interface{}{"decision": data},
+		})
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to append to stream %s: %w", s.stream, err)
+	}
+	return nil
+}
@@ -0,0 +1,291 @@
+// Package flags gates analyzer and handler behavior changes behind percentage and
+// per-client rollouts, layered from built-in defaults, env, a JSON file and Redis
+package flags
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"log/slog"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Known flags
+const (
+	ModelDetection       = "model_detection"       // Run model-backed (semantic) policies
+	EvasionNormalization = "evasion_normalization" // Match pattern policies against de-obfuscated text
+	StylometricSignals   = "stylometric_signals"   // Return signals in analyze responses
+)
+
+// redisKey is the hash of flag name → JSON flag used for fleet-wide overrides
+const redisKey = "feature_flags"
+
+// defaults keep current behavior for every known flag
+var defaults = []models.FeatureFlag{
+	{Name: ModelDetection, Percentage: 100},
+	{Name: EvasionNormalization, Percentage: 100},
+	{Name: StylometricSignals, Percentage: 100},
+}
+
+// subjectKey carries the rollout subject (usually the client ID) through a context
+type subjectKey struct{}
+
+// WithSubject returns a context whose flag checks bucket on subject
+func WithSubject(ctx context.Context, subject string) context.Context {
+	return context.WithValue(ctx, subjectKey{}, subject)
+}
+
+// Store resolves flags; later layers (env < file < Redis) replace earlier ones by name
+type Store struct {
+	rdb      *redis.Client // Optional; nil disables Redis overrides
+	interval time.Duration
+
+	mu        sync.RWMutex // Protects base and overrides
+	base      map[string]models.FeatureFlag
+	overrides map[string]models.FeatureFlag
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+}
+
+// NewStore creates a store holding the built-in defaults; Redis overrides are
+// polled every interval once started
+func NewStore(rdb *redis.Client, interval time.Duration) *Store {
+	s := &Store{
+		rdb:       rdb,
+		interval:  interval,
+		base:      make(map[string]models.FeatureFlag),
+		overrides: make(map[string]models.FeatureFlag),
+		stopChan:  make(chan struct{}),
+	}
+	for _, f := range defaults {
+		f.Source = "default"
+		s.base[f.Name] = f
+	}
+	return s
+}
+
+// LoadEnv applies a comma-separated spec such as
+// "model_detection=5,stylometric_signals=off,evasion_normalization=on"
+func (s *Store) LoadEnv(spec string) error {
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		if !ok {
+			return fmt.Errorf("invalid feature flag %q: expected name=value", item)
+		}
+		pct, err := parsePercentage(strings.TrimSpace(value))
+		if err != nil {
+			return fmt.Errorf("invalid feature flag %q: %w", item, err)
+		}
+		s.set(models.FeatureFlag{Name: strings.TrimSpace(name), Percentage: pct, Source: "env"})
+	}
+	return nil
+}
+
+// LoadFile applies a JSON array of flags
+func (s *Store) LoadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read feature flags file: %w", err)
+	}
+	var loaded []models.FeatureFlag
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		return fmt.Errorf("failed to parse feature flags file: %w", err)
+	}
+	for _, f := range loaded {
+		if err := Validate(f); err != nil {
+			return err
+		}
+		f.Source = "file"
+		s.set(f)
+	}
+	return nil
+}
+
+// set stores a base-layer flag
+func (s *Store) set(f models.FeatureFlag) {
+	s.mu.Lock()
+	s.base[f.Name] = f
+	s.mu.Unlock()
+}
+
+// Validate checks a flag's name and percentage
+func Validate(f models.FeatureFlag) error {
+	if f.Name == "" {
+		return fmt.Errorf("feature flag name is required")
+	}
+	if f.Percentage < 0 || f.Percentage > 100 {
+		return fmt.Errorf("feature flag %s: percentage must be between 0 and 100", f.Name)
+	}
+	return nil
+}
+
+// parsePercentage accepts on/off/true/false or an integer 0-100
+func parsePercentage(value string) (int, error) {
+	switch strings.ToLower(value) {
+	case "on", "true":
+		return 100, nil
+	case "off", "false":
+		return 0, nil
+	}
+	pct, err := strconv.Atoi(value)
+	if err != nil || pct < 0 || pct > 100 {
+		return 0, fmt.Errorf("value must be on, off or a percentage 0-100")
+	}
+	return pct, nil
+}
+
+// Start loads Redis overrides and starts the refresh worker
+func (s *Store) Start(ctx context.Context) {
+	if s.rdb == nil {
+		return
+	}
+	if err := s.Refresh(ctx); err != nil {
+		slog.Warn("Failed to load feature flag overrides from Redis", "error", err)
+	}
+	go s.refreshWorker(ctx)
+}
+
+// refreshWorker polls Redis so flag changes reach every replica without a restart
+func (s *Store) refreshWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh feature flags, keeping previous overrides", "error", err)
+			}
+		case <-s.stopChan:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh reloads the Redis override layer; malformed entries are skipped
+func (s *Store) Refresh(ctx context.Context) error {
+	raw, err := s.rdb.HGetAll(ctx, redisKey).Result()
+	if err != nil {
+		return err
+	}
+
+	overrides := make(map[string]models.FeatureFlag, len(raw))
+	for name, value := range raw {
+		var f models.FeatureFlag
+		if err := json.Unmarshal([]byte(value), &f); err != nil {
+			slog.Warn("Ignoring malformed feature flag", "flag", name, "error", err)
+			continue
+		}
+		f.Name = name
+		if err := Validate(f); err != nil {
+			slog.Warn("Ignoring feature flag", "error", err)
+			continue
+		}
+		f.Source = "redis"
+		overrides[name] = f
+	}
+
+	s.mu.Lock()
+	s.overrides = overrides
+	s.mu.Unlock()
+	return nil
+}
+
+// Stop stops the refresh worker
+func (s *Store) Stop() {
+	s.stopOnce.Do(func() { close(s.stopChan) })
+}
+
+// lookup returns the effective flag
+func (s *Store) lookup(name string) (models.FeatureFlag, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if f, ok := s.overrides[name]; ok {
+		return f, true
+	}
+	f, ok := s.base[name]
+	return f, ok
+}
+
+// Enabled reports whether a flag is on for the context's subject
+// Listed clients are always on; others are bucketed by a stable hash of flag and
+// subject so a client stays on the same side of a rollout. Unknown flags are off
+// A nil store keeps every known flag at its default
+func (s *Store) Enabled(ctx context.Context, name string) bool {
+	var f models.FeatureFlag
+	var ok bool
+	if s == nil {
+		f, ok = defaultFlag(name)
+	} else {
+		f, ok = s.lookup(name)
+	}
+	if !ok {
+		return false
+	}
+
+	subject, _ := ctx.Value(subjectKey{}).(string)
+	for _, client := range f.Clients {
+		if client == subject {
+			return true
+		}
+	}
+	switch f.Percentage {
+	case 0:
+		return false
+	case 100:
+		return true
+	}
+
+	h := fnv.New32a()
+	h.Write([]byte(name))
+	h.Write([]byte{0})
+	h.Write([]byte(subject))
+	return int(h.Sum32()%100) < f.Percentage
+}
+
+// defaultFlag returns a built-in flag
+func defaultFlag(name string) (models.FeatureFlag, bool) {
+	for _, f := range defaults {
+		if f.Name == name {
+			return f, true
+		}
+	}
+	return models.FeatureFlag{}, false
+}
+
+// List returns the effective flags sorted by name
+func (s *Store) List() []models.FeatureFlag {
+	s.mu.RLock()
+	merged := make(map[string]models.FeatureFlag, len(s.base)+len(s.overrides))
+	for name, f := range s.base {
+		merged[name] = f
+	}
+	for name, f := range s.overrides {
+		merged[name] = f
+	}
+	s.mu.RUnlock()
+
+	list := make([]models.FeatureFlag, 0, len(merged))
+	for _, f := range merged {
+		list = append(list, f)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
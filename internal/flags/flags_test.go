@@ -0,0 +1,81 @@
+package flags
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestStore_LoadEnv(t *testing.T) {
+	s := NewStore(nil, 0)
+	if err := s.LoadEnv("model_detection=off, stylometric_signals=25,new_resolver=on"); err != nil {
+		t.Fatalf("LoadEnv() error = %v", err)
+	}
+
+	got := map[string]int{}
+	for _, f := range s.List() {
+		got[f.Name] = f.Percentage
+	}
+	want := map[string]int{ModelDetection: 0, StylometricSignals: 25, EvasionNormalization: 100, "new_resolver": 100}
+	for name, pct := range want {
+		if got[name] != pct {
+			t.Errorf("%s percentage = %d, want %d", name, got[name], pct)
+		}
+	}
+
+	for _, spec := range []string{"model_detection", "model_detection=101", "model_detection=maybe"} {
+		if err := NewStore(nil, 0).LoadEnv(spec); err == nil {
+			t.Errorf("LoadEnv(%q) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestStore_EnabledRollout(t *testing.T) {
+	s := NewStore(nil, 0)
+	if err := s.LoadEnv("new_resolver=20"); err != nil {
+		t.Fatalf("LoadEnv() error = %v", err)
+	}
+
+	enabled := 0
+	for i := 0; i < 1000; i++ {
+		ctx := WithSubject(context.Background(), fmt.Sprintf("client-%d", i))
+		first := s.Enabled(ctx, "new_resolver")
+		if s.Enabled(ctx, "new_resolver") != first {
+			t.Fatalf("client-%d flipped between calls", i)
+		}
+		if first {
+			enabled++
+		}
+	}
+	if enabled < 150 || enabled > 250 {
+		t.Errorf("enabled for %d/1000 clients, want about 200", enabled)
+	}
+
+	if s.Enabled(context.Background(), "unknown") {
+		t.Error("unknown flag should be disabled")
+	}
+}
+
+func TestStore_EnabledClientAllowlist(t *testing.T) {
+	s := NewStore(nil, 0)
+	s.set(models.FeatureFlag{Name: "new_resolver", Clients: []string{"acme"}})
+
+	if !s.Enabled(WithSubject(context.Background(), "acme"), "new_resolver") {
+		t.Error("allowlisted client should be enabled")
+	}
+	if s.Enabled(WithSubject(context.Background(), "other"), "new_resolver") {
+		t.Error("other client should be disabled at 0%")
+	}
+}
+
+func TestStore_NilUsesDefaults(t *testing.T) {
+	var s *Store
+	if !s.Enabled(context.Background(), ModelDetection) {
+		t.Error("nil store should keep model detection at its default")
+	}
+	if s.Enabled(context.Background(), "new_resolver") {
+		t.Error("nil store should disable unknown flags")
+	}
+}
@@ -0,0 +1,315 @@
+// Package grpcapi serves AnalyzeService and PolicyService over gRPC for internal
+// callers at high QPS. Every RPC is dispatched in-process to the HTTP handler, so
+// both transports share routing, middleware, authentication, validation and metrics
+package grpcapi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// forwardedHeaders are copied from call metadata onto the HTTP request
+var forwardedHeaders = []string{"authorization", "accept-language", "cache-control", "traceparent"}
+
+// returnedHeaders are copied from the HTTP response into header metadata
+var returnedHeaders = []string{"x-request-id", "x-policy-hash", "x-decision-cache", "age", "retry-after"}
+
+// tenantParams select an isolated tenant's policies and are sent as query parameters
+var tenantParams = []string{"tenant", "client_id"}
+
+// rpc maps one method onto its HTTP endpoint
+type rpc struct {
+	name   string
+	method string   // HTTP method
+	path   string   // {id} is filled from the message's "id" field
+	query  []string // Fields sent as query parameters instead of in the body
+	list   string   // Field a JSON array response is wrapped in
+}
+
+// hasBody reports whether the rest of the message is sent as the request body
+func (r rpc) hasBody() bool {
+	return r.method == http.MethodPost || r.method == http.MethodPut || r.method == http.MethodPatch
+}
+
+// service is one gRPC service of api/proto
+type service struct {
+	name string
+	file string
+	rpcs []rpc
+}
+
+var services = []service{
+	{
+		name: "promptgateway.v1.AnalyzeService",
+		file: "api/proto/analyze.proto",
+		rpcs: []rpc{
+			{name: "Analyze", method: http.MethodPost, path: "/v1/analyze"},
+			{name: "AnalyzeBatch", method: http.MethodPost, path: "/v1/analyze/batch"},
+			{name: "Explain", method: http.MethodPost, path: "/v1/analyze/explain"},
+		},
+	},
+	{
+		name: "promptgateway.v1.PolicyService",
+		file: "api/proto/policy.proto",
+		rpcs: []rpc{
+			{name: "ListPolicies", method: http.MethodGet, path: "/v1/policies", list: "policies"},
+			{name: "GetPolicy", method: http.MethodGet, path: "/v1/policies/{id}"},
+			{name: "CreatePolicy", method: http.MethodPost, path: "/v1/policies", query: tenantParams},
+			{name: "UpdatePolicy", method: http.MethodPatch, path: "/v1/policies/{id}", query: tenantParams},
+			{name: "DeletePolicy", method: http.MethodDelete, path: "/v1/policies/{id}"},
+			{name: "EnablePolicy", method: http.MethodPost, path: "/v1/policies/{id}/enable", query: tenantParams},
+			{name: "DisablePolicy", method: http.MethodPost, path: "/v1/policies/{id}/disable", query: tenantParams},
+			{name: "LintPolicy", method: http.MethodPost, path: "/v1/policies/lint", query: tenantParams},
+			{name: "TestPolicy", method: http.MethodPost, path: "/v1/policies/test", query: tenantParams},
+		},
+	},
+}
+
+// Server bridges gRPC calls to the gateway's HTTP handler
+type Server struct {
+	handler http.Handler
+}
+
+// NewServer creates a gRPC server for handler, normally the mux from api.SetupRoutes
+func NewServer(handler http.Handler) *Server {
+	return &Server{handler: handler}
+}
+
+// gatewayServer is the handler type of every service desc
+type gatewayServer interface {
+	call(ctx context.Context, r rpc, in *structpb.Struct) (*structpb.Struct, error)
+}
+
+// Register attaches AnalyzeService and PolicyService to a gRPC server
+func (s *Server) Register(grpcServer *grpc.Server) {
+	for _, svc := range services {
+		grpcServer.RegisterService(svc.desc(), s)
+	}
+}
+
+// desc describes the service; its messages are google.protobuf.Struct values in
+// the JSON shape of the HTTP API, so no generated code is needed on either side
+func (svc service) desc() *grpc.ServiceDesc {
+	desc := &grpc.ServiceDesc{
+		ServiceName: svc.name,
+		HandlerType: (*gatewayServer)(nil),
+		Metadata:    svc.file,
+	}
+	for _, r := range svc.rpcs {
+		desc.Methods = append(desc.Methods, grpc.MethodDesc{
+			MethodName: r.name,
+			Handler:    r.handler("/" + svc.name + "/" + r.name),
+		})
+	}
+	return desc
+}
+
+func (r rpc) handler(fullMethod string) grpc.MethodHandler {
+	return func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+		in := &structpb.Struct{}
+		if err := dec(in); err != nil {
+			return nil, err
+		}
+		call := func(ctx context.Context, req interface{}) (interface{}, error) {
+			return srv.(gatewayServer).call(ctx, r, req.(*structpb.Struct))
+		}
+		if interceptor == nil {
+			return call(ctx, in)
+		}
+		return interceptor(ctx, in, &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}, call)
+	}
+}
+
+// call serves one RPC with the HTTP handler of its endpoint
+func (s *Server) call(ctx context.Context, r rpc, in *structpb.Struct) (*structpb.Struct, error) {
+	req, err := r.request(ctx, in)
+	if err != nil {
+		return nil, err
+	}
+	rec := &responseRecorder{header: make(http.Header)}
+	s.handler.ServeHTTP(rec, req)
+
+	header := metadata.MD{}
+	for _, key := range returnedHeaders {
+		if v := rec.header.Get(key); v != "" {
+			header.Set(key, v)
+		}
+	}
+	if len(header) > 0 {
+		grpc.SetHeader(ctx, header)
+	}
+
+	if rec.status >= http.StatusBadRequest {
+		return nil, statusError(rec.status, rec.body.Bytes())
+	}
+	out := &structpb.Struct{}
+	if rec.body.Len() == 0 {
+		return out, nil
+	}
+	data := rec.body.Bytes()
+	if r.list != "" {
+		data = append(append([]byte(`{"`+r.list+`":`), data...), '}')
+	}
+	if err := protojson.Unmarshal(data, out); err != nil {
+		return nil, status.Errorf(codes.Internal, "encoding response: %v", err)
+	}
+	return out, nil
+}
+
+// request builds the HTTP request for a message: "id" fills the path, query
+// fields (every field without a body) become parameters and the rest is the body
+func (r rpc) request(ctx context.Context, in *structpb.Struct) (*http.Request, error) {
+	fields := make(map[string]*structpb.Value, len(in.GetFields()))
+	for k, v := range in.GetFields() {
+		fields[k] = v
+	}
+
+	path := r.path
+	if strings.Contains(path, "{id}") {
+		id := fields["id"].GetStringValue()
+		if id == "" {
+			return nil, status.Error(codes.InvalidArgument, "id is required")
+		}
+		path = strings.Replace(path, "{id}", url.PathEscape(id), 1)
+		delete(fields, "id")
+	}
+
+	query := url.Values{}
+	for k, v := range fields {
+		if r.hasBody() && !slices.Contains(r.query, k) {
+			continue
+		}
+		param, ok := queryValue(v)
+		if !ok {
+			return nil, status.Errorf(codes.InvalidArgument, "%s must be a string, number or bool", k)
+		}
+		query.Set(k, param)
+		delete(fields, k)
+	}
+
+	var body io.Reader = http.NoBody
+	if r.hasBody() {
+		data, err := protojson.Marshal(&structpb.Struct{Fields: fields})
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid message: %v", err)
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, r.method, path, body)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "building request: %v", err)
+	}
+	req.URL.RawQuery = query.Encode()
+	req.Header.Set("Content-Type", "application/json")
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		for _, key := range forwardedHeaders {
+			if v := md.Get(key); len(v) > 0 {
+				req.Header.Set(key, v[0])
+			}
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		req.RemoteAddr = p.Addr.String()
+	}
+	return req, nil
+}
+
+// queryValue formats a scalar value as a query parameter
+func queryValue(v *structpb.Value) (string, bool) {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue, true
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64), true
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue), true
+	}
+	return "", false
+}
+
+// statusError converts an HTTP error response into a gRPC status carrying the
+// gateway error (code, message, request ID, details) as a Struct detail
+func statusError(httpStatus int, body []byte) error {
+	var resp models.ErrorResponse
+	if err := json.Unmarshal(body, &resp); err != nil || resp.Error.Message == "" {
+		return status.Error(grpcCode(httpStatus), http.StatusText(httpStatus))
+	}
+	st := status.New(grpcCode(httpStatus), resp.Error.Message)
+	if data, err := json.Marshal(resp.Error); err == nil {
+		detail := &structpb.Struct{}
+		if err := protojson.Unmarshal(data, detail); err == nil {
+			if withDetail, err := st.WithDetails(detail); err == nil {
+				st = withDetail
+			}
+		}
+	}
+	return st.Err()
+}
+
+// grpcCode maps the gateway's HTTP statuses onto gRPC codes
+func grpcCode(httpStatus int) codes.Code {
+	switch httpStatus {
+	case http.StatusBadRequest, http.StatusRequestEntityTooLarge, http.StatusUnprocessableEntity:
+		return codes.InvalidArgument
+	case http.StatusUnauthorized:
+		return codes.Unauthenticated
+	case http.StatusForbidden:
+		return codes.PermissionDenied
+	case http.StatusNotFound:
+		return codes.NotFound
+	case http.StatusMethodNotAllowed, http.StatusNotImplemented:
+		return codes.Unimplemented
+	case http.StatusConflict:
+		return codes.Aborted
+	case http.StatusPreconditionFailed, http.StatusPreconditionRequired:
+		return codes.FailedPrecondition
+	case http.StatusTooManyRequests:
+		return codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		return codes.Unavailable
+	case http.StatusGatewayTimeout:
+		return codes.DeadlineExceeded
+	}
+	return codes.Internal
+}
+
+// responseRecorder captures the HTTP handler's response
+type responseRecorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) Header() http.Header {
+	return r.header
+}
+
+func (r *responseRecorder) WriteHeader(code int) {
+	if r.status == 0 {
+		r.status = code
+	}
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	return r.body.Write(b)
+}
@@ -0,0 +1,161 @@
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// fakeGateway answers like the HTTP API and records the last request
+type fakeGateway struct {
+	last *http.Request
+	body map[string]interface{}
+}
+
+func (f *fakeGateway) mux() *http.ServeMux {
+	mux := http.NewServeMux()
+	record := func(next http.HandlerFunc) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			f.last, f.body = r, nil
+			json.NewDecoder(r.Body).Decode(&f.body)
+			w.Header().Set("X-Request-ID", "req-1")
+			next(w, r)
+		}
+	}
+	mux.HandleFunc("POST /v1/analyze", record(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+			json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.APIError{Code: "rate_limited", Message: "slow down", RequestID: "req-1"}})
+			return
+		}
+		w.Header().Set("X-Policy-Hash", "abc")
+		json.NewEncoder(w).Encode(models.AnalyzeResponse{Allowed: true, Action: "allow", TriggeredPolicies: []models.PolicyMatch{}})
+	}))
+	mux.HandleFunc("GET /v1/policies", record(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode([]models.Policy{{Name: "ssn"}})
+	}))
+	mux.HandleFunc("PATCH /v1/policies/{id}", record(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(models.Policy{Name: r.PathValue("id")})
+	}))
+	mux.HandleFunc("DELETE /v1/policies/{id}", record(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	return mux
+}
+
+func dial(t *testing.T, handler http.Handler) *grpc.ClientConn {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	NewServer(handler).Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func invoke(t *testing.T, conn *grpc.ClientConn, ctx context.Context, method string, in map[string]interface{}, opts ...grpc.CallOption) (map[string]interface{}, error) {
+	t.Helper()
+	msg, err := structpb.NewStruct(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := &structpb.Struct{}
+	if err := conn.Invoke(ctx, method, msg, out, opts...); err != nil {
+		return nil, err
+	}
+	return out.AsMap(), nil
+}
+
+func TestServer_Analyze(t *testing.T) {
+	gw := &fakeGateway{}
+	conn := dial(t, gw.mux())
+
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "authorization", "Bearer key", "accept-language", "de")
+	var header metadata.MD
+	out, err := invoke(t, conn, ctx, "/promptgateway.v1.AnalyzeService/Analyze",
+		map[string]interface{}{"client_id": "app", "prompt": "hello"}, grpc.Header(&header))
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if out["allowed"] != true || out["action"] != "allow" {
+		t.Errorf("response = %v, want the analyze response", out)
+	}
+	if gw.body["client_id"] != "app" || gw.body["prompt"] != "hello" || gw.last.Header.Get("Accept-Language") != "de" {
+		t.Errorf("request = %v %v, want the message as body and forwarded headers", gw.body, gw.last.Header)
+	}
+	if header.Get("x-policy-hash")[0] != "abc" || header.Get("x-request-id")[0] != "req-1" {
+		t.Errorf("header = %v, want response headers as metadata", header)
+	}
+
+	// Errors keep the gateway error as a status detail
+	_, err = invoke(t, conn, context.Background(), "/promptgateway.v1.AnalyzeService/Analyze",
+		map[string]interface{}{"client_id": "app", "prompt": "hello"}, grpc.Header(&header))
+	st := status.Convert(err)
+	if st.Code() != codes.ResourceExhausted || st.Message() != "slow down" {
+		t.Fatalf("error = %v, want ResourceExhausted", err)
+	}
+	if len(st.Details()) != 1 || st.Details()[0].(*structpb.Struct).AsMap()["code"] != "rate_limited" {
+		t.Errorf("details = %v, want the gateway error", st.Details())
+	}
+	if header.Get("retry-after")[0] != "1" {
+		t.Errorf("header = %v, want retry-after", header)
+	}
+}
+
+func TestServer_Policies(t *testing.T) {
+	gw := &fakeGateway{}
+	conn := dial(t, gw.mux())
+	ctx := context.Background()
+
+	out, err := invoke(t, conn, ctx, "/promptgateway.v1.PolicyService/ListPolicies", map[string]interface{}{"tenant": "acme", "unmatched_days": 30})
+	if err != nil {
+		t.Fatalf("ListPolicies() error = %v", err)
+	}
+	if policies, _ := out["policies"].([]interface{}); len(policies) != 1 {
+		t.Errorf("response = %v, want the policies wrapped", out)
+	}
+	if q := gw.last.URL.Query(); q.Get("tenant") != "acme" || q.Get("unmatched_days") != "30" {
+		t.Errorf("query = %v, want the fields as parameters", q)
+	}
+
+	out, err = invoke(t, conn, ctx, "/promptgateway.v1.PolicyService/UpdatePolicy", map[string]interface{}{"id": "p1", "tenant": "acme", "enabled": false})
+	if err != nil {
+		t.Fatalf("UpdatePolicy() error = %v", err)
+	}
+	if out["name"] != "p1" || gw.last.URL.Query().Get("tenant") != "acme" {
+		t.Errorf("response = %v for %v, want id in the path and tenant in the query", out, gw.last.URL)
+	}
+	if _, ok := gw.body["id"]; ok || gw.body["enabled"] != false || gw.body["tenant"] != nil {
+		t.Errorf("body = %v, want only the update fields", gw.body)
+	}
+
+	if out, err := invoke(t, conn, ctx, "/promptgateway.v1.PolicyService/DeletePolicy", map[string]interface{}{"id": "p1"}); err != nil || len(out) != 0 {
+		t.Errorf("DeletePolicy() = %v, %v, want an empty message", out, err)
+	}
+	if _, err := invoke(t, conn, ctx, "/promptgateway.v1.PolicyService/DeletePolicy", map[string]interface{}{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeletePolicy() without id error = %v, want InvalidArgument", err)
+	}
+	if _, err := invoke(t, conn, ctx, "/promptgateway.v1.PolicyService/GetPolicy", map[string]interface{}{"id": "p1"}); status.Code(err) != codes.Unimplemented {
+		t.Errorf("GetPolicy() on an unrouted endpoint error = %v, want Unimplemented", err)
+	}
+}
@@ -0,0 +1,229 @@
+// Package guardstream serves a bidirectional gRPC stream for continuous guarding:
+// an agent framework sends content fragments (prompts, streamed model output, tool
+// results) over one long-lived stream and gets a cumulative verdict after each
+package guardstream
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"github.com/prompt-gateway/internal/api"
+	"github.com/prompt-gateway/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// overlapBytes of a message's earlier content are evaluated again with its next
+// fragment, so patterns split across fragments are still found
+const overlapBytes = 1024
+
+// actionRank orders response actions from least to most restrictive
+var actionRank = map[string]int{"allow": 0, "log": 0, "redact": 1, "block": 2}
+
+// Evaluator is the policy evaluation core shared with the HTTP API
+type Evaluator interface {
+	Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error)
+}
+
+// Server implements promptgateway.v1.Guardrails (api/proto/guardrails.proto)
+type Server struct {
+	evaluator Evaluator
+}
+
+// NewServer creates a stream server backed by evaluator
+func NewServer(evaluator Evaluator) *Server {
+	return &Server{evaluator: evaluator}
+}
+
+// guardrailsServer is the service interface described by serviceDesc
+type guardrailsServer interface {
+	StreamAnalyze(stream grpc.BidiStreamingServer[structpb.Struct, structpb.Struct]) error
+}
+
+// serviceDesc describes promptgateway.v1.Guardrails; its messages are the
+// google.protobuf.Struct form of StreamFragment and StreamVerdict, so no generated
+// code is needed on either side
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: "promptgateway.v1.Guardrails",
+	HandlerType: (*guardrailsServer)(nil),
+	Streams: []grpc.StreamDesc{{
+		StreamName:    "StreamAnalyze",
+		Handler:       streamAnalyzeHandler,
+		ServerStreams: true,
+		ClientStreams: true,
+	}},
+	Metadata: "api/proto/guardrails.proto",
+}
+
+func streamAnalyzeHandler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(guardrailsServer).StreamAnalyze(&grpc.GenericServerStream[structpb.Struct, structpb.Struct]{ServerStream: stream})
+}
+
+// Register attaches the Guardrails service to a gRPC server
+func (s *Server) Register(grpcServer *grpc.Server) {
+	grpcServer.RegisterService(&serviceDesc, s)
+}
+
+// session is the state of one stream
+type session struct {
+	clientID string
+	context  *models.RequestContext
+	locale   string
+	role     string // Role of the message being streamed
+	message  int    // Index of that message in the stream
+	tail     string // Last overlapBytes of that message
+	received int    // Bytes of that message received so far
+	verdict  models.StreamVerdict
+	policies map[string]int // Policy ID -> index in verdict.TriggeredPolicies
+}
+
+// StreamAnalyze evaluates every fragment together with the end of the message it
+// continues and answers with the stream's cumulative verdict. Evaluation failures
+// are reported in the verdict and the stream stays open; malformed fragments end it
+func (s *Server) StreamAnalyze(stream grpc.BidiStreamingServer[structpb.Struct, structpb.Struct]) error {
+	ctx := stream.Context()
+	sess := &session{
+		verdict:  models.StreamVerdict{Allowed: true, Action: "allow", TriggeredPolicies: []models.PolicyMatch{}},
+		policies: make(map[string]int),
+	}
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var fragment models.StreamFragment
+		if err := decode(msg, &fragment); err != nil {
+			return status.Errorf(codes.InvalidArgument, "invalid fragment: %v", err)
+		}
+		if fragment.ClientID != "" {
+			sess.clientID = fragment.ClientID
+		}
+		if sess.clientID == "" {
+			return status.Error(codes.InvalidArgument, "client_id is required on the first fragment")
+		}
+		if fragment.Context != nil {
+			sess.context = fragment.Context
+		}
+		if fragment.Locale != "" {
+			sess.locale = fragment.Locale
+		}
+
+		s.evaluate(ctx, sess, fragment)
+		out, err := encode(sess.verdict)
+		if err != nil {
+			return status.Errorf(codes.Internal, "encoding verdict: %v", err)
+		}
+		if err := stream.Send(out); err != nil {
+			return err
+		}
+	}
+}
+
+// evaluate evaluates fragment and folds the decision into the session's verdict
+func (s *Server) evaluate(ctx context.Context, sess *session, fragment models.StreamFragment) {
+	role := fragment.Role
+	if role == "" {
+		role = "user"
+	}
+	if sess.verdict.Sequence > 0 && role != sess.role {
+		sess.message++
+		sess.tail = ""
+		sess.received = 0
+	}
+	sess.role = role
+	sess.verdict.Sequence++
+
+	content := sess.tail + fragment.Content
+	// Offsets in the decision are shifted from content to the whole message
+	shift := sess.received - len(sess.tail)
+	sess.received += len(fragment.Content)
+	sess.tail = tail(content, overlapBytes)
+	resp, err := s.evaluator.Evaluate(ctx, models.AnalyzeRequest{
+		ClientID: sess.clientID,
+		Context:  sess.context,
+		Messages: []models.ChatMessage{{Role: role, Content: content}},
+		Locale:   sess.locale,
+	})
+	if err != nil {
+		_, apiErr := api.EvaluateFailure(ctx, err)
+		sess.verdict.Decision = nil
+		sess.verdict.Error = &apiErr
+		return
+	}
+
+	sess.verdict.Decision = resp
+	sess.verdict.Error = nil
+	sess.verdict.Allowed = sess.verdict.Allowed && resp.Allowed
+	if actionRank[resp.Action] > actionRank[sess.verdict.Action] {
+		sess.verdict.Action = resp.Action
+	}
+	for _, match := range resp.TriggeredPolicies {
+		index := sess.message
+		match.MessageIndex = &index
+		shiftOffsets(&match, shift)
+		key := match.PolicyID.String()
+		if i, ok := sess.policies[key]; ok {
+			sess.verdict.TriggeredPolicies[i] = match
+			continue
+		}
+		sess.policies[key] = len(sess.verdict.TriggeredPolicies)
+		sess.verdict.TriggeredPolicies = append(sess.verdict.TriggeredPolicies, match)
+	}
+}
+
+// shiftOffsets moves a match's byte offsets by shift
+func shiftOffsets(match *models.PolicyMatch, shift int) {
+	if match.Start == nil || shift == 0 {
+		return
+	}
+	start, end := *match.Start+shift, *match.End+shift
+	match.Start, match.End = &start, &end
+	occurrences := make([]models.MatchOccurrence, len(match.Occurrences))
+	for i, o := range match.Occurrences {
+		occurrences[i] = models.MatchOccurrence{Start: o.Start + shift, End: o.End + shift}
+	}
+	match.Occurrences = occurrences
+}
+
+// tail returns the last n bytes of s, starting at a rune boundary
+func tail(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	i := len(s) - n
+	for i < len(s) && !utf8.RuneStart(s[i]) {
+		i++
+	}
+	return s[i:]
+}
+
+// decode converts a Struct message into v through its JSON form
+func decode(msg *structpb.Struct, v interface{}) error {
+	data, err := protojson.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+// encode converts v into a Struct message through its JSON form
+func encode(v interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	out := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
@@ -0,0 +1,151 @@
+package guardstream
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/scheduler"
+	"github.com/prompt-gateway/pkg/models"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+var secretPolicy = uuid.New()
+
+// fakeEvaluator blocks content containing "secret" and fails content containing "busy"
+type fakeEvaluator struct {
+	requests []models.AnalyzeRequest
+}
+
+func (f *fakeEvaluator) Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	f.requests = append(f.requests, req)
+	content := req.Messages[0].Content
+	if strings.Contains(content, "busy") {
+		return nil, scheduler.ErrQueueFull
+	}
+	if i := strings.Index(content, "secret"); i >= 0 {
+		end := i + len("secret")
+		return &models.AnalyzeResponse{Allowed: false, Action: "block", TriggeredPolicies: []models.PolicyMatch{
+			{PolicyID: secretPolicy, PolicyName: "secrets", Severity: "high", Start: &i, End: &end},
+		}}, nil
+	}
+	return &models.AnalyzeResponse{Allowed: true, Action: "allow", TriggeredPolicies: []models.PolicyMatch{}}, nil
+}
+
+func dial(t *testing.T, evaluator Evaluator) grpc.ClientStream {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := grpc.NewServer()
+	NewServer(evaluator).Register(srv)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	stream, err := conn.NewStream(context.Background(), &serviceDesc.Streams[0], "/promptgateway.v1.Guardrails/StreamAnalyze")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return stream
+}
+
+func send(t *testing.T, stream grpc.ClientStream, fragment models.StreamFragment) (models.StreamVerdict, error) {
+	t.Helper()
+	msg, err := encode(fragment)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.SendMsg(msg); err != nil {
+		t.Fatal(err)
+	}
+	out := &structpb.Struct{}
+	if err := stream.RecvMsg(out); err != nil {
+		return models.StreamVerdict{}, err
+	}
+	var verdict models.StreamVerdict
+	if err := decode(out, &verdict); err != nil {
+		t.Fatal(err)
+	}
+	return verdict, nil
+}
+
+func TestServer_StreamAnalyze(t *testing.T) {
+	evaluator := &fakeEvaluator{}
+	stream := dial(t, evaluator)
+
+	v, err := send(t, stream, models.StreamFragment{ClientID: "agent", Role: "assistant", Content: "the sec"})
+	if err != nil || !v.Allowed || v.Sequence != 1 {
+		t.Fatalf("first verdict = %+v, %v; want allowed", v, err)
+	}
+	// The keyword is split across fragments of one message
+	v, err = send(t, stream, models.StreamFragment{Role: "assistant", Content: "ret is out"})
+	if err != nil || v.Allowed || v.Action != "block" || len(v.TriggeredPolicies) != 1 {
+		t.Fatalf("second verdict = %+v, %v; want blocked", v, err)
+	}
+	if idx := v.TriggeredPolicies[0].MessageIndex; idx == nil || *idx != 0 {
+		t.Errorf("message index = %v, want 0", idx)
+	}
+	if start := v.TriggeredPolicies[0].Start; start == nil || *start != 4 {
+		t.Errorf("start = %v, want 4", start)
+	}
+
+	// Verdicts are cumulative: a clean tool result doesn't lift the block
+	v, err = send(t, stream, models.StreamFragment{Role: "tool", Content: "ok"})
+	if err != nil || v.Allowed || v.Action != "block" || v.Decision == nil || !v.Decision.Allowed || v.Sequence != 3 {
+		t.Errorf("third verdict = %+v, %v; want still blocked after an allowed fragment", v, err)
+	}
+	if last := evaluator.requests[len(evaluator.requests)-1]; last.ClientID != "agent" || last.Messages[0].Content != "ok" {
+		t.Errorf("new message request = %+v, want client inherited and no overlap across roles", last)
+	}
+
+	// Failed evaluations are reported without ending the stream
+	v, err = send(t, stream, models.StreamFragment{Role: "tool", Content: " busy"})
+	if err != nil || v.Error == nil || v.Error.Code != "unavailable" || v.Action != "block" {
+		t.Errorf("failed verdict = %+v, %v; want unavailable error", v, err)
+	}
+	stream.CloseSend()
+}
+
+func TestServer_StreamAnalyzeRequiresClient(t *testing.T) {
+	stream := dial(t, &fakeEvaluator{})
+	_, err := send(t, stream, models.StreamFragment{Content: "hello"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("fragment without client_id error = %v, want InvalidArgument", err)
+	}
+}
+
+func TestServer_StreamAnalyzeOffsetsRelativeToMessage(t *testing.T) {
+	stream := dial(t, &fakeEvaluator{})
+	first := strings.Repeat("a", overlapBytes+10)
+	if _, err := send(t, stream, models.StreamFragment{ClientID: "agent", Role: "assistant", Content: first}); err != nil {
+		t.Fatal(err)
+	}
+	v, err := send(t, stream, models.StreamFragment{Role: "assistant", Content: " secret"})
+	if err != nil || len(v.TriggeredPolicies) != 1 {
+		t.Fatalf("verdict = %+v, %v; want one match", v, err)
+	}
+	if start := v.TriggeredPolicies[0].Start; start == nil || *start != len(first)+1 {
+		t.Errorf("start = %v, want %d (offset in the whole message)", start, len(first)+1)
+	}
+}
+
+func TestTail(t *testing.T) {
+	if got := tail("héllo", 4); got != "llo" {
+		t.Errorf("tail() = %q, want a rune boundary", got)
+	}
+	if got := tail("hi", 4); got != "hi" {
+		t.Errorf("tail() = %q, want whole string", got)
+	}
+}
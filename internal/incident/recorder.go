@@ -0,0 +1,137 @@
+package incident
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// openRequest is a queued incident creation/link
+type openRequest struct {
+	dedupKey  string
+	title     string
+	severity  string
+	source    string
+	clientID  string
+	requestID uuid.UUID
+}
+
+// incidentStore is the part of Repository the recorder writes through
+type incidentStore interface {
+	OpenOrAppend(ctx context.Context, dedupKey, title, severity, source, clientID string, requestID uuid.UUID) error
+}
+
+// Recorder opens incidents for critical policy matches and triggered alert rules
+// Writes happen on a background worker so the request path never touches Postgres
+type Recorder struct {
+	repo     incidentStore
+	queue    chan openRequest
+	stopCh   chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewRecorder creates a Recorder and starts its worker
+func NewRecorder(repo *Repository) *Recorder {
+	return newRecorder(repo, 1000)
+}
+
+// newRecorder creates a Recorder writing to repo with room for queueSize pending
+// incidents and starts its worker
+func newRecorder(repo incidentStore, queueSize int) *Recorder {
+	r := &Recorder{
+		repo:   repo,
+		queue:  make(chan openRequest, queueSize),
+		stopCh: make(chan struct{}),
+	}
+	r.wg.Add(1)
+	go r.worker()
+	slog.Info("Incident recorder started")
+	return r
+}
+
+// Observe opens (or extends) an incident per client for each critical match
+func (r *Recorder) Observe(event models.DecisionEvent) {
+	for _, match := range event.Matches {
+		if match.Severity != "critical" {
+			continue
+		}
+		r.enqueue(openRequest{
+			dedupKey:  fmt.Sprintf("policy:%s:%s", match.PolicyID, event.Audit.ClientID),
+			title:     fmt.Sprintf("Critical policy %q matched for client %s", match.PolicyName, event.Audit.ClientID),
+			severity:  "critical",
+			source:    "policy",
+			clientID:  event.Audit.ClientID,
+			requestID: event.Audit.RequestID,
+		})
+	}
+}
+
+// RecordEvent opens (or extends) an incident for a triggered alert rule
+func (r *Recorder) RecordEvent(event notify.Event) {
+	requestID, _ := event.Details["request_id"].(uuid.UUID)
+	rule, _ := event.Details["rule"].(string)
+	r.enqueue(openRequest{
+		dedupKey:  fmt.Sprintf("rule:%s:%s", rule, event.ClientID),
+		title:     event.Summary,
+		severity:  event.Severity,
+		source:    "rule",
+		clientID:  event.ClientID,
+		requestID: requestID,
+	})
+}
+
+// enqueue queues an incident write without blocking
+func (r *Recorder) enqueue(req openRequest) {
+	select {
+	case r.queue <- req:
+	default:
+		slog.Warn("Incident queue full, dropping incident", "dedup_key", req.dedupKey)
+	}
+}
+
+// worker persists queued incidents
+func (r *Recorder) worker() {
+	defer r.wg.Done()
+
+	for {
+		select {
+		case req := <-r.queue:
+			r.persist(req)
+		case <-r.stopCh:
+			for {
+				select {
+				case req := <-r.queue:
+					r.persist(req)
+				default:
+					slog.Info("Incident recorder stopped")
+					return
+				}
+			}
+		}
+	}
+}
+
+// persist writes a single queued incident
+func (r *Recorder) persist(req openRequest) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := r.repo.OpenOrAppend(ctx, req.dedupKey, req.title, req.severity, req.source, req.clientID, req.requestID); err != nil {
+		slog.Error("Failed to record incident", "dedup_key", req.dedupKey, "error", err)
+	}
+}
+
+// Stop drains queued incidents and stops the worker
+func (r *Recorder) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopCh)
+	})
+	r.wg.Wait()
+}
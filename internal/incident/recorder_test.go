@@ -0,0 +1,128 @@
+package incident
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// fakeStore records incident writes; while gate is set each write waits on it
+type fakeStore struct {
+	mu      sync.Mutex
+	opened  []openRequest
+	started chan struct{}
+	gate    chan struct{}
+}
+
+func (s *fakeStore) OpenOrAppend(ctx context.Context, dedupKey, title, severity, source, clientID string, requestID uuid.UUID) error {
+	if s.gate != nil {
+		s.started <- struct{}{}
+		<-s.gate
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.opened = append(s.opened, openRequest{dedupKey: dedupKey, title: title, severity: severity, source: source, clientID: clientID, requestID: requestID})
+	return nil
+}
+
+func (s *fakeStore) Opened() []openRequest {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]openRequest(nil), s.opened...)
+}
+
+func TestRecorder_Observe(t *testing.T) {
+	store := &fakeStore{}
+	r := newRecorder(store, 10)
+
+	critical := models.PolicyMatch{PolicyID: uuid.New(), PolicyName: "ssn", Severity: "critical"}
+	requestID := uuid.New()
+	r.Observe(models.DecisionEvent{
+		Audit:   models.AuditLog{ClientID: "svc", RequestID: requestID},
+		Matches: []models.PolicyMatch{{PolicyID: uuid.New(), PolicyName: "email", Severity: "high"}, critical},
+	})
+	r.RecordEvent(notify.Event{
+		Severity: "high",
+		ClientID: "svc",
+		Summary:  "block rate over 50%",
+		Details:  map[string]interface{}{"rule": "block-rate", "request_id": requestID},
+	})
+	r.Stop() // Drains the queue
+
+	opened := store.Opened()
+	if len(opened) != 2 {
+		t.Fatalf("opened %d incidents, want 2 (critical match and rule): %+v", len(opened), opened)
+	}
+	want := openRequest{
+		dedupKey:  "policy:" + critical.PolicyID.String() + ":svc",
+		title:     `Critical policy "ssn" matched for client svc`,
+		severity:  "critical",
+		source:    "policy",
+		clientID:  "svc",
+		requestID: requestID,
+	}
+	if opened[0] != want {
+		t.Errorf("policy incident = %+v, want %+v", opened[0], want)
+	}
+	want = openRequest{
+		dedupKey:  "rule:block-rate:svc",
+		title:     "block rate over 50%",
+		severity:  "high",
+		source:    "rule",
+		clientID:  "svc",
+		requestID: requestID,
+	}
+	if opened[1] != want {
+		t.Errorf("rule incident = %+v, want %+v", opened[1], want)
+	}
+}
+
+func TestRecorder_DropsWhenQueueFull(t *testing.T) {
+	store := &fakeStore{started: make(chan struct{}), gate: make(chan struct{})}
+	r := newRecorder(store, 1)
+
+	event := func(rule string) notify.Event {
+		return notify.Event{Severity: "high", Details: map[string]interface{}{"rule": rule}}
+	}
+	r.RecordEvent(event("a"))
+	<-store.started // The worker holds a; b fills the queue and c is dropped
+	r.RecordEvent(event("b"))
+	r.RecordEvent(event("c"))
+
+	go func() {
+		for range store.started {
+		}
+	}()
+	close(store.gate)
+	r.Stop()
+	close(store.started)
+
+	opened := store.Opened()
+	if len(opened) != 2 || opened[0].dedupKey != "rule:a:" || opened[1].dedupKey != "rule:b:" {
+		t.Errorf("opened = %+v, want a and b only", opened)
+	}
+}
+
+func TestValidateTransition(t *testing.T) {
+	tests := []struct {
+		from, to string
+		ok       bool
+	}{
+		{"open", "acknowledged", true},
+		{"open", "resolved", true},
+		{"acknowledged", "resolved", true},
+		{"acknowledged", "open", false},
+		{"resolved", "open", true},
+		{"resolved", "acknowledged", false},
+		{"open", "closed", false},
+	}
+	for _, tt := range tests {
+		if err := validateTransition(tt.from, tt.to); (err == nil) != tt.ok {
+			t.Errorf("validateTransition(%s, %s) error = %v, want ok %v", tt.from, tt.to, err, tt.ok)
+		}
+	}
+}
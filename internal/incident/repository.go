@@ -0,0 +1,263 @@
+package incident
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// ErrNotFound is returned when an incident does not exist
+var ErrNotFound = errors.New("incident not found")
+
+// Repository handles incident data access
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new incident Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+const incidentColumns = `
+	id, title, severity, status, source, COALESCE(client_id, ''),
+	audit_request_ids, COALESCE(notes, ''),
+	COALESCE(acknowledged_by, ''), acknowledged_at,
+	COALESCE(resolved_by, ''), resolved_at, created_at, updated_at
+`
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanIncident maps a single incidents row to a model
+func scanIncident(row scanner) (*models.Incident, error) {
+	var inc models.Incident
+	var ackAt, resolvedAt sql.NullTime
+	err := row.Scan(
+		&inc.ID, &inc.Title, &inc.Severity, &inc.Status, &inc.Source, &inc.ClientID,
+		pq.Array(&inc.AuditRequestIDs), &inc.Notes,
+		&inc.AcknowledgedBy, &ackAt,
+		&inc.ResolvedBy, &resolvedAt, &inc.CreatedAt, &inc.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if ackAt.Valid {
+		inc.AcknowledgedAt = &ackAt.Time
+	}
+	if resolvedAt.Valid {
+		inc.ResolvedAt = &resolvedAt.Time
+	}
+	if inc.AuditRequestIDs == nil {
+		inc.AuditRequestIDs = []uuid.UUID{}
+	}
+	return &inc, nil
+}
+
+// List returns incidents, optionally filtered by status, newest first
+func (r *Repository) List(ctx context.Context, status string, limit int) ([]models.Incident, error) {
+	query := `SELECT ` + incidentColumns + `
+		FROM incidents
+		WHERE ($1 = '' OR status = $1)
+		ORDER BY created_at DESC
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, status, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incidents: %w", err)
+	}
+	defer rows.Close()
+
+	incidents := make([]models.Incident, 0)
+	for rows.Next() {
+		inc, err := scanIncident(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan incident: %w", err)
+		}
+		incidents = append(incidents, *inc)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating incidents: %w", err)
+	}
+
+	return incidents, nil
+}
+
+// GetByID returns an incident by ID
+func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Incident, error) {
+	query := `SELECT ` + incidentColumns + ` FROM incidents WHERE id = $1`
+
+	inc, err := scanIncident(r.db.QueryRowContext(ctx, query, id))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get incident: %w", err)
+	}
+
+	return inc, nil
+}
+
+// Create opens a manual incident
+func (r *Repository) Create(ctx context.Context, req models.CreateIncidentRequest) (*models.Incident, error) {
+	if req.Title == "" {
+		return nil, fmt.Errorf("title is required")
+	}
+	if !validSeverities[req.Severity] {
+		return nil, fmt.Errorf("invalid severity: must be low, medium, high, or critical")
+	}
+
+	query := `
+		INSERT INTO incidents (title, severity, source, client_id, audit_request_ids, notes)
+		VALUES ($1, $2, 'manual', NULLIF($3, ''), $4::uuid[], NULLIF($5, ''))
+		RETURNING ` + incidentColumns
+
+	inc, err := scanIncident(r.db.QueryRowContext(
+		ctx, query,
+		req.Title, req.Severity, req.ClientID, pq.Array(uuidStrings(req.AuditRequestIDs)), req.Notes,
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create incident: %w", err)
+	}
+
+	return inc, nil
+}
+
+// OpenOrAppend links a request to the open incident with the same dedup key,
+// creating a new incident when none is open
+func (r *Repository) OpenOrAppend(ctx context.Context, dedupKey, title, severity, source, clientID string, requestID uuid.UUID) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback() // Rollback if not committed
+
+	var id uuid.UUID
+	err = tx.QueryRowContext(ctx, `
+		SELECT id FROM incidents
+		WHERE dedup_key = $1 AND status <> 'resolved'
+		ORDER BY created_at DESC
+		LIMIT 1
+		FOR UPDATE
+	`, dedupKey).Scan(&id)
+
+	switch {
+	case err == sql.ErrNoRows:
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO incidents (title, severity, source, client_id, dedup_key, audit_request_ids)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, ARRAY[$6::uuid])
+		`, title, severity, source, clientID, dedupKey, requestID)
+		if err != nil {
+			return fmt.Errorf("failed to create incident: %w", err)
+		}
+	case err != nil:
+		return fmt.Errorf("failed to look up open incident: %w", err)
+	default:
+		_, err = tx.ExecContext(ctx, `
+			UPDATE incidents
+			SET audit_request_ids = array_append(audit_request_ids, $2::uuid), updated_at = NOW()
+			WHERE id = $1 AND NOT ($2::uuid = ANY(audit_request_ids))
+		`, id, requestID)
+		if err != nil {
+			return fmt.Errorf("failed to link audit entry to incident: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+// Update applies a status transition and/or notes change
+func (r *Repository) Update(ctx context.Context, id uuid.UUID, req models.UpdateIncidentRequest) (*models.Incident, error) {
+	current, err := r.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	status := current.Status
+	if req.Status != nil {
+		if err := validateTransition(current.Status, *req.Status); err != nil {
+			return nil, err
+		}
+		status = *req.Status
+	}
+	notes := current.Notes
+	if req.Notes != nil {
+		notes = *req.Notes
+	}
+
+	query := `
+		UPDATE incidents SET
+			status = $2,
+			notes = NULLIF($3, ''),
+			acknowledged_by = CASE WHEN $2 = 'acknowledged' AND status <> 'acknowledged' THEN NULLIF($4, '') ELSE acknowledged_by END,
+			acknowledged_at = CASE WHEN $2 = 'acknowledged' AND status <> 'acknowledged' THEN $5 ELSE acknowledged_at END,
+			resolved_by = CASE WHEN $2 = 'resolved' AND status <> 'resolved' THEN NULLIF($4, '') WHEN $2 = 'open' THEN NULL ELSE resolved_by END,
+			resolved_at = CASE WHEN $2 = 'resolved' AND status <> 'resolved' THEN $5 WHEN $2 = 'open' THEN NULL ELSE resolved_at END,
+			updated_at = $5
+		WHERE id = $1
+		RETURNING ` + incidentColumns
+
+	inc, err := scanIncident(r.db.QueryRowContext(ctx, query, id, status, notes, req.Actor, time.Now()))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update incident: %w", err)
+	}
+
+	return inc, nil
+}
+
+// Delete removes an incident
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM incidents WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete incident: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+var validSeverities = map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
+
+// validateTransition enforces the open → acknowledged → resolved workflow
+// Resolved incidents may be reopened
+func validateTransition(from, to string) error {
+	allowed := map[string]map[string]bool{
+		"open":         {"open": true, "acknowledged": true, "resolved": true},
+		"acknowledged": {"acknowledged": true, "resolved": true},
+		"resolved":     {"resolved": true, "open": true},
+	}
+	if _, ok := allowed[to]; !ok {
+		return fmt.Errorf("invalid status: must be open, acknowledged, or resolved")
+	}
+	if !allowed[from][to] {
+		return fmt.Errorf("invalid status transition: %s → %s", from, to)
+	}
+	return nil
+}
+
+// uuidStrings converts UUIDs to strings for PostgreSQL arrays
+func uuidStrings(ids []uuid.UUID) []string {
+	out := make([]string, len(ids))
+	for i, id := range ids {
+		out[i] = id.String()
+	}
+	return out
+}
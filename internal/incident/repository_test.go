@@ -0,0 +1,21 @@
+package incident
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestRepository_CreateValidates(t *testing.T) {
+	// Invalid requests are rejected before the database is touched
+	repo := NewRepository(nil)
+	for _, req := range []models.CreateIncidentRequest{
+		{Severity: "high"},
+		{Title: "leak", Severity: "urgent"},
+	} {
+		if _, err := repo.Create(context.Background(), req); err == nil {
+			t.Errorf("Create(%+v) succeeded, want validation error", req)
+		}
+	}
+}
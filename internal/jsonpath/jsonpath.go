@@ -0,0 +1,229 @@
+// Package jsonpath implements the subset of JSONPath needed to select string
+// fields in structured analyze payloads: $.a.b, $['a b'], [0], [*], .* and ..name
+package jsonpath
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// segment kinds
+const (
+	kindName = iota
+	kindIndex
+	kindWildcard
+	kindRecursive
+)
+
+// segment is one step of a compiled selector
+type segment struct {
+	kind  int
+	name  string
+	index int
+}
+
+// Path is a compiled JSONPath selector
+type Path struct {
+	raw      string
+	segments []segment
+}
+
+// String returns the selector as written
+func (p *Path) String() string { return p.raw }
+
+// Compile parses a JSONPath selector
+func Compile(selector string) (*Path, error) {
+	s := strings.TrimSpace(selector)
+	if !strings.HasPrefix(s, "$") {
+		return nil, fmt.Errorf("invalid selector %q: must start with $", selector)
+	}
+	s = s[1:]
+
+	var segments []segment
+	for len(s) > 0 {
+		switch {
+		case strings.HasPrefix(s, ".."):
+			segments = append(segments, segment{kind: kindRecursive})
+			s = s[2:]
+			// "$..name" / "$..*": the name follows directly without a dot
+			if len(s) > 0 && s[0] != '[' {
+				name, rest := readName(s)
+				if name == "" {
+					return nil, fmt.Errorf("invalid selector %q: expected name after ..", selector)
+				}
+				segments = append(segments, nameSegment(name))
+				s = rest
+			}
+		case s[0] == '.':
+			name, rest := readName(s[1:])
+			if name == "" {
+				return nil, fmt.Errorf("invalid selector %q: expected name after .", selector)
+			}
+			segments = append(segments, nameSegment(name))
+			s = rest
+		case s[0] == '[':
+			end := strings.IndexByte(s, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("invalid selector %q: unclosed [", selector)
+			}
+			inner := strings.TrimSpace(s[1:end])
+			s = s[end+1:]
+			switch {
+			case inner == "*":
+				segments = append(segments, segment{kind: kindWildcard})
+			case len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0]:
+				segments = append(segments, segment{kind: kindName, name: inner[1 : len(inner)-1]})
+			default:
+				idx, err := strconv.Atoi(inner)
+				if err != nil || idx < 0 {
+					return nil, fmt.Errorf("invalid selector %q: bad index [%s]", selector, inner)
+				}
+				segments = append(segments, segment{kind: kindIndex, index: idx})
+			}
+		default:
+			return nil, fmt.Errorf("invalid selector %q: unexpected %q", selector, s[0])
+		}
+	}
+
+	return &Path{raw: selector, segments: segments}, nil
+}
+
+// readName reads a dotted member name up to the next . or [
+func readName(s string) (string, string) {
+	end := strings.IndexAny(s, ".[")
+	if end < 0 {
+		return s, ""
+	}
+	return s[:end], s[end:]
+}
+
+// nameSegment turns a dotted name into a name or wildcard segment
+func nameSegment(name string) segment {
+	if name == "*" {
+		return segment{kind: kindWildcard}
+	}
+	return segment{kind: kindName, name: name}
+}
+
+// Step is one concrete step in a document location: an object key or array index
+type Step struct {
+	Key     string
+	Index   int
+	IsIndex bool
+}
+
+// Leaf is a string value found in a document
+type Leaf struct {
+	Path  string // Normalized location, e.g. $.tool_input.messages[0].text
+	Steps []Step
+	Value string
+}
+
+// Leaves returns every string value in a decoded JSON document in stable order
+func Leaves(doc interface{}) []Leaf {
+	var leaves []Leaf
+	walk(doc, nil, &leaves)
+	return leaves
+}
+
+// walk recursively collects string leaves
+func walk(node interface{}, steps []Step, leaves *[]Leaf) {
+	switch v := node.(type) {
+	case string:
+		stepsCopy := append([]Step(nil), steps...)
+		*leaves = append(*leaves, Leaf{Path: format(stepsCopy), Steps: stepsCopy, Value: v})
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			walk(v[k], append(steps, Step{Key: k}), leaves)
+		}
+	case []interface{}:
+		for i, item := range v {
+			walk(item, append(steps, Step{Index: i, IsIndex: true}), leaves)
+		}
+	}
+}
+
+// format renders steps as a normalized JSONPath
+func format(steps []Step) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, st := range steps {
+		switch {
+		case st.IsIndex:
+			fmt.Fprintf(&b, "[%d]", st.Index)
+		case isPlainName(st.Key):
+			b.WriteString(".")
+			b.WriteString(st.Key)
+		default:
+			fmt.Fprintf(&b, "['%s']", st.Key)
+		}
+	}
+	return b.String()
+}
+
+// isPlainName reports whether a key can be written in dot notation
+func isPlainName(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= '0' && r <= '9' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z') {
+			return false
+		}
+	}
+	return true
+}
+
+// Covers reports whether the selector selects the location or one of its
+// ancestors, so selecting an object covers every string nested inside it
+func (p *Path) Covers(steps []Step) bool {
+	for k := 0; k <= len(steps); k++ {
+		if match(p.segments, steps[:k]) {
+			return true
+		}
+	}
+	return false
+}
+
+// match reports whether segments match steps exactly
+func match(segments []segment, steps []Step) bool {
+	if len(segments) == 0 {
+		return len(steps) == 0
+	}
+
+	seg := segments[0]
+	if seg.kind == kindRecursive {
+		// Recursive descent consumes zero or more steps
+		for k := 0; k <= len(steps); k++ {
+			if match(segments[1:], steps[k:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(steps) == 0 {
+		return false
+	}
+
+	step := steps[0]
+	switch seg.kind {
+	case kindWildcard:
+	case kindName:
+		if step.IsIndex || step.Key != seg.name {
+			return false
+		}
+	case kindIndex:
+		if !step.IsIndex || step.Index != seg.index {
+			return false
+		}
+	}
+	return match(segments[1:], steps[1:])
+}
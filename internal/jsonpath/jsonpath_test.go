@@ -0,0 +1,64 @@
+package jsonpath
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSelectLeaves(t *testing.T) {
+	var doc interface{}
+	raw := `{
+		"tool": "search",
+		"input": {"query": "secret", "filters": ["a", "b"]},
+		"metadata": {"trace": "x", "note": "y"},
+		"weird key": "z"
+	}`
+	if err := json.Unmarshal([]byte(raw), &doc); err != nil {
+		t.Fatal(err)
+	}
+	leaves := Leaves(doc)
+
+	tests := []struct {
+		selector string
+		want     []string
+	}{
+		{selector: "$.input", want: []string{"$.input.filters[0]", "$.input.filters[1]", "$.input.query"}},
+		{selector: "$.input.filters[1]", want: []string{"$.input.filters[1]"}},
+		{selector: "$.input.filters[*]", want: []string{"$.input.filters[0]", "$.input.filters[1]"}},
+		{selector: "$..note", want: []string{"$.metadata.note"}},
+		{selector: "$['weird key']", want: []string{"$['weird key']"}},
+		{selector: "$.*", want: []string{"$.input.filters[0]", "$.input.filters[1]", "$.input.query", "$.metadata.note", "$.metadata.trace", "$.tool", "$['weird key']"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.selector, func(t *testing.T) {
+			p, err := Compile(tt.selector)
+			if err != nil {
+				t.Fatalf("Compile() error = %v", err)
+			}
+			var got []string
+			for _, leaf := range leaves {
+				if p.Covers(leaf.Steps) {
+					got = append(got, leaf.Path)
+				}
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("got %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for _, selector := range []string{"input", "$.", "$[abc]", "$[0"} {
+		if _, err := Compile(selector); err == nil {
+			t.Errorf("Compile(%q) expected error", selector)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+// Package langdetect guesses the language of a text, well enough to route it to
+// a language-specific model: non-Latin scripts identify their language (kana is
+// Japanese, Hangul Korean), and Latin-script text is told apart by its most
+// common function words
+package langdetect
+
+import (
+	"strings"
+	"unicode"
+)
+
+// maxBytes bounds the prefix examined; a few kilobytes settle the language
+const maxBytes = 4096
+
+// minWordHits is how many function words Latin-script text needs before its
+// language is named; shorter texts (a code snippet, a name) stay undetermined
+const minWordHits = 2
+
+// Result is the detected language of a text
+type Result struct {
+	Language   string  // ISO 639-1 code; empty when undetermined
+	Confidence float64 // Share of the evidence backing Language, 0 to 1
+}
+
+// scripts maps the non-Latin scripts to the language they are taken for
+var scripts = []struct {
+	table    *unicode.RangeTable
+	language string
+}{
+	{unicode.Hangul, "ko"},
+	{unicode.Cyrillic, "ru"},
+	{unicode.Arabic, "ar"},
+	{unicode.Hebrew, "he"},
+	{unicode.Greek, "el"},
+	{unicode.Thai, "th"},
+	{unicode.Devanagari, "hi"},
+}
+
+// functionWords are frequent words that are rare in the other languages listed
+var functionWords = map[string][]string{
+	"en": {"the", "and", "is", "are", "of", "to", "you", "that", "this", "with", "for", "not", "have", "what", "how", "be", "it", "was", "my", "your"},
+	"de": {"der", "die", "das", "und", "ist", "nicht", "ich", "sie", "mit", "ein", "eine", "zu", "auf", "für", "wie", "auch", "den", "dem", "mein", "bitte"},
+	"fr": {"le", "la", "les", "et", "est", "une", "des", "pas", "je", "vous", "que", "pour", "dans", "avec", "sur", "ce", "qui", "mon", "être", "du"},
+	"es": {"el", "los", "las", "y", "es", "una", "que", "por", "para", "con", "no", "como", "pero", "su", "mi", "yo", "está", "del", "se", "al"},
+	"it": {"il", "gli", "e", "è", "di", "che", "una", "per", "non", "sono", "come", "con", "mi", "ho", "questo", "della", "anche", "io", "lo", "ma"},
+	"pt": {"o", "os", "as", "e", "é", "um", "uma", "não", "que", "para", "com", "por", "eu", "você", "meu", "mas", "do", "da", "em", "está"},
+	"nl": {"de", "het", "een", "en", "is", "niet", "ik", "je", "van", "dat", "op", "met", "voor", "zijn", "wat", "hoe", "ook", "mijn", "maar", "er"},
+}
+
+// wordLanguages indexes functionWords by word
+var wordLanguages = func() map[string][]string {
+	index := make(map[string][]string)
+	for language, words := range functionWords {
+		for _, w := range words {
+			index[w] = append(index[w], language)
+		}
+	}
+	return index
+}()
+
+// Detect returns the language of text, or an empty Result when the text is too
+// short or mixed to tell
+func Detect(text string) Result {
+	if len(text) > maxBytes {
+		// Drops the rune the cut splits
+		text = strings.ToValidUTF8(text[:maxBytes], "")
+	}
+
+	var letters, latin, kana, han int
+	counts := make([]int, len(scripts))
+	for _, r := range text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		switch {
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		case unicode.In(r, unicode.Hiragana, unicode.Katakana):
+			kana++
+		case unicode.Is(unicode.Han, r):
+			han++
+		default:
+			for i, s := range scripts {
+				if unicode.Is(s.table, r) {
+					counts[i]++
+					break
+				}
+			}
+		}
+	}
+	if letters == 0 {
+		return Result{}
+	}
+
+	// Japanese mixes kana with kanji; Han without any kana is Chinese
+	best, bestCount := "", latin
+	if kana > 0 && kana+han > bestCount {
+		best, bestCount = "ja", kana+han
+	} else if han > bestCount {
+		best, bestCount = "zh", han
+	}
+	for i, s := range scripts {
+		if counts[i] > bestCount {
+			best, bestCount = s.language, counts[i]
+		}
+	}
+	share := float64(bestCount) / float64(letters)
+	if best != "" {
+		return Result{Language: best, Confidence: share}
+	}
+
+	language, confidence := latinLanguage(text)
+	if language == "" {
+		return Result{}
+	}
+	return Result{Language: language, Confidence: confidence * share}
+}
+
+// latinLanguage scores Latin-script text by the function words it contains
+func latinLanguage(text string) (string, float64) {
+	scores := make(map[string]int)
+	total := 0
+	for _, word := range strings.FieldsFunc(text, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' }) {
+		languages := wordLanguages[strings.ToLower(word)]
+		for _, language := range languages {
+			scores[language]++
+		}
+		if len(languages) > 0 {
+			total++
+		}
+	}
+
+	best, bestScore, tied := "", 0, false
+	for language, score := range scores {
+		switch {
+		case score > bestScore:
+			best, bestScore, tied = language, score, false
+		case score == bestScore:
+			tied = true
+		}
+	}
+	if bestScore < minWordHits || tied {
+		return "", 0
+	}
+	return best, float64(bestScore) / float64(total)
+}
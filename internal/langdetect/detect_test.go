@@ -0,0 +1,60 @@
+package langdetect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"english", "What is the best way to reset my password for this account?", "en"},
+		{"german", "Wie kann ich mein Passwort zurücksetzen? Es ist nicht möglich, bitte helfen Sie mir.", "de"},
+		{"french", "Je ne peux pas accéder à mon compte, est-ce que vous pouvez m'aider avec le mot de passe?", "fr"},
+		{"spanish", "No puedo entrar en mi cuenta, ¿me pueden ayudar con la contraseña por favor?", "es"},
+		{"japanese", "パスワードをリセットする方法を教えてください。", "ja"},
+		{"japanese kanji-heavy", "東京都の天気予報を確認してください", "ja"},
+		{"chinese", "请告诉我如何重置密码", "zh"},
+		{"korean", "비밀번호를 재설정하는 방법을 알려주세요", "ko"},
+		{"russian", "Как мне сбросить пароль от аккаунта?", "ru"},
+		{"arabic", "كيف يمكنني إعادة تعيين كلمة المرور؟", "ar"},
+		{"too short", "password reset", ""},
+		{"no letters", "1234 5678 !!", ""},
+		{"empty", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Detect(tt.text)
+			if got.Language != tt.want {
+				t.Errorf("Detect(%q) = %+v, want %q", tt.text, got, tt.want)
+			}
+			if tt.want != "" && (got.Confidence <= 0 || got.Confidence > 1) {
+				t.Errorf("Detect(%q) confidence = %v, want in (0, 1]", tt.text, got.Confidence)
+			}
+		})
+	}
+}
+
+func TestDetect_MixedScripts(t *testing.T) {
+	// An English question quoting a Japanese phrase stays English, with less
+	// confidence than a purely English one
+	mixed := Detect("What does ありがとう mean in the language of this app, and how is it used?")
+	pure := Detect("What does thanks mean in the language of this app, and how is it used?")
+	if mixed.Language != "en" || pure.Language != "en" {
+		t.Fatalf("Detect() = %+v, %+v; want en for both", mixed, pure)
+	}
+	if mixed.Confidence >= pure.Confidence {
+		t.Errorf("mixed confidence %v, want below %v", mixed.Confidence, pure.Confidence)
+	}
+}
+
+func TestDetect_LongInput(t *testing.T) {
+	// Only the prefix is examined, and a cut through a rune is harmless
+	text := "x" + strings.Repeat("日本語のテキストです。", 1000)
+	if got := Detect(text); got.Language != "ja" {
+		t.Errorf("Detect() = %+v, want ja", got)
+	}
+}
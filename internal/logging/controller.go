@@ -0,0 +1,133 @@
+// Package logging builds the gateway's structured logger: text or JSON output at a
+// runtime-adjustable level, request-scoped attributes carried by the context, and
+// scoped per-client debug logging, so one customer can be debugged without
+// restarting the fleet
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Controller owns the slog level and the set of clients with debug logging enabled
+type Controller struct {
+	level   *slog.LevelVar
+	logger  *slog.Logger // Honours the current level
+	verbose *slog.Logger // Always logs debug; used for clients under scoped debug
+	now     func() time.Time
+
+	mu      sync.Mutex           // Protects clients
+	clients map[string]time.Time // client_id → debug expiry
+}
+
+// NewController creates a controller logging to stderr in format (text or json)
+// at the given level
+func NewController(level slog.Level, format string) *Controller {
+	lv := new(slog.LevelVar)
+	lv.Set(level)
+	return &Controller{
+		level:   lv,
+		logger:  slog.New(newHandler(os.Stderr, format, lv)),
+		verbose: slog.New(newHandler(os.Stderr, format, slog.LevelDebug)),
+		now:     time.Now,
+		clients: make(map[string]time.Time),
+	}
+}
+
+// Logger returns the logger honoring the current level, to install as the
+// slog default
+func (c *Controller) Logger() *slog.Logger {
+	return c.logger
+}
+
+// ParseLevel accepts debug, info, warn or error (case-insensitive)
+func ParseLevel(s string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(strings.TrimSpace(s))); err != nil {
+		return 0, fmt.Errorf("level must be one of debug, info, warn, error")
+	}
+	return level, nil
+}
+
+// SetLevel changes the level for all subsequent log calls
+func (c *Controller) SetLevel(level slog.Level) {
+	c.level.Set(level)
+}
+
+// Level returns the current level
+func (c *Controller) Level() slog.Level {
+	return c.level.Level()
+}
+
+// EnableClient turns on debug logging for a single client until the returned time
+func (c *Controller) EnableClient(clientID string, d time.Duration) time.Time {
+	expiry := c.now().Add(d)
+	c.mu.Lock()
+	c.clients[clientID] = expiry
+	c.mu.Unlock()
+	return expiry
+}
+
+// DisableClient ends scoped debug logging for a client early
+func (c *Controller) DisableClient(clientID string) {
+	c.mu.Lock()
+	delete(c.clients, clientID)
+	c.mu.Unlock()
+}
+
+// ClientDebug reports whether scoped debug logging is active for a client
+func (c *Controller) ClientDebug(clientID string) bool {
+	if clientID == "" {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	expiry, ok := c.clients[clientID]
+	if !ok {
+		return false
+	}
+	if !c.now().Before(expiry) {
+		delete(c.clients, clientID)
+		return false
+	}
+	return true
+}
+
+// Debug logs a per-request debug line; it is emitted when the global level is
+// debug or when the request's client is under scoped debug
+func (c *Controller) Debug(ctx context.Context, clientID, msg string, args ...any) {
+	if c.ClientDebug(clientID) {
+		c.verbose.DebugContext(ctx, msg, args...)
+		return
+	}
+	c.logger.DebugContext(ctx, msg, args...)
+}
+
+// Status reports the current level and active client scopes
+func (c *Controller) Status() models.LogLevelStatus {
+	status := models.LogLevelStatus{Level: strings.ToLower(c.Level().String())}
+
+	now := c.now()
+	c.mu.Lock()
+	for clientID, expiry := range c.clients {
+		if !now.Before(expiry) {
+			delete(c.clients, clientID)
+			continue
+		}
+		status.DebugClients = append(status.DebugClients, models.DebugClient{ClientID: clientID, ExpiresAt: expiry})
+	}
+	c.mu.Unlock()
+
+	sort.Slice(status.DebugClients, func(i, j int) bool {
+		return status.DebugClients[i].ClientID < status.DebugClients[j].ClientID
+	})
+	return status
+}
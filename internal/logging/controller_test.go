@@ -0,0 +1,149 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newTestController returns a controller writing to buf at level, whose clock
+// is read from *now
+func newTestController(buf *bytes.Buffer, level slog.Level, now *time.Time) *Controller {
+	c := NewController(level, FormatText)
+	c.logger = slog.New(newHandler(buf, FormatText, c.level))
+	c.verbose = slog.New(newHandler(buf, FormatText, slog.LevelDebug))
+	c.now = func() time.Time { return *now }
+	return c
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    slog.Level
+		wantErr bool
+	}{
+		{"debug", slog.LevelDebug, false},
+		{" INFO ", slog.LevelInfo, false},
+		{"Warn", slog.LevelWarn, false},
+		{"error", slog.LevelError, false},
+		{"verbose", 0, true},
+		{"", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := ParseLevel(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLevel(%q) error = %v, wantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseLevel(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestController_SetLevel(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Now()
+	c := newTestController(&buf, slog.LevelInfo, &now)
+
+	c.Logger().Debug("hidden")
+	c.SetLevel(slog.LevelDebug)
+	if c.Level() != slog.LevelDebug {
+		t.Fatalf("Level() = %v, want debug", c.Level())
+	}
+	c.Logger().Debug("shown")
+
+	out := buf.String()
+	if strings.Contains(out, "hidden") || !strings.Contains(out, "shown") {
+		t.Errorf("output = %q, want only the debug line logged after SetLevel", out)
+	}
+}
+
+func TestController_ClientDebugExpiry(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestController(&buf, slog.LevelInfo, &now)
+
+	expiry := c.EnableClient("acme", 10*time.Minute)
+	if want := now.Add(10 * time.Minute); !expiry.Equal(want) {
+		t.Errorf("EnableClient() = %v, want %v", expiry, want)
+	}
+	if !c.ClientDebug("acme") {
+		t.Error("ClientDebug(acme) = false right after EnableClient")
+	}
+	if c.ClientDebug("other") || c.ClientDebug("") {
+		t.Error("ClientDebug() = true for a client that was never enabled")
+	}
+
+	now = expiry
+	if c.ClientDebug("acme") {
+		t.Error("ClientDebug(acme) = true at expiry")
+	}
+	if len(c.clients) != 0 {
+		t.Errorf("expired scope kept: %v", c.clients)
+	}
+
+	c.EnableClient("acme", time.Minute)
+	c.DisableClient("acme")
+	if c.ClientDebug("acme") {
+		t.Error("ClientDebug(acme) = true after DisableClient")
+	}
+}
+
+func TestController_Debug(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Now()
+	c := newTestController(&buf, slog.LevelInfo, &now)
+	c.EnableClient("acme", time.Minute)
+
+	c.Debug(context.Background(), "acme", "scoped line")
+	c.Debug(context.Background(), "other", "unscoped line")
+
+	out := buf.String()
+	if !strings.Contains(out, "scoped line") {
+		t.Errorf("output = %q, want the scoped client's debug line", out)
+	}
+	if strings.Contains(out, "unscoped line") {
+		t.Errorf("output = %q, want other clients' debug lines dropped at info", out)
+	}
+}
+
+func TestController_Status(t *testing.T) {
+	var buf bytes.Buffer
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	c := newTestController(&buf, slog.LevelWarn, &now)
+	c.EnableClient("zeta", time.Hour)
+	c.EnableClient("alpha", time.Hour)
+	c.EnableClient("short", time.Minute)
+	now = now.Add(2 * time.Minute)
+
+	status := c.Status()
+	if status.Level != "warn" {
+		t.Errorf("Level = %q, want warn", status.Level)
+	}
+	if len(status.DebugClients) != 2 || status.DebugClients[0].ClientID != "alpha" || status.DebugClients[1].ClientID != "zeta" {
+		t.Errorf("DebugClients = %+v, want alpha then zeta", status.DebugClients)
+	}
+	if _, ok := c.clients["short"]; ok {
+		t.Error("Status() kept the expired scope")
+	}
+}
+
+func TestWith(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(newHandler(&buf, FormatJSON, slog.LevelInfo))
+
+	ctx := With(context.Background(), "request_id", "req-1")
+	ctx = With(ctx, "client_id", "acme")
+	logger.InfoContext(ctx, "handled")
+
+	out := buf.String()
+	for _, want := range []string{`"request_id":"req-1"`, `"client_id":"acme"`, `"msg":"handled"`} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output = %q, want %s", out, want)
+		}
+	}
+}
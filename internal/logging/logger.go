@@ -0,0 +1,61 @@
+package logging
+
+import (
+	"context"
+	"io"
+	"log/slog"
+
+	"github.com/prompt-gateway/internal/tracing"
+)
+
+// Output formats of the process logger
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// attrsKey holds the request-scoped attributes added by With
+type attrsKey struct{}
+
+// With returns ctx carrying key/value attributes (request_id, client_id, ...) that
+// are added to every record logged with it
+func With(ctx context.Context, args ...any) context.Context {
+	attrs, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	added := slog.Group("", args...).Value.Group()
+	merged := make([]slog.Attr, 0, len(attrs)+len(added))
+	merged = append(append(merged, attrs...), added...)
+	return context.WithValue(ctx, attrsKey{}, merged)
+}
+
+// newHandler writes records to w in format (text unless json) at level
+func newHandler(w io.Writer, format string, level slog.Leveler) slog.Handler {
+	opts := &slog.HandlerOptions{Level: level}
+	if format == FormatJSON {
+		return contextHandler{slog.NewJSONHandler(w, opts)}
+	}
+	return contextHandler{slog.NewTextHandler(w, opts)}
+}
+
+// contextHandler adds the trace_id of the request a record is logged for, so log
+// lines can be joined with the caller's trace, and the attributes added by With
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if traceID := tracing.TraceID(ctx); traceID != "" {
+		record.AddAttrs(slog.String("trace_id", traceID))
+	}
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,182 @@
+// Package maintenance puts the gateway into a controlled not-ready state so
+// in-flight work and audit buffers can drain before e.g. database migrations
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// ErrActive is returned to new work while maintenance mode is on
+var ErrActive = errors.New("gateway is in maintenance mode")
+
+// Drainer flushes a buffer to durable storage
+type Drainer interface {
+	Flush(ctx context.Context) error
+}
+
+// namedDrainer labels a drainer in the status report
+type namedDrainer struct {
+	name    string
+	drainer Drainer
+}
+
+// Controller tracks maintenance mode and in-flight evaluations
+type Controller struct {
+	enabled  atomic.Bool
+	inFlight atomic.Int64
+	drainers []namedDrainer
+	timeout  time.Duration // Upper bound for a full drain
+
+	mu         sync.Mutex // Protects the fields below
+	since      time.Time
+	retryAfter int
+	state      string // "draining", "drained" or "drain_failed"
+	drainErr   string
+	cancel     context.CancelFunc
+}
+
+// NewController creates a controller; drains give up after timeout
+func NewController(timeout time.Duration) *Controller {
+	return &Controller{timeout: timeout}
+}
+
+// AddDrainer registers a buffer flushed, in registration order, when
+// maintenance starts. Must be called before the server starts
+func (c *Controller) AddDrainer(name string, drainer Drainer) {
+	c.drainers = append(c.drainers, namedDrainer{name: name, drainer: drainer})
+}
+
+// Begin admits a unit of work; it fails with ErrActive during maintenance
+// Every successful Begin must be paired with End
+func (c *Controller) Begin() error {
+	// Count first so Enable never observes zero in-flight while we slip through
+	c.inFlight.Add(1)
+	if c.enabled.Load() {
+		c.inFlight.Add(-1)
+		return ErrActive
+	}
+	return nil
+}
+
+// End marks a unit of work admitted by Begin as finished
+func (c *Controller) End() {
+	c.inFlight.Add(-1)
+}
+
+// Enabled reports whether maintenance mode is on
+func (c *Controller) Enabled() bool {
+	return c.enabled.Load()
+}
+
+// RetryAfter is the Retry-After hint (seconds) for rejected requests
+func (c *Controller) RetryAfter() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.retryAfter
+}
+
+// Enable turns maintenance mode on and drains in the background
+// Calling it again while enabled only updates the Retry-After hint
+func (c *Controller) Enable(retryAfter int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.retryAfter = retryAfter
+	if c.enabled.Swap(true) {
+		return
+	}
+	metrics.MaintenanceMode.Set(1)
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	c.since = time.Now()
+	c.state = "draining"
+	c.drainErr = ""
+	c.cancel = cancel
+	slog.Warn("Maintenance mode enabled, draining", "retry_after", retryAfter)
+	go c.drain(ctx)
+}
+
+// Disable turns maintenance mode off, abandoning any drain still running
+func (c *Controller) Disable() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.enabled.Swap(false) {
+		return
+	}
+	metrics.MaintenanceMode.Set(0)
+	if c.cancel != nil {
+		c.cancel()
+	}
+	c.state = ""
+	slog.Info("Maintenance mode disabled")
+}
+
+// drain waits for in-flight work to finish, then flushes every buffer
+func (c *Controller) drain(ctx context.Context) {
+	err := c.waitIdle(ctx)
+	for _, d := range c.drainers {
+		if err != nil {
+			break
+		}
+		if err = d.drainer.Flush(ctx); err != nil {
+			err = errors.New(d.name + ": " + err.Error())
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if ctx.Err() == context.Canceled {
+		return // Disabled while draining
+	}
+	if err != nil {
+		c.state = "drain_failed"
+		c.drainErr = err.Error()
+		slog.Error("Maintenance drain failed", "error", err)
+		return
+	}
+	c.state = "drained"
+	slog.Info("Maintenance drain complete, safe to proceed")
+}
+
+// waitIdle polls until no evaluation is in flight
+func (c *Controller) waitIdle(ctx context.Context) error {
+	ticker := time.NewTicker(50 * time.Millisecond)
+	defer ticker.Stop()
+
+	for c.inFlight.Load() > 0 {
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return errors.New("in-flight requests did not finish: " + ctx.Err().Error())
+		}
+	}
+	return nil
+}
+
+// Status reports the current maintenance state
+func (c *Controller) Status() models.MaintenanceStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := models.MaintenanceStatus{
+		Enabled:  c.enabled.Load(),
+		InFlight: c.inFlight.Load(),
+	}
+	if status.Enabled {
+		since := c.since
+		status.Since = &since
+		status.RetryAfterSeconds = c.retryAfter
+		status.DrainState = c.state
+		status.DrainError = c.drainErr
+	}
+	return status
+}
@@ -0,0 +1,58 @@
+package maintenance
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+type countingDrainer struct {
+	flushed chan struct{}
+}
+
+func (d *countingDrainer) Flush(ctx context.Context) error {
+	close(d.flushed)
+	return nil
+}
+
+func TestController_DrainsAfterInFlightWork(t *testing.T) {
+	c := NewController(time.Second)
+	drainer := &countingDrainer{flushed: make(chan struct{})}
+	c.AddDrainer("audit", drainer)
+
+	if err := c.Begin(); err != nil {
+		t.Fatalf("Begin() before maintenance error = %v", err)
+	}
+
+	c.Enable(30)
+	if err := c.Begin(); !errors.Is(err, ErrActive) {
+		t.Fatalf("Begin() during maintenance error = %v, want ErrActive", err)
+	}
+
+	select {
+	case <-drainer.flushed:
+		t.Fatal("drained while work was still in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	c.End()
+	select {
+	case <-drainer.flushed:
+	case <-time.After(time.Second):
+		t.Fatal("drainer was not flushed after in-flight work finished")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for c.Status().DrainState != "drained" && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := c.Status(); status.DrainState != "drained" || status.RetryAfterSeconds != 30 {
+		t.Errorf("Status() = %+v, want drained with retry_after 30", status)
+	}
+
+	c.Disable()
+	if err := c.Begin(); err != nil {
+		t.Errorf("Begin() after maintenance error = %v", err)
+	}
+}
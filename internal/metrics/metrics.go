@@ -1,6 +1,11 @@
 package metrics
 
-import "github.com/prometheus/client_golang/prometheus"
+import (
+	"database/sql"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
 
 var (
 	HTTPRequestsTotal = prometheus.NewCounterVec(
@@ -28,12 +33,368 @@ var (
 		[]string{"severity"},
 	)
 
+	DecisionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_decisions_total",
+			Help: "Total number of analyze decisions by final outcome (allow, block, redact, log), highest matched severity and enforcement mode.",
+		},
+		[]string{"action", "severity", "mode"},
+	)
+
+	MaintenanceMode = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_maintenance_mode",
+			Help: "1 while the gateway is in maintenance mode, 0 otherwise.",
+		},
+	)
+
+	CircuitBreakerState = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_circuit_breaker_state",
+			Help: "Circuit breaker state per dependency (0=closed, 1=half-open, 2=open).",
+		},
+		[]string{"name"},
+	)
+
+	CircuitBreakerRejections = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_circuit_breaker_rejections_total",
+			Help: "Total number of calls rejected by an open circuit breaker.",
+		},
+		[]string{"name"},
+	)
+
+	EvasionTransformsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_evasion_transforms_total",
+			Help: "Total number of analyzed contents changed by each evasion normalization.",
+		},
+		[]string{"transform"},
+	)
+
 	AuditQueueLength = prometheus.NewGauge(
 		prometheus.GaugeOpts{
 			Name: "gateway_audit_queue_length",
 			Help: "Current number of audit log entries queued in Redis for persistence.",
 		},
 	)
+
+	AuditBacklogLevel = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_audit_backlog_level",
+			Help: "Audit queue backlog level driving sync acceleration (0 normal, 1 elevated, 2 critical).",
+		},
+	)
+
+	AuditWALEntries = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_audit_wal_entries",
+			Help: "Audit entries spilled to the local disk WAL and waiting to be replayed.",
+		},
+	)
+
+	AuditWALTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_wal_total",
+			Help: "Total number of audit entries by disk WAL result (spilled, replayed, dropped when the WAL was full or unavailable, corrupt).",
+		},
+		[]string{"result"},
+	)
+
+	AuditForwardedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_forwarded_total",
+			Help: "Total number of audit entries forwarded to the owner region by result (sent, failed).",
+		},
+		[]string{"result"},
+	)
+
+	AuditSyncDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_audit_sync_duration_seconds",
+			Help:    "Duration of Redis to Postgres audit sync batches by write mode (bulk, fallback, forward).",
+			Buckets: prometheus.ExponentialBuckets(0.005, 2, 14),
+		},
+		[]string{"mode"},
+	)
+
+	AuditSyncBatchSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "gateway_audit_sync_batch_size",
+			Help:    "Number of audit log entries popped from Redis per sync batch.",
+			Buckets: prometheus.ExponentialBuckets(1, 4, 8),
+		},
+	)
+
+	AuditBulkInsertFailures = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_bulk_insert_failures_total",
+			Help: "Total number of audit sync batches whose bulk COPY failed.",
+		},
+	)
+
+	AuditFallbackInserts = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_fallback_inserts_total",
+			Help: "Total number of individual audit inserts after a failed bulk COPY, by result.",
+		},
+		[]string{"result"},
+	)
+
+	AuditRequeuedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_requeued_total",
+			Help: "Total number of audit log entries pushed back to Redis for retry.",
+		},
+	)
+
+	AuditDroppedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_dropped_total",
+			Help: "Total number of audit log entries lost, by reason (buffer_full, storage_failed, shutdown, unmarshal, requeue_failed).",
+		},
+		[]string{"reason"},
+	)
+
+	AuditBufferWaitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_audit_buffer_waits_total",
+			Help: "Total number of audit log entries that waited for a full buffer in blocking mode, by result (queued, timeout).",
+		},
+		[]string{"result"},
+	)
+
+	PolicyTimeoutsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_policy_timeouts_total",
+			Help: "Total number of evaluations that hit the deadline while a policy was still running, by policy.",
+		},
+		[]string{"policy"},
+	)
+
+	PolicyNotificationsSuppressedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_policy_notifications_suppressed_total",
+			Help: "Total number of policy notifications suppressed by the policy's cooldown, by policy.",
+		},
+		[]string{"policy"},
+	)
+
+	DecisionCacheTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_decision_cache_total",
+			Help: "Total number of analyze requests by decision cache result (hit, miss, bypass).",
+		},
+		[]string{"result"},
+	)
+
+	ModelCallsWithheldTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_model_calls_withheld_total",
+			Help: "Total number of model evaluations not sent to a provider because of tenant privacy rules.",
+		},
+		[]string{"provider", "reason"},
+	)
+
+	ModelRoutesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_model_routes_total",
+			Help: "Total number of model evaluations routed to another model by the detected content language.",
+		},
+		[]string{"model", "language"},
+	)
+
+	ModelInputTruncatedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_model_input_truncated_total",
+			Help: "Total number of model evaluations whose content was cut to MODEL_MAX_TOKENS.",
+		},
+	)
+
+	AnalyzerMatcherDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_analyzer_matcher_duration_seconds",
+			Help:    "Time spent in analyzer matchers by class (regex, keyword, profanity, model, tokens, normalize).",
+			Buckets: prometheus.ExponentialBuckets(0.00001, 4, 10),
+		},
+		[]string{"class"},
+	)
+
+	AnalyzeTokens = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_analyze_tokens",
+			Help:    "Tokens per analyze request by field (prompt, response).",
+			Buckets: prometheus.ExponentialBuckets(16, 4, 8),
+		},
+		[]string{"field"},
+	)
+
+	PolicyInputTruncatedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_policy_input_truncated_total",
+			Help: "Total number of policy evaluations that scanned only the first max_input_bytes of content.",
+		},
+	)
+
+	PolicyWritesRejectedTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_policy_writes_rejected_total",
+			Help: "Total number of policy writes rejected by the change guard, by reason (rate_limited, confirmation_required).",
+		},
+		[]string{"reason"},
+	)
+
+	SlowPolicies = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_slow_policies",
+			Help: "Number of policies whose average evaluation time has exceeded the slow threshold.",
+		},
+	)
+
+	ChaosInjectionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_chaos_injections_total",
+			Help: "Total number of faults injected by chaos mode by fault (latency, postgres, redis, model_timeout).",
+		},
+		[]string{"fault"},
+	)
+
+	AuditLastSyncTimestamp = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_audit_last_sync_timestamp_seconds",
+			Help: "Unix time of the last sync batch that wrote audit logs to Postgres.",
+		},
+	)
+
+	DBPoolSaturatedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_db_pool_saturated_total",
+			Help: "Total number of requests that arrived while every Postgres connection was in use and got a bounded wait.",
+		},
+	)
+
+	DBPoolBusyTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_db_pool_busy_total",
+			Help: "Total number of requests answered 503 storage busy after the bounded wait for a Postgres connection expired.",
+		},
+	)
+
+	FirehoseRecordsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_firehose_records_total",
+			Help: "Total number of decision records mirrored to the firehose by result (sent, dropped when the queue was full, failed after retries).",
+		},
+		[]string{"result"},
+	)
+
+	UsageReportsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_usage_reports_total",
+			Help: "Total number of tenant usage reports by period and result (delivered, failed and retried on the next check).",
+		},
+		[]string{"period", "result"},
+	)
+
+	FirehoseQueueLength = prometheus.NewGauge(
+		prometheus.GaugeOpts{
+			Name: "gateway_firehose_queue_length",
+			Help: "Number of decision records waiting for firehose delivery.",
+		},
+	)
+
+	ShadowRequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_shadow_requests_total",
+			Help: "Total number of sampled analyze requests mirrored to the shadow gateway by result (match, diff, error, dropped when the queue was full).",
+		},
+		[]string{"result"},
+	)
+
+	ShadowLatencyDelta = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Name:    "gateway_shadow_latency_delta_seconds",
+			Help:    "Shadow gateway evaluation latency minus the primary's for mirrored requests (negative when the shadow is faster).",
+			Buckets: []float64{-1, -0.25, -0.1, -0.05, -0.01, 0, 0.01, 0.05, 0.1, 0.25, 1},
+		},
+	)
+
+	SchedulerQueueLength = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_scheduler_queue_length",
+			Help: "Number of analyze requests waiting for an evaluation slot by priority.",
+		},
+		[]string{"priority"},
+	)
+
+	SchedulerWaitSeconds = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:    "gateway_scheduler_wait_seconds",
+			Help:    "Time analyze requests waited for an evaluation slot by priority.",
+			Buckets: []float64{0, .001, .005, .01, .05, .1, .5, 1, 5, 30},
+		},
+		[]string{"priority"},
+	)
+
+	SchedulerRejectedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_scheduler_rejected_total",
+			Help: "Total number of batch analyze requests rejected because the batch queue was full.",
+		},
+	)
+
+	ModelPoliciesDeferredTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_model_policies_deferred_total",
+			Help: "Total number of batch analyze requests evaluated without model policies because the gateway was under load.",
+		},
+	)
+
+	ModelPoliciesSkippedTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_model_policies_skipped_total",
+			Help: "Total number of model policy checks skipped because the model provider was unavailable.",
+		},
+	)
+
+	DegradedEvaluationsTotal = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Name: "gateway_degraded_evaluations_total",
+			Help: "Total number of analyze requests answered in degraded (pattern-only) mode.",
+		},
+	)
+
+	AnalyzeSLORequestsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_analyze_slo_requests_total",
+			Help: "Total number of analyze requests by latency SLO threshold and result (good: succeeded within the threshold, bad: failed or slower).",
+		},
+		[]string{"threshold", "result"},
+	)
+
+	AnalyzeSLOObjective = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Name: "gateway_analyze_slo_objective",
+			Help: "Target fraction of good analyze requests by latency SLO threshold.",
+		},
+		[]string{"threshold"},
+	)
+
+	AllowlistHitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_allowlist_hits_total",
+			Help: "Total number of prompts allowed without analysis because their content hash is allowlisted, by scope (prompt, message).",
+		},
+		[]string{"scope"},
+	)
+
+	RequestLimitsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gateway_request_limits_total",
+			Help: "Total number of analyze requests cut short by a per-request limit, by limit (policies, matches, occurrences, redactions).",
+		},
+		[]string{"limit"},
+	)
 )
 
 // Register registers all application metrics with the default Prometheus registry.
@@ -41,5 +402,58 @@ func Register() {
 	prometheus.MustRegister(HTTPRequestsTotal)
 	prometheus.MustRegister(HTTPRequestDuration)
 	prometheus.MustRegister(AnalyzerMatchesTotal)
+	prometheus.MustRegister(DecisionsTotal)
+	prometheus.MustRegister(MaintenanceMode)
+	prometheus.MustRegister(CircuitBreakerState)
+	prometheus.MustRegister(CircuitBreakerRejections)
+	prometheus.MustRegister(EvasionTransformsTotal)
 	prometheus.MustRegister(AuditQueueLength)
+	prometheus.MustRegister(AuditBacklogLevel)
+	prometheus.MustRegister(AuditWALEntries)
+	prometheus.MustRegister(AuditWALTotal)
+	prometheus.MustRegister(AuditForwardedTotal)
+	prometheus.MustRegister(AuditSyncDuration)
+	prometheus.MustRegister(AuditSyncBatchSize)
+	prometheus.MustRegister(AuditBulkInsertFailures)
+	prometheus.MustRegister(AuditFallbackInserts)
+	prometheus.MustRegister(AuditRequeuedTotal)
+	prometheus.MustRegister(AuditDroppedTotal)
+	prometheus.MustRegister(AuditBufferWaitsTotal)
+	prometheus.MustRegister(AuditLastSyncTimestamp)
+	prometheus.MustRegister(ChaosInjectionsTotal)
+	prometheus.MustRegister(PolicyTimeoutsTotal)
+	prometheus.MustRegister(PolicyInputTruncatedTotal)
+	prometheus.MustRegister(PolicyWritesRejectedTotal)
+	prometheus.MustRegister(ModelInputTruncatedTotal)
+	prometheus.MustRegister(ModelCallsWithheldTotal)
+	prometheus.MustRegister(ModelRoutesTotal)
+	prometheus.MustRegister(PolicyNotificationsSuppressedTotal)
+	prometheus.MustRegister(DecisionCacheTotal)
+	prometheus.MustRegister(AnalyzerMatcherDuration)
+	prometheus.MustRegister(AnalyzeTokens)
+	prometheus.MustRegister(SlowPolicies)
+	prometheus.MustRegister(DBPoolSaturatedTotal)
+	prometheus.MustRegister(DBPoolBusyTotal)
+	prometheus.MustRegister(FirehoseRecordsTotal)
+	prometheus.MustRegister(FirehoseQueueLength)
+	prometheus.MustRegister(UsageReportsTotal)
+	prometheus.MustRegister(ShadowRequestsTotal)
+	prometheus.MustRegister(ShadowLatencyDelta)
+	prometheus.MustRegister(SchedulerQueueLength)
+	prometheus.MustRegister(SchedulerWaitSeconds)
+	prometheus.MustRegister(SchedulerRejectedTotal)
+	prometheus.MustRegister(ModelPoliciesDeferredTotal)
+	prometheus.MustRegister(ModelPoliciesSkippedTotal)
+	prometheus.MustRegister(DegradedEvaluationsTotal)
+	prometheus.MustRegister(AnalyzeSLORequestsTotal)
+	prometheus.MustRegister(AnalyzeSLOObjective)
+	prometheus.MustRegister(AllowlistHitsTotal)
+	prometheus.MustRegister(RequestLimitsTotal)
+}
+
+// RegisterDBStats exports the Postgres pool's sql.DBStats (max open, open, in-use and
+// idle connections, wait count and duration, closed connections) as go_sql_* metrics
+// with db_name="postgres"
+func RegisterDBStats(db *sql.DB) {
+	prometheus.MustRegister(collectors.NewDBStatsCollector(db, "postgres"))
 }
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/redis/go-redis/v9"
+)
+
+// redisPoolCollector exports go-redis connection pool statistics, the Redis
+// counterpart of the go_sql_* Postgres pool metrics
+type redisPoolCollector struct {
+	client *redis.Client
+
+	maxConns     *prometheus.Desc
+	totalConns   *prometheus.Desc
+	idleConns    *prometheus.Desc
+	inUseConns   *prometheus.Desc
+	hits         *prometheus.Desc
+	misses       *prometheus.Desc
+	timeouts     *prometheus.Desc
+	waitCount    *prometheus.Desc
+	waitDuration *prometheus.Desc
+	staleConns   *prometheus.Desc
+}
+
+// newRedisPoolCollector creates a collector reading client's pool stats on every scrape
+func newRedisPoolCollector(client *redis.Client) *redisPoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc("gateway_redis_pool_"+name, help, nil, nil)
+	}
+	return &redisPoolCollector{
+		client:       client,
+		maxConns:     desc("max_connections", "Maximum number of connections the Redis pool may open (REDIS_POOL_SIZE)."),
+		totalConns:   desc("connections", "Number of open Redis connections, in use and idle."),
+		idleConns:    desc("idle_connections", "Number of idle Redis connections."),
+		inUseConns:   desc("in_use_connections", "Number of Redis connections currently in use."),
+		hits:         desc("hits_total", "Total number of times a free connection was found in the Redis pool."),
+		misses:       desc("misses_total", "Total number of times no free connection was found and one had to be dialed or waited for."),
+		timeouts:     desc("timeouts_total", "Total number of times waiting for a Redis connection exceeded REDIS_POOL_TIMEOUT."),
+		waitCount:    desc("wait_count_total", "Total number of times a request waited for a Redis connection."),
+		waitDuration: desc("wait_duration_seconds_total", "Total time spent waiting for Redis connections."),
+		staleConns:   desc("stale_connections_closed_total", "Total number of stale Redis connections removed from the pool."),
+	}
+}
+
+// Describe implements prometheus.Collector
+func (c *redisPoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.maxConns
+	ch <- c.totalConns
+	ch <- c.idleConns
+	ch <- c.inUseConns
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.timeouts
+	ch <- c.waitCount
+	ch <- c.waitDuration
+	ch <- c.staleConns
+}
+
+// Collect implements prometheus.Collector
+func (c *redisPoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.client.PoolStats()
+	inUse := 0.0
+	if stats.TotalConns > stats.IdleConns {
+		inUse = float64(stats.TotalConns - stats.IdleConns)
+	}
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(c.client.Options().PoolSize))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stats.TotalConns))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stats.IdleConns))
+	ch <- prometheus.MustNewConstMetric(c.inUseConns, prometheus.GaugeValue, inUse)
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.timeouts, prometheus.CounterValue, float64(stats.Timeouts))
+	ch <- prometheus.MustNewConstMetric(c.waitCount, prometheus.CounterValue, float64(stats.WaitCount))
+	ch <- prometheus.MustNewConstMetric(c.waitDuration, prometheus.CounterValue, time.Duration(stats.WaitDurationNs).Seconds())
+	ch <- prometheus.MustNewConstMetric(c.staleConns, prometheus.CounterValue, float64(stats.StaleConns))
+}
+
+// RegisterRedisPoolStats exports client's connection pool statistics as gateway_redis_pool_* metrics
+func RegisterRedisPoolStats(client *redis.Client) {
+	prometheus.MustRegister(newRedisPoolCollector(client))
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/redis/go-redis/v9"
+)
+
+func TestRedisPoolCollector(t *testing.T) {
+	client := redis.NewClient(&redis.Options{Addr: "localhost:0", PoolSize: 7})
+	defer client.Close()
+
+	c := newRedisPoolCollector(client)
+	if n := testutil.CollectAndCount(c); n != 10 {
+		t.Errorf("CollectAndCount() = %d, want 10", n)
+	}
+
+	expected := `
+# HELP gateway_redis_pool_max_connections Maximum number of connections the Redis pool may open (REDIS_POOL_SIZE).
+# TYPE gateway_redis_pool_max_connections gauge
+gateway_redis_pool_max_connections 7
+`
+	if err := testutil.CollectAndCompare(c, strings.NewReader(expected), "gateway_redis_pool_max_connections"); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,70 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// cooldownKeyPrefix namespaces notification cooldown counters in Redis
+const cooldownKeyPrefix = "notify_cooldown:"
+
+// suppressedRetention is how long a suppressed count waits for the next
+// notification to report it after its window has closed
+const suppressedRetention = 24 * time.Hour
+
+// CooldownStore counts notifications per key so only the first of each window
+// is delivered; RedisCooldowns is the shared implementation
+type CooldownStore interface {
+	// Acquire counts a notification for key, reporting whether it opens a new
+	// window and how many notifications were suppressed since the last one delivered
+	Acquire(ctx context.Context, key string, window time.Duration) (bool, int64, error)
+}
+
+// RedisCooldowns keeps cooldown counters in Redis so every gateway replica
+// shares one window; expiry is delegated to the key TTL
+type RedisCooldowns struct {
+	rdb *redis.Client
+}
+
+// NewRedisCooldowns creates a Redis-backed cooldown store
+func NewRedisCooldowns(rdb *redis.Client) *RedisCooldowns {
+	return &RedisCooldowns{rdb: rdb}
+}
+
+// Acquire increments the window counter for key; the first increment opens the
+// window and collects the suppressed count, later ones add to it
+func (c *RedisCooldowns) Acquire(ctx context.Context, key string, window time.Duration) (bool, int64, error) {
+	windowKey := cooldownKeyPrefix + key
+	suppressedKey := windowKey + ":suppressed"
+
+	var count *redis.IntCmd
+	_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		count = pipe.Incr(ctx, windowKey)
+		pipe.ExpireNX(ctx, windowKey, window)
+		return nil
+	})
+	if err != nil {
+		return false, 0, fmt.Errorf("failed to count notification: %w", err)
+	}
+
+	if count.Val() > 1 {
+		_, err := c.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Incr(ctx, suppressedKey)
+			pipe.Expire(ctx, suppressedKey, window+suppressedRetention)
+			return nil
+		})
+		if err != nil {
+			return false, 0, fmt.Errorf("failed to count suppressed notification: %w", err)
+		}
+		return false, 0, nil
+	}
+
+	suppressed, err := c.rdb.GetDel(ctx, suppressedKey).Int64()
+	if err != nil && err != redis.Nil {
+		return true, 0, fmt.Errorf("failed to read suppressed notifications: %w", err)
+	}
+	return true, suppressed, nil
+}
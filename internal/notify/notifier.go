@@ -0,0 +1,180 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Event is an alert emitted by background detectors and rule evaluators
+type Event struct {
+	Type      string                 `json:"type"`     // e.g. "anomaly.violation_rate", "rule.triggered"
+	Severity  string                 `json:"severity"` // "low", "medium", "high", "critical"
+	ClientID  string                 `json:"client_id,omitempty"`
+	Summary   string                 `json:"summary"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	Timestamp time.Time              `json:"timestamp"`
+	Sinks     []string               `json:"-"` // Restrict delivery to these sink names (all when empty)
+}
+
+// Sink delivers events to an external destination
+type Sink interface {
+	Name() string
+	Send(ctx context.Context, event Event) error
+}
+
+// Notifier fans events out to all configured sinks asynchronously
+type Notifier struct {
+	sinks   []Sink
+	events  chan Event
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	timeout time.Duration
+}
+
+// NewNotifier creates a Notifier and starts its delivery worker
+func NewNotifier(bufferSize int, sinks ...Sink) *Notifier {
+	n := &Notifier{
+		sinks:   sinks,
+		events:  make(chan Event, bufferSize),
+		stopCh:  make(chan struct{}),
+		timeout: 5 * time.Second,
+	}
+
+	n.wg.Add(1)
+	go n.worker()
+	slog.Info("Notifier started", "sinks", len(sinks))
+
+	return n
+}
+
+// Notify queues an event for delivery (non-blocking)
+// Events are dropped when the buffer is full so alerting never blocks requests
+func (n *Notifier) Notify(event Event) {
+	if event.Timestamp.IsZero() {
+		event.Timestamp = time.Now()
+	}
+
+	select {
+	case n.events <- event:
+	default:
+		slog.Warn("Notification buffer full, dropping event", "event", event.Type)
+	}
+}
+
+// worker delivers queued events to every sink
+func (n *Notifier) worker() {
+	defer n.wg.Done()
+
+	for {
+		select {
+		case event := <-n.events:
+			n.deliver(event)
+		case <-n.stopCh:
+			// Drain remaining events before stopping
+			for {
+				select {
+				case event := <-n.events:
+					n.deliver(event)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver sends one event to all sinks, logging failures
+func (n *Notifier) deliver(event Event) {
+	for _, sink := range n.sinks {
+		if !routesTo(event, sink) {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), n.timeout)
+		if err := sink.Send(ctx, event); err != nil {
+			slog.Error("Failed to deliver event", "event", event.Type, "sink", sink.Name(), "error", err)
+		}
+		cancel()
+	}
+}
+
+// routesTo reports whether an event should be delivered to a sink
+func routesTo(event Event, sink Sink) bool {
+	if len(event.Sinks) == 0 {
+		return true
+	}
+	for _, name := range event.Sinks {
+		if name == sink.Name() {
+			return true
+		}
+	}
+	return false
+}
+
+// Close stops the worker after draining queued events
+func (n *Notifier) Close() {
+	close(n.stopCh)
+	n.wg.Wait()
+	slog.Info("Notifier stopped")
+}
+
+// LogSink writes events to the process log
+type LogSink struct{}
+
+// Name identifies the sink in error logs
+func (LogSink) Name() string { return "log" }
+
+// Send logs the event
+func (LogSink) Send(ctx context.Context, event Event) error {
+	slog.ErrorContext(ctx, event.Summary, "event", event.Type, "severity", event.Severity, "client_id", event.ClientID)
+	return nil
+}
+
+// WebhookSink POSTs events as JSON to an HTTP endpoint
+type WebhookSink struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookSink creates a sink that posts events to url
+func NewWebhookSink(url string, httpClient *http.Client) *WebhookSink {
+	client := httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Second}
+	}
+	return &WebhookSink{url: url, httpClient: client}
+}
+
+// Name identifies the sink in error logs
+func (s *WebhookSink) Name() string { return "webhook" }
+
+// Send posts the event to the webhook URL
+func (s *WebhookSink) Send(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create webhook request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,190 @@
+package notify
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// PolicyLookup resolves a matched policy to its current definition
+type PolicyLookup func(id uuid.UUID) (models.Policy, bool)
+
+// policyDelivery is one event bound for one policy's webhook
+type policyDelivery struct {
+	url   string
+	event Event
+	// cooldown limits deliveries per cooldownKey to one per window (0 = none)
+	cooldown    time.Duration
+	cooldownKey string
+	policy      string
+}
+
+// PolicyWebhooks notifies the webhook a policy declares whenever it fires, so
+// policies owned by another team (e.g. insider-threat terms) reach that team
+// instead of the default security channel
+type PolicyWebhooks struct {
+	lookup     PolicyLookup
+	httpClient *http.Client
+	cooldowns  CooldownStore // Optional; nil delivers every notification
+	timeout    time.Duration
+	queue      chan policyDelivery
+	stopCh     chan struct{}
+	stopOnce   sync.Once
+	wg         sync.WaitGroup
+}
+
+// NewPolicyWebhooks creates the dispatcher and starts its delivery worker
+func NewPolicyWebhooks(lookup PolicyLookup, bufferSize int, httpClient *http.Client) *PolicyWebhooks {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 5 * time.Second}
+	}
+	w := &PolicyWebhooks{
+		lookup:     lookup,
+		httpClient: httpClient,
+		timeout:    5 * time.Second,
+		queue:      make(chan policyDelivery, bufferSize),
+		stopCh:     make(chan struct{}),
+	}
+
+	w.wg.Add(1)
+	go w.worker()
+	slog.Info("Policy webhooks started")
+
+	return w
+}
+
+// SetCooldowns enables per-policy notification cooldowns
+func (w *PolicyWebhooks) SetCooldowns(cooldowns CooldownStore) {
+	w.cooldowns = cooldowns
+}
+
+// Observe queues a notification for every matched policy with a webhook
+// (implements api.DecisionObserver). Deliveries are dropped when the buffer is full
+func (w *PolicyWebhooks) Observe(event models.DecisionEvent) {
+	for _, m := range event.Matches {
+		p, ok := w.lookup(m.PolicyID)
+		if !ok || p.WebhookURL == "" {
+			continue
+		}
+		delivery := policyDelivery{url: p.WebhookURL, event: policyEvent(p, m, event), policy: p.Name}
+		if p.NotifyCooldownSeconds > 0 {
+			delivery.cooldown = time.Duration(p.NotifyCooldownSeconds) * time.Second
+			delivery.cooldownKey = cooldownKey(p, event.Audit)
+		}
+		select {
+		case w.queue <- delivery:
+		default:
+			slog.Warn("Policy webhook buffer full, dropping notification", "policy", p.Name)
+		}
+	}
+}
+
+// cooldownKey scopes a policy's cooldown to the client, or to the session when
+// the policy asks for it and the request has one
+func cooldownKey(p models.Policy, audit models.AuditLog) string {
+	key := p.ID.String() + ":" + audit.ClientID
+	if p.NotifyCooldownScope == "session" && audit.SessionID != "" {
+		key += ":" + audit.SessionID
+	}
+	return key
+}
+
+// policyEvent describes one policy match; it carries content hashes, never content
+func policyEvent(p models.Policy, m models.PolicyMatch, event models.DecisionEvent) Event {
+	details := map[string]interface{}{
+		"request_id":      event.Audit.RequestID,
+		"policy_id":       p.ID,
+		"policy_name":     p.Name,
+		"pattern_type":    p.PatternType,
+		"matched_pattern": m.MatchedPattern,
+		"action":          event.Audit.ActionTaken,
+		"prompt_hash":     event.Audit.PromptHash,
+		"response_hash":   event.Audit.ResponseHash,
+	}
+	if event.Audit.SessionID != "" {
+		details["session_id"] = event.Audit.SessionID
+	}
+	if m.MessageIndex != nil {
+		details["message_index"] = *m.MessageIndex
+	}
+	if m.FieldPath != "" {
+		details["field_path"] = m.FieldPath
+	}
+	if m.AttachmentIndex != nil {
+		details["attachment_index"] = *m.AttachmentIndex
+	}
+	timestamp := event.Audit.CreatedAt
+	if timestamp.IsZero() {
+		timestamp = time.Now()
+	}
+	return Event{
+		Type:      "policy.matched",
+		Severity:  m.Severity,
+		ClientID:  event.Audit.ClientID,
+		Summary:   "Policy " + p.Name + " matched",
+		Details:   details,
+		Timestamp: timestamp,
+	}
+}
+
+// worker delivers queued notifications
+func (w *PolicyWebhooks) worker() {
+	defer w.wg.Done()
+
+	for {
+		select {
+		case d := <-w.queue:
+			w.deliver(d)
+		case <-w.stopCh:
+			// Drain remaining notifications before stopping
+			for {
+				select {
+				case d := <-w.queue:
+					w.deliver(d)
+				default:
+					return
+				}
+			}
+		}
+	}
+}
+
+// deliver posts one notification unless the policy's cooldown suppresses it,
+// logging failures
+func (w *PolicyWebhooks) deliver(d policyDelivery) {
+	ctx, cancel := context.WithTimeout(context.Background(), w.timeout)
+	defer cancel()
+	if d.cooldown > 0 && w.cooldowns != nil {
+		first, suppressed, err := w.cooldowns.Acquire(ctx, d.cooldownKey, d.cooldown)
+		switch {
+		case err != nil:
+			// A duplicate notification is better than a lost one
+			slog.Warn("Notification cooldown unavailable, notifying anyway", "policy", d.policy, "error", err)
+		case !first:
+			metrics.PolicyNotificationsSuppressedTotal.WithLabelValues(d.policy).Inc()
+			return
+		case suppressed > 0:
+			d.event.Details["suppressed_notifications"] = suppressed
+		}
+	}
+	sink := NewWebhookSink(d.url, w.httpClient)
+	if err := sink.Send(ctx, d.event); err != nil {
+		slog.Error("Failed to deliver event to policy webhook", "event", d.event.Type, "client_id", d.event.ClientID, "policy", d.policy, "error", err)
+	}
+}
+
+// Close stops the worker after draining queued notifications
+func (w *PolicyWebhooks) Close() {
+	w.stopOnce.Do(func() {
+		close(w.stopCh)
+		w.wg.Wait()
+		slog.Info("Policy webhooks stopped")
+	})
+}
@@ -0,0 +1,108 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestPolicyWebhooks_NotifiesPolicyOwner(t *testing.T) {
+	received := make(chan Event, 2)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		received <- event
+	}))
+	defer srv.Close()
+
+	insider := models.Policy{ID: uuid.New(), Name: "insider-terms", PatternType: "keyword", WebhookURL: srv.URL}
+	plain := models.Policy{ID: uuid.New(), Name: "ssn", PatternType: "regex"}
+	policies := map[uuid.UUID]models.Policy{insider.ID: insider, plain.ID: plain}
+
+	w := NewPolicyWebhooks(func(id uuid.UUID) (models.Policy, bool) {
+		p, ok := policies[id]
+		return p, ok
+	}, 10, nil)
+
+	w.Observe(models.DecisionEvent{
+		Audit: models.AuditLog{ClientID: "hr-bot", SessionID: "s1", PromptHash: "abc", ActionTaken: "log"},
+		Matches: []models.PolicyMatch{
+			{PolicyID: insider.ID, PolicyName: insider.Name, Severity: "high", MatchedPattern: "layoff list"},
+			{PolicyID: plain.ID, PolicyName: plain.Name, Severity: "high"},
+			{PolicyID: uuid.New(), PolicyName: "deleted"},
+		},
+	})
+	w.Close()
+
+	if len(received) != 1 {
+		t.Fatalf("webhook calls = %d, want 1", len(received))
+	}
+	event := <-received
+	if event.Type != "policy.matched" || event.ClientID != "hr-bot" || event.Severity != "high" {
+		t.Errorf("event = %+v, want policy.matched for hr-bot", event)
+	}
+	if event.Details["policy_name"] != "insider-terms" || event.Details["prompt_hash"] != "abc" || event.Details["session_id"] != "s1" {
+		t.Errorf("event details = %+v, want policy, hash and session", event.Details)
+	}
+}
+
+// memoryCooldowns is an in-process CooldownStore whose windows never expire
+type memoryCooldowns struct {
+	counts map[string]int64
+}
+
+func (c *memoryCooldowns) Acquire(ctx context.Context, key string, window time.Duration) (bool, int64, error) {
+	c.counts[key]++
+	return c.counts[key] == 1, 0, nil
+}
+
+func TestPolicyWebhooks_Cooldown(t *testing.T) {
+	received := make(chan Event, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var event Event
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			t.Errorf("decode webhook body: %v", err)
+		}
+		received <- event
+	}))
+	defer srv.Close()
+
+	perClient := models.Policy{ID: uuid.New(), Name: "pii", WebhookURL: srv.URL, NotifyCooldownSeconds: 600, NotifyCooldownScope: "client"}
+	perSession := models.Policy{ID: uuid.New(), Name: "jailbreak", WebhookURL: srv.URL, NotifyCooldownSeconds: 600, NotifyCooldownScope: "session"}
+	policies := map[uuid.UUID]models.Policy{perClient.ID: perClient, perSession.ID: perSession}
+
+	w := NewPolicyWebhooks(func(id uuid.UUID) (models.Policy, bool) {
+		p, ok := policies[id]
+		return p, ok
+	}, 10, nil)
+	w.SetCooldowns(&memoryCooldowns{counts: map[string]int64{}})
+
+	for _, audit := range []models.AuditLog{
+		{ClientID: "app", SessionID: "s1"},
+		{ClientID: "app", SessionID: "s2"},
+		{ClientID: "app", SessionID: "s2"},
+		{ClientID: "other", SessionID: "s3"},
+	} {
+		w.Observe(models.DecisionEvent{Audit: audit, Matches: []models.PolicyMatch{{PolicyID: perClient.ID}, {PolicyID: perSession.ID}}})
+	}
+	w.Close()
+	close(received)
+
+	counts := map[string]int{}
+	for event := range received {
+		counts[event.Details["policy_name"].(string)]++
+	}
+	// pii: app and other; jailbreak: s1, s2 and s3
+	if counts["pii"] != 2 || counts["jailbreak"] != 3 {
+		t.Errorf("notifications = %v, want pii 2 and jailbreak 3", counts)
+	}
+}
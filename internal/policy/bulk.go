@@ -0,0 +1,184 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/lib/pq"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// BulkFilter selects the policies of a bulk update; every set field must match
+type BulkFilter struct {
+	Tag  string // Policies carrying this tag
+	Team string // Policies owned by this team
+
+	// MaxChanges fails the update with ErrTooManyChanges, changing nothing, when it
+	// would change more policies (0 = unlimited)
+	MaxChanges int
+}
+
+// ValidateBulkUpdate rejects updates that select everything or change nothing
+func ValidateBulkUpdate(filter BulkFilter, update models.BulkPolicyUpdate) error {
+	if filter.Tag == "" && filter.Team == "" {
+		return fmt.Errorf("tag or team is required")
+	}
+	if update.Enabled == nil && update.Severity == "" {
+		return fmt.Errorf("enabled or severity is required")
+	}
+	validSeverities := map[string]bool{"": true, "low": true, "medium": true, "high": true, "critical": true}
+	if !validSeverities[update.Severity] {
+		return fmt.Errorf("invalid severity: must be low, medium, high, or critical")
+	}
+	return nil
+}
+
+// applyBulkUpdate returns the change update makes to a policy, and whether it changes anything
+func applyBulkUpdate(p models.Policy, update models.BulkPolicyUpdate) (models.PolicyChange, bool) {
+	change := models.PolicyChange{
+		PolicyID:       p.ID,
+		PolicyName:     p.Name,
+		EnabledBefore:  p.Enabled,
+		Enabled:        p.Enabled,
+		SeverityBefore: p.Severity,
+		Severity:       p.Severity,
+	}
+	if update.Enabled != nil {
+		change.Enabled = *update.Enabled
+	}
+	if update.Severity != "" {
+		change.Severity = update.Severity
+	}
+	return change, change.Enabled != change.EnabledBefore || change.Severity != change.SeverityBefore
+}
+
+// BulkUpdate enables, disables or re-grades every policy matching filter (enabled or
+// not) in one transaction, returning what changed
+func (r *Repository) BulkUpdate(ctx context.Context, filter BulkFilter, update models.BulkPolicyUpdate) (*models.BulkPolicyUpdateResult, error) {
+	if err := ValidateBulkUpdate(filter, update); err != nil {
+		return nil, err
+	}
+
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Lock the selected rows so concurrent bulk updates report accurate before-states
+	rows, err := tx.QueryContext(ctx, `
+		SELECT id, name, enabled, severity
+		FROM policies
+		WHERE ($1 = '' OR $1 = ANY(tags)) AND ($2 = '' OR team = $2)
+		ORDER BY name
+		FOR UPDATE
+	`, filter.Tag, filter.Team)
+	if err != nil {
+		return nil, fmt.Errorf("failed to select policies: %w", err)
+	}
+	result := &models.BulkPolicyUpdateResult{Changes: []models.PolicyChange{}}
+	var ids []string
+	for rows.Next() {
+		var p models.Policy
+		if err := rows.Scan(&p.ID, &p.Name, &p.Enabled, &p.Severity); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan policy: %w", err)
+		}
+		result.Matched++
+		if change, changed := applyBulkUpdate(p, update); changed {
+			result.Changes = append(result.Changes, change)
+			ids = append(ids, p.ID.String())
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating policies: %w", err)
+	}
+	result.Changed = len(result.Changes)
+	if err := checkMaxChanges(filter, result); err != nil {
+		return nil, err
+	}
+
+	newlyEnabled := 0
+	for _, change := range result.Changes {
+		if change.Enabled && !change.EnabledBefore {
+			newlyEnabled++
+		}
+	}
+	if r.maxEnabled > 0 && newlyEnabled > 0 {
+		var enabled int
+		if err := tx.QueryRowContext(ctx, `SELECT count(*) FROM policies WHERE enabled`).Scan(&enabled); err != nil {
+			return nil, fmt.Errorf("failed to count enabled policies: %w", err)
+		}
+		if enabled+newlyEnabled > r.maxEnabled {
+			return nil, limitError(r.maxEnabled)
+		}
+	}
+
+	if len(ids) > 0 {
+		_, err = tx.ExecContext(ctx, `
+			UPDATE policies
+			SET enabled = COALESCE($2, enabled),
+				severity = COALESCE(NULLIF($3, ''), severity),
+				updated_at = NOW()
+			WHERE id = ANY($1::uuid[])
+		`, pq.Array(ids), update.Enabled, update.Severity)
+		if err != nil {
+			return nil, fmt.Errorf("failed to update policies: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit bulk update: %w", err)
+	}
+	return result, nil
+}
+
+// BulkUpdateAll applies a bulk update to in-memory policies (fakes and tests),
+// returning the updated slice and the change summary; like BulkUpdate, it changes
+// nothing when the update exceeds filter.MaxChanges
+func BulkUpdateAll(policies []models.Policy, filter BulkFilter, update models.BulkPolicyUpdate) ([]models.Policy, *models.BulkPolicyUpdateResult, error) {
+	result := &models.BulkPolicyUpdateResult{Changes: []models.PolicyChange{}}
+	out := make([]models.Policy, len(policies))
+	for i, p := range policies {
+		out[i] = p
+		if !filter.matches(p) {
+			continue
+		}
+		result.Matched++
+		if change, changed := applyBulkUpdate(p, update); changed {
+			out[i].Enabled, out[i].Severity = change.Enabled, change.Severity
+			result.Changes = append(result.Changes, change)
+		}
+	}
+	sort.Slice(result.Changes, func(i, j int) bool { return result.Changes[i].PolicyName < result.Changes[j].PolicyName })
+	result.Changed = len(result.Changes)
+	if err := checkMaxChanges(filter, result); err != nil {
+		return policies, nil, err
+	}
+	return out, result, nil
+}
+
+// checkMaxChanges enforces filter.MaxChanges on a computed update
+func checkMaxChanges(filter BulkFilter, result *models.BulkPolicyUpdateResult) error {
+	if filter.MaxChanges > 0 && result.Changed > filter.MaxChanges {
+		return fmt.Errorf("%w: the update would change %d policies, only %d more may change this minute", ErrTooManyChanges, result.Changed, filter.MaxChanges)
+	}
+	return nil
+}
+
+// matches reports whether a policy is selected by the filter
+func (f BulkFilter) matches(p models.Policy) bool {
+	if f.Team != "" && p.Team != f.Team {
+		return false
+	}
+	if f.Tag == "" {
+		return true
+	}
+	for _, tag := range p.Tags {
+		if tag == f.Tag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,116 @@
+package policy
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// guardWindow is the span write rates and change counts are measured over
+const guardWindow = time.Minute
+
+// ErrTooManyChanges is returned when a write would change more policies within a
+// minute than the change guard allows without confirmation
+var ErrTooManyChanges = errors.New("too many policy changes without confirmation")
+
+// ChangeGuard protects each policy store (the shared one and every isolated tenant's)
+// from runaway automation: it throttles write requests and caps how many policies
+// may change per minute unless a write is confirmed. Counts are kept per replica
+type ChangeGuard struct {
+	writesPerMinute int // Write requests per store per minute (0 = unlimited)
+	maxChanges      int // Policies changed per store per minute without confirmation (0 = unlimited)
+	now             func() time.Time
+
+	mu     sync.Mutex // Protects stores
+	stores map[string]*guardWindows
+}
+
+// guardWindows holds one store's writes and changes of the last minute
+type guardWindows struct {
+	writes  []time.Time
+	changes []changeEvent
+}
+
+// changeEvent records how many policies one write changed
+type changeEvent struct {
+	at time.Time
+	n  int
+}
+
+// NewChangeGuard creates a guard allowing writesPerMinute write requests and
+// maxChanges changed policies per store per minute (0 = unlimited)
+func NewChangeGuard(writesPerMinute, maxChanges int) *ChangeGuard {
+	return &ChangeGuard{
+		writesPerMinute: writesPerMinute,
+		maxChanges:      maxChanges,
+		now:             time.Now,
+		stores:          make(map[string]*guardWindows),
+	}
+}
+
+// Allow counts a write request to store ("" = shared). Over the rate it returns
+// false and how long until the oldest write of the window expires. A nil guard
+// allows everything
+func (g *ChangeGuard) Allow(store string) (time.Duration, bool) {
+	if g == nil || g.writesPerMinute <= 0 {
+		return 0, true
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	w := g.windows(store, now)
+	if len(w.writes) >= g.writesPerMinute {
+		return w.writes[0].Add(guardWindow).Sub(now), false
+	}
+	w.writes = append(w.writes, now)
+	return 0, true
+}
+
+// Budget returns how many more policies of store may change this minute without
+// confirmation (-1 = unlimited)
+func (g *ChangeGuard) Budget(store string) int {
+	if g == nil || g.maxChanges <= 0 {
+		return -1
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	changed := 0
+	for _, c := range g.windows(store, g.now()).changes {
+		changed += c.n
+	}
+	return max(g.maxChanges-changed, 0)
+}
+
+// Record counts n policies of store as changed, confirmed or not
+func (g *ChangeGuard) Record(store string, n int) {
+	if g == nil || g.maxChanges <= 0 || n <= 0 {
+		return
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	now := g.now()
+	w := g.windows(store, now)
+	w.changes = append(w.changes, changeEvent{at: now, n: n})
+}
+
+// windows returns store's windows with entries older than a minute dropped
+// Callers hold g.mu
+func (g *ChangeGuard) windows(store string, now time.Time) *guardWindows {
+	w, ok := g.stores[store]
+	if !ok {
+		w = &guardWindows{}
+		g.stores[store] = w
+	}
+	cutoff := now.Add(-guardWindow)
+	i := 0
+	for i < len(w.writes) && !w.writes[i].After(cutoff) {
+		i++
+	}
+	w.writes = w.writes[i:]
+	i = 0
+	for i < len(w.changes) && !w.changes[i].at.After(cutoff) {
+		i++
+	}
+	w.changes = w.changes[i:]
+	return w
+}
@@ -0,0 +1,49 @@
+package policy
+
+import (
+	"testing"
+	"time"
+)
+
+func TestChangeGuard(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	g := NewChangeGuard(2, 3)
+	g.now = func() time.Time { return now }
+
+	for i := range 2 {
+		if _, ok := g.Allow(""); !ok {
+			t.Fatalf("write %d rejected, want allowed", i+1)
+		}
+	}
+	if wait, ok := g.Allow(""); ok || wait != time.Minute {
+		t.Errorf("third write = %v, %v; want rejected for a minute", wait, ok)
+	}
+	if _, ok := g.Allow("acme"); !ok {
+		t.Error("tenant write rejected by the shared store's rate, want separate windows")
+	}
+
+	g.Record("", 2)
+	if got := g.Budget(""); got != 1 {
+		t.Errorf("Budget() = %d after 2 changes, want 1", got)
+	}
+	g.Record("", 5)
+	if got := g.Budget(""); got != 0 {
+		t.Errorf("Budget() = %d past the limit, want 0", got)
+	}
+	if got := g.Budget("acme"); got != 3 {
+		t.Errorf("Budget(acme) = %d, want 3", got)
+	}
+
+	now = now.Add(time.Minute + time.Second)
+	if _, ok := g.Allow(""); !ok {
+		t.Error("write rejected after the window passed")
+	}
+	if got := g.Budget(""); got != 3 {
+		t.Errorf("Budget() = %d after the window passed, want 3", got)
+	}
+
+	var unlimited *ChangeGuard
+	if _, ok := unlimited.Allow(""); !ok || unlimited.Budget("") != -1 {
+		t.Error("nil guard limits writes, want unlimited")
+	}
+}
@@ -0,0 +1,181 @@
+package policy
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// hit is the not-yet-persisted match count of one policy
+type hit struct {
+	count int64
+	last  time.Time
+}
+
+// HitRecorder counts policy matches in memory and periodically adds them to the
+// policies table, so dead rules that never fire can be found and cleaned up
+type HitRecorder struct {
+	db       *sql.DB
+	interval time.Duration
+	breaker  *breaker.Breaker // Optional; while open, hits keep accumulating in memory
+
+	mu      sync.Mutex // Protects pending
+	pending map[uuid.UUID]hit
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewHitRecorder creates a recorder flushing to Postgres every interval
+func NewHitRecorder(db *sql.DB, interval time.Duration) *HitRecorder {
+	return &HitRecorder{
+		db:       db,
+		interval: interval,
+		pending:  make(map[uuid.UUID]hit),
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetBreaker guards flushes with a circuit breaker
+// Must be called before Start
+func (r *HitRecorder) SetBreaker(dbBreaker *breaker.Breaker) {
+	r.breaker = dbBreaker
+}
+
+// Observe counts the policies matched by a decision (implements api.DecisionObserver)
+func (r *HitRecorder) Observe(event models.DecisionEvent) {
+	if len(event.Matches) == 0 {
+		return
+	}
+	now := event.Audit.CreatedAt
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	r.mu.Lock()
+	for _, m := range event.Matches {
+		h := r.pending[m.PolicyID]
+		h.count++
+		if now.After(h.last) {
+			h.last = now
+		}
+		r.pending[m.PolicyID] = h
+	}
+	r.mu.Unlock()
+}
+
+// Overlay returns a copy of policies with unflushed hits added, so the list API
+// is current without waiting for the next flush and cache refresh
+func (r *HitRecorder) Overlay(policies []models.Policy) []models.Policy {
+	out := make([]models.Policy, len(policies))
+	copy(out, policies)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range out {
+		h, ok := r.pending[out[i].ID]
+		if !ok {
+			continue
+		}
+		out[i].HitCount += h.count
+		if out[i].LastMatchedAt == nil || h.last.After(*out[i].LastMatchedAt) {
+			last := h.last
+			out[i].LastMatchedAt = &last
+		}
+	}
+	return out
+}
+
+// Start runs the flush worker
+func (r *HitRecorder) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if err := r.Flush(ctx); err != nil {
+					slog.Warn("Failed to flush policy hit counts, retrying next interval", "error", err)
+				}
+			case <-r.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Policy hit recorder started", "flush", r.interval)
+}
+
+// Flush adds pending hits to Postgres in one statement; on failure they are
+// merged back so nothing is lost
+func (r *HitRecorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	batch := r.pending
+	r.pending = make(map[uuid.UUID]hit)
+	r.mu.Unlock()
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	ids := make([]string, 0, len(batch))
+	counts := make([]int64, 0, len(batch))
+	lasts := make([]string, 0, len(batch))
+	for id, h := range batch {
+		ids = append(ids, id.String())
+		counts = append(counts, h.count)
+		lasts = append(lasts, h.last.UTC().Format(time.RFC3339Nano))
+	}
+
+	query := `
+		UPDATE policies p
+		SET hit_count = p.hit_count + h.count,
+			last_matched_at = GREATEST(COALESCE(p.last_matched_at, h.last), h.last)
+		FROM unnest($1::uuid[], $2::bigint[], $3::timestamptz[]) AS h(id, count, last)
+		WHERE p.id = h.id
+	`
+	err := r.breaker.Do(func() error {
+		_, err := r.db.ExecContext(ctx, query, pq.Array(ids), pq.Array(counts), pq.Array(lasts))
+		return err
+	})
+	if err != nil {
+		r.mu.Lock()
+		for id, h := range batch {
+			p := r.pending[id]
+			p.count += h.count
+			if h.last.After(p.last) {
+				p.last = h.last
+			}
+			r.pending[id] = p
+		}
+		r.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// Stop stops the worker and flushes what is left
+func (r *HitRecorder) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		r.wg.Wait()
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := r.Flush(ctx); err != nil {
+			slog.Error("Failed to flush policy hit counts on shutdown", "error", err)
+		}
+	})
+}
@@ -0,0 +1,38 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestHitRecorder_Overlay(t *testing.T) {
+	r := NewHitRecorder(nil, time.Minute)
+	flushed := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	matched := flushed.Add(time.Hour)
+	policies := []models.Policy{
+		{ID: uuid.New(), Name: "fires", HitCount: 10, LastMatchedAt: &flushed},
+		{ID: uuid.New(), Name: "dead"},
+	}
+
+	for i := 0; i < 3; i++ {
+		r.Observe(models.DecisionEvent{
+			Audit:   models.AuditLog{CreatedAt: matched},
+			Matches: []models.PolicyMatch{{PolicyID: policies[0].ID}},
+		})
+	}
+	r.Observe(models.DecisionEvent{Audit: models.AuditLog{CreatedAt: matched}})
+
+	got := r.Overlay(policies)
+	if got[0].HitCount != 13 || !got[0].LastMatchedAt.Equal(matched) {
+		t.Errorf("fires = %d hits, last %v; want 13 hits, last %v", got[0].HitCount, got[0].LastMatchedAt, matched)
+	}
+	if got[1].HitCount != 0 || got[1].LastMatchedAt != nil {
+		t.Errorf("dead = %d hits, last %v; want untouched", got[1].HitCount, got[1].LastMatchedAt)
+	}
+	if policies[0].HitCount != 10 {
+		t.Error("Overlay() must not modify the shared cache slice")
+	}
+}
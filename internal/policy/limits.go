@@ -0,0 +1,52 @@
+package policy
+
+import (
+	"errors"
+	"fmt"
+	"regexp/syntax"
+)
+
+// Pattern limits: a single pathological pattern slows every analyze request, since
+// each one is evaluated against all enabled policies
+const (
+	MaxPatternBytes      = 4096 // Longest pattern_value accepted
+	MaxRegexInstructions = 5000 // Largest compiled RE2 program accepted for a regex policy
+)
+
+// ErrPolicyLimit is returned when a write would exceed the enabled policy cap
+var ErrPolicyLimit = errors.New("enabled policy limit reached")
+
+// validatePatternSize rejects oversized patterns and regexes that compile to
+// excessively large programs
+func validatePatternSize(patternType, value string) error {
+	if len(value) > MaxPatternBytes {
+		return fmt.Errorf("pattern_value is too long: %d bytes, at most %d", len(value), MaxPatternBytes)
+	}
+	if patternType != "regex" {
+		return nil
+	}
+	re, err := syntax.Parse(value, syntax.Perl)
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return fmt.Errorf("invalid regex pattern: %w", err)
+	}
+	if len(prog.Inst) > MaxRegexInstructions {
+		return fmt.Errorf("regex pattern is too complex: compiles to %d instructions, at most %d", len(prog.Inst), MaxRegexInstructions)
+	}
+	return nil
+}
+
+// SetMaxEnabled caps the number of enabled policies; creating or enabling policies
+// beyond it fails with ErrPolicyLimit (0 = unlimited)
+// Must be called before the repository is used
+func (r *Repository) SetMaxEnabled(n int) {
+	r.maxEnabled = n
+}
+
+// limitError reports the enabled policy cap
+func limitError(max int) error {
+	return fmt.Errorf("%w: at most %d policies may be enabled, disable some first", ErrPolicyLimit, max)
+}
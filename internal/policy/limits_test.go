@@ -0,0 +1,41 @@
+package policy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestValidateCreateRequest_PatternLimits(t *testing.T) {
+	// Repetition multiplies the program: short to write, thousands of instructions
+	body := strings.Repeat("abcdefghijklmnopqrstuvwxyz", 4)
+
+	tests := []struct {
+		name        string
+		patternType string
+		value       string
+		wantErr     string
+	}{
+		{"ordinary regex", "regex", `\b\d{3}-\d{2}-\d{4}\b`, ""},
+		{"invalid regex", "regex", `(unclosed`, "invalid regex pattern"},
+		{"oversized keyword", "keyword", strings.Repeat("a", MaxPatternBytes+1), "too long"},
+		{"large program", "regex", `(?:` + body + `){60}`, "too complex"},
+		{"nested repetition", "regex", `(?:[a-z0-9]{50}){90}`, "invalid regex pattern"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := models.CreatePolicyRequest{Name: "p", PatternType: tt.patternType, PatternValue: tt.value, Severity: "low", Action: "log"}
+			err := ValidateCreateRequest(req)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("ValidateCreateRequest() error = %v, want nil", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ValidateCreateRequest() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
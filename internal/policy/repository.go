@@ -3,15 +3,49 @@ package policy
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"net/url"
+	"strings"
+	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/prompt-gateway/internal/analyzer"
 	"github.com/prompt-gateway/pkg/models"
 )
 
+// ErrNotFound is returned when a policy does not exist
+var ErrNotFound = errors.New("policy not found")
+
+// ErrInvalid matches the errors of policy definitions that fail validation; their
+// messages are meant for the caller
+var ErrInvalid = errors.New("invalid policy")
+
+// invalidError is a validation failure: it matches ErrInvalid and keeps its own message
+type invalidError struct{ err error }
+
+func (e invalidError) Error() string        { return e.err.Error() }
+func (e invalidError) Unwrap() error        { return e.err }
+func (e invalidError) Is(target error) bool { return target == ErrInvalid }
+
+// Store is the policy persistence used by the policy cache and handlers
+// Repository is the Postgres implementation
+type Store interface {
+	List(ctx context.Context) ([]models.Policy, error)
+	GetByID(ctx context.Context, id uuid.UUID) (*models.Policy, error)
+	Create(ctx context.Context, req models.CreatePolicyRequest) (*models.Policy, error)
+	Update(ctx context.Context, id uuid.UUID, req models.CreatePolicyRequest) (*models.Policy, error)
+	SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) (*models.Policy, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	BulkUpdate(ctx context.Context, filter BulkFilter, update models.BulkPolicyUpdate) (*models.BulkPolicyUpdateResult, error)
+}
+
 // Repository handles policy data access
 type Repository struct {
-	db *sql.DB
+	db         *sql.DB
+	maxEnabled int // Cap on enabled policies (0 = unlimited)
 }
 
 // NewRepository creates a new Repository
@@ -19,11 +53,61 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
+// policyColumns is the column list shared by every policy query
+const policyColumns = `
+	id, name, description, pattern_type, pattern_value,
+	severity, action, priority, enabled, tier_actions, roles, applies_to_clients,
+	scan_scope, strip_markup, max_input_bytes, stem, match_mode, webhook_url, notify_cooldown_seconds, notify_cooldown_scope,
+	capture_constraints, escalations, hit_count, last_matched_at,
+	tags, owner, team, review_by, source, created_at, updated_at
+`
+
+// scanner is satisfied by both *sql.Row and *sql.Rows
+type scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPolicy maps a single policies row to a model
+func scanPolicy(row scanner) (models.Policy, error) {
+	var p models.Policy
+	var tierActions, captureConstraints, escalations []byte
+	var reviewBy sql.NullTime
+	// Scan maps columns to struct fields (like Pydantic parsing)
+	err := row.Scan(
+		&p.ID, &p.Name, &p.Description, &p.PatternType,
+		&p.PatternValue, &p.Severity, &p.Action, &p.Priority, &p.Enabled,
+		&tierActions, pq.Array(&p.Roles), pq.Array(&p.AppliesToClients),
+		&p.ScanScope, &p.StripMarkup, &p.MaxInputBytes, &p.Stem, &p.MatchMode, &p.WebhookURL, &p.NotifyCooldownSeconds, &p.NotifyCooldownScope,
+		&captureConstraints, &escalations, &p.HitCount, &p.LastMatchedAt,
+		pq.Array(&p.Tags), &p.Owner, &p.Team, &reviewBy, &p.Source, &p.CreatedAt, &p.UpdatedAt,
+	)
+	if err != nil {
+		return p, err
+	}
+	if reviewBy.Valid {
+		p.ReviewBy = reviewBy.Time.Format(ReviewDateLayout)
+	}
+	if len(tierActions) > 0 {
+		if err := json.Unmarshal(tierActions, &p.TierActions); err != nil {
+			return p, fmt.Errorf("invalid tier_actions: %w", err)
+		}
+	}
+	if len(captureConstraints) > 0 {
+		if err := json.Unmarshal(captureConstraints, &p.CaptureConstraints); err != nil {
+			return p, fmt.Errorf("invalid capture_constraints: %w", err)
+		}
+	}
+	if len(escalations) > 0 {
+		if err := json.Unmarshal(escalations, &p.Escalations); err != nil {
+			return p, fmt.Errorf("invalid escalations: %w", err)
+		}
+	}
+	return p, nil
+}
+
 // 1.  List returns all enabled policies
 func (r *Repository) List(ctx context.Context) ([]models.Policy, error) {
-	query := `
-		SELECT id, name, description, pattern_type, pattern_value, 
-		       severity, action, enabled, created_at, updated_at
+	query := `SELECT ` + policyColumns + `
 		FROM policies
 		WHERE enabled = true
 		ORDER BY created_at DESC
@@ -37,13 +121,7 @@ func (r *Repository) List(ctx context.Context) ([]models.Policy, error) {
 
 	var policies []models.Policy
 	for rows.Next() {
-		var p models.Policy
-		// Scan maps columns to struct fields (like Pydantic parsing)
-		err := rows.Scan(
-			&p.ID, &p.Name, &p.Description, &p.PatternType,
-			&p.PatternValue, &p.Severity, &p.Action, &p.Enabled,
-			&p.CreatedAt, &p.UpdatedAt,
-		)
+		p, err := scanPolicy(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan policy: %w", err)
 		}
@@ -59,22 +137,14 @@ func (r *Repository) List(ctx context.Context) ([]models.Policy, error) {
 
 // 2. GetByID returns a policy by ID
 func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Policy, error) {
-	query := `
-		SELECT id, name, description, pattern_type, pattern_value,
-		       severity, action, enabled, created_at, updated_at
+	query := `SELECT ` + policyColumns + `
 		FROM policies
 		WHERE id = $1
 	`
 
-	var p models.Policy
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
-		&p.ID, &p.Name, &p.Description, &p.PatternType,
-		&p.PatternValue, &p.Severity, &p.Action, &p.Enabled,
-		&p.CreatedAt, &p.UpdatedAt,
-	)
-
+	p, err := scanPolicy(r.db.QueryRowContext(ctx, query, id))
 	if err == sql.ErrNoRows {
-		return nil, fmt.Errorf("policy not found")
+		return nil, ErrNotFound
 	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to get policy: %w", err)
@@ -86,27 +156,29 @@ func (r *Repository) GetByID(ctx context.Context, id uuid.UUID) (*models.Policy,
 // 3. Create creates a new policy
 func (r *Repository) Create(ctx context.Context, req models.CreatePolicyRequest) (*models.Policy, error) {
 	// Input validation
-	if err := validateCreateRequest(req); err != nil {
+	if err := ValidateCreateRequest(req); err != nil {
 		return nil, err
 	}
 
-	query := `
-		INSERT INTO policies (name, description, pattern_type, pattern_value, severity, action, enabled)
-		VALUES ($1, $2, $3, $4, $5, $6, true)
-		RETURNING id, name, description, pattern_type, pattern_value, severity, action, enabled, created_at, updated_at
-	`
+	if req.Source == "" {
+		req.Source = SourceManual
+	}
+	args, err := definitionArgs(req)
+	if err != nil {
+		return nil, err
+	}
 
-	var p models.Policy
-	err := r.db.QueryRowContext(
-		ctx, query,
-		req.Name, req.Description, req.PatternType,
-		req.PatternValue, req.Severity, req.Action,
-	).Scan(
-		&p.ID, &p.Name, &p.Description, &p.PatternType,
-		&p.PatternValue, &p.Severity, &p.Action, &p.Enabled,
-		&p.CreatedAt, &p.UpdatedAt,
-	)
+	// The cap is checked in the same statement so no policy is inserted past it
+	query := `
+		INSERT INTO policies (` + definitionColumns + `, enabled)
+		SELECT $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, true
+		WHERE $26 = 0 OR (SELECT count(*) FROM policies WHERE enabled) < $26
+		RETURNING ` + policyColumns
 
+	p, err := scanPolicy(r.db.QueryRowContext(ctx, query, append(args, r.maxEnabled)...))
+	if err == sql.ErrNoRows {
+		return nil, limitError(r.maxEnabled)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("failed to create policy: %w", err)
 	}
@@ -114,30 +186,310 @@ func (r *Repository) Create(ctx context.Context, req models.CreatePolicyRequest)
 	return &p, nil
 }
 
-// validateCreateRequest validates the create policy request
+// 4. Update replaces a policy's definition, keeping its ID, enabled state and hit
+// statistics; an empty source keeps the current one
+func (r *Repository) Update(ctx context.Context, id uuid.UUID, req models.CreatePolicyRequest) (*models.Policy, error) {
+	if err := ValidateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	args, err := definitionArgs(req)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE policies SET (` + definitionColumns + `, updated_at) =
+			($2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22, $23, $24, $25, COALESCE(NULLIF($26, ''), source), NOW())
+		WHERE id = $1
+		RETURNING ` + policyColumns
+
+	p, err := scanPolicy(r.db.QueryRowContext(ctx, query, append([]interface{}{id}, args...)...))
+	if err == sql.ErrNoRows {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// 5. SetEnabled enables or disables a policy; enabling fails past the enabled policy cap
+func (r *Repository) SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) (*models.Policy, error) {
+	// As in Create, the cap is checked in the same statement; policies that are
+	// already enabled don't count against it
+	query := `
+		UPDATE policies SET enabled = $2, updated_at = NOW()
+		WHERE id = $1
+			AND (NOT $2 OR enabled OR $3 = 0 OR (SELECT count(*) FROM policies WHERE enabled) < $3)
+		RETURNING ` + policyColumns
+
+	p, err := scanPolicy(r.db.QueryRowContext(ctx, query, id, enabled, r.maxEnabled))
+	if err == sql.ErrNoRows {
+		if _, err := r.GetByID(ctx, id); err != nil {
+			return nil, err
+		}
+		return nil, limitError(r.maxEnabled)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to update policy: %w", err)
+	}
+
+	return &p, nil
+}
+
+// 6. Delete removes a policy
+func (r *Repository) Delete(ctx context.Context, id uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM policies WHERE id = $1`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete policy: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// RequestOf returns the definition of p, e.g. as the base of a partial update
+func RequestOf(p models.Policy) models.CreatePolicyRequest {
+	return models.CreatePolicyRequest{
+		Name:                  p.Name,
+		Description:           p.Description,
+		PatternType:           p.PatternType,
+		PatternValue:          p.PatternValue,
+		Severity:              p.Severity,
+		Action:                p.Action,
+		Priority:              p.Priority,
+		TierActions:           p.TierActions,
+		Roles:                 p.Roles,
+		ScanScope:             p.ScanScope,
+		StripMarkup:           p.StripMarkup,
+		MaxInputBytes:         p.MaxInputBytes,
+		Stem:                  p.Stem,
+		MatchMode:             p.MatchMode,
+		AppliesToClients:      p.AppliesToClients,
+		WebhookURL:            p.WebhookURL,
+		NotifyCooldownSeconds: p.NotifyCooldownSeconds,
+		NotifyCooldownScope:   p.NotifyCooldownScope,
+		CaptureConstraints:    p.CaptureConstraints,
+		Escalations:           p.Escalations,
+		Tags:                  p.Tags,
+		Owner:                 p.Owner,
+		Team:                  p.Team,
+		ReviewBy:              p.ReviewBy,
+		Source:                p.Source,
+	}
+}
+
+// definitionColumns are the columns a policy definition writes, in definitionArgs order
+const definitionColumns = `name, description, pattern_type, pattern_value, severity, action, priority, tier_actions, roles,
+	applies_to_clients, scan_scope, strip_markup, max_input_bytes, stem, match_mode, webhook_url, notify_cooldown_seconds,
+	notify_cooldown_scope, capture_constraints, escalations, tags, owner, team, review_by, source`
+
+// definitionArgs converts a policy definition to the query arguments of
+// definitionColumns, defaulting the fields the request may leave out
+func definitionArgs(req models.CreatePolicyRequest) ([]interface{}, error) {
+	tierActions, err := json.Marshal(req.TierActions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tier_actions: %w", err)
+	}
+	if req.TierActions == nil {
+		tierActions = []byte("{}")
+	}
+	captureConstraints, err := json.Marshal(req.CaptureConstraints)
+	if err != nil {
+		return nil, fmt.Errorf("invalid capture_constraints: %w", err)
+	}
+	if req.CaptureConstraints == nil {
+		captureConstraints = []byte("[]")
+	}
+	escalations, err := json.Marshal(req.Escalations)
+	if err != nil {
+		return nil, fmt.Errorf("invalid escalations: %w", err)
+	}
+	if req.Escalations == nil {
+		escalations = []byte("[]")
+	}
+	roles := req.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	appliesTo := req.AppliesToClients
+	if appliesTo == nil {
+		appliesTo = []string{}
+	}
+	scanScope := req.ScanScope
+	if scanScope == "" {
+		scanScope = "all"
+	}
+	matchMode := req.MatchMode
+	if matchMode == "" {
+		matchMode = analyzer.KeywordSubstring
+	}
+	cooldownScope := req.NotifyCooldownScope
+	if cooldownScope == "" {
+		cooldownScope = "client"
+	}
+	tags := req.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	reviewBy := sql.NullString{String: req.ReviewBy, Valid: req.ReviewBy != ""}
+
+	return []interface{}{
+		req.Name, req.Description, req.PatternType,
+		req.PatternValue, req.Severity, req.Action, req.Priority, tierActions, pq.Array(roles), pq.Array(appliesTo),
+		scanScope, req.StripMarkup, req.MaxInputBytes, req.Stem, matchMode, req.WebhookURL, req.NotifyCooldownSeconds,
+		cooldownScope, captureConstraints, escalations, pq.Array(tags), req.Owner, req.Team, reviewBy, req.Source,
+	}, nil
+}
+
+// ValidateCreateRequest validates the create policy request; failures match ErrInvalid
+func ValidateCreateRequest(req models.CreatePolicyRequest) error {
+	if err := validateCreateRequest(req); err != nil {
+		return invalidError{err}
+	}
+	return nil
+}
+
 func validateCreateRequest(req models.CreatePolicyRequest) error {
 	if req.Name == "" {
 		return fmt.Errorf("name is required")
 	}
 	validPatternTypes := map[string]bool{
-		"regex":     true,
-		"keyword":   true,
-		"profanity": true,
-		"model":     true,
+		"regex":              true,
+		"keyword":            true,
+		"dictionary":         true,
+		"contextual_number":  true,
+		"profanity":          true,
+		"model":              true,
+		"role_impersonation": true,
+		"url_exfiltration":   true,
+		"composite":          true,
+		"max_tokens":         true,
 	}
 	if !validPatternTypes[req.PatternType] {
-		return fmt.Errorf("pattern_type must be one of: regex, keyword, profanity, model")
+		return fmt.Errorf("pattern_type must be one of: regex, keyword, dictionary, contextual_number, profanity, model, role_impersonation, url_exfiltration, composite, max_tokens")
 	}
 	if req.PatternValue == "" {
 		return fmt.Errorf("pattern_value is required")
 	}
+	if err := validatePatternSize(req.PatternType, req.PatternValue); err != nil {
+		return err
+	}
+	if req.PatternType == "composite" {
+		if _, err := analyzer.ParseCondition(req.PatternValue); err != nil {
+			return fmt.Errorf("invalid composite pattern_value: %w", err)
+		}
+	}
+	if req.PatternType == "contextual_number" {
+		if _, err := analyzer.ParseNumberContexts(req.PatternValue); err != nil {
+			return err
+		}
+	}
+	if req.PatternType == "profanity" {
+		if _, err := analyzer.ParseProfanityCategories(req.PatternValue); err != nil {
+			return err
+		}
+	}
+	if req.PatternType == "max_tokens" {
+		if _, err := analyzer.ParseMaxTokens(req.PatternValue); err != nil {
+			return err
+		}
+		if req.Action == "redact" {
+			return fmt.Errorf("max_tokens policies cannot redact: use log or block")
+		}
+	}
 	validSeverities := map[string]bool{"low": true, "medium": true, "high": true, "critical": true}
 	if !validSeverities[req.Severity] {
 		return fmt.Errorf("invalid severity: must be low, medium, high, or critical")
 	}
 	validActions := map[string]bool{"log": true, "block": true, "redact": true}
-	if !validActions[req.Action] {
-		return fmt.Errorf("invalid action: must be log, block, or redact")
+	if req.Action == "allow" {
+		// Allow policies let requests of default-block clients through; they never block
+		if len(req.TierActions) > 0 {
+			return fmt.Errorf("allow policies cannot have tier_actions")
+		}
+	} else if !validActions[req.Action] {
+		return fmt.Errorf("invalid action: must be log, block, redact, or allow")
+	}
+	validTiers := map[string]bool{"trusted": true, "standard": true, "untrusted": true, "anonymous": true}
+	for tier, action := range req.TierActions {
+		if !validTiers[tier] {
+			return fmt.Errorf("invalid tier_actions tier %q: must be trusted, standard, untrusted, or anonymous", tier)
+		}
+		if !validActions[action] {
+			return fmt.Errorf("invalid tier_actions action for %s: must be log, block, or redact", tier)
+		}
+	}
+	validRoles := map[string]bool{"system": true, "developer": true, "user": true, "assistant": true, "tool": true}
+	for _, role := range req.Roles {
+		if !validRoles[role] {
+			return fmt.Errorf("invalid role %q: must be system, developer, user, assistant, or tool", role)
+		}
+	}
+	validScopes := map[string]bool{"": true, "all": true, "code": true, "prose": true}
+	if !validScopes[req.ScanScope] {
+		return fmt.Errorf("invalid scan_scope: must be all, code, or prose")
+	}
+	if req.Stem && req.PatternType != "keyword" {
+		return fmt.Errorf("stem is only supported for keyword policies")
+	}
+	validMatchModes := map[string]bool{"": true, analyzer.KeywordSubstring: true, analyzer.KeywordWholeWord: true, analyzer.KeywordWordBoundary: true}
+	if !validMatchModes[req.MatchMode] {
+		return fmt.Errorf("invalid match_mode: must be substring, whole_word, or word_boundary")
+	}
+	if analyzer.WordBounded(req.MatchMode) && req.PatternType != "keyword" {
+		return fmt.Errorf("match_mode is only supported for keyword policies")
+	}
+	if analyzer.WordBounded(req.MatchMode) && req.Stem {
+		return fmt.Errorf("match_mode cannot be combined with stem: stemmed keywords always match whole words")
+	}
+	if len(req.CaptureConstraints) > 0 {
+		if req.PatternType != "regex" {
+			return fmt.Errorf("capture_constraints are only supported for regex policies")
+		}
+		if err := analyzer.ValidateCaptureConstraints(req.PatternValue, req.CaptureConstraints); err != nil {
+			return fmt.Errorf("invalid capture_constraints: %w", err)
+		}
+	}
+	if err := analyzer.ValidateEscalations(req.PatternType, req.Action, req.Escalations); err != nil {
+		return fmt.Errorf("invalid escalations: %w", err)
+	}
+	if req.MaxInputBytes < 0 {
+		return fmt.Errorf("max_input_bytes must not be negative")
+	}
+	if req.WebhookURL != "" {
+		u, err := url.Parse(req.WebhookURL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("invalid webhook_url: must be an absolute http(s) URL")
+		}
+	}
+	if req.NotifyCooldownSeconds < 0 {
+		return fmt.Errorf("notify_cooldown_seconds must not be negative")
+	}
+	validCooldownScopes := map[string]bool{"": true, "client": true, "session": true}
+	if !validCooldownScopes[req.NotifyCooldownScope] {
+		return fmt.Errorf("invalid notify_cooldown_scope: must be client or session")
+	}
+	for _, tag := range req.Tags {
+		if strings.TrimSpace(tag) == "" || tag != strings.TrimSpace(tag) {
+			return fmt.Errorf("invalid tags: tags must be non-empty without surrounding spaces")
+		}
+	}
+	validSources := map[string]bool{"": true, SourceManual: true, SourceImport: true, SourceFeed: true}
+	if !validSources[req.Source] {
+		return fmt.Errorf("invalid source: must be manual, import, or feed")
+	}
+	if req.ReviewBy != "" {
+		if _, err := time.Parse(ReviewDateLayout, req.ReviewBy); err != nil {
+			return fmt.Errorf("invalid review_by: must be a YYYY-MM-DD date")
+		}
+	}
+	for _, selector := range req.AppliesToClients {
+		if err := validateSelector(selector); err != nil {
+			return fmt.Errorf("invalid applies_to_clients: %w", err)
+		}
 	}
 	return nil
 }
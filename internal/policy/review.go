@@ -0,0 +1,124 @@
+package policy
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/notify"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Policy sources
+const (
+	SourceManual = "manual" // Created through the API by hand
+	SourceImport = "import" // Bulk-loaded from a rule library
+	SourceFeed   = "feed"   // Synced from an external threat feed
+)
+
+// ReviewDateLayout is the format of review_by dates
+const ReviewDateLayout = "2006-01-02"
+
+// ReviewOverdue reports whether a policy's review date has passed (the review_by
+// day itself is still in time)
+func ReviewOverdue(p models.Policy, now time.Time) bool {
+	if p.ReviewBy == "" {
+		return false
+	}
+	reviewBy, err := time.Parse(ReviewDateLayout, p.ReviewBy)
+	if err != nil {
+		return false
+	}
+	return now.UTC().Format(ReviewDateLayout) > reviewBy.Format(ReviewDateLayout)
+}
+
+// ReviewReminder periodically sends a "policy.review_due" event listing the
+// policies whose review date has passed, so their owners can revisit them
+type ReviewReminder struct {
+	policies func() []models.Policy
+	notifier *notify.Notifier
+	interval time.Duration
+	now      func() time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewReviewReminder creates a reminder checking the policies returned by
+// policies (e.g. the policy cache) every interval
+func NewReviewReminder(policies func() []models.Policy, notifier *notify.Notifier, interval time.Duration) *ReviewReminder {
+	return &ReviewReminder{
+		policies: policies,
+		notifier: notifier,
+		interval: interval,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// Start runs the reminder worker; the first check happens one interval after start
+func (r *ReviewReminder) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		ticker := time.NewTicker(r.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+				if event, ok := r.Check(); ok {
+					r.notifier.Notify(event)
+				}
+			case <-r.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Policy review reminder started", "interval", r.interval)
+}
+
+// Check builds the reminder event, reporting false when no review is overdue
+func (r *ReviewReminder) Check() (notify.Event, bool) {
+	now := r.now()
+	var overdue []map[string]interface{}
+	for _, p := range r.policies() {
+		if !ReviewOverdue(p, now) {
+			continue
+		}
+		overdue = append(overdue, map[string]interface{}{
+			"policy_id":   p.ID,
+			"policy_name": p.Name,
+			"owner":       p.Owner,
+			"team":        p.Team,
+			"review_by":   p.ReviewBy,
+		})
+	}
+	if len(overdue) == 0 {
+		return notify.Event{}, false
+	}
+	sort.Slice(overdue, func(i, j int) bool {
+		return overdue[i]["review_by"].(string) < overdue[j]["review_by"].(string)
+	})
+	return notify.Event{
+		Type:      "policy.review_due",
+		Severity:  "low",
+		Summary:   fmt.Sprintf("%d policies are past their review date", len(overdue)),
+		Details:   map[string]interface{}{"policies": overdue},
+		Timestamp: now,
+	}, true
+}
+
+// Stop stops the worker
+func (r *ReviewReminder) Stop() {
+	r.stopOnce.Do(func() {
+		close(r.stopChan)
+		r.wg.Wait()
+	})
+}
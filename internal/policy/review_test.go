@@ -0,0 +1,56 @@
+package policy
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestReviewReminder_Check(t *testing.T) {
+	policies := []models.Policy{
+		{ID: uuid.New(), Name: "orphan", Owner: "alice", Team: "red", ReviewBy: "2026-03-01"},
+		{ID: uuid.New(), Name: "due-today", ReviewBy: "2026-03-10"},
+		{ID: uuid.New(), Name: "older", Owner: "bob", ReviewBy: "2025-12-31"},
+		{ID: uuid.New(), Name: "unscheduled"},
+	}
+	r := NewReviewReminder(func() []models.Policy { return policies }, nil, time.Hour)
+	r.now = func() time.Time { return time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC) }
+
+	event, ok := r.Check()
+	if !ok || event.Type != "policy.review_due" {
+		t.Fatalf("Check() = %+v, %v, want policy.review_due event", event, ok)
+	}
+	overdue := event.Details["policies"].([]map[string]interface{})
+	if len(overdue) != 2 || overdue[0]["policy_name"] != "older" || overdue[1]["policy_name"] != "orphan" {
+		t.Errorf("overdue = %v, want older then orphan", overdue)
+	}
+
+	policies = policies[1:2]
+	if _, ok := r.Check(); ok {
+		t.Error("Check() reported an event with no overdue policies")
+	}
+}
+
+func TestValidateCreateRequest_Ownership(t *testing.T) {
+	base := models.CreatePolicyRequest{Name: "p", PatternType: "keyword", PatternValue: "x", Severity: "low", Action: "log"}
+
+	valid := base
+	valid.Owner, valid.Team, valid.ReviewBy, valid.Source = "alice", "red", "2026-12-31", SourceFeed
+	if err := ValidateCreateRequest(valid); err != nil {
+		t.Errorf("ValidateCreateRequest() error = %v", err)
+	}
+
+	badDate := base
+	badDate.ReviewBy = "31/12/2026"
+	if err := ValidateCreateRequest(badDate); err == nil {
+		t.Error("ValidateCreateRequest(review_by 31/12/2026) error = nil, want error")
+	}
+
+	badSource := base
+	badSource.Source = "scraped"
+	if err := ValidateCreateRequest(badSource); err == nil {
+		t.Error("ValidateCreateRequest(source scraped) error = nil, want error")
+	}
+}
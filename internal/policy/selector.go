@@ -0,0 +1,81 @@
+package policy
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// AppliesTo reports whether a policy's applies_to_clients selectors match a client
+// A policy without selectors applies to every client; otherwise any one selector
+// matching is enough. Selector forms:
+//
+//	"acme-prod"            exact client ID
+//	"billing-*"            glob against the client ID
+//	"env=prod,team!=ml"    label selector against the client registry (all terms must hold)
+func AppliesTo(p models.Policy, client models.Client) bool {
+	if len(p.AppliesToClients) == 0 {
+		return true
+	}
+	for _, selector := range p.AppliesToClients {
+		if matchSelector(selector, client) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchSelector evaluates a single selector; malformed ones never match
+func matchSelector(selector string, client models.Client) bool {
+	if strings.Contains(selector, "=") {
+		for _, term := range strings.Split(selector, ",") {
+			key, value, negate, ok := parseLabelTerm(term)
+			if !ok {
+				return false
+			}
+			if (client.Labels[key] == value) == negate {
+				return false
+			}
+		}
+		return true
+	}
+	if strings.ContainsAny(selector, "*?[") {
+		matched, err := path.Match(selector, client.ID)
+		return err == nil && matched
+	}
+	return selector == client.ID
+}
+
+// parseLabelTerm splits "key=value" or "key!=value"
+func parseLabelTerm(term string) (key, value string, negate, ok bool) {
+	if k, v, found := strings.Cut(term, "!="); found {
+		key, value, negate = k, v, true
+	} else if k, v, found := strings.Cut(term, "="); found {
+		key, value = k, v
+	} else {
+		return "", "", false, false
+	}
+	key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+	return key, value, negate, key != ""
+}
+
+// validateSelector rejects selectors that could never match as intended
+func validateSelector(selector string) error {
+	if strings.TrimSpace(selector) == "" {
+		return fmt.Errorf("selector must not be empty")
+	}
+	if strings.Contains(selector, "=") {
+		for _, term := range strings.Split(selector, ",") {
+			if _, _, _, ok := parseLabelTerm(term); !ok {
+				return fmt.Errorf("invalid label term %q: expected key=value or key!=value", term)
+			}
+		}
+		return nil
+	}
+	if _, err := path.Match(selector, ""); err != nil {
+		return fmt.Errorf("invalid glob %q: %w", selector, err)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package policy
+
+import (
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestAppliesTo(t *testing.T) {
+	client := models.Client{ID: "billing-eu", Labels: map[string]string{"env": "prod", "team": "payments"}}
+
+	tests := []struct {
+		name      string
+		selectors []string
+		want      bool
+	}{
+		{"no selectors applies to all", nil, true},
+		{"exact id", []string{"billing-eu"}, true},
+		{"exact id mismatch", []string{"billing"}, false},
+		{"glob", []string{"billing-*"}, true},
+		{"glob mismatch", []string{"search-*"}, false},
+		{"label selector", []string{"env=prod"}, true},
+		{"all label terms must hold", []string{"env=prod,team=ml"}, false},
+		{"negated label", []string{"env=prod,team!=ml"}, true},
+		{"missing label with negation", []string{"region!=us"}, true},
+		{"any selector matching is enough", []string{"search-*", "team=payments"}, true},
+		{"malformed label term never matches", []string{"env=prod,oops"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := models.Policy{AppliesToClients: tt.selectors}
+			if got := AppliesTo(p, client); got != tt.want {
+				t.Errorf("AppliesTo(%v) = %v, want %v", tt.selectors, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package policydiff
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// maxLine bounds one corpus line
+const maxLine = 4 << 20
+
+// ParseCorpus reads one entry per line: a JSON analyze request
+// ({"prompt": ..., "response": ..., "messages": [...], "client_id": ...}) or, for
+// any other line, a plain prompt. Blank lines are skipped
+func ParseCorpus(r io.Reader) ([]models.AnalyzeRequest, error) {
+	var corpus []models.AnalyzeRequest
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		if !strings.HasPrefix(text, "{") {
+			corpus = append(corpus, models.AnalyzeRequest{Prompt: text})
+			continue
+		}
+		var entry models.AnalyzeRequest
+		if err := json.Unmarshal([]byte(text), &entry); err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		corpus = append(corpus, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return corpus, nil
+}
@@ -0,0 +1,347 @@
+// Package policydiff evaluates a prompt corpus against two policy bundles and
+// reports which decisions would change, summarized per policy, to quantify the
+// blast radius of a policy change before it ships
+package policydiff
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Policy changes between bundles
+const (
+	ChangeAdded     = "added"
+	ChangeRemoved   = "removed"
+	ChangeModified  = "modified"
+	ChangeUnchanged = "unchanged"
+)
+
+// Differ compares policy bundles over a corpus without side effects: nothing is
+// audited, cached or counted, and model policies are skipped
+type Differ struct {
+	analyzer *analyzer.Analyzer
+	clients  func(id string) models.Client
+	benign   *benign.Corpus // Optional; nil skips false-positive estimates
+}
+
+// New creates a Differ matching with a (see Analyzer.Offline); clients resolves
+// corpus client IDs so applies_to_clients selectors and tier_actions apply, and
+// may be nil
+func New(a *analyzer.Analyzer, clients func(id string) models.Client) *Differ {
+	if clients == nil {
+		clients = func(id string) models.Client { return models.Client{ID: id} }
+	}
+	return &Differ{analyzer: a, clients: clients}
+}
+
+// SetBenignCorpus estimates the false positives of added and modified policies on corpus
+func (d *Differ) SetBenignCorpus(corpus benign.Corpus) {
+	d.benign = &corpus
+}
+
+// outcome is one policy's result on one corpus entry
+type outcome struct {
+	matched bool
+	err     bool
+	// escalated is the action an escalation raised the policy to, if any
+	escalated string
+}
+
+// Run evaluates every corpus entry against both bundles
+func (d *Differ) Run(ctx context.Context, before, after []models.Policy, corpus []models.AnalyzeRequest) (*models.PolicyDiffReport, error) {
+	for i, entry := range corpus {
+		if entry.Prompt == "" && entry.Response == "" && len(entry.Messages) == 0 {
+			return nil, fmt.Errorf("corpus entry %d: prompt, response or messages is required", i)
+		}
+	}
+	beforeByName, err := byName(before)
+	if err != nil {
+		return nil, fmt.Errorf("before: %w", err)
+	}
+	afterByName, err := byName(after)
+	if err != nil {
+		return nil, fmt.Errorf("after: %w", err)
+	}
+
+	report := &models.PolicyDiffReport{
+		Transitions: make(map[string]int),
+		Policies:    []models.PolicyDiffSummary{},
+		Changes:     []models.DecisionChange{},
+	}
+	summaries := make(map[string]*models.PolicyDiffSummary)
+	var names []string
+	for _, p := range append(slices.Clone(before), after...) {
+		if _, ok := summaries[p.Name]; ok {
+			continue
+		}
+		if analyzer.UsesModel(p) {
+			report.SkippedModelPolicies = append(report.SkippedModelPolicies, p.Name)
+		}
+		names = append(names, p.Name)
+		summaries[p.Name] = &models.PolicyDiffSummary{PolicyName: p.Name, Change: change(beforeByName, afterByName, p.Name)}
+	}
+	sort.Strings(report.SkippedModelPolicies)
+
+	for i, entry := range corpus {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		client := d.clients(entry.ClientID)
+		// Policies defined identically in both bundles are evaluated once
+		memo := make(map[string]outcome)
+		beforeResults := d.evaluate(ctx, before, entry, client, memo)
+		afterResults := d.evaluate(ctx, after, entry, client, memo)
+		beforeDecision, beforeMatched := decide(before, beforeResults, client)
+		afterDecision, afterMatched := decide(after, afterResults, client)
+		changed := beforeDecision != afterDecision
+
+		for _, name := range names {
+			s := summaries[name]
+			b, a := beforeResults[name], afterResults[name]
+			if b.matched {
+				s.MatchesBefore++
+			}
+			if a.matched {
+				s.MatchesAfter++
+			}
+			if b.err || a.err {
+				s.Errors++
+			}
+			switch {
+			case a.matched && !b.matched:
+				s.NewlyMatched++
+			case b.matched && !a.matched:
+				s.NoLongerMatched++
+			case !a.matched || s.Change != ChangeModified:
+				continue
+			}
+			// The policy's match changed, or it matched with a new definition (e.g. action)
+			if changed {
+				s.DecisionsChanged++
+			}
+		}
+
+		report.Evaluated++
+		if !changed {
+			continue
+		}
+		report.Changed++
+		report.Transitions[beforeDecision+"->"+afterDecision]++
+		report.Changes = append(report.Changes, models.DecisionChange{
+			Index:          i,
+			ClientID:       entry.ClientID,
+			Before:         beforeDecision,
+			After:          afterDecision,
+			PoliciesBefore: beforeMatched,
+			PoliciesAfter:  afterMatched,
+		})
+	}
+
+	if err := d.estimate(ctx, report, summaries, afterByName); err != nil {
+		return nil, err
+	}
+
+	for _, name := range names {
+		s := summaries[name]
+		if s.Change != ChangeUnchanged || s.NewlyMatched > 0 || s.NoLongerMatched > 0 || s.Errors > 0 {
+			report.Policies = append(report.Policies, *s)
+		}
+	}
+	sort.SliceStable(report.Policies, func(i, j int) bool {
+		a, b := report.Policies[i], report.Policies[j]
+		if a.DecisionsChanged != b.DecisionsChanged {
+			return a.DecisionsChanged > b.DecisionsChanged
+		}
+		if a.NewlyMatched+a.NoLongerMatched != b.NewlyMatched+b.NoLongerMatched {
+			return a.NewlyMatched+a.NoLongerMatched > b.NewlyMatched+b.NoLongerMatched
+		}
+		return a.PolicyName < b.PolicyName
+	})
+	return report, nil
+}
+
+// estimate reports the benign matches per 10k prompts of every enabled added or
+// modified policy, i.e. those about to go live
+func (d *Differ) estimate(ctx context.Context, report *models.PolicyDiffReport, summaries map[string]*models.PolicyDiffSummary, after map[string]models.Policy) error {
+	if d.benign == nil {
+		return nil
+	}
+	info := d.benign.Info()
+	report.BenignCorpus = &info
+	for name, s := range summaries {
+		p, ok := after[name]
+		if !ok || !p.Enabled || analyzer.UsesModel(p) || (s.Change != ChangeAdded && s.Change != ChangeModified) {
+			continue
+		}
+		matched, err := d.benign.Match(ctx, d.analyzer, p)
+		if err != nil {
+			return err
+		}
+		rate := benign.Per10k(len(matched), info.Size)
+		s.BenignMatchesPer10k = &rate
+	}
+	return nil
+}
+
+// evaluate checks each applicable policy of a bundle on its own, so every match is
+// attributed even though Analyze stops at the first one
+func (d *Differ) evaluate(ctx context.Context, policies []models.Policy, entry models.AnalyzeRequest, client models.Client, memo map[string]outcome) map[string]outcome {
+	results := make(map[string]outcome, len(policies))
+	for _, p := range policies {
+		if !p.Enabled || analyzer.UsesModel(p) || !policy.AppliesTo(p, client) {
+			continue
+		}
+		key := definition(p)
+		result, ok := memo[key]
+		if !ok {
+			result = d.check(ctx, p, entry)
+			memo[key] = result
+		}
+		results[p.Name] = result
+	}
+	return results
+}
+
+// check evaluates one policy the way the analyze pipeline would for the entry
+func (d *Differ) check(ctx context.Context, p models.Policy, entry models.AnalyzeRequest) outcome {
+	var contents []string
+	switch {
+	case len(entry.Messages) > 0:
+		for _, msg := range entry.Messages {
+			if len(analyzer.PoliciesForRole([]models.Policy{p}, msg.Role)) > 0 {
+				contents = append(contents, msg.AnalyzableContent())
+			}
+		}
+	case analyzer.IsResponseOnly(p):
+		if entry.Response != "" {
+			contents = append(contents, entry.Response)
+		}
+	default:
+		content := entry.Prompt
+		if entry.Response != "" {
+			content += "\n" + entry.Response
+		}
+		contents = append(contents, content)
+	}
+
+	var result outcome
+	for _, content := range contents {
+		matches, err := d.analyzer.Analyze(ctx, content, []models.Policy{p})
+		if err != nil {
+			return outcome{err: true}
+		}
+		for _, m := range matches {
+			result.matched = true
+			result.escalated = analyzer.EffectiveAction(result.escalated, m)
+		}
+		// Escalations count per message, so every message must be checked
+		if result.matched && len(p.Escalations) == 0 {
+			break
+		}
+	}
+	return result
+}
+
+// decide resolves the decision for a bundle's matches with the client's tier actions:
+// block, then redact, then log when anything matched, else allow; default-block
+// clients are blocked unless an allow policy matched
+func decide(policies []models.Policy, results map[string]outcome, client models.Client) (string, []string) {
+	decision := "allow"
+	matched := []string{}
+	explicit := false
+	rank := map[string]int{"allow": 0, "log": 1, "redact": 2, "block": 3}
+	for _, p := range policies {
+		if !results[p.Name].matched {
+			continue
+		}
+		matched = append(matched, p.Name)
+		if p.Action == "allow" {
+			explicit = true
+			continue
+		}
+		action := p.Action
+		if override, ok := p.TierActions[client.TrustTier]; ok {
+			action = override
+		}
+		action = analyzer.EffectiveAction(action, models.PolicyMatch{Action: results[p.Name].escalated})
+		if rank[action] > rank[decision] {
+			decision = action
+		}
+	}
+	if client.DefaultAction == clients.DefaultBlock && !explicit {
+		decision = "block"
+	}
+	sort.Strings(matched)
+	return decision, matched
+}
+
+// byName indexes a bundle by policy name, which identifies policies across bundles
+func byName(policies []models.Policy) (map[string]models.Policy, error) {
+	index := make(map[string]models.Policy, len(policies))
+	for i, p := range policies {
+		if p.Name == "" {
+			return nil, fmt.Errorf("policy %d has no name", i)
+		}
+		if _, dup := index[p.Name]; dup {
+			return nil, fmt.Errorf("duplicate policy name %q", p.Name)
+		}
+		if err := policy.ValidateCreateRequest(createRequest(p)); err != nil {
+			return nil, fmt.Errorf("policy %q: %w", p.Name, err)
+		}
+		index[p.Name] = p
+	}
+	return index, nil
+}
+
+// change classifies how a policy differs between the bundles
+func change(before, after map[string]models.Policy, name string) string {
+	b, inBefore := before[name]
+	a, inAfter := after[name]
+	switch {
+	case !inBefore:
+		return ChangeAdded
+	case !inAfter:
+		return ChangeRemoved
+	case definition(b) != definition(a):
+		return ChangeModified
+	}
+	return ChangeUnchanged
+}
+
+// definition fingerprints the fields that affect decisions, ignoring identity,
+// ownership metadata and hit statistics
+func definition(p models.Policy) string {
+	key, _ := json.Marshal(createRequest(p))
+	return string(key) + fmt.Sprint(p.Enabled)
+}
+
+// createRequest is the decision-relevant definition of a policy
+func createRequest(p models.Policy) models.CreatePolicyRequest {
+	return models.CreatePolicyRequest{
+		Name:               p.Name,
+		PatternType:        p.PatternType,
+		PatternValue:       p.PatternValue,
+		Severity:           p.Severity,
+		Action:             p.Action,
+		Priority:           p.Priority,
+		TierActions:        p.TierActions,
+		Roles:              p.Roles,
+		AppliesToClients:   p.AppliesToClients,
+		ScanScope:          p.ScanScope,
+		StripMarkup:        p.StripMarkup,
+		MaxInputBytes:      p.MaxInputBytes,
+		Stem:               p.Stem,
+		MatchMode:          p.MatchMode,
+		CaptureConstraints: p.CaptureConstraints,
+		Escalations:        p.Escalations,
+	}
+}
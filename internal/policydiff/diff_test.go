@@ -0,0 +1,157 @@
+package policydiff
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func bundle(t *testing.T, data string) []models.Policy {
+	t.Helper()
+	var b models.PolicyBundle
+	if err := json.Unmarshal([]byte(data), &b); err != nil {
+		t.Fatalf("bundle: %v", err)
+	}
+	return b
+}
+
+func TestDiffer_Run(t *testing.T) {
+	before := bundle(t, `[
+		{"name": "secret", "pattern_type": "keyword", "pattern_value": "password", "severity": "high", "action": "block"},
+		{"name": "email", "pattern_type": "regex", "pattern_value": "\\w+@\\w+\\.com", "severity": "medium", "action": "log"},
+		{"name": "safety", "pattern_type": "model", "pattern_value": "m", "severity": "high", "action": "block"}
+	]`)
+	after := bundle(t, `[
+		{"name": "secret", "pattern_type": "keyword", "pattern_value": "password", "severity": "high", "action": "block", "enabled": false},
+		{"name": "email", "pattern_type": "regex", "pattern_value": "\\w+@\\w+\\.com", "severity": "medium", "action": "log"},
+		{"name": "token", "pattern_type": "keyword", "pattern_value": "token", "severity": "high", "action": "block", "tier_actions": {"trusted": "log"}},
+		{"name": "safety", "pattern_type": "model", "pattern_value": "m", "severity": "high", "action": "block"}
+	]`)
+	corpus, err := ParseCorpus(strings.NewReader(`my password is hunter2
+mail a@b.com
+
+{"prompt": "my token", "client_id": "partner"}
+{"prompt": "my token", "client_id": "internal"}
+`))
+	if err != nil || len(corpus) != 4 {
+		t.Fatalf("ParseCorpus() = %d entries, %v, want 4", len(corpus), err)
+	}
+
+	d := New(analyzer.NewAnalyzer(nil), func(id string) models.Client {
+		c := models.Client{ID: id, TrustTier: "standard"}
+		if id == "internal" {
+			c.TrustTier = "trusted"
+		}
+		return c
+	})
+	report, err := d.Run(context.Background(), before, after, corpus)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.Evaluated != 4 || report.Changed != 3 {
+		t.Errorf("evaluated %d, changed %d, want 4 and 3", report.Evaluated, report.Changed)
+	}
+	want := map[string]int{"block->allow": 1, "allow->block": 1, "allow->log": 1}
+	for k, v := range want {
+		if report.Transitions[k] != v {
+			t.Errorf("Transitions = %v, want %v", report.Transitions, want)
+			break
+		}
+	}
+	if len(report.SkippedModelPolicies) != 1 || report.SkippedModelPolicies[0] != "safety" {
+		t.Errorf("SkippedModelPolicies = %v, want [safety]", report.SkippedModelPolicies)
+	}
+
+	// email neither changed nor matched differently, so only secret and token are summarized
+	if len(report.Policies) != 2 {
+		t.Fatalf("Policies = %+v, want secret and token", report.Policies)
+	}
+	token, secret := report.Policies[0], report.Policies[1]
+	if token.PolicyName != "token" || token.Change != ChangeAdded || token.NewlyMatched != 2 || token.DecisionsChanged != 2 {
+		t.Errorf("token summary = %+v, want added, 2 newly matched, 2 decisions", token)
+	}
+	if secret.PolicyName != "secret" || secret.Change != ChangeModified || secret.NoLongerMatched != 1 || secret.DecisionsChanged != 1 {
+		t.Errorf("secret summary = %+v, want modified, 1 no longer matched, 1 decision", secret)
+	}
+	if c := report.Changes[0]; c.Index != 0 || c.Before != "block" || c.After != "allow" || len(c.PoliciesBefore) != 1 {
+		t.Errorf("Changes[0] = %+v, want entry 0 block -> allow", c)
+	}
+
+	if _, err := d.Run(context.Background(), before, append(after, after[0]), corpus); err == nil {
+		t.Error("Run() with duplicate policy names error = nil, want error")
+	}
+}
+
+func TestDiffer_RunDefaultBlock(t *testing.T) {
+	before := bundle(t, `[
+		{"name": "secret", "pattern_type": "keyword", "pattern_value": "password", "severity": "high", "action": "block"}
+	]`)
+	after := bundle(t, `[
+		{"name": "secret", "pattern_type": "keyword", "pattern_value": "password", "severity": "high", "action": "block"},
+		{"name": "faq", "pattern_type": "regex", "pattern_value": "^What are your opening hours\\?$", "severity": "low", "action": "allow"}
+	]`)
+	corpus := []models.AnalyzeRequest{
+		{Prompt: "What are your opening hours?", ClientID: "kiosk"},
+		{Prompt: "Tell me a joke", ClientID: "kiosk"},
+		{Prompt: "Tell me a joke", ClientID: "web"},
+	}
+
+	d := New(analyzer.NewAnalyzer(nil), func(id string) models.Client {
+		c := models.Client{ID: id, DefaultAction: clients.DefaultAllow}
+		if id == "kiosk" {
+			c.DefaultAction = clients.DefaultBlock
+		}
+		return c
+	})
+	report, err := d.Run(context.Background(), before, after, corpus)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	// Only the kiosk prompt matching the new allow policy gets through
+	if report.Changed != 1 || report.Transitions["block->allow"] != 1 {
+		t.Fatalf("Changed = %d, Transitions = %v, want one block->allow", report.Changed, report.Transitions)
+	}
+	if c := report.Changes[0]; c.Index != 0 || len(c.PoliciesAfter) != 1 || c.PoliciesAfter[0] != "faq" {
+		t.Errorf("change = %+v, want entry 0 allowed by faq", c)
+	}
+}
+
+func TestDiffer_RunBenignEstimates(t *testing.T) {
+	before := bundle(t, `[
+		{"name": "secret", "pattern_type": "keyword", "pattern_value": "password", "severity": "high", "action": "block"},
+		{"name": "legacy", "pattern_type": "keyword", "pattern_value": "the", "severity": "low", "action": "log"}
+	]`)
+	after := bundle(t, `[
+		{"name": "secret", "pattern_type": "keyword", "pattern_value": "password", "severity": "high", "action": "block"},
+		{"name": "legacy", "pattern_type": "keyword", "pattern_value": "the", "severity": "low", "action": "log"},
+		{"name": "recipe", "pattern_type": "keyword", "pattern_value": "recipe", "severity": "low", "action": "log"}
+	]`)
+	corpus := []models.AnalyzeRequest{{Prompt: "a recipe for soup"}}
+
+	d := New(analyzer.NewAnalyzer(nil), nil)
+	d.SetBenignCorpus(benign.Corpus{Source: benign.SourceUploaded, Prompts: []string{
+		"Give me a recipe for lasagna", "What is the capital of France?", "Plan a trip", "Write a poem",
+	}})
+	report, err := d.Run(context.Background(), before, after, corpus)
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	if report.BenignCorpus == nil || report.BenignCorpus.Size != 4 || report.BenignCorpus.Source != benign.SourceUploaded {
+		t.Fatalf("BenignCorpus = %+v, want the 4 uploaded prompts", report.BenignCorpus)
+	}
+	// Only the added policy is estimated: 1 of 4 benign prompts is 2500 per 10k
+	if len(report.Policies) != 1 {
+		t.Fatalf("Policies = %+v, want recipe", report.Policies)
+	}
+	if rate := report.Policies[0].BenignMatchesPer10k; rate == nil || *rate != 2500 {
+		t.Errorf("recipe benign_matches_per_10k = %v, want 2500", rate)
+	}
+}
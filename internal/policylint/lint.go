@@ -0,0 +1,182 @@
+// Package policylint checks a policy definition for common mistakes before it is
+// created: patterns that fire on ordinary prompts, unanchored regexes, duplicates
+// of existing policies and keywords too short to be specific
+package policylint
+
+import (
+	"context"
+	"fmt"
+	"regexp/syntax"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Warning codes
+const (
+	WarnBroadPattern  = "broad_pattern"
+	WarnMissingAnchor = "missing_anchor"
+	WarnDuplicate     = "duplicate"
+	WarnShortKeyword  = "short_keyword"
+)
+
+// DefaultBroadPercent is the share of the benign corpus a policy may match before
+// it is reported as too broad
+const DefaultBroadPercent = 1.0
+
+// minKeywordLength is the shortest keyword that isn't reported as too short
+const minKeywordLength = 3
+
+// maxExamples bounds the benign prompts quoted for a broad pattern
+const maxExamples = 3
+
+// Linter reports problems with policy definitions; like policy diffs, it
+// evaluates without side effects
+type Linter struct {
+	analyzer     *analyzer.Analyzer
+	broadPercent float64
+}
+
+// New creates a Linter matching with a (see Analyzer.Offline) that reports
+// policies matching more than broadPercent of the benign corpus
+func New(a *analyzer.Analyzer, broadPercent float64) *Linter {
+	if broadPercent <= 0 {
+		broadPercent = DefaultBroadPercent
+	}
+	return &Linter{analyzer: a, broadPercent: broadPercent}
+}
+
+// Lint checks req against the best practices, the existing policies and the
+// benign corpus. A request the create endpoint would reject is reported invalid
+// without further checks
+func (l *Linter) Lint(ctx context.Context, req models.CreatePolicyRequest, existing []models.Policy, corpus benign.Corpus) (*models.PolicyLintReport, error) {
+	report := &models.PolicyLintReport{Valid: true, Warnings: []models.LintWarning{}}
+	if err := policy.ValidateCreateRequest(req); err != nil {
+		report.Valid = false
+		report.Error = err.Error()
+		return report, nil
+	}
+
+	if req.PatternType == "keyword" && utf8.RuneCountInString(strings.TrimSpace(req.PatternValue)) < minKeywordLength {
+		report.Warnings = append(report.Warnings, models.LintWarning{
+			Code:    WarnShortKeyword,
+			Message: fmt.Sprintf("keyword %q is shorter than %d characters and matches inside many unrelated words", req.PatternValue, minKeywordLength),
+		})
+	}
+	if req.PatternType == "regex" && !anchored(req.PatternValue) {
+		report.Warnings = append(report.Warnings, models.LintWarning{
+			Code:    WarnMissingAnchor,
+			Message: `regex has no anchors or word boundaries (^, $, \b), so it also matches inside longer words`,
+		})
+	}
+	for _, p := range existing {
+		if p.PatternType == req.PatternType && samePattern(req.PatternType, p.PatternValue, req.PatternValue) {
+			report.Warnings = append(report.Warnings, models.LintWarning{
+				Code:    WarnDuplicate,
+				Message: fmt.Sprintf("policy %q already matches the same %s pattern", p.Name, p.PatternType),
+				Policy:  p.Name,
+			})
+		}
+	}
+
+	p := fromRequest(req)
+	if analyzer.UsesModel(p) {
+		return report, nil
+	}
+	result, err := l.evaluate(ctx, p, corpus)
+	if err != nil {
+		return nil, err
+	}
+	report.Corpus = result
+	if result.MatchPercent > l.broadPercent {
+		report.Warnings = append(report.Warnings, models.LintWarning{
+			Code:    WarnBroadPattern,
+			Message: fmt.Sprintf("matches %d of %d benign prompts (%.1f%%, about %.0f per 10k; limit %.1f%%)", result.Matches, result.Size, result.MatchPercent, result.MatchesPer10k, l.broadPercent),
+		})
+	}
+	return report, nil
+}
+
+// evaluate runs p over the benign corpus
+func (l *Linter) evaluate(ctx context.Context, p models.Policy, corpus benign.Corpus) (*models.LintCorpusResult, error) {
+	matched, err := corpus.Match(ctx, l.analyzer, p)
+	if err != nil {
+		return nil, err
+	}
+	result := &models.LintCorpusResult{
+		Source:        corpus.Source,
+		Size:          len(corpus.Prompts),
+		Matches:       len(matched),
+		MatchesPer10k: benign.Per10k(len(matched), len(corpus.Prompts)),
+		Examples:      matched[:min(len(matched), maxExamples)],
+	}
+	if result.Examples == nil {
+		result.Examples = []string{}
+	}
+	result.MatchPercent = result.MatchesPer10k / 100
+	return result, nil
+}
+
+// anchored reports whether a regex is tied to line, text or word boundaries
+func anchored(pattern string) bool {
+	re, err := syntax.Parse(pattern, syntax.Perl)
+	if err != nil {
+		return true
+	}
+	var walk func(re *syntax.Regexp) bool
+	walk = func(re *syntax.Regexp) bool {
+		switch re.Op {
+		case syntax.OpBeginLine, syntax.OpEndLine, syntax.OpBeginText, syntax.OpEndText, syntax.OpWordBoundary:
+			return true
+		}
+		for _, sub := range re.Sub {
+			if walk(sub) {
+				return true
+			}
+		}
+		return false
+	}
+	return walk(re)
+}
+
+// samePattern reports whether two pattern values of a type match the same content
+func samePattern(patternType, a, b string) bool {
+	a, b = strings.TrimSpace(a), strings.TrimSpace(b)
+	if patternType == "keyword" {
+		return strings.EqualFold(a, b)
+	}
+	return a == b
+}
+
+// fromRequest builds the enabled policy req would create
+func fromRequest(req models.CreatePolicyRequest) models.Policy {
+	scanScope := req.ScanScope
+	if scanScope == "" {
+		scanScope = "all"
+	}
+	return models.Policy{
+		ID:                 uuid.New(),
+		Name:               req.Name,
+		PatternType:        req.PatternType,
+		PatternValue:       req.PatternValue,
+		Severity:           req.Severity,
+		Action:             req.Action,
+		Priority:           req.Priority,
+		Enabled:            true,
+		TierActions:        req.TierActions,
+		Roles:              req.Roles,
+		ScanScope:          scanScope,
+		StripMarkup:        req.StripMarkup,
+		MaxInputBytes:      req.MaxInputBytes,
+		Stem:               req.Stem,
+		MatchMode:          req.MatchMode,
+		CaptureConstraints: req.CaptureConstraints,
+		Escalations:        req.Escalations,
+	}
+}
@@ -0,0 +1,139 @@
+package policylint
+
+import (
+	"context"
+	"testing"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func codes(report *models.PolicyLintReport) map[string]bool {
+	found := make(map[string]bool)
+	for _, w := range report.Warnings {
+		found[w.Code] = true
+	}
+	return found
+}
+
+func TestLinter_Lint(t *testing.T) {
+	existing := []models.Policy{
+		{Name: "secrets", PatternType: "keyword", PatternValue: "Password", Severity: "high", Action: "block", Enabled: true},
+	}
+	tests := []struct {
+		name      string
+		req       models.CreatePolicyRequest
+		wantValid bool
+		want      []string
+	}{
+		{
+			name:      "clean anchored regex",
+			req:       models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\b\d{3}-\d{2}-\d{4}\b`, Severity: "high", Action: "block"},
+			wantValid: true,
+		},
+		{
+			name:      "unanchored regex",
+			req:       models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+			wantValid: true,
+			want:      []string{WarnMissingAnchor},
+		},
+		{
+			name:      "broad short keyword",
+			req:       models.CreatePolicyRequest{Name: "an", PatternType: "keyword", PatternValue: "an", Severity: "low", Action: "log"},
+			wantValid: true,
+			want:      []string{WarnShortKeyword, WarnBroadPattern},
+		},
+		{
+			name:      "duplicate keyword ignores case",
+			req:       models.CreatePolicyRequest{Name: "pw", PatternType: "keyword", PatternValue: "password ", Severity: "high", Action: "block"},
+			wantValid: true,
+			want:      []string{WarnDuplicate},
+		},
+		{
+			name: "invalid policy",
+			req:  models.CreatePolicyRequest{Name: "bad", PatternType: "regex", PatternValue: `(`, Severity: "high", Action: "block"},
+		},
+	}
+
+	l := New(analyzer.NewAnalyzer(nil), DefaultBroadPercent)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := l.Lint(context.Background(), tt.req, existing, benign.Bundled())
+			if err != nil {
+				t.Fatalf("Lint() error = %v", err)
+			}
+			if report.Valid != tt.wantValid {
+				t.Fatalf("Valid = %v (%s), want %v", report.Valid, report.Error, tt.wantValid)
+			}
+			got := codes(report)
+			if len(got) != len(tt.want) {
+				t.Errorf("warnings = %+v, want %v", report.Warnings, tt.want)
+			}
+			for _, code := range tt.want {
+				if !got[code] {
+					t.Errorf("missing %s warning in %+v", code, report.Warnings)
+				}
+			}
+		})
+	}
+}
+
+func TestLinter_LintCorpus(t *testing.T) {
+	l := New(analyzer.NewAnalyzer(nil), 50)
+	report, err := l.Lint(context.Background(), models.CreatePolicyRequest{
+		Name: "the", PatternType: "keyword", PatternValue: "the", Severity: "low", Action: "log",
+	}, nil, benign.Bundled())
+	if err != nil {
+		t.Fatalf("Lint() error = %v", err)
+	}
+	c := report.Corpus
+	if c == nil || c.Size != len(benign.Bundled().Prompts) || c.Matches == 0 || len(c.Examples) != maxExamples {
+		t.Fatalf("corpus = %+v, want matches with %d examples", c, maxExamples)
+	}
+	if broad := codes(report)[WarnBroadPattern]; broad != (c.MatchPercent > 50) {
+		t.Errorf("broad_pattern = %v at %.1f%%, limit 50%%", broad, c.MatchPercent)
+	}
+
+	report, err = l.Lint(context.Background(), models.CreatePolicyRequest{
+		Name: "safety", PatternType: "model", PatternValue: "m", Severity: "high", Action: "block",
+	}, nil, benign.Bundled())
+	if err != nil || report.Corpus != nil {
+		t.Errorf("model policy corpus = %+v, %v, want none", report.Corpus, err)
+	}
+}
+
+func TestLinter_Test(t *testing.T) {
+	l := New(analyzer.NewAnalyzer(nil), DefaultBroadPercent)
+	ssn := models.CreatePolicyRequest{
+		Name: "ssn", PatternType: "regex", PatternValue: `\b\d{3}-\d{2}-\d{4}\b`, Severity: "high", Action: "log",
+		Escalations: []models.Escalation{{MinMatches: 2, Action: "block"}},
+	}
+	result, err := l.Test(context.Background(), ssn, []string{"ssn 123-45-6789", "no match 1234-56-789", "123-45-6789 and 987-65-4321"})
+	if err != nil {
+		t.Fatalf("Test() error = %v", err)
+	}
+	if !result.Valid || len(result.Results) != 3 {
+		t.Fatalf("Test() = %+v, want 3 results", result)
+	}
+	first, second, third := result.Results[0], result.Results[1], result.Results[2]
+	if !first.Matched || first.Action != "log" || first.Match.Start == nil || *first.Match.Start != 4 {
+		t.Errorf("first = %+v, want a logged match at offset 4", first)
+	}
+	if second.Matched || second.Match != nil {
+		t.Errorf("second = %+v, want no match", second)
+	}
+	if !third.Matched || third.Action != "block" {
+		t.Errorf("third = %+v, want the escalated block action", third)
+	}
+
+	invalid, err := l.Test(context.Background(), models.CreatePolicyRequest{Name: "bad", PatternType: "regex", PatternValue: `(`, Severity: "high", Action: "block"}, []string{"x"})
+	if err != nil || invalid.Valid || invalid.Error == "" || len(invalid.Results) != 0 {
+		t.Errorf("Test() = %+v, %v, want invalid without results", invalid, err)
+	}
+
+	model := models.CreatePolicyRequest{Name: "safety", PatternType: "model", PatternValue: "nemo", Severity: "high", Action: "block"}
+	if _, err := l.Test(context.Background(), model, []string{"x"}); err != ErrModelPolicy {
+		t.Errorf("Test() error = %v, want ErrModelPolicy", err)
+	}
+}
@@ -0,0 +1,46 @@
+package policylint
+
+import (
+	"context"
+	"errors"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// MaxTestSamples bounds the samples one policy test runs on
+const MaxTestSamples = 100
+
+// ErrModelPolicy is returned when testing a policy that would call a model provider
+var ErrModelPolicy = errors.New("policies using a model can't be tested: they would call the model provider")
+
+// Test runs a candidate policy over sample content without storing it. A
+// request the create endpoint would reject is reported invalid without results
+func (l *Linter) Test(ctx context.Context, req models.CreatePolicyRequest, samples []string) (*models.PolicyTestResponse, error) {
+	resp := &models.PolicyTestResponse{Valid: true, Results: []models.PolicyTestResult{}}
+	if err := policy.ValidateCreateRequest(req); err != nil {
+		resp.Valid = false
+		resp.Error = err.Error()
+		return resp, nil
+	}
+
+	p := fromRequest(req)
+	if analyzer.UsesModel(p) {
+		return nil, ErrModelPolicy
+	}
+	policies := []models.Policy{p}
+	for _, sample := range samples {
+		matches, err := l.analyzer.Analyze(ctx, sample, policies)
+		if err != nil {
+			return nil, err
+		}
+		var result models.PolicyTestResult
+		if len(matches) > 0 {
+			match := matches[0]
+			result = models.PolicyTestResult{Matched: true, Action: analyzer.EffectiveAction(p.Action, match), Match: &match}
+		}
+		resp.Results = append(resp.Results, result)
+	}
+	return resp, nil
+}
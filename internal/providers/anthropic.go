@@ -0,0 +1,141 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Anthropic handles the Messages API (/v1/messages)
+type Anthropic struct{}
+
+// anthropicRequest is the subset of a Messages API request we inspect
+type anthropicRequest struct {
+	Model    string             `json:"model"`
+	System   json.RawMessage    `json:"system"` // string or list of text blocks
+	Messages []anthropicMessage `json:"messages"`
+}
+
+type anthropicMessage struct {
+	Role    string          `json:"role"`
+	Content json.RawMessage `json:"content"` // string or list of content blocks
+}
+
+// anthropicBlock is a content block: text, tool_use or tool_result
+type anthropicBlock struct {
+	Type      string          `json:"type"`
+	Text      string          `json:"text"`
+	ID        string          `json:"id"`
+	Name      string          `json:"name"`
+	Input     json.RawMessage `json:"input"`
+	ToolUseID string          `json:"tool_use_id"`
+	Content   json.RawMessage `json:"content"` // tool_result payload: string or blocks
+}
+
+// Name implements Provider
+func (Anthropic) Name() string { return "anthropic" }
+
+// ParseRequest implements Provider
+// Tool results are split out into "tool" messages so role-aware policies apply
+func (Anthropic) ParseRequest(path string, body []byte) (*ParsedRequest, error) {
+	var req anthropicRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid Anthropic request body: %w", err)
+	}
+	if len(req.Messages) == 0 {
+		return nil, fmt.Errorf("no messages in Anthropic request body")
+	}
+
+	parsed := &ParsedRequest{Model: req.Model}
+	if system, _ := anthropicText(req.System); system != "" {
+		parsed.Messages = append(parsed.Messages, models.ChatMessage{Role: "system", Content: system})
+	}
+
+	for _, msg := range req.Messages {
+		text, blocks := anthropicText(msg.Content)
+		chat := models.ChatMessage{Role: msg.Role, Content: text}
+		var toolResults []models.ChatMessage
+		for _, block := range blocks {
+			switch block.Type {
+			case "tool_use":
+				chat.ToolCalls = append(chat.ToolCalls, models.ToolCall{
+					ID:       block.ID,
+					Type:     "function",
+					Function: models.ToolCallFunction{Name: block.Name, Arguments: string(block.Input)},
+				})
+			case "tool_result":
+				result, _ := anthropicText(block.Content)
+				toolResults = append(toolResults, models.ChatMessage{Role: "tool", Content: result, ToolCallID: block.ToolUseID})
+			}
+		}
+		if chat.Content != "" || len(chat.ToolCalls) > 0 {
+			parsed.Messages = append(parsed.Messages, chat)
+		}
+		parsed.Messages = append(parsed.Messages, toolResults...)
+	}
+
+	return parsed, nil
+}
+
+// anthropicText flattens a string-or-blocks content field into its text,
+// also returning the decoded blocks for further inspection
+func anthropicText(raw json.RawMessage) (string, []anthropicBlock) {
+	if len(raw) == 0 {
+		return "", nil
+	}
+	var text string
+	if err := json.Unmarshal(raw, &text); err == nil {
+		return text, nil
+	}
+	var blocks []anthropicBlock
+	if err := json.Unmarshal(raw, &blocks); err != nil {
+		return "", nil
+	}
+	var parts []string
+	for _, block := range blocks {
+		if block.Type == "text" {
+			parts = append(parts, block.Text)
+		}
+	}
+	return strings.Join(parts, "\n"), blocks
+}
+
+// ResponseText implements Provider
+func (Anthropic) ResponseText(body []byte) (string, error) {
+	var resp struct {
+		Content []anthropicBlock `json:"content"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("invalid Anthropic response body: %w", err)
+	}
+
+	var text string
+	for _, block := range resp.Content {
+		switch block.Type {
+		case "text":
+			text += block.Text
+		case "tool_use":
+			text += "\n" + block.Name + "(" + string(block.Input) + ")"
+		}
+	}
+	return text, nil
+}
+
+// StreamDelta implements Provider
+// Only content_block_delta events carry generated text or tool input
+func (Anthropic) StreamDelta(data []byte) string {
+	var event struct {
+		Type  string `json:"type"`
+		Delta struct {
+			Type        string `json:"type"`
+			Text        string `json:"text"`
+			PartialJSON string `json:"partial_json"`
+		} `json:"delta"`
+	}
+	if err := json.Unmarshal(data, &event); err != nil || event.Type != "content_block_delta" {
+		return ""
+	}
+	return event.Delta.Text + event.Delta.PartialJSON
+}
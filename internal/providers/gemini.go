@@ -0,0 +1,158 @@
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Gemini handles the Google Gemini generateContent/streamGenerateContent API
+type Gemini struct{}
+
+// geminiRequest is the subset of a generateContent request we inspect
+type geminiRequest struct {
+	Contents          []geminiContent `json:"contents"`
+	SystemInstruction *geminiContent  `json:"systemInstruction"`
+}
+
+type geminiContent struct {
+	Role  string       `json:"role"` // "user" or "model"
+	Parts []geminiPart `json:"parts"`
+}
+
+type geminiPart struct {
+	Text         string `json:"text"`
+	FunctionCall *struct {
+		Name string          `json:"name"`
+		Args json.RawMessage `json:"args"`
+	} `json:"functionCall"`
+	FunctionResponse *struct {
+		Name     string          `json:"name"`
+		Response json.RawMessage `json:"response"`
+	} `json:"functionResponse"`
+}
+
+// geminiResponse is a full response or a single streamed chunk
+type geminiResponse struct {
+	Candidates []struct {
+		Content geminiContent `json:"content"`
+	} `json:"candidates"`
+}
+
+// Name implements Provider
+func (Gemini) Name() string { return "gemini" }
+
+// ParseRequest implements Provider
+// The model is taken from the path: /v1beta/models/{model}:generateContent
+func (Gemini) ParseRequest(path string, body []byte) (*ParsedRequest, error) {
+	var req geminiRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid Gemini request body: %w", err)
+	}
+	if len(req.Contents) == 0 {
+		return nil, fmt.Errorf("no contents in Gemini request body")
+	}
+
+	parsed := &ParsedRequest{Model: geminiModel(path)}
+	if req.SystemInstruction != nil {
+		if system := geminiText(req.SystemInstruction.Parts); system != "" {
+			parsed.Messages = append(parsed.Messages, models.ChatMessage{Role: "system", Content: system})
+		}
+	}
+
+	for _, content := range req.Contents {
+		role := "user"
+		if content.Role == "model" {
+			role = "assistant"
+		}
+		chat := models.ChatMessage{Role: role, Content: geminiText(content.Parts)}
+		var toolResults []models.ChatMessage
+		for _, part := range content.Parts {
+			if part.FunctionCall != nil {
+				chat.ToolCalls = append(chat.ToolCalls, models.ToolCall{
+					Type:     "function",
+					Function: models.ToolCallFunction{Name: part.FunctionCall.Name, Arguments: string(part.FunctionCall.Args)},
+				})
+			}
+			if part.FunctionResponse != nil {
+				toolResults = append(toolResults, models.ChatMessage{
+					Role:    "tool",
+					Name:    part.FunctionResponse.Name,
+					Content: string(part.FunctionResponse.Response),
+				})
+			}
+		}
+		if chat.Content != "" || len(chat.ToolCalls) > 0 {
+			parsed.Messages = append(parsed.Messages, chat)
+		}
+		parsed.Messages = append(parsed.Messages, toolResults...)
+	}
+
+	return parsed, nil
+}
+
+// geminiModel extracts the model name from a models/{model}:method path
+func geminiModel(path string) string {
+	_, rest, ok := strings.Cut(path, "models/")
+	if !ok {
+		return ""
+	}
+	model, _, _ := strings.Cut(rest, ":")
+	return model
+}
+
+// geminiText joins the text parts of a content entry
+func geminiText(parts []geminiPart) string {
+	var texts []string
+	for _, part := range parts {
+		if part.Text != "" {
+			texts = append(texts, part.Text)
+		}
+	}
+	return strings.Join(texts, "\n")
+}
+
+// ResponseText implements Provider
+// Non-SSE streamGenerateContent returns a JSON array of chunks, which is accepted too
+func (g Gemini) ResponseText(body []byte) (string, error) {
+	var chunks []json.RawMessage
+	if err := json.Unmarshal(body, &chunks); err != nil {
+		chunks = []json.RawMessage{body}
+	}
+
+	var text string
+	for _, chunk := range chunks {
+		var resp geminiResponse
+		if err := json.Unmarshal(chunk, &resp); err != nil {
+			return "", fmt.Errorf("invalid Gemini response body: %w", err)
+		}
+		text += geminiResponseText(resp)
+	}
+	return text, nil
+}
+
+// StreamDelta implements Provider
+// With alt=sse every data payload is a complete GenerateContentResponse chunk
+func (Gemini) StreamDelta(data []byte) string {
+	var resp geminiResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return ""
+	}
+	return geminiResponseText(resp)
+}
+
+// geminiResponseText concatenates candidate text and function calls
+func geminiResponseText(resp geminiResponse) string {
+	var text string
+	for _, candidate := range resp.Candidates {
+		for _, part := range candidate.Content.Parts {
+			text += part.Text
+			if part.FunctionCall != nil {
+				text += "\n" + part.FunctionCall.Name + "(" + string(part.FunctionCall.Args) + ")"
+			}
+		}
+	}
+	return text
+}
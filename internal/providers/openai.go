@@ -0,0 +1,131 @@
+// Package providers understands the request/response wire formats of LLM
+// provider APIs so traffic can be mapped onto analyze requests
+package providers
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// openAIChatRequest is the subset of the OpenAI chat/completions request we inspect
+type openAIChatRequest struct {
+	Model    string               `json:"model"`
+	Messages []models.ChatMessage `json:"messages"`
+	Prompt   json.RawMessage      `json:"prompt"` // Legacy completions: string or []string
+	Input    json.RawMessage      `json:"input"`  // Responses API: string or message list
+}
+
+// ParsedRequest is an LLM API request mapped onto analyzable content
+type ParsedRequest struct {
+	Model    string
+	Prompt   string
+	Messages []models.ChatMessage
+}
+
+// ParseOpenAIRequest maps an OpenAI-compatible request body (chat completions,
+// legacy completions or responses API) onto analyzable content
+func ParseOpenAIRequest(body []byte) (*ParsedRequest, error) {
+	var req openAIChatRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, fmt.Errorf("invalid OpenAI request body: %w", err)
+	}
+
+	parsed := &ParsedRequest{Model: req.Model, Messages: req.Messages}
+	if len(parsed.Messages) > 0 {
+		return parsed, nil
+	}
+
+	for _, raw := range []json.RawMessage{req.Prompt, req.Input} {
+		if len(raw) == 0 {
+			continue
+		}
+		var text string
+		if err := json.Unmarshal(raw, &text); err == nil {
+			parsed.Prompt = text
+			return parsed, nil
+		}
+		var texts []string
+		if err := json.Unmarshal(raw, &texts); err == nil {
+			for _, t := range texts {
+				parsed.Prompt += t + "\n"
+			}
+			return parsed, nil
+		}
+		var messages []models.ChatMessage
+		if err := json.Unmarshal(raw, &messages); err == nil && len(messages) > 0 {
+			parsed.Messages = messages
+			return parsed, nil
+		}
+	}
+
+	return nil, fmt.Errorf("no messages, prompt or input in OpenAI request body")
+}
+
+// OpenAI handles chat completions, legacy completions and the responses API
+type OpenAI struct{}
+
+// Name implements Provider
+func (OpenAI) Name() string { return "openai" }
+
+// ParseRequest implements Provider
+func (OpenAI) ParseRequest(path string, body []byte) (*ParsedRequest, error) {
+	return ParseOpenAIRequest(body)
+}
+
+// openAIResponse covers chat/legacy completion responses and streamed chunks
+type openAIResponse struct {
+	Choices []struct {
+		Text    string `json:"text"`
+		Message struct {
+			Content string `json:"content"`
+		} `json:"message"`
+		Delta struct {
+			Content string `json:"content"`
+		} `json:"delta"`
+	} `json:"choices"`
+	Output []struct {
+		Content []struct {
+			Text string `json:"text"`
+		} `json:"content"`
+	} `json:"output"`
+	Type  string `json:"type"`  // Responses API stream event type
+	Delta string `json:"delta"` // Responses API response.output_text.delta payload
+}
+
+// ResponseText implements Provider
+func (OpenAI) ResponseText(body []byte) (string, error) {
+	var resp openAIResponse
+	if err := json.Unmarshal(body, &resp); err != nil {
+		return "", fmt.Errorf("invalid OpenAI response body: %w", err)
+	}
+
+	var text string
+	for _, choice := range resp.Choices {
+		text += choice.Message.Content + choice.Text
+	}
+	for _, item := range resp.Output {
+		for _, part := range item.Content {
+			text += part.Text
+		}
+	}
+	return text, nil
+}
+
+// StreamDelta implements Provider
+func (OpenAI) StreamDelta(data []byte) string {
+	var chunk openAIResponse
+	if err := json.Unmarshal(data, &chunk); err != nil {
+		return ""
+	}
+	if chunk.Type == "response.output_text.delta" {
+		return chunk.Delta
+	}
+
+	var text string
+	for _, choice := range chunk.Choices {
+		text += choice.Delta.Content + choice.Text
+	}
+	return text
+}
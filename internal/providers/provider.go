@@ -0,0 +1,96 @@
+package providers
+
+import (
+	"bytes"
+	"strings"
+)
+
+// Provider maps one LLM vendor's request/response wire format onto
+// analyzable content
+type Provider interface {
+	// Name is the short identifier used in proxy routes ("openai", "anthropic", "gemini")
+	Name() string
+	// ParseRequest extracts the prompt or conversation from a request body
+	// path is the upstream API path (Gemini carries the model in it)
+	ParseRequest(path string, body []byte) (*ParsedRequest, error)
+	// ResponseText extracts the generated text from a non-streaming response body
+	ResponseText(body []byte) (string, error)
+	// StreamDelta extracts the generated text from one SSE data payload
+	StreamDelta(data []byte) string
+}
+
+// registry holds the supported providers by name
+var registry = map[string]Provider{
+	"openai":    OpenAI{},
+	"anthropic": Anthropic{},
+	"gemini":    Gemini{},
+}
+
+// Lookup returns the provider registered under name
+func Lookup(name string) (Provider, bool) {
+	p, ok := registry[name]
+	return p, ok
+}
+
+// Detect guesses the provider from an upstream API path
+// Anything unrecognized is treated as OpenAI-compatible
+func Detect(path string) Provider {
+	switch {
+	case strings.HasSuffix(path, "/v1/messages"):
+		return Anthropic{}
+	case strings.Contains(path, ":generateContent"), strings.Contains(path, ":streamGenerateContent"):
+		return Gemini{}
+	default:
+		return OpenAI{}
+	}
+}
+
+// StreamCollector accumulates generated text from a server-sent events stream
+// It is an io.Writer so raw upstream chunks can be teed into it as they are relayed
+type StreamCollector struct {
+	provider Provider
+	pending  []byte
+	text     strings.Builder
+}
+
+// NewStreamCollector creates a collector decoding events with provider
+func NewStreamCollector(provider Provider) *StreamCollector {
+	return &StreamCollector{provider: provider}
+}
+
+// Write consumes a chunk of the stream; events may span chunk boundaries
+func (c *StreamCollector) Write(p []byte) (int, error) {
+	c.pending = append(c.pending, p...)
+	for {
+		i := bytes.IndexByte(c.pending, '\n')
+		if i < 0 {
+			break
+		}
+		c.line(c.pending[:i])
+		c.pending = c.pending[i+1:]
+	}
+	return len(p), nil
+}
+
+// Text returns the text generated so far, including an unterminated final line
+func (c *StreamCollector) Text() string {
+	if len(c.pending) > 0 {
+		c.line(c.pending)
+		c.pending = nil
+	}
+	return c.text.String()
+}
+
+// line handles one SSE line; only data fields carry payloads we care about
+func (c *StreamCollector) line(line []byte) {
+	line = bytes.TrimRight(line, "\r")
+	data, ok := bytes.CutPrefix(line, []byte("data:"))
+	if !ok {
+		return
+	}
+	data = bytes.TrimSpace(data)
+	if len(data) == 0 || string(data) == "[DONE]" {
+		return
+	}
+	c.text.WriteString(c.provider.StreamDelta(data))
+}
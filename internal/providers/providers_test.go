@@ -0,0 +1,101 @@
+package providers
+
+import (
+	"testing"
+)
+
+func TestParseRequest(t *testing.T) {
+	tests := []struct {
+		name      string
+		provider  Provider
+		path      string
+		body      string
+		wantModel string
+		wantRoles []string
+	}{
+		{
+			name:      "anthropic system, text and tool result",
+			provider:  Anthropic{},
+			path:      "/v1/messages",
+			body:      `{"model":"claude-x","system":"be nice","messages":[{"role":"user","content":"hi"},{"role":"assistant","content":[{"type":"tool_use","id":"t1","name":"search","input":{"q":"x"}}]},{"role":"user","content":[{"type":"tool_result","tool_use_id":"t1","content":"result"}]}]}`,
+			wantModel: "claude-x",
+			wantRoles: []string{"system", "user", "assistant", "tool"},
+		},
+		{
+			name:      "gemini model from path",
+			provider:  Gemini{},
+			path:      "/v1beta/models/gemini-pro:streamGenerateContent",
+			body:      `{"systemInstruction":{"parts":[{"text":"rules"}]},"contents":[{"role":"user","parts":[{"text":"hi"}]},{"role":"model","parts":[{"text":"hello"}]}]}`,
+			wantModel: "gemini-pro",
+			wantRoles: []string{"system", "user", "assistant"},
+		},
+		{
+			name:      "openai chat",
+			provider:  OpenAI{},
+			path:      "/v1/chat/completions",
+			body:      `{"model":"gpt-x","messages":[{"role":"user","content":"hi"}]}`,
+			wantModel: "gpt-x",
+			wantRoles: []string{"user"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := tt.provider.ParseRequest(tt.path, []byte(tt.body))
+			if err != nil {
+				t.Fatalf("ParseRequest() error = %v", err)
+			}
+			if parsed.Model != tt.wantModel {
+				t.Errorf("Model = %q, want %q", parsed.Model, tt.wantModel)
+			}
+			if len(parsed.Messages) != len(tt.wantRoles) {
+				t.Fatalf("got %d messages, want %d", len(parsed.Messages), len(tt.wantRoles))
+			}
+			for i, role := range tt.wantRoles {
+				if parsed.Messages[i].Role != role {
+					t.Errorf("Messages[%d].Role = %q, want %q", i, parsed.Messages[i].Role, role)
+				}
+			}
+		})
+	}
+}
+
+func TestStreamCollector(t *testing.T) {
+	tests := []struct {
+		name     string
+		provider Provider
+		chunks   []string
+		want     string
+	}{
+		{
+			name:     "openai chunks split mid-line",
+			provider: OpenAI{},
+			chunks:   []string{"data: {\"choices\":[{\"delta\":{\"content\":\"Hel\"}}]}\n\ndata: {\"choi", "ces\":[{\"delta\":{\"content\":\"lo\"}}]}\n\ndata: [DONE]\n\n"},
+			want:     "Hello",
+		},
+		{
+			name:     "anthropic content_block_delta",
+			provider: Anthropic{},
+			chunks:   []string{"event: message_start\ndata: {\"type\":\"message_start\"}\n\nevent: content_block_delta\r\ndata: {\"type\":\"content_block_delta\",\"delta\":{\"type\":\"text_delta\",\"text\":\"Hi\"}}\r\n\r\n"},
+			want:     "Hi",
+		},
+		{
+			name:     "gemini sse without trailing newline",
+			provider: Gemini{},
+			chunks:   []string{"data: {\"candidates\":[{\"content\":{\"parts\":[{\"text\":\"Hey\"}]}}]}"},
+			want:     "Hey",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collector := NewStreamCollector(tt.provider)
+			for _, chunk := range tt.chunks {
+				collector.Write([]byte(chunk))
+			}
+			if got := collector.Text(); got != tt.want {
+				t.Errorf("Text() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
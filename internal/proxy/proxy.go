@@ -0,0 +1,323 @@
+// Package proxy relays LLM API traffic to upstream providers, evaluating
+// prompts before forwarding and completions before returning them
+package proxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prompt-gateway/internal/api"
+	"github.com/prompt-gateway/internal/maintenance"
+	"github.com/prompt-gateway/internal/providers"
+	"github.com/prompt-gateway/internal/replay"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// maxBodyBytes caps buffered request and non-streaming response bodies
+const maxBodyBytes = 10 << 20
+
+// errBodyTooLarge is returned for bodies over maxBodyBytes; a truncated body
+// would be evaluated and forwarded as if it were complete
+var errBodyTooLarge = errors.New("body exceeds the proxy limit")
+
+// Evaluator is the policy evaluation core shared with the HTTP API
+type Evaluator interface {
+	Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error)
+}
+
+// Upstream is a provider API reachable behind the proxy
+type Upstream struct {
+	Provider providers.Provider
+	BaseURL  *url.URL
+}
+
+// Proxy serves /proxy/{provider}/{path...} by relaying to the provider's upstream
+type Proxy struct {
+	evaluator  Evaluator
+	upstreams  map[string]Upstream
+	httpClient *http.Client
+}
+
+// Headers consumed by the proxy and not forwarded upstream
+var strippedHeaders = []string{"Connection", "Content-Length", "Accept-Encoding", "X-Client-Id", "X-Session-Id", "X-Guardrails-Nonce", "X-Guardrails-Timestamp"}
+
+// NewProxy creates a proxy for the given upstreams
+// httpClient must not set a timeout shorter than the longest expected stream
+func NewProxy(evaluator Evaluator, upstreams []Upstream, httpClient *http.Client) *Proxy {
+	if httpClient == nil {
+		httpClient = &http.Client{}
+	}
+	byName := make(map[string]Upstream, len(upstreams))
+	for _, u := range upstreams {
+		byName[u.Provider.Name()] = u
+	}
+	return &Proxy{evaluator: evaluator, upstreams: byName, httpClient: httpClient}
+}
+
+// ServeHTTP evaluates the prompt, forwards the call and evaluates the completion
+// Streamed completions can't be recalled once relayed, so they are evaluated
+// (and audited) after the stream ends instead of being blocked
+func (p *Proxy) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	upstream, ok := p.upstreams[r.PathValue("provider")]
+	if !ok {
+		respondError(w, http.StatusNotFound, "Unknown provider")
+		return
+	}
+	path := "/" + r.PathValue("path")
+
+	body, err := readLimited(r.Body)
+	if errors.Is(err, errBodyTooLarge) {
+		respondError(w, http.StatusRequestEntityTooLarge, "Request body exceeds the proxy limit")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusBadRequest, "Failed to read request body")
+		return
+	}
+
+	clientID := r.Header.Get("X-Client-ID")
+	if clientID == "" {
+		clientID = "proxy"
+	}
+	requestCtx := &models.RequestContext{SessionID: r.Header.Get("X-Session-ID")}
+
+	// Requests without a body (model listings, ...) or without text content
+	// (embeddings of token arrays, image-only turns) are forwarded as-is
+	parsed := &providers.ParsedRequest{}
+	if len(body) > 0 {
+		parsed, err = upstream.Provider.ParseRequest(path, body)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err.Error())
+			return
+		}
+		requestCtx.Model = parsed.Model
+	}
+
+	if parsed.Prompt != "" || len(parsed.Messages) > 0 {
+		timestamp, _ := strconv.ParseInt(r.Header.Get("X-Guardrails-Timestamp"), 10, 64)
+		analyzeReq := models.AnalyzeRequest{
+			ClientID:  clientID,
+			Prompt:    parsed.Prompt,
+			Messages:  parsed.Messages,
+			Context:   requestCtx,
+			Nonce:     r.Header.Get("X-Guardrails-Nonce"),
+			Timestamp: timestamp,
+		}
+		decision, err := p.evaluator.Evaluate(r.Context(), analyzeReq)
+		if errors.Is(err, maintenance.ErrActive) {
+			respondError(w, http.StatusServiceUnavailable, "Gateway is in maintenance mode")
+			return
+		}
+		if errors.Is(err, replay.ErrReplayed) {
+			respondError(w, http.StatusConflict, "Replayed request: nonce already used")
+			return
+		}
+		if errors.Is(err, api.ErrInvalidRequest) {
+			respondError(w, http.StatusBadRequest, strings.TrimPrefix(err.Error(), api.ErrInvalidRequest.Error()+": "))
+			return
+		}
+		if err != nil {
+			slog.ErrorContext(r.Context(), "Proxy prompt evaluation failed", "provider", upstream.Provider.Name(), "path", path, "error", err)
+			respondError(w, http.StatusBadGateway, "Guardrails evaluation failed")
+			return
+		}
+		setDecisionHeaders(w, "Prompt", decision)
+		if !decision.Allowed {
+			respondBlocked(w, decision)
+			return
+		}
+		if api.Redacted(analyzeReq, decision) {
+			redacted, ok := redactBody(body, parsed, decision)
+			if !ok {
+				// The unredacted prompt must not reach the provider
+				respondBlocked(w, decision)
+				return
+			}
+			body = redacted
+		}
+	}
+
+	target := *upstream.BaseURL
+	target.Path = strings.TrimSuffix(target.Path, "/") + path
+	target.RawQuery = r.URL.RawQuery
+
+	upstreamReq, err := http.NewRequestWithContext(r.Context(), r.Method, target.String(), bytes.NewReader(body))
+	if err != nil {
+		respondError(w, http.StatusInternalServerError, "Failed to build upstream request")
+		return
+	}
+	upstreamReq.Header = r.Header.Clone()
+	for _, h := range strippedHeaders {
+		upstreamReq.Header.Del(h)
+	}
+
+	resp, err := p.httpClient.Do(upstreamReq)
+	if err != nil {
+		slog.ErrorContext(r.Context(), "Proxy upstream request failed", "provider", upstream.Provider.Name(), "path", path, "error", err)
+		respondError(w, http.StatusBadGateway, "Upstream request failed")
+		return
+	}
+	defer resp.Body.Close()
+
+	copyHeaders(w.Header(), resp.Header)
+
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "text/event-stream") {
+		p.relayStream(w, r, resp, upstream.Provider, clientID, requestCtx)
+		return
+	}
+
+	respBody, err := readLimited(resp.Body)
+	if errors.Is(err, errBodyTooLarge) {
+		respondError(w, http.StatusBadGateway, "Upstream response exceeds the proxy limit")
+		return
+	}
+	if err != nil {
+		respondError(w, http.StatusBadGateway, "Failed to read upstream response")
+		return
+	}
+
+	if resp.StatusCode < 300 {
+		if text, err := upstream.Provider.ResponseText(respBody); err == nil && text != "" {
+			decision, err := p.evaluateCompletion(r.Context(), clientID, requestCtx, text)
+			if err != nil {
+				slog.ErrorContext(r.Context(), "Proxy completion evaluation failed", "provider", upstream.Provider.Name(), "path", path, "error", err)
+				respondError(w, http.StatusBadGateway, "Guardrails evaluation failed")
+				return
+			}
+			setDecisionHeaders(w, "Response", decision)
+			if !decision.Allowed {
+				respondBlocked(w, decision)
+				return
+			}
+		}
+	}
+
+	w.Header().Del("Content-Length")
+	w.WriteHeader(resp.StatusCode)
+	w.Write(respBody)
+}
+
+// relayStream forwards an SSE response chunk by chunk while collecting the
+// generated text, then evaluates the completion for audit and alerting
+func (p *Proxy) relayStream(w http.ResponseWriter, r *http.Request, resp *http.Response, provider providers.Provider, clientID string, requestCtx *models.RequestContext) {
+	// Streams outlive the server's write timeout
+	rc := http.NewResponseController(w)
+	rc.SetWriteDeadline(time.Time{})
+
+	w.Header().Del("Content-Length")
+	w.WriteHeader(resp.StatusCode)
+
+	collector := providers.NewStreamCollector(provider)
+	buf := make([]byte, 32*1024)
+	for {
+		n, err := resp.Body.Read(buf)
+		if n > 0 {
+			collector.Write(buf[:n])
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return
+			}
+			rc.Flush()
+		}
+		if err != nil {
+			if err != io.EOF {
+				slog.WarnContext(r.Context(), "Proxy stream interrupted", "provider", provider.Name(), "error", err)
+			}
+			break
+		}
+	}
+
+	text := collector.Text()
+	if text == "" || resp.StatusCode >= 300 {
+		return
+	}
+	// The client may already be gone; evaluate detached from its context
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(r.Context()), 30*time.Second)
+	defer cancel()
+	decision, err := p.evaluateCompletion(ctx, clientID, requestCtx, text)
+	if err != nil {
+		slog.ErrorContext(ctx, "Proxy stream evaluation failed", "provider", provider.Name(), "error", err)
+		return
+	}
+	if !decision.Allowed {
+		slog.WarnContext(ctx, "Streamed completion violated policy after delivery", "provider", provider.Name(), "request_id", decision.RequestID, "client_id", clientID)
+	}
+}
+
+// readLimited reads a whole body of at most maxBodyBytes
+func readLimited(body io.Reader) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(body, maxBodyBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxBodyBytes {
+		return nil, errBodyTooLarge
+	}
+	return data, nil
+}
+
+// evaluateCompletion runs the generated text through the policies as an assistant turn
+func (p *Proxy) evaluateCompletion(ctx context.Context, clientID string, requestCtx *models.RequestContext, text string) (*models.AnalyzeResponse, error) {
+	return p.evaluator.Evaluate(ctx, models.AnalyzeRequest{
+		ClientID: clientID,
+		Messages: []models.ChatMessage{{Role: "assistant", Content: text}},
+		Context:  requestCtx,
+	})
+}
+
+// copyHeaders copies upstream response headers, skipping hop-by-hop ones
+func copyHeaders(dst, src http.Header) {
+	for key, values := range src {
+		switch key {
+		case "Connection", "Keep-Alive", "Transfer-Encoding", "Upgrade":
+			continue
+		}
+		dst[key] = values
+	}
+}
+
+// setDecisionHeaders exposes the guardrails decision for a phase (Prompt/Response)
+func setDecisionHeaders(w http.ResponseWriter, phase string, decision *models.AnalyzeResponse) {
+	w.Header().Set(fmt.Sprintf("X-Guardrails-%s-Request-ID", phase), decision.RequestID.String())
+	w.Header().Set(fmt.Sprintf("X-Guardrails-%s-Action", phase), decision.Action)
+	if decision.PolicyHash != "" {
+		w.Header().Set(fmt.Sprintf("X-Guardrails-%s-Policy-Hash", phase), decision.PolicyHash)
+	}
+}
+
+// respondBlocked returns a provider-agnostic error body describing the block
+func respondBlocked(w http.ResponseWriter, decision *models.AnalyzeResponse) {
+	respondJSON(w, http.StatusForbidden, map[string]interface{}{
+		"error": map[string]interface{}{
+			"type":               "guardrails_blocked",
+			"message":            "Request blocked by guardrails policy",
+			"request_id":         decision.RequestID,
+			"triggered_policies": decision.TriggeredPolicies,
+		},
+	})
+}
+
+// respondJSON writes a JSON response
+func respondJSON(w http.ResponseWriter, status int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Del("Content-Length")
+	w.Header().Del("Content-Encoding")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(data)
+}
+
+// respondError writes an error in the same {"error": {...}} shape providers use
+func respondError(w http.ResponseWriter, status int, message string) {
+	respondJSON(w, status, map[string]interface{}{
+		"error": map[string]string{"type": "proxy_error", "message": message},
+	})
+}
@@ -0,0 +1,200 @@
+package proxy
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/api"
+	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/internal/providers"
+	"github.com/prompt-gateway/pkg/guardrailstest"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// evaluatorFunc adapts a function to Evaluator
+type evaluatorFunc func(req models.AnalyzeRequest) *models.AnalyzeResponse
+
+func (f evaluatorFunc) Evaluate(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	return f(req), nil
+}
+
+// allowAll allows every prompt and completion
+var allowAll = evaluatorFunc(func(models.AnalyzeRequest) *models.AnalyzeResponse {
+	return &models.AnalyzeResponse{Allowed: true, Action: "log"}
+})
+
+// newEvaluator returns the gateway's evaluation core enforcing policies
+func newEvaluator(t *testing.T, policies ...models.CreatePolicyRequest) *api.Handler {
+	t.Helper()
+	repo := guardrailstest.NewPolicyRepository()
+	for _, req := range policies {
+		if _, err := repo.Create(context.Background(), req); err != nil {
+			t.Fatalf("Create(%q) error = %v", req.Name, err)
+		}
+	}
+	policyCache := cache.NewPolicyCache(repo)
+	if err := policyCache.Invalidate(context.Background()); err != nil {
+		t.Fatalf("Invalidate() error = %v", err)
+	}
+	analyzerSvc := analyzer.NewAnalyzer(nil)
+	analyzerSvc.SetPatternSource(policyCache)
+	return api.NewHandler(repo, policyCache, analyzerSvc, guardrailstest.NewAuditSink(), nil, nil, clients.NewRegistry(nil, time.Minute), nil)
+}
+
+const completion = `{"choices": [{"message": {"role": "assistant", "content": "Hello there"}}]}`
+
+// newTestProxy serves a proxy to an OpenAI upstream answering with respBody,
+// and returns the request bodies the upstream received
+func newTestProxy(t *testing.T, evaluator Evaluator, respBody string) (*httptest.Server, *[]string) {
+	t.Helper()
+	var received []string
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received = append(received, string(body))
+		w.Header().Set("Content-Type", "application/json")
+		io.WriteString(w, respBody)
+	}))
+	t.Cleanup(upstream.Close)
+
+	baseURL, _ := url.Parse(upstream.URL)
+	provider, _ := providers.Lookup("openai")
+	mux := http.NewServeMux()
+	mux.Handle("/proxy/{provider}/{path...}", NewProxy(evaluator, []Upstream{{Provider: provider, BaseURL: baseURL}}, nil))
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return server, &received
+}
+
+func post(t *testing.T, server *httptest.Server, path, body string) *http.Response {
+	t.Helper()
+	resp, err := http.Post(server.URL+path, "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("POST %s: %v", path, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+const chatBody = `{"model": "gpt-4o", "messages": [{"role": "user", "content": "my ssn is 123-45-6789"}]}`
+
+func TestProxy_ForwardsAllowedCalls(t *testing.T) {
+	server, received := newTestProxy(t, allowAll, completion)
+	resp := post(t, server, "/proxy/openai/v1/chat/completions", chatBody)
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK || string(body) != completion {
+		t.Fatalf("response = %d %s, want the upstream completion", resp.StatusCode, body)
+	}
+	if len(*received) != 1 || (*received)[0] != chatBody {
+		t.Errorf("upstream received %v, want the original body", *received)
+	}
+	if resp.Header.Get("X-Guardrails-Prompt-Action") != "log" || resp.Header.Get("X-Guardrails-Response-Action") != "log" {
+		t.Errorf("decision headers = %v", resp.Header)
+	}
+}
+
+func TestProxy_BlocksPromptsAndCompletions(t *testing.T) {
+	tests := []struct {
+		name         string
+		blockRole    string // Role of the evaluated message to block
+		wantUpstream int
+	}{
+		{"prompt", "user", 0},
+		{"completion", "assistant", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator := evaluatorFunc(func(req models.AnalyzeRequest) *models.AnalyzeResponse {
+				if req.Messages[0].Role == tt.blockRole {
+					return &models.AnalyzeResponse{Allowed: false, Action: "block"}
+				}
+				return &models.AnalyzeResponse{Allowed: true, Action: "log"}
+			})
+			server, received := newTestProxy(t, evaluator, completion)
+			resp := post(t, server, "/proxy/openai/v1/chat/completions", chatBody)
+			if resp.StatusCode != http.StatusForbidden {
+				t.Errorf("status = %d, want 403", resp.StatusCode)
+			}
+			if len(*received) != tt.wantUpstream {
+				t.Errorf("upstream calls = %d, want %d", len(*received), tt.wantUpstream)
+			}
+		})
+	}
+}
+
+func TestProxy_ForwardsRedactedPrompts(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string // Content the upstream must receive ("" = not forwarded)
+	}{
+		{name: "message", body: chatBody, want: "my ssn is [REDACTED]"},
+		{
+			name: "prompt",
+			body: `{"model": "gpt-3.5-turbo-instruct", "prompt": "my ssn is 123-45-6789", "max_tokens": 16}`,
+			want: "my ssn is [REDACTED]",
+		},
+		{
+			name: "prompt that can't be placed",
+			body: `{"model": "gpt-3.5-turbo-instruct", "prompt": ["my ssn", "is 123-45-6789"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			evaluator := newEvaluator(t, models.CreatePolicyRequest{
+				Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "redact",
+			})
+			server, received := newTestProxy(t, evaluator, completion)
+			resp := post(t, server, "/proxy/openai/v1/chat/completions", tt.body)
+			if tt.want == "" {
+				if resp.StatusCode != http.StatusForbidden || len(*received) != 0 {
+					t.Fatalf("status = %d, upstream calls = %d; want 403 and none", resp.StatusCode, len(*received))
+				}
+				return
+			}
+			if resp.StatusCode != http.StatusOK || len(*received) != 1 {
+				t.Fatalf("status = %d, upstream calls = %d; want 200 and one", resp.StatusCode, len(*received))
+			}
+			forwarded := (*received)[0]
+			if strings.Contains(forwarded, "123-45-6789") || !strings.Contains(forwarded, tt.want) {
+				t.Errorf("upstream received %s, want the redacted content", forwarded)
+			}
+			var doc map[string]interface{}
+			if err := json.Unmarshal([]byte(forwarded), &doc); err != nil || doc["model"] == nil {
+				t.Errorf("upstream received %s, want the rest of the body intact", forwarded)
+			}
+		})
+	}
+}
+
+func TestProxy_RejectsOversizedBodies(t *testing.T) {
+	large := `{"model": "gpt-4o", "messages": [{"role": "user", "content": "` + strings.Repeat("a", maxBodyBytes) + `"}]}`
+
+	server, received := newTestProxy(t, allowAll, completion)
+	if resp := post(t, server, "/proxy/openai/v1/chat/completions", large); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("oversized request status = %d, want 413", resp.StatusCode)
+	}
+	if len(*received) != 0 {
+		t.Errorf("upstream calls = %d, want none", len(*received))
+	}
+
+	server, _ = newTestProxy(t, allowAll, `{"choices": [{"message": {"content": "`+strings.Repeat("a", maxBodyBytes)+`"}}]}`)
+	if resp := post(t, server, "/proxy/openai/v1/chat/completions", chatBody); resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("oversized response status = %d, want 502", resp.StatusCode)
+	}
+}
+
+func TestProxy_UnknownProvider(t *testing.T) {
+	server, _ := newTestProxy(t, allowAll, completion)
+	if resp := post(t, server, "/proxy/unknown/v1/chat/completions", chatBody); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("status = %d, want 404", resp.StatusCode)
+	}
+}
@@ -0,0 +1,68 @@
+package proxy
+
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/prompt-gateway/internal/providers"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// redactBody rewrites a provider request body so every redacted prompt or
+// message replaces its original text. Bodies are provider-specific, so the
+// original strings are replaced wherever they appear as JSON string values;
+// false when some redacted text couldn't be placed (e.g. a prompt joined from a
+// list), since the body must then not be forwarded
+func redactBody(body []byte, parsed *providers.ParsedRequest, decision *models.AnalyzeResponse) ([]byte, bool) {
+	replacements := make(map[string]string)
+	if decision.RedactedPrompt != "" && decision.RedactedPrompt != parsed.Prompt {
+		replacements[parsed.Prompt] = decision.RedactedPrompt
+	}
+	for _, verdict := range decision.MessageResults {
+		if verdict.RedactedContent == "" || verdict.Index >= len(parsed.Messages) {
+			continue
+		}
+		replacements[parsed.Messages[verdict.Index].Content] = verdict.RedactedContent
+	}
+	if len(replacements) == 0 {
+		return body, true
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber() // Keep numbers (seeds, token limits) exactly as sent
+	var doc interface{}
+	if err := decoder.Decode(&doc); err != nil {
+		return nil, false
+	}
+	replaced := make(map[string]bool, len(replacements))
+	doc = replaceStrings(doc, replacements, replaced)
+	if len(replaced) != len(replacements) {
+		return nil, false
+	}
+	redacted, err := json.Marshal(doc)
+	if err != nil {
+		return nil, false
+	}
+	return redacted, true
+}
+
+// replaceStrings swaps the string values of v found in replacements, noting
+// which ones it replaced
+func replaceStrings(v interface{}, replacements map[string]string, replaced map[string]bool) interface{} {
+	switch value := v.(type) {
+	case string:
+		if r, ok := replacements[value]; ok {
+			replaced[value] = true
+			return r
+		}
+	case map[string]interface{}:
+		for key, item := range value {
+			value[key] = replaceStrings(item, replacements, replaced)
+		}
+	case []interface{}:
+		for i, item := range value {
+			value[i] = replaceStrings(item, replacements, replaced)
+		}
+	}
+	return v
+}
@@ -0,0 +1,53 @@
+// Package replay rejects analyze calls that reuse a client nonce, so captured
+// requests can't be replayed to probe policies or duplicate audit entries
+package replay
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// nonceKeyPrefix namespaces seen nonces in Redis
+const nonceKeyPrefix = "nonce:"
+
+// Replay protection errors
+var (
+	ErrReplayed = errors.New("nonce already used")
+	ErrStale    = errors.New("timestamp outside replay window")
+)
+
+// Guard remembers nonces for the replay window
+// Requests must carry a timestamp within ±window of the gateway clock, so a
+// nonce only has to be remembered for 2×window to make replays impossible
+type Guard struct {
+	rdb    *redis.Client
+	window time.Duration
+	now    func() time.Time
+}
+
+// NewGuard creates a replay guard with the given window
+func NewGuard(rdb *redis.Client, window time.Duration) *Guard {
+	return &Guard{rdb: rdb, window: window, now: time.Now}
+}
+
+// Check records a client's nonce, failing if it was seen before or the
+// timestamp (unix seconds) is too far from now
+func (g *Guard) Check(ctx context.Context, clientID, nonce string, timestamp int64) error {
+	skew := g.now().Sub(time.Unix(timestamp, 0))
+	if skew > g.window || skew < -g.window {
+		return ErrStale
+	}
+
+	fresh, err := g.rdb.SetNX(ctx, nonceKeyPrefix+clientID+":"+nonce, timestamp, 2*g.window).Result()
+	if err != nil {
+		return fmt.Errorf("failed to record nonce: %w", err)
+	}
+	if !fresh {
+		return ErrReplayed
+	}
+	return nil
+}
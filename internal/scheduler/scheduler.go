@@ -0,0 +1,194 @@
+// Package scheduler admits analyze requests into a fixed number of evaluation
+// slots, serving interactive traffic ahead of batch traffic
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// Request priorities
+const (
+	PriorityInteractive = "interactive"
+	PriorityBatch       = "batch"
+)
+
+// ErrQueueFull is returned when too many batch requests are already waiting
+var ErrQueueFull = errors.New("batch queue full")
+
+// ValidPriority reports whether p is a known priority (empty means interactive)
+func ValidPriority(p string) bool {
+	return p == "" || p == PriorityInteractive || p == PriorityBatch
+}
+
+// waiter is a queued request; ready is closed once it holds a slot
+type waiter struct {
+	ready   chan struct{}
+	granted bool
+}
+
+// Scheduler is a two-class semaphore: a freed slot always goes to the oldest
+// interactive waiter first, and batch requests may hold at most batchLimit slots
+// so interactive requests never queue behind a backfill
+type Scheduler struct {
+	capacity   int
+	batchLimit int
+	maxQueue   int // Batch requests allowed to wait
+
+	mu           sync.Mutex // Protects the fields below
+	running      int
+	batchRunning int
+	interactive  []*waiter
+	batch        []*waiter
+}
+
+// New creates a scheduler with capacity slots, of which batch requests may hold
+// batchLimit, and at most maxQueue waiting batch requests
+func New(capacity, batchLimit, maxQueue int) *Scheduler {
+	if batchLimit <= 0 || batchLimit > capacity {
+		batchLimit = capacity
+	}
+	return &Scheduler{capacity: capacity, batchLimit: batchLimit, maxQueue: maxQueue}
+}
+
+// Acquire waits for a slot for a request of the given priority and returns the
+// function releasing it. A nil scheduler admits everything immediately
+func (s *Scheduler) Acquire(ctx context.Context, priority string) (func(), error) {
+	if s == nil {
+		return func() {}, nil
+	}
+	batch := priority == PriorityBatch
+	label := PriorityInteractive
+	if batch {
+		label = PriorityBatch
+	}
+	start := time.Now()
+
+	s.mu.Lock()
+	if s.canRun(batch) {
+		s.take(batch)
+		s.mu.Unlock()
+		metrics.SchedulerWaitSeconds.WithLabelValues(label).Observe(0)
+		return s.releaser(batch), nil
+	}
+	if batch && s.maxQueue > 0 && len(s.batch) >= s.maxQueue {
+		s.mu.Unlock()
+		metrics.SchedulerRejectedTotal.Inc()
+		return nil, ErrQueueFull
+	}
+	w := &waiter{ready: make(chan struct{})}
+	if batch {
+		s.batch = append(s.batch, w)
+	} else {
+		s.interactive = append(s.interactive, w)
+	}
+	s.updateQueueMetrics()
+	s.mu.Unlock()
+
+	select {
+	case <-w.ready:
+		metrics.SchedulerWaitSeconds.WithLabelValues(label).Observe(time.Since(start).Seconds())
+		return s.releaser(batch), nil
+	case <-ctx.Done():
+		s.mu.Lock()
+		if w.granted {
+			// Granted while giving up: hand the slot on
+			s.mu.Unlock()
+			s.releaser(batch)()
+		} else {
+			s.remove(w, batch)
+			s.updateQueueMetrics()
+			s.mu.Unlock()
+		}
+		return nil, ctx.Err()
+	}
+}
+
+// UnderLoad reports whether every slot is taken or requests are waiting
+func (s *Scheduler) UnderLoad() bool {
+	if s == nil {
+		return false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.running >= s.capacity || len(s.interactive) > 0 || len(s.batch) > 0
+}
+
+// canRun reports whether a new request may start now; callers hold mu
+// Batch requests also wait while any interactive request is queued
+func (s *Scheduler) canRun(batch bool) bool {
+	if s.running >= s.capacity {
+		return false
+	}
+	if batch {
+		return len(s.interactive) == 0 && len(s.batch) == 0 && s.batchRunning < s.batchLimit
+	}
+	return len(s.interactive) == 0
+}
+
+// take claims a slot; callers hold mu
+func (s *Scheduler) take(batch bool) {
+	s.running++
+	if batch {
+		s.batchRunning++
+	}
+}
+
+// releaser returns a function freeing the slot exactly once
+func (s *Scheduler) releaser(batch bool) func() {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.mu.Lock()
+			s.running--
+			if batch {
+				s.batchRunning--
+			}
+			s.dispatch()
+			s.updateQueueMetrics()
+			s.mu.Unlock()
+		})
+	}
+}
+
+// dispatch hands free slots to waiters, interactive first; callers hold mu
+func (s *Scheduler) dispatch() {
+	for s.running < s.capacity && len(s.interactive) > 0 {
+		w := s.interactive[0]
+		s.interactive = s.interactive[1:]
+		s.take(false)
+		w.granted = true
+		close(w.ready)
+	}
+	for s.running < s.capacity && len(s.batch) > 0 && s.batchRunning < s.batchLimit {
+		w := s.batch[0]
+		s.batch = s.batch[1:]
+		s.take(true)
+		w.granted = true
+		close(w.ready)
+	}
+}
+
+// remove drops an abandoned waiter from its queue; callers hold mu
+func (s *Scheduler) remove(w *waiter, batch bool) {
+	queue := &s.interactive
+	if batch {
+		queue = &s.batch
+	}
+	for i, q := range *queue {
+		if q == w {
+			*queue = append((*queue)[:i], (*queue)[i+1:]...)
+			return
+		}
+	}
+}
+
+// updateQueueMetrics exports queue lengths; callers hold mu
+func (s *Scheduler) updateQueueMetrics() {
+	metrics.SchedulerQueueLength.WithLabelValues(PriorityInteractive).Set(float64(len(s.interactive)))
+	metrics.SchedulerQueueLength.WithLabelValues(PriorityBatch).Set(float64(len(s.batch)))
+}
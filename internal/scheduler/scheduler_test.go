@@ -0,0 +1,89 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestScheduler_InteractiveFirst(t *testing.T) {
+	s := New(1, 1, 10)
+	ctx := context.Background()
+
+	release, err := s.Acquire(ctx, PriorityBatch)
+	if err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+
+	order := make(chan string, 2)
+	go func() {
+		r, _ := s.Acquire(ctx, PriorityBatch)
+		order <- PriorityBatch
+		r()
+	}()
+	waitQueued(t, s, 0, 1)
+	go func() {
+		r, _ := s.Acquire(ctx, PriorityInteractive)
+		order <- PriorityInteractive
+		r()
+	}()
+	waitQueued(t, s, 1, 1)
+
+	if !s.UnderLoad() {
+		t.Error("UnderLoad() = false with queued requests, want true")
+	}
+	release()
+
+	if first := <-order; first != PriorityInteractive {
+		t.Errorf("first admitted = %s, want interactive ahead of the earlier batch request", first)
+	}
+	<-order
+}
+
+func TestScheduler_BatchLimitReservesSlots(t *testing.T) {
+	s := New(2, 1, 10)
+	ctx := context.Background()
+
+	if _, err := s.Acquire(ctx, PriorityBatch); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	short, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	if _, err := s.Acquire(short, PriorityBatch); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("second batch Acquire() error = %v, want deadline exceeded", err)
+	}
+	if _, err := s.Acquire(ctx, PriorityInteractive); err != nil {
+		t.Errorf("interactive Acquire() error = %v, want the reserved slot", err)
+	}
+}
+
+func TestScheduler_QueueFull(t *testing.T) {
+	s := New(1, 1, 1)
+	ctx := context.Background()
+	if _, err := s.Acquire(ctx, PriorityInteractive); err != nil {
+		t.Fatalf("Acquire() error = %v", err)
+	}
+	go func() { s.Acquire(ctx, PriorityBatch) }() // Waits until the test ends
+	waitQueued(t, s, 0, 1)
+
+	if _, err := s.Acquire(ctx, PriorityBatch); !errors.Is(err, ErrQueueFull) {
+		t.Errorf("Acquire() error = %v, want ErrQueueFull", err)
+	}
+}
+
+// waitQueued waits until the queues reach the given lengths
+func waitQueued(t *testing.T, s *Scheduler, interactive, batch int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.mu.Lock()
+		ok := len(s.interactive) == interactive && len(s.batch) == batch
+		s.mu.Unlock()
+		if ok {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("queues did not reach interactive=%d batch=%d", interactive, batch)
+}
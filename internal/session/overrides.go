@@ -0,0 +1,70 @@
+// Package session holds state that spans the requests of a conversation
+package session
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+	"github.com/redis/go-redis/v9"
+)
+
+// overrideKeyPrefix namespaces pinned session decisions in Redis
+const overrideKeyPrefix = "session_override:"
+
+// OverrideStore keeps sticky per-session decisions in Redis so every gateway
+// replica sees them; expiry is delegated to the key TTL
+type OverrideStore struct {
+	rdb *redis.Client
+}
+
+// NewOverrideStore creates a new override store
+func NewOverrideStore(rdb *redis.Client) *OverrideStore {
+	return &OverrideStore{rdb: rdb}
+}
+
+// Set pins a decision for a session for ttl, replacing any existing override
+func (s *OverrideStore) Set(ctx context.Context, override models.SessionOverride, ttl time.Duration) error {
+	if ttl <= 0 {
+		return fmt.Errorf("override ttl must be positive")
+	}
+	override.CreatedAt = time.Now()
+	override.ExpiresAt = override.CreatedAt.Add(ttl)
+
+	data, err := json.Marshal(override)
+	if err != nil {
+		return fmt.Errorf("failed to marshal session override: %w", err)
+	}
+	if err := s.rdb.Set(ctx, overrideKeyPrefix+override.SessionID, data, ttl).Err(); err != nil {
+		return fmt.Errorf("failed to store session override: %w", err)
+	}
+	return nil
+}
+
+// Get returns the active override for a session, or nil when none is pinned
+func (s *OverrideStore) Get(ctx context.Context, sessionID string) (*models.SessionOverride, error) {
+	data, err := s.rdb.Get(ctx, overrideKeyPrefix+sessionID).Bytes()
+	if err == redis.Nil {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load session override: %w", err)
+	}
+
+	var override models.SessionOverride
+	if err := json.Unmarshal(data, &override); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal session override: %w", err)
+	}
+	return &override, nil
+}
+
+// Delete clears a session override; it reports whether one existed
+func (s *OverrideStore) Delete(ctx context.Context, sessionID string) (bool, error) {
+	n, err := s.rdb.Del(ctx, overrideKeyPrefix+sessionID).Result()
+	if err != nil {
+		return false, fmt.Errorf("failed to delete session override: %w", err)
+	}
+	return n > 0, nil
+}
@@ -0,0 +1,254 @@
+// Package shadow mirrors a sample of analyze requests to a second gateway
+// deployment (a new version or policy set) and records where its decisions
+// differ, so upgrades can be validated against production traffic before cutover
+package shadow
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/prompt-gateway/internal/metrics"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Config controls sampling and backpressure
+type Config struct {
+	URL        string        // Base URL of the shadow gateway
+	Token      string        // Bearer token sent to the shadow gateway
+	SampleRate float64       // Fraction of decisions mirrored (0-1)
+	BufferSize int           // Sampled requests queued before new ones are dropped
+	Workers    int           // Concurrent requests to the shadow gateway
+	Timeout    time.Duration // Per-request timeout
+	KeepDiffs  int           // Recent diffs kept for GET /v1/shadow
+}
+
+// job is a sampled request with the primary decision to compare against
+type job struct {
+	requestID uuid.UUID
+	request   models.AnalyzeRequest
+	primary   models.ShadowDecision
+}
+
+// Mirror forwards sampled requests from background workers. The request path
+// never waits on the shadow gateway: when it falls behind, sampled requests are
+// dropped and counted. Shadow responses never affect the primary decision
+type Mirror struct {
+	config     Config
+	httpClient *http.Client
+	jobs       chan job
+	sample     func() float64
+
+	mirrored, matched, diffs, errors, dropped atomic.Int64
+
+	mu     sync.Mutex
+	recent []models.ShadowDiff // Newest last, at most config.KeepDiffs
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewMirror creates a mirror forwarding to config.URL; a nil httpClient uses one
+// with config.Timeout
+func NewMirror(config Config, httpClient *http.Client) *Mirror {
+	if config.Workers <= 0 {
+		config.Workers = 1
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = 10 * time.Second
+	}
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: config.Timeout}
+	}
+	config.URL = strings.TrimRight(config.URL, "/")
+	return &Mirror{
+		config:     config,
+		httpClient: httpClient,
+		jobs:       make(chan job, config.BufferSize),
+		sample:     rand.Float64,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+// Observe queues a sample of decisions for mirroring (implements api.DecisionObserver)
+// Decisions forced by a session override are skipped: policies didn't decide them
+func (m *Mirror) Observe(event models.DecisionEvent) {
+	if event.Request == nil || event.Response == nil || event.Response.Override != nil {
+		return
+	}
+	if m.sample() >= m.config.SampleRate {
+		return
+	}
+	j := job{
+		requestID: event.Audit.RequestID,
+		request:   *event.Request,
+		primary:   decisionOf(event.Response),
+	}
+	// The shadow must evaluate, not answer from its cache or fill it
+	j.request.CacheControl = "no-cache, no-store"
+	select {
+	case m.jobs <- j:
+	default:
+		m.dropped.Add(1)
+		metrics.ShadowRequestsTotal.WithLabelValues("dropped").Inc()
+	}
+}
+
+// decisionOf extracts the compared fields of a response
+func decisionOf(resp *models.AnalyzeResponse) models.ShadowDecision {
+	names := make([]string, 0, len(resp.TriggeredPolicies))
+	for _, m := range resp.TriggeredPolicies {
+		if !slices.Contains(names, m.PolicyName) {
+			names = append(names, m.PolicyName)
+		}
+	}
+	slices.Sort(names)
+	return models.ShadowDecision{
+		Action:    resp.Action,
+		Allowed:   resp.Allowed,
+		Policies:  names,
+		LatencyMs: resp.LatencyMs,
+	}
+}
+
+// Start runs the workers
+func (m *Mirror) Start() {
+	for i := 0; i < m.config.Workers; i++ {
+		m.wg.Add(1)
+		go m.worker()
+	}
+	slog.Info("Shadow traffic mirroring started", "url", m.config.URL, "sample_rate", m.config.SampleRate)
+}
+
+// worker forwards queued requests until stopped; queued requests are abandoned
+// on shutdown since they only validate the shadow deployment
+func (m *Mirror) worker() {
+	defer m.wg.Done()
+	for {
+		select {
+		case j := <-m.jobs:
+			m.compare(j)
+		case <-m.stopChan:
+			return
+		}
+	}
+}
+
+// compare forwards a request and records whether the shadow decided the same
+func (m *Mirror) compare(j job) {
+	ctx, cancel := context.WithTimeout(context.Background(), m.config.Timeout)
+	defer cancel()
+
+	resp, err := m.forward(ctx, j.request)
+	if err != nil {
+		m.errors.Add(1)
+		metrics.ShadowRequestsTotal.WithLabelValues("error").Inc()
+		slog.Warn("Shadow request failed", "request_id", j.requestID, "error", err)
+		return
+	}
+	m.mirrored.Add(1)
+	shadow := decisionOf(resp)
+	metrics.ShadowLatencyDelta.Observe(float64(shadow.LatencyMs-j.primary.LatencyMs) / 1000)
+
+	if shadow.Action == j.primary.Action && shadow.Allowed == j.primary.Allowed && slices.Equal(shadow.Policies, j.primary.Policies) {
+		m.matched.Add(1)
+		metrics.ShadowRequestsTotal.WithLabelValues("match").Inc()
+		return
+	}
+	m.diffs.Add(1)
+	metrics.ShadowRequestsTotal.WithLabelValues("diff").Inc()
+	diff := models.ShadowDiff{
+		RequestID: j.requestID,
+		ClientID:  j.request.ClientID,
+		Primary:   j.primary,
+		Shadow:    shadow,
+		Timestamp: time.Now().UTC(),
+	}
+	slog.Warn("Shadow decision differs", "request_id", diff.RequestID,
+		"primary_action", diff.Primary.Action, "primary_policies", diff.Primary.Policies,
+		"shadow_action", diff.Shadow.Action, "shadow_policies", diff.Shadow.Policies)
+	m.record(diff)
+}
+
+// forward posts the request to the shadow gateway's analyze endpoint
+func (m *Mirror) forward(ctx context.Context, request models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.config.URL+"/v1/analyze", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create shadow request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.config.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+m.config.Token)
+	}
+
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("shadow request failed: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("shadow gateway returned status %d", resp.StatusCode)
+	}
+	var decision models.AnalyzeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decision); err != nil {
+		return nil, fmt.Errorf("invalid shadow response: %w", err)
+	}
+	return &decision, nil
+}
+
+// record keeps a diff among the most recent ones
+func (m *Mirror) record(diff models.ShadowDiff) {
+	if m.config.KeepDiffs <= 0 {
+		return
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.recent) >= m.config.KeepDiffs {
+		m.recent = slices.Delete(m.recent, 0, len(m.recent)-m.config.KeepDiffs+1)
+	}
+	m.recent = append(m.recent, diff)
+}
+
+// Status reports the comparison counts and the most recent diffs
+func (m *Mirror) Status() models.ShadowStatus {
+	m.mu.Lock()
+	recent := make([]models.ShadowDiff, len(m.recent))
+	for i, diff := range m.recent {
+		recent[len(m.recent)-1-i] = diff
+	}
+	m.mu.Unlock()
+	return models.ShadowStatus{
+		URL:        m.config.URL,
+		SampleRate: m.config.SampleRate,
+		Mirrored:   m.mirrored.Load(),
+		Matched:    m.matched.Load(),
+		Diffs:      m.diffs.Load(),
+		Errors:     m.errors.Load(),
+		Dropped:    m.dropped.Load(),
+		Recent:     recent,
+	}
+}
+
+// Stop stops the workers after their in-flight requests
+func (m *Mirror) Stop() {
+	m.stopOnce.Do(func() {
+		close(m.stopChan)
+		m.wg.Wait()
+		slog.Info("Shadow traffic mirroring stopped")
+	})
+}
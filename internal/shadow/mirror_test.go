@@ -0,0 +1,87 @@
+package shadow
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func event(prompt, action string, policies ...string) models.DecisionEvent {
+	resp := &models.AnalyzeResponse{Allowed: action != "block", Action: action, TriggeredPolicies: []models.PolicyMatch{}}
+	for _, name := range policies {
+		resp.TriggeredPolicies = append(resp.TriggeredPolicies, models.PolicyMatch{PolicyID: uuid.New(), PolicyName: name})
+	}
+	return models.DecisionEvent{
+		Audit:    models.AuditLog{RequestID: uuid.New(), ClientID: "svc", ActionTaken: action},
+		Response: resp,
+		Request:  &models.AnalyzeRequest{ClientID: "svc", Prompt: prompt},
+	}
+}
+
+func TestMirror_RecordsDiffs(t *testing.T) {
+	// The shadow blocks anything mentioning "secret"; the primary only blocks "password"
+	shadowGateway := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req models.AnalyzeRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if r.URL.Path != "/v1/analyze" || r.Header.Get("Authorization") != "Bearer tok" || req.CacheControl != "no-cache, no-store" {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		resp := event(req.Prompt, "allow").Response
+		if req.Prompt == "secret" {
+			resp = event(req.Prompt, "block", "secrets").Response
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+	defer shadowGateway.Close()
+
+	m := NewMirror(Config{URL: shadowGateway.URL + "/", Token: "tok", SampleRate: 1, BufferSize: 10, KeepDiffs: 1}, nil)
+	m.Observe(event("hello", "allow"))
+	m.Observe(event("secret", "allow"))
+	m.Observe(event("secret", "allow"))
+	overridden := event("hello", "block")
+	overridden.Response.Override = &models.SessionOverride{Action: "block"}
+	m.Observe(overridden)
+	m.Start()
+
+	deadline := time.Now().Add(2 * time.Second)
+	for m.Status().Mirrored < 3 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	m.Stop()
+
+	status := m.Status()
+	if status.Mirrored != 3 || status.Matched != 1 || status.Diffs != 2 || status.Errors != 0 {
+		t.Fatalf("Status() = %+v, want 3 mirrored, 1 matched, 2 diffs", status)
+	}
+	if len(status.Recent) != 1 {
+		t.Fatalf("Recent = %d diffs, want 1 kept", len(status.Recent))
+	}
+	diff := status.Recent[0]
+	if diff.Primary.Action != "allow" || diff.Shadow.Action != "block" || len(diff.Shadow.Policies) != 1 || diff.Shadow.Policies[0] != "secrets" {
+		t.Errorf("diff = %+v, want allow → block by secrets", diff)
+	}
+}
+
+func TestMirror_SamplesAndDrops(t *testing.T) {
+	m := NewMirror(Config{URL: "http://shadow.invalid", SampleRate: 0.5, BufferSize: 1}, nil)
+	samples := []float64{0.7, 0.1, 0.2}
+	m.sample = func() float64 {
+		s := samples[0]
+		samples = samples[1:]
+		return s
+	}
+	for i := 0; i < 3; i++ {
+		m.Observe(event("hello", "allow"))
+	}
+	// 0.7 is not sampled, 0.1 is queued, 0.2 finds the queue full
+	if len(m.jobs) != 1 || m.Status().Dropped != 1 {
+		t.Errorf("queued %d, dropped %d, want 1 and 1", len(m.jobs), m.Status().Dropped)
+	}
+}
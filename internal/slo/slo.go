@@ -0,0 +1,82 @@
+// Package slo counts analyze requests as good or bad against latency targets, so
+// alerts can fire on error-budget burn rate instead of raw percentiles
+package slo
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+// Target is a latency threshold and the fraction of requests that must meet it
+type Target struct {
+	Threshold time.Duration
+	Objective float64 // e.g. 0.99
+}
+
+// Label is the threshold label value of the target's metrics, e.g. "250ms"
+func (t Target) Label() string {
+	return strconv.FormatInt(t.Threshold.Milliseconds(), 10) + "ms"
+}
+
+// Parse parses "threshold_ms:objective" pairs, e.g. "250:0.99,1000:0.999"
+func Parse(spec string) ([]Target, error) {
+	var targets []Target
+	seen := make(map[time.Duration]bool)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		ms, objective, ok := strings.Cut(item, ":")
+		threshold, err := strconv.Atoi(strings.TrimSpace(ms))
+		if !ok || err != nil || threshold <= 0 {
+			return nil, fmt.Errorf("invalid SLO %q: expected threshold_ms:objective", item)
+		}
+		obj, err := strconv.ParseFloat(strings.TrimSpace(objective), 64)
+		if err != nil || obj <= 0 || obj >= 1 {
+			return nil, fmt.Errorf("invalid SLO %q: objective must be between 0 and 1", item)
+		}
+		t := Target{Threshold: time.Duration(threshold) * time.Millisecond, Objective: obj}
+		if seen[t.Threshold] {
+			return nil, fmt.Errorf("SLO threshold %dms is listed twice", threshold)
+		}
+		seen[t.Threshold] = true
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Tracker classifies completed requests against every target
+type Tracker struct {
+	targets []Target
+}
+
+// NewTracker creates a tracker and exports each target's objective; counters
+// start at zero so burn-rate ratios are defined before the first bad request
+func NewTracker(targets []Target) *Tracker {
+	for _, t := range targets {
+		metrics.AnalyzeSLOObjective.WithLabelValues(t.Label()).Set(t.Objective)
+		metrics.AnalyzeSLORequestsTotal.WithLabelValues(t.Label(), "good").Add(0)
+		metrics.AnalyzeSLORequestsTotal.WithLabelValues(t.Label(), "bad").Add(0)
+	}
+	return &Tracker{targets: targets}
+}
+
+// Observe counts one request: good when it succeeded (status below 500) within
+// the threshold, bad otherwise. A nil tracker ignores it
+func (t *Tracker) Observe(elapsed time.Duration, status int) {
+	if t == nil {
+		return
+	}
+	for _, target := range t.targets {
+		result := "good"
+		if status >= 500 || elapsed > target.Threshold {
+			result = "bad"
+		}
+		metrics.AnalyzeSLORequestsTotal.WithLabelValues(target.Label(), result).Inc()
+	}
+}
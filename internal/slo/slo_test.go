@@ -0,0 +1,52 @@
+package slo
+
+import (
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+func TestParse(t *testing.T) {
+	targets, err := Parse("250:0.99, 1000:0.999")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	if len(targets) != 2 || targets[0].Threshold != 250*time.Millisecond || targets[1].Objective != 0.999 || targets[0].Label() != "250ms" {
+		t.Errorf("Parse() = %+v", targets)
+	}
+	if targets, err := Parse(""); err != nil || len(targets) != 0 {
+		t.Errorf("Parse(\"\") = %v, %v, want no targets", targets, err)
+	}
+	for _, spec := range []string{"250", "abc:0.9", "250:1", "250:0", "-5:0.9", "250:0.9,250:0.99"} {
+		if _, err := Parse(spec); err == nil {
+			t.Errorf("Parse(%q) error = nil, want error", spec)
+		}
+	}
+}
+
+func TestTracker_Observe(t *testing.T) {
+	tracker := NewTracker([]Target{{Threshold: 40 * time.Millisecond, Objective: 0.9}})
+	good := metrics.AnalyzeSLORequestsTotal.WithLabelValues("40ms", "good")
+	bad := metrics.AnalyzeSLORequestsTotal.WithLabelValues("40ms", "bad")
+
+	tracker.Observe(10*time.Millisecond, 200)
+	tracker.Observe(10*time.Millisecond, 400)
+	tracker.Observe(50*time.Millisecond, 200)
+	tracker.Observe(10*time.Millisecond, 503)
+
+	if got := testutil.ToFloat64(good); got != 2 {
+		t.Errorf("good = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(bad); got != 2 {
+		t.Errorf("bad = %v, want 2", got)
+	}
+	if got := testutil.ToFloat64(metrics.AnalyzeSLOObjective.WithLabelValues("40ms")); got != 0.9 {
+		t.Errorf("objective = %v, want 0.9", got)
+	}
+
+	var disabled *Tracker
+	disabled.Observe(time.Second, 500)
+}
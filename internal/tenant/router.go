@@ -0,0 +1,241 @@
+// Package tenant isolates the policies, audit logs and benign corpus of selected
+// tenants in their own Postgres schema or database. A client's tenant is its
+// "tenant" label; clients without one, or whose tenant isn't isolated, use the
+// shared storage
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/audit"
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/envelope"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Label is the client label naming its tenant
+const Label = "tenant"
+
+// schemaName restricts schemas to plain lowercase identifiers so they can be
+// passed as search_path without quoting
+var schemaName = regexp.MustCompile(`^[a-z_][a-z0-9_]{0,62}$`)
+
+// Pool sizes the connection pool opened for each tenant
+type Pool struct {
+	MaxOpen int
+	MaxIdle int
+}
+
+// Storage is one tenant's isolated repositories
+type Storage struct {
+	Name     string
+	DB       *sql.DB
+	Policies *policy.Repository
+	Cache    *cache.PolicyCache
+	Audit    *audit.Repository
+	Benign   *benign.Repository
+	Keys     *envelope.Keyring // Data keys sealing the tenant's retained content; nil stores it in plaintext
+}
+
+// Router resolves tenants to their isolated storage
+type Router struct {
+	tenants map[string]*Storage
+}
+
+// Of returns the tenant of a client ("" when it has none)
+func Of(client models.Client) string {
+	return client.Labels[Label]
+}
+
+// ParseSpec parses "tenant=value,tenant=value" into a map
+func ParseSpec(spec string) (map[string]string, error) {
+	out := make(map[string]string)
+	for _, item := range strings.Split(spec, ",") {
+		item = strings.TrimSpace(item)
+		if item == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(item, "=")
+		name, value = strings.TrimSpace(name), strings.TrimSpace(value)
+		if !ok || name == "" || value == "" {
+			return nil, fmt.Errorf("invalid tenant mapping %q: expected tenant=value", item)
+		}
+		if _, dup := out[name]; dup {
+			return nil, fmt.Errorf("tenant %q is mapped twice", name)
+		}
+		out[name] = value
+	}
+	return out, nil
+}
+
+// SchemaDSN returns baseDSN with its search_path set to schema
+// Both URL and key=value connection strings are supported
+func SchemaDSN(baseDSN, schema string) (string, error) {
+	if !schemaName.MatchString(schema) {
+		return "", fmt.Errorf("invalid schema name %q", schema)
+	}
+	if strings.HasPrefix(baseDSN, "postgres://") || strings.HasPrefix(baseDSN, "postgresql://") {
+		u, err := url.Parse(baseDSN)
+		if err != nil {
+			return "", fmt.Errorf("invalid DATABASE_URL: %w", err)
+		}
+		q := u.Query()
+		q.Set("search_path", schema)
+		u.RawQuery = q.Encode()
+		return u.String(), nil
+	}
+	return baseDSN + " search_path=" + schema, nil
+}
+
+// Open connects every isolated tenant: schemas maps tenants to a schema in the
+// shared database, databases maps tenants to their own connection string
+func Open(baseDSN string, schemas, databases map[string]string, pool Pool) (*Router, error) {
+	r := &Router{tenants: make(map[string]*Storage)}
+	dsns := make(map[string]string)
+	for name, schema := range schemas {
+		dsn, err := SchemaDSN(baseDSN, schema)
+		if err != nil {
+			return nil, fmt.Errorf("tenant %s: %w", name, err)
+		}
+		dsns[name] = dsn
+	}
+	for name, dsn := range databases {
+		if _, dup := dsns[name]; dup {
+			return nil, fmt.Errorf("tenant %s has both a schema and a database", name)
+		}
+		dsns[name] = dsn
+	}
+
+	for name, dsn := range dsns {
+		db, err := sql.Open("postgres", dsn)
+		if err == nil {
+			db.SetMaxOpenConns(pool.MaxOpen)
+			db.SetMaxIdleConns(pool.MaxIdle)
+			db.SetConnMaxLifetime(5 * time.Minute)
+			err = db.Ping()
+		}
+		if err != nil {
+			r.Close()
+			return nil, fmt.Errorf("tenant %s: failed to connect: %w", name, err)
+		}
+		repo := policy.NewRepository(db)
+		r.tenants[name] = &Storage{
+			Name:     name,
+			DB:       db,
+			Policies: repo,
+			Cache:    cache.NewPolicyCache(repo),
+			Audit:    audit.NewRepository(db),
+			Benign:   benign.NewRepository(db),
+		}
+	}
+	return r, nil
+}
+
+// Get returns a tenant's isolated storage; a nil router isolates nobody
+func (r *Router) Get(tenant string) (*Storage, bool) {
+	if r == nil || tenant == "" {
+		return nil, false
+	}
+	s, ok := r.tenants[tenant]
+	return s, ok
+}
+
+// DB returns a tenant's database, or nil when it uses the shared one
+func (r *Router) DB(tenant string) *sql.DB {
+	if s, ok := r.Get(tenant); ok {
+		return s.DB
+	}
+	return nil
+}
+
+// Policy looks up a policy by ID across every tenant's policy cache
+func (r *Router) Policy(id uuid.UUID) (models.Policy, bool) {
+	if r == nil {
+		return models.Policy{}, false
+	}
+	for _, s := range r.tenants {
+		if p, ok := s.Cache.Policy(id); ok {
+			return p, true
+		}
+	}
+	return models.Policy{}, false
+}
+
+// Names lists the isolated tenants
+func (r *Router) Names() []string {
+	if r == nil {
+		return nil
+	}
+	names := make([]string, 0, len(r.tenants))
+	for name := range r.tenants {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SetMaxEnabledPolicies caps the enabled policies of every tenant (0 = unlimited)
+// Must be called before Start
+func (r *Router) SetMaxEnabledPolicies(n int) {
+	for _, s := range r.tenants {
+		s.Policies.SetMaxEnabled(n)
+	}
+}
+
+// SetInvalidator syncs every tenant's policy cache across replicas, scoped by
+// tenant name
+func (r *Router) SetInvalidator(inv *cache.Invalidator) {
+	for name, s := range r.tenants {
+		inv.Register(name, s.Cache)
+	}
+}
+
+// SetContentKeys seals the content each tenant retains under its own data keys,
+// kept in the tenant's storage wrapped by master (previous open keys wrapped
+// before a master key rotation)
+func (r *Router) SetContentKeys(master envelope.Master, previous ...envelope.Master) {
+	for name, s := range r.tenants {
+		s.Keys = envelope.NewKeyring(envelope.NewRepository(s.DB), master, previous...)
+		s.Benign.SetKeyring(s.Keys, name)
+	}
+}
+
+// Start loads and begins refreshing every tenant's policy cache
+func (r *Router) Start(ctx context.Context, dbBreaker *breaker.Breaker) error {
+	for _, name := range r.Names() {
+		s := r.tenants[name]
+		s.Cache.SetBreaker(dbBreaker)
+		if err := s.Cache.Start(ctx); err != nil {
+			return fmt.Errorf("tenant %s: %w", name, err)
+		}
+	}
+	slog.Info("Tenant isolation enabled", "tenants", r.Names())
+	return nil
+}
+
+// Stop stops the policy caches
+func (r *Router) Stop() {
+	for _, s := range r.tenants {
+		s.Cache.Stop()
+	}
+}
+
+// Close closes every tenant connection pool
+func (r *Router) Close() {
+	for _, s := range r.tenants {
+		s.DB.Close()
+	}
+}
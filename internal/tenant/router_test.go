@@ -0,0 +1,80 @@
+package tenant
+
+import (
+	"testing"
+
+	"github.com/prompt-gateway/internal/benign"
+	"github.com/prompt-gateway/internal/envelope"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestParseSpec(t *testing.T) {
+	got, err := ParseSpec(" acme=tenant_acme, globex = tenant_globex ")
+	if err != nil {
+		t.Fatalf("ParseSpec() error = %v", err)
+	}
+	if len(got) != 2 || got["acme"] != "tenant_acme" || got["globex"] != "tenant_globex" {
+		t.Errorf("ParseSpec() = %v", got)
+	}
+
+	for _, bad := range []string{"acme", "acme=", "=x", "a=x,a=y"} {
+		if _, err := ParseSpec(bad); err == nil {
+			t.Errorf("ParseSpec(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestSchemaDSN(t *testing.T) {
+	tests := []struct {
+		base, schema, want string
+		wantErr            bool
+	}{
+		{"postgres://u:p@db:5432/gw?sslmode=disable", "tenant_acme", "postgres://u:p@db:5432/gw?search_path=tenant_acme&sslmode=disable", false},
+		{"host=db dbname=gw", "tenant_acme", "host=db dbname=gw search_path=tenant_acme", false},
+		{"postgres://db/gw", "Acme; DROP", "", true},
+	}
+	for _, tt := range tests {
+		got, err := SchemaDSN(tt.base, tt.schema)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("SchemaDSN(%q, %q) error = %v, wantErr %v", tt.base, tt.schema, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("SchemaDSN(%q, %q) = %q, want %q", tt.base, tt.schema, got, tt.want)
+		}
+	}
+}
+
+func TestRouter_NilIsolatesNobody(t *testing.T) {
+	var r *Router
+	if _, ok := r.Get("acme"); ok {
+		t.Error("nil router Get() ok = true, want false")
+	}
+	if db := r.DB("acme"); db != nil {
+		t.Error("nil router DB() != nil")
+	}
+	if got := Of(models.Client{Labels: map[string]string{"tenant": "acme"}}); got != "acme" {
+		t.Errorf("Of() = %q, want acme", got)
+	}
+}
+
+func TestRouter_SetContentKeys(t *testing.T) {
+	r := &Router{tenants: map[string]*Storage{
+		"acme":   {Name: "acme", Benign: benign.NewRepository(nil)},
+		"globex": {Name: "globex", Benign: benign.NewRepository(nil)},
+	}}
+	master, err := envelope.NewLocalMaster("k1", make([]byte, 32))
+	if err != nil {
+		t.Fatalf("NewLocalMaster() error = %v", err)
+	}
+	r.SetContentKeys(master)
+
+	acme, _ := r.Get("acme")
+	globex, _ := r.Get("globex")
+	if acme.Keys == nil || globex.Keys == nil {
+		t.Fatal("SetContentKeys() left a tenant without a keyring")
+	}
+	if acme.Keys == globex.Keys {
+		t.Error("tenants share a keyring, want each keeping its own data keys")
+	}
+}
@@ -0,0 +1,101 @@
+package tokenizer
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pretokenize splits text the way cl100k_base's pattern does before byte-pair
+// merging:
+//
+//	(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+(?!\S)|\s+
+//
+// Go's regexp has no lookahead, so the alternatives are matched by hand, in order
+func pretokenize(text string) []string {
+	var pieces []string
+	for i := 0; i < len(text); {
+		n := matchPiece(text[i:])
+		pieces = append(pieces, text[i:i+n])
+		i += n
+	}
+	return pieces
+}
+
+// matchPiece returns the byte length of the pre-token at the start of s
+func matchPiece(s string) int {
+	r, size := utf8.DecodeRuneInString(s)
+
+	// Contractions
+	if r == '\'' {
+		for _, suffix := range []string{"s", "t", "re", "ve", "m", "ll", "d"} {
+			if len(s) > len(suffix) && strings.EqualFold(s[1:1+len(suffix)], suffix) {
+				return 1 + len(suffix)
+			}
+		}
+	}
+
+	// Words, optionally preceded by one non-letter, non-digit, non-newline character
+	if unicode.IsLetter(r) {
+		return size + spanOf(s[size:], unicode.IsLetter)
+	}
+	if r != '\r' && r != '\n' && !unicode.IsNumber(r) {
+		if next, _ := utf8.DecodeRuneInString(s[size:]); size < len(s) && unicode.IsLetter(next) {
+			return size + spanOf(s[size:], unicode.IsLetter)
+		}
+	}
+
+	// Up to three digits
+	if unicode.IsNumber(r) {
+		n := size
+		for count := 1; count < 3 && n < len(s); count++ {
+			next, nextSize := utf8.DecodeRuneInString(s[n:])
+			if !unicode.IsNumber(next) {
+				break
+			}
+			n += nextSize
+		}
+		return n
+	}
+
+	// Punctuation runs, optionally preceded by a space, with trailing newlines
+	start := 0
+	if r == ' ' {
+		start = 1
+	}
+	if n := spanOf(s[start:], isPunct); n > 0 {
+		n += start
+		return n + spanOf(s[n:], func(r rune) bool { return r == '\r' || r == '\n' })
+	}
+
+	// Whitespace: up to the last newline of the run; otherwise all but the last
+	// character when text follows; otherwise the whole run
+	run := spanOf(s, unicode.IsSpace)
+	if lastNewline := strings.LastIndexAny(s[:run], "\r\n"); lastNewline >= 0 {
+		return lastNewline + 1
+	}
+	if run < len(s) {
+		if _, lastSize := utf8.DecodeLastRuneInString(s[:run]); run > lastSize {
+			return run - lastSize
+		}
+	}
+	if run > 0 {
+		return run
+	}
+	return size
+}
+
+// isPunct reports whether r is neither whitespace, a letter nor a digit
+func isPunct(r rune) bool {
+	return !unicode.IsSpace(r) && !unicode.IsLetter(r) && !unicode.IsNumber(r)
+}
+
+// spanOf returns the byte length of the prefix of s whose runes satisfy f
+func spanOf(s string, f func(rune) bool) int {
+	for i, r := range s {
+		if !f(r) {
+			return i
+		}
+	}
+	return len(s)
+}
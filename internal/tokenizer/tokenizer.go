@@ -0,0 +1,179 @@
+// Package tokenizer counts and truncates text in the byte-pair-encoding tokens of
+// OpenAI models, reading vocabularies in tiktoken's format (e.g. cl100k_base.tiktoken)
+package tokenizer
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// EstimateName is reported as the encoding when no vocabulary is loaded
+const EstimateName = "estimate"
+
+// maxPieceBytes bounds the byte-pair merge of one pre-token; longer pieces (base64
+// blobs, minified code) are counted in chunks so hostile input stays linear
+const maxPieceBytes = 256
+
+// Tokenizer encodes text with a tiktoken vocabulary
+// A nil Tokenizer estimates counts (about 4 bytes per token within each word)
+type Tokenizer struct {
+	name  string
+	ranks map[string]int // Token bytes → merge rank
+}
+
+// Load reads a tiktoken vocabulary: one "<base64 token> <rank>" pair per line
+// The encoding is named after the file (cl100k_base.tiktoken → cl100k_base)
+func Load(path string) (*Tokenizer, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open vocabulary: %w", err)
+	}
+	defer f.Close()
+
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(f)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		token, rank, ok := strings.Cut(text, " ")
+		if !ok {
+			return nil, fmt.Errorf("vocabulary line %d: expected \"<token> <rank>\"", line)
+		}
+		decoded, err := base64.StdEncoding.DecodeString(token)
+		if err != nil {
+			return nil, fmt.Errorf("vocabulary line %d: invalid token: %w", line, err)
+		}
+		n, err := strconv.Atoi(rank)
+		if err != nil {
+			return nil, fmt.Errorf("vocabulary line %d: invalid rank: %w", line, err)
+		}
+		ranks[string(decoded)] = n
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vocabulary: %w", err)
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("vocabulary %s is empty", path)
+	}
+	return &Tokenizer{name: strings.TrimSuffix(filepath.Base(path), filepath.Ext(path)), ranks: ranks}, nil
+}
+
+// Name returns the encoding name, or "estimate" for a nil Tokenizer
+func (t *Tokenizer) Name() string {
+	if t == nil {
+		return EstimateName
+	}
+	return t.name
+}
+
+// Count returns the number of tokens in text
+func (t *Tokenizer) Count(text string) int {
+	count := 0
+	for _, piece := range pretokenize(text) {
+		count += len(t.encodePiece(piece))
+	}
+	return count
+}
+
+// Truncate returns the longest prefix of text holding at most max tokens, cut on a
+// token boundary (backed off to a whole character), and whether anything was cut
+func (t *Tokenizer) Truncate(text string, max int) (string, bool) {
+	count, offset := 0, 0
+	for _, piece := range pretokenize(text) {
+		lengths := t.encodePiece(piece)
+		if count+len(lengths) <= max {
+			count += len(lengths)
+			offset += len(piece)
+			continue
+		}
+		for _, n := range lengths[:max-count] {
+			offset += n
+		}
+		for offset > 0 && !utf8.RuneStart(text[offset]) {
+			offset--
+		}
+		return text[:offset], true
+	}
+	return text, false
+}
+
+// encodePiece splits one pre-token into tokens, returning their byte lengths
+func (t *Tokenizer) encodePiece(piece string) []int {
+	var lengths []int
+	for len(piece) > maxPieceBytes {
+		lengths = append(lengths, t.encodePiece(piece[:maxPieceBytes])...)
+		piece = piece[maxPieceBytes:]
+	}
+	if t == nil {
+		return append(lengths, estimatePiece(piece)...)
+	}
+	if _, ok := t.ranks[piece]; ok {
+		return append(lengths, len(piece))
+	}
+	return append(lengths, t.bytePairMerge(piece)...)
+}
+
+// bytePairMerge repeatedly merges the adjacent pair of parts whose concatenation
+// has the lowest rank (leftmost on ties), as tiktoken does
+func (t *Tokenizer) bytePairMerge(piece string) []int {
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	// rank of the part pair starting at bounds[i], -1 when not in the vocabulary
+	pairRank := func(i int) int {
+		if i+2 >= len(bounds) {
+			return -1
+		}
+		if r, ok := t.ranks[piece[bounds[i]:bounds[i+2]]]; ok {
+			return r
+		}
+		return -1
+	}
+	ranks := make([]int, len(bounds))
+	for i := range ranks {
+		ranks[i] = pairRank(i)
+	}
+
+	for len(bounds) > 2 {
+		best := -1
+		for i := 0; i < len(bounds)-2; i++ {
+			if ranks[i] >= 0 && (best < 0 || ranks[i] < ranks[best]) {
+				best = i
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+		ranks = append(ranks[:best+1], ranks[best+2:]...)
+		ranks[best] = pairRank(best)
+		if best > 0 {
+			ranks[best-1] = pairRank(best - 1)
+		}
+	}
+
+	lengths := make([]int, len(bounds)-1)
+	for i := range lengths {
+		lengths[i] = bounds[i+1] - bounds[i]
+	}
+	return lengths
+}
+
+// estimatePiece splits a pre-token into tokens of about 4 bytes each
+func estimatePiece(piece string) []int {
+	var lengths []int
+	for len(piece) > 4 {
+		lengths = append(lengths, 4)
+		piece = piece[4:]
+	}
+	return append(lengths, len(piece))
+}
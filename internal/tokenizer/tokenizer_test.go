@@ -0,0 +1,102 @@
+package tokenizer
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// writeVocab writes a tiktoken vocabulary of all single bytes plus merges
+func writeVocab(t *testing.T, merges ...string) string {
+	t.Helper()
+	var b strings.Builder
+	rank := 0
+	for i := 0; i < 256; i++ {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte{byte(i)}), rank)
+		rank++
+	}
+	for _, m := range merges {
+		fmt.Fprintf(&b, "%s %d\n", base64.StdEncoding.EncodeToString([]byte(m)), rank)
+		rank++
+	}
+	path := filepath.Join(t.TempDir(), "test_base.tiktoken")
+	if err := os.WriteFile(path, []byte(b.String()), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestPretokenize(t *testing.T) {
+	tests := []struct {
+		text string
+		want []string
+	}{
+		{"Hello world", []string{"Hello", " world"}},
+		{"it's 12345 ok!!\n\nNext", []string{"it", "'s", " ", "123", "45", " ok", "!!\n\n", "Next"}},
+		{"a  b", []string{"a", " ", " b"}},
+		{"end   ", []string{"end", "   "}},
+		{"x \n y", []string{"x", " \n", " y"}},
+		{"(café)", []string{"(café", ")"}},
+	}
+	for _, tt := range tests {
+		if got := pretokenize(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("pretokenize(%q) = %q, want %q", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTokenizer_CountAndTruncate(t *testing.T) {
+	tok, err := Load(writeVocab(t, "he", "ll", "hell", "hello", " w", "or", " wor", " world"))
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	if tok.Name() != "test_base" {
+		t.Errorf("Name() = %q, want test_base", tok.Name())
+	}
+
+	tests := []struct {
+		text string
+		want int
+	}{
+		{"hello world", 2},  // both pieces are whole tokens
+		{"hellx world", 3},  // "hell" + "x", " world"
+		{"help", 3},         // "he" + "l" + "p"
+		{"hello, world", 3}, // "hello", ",", " world"
+		{"", 0},
+	}
+	for _, tt := range tests {
+		if got := tok.Count(tt.text); got != tt.want {
+			t.Errorf("Count(%q) = %d, want %d", tt.text, got, tt.want)
+		}
+	}
+
+	if got, cut := tok.Truncate("hello world again", 2); got != "hello world" || !cut {
+		t.Errorf("Truncate() = %q, %v, want %q, true", got, cut, "hello world")
+	}
+	if got, cut := tok.Truncate("hellx", 1); got != "hell" || !cut {
+		t.Errorf("Truncate() = %q, %v, want %q, true", got, cut, "hell")
+	}
+	if got, cut := tok.Truncate("hello", 5); got != "hello" || cut {
+		t.Errorf("Truncate() = %q, %v, want unchanged", got, cut)
+	}
+	if got, _ := tok.Truncate("é", 1); got != "" {
+		t.Errorf("Truncate() split a character: %q", got)
+	}
+}
+
+func TestTokenizer_Estimate(t *testing.T) {
+	var tok *Tokenizer
+	if tok.Name() != EstimateName {
+		t.Errorf("Name() = %q, want %q", tok.Name(), EstimateName)
+	}
+	if got := tok.Count("hello wonderful world"); got != 7 {
+		t.Errorf("Count() = %d, want 7", got)
+	}
+	if got := tok.Count(strings.Repeat("a", 10000)); got != 2500 {
+		t.Errorf("Count(long word) = %d, want 2500", got)
+	}
+}
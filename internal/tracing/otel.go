@@ -0,0 +1,112 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// Config selects whether and as what service spans are exported
+type Config struct {
+	Endpoint    string // OTLP/HTTP collector endpoint; tracing stays off when empty
+	ServiceName string // Reported service.name unless OTEL_SERVICE_NAME overrides it
+	Version     string // Reported service.version
+}
+
+// instrumentationName identifies the gateway's own spans
+const instrumentationName = "github.com/prompt-gateway"
+
+// tracer creates every span; until Setup installs a provider it is a no-op, so
+// instrumented code costs next to nothing with tracing off
+var tracer trace.Tracer = noop.NewTracerProvider().Tracer(instrumentationName)
+
+// propagator reads and writes W3C traceparent headers
+var propagator = propagation.TraceContext{}
+
+// Setup installs the global tracer provider, exporting spans in batches over
+// OTLP/HTTP. The exporter honors the standard OTEL_EXPORTER_OTLP_* variables
+// (headers, timeout, TLS) and the provider OTEL_TRACES_SAMPLER(_ARG), so only
+// the endpoint needs setting. The returned func flushes pending spans
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.Endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, err
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(
+			attribute.String("service.name", cfg.ServiceName),
+			attribute.String("service.version", cfg.Version),
+		),
+		resource.WithFromEnv(),
+		resource.WithTelemetrySDK(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	provider := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter), sdktrace.WithResource(res))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagator)
+	tracer = provider.Tracer(instrumentationName)
+	return provider.Shutdown, nil
+}
+
+// Start opens a span named name as a child of the span in ctx, if any
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// StartClient opens a client span for a call to a dependency (Redis, Postgres),
+// but only inside a traced request: background polling would otherwise flood
+// the collector with single-span traces
+func StartClient(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	parent := trace.SpanFromContext(ctx)
+	if !parent.IsRecording() {
+		return ctx, parent
+	}
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(attrs...))
+}
+
+// StartRequest opens the server span of an incoming request, joining the
+// caller's trace from its traceparent header. The resulting span context is
+// stored for WithSpanContext readers, so logs and exemplars carry the trace ID
+// whether the gateway records the span itself or only passes the caller's on
+func StartRequest(r *http.Request, name string) (context.Context, trace.Span) {
+	ctx := r.Context()
+	if sc, ok := Parse(r.Header.Get(Header)); ok {
+		ctx = WithSpanContext(ctx, sc)
+	}
+	ctx = propagator.Extract(ctx, propagation.HeaderCarrier(r.Header))
+	ctx, span := tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindServer), trace.WithAttributes(
+		attribute.String("http.request.method", r.Method),
+		attribute.String("url.path", r.URL.Path),
+	))
+	if own := span.SpanContext(); span.IsRecording() {
+		ctx = WithSpanContext(ctx, SpanContext{TraceID: own.TraceID().String(), SpanID: own.SpanID().String(), Sampled: own.IsSampled()})
+	}
+	return ctx, span
+}
+
+// End records err, if any, on span and ends it. Cancellations are recorded
+// without marking the span failed: a sibling match or the deadline stopped it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if !errors.Is(err, context.Canceled) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
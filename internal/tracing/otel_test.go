@@ -0,0 +1,128 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans routes spans to an in-memory recorder for the test
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous := tracer
+	tracer = provider.Tracer(instrumentationName)
+	t.Cleanup(func() { tracer = previous })
+	return recorder
+}
+
+const callerTraceparent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+
+func TestStartRequest_JoinsCallerTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	r := httptest.NewRequest("POST", "/v1/analyze", nil)
+	r.Header.Set(Header, callerTraceparent)
+
+	ctx, span := StartRequest(r, "POST /v1/analyze")
+	span.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(ended))
+	}
+	got := ended[0]
+	if got.SpanContext().TraceID().String() != "4bf92f3577b34da6a3ce929d0e0e4736" || got.Parent().SpanID().String() != "00f067aa0ba902b7" {
+		t.Errorf("span trace/parent = %s/%s, want the caller's", got.SpanContext().TraceID(), got.Parent().SpanID())
+	}
+	if got.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want server", got.SpanKind())
+	}
+	// Logs carry the gateway's own span once it records one
+	if sc, _ := FromContext(ctx); sc.SpanID != got.SpanContext().SpanID().String() || !sc.Sampled {
+		t.Errorf("FromContext() = %+v, want the request span", sc)
+	}
+}
+
+func TestStartRequest_WithoutTracerKeepsCallerContext(t *testing.T) {
+	r := httptest.NewRequest("POST", "/v1/analyze", nil)
+	r.Header.Set(Header, callerTraceparent)
+
+	ctx, span := StartRequest(r, "POST /v1/analyze")
+	span.End()
+
+	want := SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}
+	if sc, ok := FromContext(ctx); !ok || sc != want {
+		t.Errorf("FromContext() = %+v, %v; want %+v", sc, ok, want)
+	}
+}
+
+func TestStartClient_OnlyInsideTracedRequests(t *testing.T) {
+	recorder := recordSpans(t)
+
+	_, span := StartClient(context.Background(), "redis get")
+	span.End()
+	if n := len(recorder.Ended()); n != 0 {
+		t.Fatalf("untraced call recorded %d spans, want 0", n)
+	}
+
+	ctx, parent := Start(context.Background(), "gateway.evaluate")
+	_, span = StartClient(ctx, "redis get")
+	End(span, errors.New("connection refused"))
+	parent.End()
+
+	ended := recorder.Ended()
+	if len(ended) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(ended))
+	}
+	if ended[0].Name() != "redis get" || ended[0].SpanKind() != trace.SpanKindClient || ended[0].Status().Code != codes.Error {
+		t.Errorf("client span = %s %v %v, want a failed client span", ended[0].Name(), ended[0].SpanKind(), ended[0].Status())
+	}
+}
+
+func TestEnd_CancellationIsNotAFailure(t *testing.T) {
+	recorder := recordSpans(t)
+	_, span := Start(context.Background(), "analyzer.policy")
+	End(span, context.Canceled)
+
+	if status := recorder.Ended()[0].Status(); status.Code == codes.Error {
+		t.Errorf("status = %v, want unset for a canceled check", status)
+	}
+}
+
+// fakeConn answers every query, like a driver connection
+type fakeConn struct{ driver.Conn }
+
+func (fakeConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	return nil, nil
+}
+
+type fakeConnector struct{ driver.Connector }
+
+func (fakeConnector) Connect(context.Context) (driver.Conn, error) { return fakeConn{}, nil }
+
+func TestWrapConnector_SpansQueries(t *testing.T) {
+	recorder := recordSpans(t)
+	conn, err := WrapConnector(fakeConnector{}).Connect(context.Background())
+	if err != nil {
+		t.Fatalf("Connect() error = %v", err)
+	}
+
+	ctx, parent := Start(context.Background(), "policy_cache.refresh")
+	conn.(driver.QueryerContext).QueryContext(ctx, "  select id FROM policies", nil)
+	parent.End()
+	if name := recorder.Ended()[0].Name(); name != "postgres SELECT" {
+		t.Errorf("span name = %q, want postgres SELECT", name)
+	}
+
+	// Drivers without context-aware execution fall back through database/sql
+	if _, err := conn.(driver.ExecerContext).ExecContext(ctx, "DELETE FROM policies", nil); !errors.Is(err, driver.ErrSkip) {
+		t.Errorf("ExecContext() on a driver without ExecerContext = %v, want ErrSkip", err)
+	}
+}
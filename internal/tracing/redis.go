@@ -0,0 +1,58 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+
+	"github.com/redis/go-redis/v9"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// RedisHook opens a client span for every command a traced request sends
+// Register it with rdb.AddHook before the breaker hook, so rejected calls show too
+type RedisHook struct{}
+
+// NewRedisHook creates a go-redis tracing hook
+func NewRedisHook() *RedisHook {
+	return &RedisHook{}
+}
+
+// DialHook implements redis.Hook
+func (h *RedisHook) DialHook(next redis.DialHook) redis.DialHook {
+	return next
+}
+
+// ProcessHook implements redis.Hook
+func (h *RedisHook) ProcessHook(next redis.ProcessHook) redis.ProcessHook {
+	return func(ctx context.Context, cmd redis.Cmder) error {
+		ctx, span := StartClient(ctx, "redis "+cmd.Name(),
+			attribute.String("db.system.name", "redis"),
+			attribute.String("db.operation.name", cmd.Name()),
+		)
+		err := next(ctx, cmd)
+		End(span, redisError(err))
+		return err
+	}
+}
+
+// ProcessPipelineHook implements redis.Hook
+func (h *RedisHook) ProcessPipelineHook(next redis.ProcessPipelineHook) redis.ProcessPipelineHook {
+	return func(ctx context.Context, cmds []redis.Cmder) error {
+		ctx, span := StartClient(ctx, "redis pipeline",
+			attribute.String("db.system.name", "redis"),
+			attribute.String("db.operation.name", "pipeline"),
+			attribute.Int("db.operation.batch.size", len(cmds)),
+		)
+		err := next(ctx, cmds)
+		End(span, redisError(err))
+		return err
+	}
+}
+
+// redisError drops cache misses, which are answers rather than failures
+func redisError(err error) error {
+	if errors.Is(err, redis.Nil) {
+		return nil
+	}
+	return err
+}
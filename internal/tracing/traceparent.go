@@ -0,0 +1,81 @@
+// Package tracing records the gateway's spans over OpenTelemetry and reads the
+// W3C Trace Context (traceparent) that instrumented callers and ingresses
+// propagate, so request logs and metric exemplars link to their traces
+package tracing
+
+import (
+	"context"
+	"strings"
+)
+
+// Header is the W3C Trace Context request header
+const Header = "traceparent"
+
+// SpanContext is the caller's span a request belongs to
+type SpanContext struct {
+	TraceID string // 32 lowercase hex digits
+	SpanID  string // 16 lowercase hex digits
+	Sampled bool   // The caller records the trace, so it can be looked up
+}
+
+type spanContextKey struct{}
+
+// Parse reads a traceparent header value ("00-<trace-id>-<span-id>-<flags>").
+// Invalid values are ignored, as the spec requires; later versions are read by
+// their version 00 prefix
+func Parse(header string) (SpanContext, bool) {
+	header = strings.TrimSpace(header)
+	if len(header) < 55 || (len(header) > 55 && header[55] != '-') {
+		return SpanContext{}, false
+	}
+	version, traceID, spanID, flags := header[0:2], header[3:35], header[36:52], header[53:55]
+	if header[2] != '-' || header[35] != '-' || header[52] != '-' {
+		return SpanContext{}, false
+	}
+	if !isHex(version) || version == "ff" || (version == "00" && len(header) != 55) {
+		return SpanContext{}, false
+	}
+	if !isHex(traceID) || !isHex(spanID) || !isHex(flags) || allZero(traceID) || allZero(spanID) {
+		return SpanContext{}, false
+	}
+	return SpanContext{TraceID: traceID, SpanID: spanID, Sampled: unhex(flags[1])&1 == 1}, true
+}
+
+// WithSpanContext returns a context carrying sc
+func WithSpanContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, spanContextKey{}, sc)
+}
+
+// FromContext returns the span context stored by WithSpanContext
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(spanContextKey{}).(SpanContext)
+	return sc, ok
+}
+
+// TraceID returns the trace ID of the request ctx belongs to, or ""
+func TraceID(ctx context.Context) string {
+	sc, _ := FromContext(ctx)
+	return sc.TraceID
+}
+
+// isHex reports whether s is lowercase hex, as traceparent requires
+func isHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if (c < '0' || c > '9') && (c < 'a' || c > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+func allZero(s string) bool {
+	return strings.Trim(s, "0") == ""
+}
+
+func unhex(c byte) byte {
+	if c >= 'a' {
+		return c - 'a' + 10
+	}
+	return c - '0'
+}
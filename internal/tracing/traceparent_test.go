@@ -0,0 +1,47 @@
+package tracing
+
+import (
+	"context"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   SpanContext
+		wantOK bool
+	}{
+		{"sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+			SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, true},
+		{"not sampled", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-00",
+			SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}, true},
+		{"future version with extra fields", "01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-09-extra",
+			SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7", Sampled: true}, true},
+		{"version 00 with extra fields", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra", SpanContext{}, false},
+		{"invalid version", "ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"zero trace id", "00-00000000000000000000000000000000-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"zero span id", "00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01", SpanContext{}, false},
+		{"uppercase", "00-4BF92F3577B34DA6A3CE929D0E0E4736-00f067aa0ba902b7-01", SpanContext{}, false},
+		{"truncated", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7", SpanContext{}, false},
+		{"empty", "", SpanContext{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := Parse(tt.header)
+			if ok != tt.wantOK || got != tt.want {
+				t.Errorf("Parse(%q) = %+v, %v; want %+v, %v", tt.header, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	if id := TraceID(context.Background()); id != "" {
+		t.Errorf("TraceID() without a span context = %q, want empty", id)
+	}
+	ctx := WithSpanContext(context.Background(), SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736"})
+	if id := TraceID(ctx); id != "4bf92f3577b34da6a3ce929d0e0e4736" {
+		t.Errorf("TraceID() = %q, want the stored trace ID", id)
+	}
+}
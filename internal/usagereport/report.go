@@ -0,0 +1,248 @@
+// Package usagereport generates weekly and monthly usage and violation summaries per
+// tenant from the audit roll-ups, and delivers them on schedule to a webhook, an
+// S3 bucket or an email sink. A client's tenant is its "tenant" label; clients
+// without one are not reported
+package usagereport
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// Report periods
+	PeriodWeekly  = "weekly"
+	PeriodMonthly = "monthly"
+
+	// Rendered formats
+	FormatJSON = "json"
+	FormatCSV  = "csv"
+
+	// topPolicies bounds the policies listed per report
+	topPolicies = 10
+)
+
+// Report summarizes one tenant's traffic over a report period
+type Report struct {
+	Tenant      string           `json:"tenant"`
+	Period      string           `json:"period"`
+	Since       time.Time        `json:"since"`
+	Until       time.Time        `json:"until"`
+	GeneratedAt time.Time        `json:"generated_at"`
+	Requests    int64            `json:"requests"`
+	Violations  int64            `json:"violations"` // Requests a policy matched (any action but allow)
+	Actions     map[string]int64 `json:"actions"`
+	Clients     []ClientUsage    `json:"clients"`      // Most requests first
+	TopPolicies []PolicyHits     `json:"top_policies"` // Most hits first
+}
+
+// ClientUsage is one client's share of a report
+type ClientUsage struct {
+	ClientID     string           `json:"client_id"`
+	Requests     int64            `json:"requests"`
+	Violations   int64            `json:"violations"`
+	Actions      map[string]int64 `json:"actions"`
+	AvgLatencyMs float64          `json:"avg_latency_ms"`
+}
+
+// PolicyHits counts how often a policy triggered for the tenant's clients
+type PolicyHits struct {
+	PolicyID   uuid.UUID `json:"policy_id"`
+	PolicyName string    `json:"policy_name"`
+	Hits       int64     `json:"hits"`
+}
+
+// usageRow is one tenant/client/action total of the daily roll-ups
+type usageRow struct {
+	tenant, clientID, action string
+	requests, latencyMs      int64
+}
+
+// hitRow is one tenant/policy total of the daily policy roll-ups
+type hitRow struct {
+	tenant     string
+	policyID   uuid.UUID
+	policyName string
+	hits       int64
+}
+
+// ParsePeriods parses a comma-separated list of report periods, e.g. "weekly,monthly"
+func ParsePeriods(spec string) ([]string, error) {
+	var periods []string
+	for _, p := range strings.Split(spec, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if p != PeriodWeekly && p != PeriodMonthly {
+			return nil, fmt.Errorf("unknown report period %q (want weekly or monthly)", p)
+		}
+		if !slices.Contains(periods, p) {
+			periods = append(periods, p)
+		}
+	}
+	if len(periods) == 0 {
+		return nil, fmt.Errorf("no report period in %q", spec)
+	}
+	return periods, nil
+}
+
+// Bounds returns the last complete period before now as [since, until): the
+// previous ISO week (from Monday 00:00 UTC) or the previous calendar month in UTC
+func Bounds(period string, now time.Time) (since, until time.Time) {
+	now = now.UTC()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if period == PeriodMonthly {
+		until = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return until.AddDate(0, -1, 0), until
+	}
+	// Weekday counts from Sunday; weeks here start on Monday
+	until = today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7))
+	return until.AddDate(0, 0, -7), until
+}
+
+// build assembles the reports of every tenant present in usage, ordered by tenant
+func build(period string, since, until, generatedAt time.Time, usage []usageRow, hits []hitRow) []*Report {
+	reports := make(map[string]*Report)
+	clients := make(map[string]map[string]*ClientUsage)
+	latency := make(map[string]map[string]int64)
+	for _, row := range usage {
+		r, ok := reports[row.tenant]
+		if !ok {
+			r = &Report{
+				Tenant: row.tenant, Period: period, Since: since, Until: until, GeneratedAt: generatedAt,
+				Actions: make(map[string]int64), Clients: []ClientUsage{}, TopPolicies: []PolicyHits{},
+			}
+			reports[row.tenant] = r
+			clients[row.tenant] = make(map[string]*ClientUsage)
+			latency[row.tenant] = make(map[string]int64)
+		}
+		c, ok := clients[row.tenant][row.clientID]
+		if !ok {
+			c = &ClientUsage{ClientID: row.clientID, Actions: make(map[string]int64)}
+			clients[row.tenant][row.clientID] = c
+		}
+		r.Requests += row.requests
+		r.Actions[row.action] += row.requests
+		c.Requests += row.requests
+		c.Actions[row.action] += row.requests
+		if row.action != "allow" {
+			r.Violations += row.requests
+			c.Violations += row.requests
+		}
+		latency[row.tenant][row.clientID] += row.latencyMs
+	}
+	for _, row := range hits {
+		if r, ok := reports[row.tenant]; ok {
+			r.TopPolicies = append(r.TopPolicies, PolicyHits{PolicyID: row.policyID, PolicyName: row.policyName, Hits: row.hits})
+		}
+	}
+
+	out := make([]*Report, 0, len(reports))
+	for tenant, r := range reports {
+		for id, c := range clients[tenant] {
+			if c.Requests > 0 {
+				c.AvgLatencyMs = float64(latency[tenant][id]) / float64(c.Requests)
+			}
+			r.Clients = append(r.Clients, *c)
+		}
+		sort.Slice(r.Clients, func(i, j int) bool {
+			if r.Clients[i].Requests != r.Clients[j].Requests {
+				return r.Clients[i].Requests > r.Clients[j].Requests
+			}
+			return r.Clients[i].ClientID < r.Clients[j].ClientID
+		})
+		sort.Slice(r.TopPolicies, func(i, j int) bool {
+			if r.TopPolicies[i].Hits != r.TopPolicies[j].Hits {
+				return r.TopPolicies[i].Hits > r.TopPolicies[j].Hits
+			}
+			return r.TopPolicies[i].PolicyID.String() < r.TopPolicies[j].PolicyID.String()
+		})
+		if len(r.TopPolicies) > topPolicies {
+			r.TopPolicies = r.TopPolicies[:topPolicies]
+		}
+		out = append(out, r)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tenant < out[j].Tenant })
+	return out
+}
+
+// Filename names a rendered report, e.g. usage-acme-weekly-2026-03-02.csv
+func Filename(r *Report, format string) string {
+	return fmt.Sprintf("usage-%s-%s-%s.%s", safeName(r.Tenant), r.Period, r.Since.Format("2006-01-02"), format)
+}
+
+// ContentType is the media type of a rendered format
+func ContentType(format string) string {
+	if format == FormatCSV {
+		return "text/csv"
+	}
+	return "application/json"
+}
+
+// Render encodes r as JSON, or as CSV with one metric per row:
+// tenant,period,since,until,scope,id,name,metric,value where scope is tenant,
+// client or policy
+func Render(r *Report, format string) ([]byte, error) {
+	if format != FormatCSV {
+		return json.Marshal(r)
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	since, until := r.Since.Format(time.RFC3339), r.Until.Format(time.RFC3339)
+	write := func(scope, id, name, metric string, value int64) {
+		w.Write([]string{r.Tenant, r.Period, since, until, scope, id, name, metric, strconv.FormatInt(value, 10)})
+	}
+	w.Write([]string{"tenant", "period", "since", "until", "scope", "id", "name", "metric", "value"})
+	write("tenant", r.Tenant, "", "requests", r.Requests)
+	write("tenant", r.Tenant, "", "violations", r.Violations)
+	for _, action := range sortedKeys(r.Actions) {
+		write("tenant", r.Tenant, "", "action:"+action, r.Actions[action])
+	}
+	for _, c := range r.Clients {
+		write("client", c.ClientID, "", "requests", c.Requests)
+		write("client", c.ClientID, "", "violations", c.Violations)
+		for _, action := range sortedKeys(c.Actions) {
+			write("client", c.ClientID, "", "action:"+action, c.Actions[action])
+		}
+	}
+	for _, p := range r.TopPolicies {
+		write("policy", p.PolicyID.String(), p.PolicyName, "hits", p.Hits)
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to encode report: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// sortedKeys returns m's keys in order, so CSV rows are stable
+func sortedKeys(m map[string]int64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// safeName reduces s to characters safe in file names and object keys
+func safeName(s string) string {
+	out := []byte(s)
+	for i, c := range out {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' || c == '.') {
+			out[i] = '_'
+		}
+	}
+	return string(out)
+}
@@ -0,0 +1,282 @@
+package usagereport
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"sort"
+	"strings"
+	"time"
+)
+
+// WebhookSink POSTs each report as the request body, with the tenant, period and
+// file name in X-Report-* headers
+type WebhookSink struct {
+	url        string
+	token      string
+	httpClient *http.Client
+}
+
+// NewWebhookSink creates a sink posting to url, with token as a bearer credential when set
+func NewWebhookSink(url, token string, httpClient *http.Client) *WebhookSink {
+	client := httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &WebhookSink{url: url, token: token, httpClient: client}
+}
+
+// Name identifies the sink in logs
+func (s *WebhookSink) Name() string { return "webhook" }
+
+// Deliver posts the report
+func (s *WebhookSink) Deliver(ctx context.Context, r *Report, filename, contentType string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create report request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("X-Report-Tenant", r.Tenant)
+	req.Header.Set("X-Report-Period", r.Period)
+	req.Header.Set("X-Report-Filename", filename)
+	if s.token != "" {
+		req.Header.Set("Authorization", "Bearer "+s.token)
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("report webhook request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("report webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// S3Config locates the bucket reports are written to
+type S3Config struct {
+	Endpoint     string // e.g. https://s3.eu-west-1.amazonaws.com or a compatible store (defaults from Region)
+	Bucket       string
+	Prefix       string // Key prefix; reports land at <prefix><tenant>/<filename>
+	Region       string
+	AccessKey    string
+	SecretKey    string
+	SessionToken string // Optional, for temporary credentials
+}
+
+// S3Sink PUTs each report as an object, signed with AWS Signature Version 4 and
+// addressed path-style so S3-compatible stores work too
+type S3Sink struct {
+	cfg        S3Config
+	httpClient *http.Client
+	now        func() time.Time
+}
+
+// NewS3Sink creates a sink writing to cfg's bucket
+func NewS3Sink(cfg S3Config, httpClient *http.Client) *S3Sink {
+	client := httpClient
+	if client == nil {
+		client = &http.Client{Timeout: 30 * time.Second}
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	cfg.Endpoint = strings.TrimSuffix(cfg.Endpoint, "/")
+	return &S3Sink{cfg: cfg, httpClient: client, now: time.Now}
+}
+
+// Name identifies the sink in logs
+func (s *S3Sink) Name() string { return "s3" }
+
+// Deliver uploads the report
+func (s *S3Sink) Deliver(ctx context.Context, r *Report, filename, contentType string, body []byte) error {
+	key := s.cfg.Prefix + safeName(r.Tenant) + "/" + filename
+	target := s.cfg.Endpoint + "/" + s3Escape(s.cfg.Bucket) + "/" + s3Escape(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create S3 request: %w", err)
+	}
+	req.Header.Set("Content-Type", contentType)
+	if s.cfg.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", s.cfg.SessionToken)
+	}
+	signV4(req, body, s.cfg.Region, s.cfg.AccessKey, s.cfg.SecretKey, s.now())
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("S3 upload failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("S3 upload of %s returned status %d", key, resp.StatusCode)
+	}
+	return nil
+}
+
+// signV4 adds the X-Amz-Date, X-Amz-Content-Sha256 and Authorization headers of
+// an S3 Signature Version 4 request, signing the host and every header already set
+func signV4(req *http.Request, payload []byte, region, accessKey, secretKey string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	payloadHash := sha256Hex(payload)
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method, req.URL.EscapedPath(), req.URL.RawQuery,
+		canonicalHeaders.String(), signedHeaders, payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+secretKey), date)
+	for _, part := range []string{region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		accessKey, scope, signedHeaders, signature))
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Escape percent-encodes everything but unreserved characters and slashes, as
+// Signature Version 4 canonical URIs require
+func s3Escape(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || strings.IndexByte("-_.~/", c) >= 0 {
+			b.WriteByte(c)
+			continue
+		}
+		fmt.Fprintf(&b, "%%%02X", c)
+	}
+	return b.String()
+}
+
+// EmailConfig addresses report emails
+type EmailConfig struct {
+	Addr     string // SMTP server host:port
+	Username string // PLAIN auth when set
+	Password string
+	From     string
+	To       []string
+}
+
+// EmailSink mails each report as an attachment through an SMTP server
+type EmailSink struct {
+	cfg  EmailConfig
+	send func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailSink creates a sink mailing reports with cfg
+func NewEmailSink(cfg EmailConfig) *EmailSink {
+	to := make([]string, 0, len(cfg.To))
+	for _, addr := range cfg.To {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			to = append(to, addr)
+		}
+	}
+	cfg.To = to
+	return &EmailSink{cfg: cfg, send: smtp.SendMail}
+}
+
+// Name identifies the sink in logs
+func (s *EmailSink) Name() string { return "email" }
+
+// Deliver mails the report
+func (s *EmailSink) Deliver(ctx context.Context, r *Report, filename, contentType string, body []byte) error {
+	msg, err := s.message(r, filename, contentType, body)
+	if err != nil {
+		return err
+	}
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		host, _, _ := net.SplitHostPort(s.cfg.Addr)
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, host)
+	}
+	if err := s.send(s.cfg.Addr, auth, s.cfg.From, s.cfg.To, msg); err != nil {
+		return fmt.Errorf("failed to mail report: %w", err)
+	}
+	return nil
+}
+
+// message builds a multipart email: a plain-text summary and the report attached
+func (s *EmailSink) message(r *Report, filename, contentType string, body []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	mw := multipart.NewWriter(&buf)
+	subject := fmt.Sprintf("Usage report %s: %s %s to %s", r.Tenant, r.Period,
+		r.Since.Format("2006-01-02"), r.Until.AddDate(0, 0, -1).Format("2006-01-02"))
+	fmt.Fprintf(&buf, "From: %s\r\nTo: %s\r\nSubject: %s\r\nMIME-Version: 1.0\r\nContent-Type: multipart/mixed; boundary=%s\r\n\r\n",
+		s.cfg.From, strings.Join(s.cfg.To, ", "), mime.QEncoding.Encode("utf-8", subject), mw.Boundary())
+
+	text, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	fmt.Fprintf(text, "%s usage of tenant %s from %s to %s: %d requests, %d violations across %d clients.\r\n",
+		r.Period, r.Tenant, r.Since.Format(time.RFC3339), r.Until.Format(time.RFC3339), r.Requests, r.Violations, len(r.Clients))
+
+	attachment, err := mw.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": filename})},
+		"Content-Transfer-Encoding": {"base64"},
+	})
+	if err != nil {
+		return nil, err
+	}
+	writeBase64Lines(attachment, body)
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeBase64Lines writes data base64-encoded in 76-character lines, as MIME requires
+func writeBase64Lines(w io.Writer, data []byte) {
+	encoded := base64.StdEncoding.EncodeToString(data)
+	for len(encoded) > 76 {
+		io.WriteString(w, encoded[:76]+"\r\n")
+		encoded = encoded[76:]
+	}
+	io.WriteString(w, encoded+"\r\n")
+}
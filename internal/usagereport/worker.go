@@ -0,0 +1,253 @@
+package usagereport
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/internal/metrics"
+)
+
+const (
+	// reportLockKey is the advisory lock held while reports are generated, so
+	// replicas sharing a database deliver each report once
+	reportLockKey = 0x75736167655f7270
+	// settleDelay is how long after a period ends the roll-ups must have been
+	// refreshed before its reports go out, so entries synced late from Redis count
+	settleDelay = time.Hour
+)
+
+// Sink delivers rendered reports
+type Sink interface {
+	// Name identifies the sink in logs and the delivery records
+	Name() string
+	Deliver(ctx context.Context, r *Report, filename, contentType string, body []byte) error
+}
+
+// Worker checks every interval for report periods that ended, and delivers the
+// reports of every tenant that hasn't received them yet. Deliveries are recorded
+// in usage_report_deliveries; failed ones are retried on the next check
+type Worker struct {
+	db       *sql.DB
+	sink     Sink
+	periods  []string
+	format   string
+	interval time.Duration
+	breaker  *breaker.Breaker // Optional; while open, checks are skipped
+	now      func() time.Time
+
+	stopChan chan struct{}
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// NewWorker creates a worker delivering the reports of periods (weekly, monthly)
+// rendered in format (json, csv) to sink
+func NewWorker(db *sql.DB, sink Sink, periods []string, format string, interval time.Duration) *Worker {
+	return &Worker{
+		db:       db,
+		sink:     sink,
+		periods:  periods,
+		format:   format,
+		interval: interval,
+		now:      time.Now,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetBreaker guards the worker's queries with a circuit breaker
+// Must be called before Start
+func (w *Worker) SetBreaker(dbBreaker *breaker.Breaker) {
+	w.breaker = dbBreaker
+}
+
+// Start runs the worker until Stop or ctx is done
+func (w *Worker) Start(ctx context.Context) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		ticker := time.NewTicker(w.interval)
+		defer ticker.Stop()
+
+		for {
+			if err := w.Run(ctx); err != nil {
+				slog.Warn("Failed to generate usage reports, retrying next interval", "error", err)
+			}
+			select {
+			case <-ticker.C:
+			case <-w.stopChan:
+				return
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	slog.Info("Usage report worker started", "periods", w.periods, "format", w.format, "sink", w.sink.Name())
+}
+
+// Stop stops the worker
+func (w *Worker) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.stopChan)
+		w.wg.Wait()
+	})
+}
+
+// Run delivers the reports that are due. It is a no-op while another replica
+// holds the report lock. Only database errors are returned; a failed delivery is
+// logged, counted and retried on the next run
+func (w *Worker) Run(ctx context.Context) error {
+	return w.breaker.Do(func() error {
+		tx, err := w.db.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		var locked bool
+		if err := tx.QueryRowContext(ctx, `SELECT pg_try_advisory_xact_lock($1)`, int64(reportLockKey)).Scan(&locked); err != nil {
+			return err
+		}
+		if !locked {
+			return nil
+		}
+
+		var refreshed sql.NullTime
+		err = tx.QueryRowContext(ctx, `SELECT refreshed_at FROM audit_rollup_state WHERE name = 'audit'`).Scan(&refreshed)
+		if err == sql.ErrNoRows {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		now := w.now()
+		for _, period := range w.periods {
+			since, until := Bounds(period, now)
+			if refreshed.Time.Before(until.Add(settleDelay)) {
+				continue
+			}
+			if err := w.deliverPeriod(ctx, tx, period, since, until, now); err != nil {
+				return fmt.Errorf("%s usage reports: %w", period, err)
+			}
+		}
+		return tx.Commit()
+	})
+}
+
+// deliverPeriod sends the reports of one period to the tenants still missing them
+func (w *Worker) deliverPeriod(ctx context.Context, tx *sql.Tx, period string, since, until, now time.Time) error {
+	delivered, err := deliveredTenants(ctx, tx, period, since)
+	if err != nil {
+		return err
+	}
+	reports, err := generate(ctx, tx, period, since, until, now)
+	if err != nil {
+		return err
+	}
+
+	for _, r := range reports {
+		if delivered[r.Tenant] {
+			continue
+		}
+		body, err := Render(r, w.format)
+		if err != nil {
+			return err
+		}
+		if err := w.sink.Deliver(ctx, r, Filename(r, w.format), ContentType(w.format), body); err != nil {
+			metrics.UsageReportsTotal.WithLabelValues(period, "failed").Inc()
+			slog.Warn("Failed to deliver usage report, retrying next interval",
+				"tenant", r.Tenant, "period", period, "since", since, "sink", w.sink.Name(), "error", err)
+			continue
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO usage_report_deliveries (period, period_start, tenant, sink) VALUES ($1, $2, $3, $4)
+			ON CONFLICT DO NOTHING
+		`, period, since, r.Tenant, w.sink.Name())
+		if err != nil {
+			return err
+		}
+		metrics.UsageReportsTotal.WithLabelValues(period, "delivered").Inc()
+		slog.Info("Usage report delivered", "tenant", r.Tenant, "period", period, "since", since, "sink", w.sink.Name())
+	}
+	return nil
+}
+
+// deliveredTenants returns the tenants that already received a period's report
+func deliveredTenants(ctx context.Context, tx *sql.Tx, period string, since time.Time) (map[string]bool, error) {
+	rows, err := tx.QueryContext(ctx, `SELECT tenant FROM usage_report_deliveries WHERE period = $1 AND period_start = $2`, period, since)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	delivered := make(map[string]bool)
+	for rows.Next() {
+		var tenant string
+		if err := rows.Scan(&tenant); err != nil {
+			return nil, err
+		}
+		delivered[tenant] = true
+	}
+	return delivered, rows.Err()
+}
+
+// generate builds every tenant's report for [since, until) from the daily roll-ups
+func generate(ctx context.Context, tx *sql.Tx, period string, since, until, now time.Time) ([]*Report, error) {
+	rows, err := tx.QueryContext(ctx, `
+		SELECT c.labels->>'tenant', r.client_id, r.action, SUM(r.requests), SUM(r.latency_ms_total)
+		FROM audit_rollups r
+		JOIN clients c ON c.id = r.client_id
+		WHERE r.granularity = 'day' AND r.bucket >= $1 AND r.bucket < $2
+		  AND COALESCE(c.labels->>'tenant', '') <> ''
+		GROUP BY 1, 2, 3
+	`, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query audit roll-ups: %w", err)
+	}
+	var usage []usageRow
+	for rows.Next() {
+		var row usageRow
+		if err := rows.Scan(&row.tenant, &row.clientID, &row.action, &row.requests, &row.latencyMs); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		usage = append(usage, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rows, err = tx.QueryContext(ctx, `
+		SELECT c.labels->>'tenant', r.policy_id, COALESCE(p.name, ''), SUM(r.hits)
+		FROM audit_policy_rollups r
+		JOIN clients c ON c.id = r.client_id
+		LEFT JOIN policies p ON p.id = r.policy_id
+		WHERE r.granularity = 'day' AND r.bucket >= $1 AND r.bucket < $2
+		  AND COALESCE(c.labels->>'tenant', '') <> ''
+		GROUP BY 1, 2, 3
+	`, since, until)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query policy roll-ups: %w", err)
+	}
+	var hits []hitRow
+	for rows.Next() {
+		var row hitRow
+		if err := rows.Scan(&row.tenant, &row.policyID, &row.policyName, &row.hits); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		hits = append(hits, row)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return build(period, since, until, now.UTC(), usage, hits), nil
+}
@@ -0,0 +1,203 @@
+// Package usermessage renders the end-user explanation of a decision from a
+// localized catalog of templates, so product teams show consistent, non-technical
+// refusal messages instead of mapping policy IDs themselves
+package usermessage
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// explainedActions are the decision actions an end user is told about
+var explainedActions = map[string]bool{"block": true, "redact": true}
+
+// severityRank orders match severities; the most severe policy's message is shown
+var severityRank = map[string]int{"low": 1, "medium": 2, "high": 3, "critical": 4}
+
+// Data is what templates can refer to
+type Data struct {
+	Action   string // "block" or "redact"
+	Policy   string // Name of the policy whose message is rendered (empty for action messages)
+	Severity string // Its severity
+}
+
+// Catalog holds the message templates of every configured locale
+type Catalog struct {
+	defaultLocale string
+	locales       map[string]*locale
+}
+
+// locale holds one locale's templates
+type locale struct {
+	actions  map[string]*template.Template            // Action -> message
+	policies map[string]map[string]*template.Template // Policy name -> action -> message
+}
+
+// catalogFile is the on-disk JSON representation of a catalog
+type catalogFile struct {
+	DefaultLocale string `json:"default_locale"`
+	Locales       map[string]struct {
+		Actions  map[string]string            `json:"actions"`
+		Policies map[string]map[string]string `json:"policies"`
+	} `json:"locales"`
+}
+
+// Load reads a catalog from a JSON file
+func Load(path string) (*Catalog, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read user message catalog: %w", err)
+	}
+	return Parse(data)
+}
+
+// Parse builds a catalog from its JSON form, compiling and trial-rendering every
+// template so mistakes surface at startup rather than on a blocked request
+func Parse(data []byte) (*Catalog, error) {
+	var raw catalogFile
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return nil, fmt.Errorf("failed to parse user message catalog: %w", err)
+	}
+	c := &Catalog{defaultLocale: normalize(raw.DefaultLocale), locales: make(map[string]*locale, len(raw.Locales))}
+	if c.defaultLocale == "" {
+		return nil, fmt.Errorf("default_locale is required")
+	}
+	for tag, messages := range raw.Locales {
+		l := &locale{actions: make(map[string]*template.Template), policies: make(map[string]map[string]*template.Template)}
+		for action, text := range messages.Actions {
+			tmpl, err := compile(tag, action, "", text)
+			if err != nil {
+				return nil, err
+			}
+			l.actions[action] = tmpl
+		}
+		for policy, byAction := range messages.Policies {
+			l.policies[policy] = make(map[string]*template.Template, len(byAction))
+			for action, text := range byAction {
+				tmpl, err := compile(tag, action, policy, text)
+				if err != nil {
+					return nil, err
+				}
+				l.policies[policy][action] = tmpl
+			}
+		}
+		c.locales[normalize(tag)] = l
+	}
+	if c.locales[c.defaultLocale] == nil {
+		return nil, fmt.Errorf("default_locale %q has no messages", raw.DefaultLocale)
+	}
+	return c, nil
+}
+
+// compile parses one template and renders it once with sample data
+func compile(tag, action, policy, text string) (*template.Template, error) {
+	where := fmt.Sprintf("%s %s message", tag, action)
+	if policy != "" {
+		where = fmt.Sprintf("%s %s message of policy %q", tag, action, policy)
+	}
+	if !explainedActions[action] {
+		return nil, fmt.Errorf("%s: action must be block or redact", where)
+	}
+	tmpl, err := template.New(where).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", where, err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, Data{Action: action, Policy: policy, Severity: "high"}); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", where, err)
+	}
+	return tmpl, nil
+}
+
+// Render returns the message explaining response to an end user in the requested
+// locale (a BCP 47 tag such as "de-CH", falling back to "de" and then the default
+// locale). action is the decision's outcome: response.Action is only allow or
+// block, so the caller passes "redact" for allowed responses whose content was
+// rewritten. Within a locale the most severe triggered policy with a message for
+// the action wins over the action's generic message. Allowed, logged and
+// monitor-only decisions get no message, nor does a nil catalog
+func (c *Catalog) Render(requested, action string, response *models.AnalyzeResponse) string {
+	if c == nil || response.MonitorOnly || !explainedActions[action] {
+		return ""
+	}
+	matches := append([]models.PolicyMatch(nil), response.TriggeredPolicies...)
+	sort.SliceStable(matches, func(i, j int) bool {
+		return severityRank[matches[i].Severity] > severityRank[matches[j].Severity]
+	})
+	for _, tag := range c.chain(requested) {
+		l := c.locales[tag]
+		for _, m := range matches {
+			if tmpl := l.policies[m.PolicyName][action]; tmpl != nil {
+				return render(tmpl, Data{Action: action, Policy: m.PolicyName, Severity: m.Severity})
+			}
+		}
+		if tmpl := l.actions[action]; tmpl != nil {
+			return render(tmpl, Data{Action: action})
+		}
+	}
+	return ""
+}
+
+// chain lists the configured locales to try for a requested tag, most specific first
+func (c *Catalog) chain(requested string) []string {
+	var tags []string
+	for tag := normalize(requested); tag != ""; {
+		if c.locales[tag] != nil {
+			tags = append(tags, tag)
+		}
+		i := strings.LastIndex(tag, "-")
+		if i < 0 {
+			break
+		}
+		tag = tag[:i]
+	}
+	if !slices.Contains(tags, c.defaultLocale) {
+		tags = append(tags, c.defaultLocale)
+	}
+	return tags
+}
+
+// render executes tmpl, returning no message when it fails
+func render(tmpl *template.Template, data Data) string {
+	var b strings.Builder
+	if err := tmpl.Execute(&b, data); err != nil {
+		return ""
+	}
+	return strings.TrimSpace(b.String())
+}
+
+// normalize lowercases a locale tag and accepts "_" as separator ("pt_BR")
+func normalize(tag string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(tag), "_", "-"))
+}
+
+// FromAcceptLanguage returns the most preferred tag of an Accept-Language header
+func FromAcceptLanguage(header string) string {
+	best, bestQ := "", -1.0
+	for _, part := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		tag = strings.TrimSpace(tag)
+		if tag == "" || tag == "*" {
+			continue
+		}
+		q := 1.0
+		if v, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > bestQ {
+			best, bestQ = tag, q
+		}
+	}
+	return best
+}
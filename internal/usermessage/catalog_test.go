@@ -0,0 +1,93 @@
+package usermessage
+
+import (
+	"testing"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+const testCatalog = `{
+  "default_locale": "en",
+  "locales": {
+    "en": {
+      "actions": {"block": "Sorry, we can't help with that.", "redact": "Some details were removed."},
+      "policies": {
+        "ssn": {"block": "Please don't share Social Security numbers."},
+        "secrets": {"block": "Please remove the {{.Policy}} from your message."}
+      }
+    },
+    "de": {"actions": {"block": "Dabei können wir leider nicht helfen."}},
+    "pt_BR": {"actions": {"block": "Não podemos ajudar com isso."}}
+  }
+}`
+
+func TestCatalog_Render(t *testing.T) {
+	c, err := Parse([]byte(testCatalog))
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+	match := func(name, severity string) models.PolicyMatch {
+		return models.PolicyMatch{PolicyName: name, Severity: severity}
+	}
+
+	tests := []struct {
+		name     string
+		locale   string
+		response models.AnalyzeResponse
+		want     string
+	}{
+		{"allowed", "en", models.AnalyzeResponse{Allowed: true, Action: "allow"}, ""},
+		{"logged", "en", models.AnalyzeResponse{Allowed: true, Action: "log", TriggeredPolicies: []models.PolicyMatch{match("ssn", "high")}}, ""},
+		{"monitor only", "en", models.AnalyzeResponse{Allowed: true, Action: "block", MonitorOnly: true}, ""},
+		{"action message", "en", models.AnalyzeResponse{Action: "block", TriggeredPolicies: []models.PolicyMatch{match("toxicity", "high")}}, "Sorry, we can't help with that."},
+		{"policy message", "en", models.AnalyzeResponse{Action: "block", TriggeredPolicies: []models.PolicyMatch{match("ssn", "high")}}, "Please don't share Social Security numbers."},
+		{"most severe policy wins", "en", models.AnalyzeResponse{Action: "block", TriggeredPolicies: []models.PolicyMatch{match("ssn", "medium"), match("secrets", "critical")}}, "Please remove the secrets from your message."},
+		{"region falls back to language", "de-CH", models.AnalyzeResponse{Action: "block", TriggeredPolicies: []models.PolicyMatch{match("ssn", "high")}}, "Dabei können wir leider nicht helfen."},
+		{"unknown locale", "fr", models.AnalyzeResponse{Action: "block"}, "Sorry, we can't help with that."},
+		{"underscore separator", "PT-br", models.AnalyzeResponse{Action: "block"}, "Não podemos ajudar com isso."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := c.Render(tt.locale, tt.response.Action, &tt.response); got != tt.want {
+				t.Errorf("Render() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	var none *Catalog
+	if got := none.Render("en", "block", &models.AnalyzeResponse{Action: "block"}); got != "" {
+		t.Errorf("nil catalog Render() = %q, want empty", got)
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	tests := map[string]string{
+		"no default locale":   `{"locales": {"en": {"actions": {"block": "no"}}}}`,
+		"missing default":     `{"default_locale": "fr", "locales": {"en": {"actions": {"block": "no"}}}}`,
+		"unexplained action":  `{"default_locale": "en", "locales": {"en": {"actions": {"log": "logged"}}}}`,
+		"bad template syntax": `{"default_locale": "en", "locales": {"en": {"actions": {"block": "{{.Action"}}}}`,
+		"unknown field":       `{"default_locale": "en", "locales": {"en": {"policies": {"p": {"block": "{{.Client}}"}}}}}`,
+	}
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Parse([]byte(data)); err == nil {
+				t.Error("Parse() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestFromAcceptLanguage(t *testing.T) {
+	tests := map[string]string{
+		"":                          "",
+		"de-CH":                     "de-CH",
+		"fr;q=0.5, de-CH, en;q=0.8": "de-CH",
+		"*, en;q=0.3, fr;q=0.9":     "fr",
+		"en;q=bogus, es":            "es",
+	}
+	for header, want := range tests {
+		if got := FromAcceptLanguage(header); got != want {
+			t.Errorf("FromAcceptLanguage(%q) = %q, want %q", header, got, want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package wordlist
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/lib/pq"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Wordlist limits
+const (
+	MaxTerms      = 100000
+	MaxTermLength = 256
+)
+
+// ErrNotFound is returned when a wordlist does not exist
+var ErrNotFound = errors.New("wordlist not found")
+
+// Repository handles wordlist data access
+type Repository struct {
+	db *sql.DB
+}
+
+// NewRepository creates a new wordlist Repository
+func NewRepository(db *sql.DB) *Repository {
+	return &Repository{db: db}
+}
+
+// List returns all wordlists with their terms, sorted by name
+func (r *Repository) List(ctx context.Context) ([]models.Wordlist, error) {
+	query := `
+		SELECT name, COALESCE(description, ''), terms, created_at, updated_at
+		FROM wordlists
+		ORDER BY name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query wordlists: %w", err)
+	}
+	defer rows.Close()
+
+	lists := make([]models.Wordlist, 0)
+	for rows.Next() {
+		var wl models.Wordlist
+		if err := rows.Scan(&wl.Name, &wl.Description, pq.Array(&wl.Terms), &wl.CreatedAt, &wl.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan wordlist: %w", err)
+		}
+		wl.TermCount = len(wl.Terms)
+		lists = append(lists, wl)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating wordlists: %w", err)
+	}
+
+	return lists, nil
+}
+
+// Upsert creates a wordlist or replaces its description and terms
+func (r *Repository) Upsert(ctx context.Context, name string, req models.UpsertWordlistRequest) error {
+	query := `
+		INSERT INTO wordlists (name, description, terms)
+		VALUES ($1, NULLIF($2, ''), $3)
+		ON CONFLICT (name) DO UPDATE SET
+			description = NULLIF($2, ''),
+			terms = $3,
+			updated_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, name, req.Description, pq.Array(req.Terms))
+	if err != nil {
+		return fmt.Errorf("failed to upsert wordlist: %w", err)
+	}
+
+	return nil
+}
+
+// Delete removes a wordlist
+func (r *Repository) Delete(ctx context.Context, name string) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM wordlists WHERE name = $1`, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete wordlist: %w", err)
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Normalize validates an upsert request and returns it with terms trimmed,
+// deduplicated case-insensitively and empty terms removed
+func Normalize(name string, req models.UpsertWordlistRequest) (models.UpsertWordlistRequest, error) {
+	if name == "" {
+		return req, fmt.Errorf("name is required")
+	}
+	if len(req.Terms) == 0 {
+		return req, fmt.Errorf("terms is required")
+	}
+	if len(req.Terms) > MaxTerms {
+		return req, fmt.Errorf("at most %d terms are allowed", MaxTerms)
+	}
+
+	seen := make(map[string]bool, len(req.Terms))
+	terms := make([]string, 0, len(req.Terms))
+	for _, term := range req.Terms {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+		if len(term) > MaxTermLength {
+			return req, fmt.Errorf("terms must be at most %d bytes: %q", MaxTermLength, term[:32])
+		}
+		key := strings.ToLower(term)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		terms = append(terms, term)
+	}
+	if len(terms) == 0 {
+		return req, fmt.Errorf("terms must contain at least one non-empty term")
+	}
+	req.Terms = terms
+	return req, nil
+}
@@ -0,0 +1,169 @@
+// Package wordlist manages named term lists and their compiled matchers
+package wordlist
+
+import (
+	"context"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prompt-gateway/internal/ahocorasick"
+	"github.com/prompt-gateway/internal/breaker"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// entry is a loaded wordlist with its automaton
+type entry struct {
+	list    models.Wordlist
+	matcher *ahocorasick.Matcher
+}
+
+// Store keeps compiled wordlists in memory with periodic refresh
+type Store struct {
+	repo     *Repository
+	lists    map[string]entry
+	mu       sync.RWMutex // Protects lists map
+	interval time.Duration
+	stopChan chan struct{}
+	stopOnce sync.Once
+	breaker  *breaker.Breaker // Optional; while open, the last lists are served
+}
+
+// NewStore creates a wordlist store refreshed every interval
+func NewStore(repo *Repository, interval time.Duration) *Store {
+	return &Store{
+		repo:     repo,
+		lists:    make(map[string]entry),
+		interval: interval,
+		stopChan: make(chan struct{}),
+	}
+}
+
+// SetBreaker guards wordlist reloads with a circuit breaker
+// Must be called before Start
+func (s *Store) SetBreaker(dbBreaker *breaker.Breaker) {
+	s.breaker = dbBreaker
+}
+
+// Start performs the initial load and starts the refresh worker
+// The worker runs even when the initial load fails, so a caller tolerating the
+// error (e.g. a warm start) picks up wordlists once the database answers
+func (s *Store) Start(ctx context.Context) error {
+	go s.refreshWorker(ctx)
+	if err := s.Refresh(ctx); err != nil {
+		return err
+	}
+	slog.Info("Wordlist store initialized", "wordlists", len(s.List()), "refresh", s.interval)
+	return nil
+}
+
+// refreshWorker reloads wordlists periodically so other replicas' edits are picked up
+func (s *Store) refreshWorker(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := s.Refresh(ctx); err != nil {
+				slog.Warn("Failed to refresh wordlists, serving stale lists", "error", err)
+			}
+		case <-s.stopChan:
+			slog.Info("Wordlist refresh worker stopped")
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// Refresh reloads all wordlists, rebuilding only the automata whose lists changed
+func (s *Store) Refresh(ctx context.Context) error {
+	var loaded []models.Wordlist
+	err := s.breaker.Do(func() error {
+		var err error
+		loaded, err = s.repo.List(ctx)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	previous := s.lists
+	s.mu.RUnlock()
+
+	lists := make(map[string]entry, len(loaded))
+	for _, wl := range loaded {
+		if prev, ok := previous[wl.Name]; ok && prev.list.UpdatedAt.Equal(wl.UpdatedAt) {
+			lists[wl.Name] = prev
+			continue
+		}
+		lists[wl.Name] = entry{list: wl, matcher: ahocorasick.New(wl.Terms)}
+	}
+
+	s.mu.Lock()
+	s.lists = lists
+	s.mu.Unlock()
+
+	return nil
+}
+
+// Matcher returns the compiled matcher for a wordlist
+func (s *Store) Matcher(name string) (*ahocorasick.Matcher, bool) {
+	s.mu.RLock()
+	e, ok := s.lists[name]
+	s.mu.RUnlock()
+	return e.matcher, ok
+}
+
+// Get returns a wordlist with its terms
+func (s *Store) Get(name string) (models.Wordlist, bool) {
+	s.mu.RLock()
+	e, ok := s.lists[name]
+	s.mu.RUnlock()
+	return e.list, ok
+}
+
+// List returns all wordlists without their terms, sorted by name
+func (s *Store) List() []models.Wordlist {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]models.Wordlist, 0, len(s.lists))
+	for _, e := range s.lists {
+		wl := e.list
+		wl.Terms = nil
+		list = append(list, wl)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Upsert saves a normalized wordlist, then reloads the store
+func (s *Store) Upsert(ctx context.Context, name string, req models.UpsertWordlistRequest) (models.Wordlist, error) {
+	if err := s.repo.Upsert(ctx, name, req); err != nil {
+		return models.Wordlist{}, err
+	}
+	if err := s.Refresh(ctx); err != nil {
+		return models.Wordlist{}, err
+	}
+	wl, _ := s.Get(name)
+	return wl, nil
+}
+
+// Delete removes a wordlist and reloads the store
+func (s *Store) Delete(ctx context.Context, name string) error {
+	if err := s.repo.Delete(ctx, name); err != nil {
+		return err
+	}
+	return s.Refresh(ctx)
+}
+
+// Stop gracefully stops the refresh worker
+func (s *Store) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stopChan)
+	})
+}
@@ -0,0 +1,6 @@
+-- Track the originating session on each audit entry so multi-turn
+-- conversations can be reconstructed as a decision timeline.
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS session_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_session ON audit_logs(session_id, created_at) WHERE session_id IS NOT NULL;
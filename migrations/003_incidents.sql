@@ -0,0 +1,22 @@
+-- Incident records raised by critical policy matches and alert rules
+
+CREATE TABLE IF NOT EXISTS incidents (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    title VARCHAR(255) NOT NULL,
+    severity VARCHAR(20) NOT NULL,          -- 'low', 'medium', 'high', 'critical'
+    status VARCHAR(20) NOT NULL DEFAULT 'open', -- 'open', 'acknowledged', 'resolved'
+    source VARCHAR(20) NOT NULL,            -- 'policy', 'rule', 'manual'
+    client_id VARCHAR(255),
+    dedup_key VARCHAR(512),
+    audit_request_ids UUID[] NOT NULL DEFAULT '{}',
+    notes TEXT,
+    acknowledged_by VARCHAR(255),
+    acknowledged_at TIMESTAMP,
+    resolved_by VARCHAR(255),
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_incidents_status ON incidents(status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_incidents_dedup_open ON incidents(dedup_key) WHERE status <> 'resolved';
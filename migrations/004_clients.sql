@@ -0,0 +1,13 @@
+-- Client registry used for trust scoring, plus per-tier policy actions
+
+CREATE TABLE IF NOT EXISTS clients (
+    id VARCHAR(255) PRIMARY KEY,        -- matches AnalyzeRequest.client_id
+    name VARCHAR(255),
+    verified BOOLEAN NOT NULL DEFAULT false,
+    trust_adjustment DOUBLE PRECISION NOT NULL DEFAULT 0, -- manual +/- points
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
+
+-- Optional per-trust-tier override of a policy's action, e.g. {"trusted": "log", "anonymous": "block"}
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS tier_actions JSONB NOT NULL DEFAULT '{}';
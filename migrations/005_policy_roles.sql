@@ -0,0 +1,6 @@
+-- Restrict policies to specific chat message roles (empty = all roles)
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS roles TEXT[] NOT NULL DEFAULT '{}';
+
+INSERT INTO policies (name, description, pattern_type, pattern_value, severity, action, enabled, roles) VALUES
+    ('Role Impersonation', 'Detects user/tool messages that impersonate the system role', 'role_impersonation', 'builtin', 'high', 'block', true, '{user,tool}');
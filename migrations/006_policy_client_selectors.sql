@@ -0,0 +1,5 @@
+-- Free-form client labels and per-policy client selectors (empty = all clients)
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS labels JSONB NOT NULL DEFAULT '{}';
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS applies_to_clients TEXT[] NOT NULL DEFAULT '{}';
@@ -0,0 +1,3 @@
+-- Clients that must send a nonce + timestamp with every analyze call
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS require_nonce BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,9 @@
+-- Named term lists referenced by "dictionary" policies (pattern_value = wordlist name)
+
+CREATE TABLE IF NOT EXISTS wordlists (
+    name VARCHAR(255) PRIMARY KEY,
+    description TEXT,
+    terms TEXT[] NOT NULL DEFAULT '{}',
+    created_at TIMESTAMP DEFAULT NOW(),
+    updated_at TIMESTAMP DEFAULT NOW()
+);
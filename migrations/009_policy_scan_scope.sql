@@ -0,0 +1,4 @@
+-- Markdown-aware scanning: restrict a policy to code blocks or prose, optionally stripping markup
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS scan_scope VARCHAR(10) NOT NULL DEFAULT 'all';  -- 'all', 'code', 'prose'
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS strip_markup BOOLEAN NOT NULL DEFAULT false;
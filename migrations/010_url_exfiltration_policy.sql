@@ -0,0 +1,4 @@
+-- Builtin response detector for data exfiltration through URL query strings
+
+INSERT INTO policies (name, description, pattern_type, pattern_value, severity, action, enabled, roles) VALUES
+    ('URL Exfiltration', 'Detects model output linking to URLs whose query strings carry encoded data, emails or prompt fragments', 'url_exfiltration', 'builtin', 'high', 'block', true, '{assistant}');
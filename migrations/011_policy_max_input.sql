@@ -0,0 +1,3 @@
+-- Per-policy input size guard: only the first max_input_bytes of content are matched (0 = unlimited)
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS max_input_bytes INTEGER NOT NULL DEFAULT 0;
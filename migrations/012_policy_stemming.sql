@@ -0,0 +1,3 @@
+-- Keyword policies can match stemmed words so morphological variants need no enumeration
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS stem BOOLEAN NOT NULL DEFAULT false;
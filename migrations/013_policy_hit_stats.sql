@@ -0,0 +1,4 @@
+-- Per-policy hit telemetry, flushed in batches, to find rules that never fire
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS hit_count BIGINT NOT NULL DEFAULT 0;
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS last_matched_at TIMESTAMP WITH TIME ZONE;
@@ -0,0 +1,10 @@
+-- Record which gateway deployment made each decision, for multi-region incident analysis
+
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS instance_id VARCHAR(255);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS hostname VARCHAR(255);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS region VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS zone VARCHAR(64);
+ALTER TABLE audit_logs ADD COLUMN IF NOT EXISTS gateway_version VARCHAR(64);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_region ON audit_logs(region, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_audit_logs_instance ON audit_logs(instance_id, created_at DESC);
@@ -0,0 +1,3 @@
+-- Policies can notify their own team through a webhook when they fire, in addition to the global alert sinks
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS webhook_url TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,14 @@
+-- Rolling counts of blocked prompt fingerprints per client, to spot attack campaigns
+-- reusing the same payload across clients. Rows unseen for the retention window are pruned
+
+CREATE TABLE IF NOT EXISTS blocked_prompts (
+    prompt_hash VARCHAR(64) NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    simhash BIGINT NOT NULL,
+    block_count BIGINT NOT NULL DEFAULT 0,
+    first_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    last_seen TIMESTAMP WITH TIME ZONE NOT NULL,
+    PRIMARY KEY (prompt_hash, client_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_blocked_prompts_last_seen ON blocked_prompts(last_seen);
@@ -0,0 +1,3 @@
+-- Regex policies can validate capture groups after matching (numeric ranges, lengths, checksums)
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS capture_constraints JSONB NOT NULL DEFAULT '[]';
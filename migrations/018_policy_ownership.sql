@@ -0,0 +1,9 @@
+-- Policies record who owns them, when they are due for review and how they were added,
+-- so orphaned rules can be found and reassigned
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS owner TEXT NOT NULL DEFAULT '';
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS team TEXT NOT NULL DEFAULT '';
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS review_by DATE;
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS source TEXT NOT NULL DEFAULT 'manual';
+
+CREATE INDEX IF NOT EXISTS idx_policies_review_by ON policies (review_by) WHERE review_by IS NOT NULL;
@@ -0,0 +1,6 @@
+-- Policies can be tagged (e.g. "experimental", "pii") so groups of them can be
+-- enabled, disabled or re-graded in one bulk update
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS tags TEXT[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX IF NOT EXISTS idx_policies_tags ON policies USING GIN (tags);
@@ -0,0 +1,4 @@
+-- Decision for requests no policy matched: 'allow' (default), or 'block' for clients
+-- on an allowlist model, whose requests must match an 'allow' policy to pass
+
+ALTER TABLE clients ADD COLUMN IF NOT EXISTS default_action VARCHAR(20) NOT NULL DEFAULT 'allow';
@@ -0,0 +1,8 @@
+-- Uploaded corpus of benign prompts that policy lints and diffs estimate false
+-- positives on; a single row, and the bundled corpus applies while it is absent
+
+CREATE TABLE IF NOT EXISTS benign_corpus (
+    id BOOLEAN PRIMARY KEY DEFAULT TRUE CHECK (id),
+    prompts TEXT[] NOT NULL DEFAULT '{}',
+    updated_at TIMESTAMP DEFAULT NOW()
+);
@@ -0,0 +1,3 @@
+-- Policies can raise their severity or action when they match many times in one text
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS escalations JSONB NOT NULL DEFAULT '[]';
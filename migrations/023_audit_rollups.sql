@@ -0,0 +1,31 @@
+-- Hourly and daily audit roll-ups by client, action and policy, refreshed by a
+-- background worker so /v1/stats never aggregates the raw audit_logs table
+
+CREATE TABLE IF NOT EXISTS audit_rollups (
+    granularity VARCHAR(8) NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    requests BIGINT NOT NULL,
+    latency_ms_total BIGINT NOT NULL,
+    PRIMARY KEY (granularity, bucket, client_id, action)
+);
+
+CREATE TABLE IF NOT EXISTS audit_policy_rollups (
+    granularity VARCHAR(8) NOT NULL,
+    bucket TIMESTAMP WITH TIME ZONE NOT NULL,
+    policy_id UUID NOT NULL,
+    client_id VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    hits BIGINT NOT NULL,
+    PRIMARY KEY (granularity, bucket, policy_id, client_id, action)
+);
+
+CREATE INDEX IF NOT EXISTS idx_audit_rollups_client ON audit_rollups(granularity, client_id, bucket);
+CREATE INDEX IF NOT EXISTS idx_audit_policy_rollups_policy ON audit_policy_rollups(granularity, policy_id, bucket);
+
+-- When the roll-ups were last refreshed, reported with every stats response
+CREATE TABLE IF NOT EXISTS audit_rollup_state (
+    name VARCHAR(32) PRIMARY KEY,
+    refreshed_at TIMESTAMP WITH TIME ZONE NOT NULL
+);
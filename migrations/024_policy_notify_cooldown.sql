@@ -0,0 +1,4 @@
+-- Policies can rate-limit their notifications per client or session
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS notify_cooldown_seconds INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS notify_cooldown_scope TEXT NOT NULL DEFAULT 'client';
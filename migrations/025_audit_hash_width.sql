@@ -0,0 +1,8 @@
+-- Content hashes may be SHA-512 (128 hex characters), and audit entries can be looked
+-- up by prompt hash to find every decision on the same prompt
+
+ALTER TABLE audit_logs ALTER COLUMN prompt_hash TYPE VARCHAR(128);
+ALTER TABLE audit_logs ALTER COLUMN response_hash TYPE VARCHAR(128);
+ALTER TABLE blocked_prompts ALTER COLUMN prompt_hash TYPE VARCHAR(128);
+
+CREATE INDEX IF NOT EXISTS idx_audit_logs_prompt_hash ON audit_logs(prompt_hash, created_at DESC);
@@ -0,0 +1,11 @@
+-- Tenant usage reports already delivered, so each period's report goes out once
+-- across replicas and restarts
+
+CREATE TABLE IF NOT EXISTS usage_report_deliveries (
+    period VARCHAR(16) NOT NULL,
+    period_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    tenant VARCHAR(255) NOT NULL,
+    sink VARCHAR(32) NOT NULL,
+    delivered_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (period, period_start, tenant)
+);
@@ -0,0 +1,3 @@
+-- Policies are evaluated and reported by priority, then severity, then name
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS priority INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Keyword policies match anywhere, as whole words, or at the start of a word
+
+ALTER TABLE policies ADD COLUMN IF NOT EXISTS match_mode VARCHAR(20) NOT NULL DEFAULT 'substring';  -- 'substring', 'whole_word', 'word_boundary'
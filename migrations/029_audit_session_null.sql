@@ -0,0 +1,4 @@
+-- Audit entries without a session store NULL, not '', so idx_audit_logs_session
+-- (WHERE session_id IS NOT NULL) only indexes entries that belong to a session
+
+UPDATE audit_logs SET session_id = NULL WHERE session_id = '';
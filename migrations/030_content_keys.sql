@@ -0,0 +1,19 @@
+-- Per-tenant data keys sealing retained content, stored wrapped by the master key
+-- (CONTENT_MASTER_KEY); sealed rows record the key_id that sealed them
+
+CREATE TABLE IF NOT EXISTS content_keys (
+    key_id UUID PRIMARY KEY,
+    tenant VARCHAR(255) NOT NULL DEFAULT '',
+    wrapped_key BYTEA NOT NULL,
+    master_key_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE NOT NULL DEFAULT NOW(),
+    retired_at TIMESTAMP WITH TIME ZONE
+);
+
+-- One active key per tenant; retired keys only open what they sealed
+CREATE UNIQUE INDEX IF NOT EXISTS idx_content_keys_active ON content_keys (tenant) WHERE retired_at IS NULL;
+
+-- An uploaded benign corpus is sealed into sealed_prompts (prompts stays empty)
+-- while a master key is configured; key_id is NULL for a plaintext corpus
+ALTER TABLE benign_corpus ADD COLUMN IF NOT EXISTS key_id UUID REFERENCES content_keys (key_id);
+ALTER TABLE benign_corpus ADD COLUMN IF NOT EXISTS sealed_prompts BYTEA;
@@ -0,0 +1,92 @@
+// Package guardrailsmw checks inbound request bodies and outbound responses of Go
+// web services against the gateway. Middleware works as-is with net/http and chi;
+// the guardrailsgin and guardrailsecho modules adapt it to Gin and Echo
+package guardrailsmw
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Checker evaluates content against the gateway's policies
+type Checker interface {
+	Analyze(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error)
+}
+
+// Client calls POST /v1/analyze on a gateway
+type Client struct {
+	url        string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the gateway at baseURL; apiKey, when set, is
+// sent as a bearer token for gateways behind an authenticating proxy
+func NewClient(baseURL, apiKey string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 10 * time.Second}
+	}
+	return &Client{url: strings.TrimRight(baseURL, "/") + "/v1/analyze", apiKey: apiKey, httpClient: httpClient}
+}
+
+// NewEmbeddedClient creates a client that serves requests with an in-process
+// gateway handler (e.g. an embedded engine) instead of the network
+func NewEmbeddedClient(handler http.Handler) *Client {
+	return NewClient("http://embedded", "", &http.Client{Transport: handlerTransport{handler}})
+}
+
+// Analyze posts req to the gateway; non-2xx responses return the API error message
+func (c *Client) Analyze(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode analyze request: %w", err)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create analyze request: %w", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return nil, fmt.Errorf("analyze request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return nil, fmt.Errorf("analyze failed with status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("analyze failed with status %d: %s: %s", resp.StatusCode, apiErr.Error.Code, apiErr.Error.Message)
+	}
+
+	var out models.AnalyzeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, fmt.Errorf("failed to decode analyze response: %w", err)
+	}
+	return &out, nil
+}
+
+// handlerTransport answers HTTP requests with a handler in the same process
+type handlerTransport struct {
+	handler http.Handler
+}
+
+// RoundTrip serves req with the handler
+func (t handlerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	rec := httptest.NewRecorder()
+	t.handler.ServeHTTP(rec, req)
+	return rec.Result(), nil
+}
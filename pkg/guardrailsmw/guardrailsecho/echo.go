@@ -0,0 +1,32 @@
+// Package guardrailsecho adapts the guardrailsmw middleware to Echo:
+//
+//	e.Use(guardrailsecho.Middleware(guardrailsmw.Config{Checker: client, ClientID: "orders-service"}))
+package guardrailsecho
+
+import (
+	"net/http"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prompt-gateway/pkg/guardrailsmw"
+)
+
+// Middleware checks request (and optionally response) bodies with the gateway.
+// Handler errors are rendered inside the middleware, so error pages are checked
+// like any other response
+func Middleware(cfg guardrailsmw.Config) echo.MiddlewareFunc {
+	m := guardrailsmw.New(cfg)
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			original := c.Response()
+			m.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				c.SetRequest(r)
+				c.SetResponse(echo.NewResponse(w, c.Echo()))
+				if err := next(c); err != nil {
+					c.Error(err)
+				}
+			})).ServeHTTP(original, c.Request())
+			c.SetResponse(original)
+			return nil
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package guardrailsecho
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prompt-gateway/pkg/guardrailsmw"
+	"github.com/prompt-gateway/pkg/guardrailstest"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestMiddleware(t *testing.T) {
+	gw := guardrailstest.NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+	)
+
+	e := echo.New()
+	e.Use(Middleware(guardrailsmw.Config{
+		Checker:          guardrailsmw.NewClient(gw.URL, "", gw.Client()),
+		ClientID:         "orders-service",
+		RequestFields:    []string{"$.message"},
+		InspectResponses: true,
+	}))
+	e.POST("/chat", func(c echo.Context) error {
+		var body struct {
+			Message string `json:"message"`
+		}
+		if err := c.Bind(&body); err != nil {
+			return err
+		}
+		return c.JSON(http.StatusOK, map[string]string{"reply": "you said " + body.Message})
+	})
+	e.GET("/leak", func(c echo.Context) error {
+		return echo.NewHTTPError(http.StatusBadRequest, "customer ssn 123-45-6789 is invalid")
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"clean request", http.MethodPost, "/chat", `{"message": "hello"}`, http.StatusOK, "you said hello"},
+		{"blocked request", http.MethodPost, "/chat", `{"message": "123-45-6789"}`, http.StatusForbidden, "content_blocked"},
+		{"blocked error response", http.MethodGet, "/leak", "", http.StatusForbidden, "content_blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			e.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %q, want %d containing %q", rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
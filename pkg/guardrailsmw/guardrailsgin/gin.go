@@ -0,0 +1,87 @@
+// Package guardrailsgin adapts the guardrailsmw middleware to Gin:
+//
+//	router.Use(guardrailsgin.Middleware(guardrailsmw.Config{Checker: client, ClientID: "orders-service"}))
+package guardrailsgin
+
+import (
+	"bytes"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prompt-gateway/pkg/guardrailsmw"
+)
+
+// Middleware checks request (and optionally response) bodies with the gateway,
+// aborting the chain when content is blocked
+func Middleware(cfg guardrailsmw.Config) gin.HandlerFunc {
+	m := guardrailsmw.New(cfg)
+	return func(c *gin.Context) {
+		if !m.Matches(c.Request) {
+			c.Next()
+			return
+		}
+		prompt, ok := m.InspectRequest(c.Writer, c.Request)
+		if !ok {
+			c.Abort()
+			return
+		}
+		if !m.InspectsResponses() {
+			c.Next()
+			return
+		}
+
+		original := c.Writer
+		buf := &bufferedWriter{ResponseWriter: original, header: http.Header{}, status: http.StatusOK}
+		c.Writer = buf
+		c.Next()
+		c.Writer = original
+		m.WriteResponse(original, c.Request, prompt, buf.header, buf.status, buf.body.Bytes())
+	}
+}
+
+// bufferedWriter holds the rest of the chain's response until it has been checked
+type bufferedWriter struct {
+	gin.ResponseWriter
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// Header returns the buffered headers
+func (b *bufferedWriter) Header() http.Header { return b.header }
+
+// WriteHeader records the status; only the first call counts
+func (b *bufferedWriter) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+// WriteHeaderNow marks the headers written
+func (b *bufferedWriter) WriteHeaderNow() { b.wroteHeader = true }
+
+// Write buffers body bytes
+func (b *bufferedWriter) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
+
+// WriteString buffers body text
+func (b *bufferedWriter) WriteString(s string) (int, error) {
+	b.wroteHeader = true
+	return b.body.WriteString(s)
+}
+
+// Status returns the buffered status
+func (b *bufferedWriter) Status() int { return b.status }
+
+// Size returns the buffered body size
+func (b *bufferedWriter) Size() int { return b.body.Len() }
+
+// Written reports whether anything was written
+func (b *bufferedWriter) Written() bool { return b.wroteHeader }
+
+// Flush is a no-op: the response is released once it has been checked
+func (b *bufferedWriter) Flush() {}
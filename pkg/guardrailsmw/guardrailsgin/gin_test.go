@@ -0,0 +1,61 @@
+package guardrailsgin
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prompt-gateway/pkg/guardrailsmw"
+	"github.com/prompt-gateway/pkg/guardrailstest"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	gw := guardrailstest.NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+	)
+
+	router := gin.New()
+	router.Use(Middleware(guardrailsmw.Config{
+		Checker:          guardrailsmw.NewClient(gw.URL, "", gw.Client()),
+		ClientID:         "orders-service",
+		InspectResponses: true,
+	}))
+	router.POST("/chat", func(c *gin.Context) {
+		var body struct {
+			Message string `json:"message"`
+		}
+		c.BindJSON(&body)
+		c.JSON(http.StatusOK, gin.H{"reply": "you said " + body.Message})
+	})
+	router.GET("/leak", func(c *gin.Context) {
+		c.String(http.StatusOK, "customer ssn 123-45-6789")
+	})
+
+	tests := []struct {
+		name       string
+		method     string
+		target     string
+		body       string
+		wantStatus int
+		wantBody   string
+	}{
+		{"clean request", http.MethodPost, "/chat", `{"message": "hello"}`, http.StatusOK, "you said hello"},
+		{"blocked request", http.MethodPost, "/chat", `{"message": "123-45-6789"}`, http.StatusForbidden, "content_blocked"},
+		{"blocked response", http.MethodGet, "/leak", "", http.StatusForbidden, "content_blocked"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, req)
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %q, want %d containing %q", rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
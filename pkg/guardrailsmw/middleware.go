@@ -0,0 +1,256 @@
+package guardrailsmw
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// defaultMaxBodyBytes bounds the bodies buffered for inspection
+const defaultMaxBodyBytes = 1 << 20
+
+// ErrBodyTooLarge is reported for bodies over Config.MaxBodyBytes, which can't be inspected
+var ErrBodyTooLarge = errors.New("body too large to inspect")
+
+// Config selects the traffic the middleware inspects and how it reacts
+type Config struct {
+	Checker  Checker // Gateway client (NewClient) or an embedded one (NewEmbeddedClient)
+	ClientID string  // Gateway client the service analyzes as
+
+	// Routes are path.Match globs ("/api/chat/*"); empty inspects every route
+	Routes []string
+	// Methods whose request bodies are inspected (default POST, PUT and PATCH)
+	Methods []string
+	// RequestFields/ResponseFields are JSONPath selectors of JSON bodies to inspect
+	// ("$.messages[*].content"); empty inspects every string
+	RequestFields  []string
+	ResponseFields []string
+	// InspectResponses also checks what the service sends back, buffering responses
+	InspectResponses bool
+	// MaxBodyBytes bounds inspected bodies (default 1 MiB); larger ones fail inspection
+	MaxBodyBytes int64
+	// FailOpen lets traffic through when inspection fails (gateway unreachable,
+	// body too large); by default such requests get 503
+	FailOpen bool
+
+	// OnBlock writes the reply to blocked traffic (default 403 with an error body)
+	OnBlock func(w http.ResponseWriter, r *http.Request, decision *models.AnalyzeResponse)
+	// OnError is told about inspection failures, e.g. for logging
+	OnError func(r *http.Request, err error)
+}
+
+// Middleware checks request and response bodies with the gateway
+type Middleware struct {
+	cfg Config
+}
+
+// New creates a middleware from cfg, filling in defaults
+func New(cfg Config) *Middleware {
+	if len(cfg.Methods) == 0 {
+		cfg.Methods = []string{http.MethodPost, http.MethodPut, http.MethodPatch}
+	}
+	if cfg.MaxBodyBytes <= 0 {
+		cfg.MaxBodyBytes = defaultMaxBodyBytes
+	}
+	if cfg.OnBlock == nil {
+		cfg.OnBlock = writeBlocked
+	}
+	return &Middleware{cfg: cfg}
+}
+
+// Handler wraps next; it is the net/http (and chi) middleware
+func (m *Middleware) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !m.Matches(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		prompt, ok := m.InspectRequest(w, r)
+		if !ok {
+			return
+		}
+		if !m.cfg.InspectResponses {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		buf := &bufferedResponse{header: http.Header{}, status: http.StatusOK}
+		next.ServeHTTP(buf, r)
+		m.WriteResponse(w, r, prompt, buf.header, buf.status, buf.body.Bytes())
+	})
+}
+
+// InspectsResponses reports whether responses are buffered and checked
+func (m *Middleware) InspectsResponses() bool {
+	return m.cfg.InspectResponses
+}
+
+// Matches reports whether r's route is inspected
+func (m *Middleware) Matches(r *http.Request) bool {
+	if len(m.cfg.Routes) == 0 {
+		return true
+	}
+	for _, route := range m.cfg.Routes {
+		if ok, _ := path.Match(route, r.URL.Path); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// InspectRequest checks r's body, restoring it (redacted when the gateway redacts
+// a plain-text body) for the next handler. It returns the text that was checked,
+// as context for the response check, and false when it already replied to the request
+func (m *Middleware) InspectRequest(w http.ResponseWriter, r *http.Request) (string, bool) {
+	if r.Body == nil || !slices.Contains(m.cfg.Methods, r.Method) {
+		return r.Method + " " + r.URL.Path, true
+	}
+	body, err := io.ReadAll(io.LimitReader(r.Body, m.cfg.MaxBodyBytes+1))
+	r.Body.Close()
+	if err == nil && int64(len(body)) > m.cfg.MaxBodyBytes {
+		err = ErrBodyTooLarge
+	}
+	if err != nil {
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		return "", m.failed(w, r, err)
+	}
+	r.Body = io.NopCloser(bytes.NewReader(body))
+	if len(bytes.TrimSpace(body)) == 0 {
+		return r.Method + " " + r.URL.Path, true
+	}
+
+	req := m.analyzeRequest(r.Header.Get("Content-Type"), body, m.cfg.RequestFields)
+	decision, err := m.cfg.Checker.Analyze(r.Context(), req)
+	if err != nil {
+		return "", m.failed(w, r, err)
+	}
+	if !decision.Allowed {
+		m.cfg.OnBlock(w, r, decision)
+		return "", false
+	}
+	if req.Prompt != "" && decision.RedactedPrompt != "" && decision.RedactedPrompt != req.Prompt {
+		body = []byte(decision.RedactedPrompt)
+		r.Body = io.NopCloser(bytes.NewReader(body))
+		r.ContentLength = int64(len(body))
+		r.Header.Set("Content-Length", strconv.Itoa(len(body)))
+	}
+	return string(body), true
+}
+
+// WriteResponse checks a buffered response of r and writes it to w, or replies
+// with the block or failure response instead
+func (m *Middleware) WriteResponse(w http.ResponseWriter, r *http.Request, prompt string, header http.Header, status int, body []byte) {
+	if len(bytes.TrimSpace(body)) > 0 {
+		var err error
+		if int64(len(body)) > m.cfg.MaxBodyBytes {
+			err = ErrBodyTooLarge
+		}
+		var decision *models.AnalyzeResponse
+		if err == nil {
+			req := m.analyzeRequest(header.Get("Content-Type"), body, m.cfg.ResponseFields)
+			if req.Prompt != "" {
+				// Plain-text responses are analyzed as a reply to the request
+				if prompt == "" {
+					prompt = r.Method + " " + r.URL.Path
+				}
+				req.Prompt, req.Response = prompt, string(body)
+			}
+			decision, err = m.cfg.Checker.Analyze(r.Context(), req)
+		}
+		if err != nil && !m.failed(w, r, err) {
+			return
+		}
+		if decision != nil && !decision.Allowed {
+			m.cfg.OnBlock(w, r, decision)
+			return
+		}
+	}
+
+	for k, v := range header {
+		w.Header()[k] = v
+	}
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	w.WriteHeader(status)
+	w.Write(body)
+}
+
+// analyzeRequest builds the gateway request for a body: JSON bodies are sent as a
+// document with the configured fields, anything else as text
+func (m *Middleware) analyzeRequest(contentType string, body []byte, fields []string) models.AnalyzeRequest {
+	req := models.AnalyzeRequest{ClientID: m.cfg.ClientID}
+	if mediaType, _, _ := mime.ParseMediaType(contentType); (mediaType == "application/json" || strings.HasSuffix(mediaType, "+json")) && json.Valid(body) {
+		req.Document = body
+		req.IncludePaths = fields
+		return req
+	}
+	req.Prompt = string(body)
+	return req
+}
+
+// failed reports an inspection failure, replying with 503 unless the middleware
+// fails open; it returns whether the traffic may proceed
+func (m *Middleware) failed(w http.ResponseWriter, r *http.Request, err error) bool {
+	if m.cfg.OnError != nil {
+		m.cfg.OnError(r, err)
+	}
+	if m.cfg.FailOpen {
+		return true
+	}
+	writeError(w, http.StatusServiceUnavailable, "guardrails_unavailable", "content could not be checked: "+err.Error(), "")
+	return false
+}
+
+// writeBlocked is the default reply to blocked traffic
+func writeBlocked(w http.ResponseWriter, r *http.Request, decision *models.AnalyzeResponse) {
+	names := make([]string, len(decision.TriggeredPolicies))
+	for i, p := range decision.TriggeredPolicies {
+		names[i] = p.PolicyName
+	}
+	message := "blocked by content policy"
+	if len(names) > 0 {
+		message = fmt.Sprintf("blocked by content policy (%s)", strings.Join(names, ", "))
+	}
+	writeError(w, http.StatusForbidden, "content_blocked", message, decision.RequestID.String())
+}
+
+// writeError writes an error in the gateway's error format
+func writeError(w http.ResponseWriter, status int, code, message, requestID string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(models.ErrorResponse{Error: models.APIError{Code: code, Message: message, RequestID: requestID}})
+}
+
+// bufferedResponse holds a handler's response until it has been checked
+type bufferedResponse struct {
+	header      http.Header
+	status      int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+// Header returns the buffered headers
+func (b *bufferedResponse) Header() http.Header { return b.header }
+
+// WriteHeader records the status; like net/http, only the first call counts
+func (b *bufferedResponse) WriteHeader(status int) {
+	if !b.wroteHeader {
+		b.status = status
+		b.wroteHeader = true
+	}
+}
+
+// Write buffers body bytes
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
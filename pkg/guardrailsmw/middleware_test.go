@@ -0,0 +1,119 @@
+package guardrailsmw
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/pkg/guardrailstest"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// echoHandler replies with the request body it received
+var echoHandler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+	w.Header().Set("Content-Type", r.Header.Get("Content-Type"))
+	w.Write(body)
+})
+
+func serve(h http.Handler, method, target, contentType, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestMiddleware_Requests(t *testing.T) {
+	gw := guardrailstest.NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+		models.CreatePolicyRequest{Name: "email", PatternType: "regex", PatternValue: `[a-z]+@example\.com`, Severity: "medium", Action: "redact"},
+	)
+	mw := New(Config{
+		Checker:       NewClient(gw.URL, "", gw.Client()),
+		ClientID:      "orders-service",
+		Routes:        []string{"/api/chat/*"},
+		RequestFields: []string{"$.message"},
+	})
+	h := mw.Handler(echoHandler)
+
+	tests := []struct {
+		name        string
+		target      string
+		contentType string
+		body        string
+		wantStatus  int
+		wantBody    string
+	}{
+		{"blocked field", "/api/chat/send", "application/json", `{"message": "my ssn is 123-45-6789"}`, http.StatusForbidden, "content_blocked"},
+		{"field not inspected", "/api/chat/send", "application/json", `{"message": "hi", "order": "123-45-6789"}`, http.StatusOK, "123-45-6789"},
+		{"route not inspected", "/api/orders", "application/json", `{"message": "my ssn is 123-45-6789"}`, http.StatusOK, "123-45-6789"},
+		{"plain text redacted", "/api/chat/send", "text/plain", "mail bob@example.com please", http.StatusOK, "mail [REDACTED] please"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := serve(h, http.MethodPost, tt.target, tt.contentType, tt.body)
+			if rec.Code != tt.wantStatus || !strings.Contains(rec.Body.String(), tt.wantBody) {
+				t.Errorf("got %d %q, want %d containing %q", rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+		})
+	}
+}
+
+func TestMiddleware_Responses(t *testing.T) {
+	gw := guardrailstest.NewServer(t,
+		models.CreatePolicyRequest{Name: "secret", PatternType: "keyword", PatternValue: "internal-token", Severity: "critical", Action: "block"},
+	)
+	h := New(Config{
+		Checker:          NewClient(gw.URL, "", gw.Client()),
+		ClientID:         "orders-service",
+		InspectResponses: true,
+	}).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "here is the internal-token")
+	}))
+
+	rec := serve(h, http.MethodGet, "/api/debug", "", "")
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("leaking response got %d %q, want 403", rec.Code, rec.Body.String())
+	}
+
+	clean := New(Config{Checker: NewClient(gw.URL, "", gw.Client()), ClientID: "orders-service", InspectResponses: true}).Handler(echoHandler)
+	rec = serve(clean, http.MethodPost, "/api/echo", "text/plain", "hello")
+	if rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Errorf("clean response got %d %q, want 200 hello", rec.Code, rec.Body.String())
+	}
+}
+
+func TestMiddleware_Failures(t *testing.T) {
+	down := checkerFunc(func() error { return errors.New("connection refused") })
+	var reported error
+	closed := New(Config{Checker: down, ClientID: "svc", OnError: func(r *http.Request, err error) { reported = err }}).Handler(echoHandler)
+	if rec := serve(closed, http.MethodPost, "/", "text/plain", "hello"); rec.Code != http.StatusServiceUnavailable || reported == nil {
+		t.Errorf("fail closed got %d (reported %v), want 503", rec.Code, reported)
+	}
+
+	open := New(Config{Checker: down, ClientID: "svc", FailOpen: true}).Handler(echoHandler)
+	if rec := serve(open, http.MethodPost, "/", "text/plain", "hello"); rec.Code != http.StatusOK || rec.Body.String() != "hello" {
+		t.Errorf("fail open got %d %q, want 200 hello", rec.Code, rec.Body.String())
+	}
+
+	small := New(Config{Checker: down, ClientID: "svc", MaxBodyBytes: 4}).Handler(echoHandler)
+	if rec := serve(small, http.MethodPost, "/", "text/plain", "hello"); rec.Code != http.StatusServiceUnavailable || !strings.Contains(rec.Body.String(), ErrBodyTooLarge.Error()) {
+		t.Errorf("oversized body got %d %q, want 503", rec.Code, rec.Body.String())
+	}
+}
+
+// checkerFunc is a Checker that always fails
+type checkerFunc func() error
+
+func (f checkerFunc) Analyze(_ context.Context, _ models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	return nil, f()
+}
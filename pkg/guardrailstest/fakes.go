@@ -0,0 +1,291 @@
+// Package guardrailstest provides in-memory fakes and an httptest-backed gateway
+// so downstream services can write integration tests without Postgres or Redis
+package guardrailstest
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// PolicyRepository is an in-memory policy store with the same validation as Postgres
+type PolicyRepository struct {
+	mu       sync.RWMutex // Protects policies
+	policies []models.Policy
+}
+
+// NewPolicyRepository creates a repository seeded with policies
+// Seeded policies get an ID and timestamps when they have none
+func NewPolicyRepository(policies ...models.Policy) *PolicyRepository {
+	r := &PolicyRepository{}
+	for _, p := range policies {
+		r.Add(p)
+	}
+	return r
+}
+
+// Add stores a policy as-is, bypassing validation
+func (r *PolicyRepository) Add(p models.Policy) models.Policy {
+	if p.ID == uuid.Nil {
+		p.ID = uuid.New()
+	}
+	if p.CreatedAt.IsZero() {
+		p.CreatedAt = time.Now()
+		p.UpdatedAt = p.CreatedAt
+	}
+	if p.ScanScope == "" {
+		p.ScanScope = "all"
+	}
+	if p.Source == "" {
+		p.Source = policy.SourceManual
+	}
+	r.mu.Lock()
+	r.policies = append(r.policies, p)
+	r.mu.Unlock()
+	return p
+}
+
+// List returns enabled policies, like the Postgres repository
+func (r *PolicyRepository) List(ctx context.Context) ([]models.Policy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	enabled := make([]models.Policy, 0, len(r.policies))
+	for _, p := range r.policies {
+		if p.Enabled {
+			enabled = append(enabled, p)
+		}
+	}
+	return enabled, nil
+}
+
+// GetByID returns a policy, enabled or not
+func (r *PolicyRepository) GetByID(ctx context.Context, id uuid.UUID) (*models.Policy, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	for _, p := range r.policies {
+		if p.ID == id {
+			return &p, nil
+		}
+	}
+	return nil, policy.ErrNotFound
+}
+
+// Create validates and stores a new enabled policy
+func (r *PolicyRepository) Create(ctx context.Context, req models.CreatePolicyRequest) (*models.Policy, error) {
+	if err := policy.ValidateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	p := models.Policy{Enabled: true}
+	define(&p, req)
+	p = r.Add(p)
+	return &p, nil
+}
+
+// Update validates and replaces a policy's definition, like the Postgres repository
+func (r *PolicyRepository) Update(ctx context.Context, id uuid.UUID, req models.CreatePolicyRequest) (*models.Policy, error) {
+	if err := policy.ValidateCreateRequest(req); err != nil {
+		return nil, err
+	}
+	return r.update(id, func(p *models.Policy) {
+		if req.Source == "" {
+			req.Source = p.Source
+		}
+		define(p, req)
+	})
+}
+
+// SetEnabled enables or disables a policy
+func (r *PolicyRepository) SetEnabled(ctx context.Context, id uuid.UUID, enabled bool) (*models.Policy, error) {
+	return r.update(id, func(p *models.Policy) { p.Enabled = enabled })
+}
+
+// Delete removes a policy
+func (r *PolicyRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i, p := range r.policies {
+		if p.ID == id {
+			r.policies = append(r.policies[:i:i], r.policies[i+1:]...)
+			return nil
+		}
+	}
+	return policy.ErrNotFound
+}
+
+// update applies change to a stored policy and returns the result
+func (r *PolicyRepository) update(id uuid.UUID, change func(p *models.Policy)) (*models.Policy, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.policies {
+		if r.policies[i].ID == id {
+			change(&r.policies[i])
+			r.policies[i].UpdatedAt = time.Now()
+			p := r.policies[i]
+			return &p, nil
+		}
+	}
+	return nil, policy.ErrNotFound
+}
+
+// define copies a policy definition onto p
+func define(p *models.Policy, req models.CreatePolicyRequest) {
+	p.Name = req.Name
+	p.Description = req.Description
+	p.PatternType = req.PatternType
+	p.PatternValue = req.PatternValue
+	p.Severity = req.Severity
+	p.Action = req.Action
+	p.TierActions = req.TierActions
+	p.Roles = req.Roles
+	p.AppliesToClients = req.AppliesToClients
+	p.ScanScope = req.ScanScope
+	if p.ScanScope == "" {
+		p.ScanScope = "all"
+	}
+	p.StripMarkup = req.StripMarkup
+	p.MaxInputBytes = req.MaxInputBytes
+	p.Priority = req.Priority
+	p.Stem = req.Stem
+	p.MatchMode = req.MatchMode
+	if p.MatchMode == "" {
+		p.MatchMode = "substring"
+	}
+	p.WebhookURL = req.WebhookURL
+	p.NotifyCooldownSeconds = req.NotifyCooldownSeconds
+	p.NotifyCooldownScope = req.NotifyCooldownScope
+	if p.NotifyCooldownScope == "" {
+		p.NotifyCooldownScope = "client"
+	}
+	p.CaptureConstraints = req.CaptureConstraints
+	p.Escalations = req.Escalations
+	p.Tags = req.Tags
+	p.Owner = req.Owner
+	p.Team = req.Team
+	p.ReviewBy = req.ReviewBy
+	p.Source = req.Source
+}
+
+// BulkUpdate changes every matching policy, enabled or not, like the Postgres repository
+func (r *PolicyRepository) BulkUpdate(ctx context.Context, filter policy.BulkFilter, update models.BulkPolicyUpdate) (*models.BulkPolicyUpdateResult, error) {
+	if err := policy.ValidateBulkUpdate(filter, update); err != nil {
+		return nil, err
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	updated, result, err := policy.BulkUpdateAll(r.policies, filter, update)
+	if err != nil {
+		return nil, err
+	}
+	r.policies = updated
+	return result, nil
+}
+
+// ModelClient is a fake content-safety model; models are clean unless told otherwise
+type ModelClient struct {
+	mu        sync.Mutex // Protects the fields below
+	triggered map[string]string
+	err       error
+	calls     []ModelCall
+}
+
+// ModelCall records one evaluation request
+type ModelCall struct {
+	Model   string
+	Content string
+}
+
+// NewModelClient creates a fake model that never triggers
+func NewModelClient() *ModelClient {
+	return &ModelClient{triggered: make(map[string]string)}
+}
+
+// Trigger makes every evaluation against model report a violation with detail
+func (m *ModelClient) Trigger(model, detail string) {
+	m.mu.Lock()
+	m.triggered[model] = detail
+	m.mu.Unlock()
+}
+
+// Fail makes every evaluation return err (nil restores normal behavior)
+func (m *ModelClient) Fail(err error) {
+	m.mu.Lock()
+	m.err = err
+	m.mu.Unlock()
+}
+
+// Calls returns the evaluations made so far
+func (m *ModelClient) Calls() []ModelCall {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]ModelCall(nil), m.calls...)
+}
+
+// Evaluate implements the analyzer's model client
+func (m *ModelClient) Evaluate(ctx context.Context, model string, content string) (analyzer.ModelEvaluation, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.calls = append(m.calls, ModelCall{Model: model, Content: content})
+	if m.err != nil {
+		return analyzer.ModelEvaluation{}, m.err
+	}
+	if detail, ok := m.triggered[model]; ok {
+		return analyzer.ModelEvaluation{Triggered: true, Detail: detail}, nil
+	}
+	return analyzer.ModelEvaluation{}, nil
+}
+
+// AuditSink captures audit entries in memory instead of persisting them
+type AuditSink struct {
+	mu      sync.Mutex // Protects entries
+	entries []models.AuditLog
+}
+
+// NewAuditSink creates an empty sink
+func NewAuditSink() *AuditSink {
+	return &AuditSink{}
+}
+
+// Log captures an entry
+func (s *AuditSink) Log(entry models.AuditLog) error {
+	s.mu.Lock()
+	s.entries = append(s.entries, entry)
+	s.mu.Unlock()
+	return nil
+}
+
+// PurgePending removes captured entries matching match
+func (s *AuditSink) PurgePending(ctx context.Context, match func(models.AuditLog) bool) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	var removed int64
+	for _, e := range s.entries {
+		if match(e) {
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.entries = kept
+	return removed, nil
+}
+
+// Entries returns the captured entries in logging order
+func (s *AuditSink) Entries() []models.AuditLog {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]models.AuditLog(nil), s.entries...)
+}
+
+// Reset discards captured entries
+func (s *AuditSink) Reset() {
+	s.mu.Lock()
+	s.entries = nil
+	s.mu.Unlock()
+}
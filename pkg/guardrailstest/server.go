@@ -0,0 +1,135 @@
+package guardrailstest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prompt-gateway/internal/analyzer"
+	"github.com/prompt-gateway/internal/api"
+	"github.com/prompt-gateway/internal/cache"
+	"github.com/prompt-gateway/internal/clients"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+// Gateway is a running in-process gateway backed entirely by fakes
+// It serves POST /v1/analyze, POST /v1/analyze/batch, POST /v1/analyze/explain, GET/POST/PATCH /v1/policies, /v1/policies/{id}, GET /v1/health and GET /v1/version;
+// endpoints that need Postgres-only data (sessions, incidents, clients) are not routed
+type Gateway struct {
+	URL      string
+	Policies *PolicyRepository
+	Model    *ModelClient
+	Audit    *AuditSink
+
+	cache   *cache.PolicyCache
+	handler *api.Handler
+	server  *httptest.Server
+}
+
+// NewServer starts a gateway enforcing the given policies and stops it when the
+// test finishes. Invalid policies fail the test
+func NewServer(tb testing.TB, policies ...models.CreatePolicyRequest) *Gateway {
+	tb.Helper()
+
+	g := &Gateway{
+		Policies: NewPolicyRepository(),
+		Model:    NewModelClient(),
+		Audit:    NewAuditSink(),
+	}
+	for _, req := range policies {
+		if _, err := g.Policies.Create(context.Background(), req); err != nil {
+			tb.Fatalf("guardrailstest: invalid policy %q: %v", req.Name, err)
+		}
+	}
+
+	g.cache = cache.NewPolicyCache(g.Policies)
+	if err := g.Reload(context.Background()); err != nil {
+		tb.Fatalf("guardrailstest: failed to load policies: %v", err)
+	}
+
+	analyzerSvc := analyzer.NewAnalyzer(g.Model)
+	analyzerSvc.SetPatternSource(g.cache)
+	handler := api.NewHandler(g.Policies, g.cache, analyzerSvc, g.Audit, nil, nil, clients.NewRegistry(nil, time.Minute), nil)
+	g.handler = handler
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /v1/analyze", handler.HandleAnalyze)
+	mux.HandleFunc("POST /v1/analyze/batch", handler.HandleAnalyzeBatch)
+	mux.HandleFunc("POST /v1/analyze/explain", handler.HandleExplain)
+	mux.HandleFunc("GET /v1/policies", handler.HandleListPolicies)
+	mux.HandleFunc("POST /v1/policies", handler.HandleCreatePolicy)
+	mux.HandleFunc("PATCH /v1/policies", handler.HandleBulkUpdatePolicies)
+	mux.HandleFunc("GET /v1/policies/{id}", handler.HandleGetPolicy)
+	mux.HandleFunc("PUT /v1/policies/{id}", handler.HandleUpdatePolicy)
+	mux.HandleFunc("PATCH /v1/policies/{id}", handler.HandleUpdatePolicy)
+	mux.HandleFunc("DELETE /v1/policies/{id}", handler.HandleDeletePolicy)
+	mux.HandleFunc("POST /v1/policies/{id}/enable", handler.HandleEnablePolicy)
+	mux.HandleFunc("POST /v1/policies/{id}/disable", handler.HandleDisablePolicy)
+	mux.HandleFunc("GET /v1/health", handler.HandleHealth)
+	mux.HandleFunc("GET /v1/version", handler.HandleVersion)
+
+	g.server = httptest.NewServer(mux)
+	g.URL = g.server.URL
+	tb.Cleanup(g.Close)
+	return g
+}
+
+// Reload makes policies added directly to Policies visible to the gateway
+func (g *Gateway) Reload(ctx context.Context) error {
+	return g.cache.Invalidate(ctx)
+}
+
+// SetRequestLimits caps the policies evaluated, matches returned and redactions
+// applied per analyze request, as MAX_*_PER_REQUEST do (0 = unlimited)
+// Must be called before the first request
+func (g *Gateway) SetRequestLimits(maxPolicies, maxMatches, maxRedactions int) {
+	g.handler.SetRequestLimits(maxPolicies, maxMatches, maxRedactions)
+}
+
+// Client returns an HTTP client for the gateway
+func (g *Gateway) Client() *http.Client {
+	return g.server.Client()
+}
+
+// Analyze posts req to /v1/analyze; non-2xx responses return the API error message
+func (g *Gateway) Analyze(ctx context.Context, req models.AnalyzeRequest) (*models.AnalyzeResponse, error) {
+	body, err := json.Marshal(req)
+	if err != nil {
+		return nil, err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.URL+"/v1/analyze", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	resp, err := g.Client().Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		var apiErr models.ErrorResponse
+		if err := json.NewDecoder(resp.Body).Decode(&apiErr); err != nil {
+			return nil, fmt.Errorf("analyze failed with status %d", resp.StatusCode)
+		}
+		return nil, fmt.Errorf("analyze failed with status %d: %s: %s", resp.StatusCode, apiErr.Error.Code, apiErr.Error.Message)
+	}
+
+	var out models.AnalyzeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return nil, err
+	}
+	return &out, nil
+}
+
+// Close shuts the gateway down; it is safe to call more than once
+func (g *Gateway) Close() {
+	g.server.Close()
+}
@@ -0,0 +1,370 @@
+package guardrailstest
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/prompt-gateway/internal/policy"
+	"github.com/prompt-gateway/pkg/models"
+)
+
+func TestServer_Analyze(t *testing.T) {
+	gw := NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+		models.CreatePolicyRequest{Name: "toxicity", PatternType: "model", PatternValue: "safety-model", Severity: "medium", Action: "log"},
+	)
+	ctx := context.Background()
+
+	resp, err := gw.Analyze(ctx, models.AnalyzeRequest{ClientID: "svc", Prompt: "my ssn is 123-45-6789"})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if resp.Allowed || resp.Action != "block" {
+		t.Errorf("Analyze() = allowed %v action %q, want blocked", resp.Allowed, resp.Action)
+	}
+
+	gw.Model.Trigger("safety-model", "unsafe")
+	resp, err = gw.Analyze(ctx, models.AnalyzeRequest{ClientID: "svc", Prompt: "hello"})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if !resp.Allowed || len(resp.TriggeredPolicies) != 1 || resp.TriggeredPolicies[0].PolicyName != "toxicity" {
+		t.Errorf("Analyze() = %+v, want allowed with toxicity match", resp)
+	}
+	if calls := gw.Model.Calls(); len(calls) == 0 || calls[len(calls)-1].Content != "hello" {
+		t.Errorf("model calls = %+v, want last call with prompt", calls)
+	}
+
+	entries := gw.Audit.Entries()
+	if len(entries) != 2 || entries[0].ActionTaken != "block" || entries[0].ClientID != "svc" {
+		t.Errorf("audit entries = %+v, want block then allow for svc", entries)
+	}
+
+	if _, err := gw.Analyze(ctx, models.AnalyzeRequest{Prompt: "no client"}); err == nil {
+		t.Error("Analyze() without client_id error = nil, want invalid request")
+	}
+}
+
+func TestServer_AnalyzeBatch(t *testing.T) {
+	gw := NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+	)
+
+	post := func(body string) (int, models.BatchAnalyzeResponse) {
+		t.Helper()
+		resp, err := gw.Client().Post(gw.URL+"/v1/analyze/batch", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out models.BatchAnalyzeResponse
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	var items []string
+	for i := range 20 {
+		if i%2 == 0 {
+			items = append(items, `{"client_id": "svc", "prompt": "my ssn is 123-45-6789"}`)
+		} else {
+			items = append(items, `{"client_id": "svc", "prompt": "hello", "context": {"session_id": "s1"}}`)
+		}
+	}
+	items = append(items, `{"prompt": "no client"}`)
+	status, out := post(`{"items": [` + strings.Join(items, ",") + `]}`)
+	if status != http.StatusOK || len(out.Results) != 21 || out.Failed != 1 {
+		t.Fatalf("POST /v1/analyze/batch = %d, %d results, %d failed; want 200, 21, 1", status, len(out.Results), out.Failed)
+	}
+	for i, r := range out.Results[:20] {
+		wantAction := "allow"
+		if i%2 == 0 {
+			wantAction = "block"
+		}
+		if r.Index != i || r.Status != http.StatusOK || r.Result == nil || r.Result.Action != wantAction {
+			t.Errorf("results[%d] = %+v, want %s", i, r, wantAction)
+		}
+	}
+	if r := out.Results[20]; r.Status != http.StatusBadRequest || r.Error == nil || r.Error.Code != "invalid_request" {
+		t.Errorf("item without client_id = %+v, want invalid_request", r)
+	}
+	if n := len(gw.Audit.Entries()); n != 20 {
+		t.Errorf("audit entries = %d, want one per evaluated item", n)
+	}
+
+	if status, _ := post(`{"items": []}`); status != http.StatusBadRequest {
+		t.Errorf("empty batch = %d, want 400", status)
+	}
+	tooMany := strings.Repeat(`{"client_id": "svc", "prompt": "hi"},`, 101)
+	if status, _ := post(`{"items": [` + strings.TrimSuffix(tooMany, ",") + `]}`); status != http.StatusBadRequest {
+		t.Errorf("oversized batch = %d, want 400", status)
+	}
+}
+
+func TestServer_Explain(t *testing.T) {
+	gw := NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "block"},
+		models.CreatePolicyRequest{Name: "secret", PatternType: "keyword", PatternValue: "password", Severity: "low", Action: "redact"},
+		models.CreatePolicyRequest{Name: "toxicity", PatternType: "model", PatternValue: "safety-model", Severity: "medium", Action: "log"},
+	)
+
+	post := func(body string) (int, models.ExplainResponse) {
+		t.Helper()
+		resp, err := gw.Client().Post(gw.URL+"/v1/analyze/explain", "application/json", strings.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var out models.ExplainResponse
+		json.NewDecoder(resp.Body).Decode(&out)
+		return resp.StatusCode, out
+	}
+
+	status, out := post(`{"client_id": "svc", "prompt": "my password", "response": "ssn 123-45-6789"}`)
+	if status != http.StatusOK || out.Action != "block" || len(out.Texts) != 2 {
+		t.Fatalf("POST /v1/analyze/explain = %d, %+v; want 200 with prompt and response", status, out)
+	}
+	prompt, response := out.Texts[0], out.Texts[1]
+	if prompt.Source != "prompt" || len(prompt.Spans) != 1 || prompt.Spans[0].PolicyName != "secret" || prompt.Spans[0].Action != "redact" {
+		t.Errorf("prompt = %+v, want the keyword span", prompt)
+	}
+	if response.Source != "response" || len(response.Spans) != 1 || response.Spans[0].Start != 4 || response.Spans[0].End != 15 {
+		t.Errorf("response = %+v, want the ssn at 4-15 of the response", response)
+	}
+	if len(response.Segments) != 2 || response.Segments[1].Text != "123-45-6789" {
+		t.Errorf("segments = %+v, want the ssn as its own segment", response.Segments)
+	}
+	if len(out.Skipped) != 1 || out.Skipped[0] != "toxicity" || len(gw.Model.Calls()) != 0 {
+		t.Errorf("skipped = %v, model calls = %d; want the model policy skipped", out.Skipped, len(gw.Model.Calls()))
+	}
+
+	status, out = post(`{"client_id": "svc", "messages": [{"role": "system", "content": "be nice"}, {"role": "user", "content": "password"}]}`)
+	if status != http.StatusOK || len(out.Texts) != 2 || len(out.Texts[0].Spans) != 0 || len(out.Texts[1].Spans) != 1 || *out.Texts[1].MessageIndex != 1 {
+		t.Errorf("POST /v1/analyze/explain with messages = %d, %+v; want one span in message 1", status, out)
+	}
+
+	if n := len(gw.Audit.Entries()); n != 0 {
+		t.Errorf("audit entries = %d, want explanations not audited", n)
+	}
+	if status, _ := post(`{"prompt": "no client"}`); status != http.StatusBadRequest {
+		t.Errorf("explain without client_id = %d, want 400", status)
+	}
+}
+
+func TestPolicyRepository_CreateValidates(t *testing.T) {
+	repo := NewPolicyRepository()
+	if _, err := repo.Create(context.Background(), models.CreatePolicyRequest{Name: "bad", PatternType: "regex", PatternValue: "x", Severity: "extreme", Action: "block"}); err == nil {
+		t.Error("Create() with invalid severity error = nil, want error")
+	}
+}
+
+func TestServer_AnalyzeAttachments(t *testing.T) {
+	gw := NewServer(t,
+		models.CreatePolicyRequest{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "redact"},
+	)
+	ctx := context.Background()
+
+	resp, err := gw.Analyze(ctx, models.AnalyzeRequest{
+		ClientID: "svc",
+		Prompt:   "summarize the attached files",
+		Attachments: []models.Attachment{
+			{Filename: "notes.txt", MimeType: "text/plain; charset=utf-8", Content: "employee ssn 123-45-6789"},
+			{Filename: "page.html", MimeType: "text/html", Content: "<p>ssn <b>987-65-4321</b></p>"},
+			{Filename: "record.json", MimeType: "application/json", Content: `{"people":[{"ssn":"111-22-3333"}]}`},
+			{Filename: "readme.md", MimeType: "text/markdown", Content: "nothing to see"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Analyze() error = %v", err)
+	}
+	if len(resp.AttachmentResults) != 4 || len(resp.TriggeredPolicies) != 3 {
+		t.Fatalf("Analyze() = %d attachment results, %d matches, want 4 and 3", len(resp.AttachmentResults), len(resp.TriggeredPolicies))
+	}
+	if got := resp.AttachmentResults[0].RedactedContent; got != "employee ssn [REDACTED]" {
+		t.Errorf("notes.txt redacted = %q", got)
+	}
+	if m := resp.TriggeredPolicies[2]; m.AttachmentIndex == nil || *m.AttachmentIndex != 2 || m.FieldPath != "$.people[0].ssn" {
+		t.Errorf("JSON attachment match = %+v, want attachment 2 at $.people[0].ssn", m)
+	}
+	if r := resp.AttachmentResults[3]; !r.Allowed || len(r.TriggeredPolicies) != 0 {
+		t.Errorf("readme.md result = %+v, want clean", r)
+	}
+
+	for _, att := range []models.Attachment{
+		{MimeType: "application/pdf", Content: "%PDF-1.7"},
+		{MimeType: "application/json", Content: "{not json"},
+	} {
+		if _, err := gw.Analyze(ctx, models.AnalyzeRequest{ClientID: "svc", Attachments: []models.Attachment{att}}); err == nil {
+			t.Errorf("Analyze() with %s attachment error = nil, want invalid request", att.MimeType)
+		}
+	}
+}
+
+func TestServer_RequestLimits(t *testing.T) {
+	policies := []models.CreatePolicyRequest{
+		{Name: "secret", PatternType: "keyword", PatternValue: "password", Severity: "critical", Action: "redact"},
+		{Name: "ssn", PatternType: "regex", PatternValue: `\d{3}-\d{2}-\d{4}`, Severity: "high", Action: "redact"},
+		{Name: "tone", PatternType: "keyword", PatternValue: "stupid", Severity: "low", Action: "log"},
+	}
+	prompt := "stupid password 123-45-6789 and 987-65-4321"
+	ctx := context.Background()
+	analyze := func(maxPolicies, maxMatches, maxRedactions int) *models.AnalyzeResponse {
+		t.Helper()
+		gw := NewServer(t, policies...)
+		gw.SetRequestLimits(maxPolicies, maxMatches, maxRedactions)
+		resp, err := gw.Analyze(ctx, models.AnalyzeRequest{ClientID: "svc", Prompt: prompt})
+		if err != nil {
+			t.Fatalf("Analyze() error = %v", err)
+		}
+		return resp
+	}
+
+	if resp := analyze(0, 0, 0); len(resp.TriggeredPolicies) != 3 || resp.Overflow != nil {
+		t.Errorf("unlimited = %d matches, overflow %+v, want 3 and none", len(resp.TriggeredPolicies), resp.Overflow)
+	}
+
+	// The least severe policy is left out
+	resp := analyze(2, 0, 0)
+	if len(resp.TriggeredPolicies) != 2 || resp.Overflow == nil || resp.Overflow.PoliciesSkipped != 1 {
+		t.Errorf("policy limit = %+v, overflow %+v, want 2 matches and 1 skipped policy", resp.TriggeredPolicies, resp.Overflow)
+	}
+
+	// The decision keeps every match; the response the most severe
+	resp = analyze(0, 1, 0)
+	if len(resp.TriggeredPolicies) != 1 || resp.TriggeredPolicies[0].PolicyName != "secret" {
+		t.Errorf("match limit = %+v, want the critical match", resp.TriggeredPolicies)
+	}
+	if resp.Overflow == nil || resp.Overflow.MatchesOmitted != 2 || resp.RedactedPrompt != "stupid [REDACTED] [REDACTED] and [REDACTED]" {
+		t.Errorf("match limit overflow = %+v, redacted %q, want 2 omitted and full redaction", resp.Overflow, resp.RedactedPrompt)
+	}
+	resp = analyze(0, 3, 0)
+	if resp.Overflow != nil {
+		t.Errorf("overflow = %+v, want none within the limit", resp.Overflow)
+	}
+
+	// Content that can't be fully redacted is blocked
+	resp = analyze(0, 0, 1)
+	if resp.Allowed || resp.Action != "block" || resp.Overflow == nil || resp.Overflow.RedactionsSkipped != 1 {
+		t.Errorf("redaction limit = allowed %v action %q overflow %+v, want blocked with 1 skipped", resp.Allowed, resp.Action, resp.Overflow)
+	}
+}
+
+func TestServer_BulkUpdatePolicies(t *testing.T) {
+	gw := NewServer(t,
+		models.CreatePolicyRequest{Name: "beta-a", PatternType: "keyword", PatternValue: "alpha", Severity: "high", Action: "block", Tags: []string{"experimental"}},
+		models.CreatePolicyRequest{Name: "beta-b", PatternType: "keyword", PatternValue: "bravo", Severity: "low", Action: "block", Tags: []string{"experimental", "pii"}, Team: "red"},
+		models.CreatePolicyRequest{Name: "stable", PatternType: "keyword", PatternValue: "charlie", Severity: "high", Action: "block"},
+	)
+	ctx := context.Background()
+
+	patch := func(query, body string) (int, models.BulkPolicyUpdateResult) {
+		t.Helper()
+		req, _ := http.NewRequest(http.MethodPatch, gw.URL+"/v1/policies?"+query, strings.NewReader(body))
+		resp, err := gw.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result models.BulkPolicyUpdateResult
+		json.NewDecoder(resp.Body).Decode(&result)
+		return resp.StatusCode, result
+	}
+
+	status, result := patch("tag=experimental", `{"enabled": false}`)
+	if status != http.StatusOK || result.Matched != 2 || result.Changed != 2 || result.Changes[0].PolicyName != "beta-a" || result.Changes[0].Enabled {
+		t.Fatalf("PATCH tag=experimental = %d %+v, want both experimental policies disabled", status, result)
+	}
+	resp, err := gw.Analyze(ctx, models.AnalyzeRequest{ClientID: "svc", Prompt: "alpha bravo"})
+	if err != nil || !resp.Allowed {
+		t.Errorf("Analyze() = %+v, %v, want allowed with experimental policies off", resp, err)
+	}
+
+	// Disabled policies can be switched back on; unchanged ones aren't reported
+	status, result = patch("tag=pii&team=red", `{"enabled": true, "severity": "low"}`)
+	if status != http.StatusOK || result.Matched != 1 || result.Changed != 1 || result.Changes[0].SeverityBefore != "low" || !result.Changes[0].Enabled {
+		t.Errorf("PATCH tag=pii&team=red = %d %+v, want beta-b re-enabled", status, result)
+	}
+
+	if status, _ := patch("", `{"enabled": false}`); status != http.StatusBadRequest {
+		t.Errorf("PATCH without selector = %d, want 400", status)
+	}
+	// An update past MaxChanges is refused whole
+	_, err = gw.Policies.BulkUpdate(ctx, policy.BulkFilter{Tag: "experimental", MaxChanges: 1}, models.BulkPolicyUpdate{Severity: "critical"})
+	if !errors.Is(err, policy.ErrTooManyChanges) {
+		t.Errorf("BulkUpdate() past MaxChanges error = %v, want ErrTooManyChanges", err)
+	}
+	if p, _ := gw.Policies.GetByID(ctx, gw.Policies.policies[0].ID); p.Severity != "high" {
+		t.Errorf("refused bulk update changed severity to %q", p.Severity)
+	}
+}
+
+func TestServer_PolicyLifecycle(t *testing.T) {
+	gw := NewServer(t,
+		models.CreatePolicyRequest{Name: "secret", PatternType: "keyword", PatternValue: "alpha", Severity: "high", Action: "block", Owner: "sec"},
+	)
+	ctx := context.Background()
+	id := gw.Policies.policies[0].ID.String()
+
+	do := func(method, path, body string) (int, models.Policy) {
+		t.Helper()
+		req, _ := http.NewRequest(method, gw.URL+path, strings.NewReader(body))
+		resp, err := gw.Client().Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var p models.Policy
+		json.NewDecoder(resp.Body).Decode(&p)
+		return resp.StatusCode, p
+	}
+	blocked := func(prompt string) bool {
+		t.Helper()
+		resp, err := gw.Analyze(ctx, models.AnalyzeRequest{ClientID: "svc", Prompt: prompt})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return !resp.Allowed
+	}
+
+	// PATCH changes only the fields in the body
+	status, p := do(http.MethodPatch, "/v1/policies/"+id, `{"pattern_value": "bravo"}`)
+	if status != http.StatusOK || p.PatternValue != "bravo" || p.Owner != "sec" || p.Action != "block" {
+		t.Fatalf("PATCH = %d %+v, want pattern bravo with other fields kept", status, p)
+	}
+	if blocked("alpha") || !blocked("bravo") {
+		t.Error("policy cache not refreshed after PATCH")
+	}
+
+	// PUT replaces the whole definition
+	status, p = do(http.MethodPut, "/v1/policies/"+id, `{"name": "secret", "pattern_type": "keyword", "pattern_value": "bravo", "severity": "low", "action": "log"}`)
+	if status != http.StatusOK || p.Owner != "" || p.Action != "log" || !p.Enabled {
+		t.Errorf("PUT = %d %+v, want replaced definition", status, p)
+	}
+	if status, _ := do(http.MethodPut, "/v1/policies/"+id, `{"name": "secret", "pattern_type": "keyword", "pattern_value": "bravo", "severity": "extreme", "action": "log"}`); status != http.StatusBadRequest {
+		t.Errorf("PUT invalid = %d, want 400", status)
+	}
+
+	do(http.MethodPatch, "/v1/policies/"+id, `{"action": "block"}`)
+	if status, p := do(http.MethodPost, "/v1/policies/"+id+"/disable", ""); status != http.StatusOK || p.Enabled || blocked("bravo") {
+		t.Errorf("disable = %d %+v, want disabled policy no longer enforced", status, p)
+	}
+	if status, p := do(http.MethodGet, "/v1/policies/"+id, ""); status != http.StatusOK || p.Enabled {
+		t.Errorf("GET disabled = %d %+v, want disabled policy", status, p)
+	}
+	if status, p := do(http.MethodPost, "/v1/policies/"+id+"/enable", ""); status != http.StatusOK || !p.Enabled || !blocked("bravo") {
+		t.Errorf("enable = %d %+v, want policy enforced again", status, p)
+	}
+
+	if status, _ := do(http.MethodDelete, "/v1/policies/"+id, ""); status != http.StatusNoContent || blocked("bravo") {
+		t.Errorf("DELETE = %d, want 204 and policy gone", status)
+	}
+	if status, _ := do(http.MethodDelete, "/v1/policies/"+id, ""); status != http.StatusNotFound {
+		t.Errorf("DELETE again = %d, want 404", status)
+	}
+	if status, _ := do(http.MethodGet, "/v1/policies/not-a-uuid", ""); status != http.StatusBadRequest {
+		t.Errorf("GET invalid ID = %d, want 400", status)
+	}
+}
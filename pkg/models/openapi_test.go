@@ -0,0 +1,62 @@
+package models
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// TestOpenAPISchemas keeps api/openapi.json, and the SDKs generated from it, in
+// step with the wire types: every JSON field must appear in its schema and vice versa
+func TestOpenAPISchemas(t *testing.T) {
+	data, err := os.ReadFile("../../api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var spec struct {
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]json.RawMessage `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	if err := json.Unmarshal(data, &spec); err != nil {
+		t.Fatalf("invalid spec: %v", err)
+	}
+
+	types := []any{
+		AnalyzeRequest{}, Attachment{}, ChatMessage{}, ToolCall{}, ToolCallFunction{}, RequestContext{},
+		AnalyzeResponse{}, BatchAnalyzeRequest{}, BatchAnalyzeResponse{}, BatchAnalyzeResult{}, ExplainRequest{}, ExplainResponse{}, ExplainedText{}, MatchSpan{}, TextSegment{}, PolicyTrace{}, PolicyMatch{}, MatchOccurrence{}, MessageVerdict{}, AttachmentVerdict{}, SessionOverride{},
+		Signals{}, TokenCounts{}, CacheStatus{}, LimitOverflow{}, VerifyTokenRequest{}, VerifyTokenResponse{},
+		Policy{}, CreatePolicyRequest{}, CaptureConstraint{}, Escalation{}, PolicyLintReport{}, LintWarning{}, LintCorpusResult{},
+		PolicyTestRequest{}, PolicyTestResponse{}, PolicyTestResult{},
+		BulkPolicyUpdate{}, PolicyChange{}, BulkPolicyUpdateResult{}, HealthResponse{},
+		MaintenanceStatus{}, VersionResponse{}, FeatureFlag{}, ErrorResponse{}, APIError{}, ErrorDetail{},
+	}
+	for _, v := range types {
+		typ := reflect.TypeOf(v)
+		schema, ok := spec.Components.Schemas[typ.Name()]
+		if !ok {
+			t.Errorf("schema %s missing", typ.Name())
+			continue
+		}
+		var fields, props []string
+		for i := 0; i < typ.NumField(); i++ {
+			name, _, _ := strings.Cut(typ.Field(i).Tag.Get("json"), ",")
+			if name != "" && name != "-" {
+				fields = append(fields, name)
+			}
+		}
+		for name := range schema.Properties {
+			props = append(props, name)
+		}
+		sort.Strings(fields)
+		sort.Strings(props)
+		if !reflect.DeepEqual(fields, props) {
+			t.Errorf("schema %s properties = %v, want %v", typ.Name(), props, fields)
+		}
+	}
+}
@@ -1,6 +1,8 @@
 package models
 
 import (
+	"encoding/json"
+	"fmt"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,16 +18,121 @@ type Policy struct {
 	Severity     string    `json:"severity"`      // "low", "medium", "high", "critical"
 	Action       string    `json:"action"`        // "log", "block", "redact"
 	Enabled      bool      `json:"enabled"`
-	CreatedAt    time.Time `json:"created_at"`
-	UpdatedAt    time.Time `json:"updated_at"`
+	// Priority orders evaluation and reported matches: higher first, then more
+	// severe, then by name (default 0)
+	Priority int `json:"priority,omitempty"`
+	// TierActions overrides Action per client trust tier ("trusted", "standard", "untrusted", "anonymous")
+	TierActions map[string]string `json:"tier_actions,omitempty"`
+	// Roles restricts the policy to chat messages with these roles (all when empty)
+	Roles []string `json:"roles,omitempty"`
+	// ScanScope limits matching to fenced/inline code ("code"), the text outside it ("prose") or everything ("all")
+	ScanScope string `json:"scan_scope,omitempty"`
+	// StripMarkup removes markdown/HTML syntax before matching
+	StripMarkup bool `json:"strip_markup,omitempty"`
+	// MaxInputBytes matches only the first MaxInputBytes of content (0 = unlimited)
+	MaxInputBytes int `json:"max_input_bytes,omitempty"`
+	// Stem makes keyword policies compare stemmed words ("jailbreaking" matches "jailbreak")
+	Stem bool `json:"stem,omitempty"`
+	// MatchMode bounds where a keyword matches: "substring" (anywhere, default),
+	// "whole_word" ("DAN" but not "DANs" or "abundance") or "word_boundary" (at
+	// the start of a word: "DANs" but not "abundance")
+	MatchMode string `json:"match_mode,omitempty"`
+	// AppliesToClients restricts the policy to matching clients: exact IDs, globs
+	// or label selectors like "env=prod,team!=ml" (all clients when empty)
+	AppliesToClients []string `json:"applies_to_clients,omitempty"`
+	// WebhookURL is notified whenever the policy fires, in addition to the global alert sinks
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// NotifyCooldownSeconds sends at most one notification per window for each
+	// client, or each session with NotifyCooldownScope "session" (0 = every match)
+	NotifyCooldownSeconds int    `json:"notify_cooldown_seconds,omitempty"`
+	NotifyCooldownScope   string `json:"notify_cooldown_scope,omitempty"`
+	// CaptureConstraints must all hold for a regex match to count (e.g. an amount above 10,000)
+	CaptureConstraints []CaptureConstraint `json:"capture_constraints,omitempty"`
+	// Escalations raise the severity or action when the policy matches many times in one text
+	Escalations []Escalation `json:"escalations,omitempty"`
+	// Tags group policies for bulk updates (e.g. "experimental")
+	Tags []string `json:"tags,omitempty"`
+	// Owner and Team maintain the policy; ReviewBy (YYYY-MM-DD) is when it must next be reviewed
+	Owner    string `json:"owner,omitempty"`
+	Team     string `json:"team,omitempty"`
+	ReviewBy string `json:"review_by,omitempty"`
+	// Source records how the policy was added: "manual", "import" or "feed"
+	Source string `json:"source"`
+	// HitCount and LastMatchedAt are match telemetry, flushed to Postgres in batches
+	HitCount      int64      `json:"hit_count"`
+	LastMatchedAt *time.Time `json:"last_matched_at,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at"`
 }
 
 // AnalyzeRequest is the input for prompt analysis
+// One of Prompt (flat), Messages (OpenAI-style chat) or Document (structured JSON) must be set
 type AnalyzeRequest struct {
 	ClientID string          `json:"client_id"`
-	Prompt   string          `json:"prompt"`
+	Prompt   string          `json:"prompt,omitempty"`
 	Response string          `json:"response,omitempty"`
-	Context  *RequestContext `json:"context,omitempty"`
+	Messages []ChatMessage   `json:"messages,omitempty"`
+	Document json.RawMessage `json:"document,omitempty"`
+	// IncludePaths/ExcludePaths are JSONPath selectors over Document (all strings when no include is given)
+	IncludePaths []string        `json:"include_paths,omitempty"`
+	ExcludePaths []string        `json:"exclude_paths,omitempty"`
+	Context      *RequestContext `json:"context,omitempty"`
+	// Nonce/Timestamp (unix seconds) protect against replays; required for clients with require_nonce
+	Nonce     string `json:"nonce,omitempty"`
+	Timestamp int64  `json:"timestamp,omitempty"`
+	// Priority is "interactive" (default) or "batch"; batch requests queue behind
+	// interactive ones and may skip model policies under load
+	Priority string `json:"priority,omitempty"`
+	// Attachments are plain-text extracts of files passed along with the prompt
+	Attachments []Attachment `json:"attachments,omitempty"`
+	// CacheControl takes Cache-Control request directives for the decision cache:
+	// "no-cache" forces re-evaluation, "max-age=N" accepts decisions up to N seconds
+	// old even if policies changed since, "no-store" keeps the decision out of the cache
+	CacheControl string `json:"cache_control,omitempty"`
+	// Locale (BCP 47, e.g. "de-CH") selects the language of UserMessage; the HTTP
+	// API falls back to Accept-Language, then the catalog's default locale
+	Locale string `json:"locale,omitempty"`
+}
+
+// Attachment is the extracted text of a file, analyzed according to its MIME type
+type Attachment struct {
+	Filename string `json:"filename,omitempty"`
+	MimeType string `json:"mime_type"` // e.g. "text/plain", "text/csv", "text/html", "application/json"
+	Content  string `json:"content"`
+}
+
+// ChatMessage is an OpenAI-style chat message
+type ChatMessage struct {
+	Role       string     `json:"role"` // "system", "developer", "user", "assistant", "tool"
+	Content    string     `json:"content"`
+	Name       string     `json:"name,omitempty"`
+	ToolCalls  []ToolCall `json:"tool_calls,omitempty"`
+	ToolCallID string     `json:"tool_call_id,omitempty"`
+}
+
+// ToolCall is a function call requested by the assistant
+type ToolCall struct {
+	ID       string           `json:"id,omitempty"`
+	Type     string           `json:"type,omitempty"`
+	Function ToolCallFunction `json:"function"`
+}
+
+// ToolCallFunction holds the called function name and its JSON arguments
+type ToolCallFunction struct {
+	Name      string `json:"name"`
+	Arguments string `json:"arguments"`
+}
+
+// AnalyzableContent returns the text scanned for a message: its content plus tool call arguments
+func (m ChatMessage) AnalyzableContent() string {
+	if len(m.ToolCalls) == 0 {
+		return m.Content
+	}
+	content := m.Content
+	for _, call := range m.ToolCalls {
+		content += "\n" + call.Function.Name + "(" + call.Function.Arguments + ")"
+	}
+	return content
 }
 
 type RequestContext struct {
@@ -35,19 +142,211 @@ type RequestContext struct {
 
 // AnalyzeResponse is the output of prompt analysis
 type AnalyzeResponse struct {
-	RequestID         uuid.UUID       `json:"request_id"`
-	Allowed           bool            `json:"allowed"`
-	Action            string          `json:"action"`
-	TriggeredPolicies []PolicyMatch   `json:"triggered_policies"`
-	RedactedPrompt    string          `json:"redacted_prompt,omitempty"`
-	LatencyMs         int64           `json:"latency_ms"`
+	RequestID         uuid.UUID           `json:"request_id"`
+	Allowed           bool                `json:"allowed"`
+	Action            string              `json:"action"`
+	TriggeredPolicies []PolicyMatch       `json:"triggered_policies"`
+	RedactedPrompt    string              `json:"redacted_prompt,omitempty"`
+	MessageResults    []MessageVerdict    `json:"message_results,omitempty"`
+	AttachmentResults []AttachmentVerdict `json:"attachment_results,omitempty"`
+	Override          *SessionOverride    `json:"override,omitempty"`          // Set when a pinned session decision applied
+	MonitorOnly       bool                `json:"monitor_only,omitempty"`      // Monitor mode: action is what would have been enforced
+	DecisionToken     string              `json:"decision_token,omitempty"`    // Signed proof of the decision (when enabled)
+	Signals           *Signals            `json:"signals,omitempty"`           // Stylometric features of the analyzed text
+	Tokens            *TokenCounts        `json:"tokens,omitempty"`            // Token counts of the prompt and response
+	Cache             *CacheStatus        `json:"cache,omitempty"`             // Set when the decision cache is enabled
+	DeferredPolicies  []string            `json:"deferred_policies,omitempty"` // Model policies skipped for a batch request under load
+	EvaluationMode    string              `json:"evaluation_mode,omitempty"`   // "degraded" when model providers were unavailable
+	Trace             []PolicyTrace       `json:"trace,omitempty"`             // Per-policy evaluation record of ?debug=true requests
+	Allowlisted       bool                `json:"allowlisted,omitempty"`       // The prompt's content hash is allowlisted; nothing was evaluated
+	AllowedBy         []PolicyMatch       `json:"allowed_by,omitempty"`        // Allow policies that let a default-block client's request through
+	DefaultBlocked    bool                `json:"default_blocked,omitempty"`   // Blocked by the client's default action: no allow policy matched
+	PolicyHash        string              `json:"policy_hash,omitempty"`       // Policy snapshot that produced the decision (absent when none was evaluated)
+	UserMessage       string              `json:"user_message,omitempty"`      // Non-technical explanation of a block or redaction for end users (when a catalog is configured)
+	Overflow          *LimitOverflow      `json:"overflow,omitempty"`          // What the gateway's request limits cut from the evaluation or response
+	LatencyMs         int64               `json:"latency_ms"`
+}
+
+// LimitOverflow reports what the gateway's per-request limits left out; absent
+// when the request stayed within them
+type LimitOverflow struct {
+	PoliciesSkipped    int `json:"policies_skipped,omitempty"`    // Least severe policies not evaluated
+	MatchesOmitted     int `json:"matches_omitted,omitempty"`     // Least severe matches left out of triggered_policies lists
+	OccurrencesOmitted int `json:"occurrences_omitted,omitempty"` // Occurrences left out of matches
+	RedactionsSkipped  int `json:"redactions_skipped,omitempty"`  // Redactions not applied; the request is blocked instead
+}
+
+// BatchAnalyzeRequest evaluates several analyze requests, each with its own
+// client and context, in one call
+type BatchAnalyzeRequest struct {
+	Items []AnalyzeRequest `json:"items"`
+}
+
+// BatchAnalyzeResponse holds one result per item, in request order
+type BatchAnalyzeResponse struct {
+	Results   []BatchAnalyzeResult `json:"results"`
+	Failed    int                  `json:"failed"` // Items that returned an error instead of a decision
+	LatencyMs int64                `json:"latency_ms"`
+}
+
+// BatchAnalyzeResult is one item's decision, or the error /v1/analyze would have
+// answered it with
+type BatchAnalyzeResult struct {
+	Index  int              `json:"index"`
+	Status int              `json:"status"` // HTTP status the item alone would have received
+	Result *AnalyzeResponse `json:"result,omitempty"`
+	Error  *APIError        `json:"error,omitempty"`
+}
+
+// ExplainRequest is the body of POST /v1/analyze/explain: content to annotate with
+// the spans the client's policies match. One of Prompt or Messages must be set
+type ExplainRequest struct {
+	ClientID string        `json:"client_id"`
+	Prompt   string        `json:"prompt,omitempty"`
+	Response string        `json:"response,omitempty"`
+	Messages []ChatMessage `json:"messages,omitempty"`
+}
+
+// ExplainResponse annotates analyzed content with match spans for highlighting in
+// UIs; nothing is audited or counted
+type ExplainResponse struct {
+	Action     string          `json:"action"` // Action the matches resolve to, before client defaults and session overrides
+	Texts      []ExplainedText `json:"texts"`
+	Unlocated  []PolicyMatch   `json:"unlocated"`                  // Matches without a position (profanity, evasions, scoped policies)
+	Skipped    []string        `json:"skipped_policies,omitempty"` // Model policies, which are not evaluated
+	PolicyHash string          `json:"policy_hash,omitempty"`
+}
+
+// ExplainedText is one analyzed text with the spans matched in it
+type ExplainedText struct {
+	Source       string        `json:"source"` // "prompt", "response" or "message"
+	MessageIndex *int          `json:"message_index,omitempty"`
+	Role         string        `json:"role,omitempty"`
+	Content      string        `json:"content"`
+	Spans        []MatchSpan   `json:"spans"`    // Ordered by start; spans of different policies may overlap
+	Segments     []TextSegment `json:"segments"` // Content cut at every span boundary, in order
+}
+
+// MatchSpan is one occurrence of a policy match, as byte offsets [start, end) in
+// the text's content
+type MatchSpan struct {
+	Start      int       `json:"start"`
+	End        int       `json:"end"`
+	PolicyID   uuid.UUID `json:"policy_id"`
+	PolicyName string    `json:"policy_name"`
+	Severity   string    `json:"severity"`
+	Action     string    `json:"action"`
+}
+
+// TextSegment is a run of content covered by the same spans, so UIs can render
+// highlights without handling byte offsets
+type TextSegment struct {
+	Text  string `json:"text"`
+	Spans []int  `json:"spans"` // Indexes into the text's spans; empty for unmatched text
+}
+
+// StreamFragment is one piece of content sent on the StreamAnalyze gRPC stream;
+// consecutive fragments with the same role continue one message
+type StreamFragment struct {
+	ClientID string          `json:"client_id,omitempty"` // Required on the first fragment; later ones inherit it
+	Context  *RequestContext `json:"context,omitempty"`
+	Role     string          `json:"role,omitempty"`   // Chat role of the content (default "user")
+	Locale   string          `json:"locale,omitempty"` // Language of user_message; later fragments inherit it
+	Content  string          `json:"content"`
+}
+
+// StreamVerdict is the cumulative decision sent after every StreamAnalyze fragment
+type StreamVerdict struct {
+	Sequence          int              `json:"sequence"`           // Fragments received so far
+	Allowed           bool             `json:"allowed"`            // False once any fragment was not allowed
+	Action            string           `json:"action"`             // Strictest action of the stream so far
+	TriggeredPolicies []PolicyMatch    `json:"triggered_policies"` // Latest match of every policy triggered so far
+	Decision          *AnalyzeResponse `json:"decision,omitempty"` // Evaluation of the latest fragment
+	Error             *APIError        `json:"error,omitempty"`    // Set when the latest fragment couldn't be evaluated
+}
+
+// Signals are cheap stylometric features of the analyzed text, returned so
+// downstream ranking systems don't have to recompute them
+type Signals struct {
+	Length             int     `json:"length"`               // Characters (runes)
+	WordCount          int     `json:"word_count"`           // Whitespace/punctuation separated tokens
+	Entropy            float64 `json:"entropy"`              // Shannon entropy in bits per character
+	UppercaseRatio     float64 `json:"uppercase_ratio"`      // Uppercase letters / letters
+	RepeatedTokenRatio float64 `json:"repeated_token_ratio"` // 1 - unique tokens / tokens
+	NonASCIIFraction   float64 `json:"non_ascii_fraction"`   // Non-ASCII characters / characters
+	ImperativeDensity  float64 `json:"imperative_density"`   // Sentences opening with an imperative verb / sentences
+}
+
+// CacheStatus reports whether a decision was answered from the decision cache
+type CacheStatus struct {
+	Hit        bool  `json:"hit"`
+	AgeSeconds int64 `json:"age_seconds"` // Time since the decision was evaluated
+}
+
+// TokenCounts are the prompt and response sizes in model tokens
+type TokenCounts struct {
+	Prompt   int    `json:"prompt"`   // Prompt, user/system/tool messages, document and attachments
+	Response int    `json:"response"` // Response or assistant messages
+	Encoding string `json:"encoding"` // Vocabulary used, e.g. "cl100k_base", or "estimate"
+}
+
+// PolicyTrace is one policy's evaluation in a debug trace
+type PolicyTrace struct {
+	PolicyID    uuid.UUID `json:"policy_id"`
+	PolicyName  string    `json:"policy_name"`
+	PatternType string    `json:"pattern_type"`
+	Target      string    `json:"target,omitempty"` // What was analyzed: "prompt", "messages[1]", "$.field", "attachments[0]"
+	Status      string    `json:"status"`           // "matched", "not_matched", "skipped" or "error"
+	Reason      string    `json:"reason,omitempty"` // Why a policy was skipped or decided without running
+	DurationUs  int64     `json:"duration_us"`
+	Error       string    `json:"error,omitempty"`
+}
+
+// AttachmentVerdict is the per-attachment decision when attachments are analyzed
+type AttachmentVerdict struct {
+	Index             int           `json:"index"`
+	Filename          string        `json:"filename,omitempty"`
+	MimeType          string        `json:"mime_type"`
+	Allowed           bool          `json:"allowed"`
+	Action            string        `json:"action"`
+	TriggeredPolicies []PolicyMatch `json:"triggered_policies"`
+	RedactedContent   string        `json:"redacted_content,omitempty"` // Plain-text attachments only
+}
+
+// MessageVerdict is the per-message decision when analyzing chat messages
+type MessageVerdict struct {
+	Index             int           `json:"index"`
+	Role              string        `json:"role"`
+	Allowed           bool          `json:"allowed"`
+	Action            string        `json:"action"`
+	TriggeredPolicies []PolicyMatch `json:"triggered_policies"`
+	RedactedContent   string        `json:"redacted_content,omitempty"`
+	Allowlisted       bool          `json:"allowlisted,omitempty"` // The message's content hash is allowlisted; it was not evaluated
 }
 
 type PolicyMatch struct {
-	PolicyID       uuid.UUID `json:"policy_id"`
-	PolicyName     string    `json:"policy_name"`
-	Severity       string    `json:"severity"`
-	MatchedPattern string    `json:"matched_pattern"`
+	PolicyID        uuid.UUID `json:"policy_id"`
+	PolicyName      string    `json:"policy_name"`
+	Severity        string    `json:"severity"`
+	MatchedPattern  string    `json:"matched_pattern"`
+	MessageIndex    *int      `json:"message_index,omitempty"`    // Set when analyzing chat messages
+	FieldPath       string    `json:"field_path,omitempty"`       // Set when analyzing a JSON document
+	AttachmentIndex *int      `json:"attachment_index,omitempty"` // Set when analyzing attachments (with FieldPath for JSON ones)
+	MatchCount      int       `json:"match_count,omitempty"`      // Set for policies with escalations
+	Action          string    `json:"action,omitempty"`           // Escalated action; enforced when stricter than the policy's
+	// Start/End are the byte offsets of the first occurrence in the analyzed text (the
+	// prompt, message, document field or attachment); Occurrences lists every one.
+	// Absent for detectors without positions (models, profanity, ...) and for text
+	// only found after undoing an evasion
+	Start       *int              `json:"start,omitempty"`
+	End         *int              `json:"end,omitempty"`
+	Occurrences []MatchOccurrence `json:"occurrences,omitempty"`
+}
+
+// MatchOccurrence is one place a policy matched, as byte offsets [start, end)
+type MatchOccurrence struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 // CreatePolicyRequest is the input for creating a policy
@@ -58,6 +357,206 @@ type CreatePolicyRequest struct {
 	PatternValue string `json:"pattern_value"`
 	Severity     string `json:"severity"`
 	Action       string `json:"action"`
+	// Priority puts the policy ahead of lower-priority ones with the same match (default 0)
+	Priority int `json:"priority,omitempty"`
+	// TierActions overrides Action per client trust tier
+	TierActions map[string]string `json:"tier_actions,omitempty"`
+	// Roles restricts the policy to chat messages with these roles
+	Roles []string `json:"roles,omitempty"`
+	// ScanScope is "all" (default), "code" or "prose"; StripMarkup removes markdown/HTML first
+	ScanScope   string `json:"scan_scope,omitempty"`
+	StripMarkup bool   `json:"strip_markup,omitempty"`
+	// MaxInputBytes caps how much content the policy scans (0 = unlimited)
+	MaxInputBytes int `json:"max_input_bytes,omitempty"`
+	// Stem matches keyword policies on stemmed words
+	Stem bool `json:"stem,omitempty"`
+	// MatchMode is "substring" (default), "whole_word" or "word_boundary" for keyword policies
+	MatchMode string `json:"match_mode,omitempty"`
+	// AppliesToClients restricts the policy to matching clients
+	AppliesToClients []string `json:"applies_to_clients,omitempty"`
+	// WebhookURL is notified whenever the policy fires
+	WebhookURL string `json:"webhook_url,omitempty"`
+	// NotifyCooldownSeconds rate-limits notifications (not enforcement) per client,
+	// or per session when NotifyCooldownScope is "session"
+	NotifyCooldownSeconds int    `json:"notify_cooldown_seconds,omitempty"`
+	NotifyCooldownScope   string `json:"notify_cooldown_scope,omitempty"`
+	// CaptureConstraints validate regex capture groups after matching
+	CaptureConstraints []CaptureConstraint `json:"capture_constraints,omitempty"`
+	// Escalations apply once the policy matches at least min_matches times
+	Escalations []Escalation `json:"escalations,omitempty"`
+	// Tags group policies for bulk updates
+	Tags []string `json:"tags,omitempty"`
+	// Owner, Team and ReviewBy (YYYY-MM-DD) record who maintains the policy and until when
+	Owner    string `json:"owner,omitempty"`
+	Team     string `json:"team,omitempty"`
+	ReviewBy string `json:"review_by,omitempty"`
+	// Source is "manual" (default), "import" or "feed"
+	Source string `json:"source,omitempty"`
+}
+
+// BulkPolicyUpdate is the body of PATCH /v1/policies: the changes applied to every
+// selected policy (unset fields are left alone)
+type BulkPolicyUpdate struct {
+	Enabled  *bool  `json:"enabled,omitempty"`
+	Severity string `json:"severity,omitempty"`
+}
+
+// PolicyChange is one policy's state before and after a bulk update
+type PolicyChange struct {
+	PolicyID       uuid.UUID `json:"policy_id"`
+	PolicyName     string    `json:"policy_name"`
+	EnabledBefore  bool      `json:"enabled_before"`
+	Enabled        bool      `json:"enabled"`
+	SeverityBefore string    `json:"severity_before"`
+	Severity       string    `json:"severity"`
+}
+
+// BulkPolicyUpdateResult summarizes a bulk update
+type BulkPolicyUpdateResult struct {
+	Matched int            `json:"matched"` // Policies selected
+	Changed int            `json:"changed"` // Policies whose state changed
+	Changes []PolicyChange `json:"changes"`
+}
+
+// PolicyBundle is a set of policy definitions evaluated offline, in the format of
+// GET /v1/policies; policies without an "enabled" field are enabled
+type PolicyBundle []Policy
+
+// UnmarshalJSON decodes the bundle, enabling policies by default
+func (b *PolicyBundle) UnmarshalJSON(data []byte) error {
+	var raw []json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	policies := make(PolicyBundle, len(raw))
+	for i, r := range raw {
+		policies[i].Enabled = true
+		if err := json.Unmarshal(r, &policies[i]); err != nil {
+			return fmt.Errorf("policy %d: %w", i, err)
+		}
+	}
+	*b = policies
+	return nil
+}
+
+// PolicyDiffRequest compares the decisions of two policy bundles over a corpus
+type PolicyDiffRequest struct {
+	Before PolicyBundle     `json:"before,omitempty"` // Defaults to the live policies
+	After  PolicyBundle     `json:"after"`
+	Corpus []AnalyzeRequest `json:"corpus"` // Prompts (and optional responses or messages) to evaluate
+}
+
+// PolicyLintReport is the result of linting a policy definition before creating it
+type PolicyLintReport struct {
+	Valid    bool              `json:"valid"`
+	Error    string            `json:"error,omitempty"` // Why creating the policy would fail
+	Warnings []LintWarning     `json:"warnings"`
+	Corpus   *LintCorpusResult `json:"corpus,omitempty"` // Absent for invalid and model policies
+}
+
+// LintWarning is one best-practice problem with a policy definition
+type LintWarning struct {
+	Code    string `json:"code"` // "broad_pattern", "missing_anchor", "duplicate" or "short_keyword"
+	Message string `json:"message"`
+	Policy  string `json:"policy,omitempty"` // Existing policy a duplicate repeats
+}
+
+// LintCorpusResult is how often a policy matches the benign prompt corpus
+type LintCorpusResult struct {
+	Source        string   `json:"source"` // "bundled" or "uploaded"
+	Size          int      `json:"size"`
+	Matches       int      `json:"matches"`
+	MatchPercent  float64  `json:"match_percent"`
+	MatchesPer10k float64  `json:"matches_per_10k"` // Estimated false positives per 10,000 benign prompts
+	Examples      []string `json:"examples"`        // A few of the matched prompts
+}
+
+// PolicyTestRequest is the body of POST /v1/policies/test: a candidate policy
+// definition and sample content to run it on
+type PolicyTestRequest struct {
+	Policy  CreatePolicyRequest `json:"policy"`
+	Samples []string            `json:"samples"`
+}
+
+// PolicyTestResponse reports whether a candidate policy matches each sample
+type PolicyTestResponse struct {
+	Valid   bool               `json:"valid"`
+	Error   string             `json:"error,omitempty"` // Why creating the policy would fail
+	Results []PolicyTestResult `json:"results"`         // One per sample, in order; empty when invalid
+}
+
+// PolicyTestResult is a candidate policy's verdict on one sample
+type PolicyTestResult struct {
+	Matched bool         `json:"matched"`
+	Action  string       `json:"action,omitempty"` // Action the match would enforce, escalations included
+	Match   *PolicyMatch `json:"match,omitempty"`
+}
+
+// BenignCorpusInfo describes the benign prompt corpus false positives are estimated on
+type BenignCorpusInfo struct {
+	Source    string     `json:"source"` // "bundled", or "uploaded" with PUT /v1/corpus/benign
+	Size      int        `json:"size"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// UploadBenignCorpusRequest replaces the bundled benign corpus
+type UploadBenignCorpusRequest struct {
+	Prompts []string `json:"prompts"`
+}
+
+// PolicyDiffReport lists the decisions that change between two policy bundles
+type PolicyDiffReport struct {
+	Evaluated            int                 `json:"evaluated"`   // Corpus entries evaluated
+	Changed              int                 `json:"changed"`     // Entries whose decision changes
+	Transitions          map[string]int      `json:"transitions"` // "allow->block" and so on, by count
+	Policies             []PolicyDiffSummary `json:"policies"`    // Policies whose matches change, largest blast radius first
+	Changes              []DecisionChange    `json:"changes"`     // Changed entries, in corpus order
+	SkippedModelPolicies []string            `json:"skipped_model_policies,omitempty"`
+	BenignCorpus         *BenignCorpusInfo   `json:"benign_corpus,omitempty"` // Corpus of the benign_matches_per_10k estimates
+}
+
+// PolicyDiffSummary is one policy's contribution to a decision diff
+type PolicyDiffSummary struct {
+	PolicyName       string `json:"policy_name"`
+	Change           string `json:"change"` // "added", "removed", "modified" or "unchanged"
+	MatchesBefore    int    `json:"matches_before"`
+	MatchesAfter     int    `json:"matches_after"`
+	NewlyMatched     int    `json:"newly_matched"`
+	NoLongerMatched  int    `json:"no_longer_matched"`
+	DecisionsChanged int    `json:"decisions_changed"` // Changed entries this policy's match changed on
+	Errors           int    `json:"errors,omitempty"`  // Entries the policy failed to evaluate
+	// BenignMatchesPer10k estimates the false positives of an added or modified
+	// policy per 10,000 benign prompts
+	BenignMatchesPer10k *float64 `json:"benign_matches_per_10k,omitempty"`
+}
+
+// DecisionChange is a corpus entry whose decision differs between the bundles
+type DecisionChange struct {
+	Index          int      `json:"index"`
+	ClientID       string   `json:"client_id,omitempty"`
+	Before         string   `json:"before"` // "allow", "log", "redact" or "block"
+	After          string   `json:"after"`
+	PoliciesBefore []string `json:"policies_before"`
+	PoliciesAfter  []string `json:"policies_after"`
+}
+
+// CaptureConstraint restricts one capture group of a regex policy
+// Group is a group name or number; every bound that is set must hold
+type CaptureConstraint struct {
+	Group     string   `json:"group"`
+	Min       *float64 `json:"min,omitempty"`        // Numeric lower bound (inclusive)
+	Max       *float64 `json:"max,omitempty"`        // Numeric upper bound (inclusive)
+	MinLength int      `json:"min_length,omitempty"` // Minimum length in characters
+	MaxLength int      `json:"max_length,omitempty"` // Maximum length in characters (0 = unlimited)
+	Checksum  string   `json:"checksum,omitempty"`   // "luhn"
+}
+
+// Escalation raises a policy's severity and/or action once it matches at least
+// MinMatches times in one text (e.g. five emails block as bulk PII exfiltration)
+type Escalation struct {
+	MinMatches int    `json:"min_matches"`
+	Severity   string `json:"severity,omitempty"`
+	Action     string `json:"action,omitempty"` // "log", "redact" or "block"; never weaker than the policy's own
 }
 
 // AuditLog represents an audit log entry
@@ -65,17 +564,432 @@ type AuditLog struct {
 	ID                uuid.UUID   `json:"id"`
 	RequestID         uuid.UUID   `json:"request_id"`
 	ClientID          string      `json:"client_id"`
+	SessionID         string      `json:"session_id,omitempty"`
 	PromptHash        string      `json:"prompt_hash"`
 	ResponseHash      string      `json:"response_hash,omitempty"`
 	PoliciesTriggered []uuid.UUID `json:"policies_triggered"`
 	ActionTaken       string      `json:"action_taken"`
 	LatencyMs         int         `json:"latency_ms"`
 	CreatedAt         time.Time   `json:"created_at"`
+	Tenant            string      `json:"tenant,omitempty"` // Routes the entry to the tenant's isolated storage; not persisted
+	GatewayOrigin
+}
+
+// GatewayOrigin identifies the gateway deployment that made a decision
+type GatewayOrigin struct {
+	InstanceID     string `json:"instance_id,omitempty"`
+	Hostname       string `json:"hostname,omitempty"`
+	Region         string `json:"region,omitempty"`
+	Zone           string `json:"zone,omitempty"`
+	GatewayVersion string `json:"gateway_version,omitempty"`
+}
+
+// ClusterMember is one gateway replica's self-reported state
+type ClusterMember struct {
+	GatewayOrigin
+	Status           string            `json:"status"` // "healthy", "degraded", "maintenance", or "stale" once heartbeats stop
+	StartedAt        time.Time         `json:"started_at"`
+	LastSeen         time.Time         `json:"last_seen"`
+	PolicyHash       string            `json:"policy_hash"`
+	PolicyCount      int               `json:"policy_count"`
+	PoliciesLoadedAt time.Time         `json:"policies_loaded_at"`
+	Breakers         map[string]string `json:"breakers,omitempty"` // Circuit breaker states by name
+}
+
+// ClusterState is the fleet as seen through the replica registry
+type ClusterState struct {
+	Members      []ClusterMember `json:"members"`
+	PolicyHashes map[string]int  `json:"policy_hashes"` // Live members per policy snapshot
+	Versions     map[string]int  `json:"versions"`      // Live members per gateway version
+	Consistent   bool            `json:"consistent"`    // All live members run the same policies and version
+}
+
+// AuditFilter narrows an audit log query; empty fields match everything
+type AuditFilter struct {
+	ClientID       string
+	SessionID      string
+	Action         string
+	InstanceID     string
+	Hostname       string
+	Region         string
+	Zone           string
+	GatewayVersion string
+	PromptHash     string
+	Since          time.Time
+	Until          time.Time
+	Limit          int
+}
+
+// DecisionEvent describes a completed analyze decision for background observers
+type DecisionEvent struct {
+	Audit    AuditLog         `json:"audit"`
+	Matches  []PolicyMatch    `json:"matches"`
+	Model    string           `json:"model,omitempty"`
+	Response *AnalyzeResponse `json:"-"` // Full response; may carry redacted content, never serialize it
+	Request  *AnalyzeRequest  `json:"-"` // Original request; carries raw content, never serialize it
+	// PromptSimhash fingerprints the prompt of blocked decisions (0 otherwise)
+	PromptSimhash uint64 `json:"-"`
+}
+
+// DecisionRecord is an analyze decision mirrored to the firehose
+// It carries hashes and policy metadata only, never prompt, response or matched text
+type DecisionRecord struct {
+	RequestID    uuid.UUID             `json:"request_id"`
+	ClientID     string                `json:"client_id"`
+	SessionID    string                `json:"session_id,omitempty"`
+	Model        string                `json:"model,omitempty"`
+	Action       string                `json:"action"`
+	Allowed      bool                  `json:"allowed"`
+	Redacted     bool                  `json:"redacted,omitempty"`
+	MonitorOnly  bool                  `json:"monitor_only,omitempty"`
+	Override     string                `json:"override,omitempty"` // Pinned session action that applied
+	PolicyHash   string                `json:"policy_hash,omitempty"`
+	Policies     []DecisionRecordMatch `json:"policies"`
+	PromptHash   string                `json:"prompt_hash"`
+	ResponseHash string                `json:"response_hash,omitempty"`
+	Signals      *Signals              `json:"signals,omitempty"`
+	LatencyMs    int64                 `json:"latency_ms"`
+	Timestamp    time.Time             `json:"timestamp"`
+}
+
+// DecisionRecordMatch is a matched policy in a DecisionRecord, without the matched text
+type DecisionRecordMatch struct {
+	PolicyID        uuid.UUID `json:"policy_id"`
+	PolicyName      string    `json:"policy_name"`
+	Severity        string    `json:"severity"`
+	MessageIndex    *int      `json:"message_index,omitempty"`
+	FieldPath       string    `json:"field_path,omitempty"`
+	AttachmentIndex *int      `json:"attachment_index,omitempty"`
+}
+
+// ShadowDecision is the part of a decision compared between gateways
+type ShadowDecision struct {
+	Action    string   `json:"action"`
+	Allowed   bool     `json:"allowed"`
+	Policies  []string `json:"policies"` // Triggered policy names, sorted
+	LatencyMs int64    `json:"latency_ms"`
+}
+
+// ShadowDiff is a mirrored request the shadow gateway decided differently
+type ShadowDiff struct {
+	RequestID uuid.UUID      `json:"request_id"`
+	ClientID  string         `json:"client_id"`
+	Primary   ShadowDecision `json:"primary"`
+	Shadow    ShadowDecision `json:"shadow"`
+	Timestamp time.Time      `json:"timestamp"`
+}
+
+// ShadowStatus summarizes traffic mirrored to the shadow gateway by this replica
+type ShadowStatus struct {
+	URL        string       `json:"url"`
+	SampleRate float64      `json:"sample_rate"`
+	Mirrored   int64        `json:"mirrored"` // Shadow responses compared
+	Matched    int64        `json:"matched"`
+	Diffs      int64        `json:"diffs"`
+	Errors     int64        `json:"errors"`  // Shadow requests that failed
+	Dropped    int64        `json:"dropped"` // Sampled requests dropped because the queue was full
+	Recent     []ShadowDiff `json:"recent"`  // Most recent diffs, newest first
+}
+
+// SessionTimelineResponse is the ordered decision history for a session
+type SessionTimelineResponse struct {
+	SessionID string     `json:"session_id"`
+	Count     int        `json:"count"`
+	Decisions []AuditLog `json:"decisions"`
+}
+
+// SessionOverride is a decision pinned for every request of a session until it expires
+type SessionOverride struct {
+	SessionID string    `json:"session_id"`
+	Action    string    `json:"action"` // "block" or "allow"
+	Reason    string    `json:"reason,omitempty"`
+	SetBy     string    `json:"set_by,omitempty"` // Operator or alert rule that pinned the decision
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SetSessionOverrideRequest is the body for pinning a session decision
+type SetSessionOverrideRequest struct {
+	Action     string `json:"action"`
+	Reason     string `json:"reason,omitempty"`
+	SetBy      string `json:"set_by,omitempty"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// VerifyTokenRequest is the body of /v1/verify
+type VerifyTokenRequest struct {
+	Token string `json:"token"`
+}
+
+// VerifyTokenResponse reports whether a decision token is authentic and unexpired
+type VerifyTokenResponse struct {
+	Valid  bool        `json:"valid"`
+	Error  string      `json:"error,omitempty"`
+	Claims interface{} `json:"claims,omitempty"`
+}
+
+// ErasureReport summarizes a right-to-erasure purge for a data subject
+type ErasureReport struct {
+	ClientID               string    `json:"client_id,omitempty"`
+	SessionID              string    `json:"session_id,omitempty"`
+	AuditLogsDeleted       int64     `json:"audit_logs_deleted"`
+	PendingLogsDeleted     int64     `json:"pending_logs_deleted"`
+	CachedDecisionsDeleted int       `json:"cached_decisions_deleted"` // Decisions dropped from this instance's decision cache
+	CompletedAt            time.Time `json:"completed_at"`
+}
+
+// Incident tracks the handling of a critical match or triggered alert rule
+type Incident struct {
+	ID              uuid.UUID   `json:"id"`
+	Title           string      `json:"title"`
+	Severity        string      `json:"severity"` // "low", "medium", "high", "critical"
+	Status          string      `json:"status"`   // "open", "acknowledged", "resolved"
+	Source          string      `json:"source"`   // "policy", "rule", "manual"
+	ClientID        string      `json:"client_id,omitempty"`
+	AuditRequestIDs []uuid.UUID `json:"audit_request_ids"`
+	Notes           string      `json:"notes,omitempty"`
+	AcknowledgedBy  string      `json:"acknowledged_by,omitempty"`
+	AcknowledgedAt  *time.Time  `json:"acknowledged_at,omitempty"`
+	ResolvedBy      string      `json:"resolved_by,omitempty"`
+	ResolvedAt      *time.Time  `json:"resolved_at,omitempty"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       time.Time   `json:"updated_at"`
+}
+
+// IncidentDetail is an incident together with its linked audit entries
+type IncidentDetail struct {
+	Incident
+	AuditEntries []AuditLog `json:"audit_entries"`
+}
+
+// CreateIncidentRequest is the input for manually opening an incident
+type CreateIncidentRequest struct {
+	Title           string      `json:"title"`
+	Severity        string      `json:"severity"`
+	ClientID        string      `json:"client_id,omitempty"`
+	AuditRequestIDs []uuid.UUID `json:"audit_request_ids,omitempty"`
+	Notes           string      `json:"notes,omitempty"`
+}
+
+// UpdateIncidentRequest changes an incident's status or notes
+type UpdateIncidentRequest struct {
+	Status *string `json:"status,omitempty"`
+	Notes  *string `json:"notes,omitempty"`
+	Actor  string  `json:"actor,omitempty"` // Who acknowledged/resolved the incident
+}
+
+// Client is a registered API consumer with its derived trust score
+type Client struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name,omitempty"`
+	Verified        bool              `json:"verified"`
+	TrustAdjustment float64           `json:"trust_adjustment"`
+	RequestCount    int64             `json:"request_count"`    // Decisions in the scoring window
+	ViolationCount  int64             `json:"violation_count"`  // Non-allow decisions in the scoring window
+	TrustScore      float64           `json:"trust_score"`      // 0-100
+	TrustTier       string            `json:"trust_tier"`       // "trusted", "standard", "untrusted", "anonymous"
+	Labels          map[string]string `json:"labels,omitempty"` // Matched by policy applies_to_clients label selectors
+	RequireNonce    bool              `json:"require_nonce"`    // Analyze calls must carry a fresh nonce + timestamp
+	DefaultAction   string            `json:"default_action"`   // Decision when no policy matched: "allow", or "block" unless an allow policy matched
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// UpsertClientRequest registers or updates a client
+type UpsertClientRequest struct {
+	Name            string   `json:"name,omitempty"`
+	Verified        *bool    `json:"verified,omitempty"`
+	TrustAdjustment *float64 `json:"trust_adjustment,omitempty"`
+	// Labels replaces the client's labels when present
+	Labels        map[string]string `json:"labels,omitempty"`
+	RequireNonce  *bool             `json:"require_nonce,omitempty"`
+	DefaultAction *string           `json:"default_action,omitempty"`
+}
+
+// Wordlist is a named set of terms matched by "dictionary" policies
+type Wordlist struct {
+	Name        string    `json:"name"`
+	Description string    `json:"description,omitempty"`
+	TermCount   int       `json:"term_count"`
+	Terms       []string  `json:"terms,omitempty"` // Omitted from list responses
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// UpsertWordlistRequest creates or replaces a wordlist
+type UpsertWordlistRequest struct {
+	Description string   `json:"description,omitempty"`
+	Terms       []string `json:"terms"`
+}
+
+// AllowlistRequest adds known-benign prompts to the allowlist, by content or by
+// content hash (hex, as in the audit log's prompt_hash)
+type AllowlistRequest struct {
+	Prompts []string `json:"prompts,omitempty"`
+	Hashes  []string `json:"hashes,omitempty"`
+}
+
+// AllowlistResponse lists the allowlisted hashes
+type AllowlistResponse struct {
+	Hashes []string `json:"hashes"`
+	Count  int      `json:"count"`
+	Added  int      `json:"added,omitempty"` // Hashes that were new, on POST
+}
+
+// AuditIngestRequest carries audit entries forwarded to the region owning audit persistence
+type AuditIngestRequest struct {
+	Entries []AuditLog `json:"entries"`
+}
+
+// AuditIngestResponse reports how many forwarded entries were queued
+type AuditIngestResponse struct {
+	Accepted int `json:"accepted"`
+}
+
+// MaintenanceStatus reports maintenance mode and drain progress
+type MaintenanceStatus struct {
+	Enabled           bool       `json:"enabled"`
+	Since             *time.Time `json:"since,omitempty"`
+	RetryAfterSeconds int        `json:"retry_after_seconds,omitempty"`
+	InFlight          int64      `json:"in_flight"`
+	DrainState        string     `json:"drain_state,omitempty"` // "draining", "drained", "drain_failed"
+	DrainError        string     `json:"drain_error,omitempty"`
+}
+
+// SetMaintenanceRequest toggles maintenance mode
+type SetMaintenanceRequest struct {
+	Enabled           bool `json:"enabled"`
+	RetryAfterSeconds int  `json:"retry_after_seconds,omitempty"`
+}
+
+// LogLevelStatus reports the runtime log level and clients under scoped debug logging
+type LogLevelStatus struct {
+	Level        string        `json:"level"` // "debug", "info", "warn", "error"
+	DebugClients []DebugClient `json:"debug_clients,omitempty"`
+}
+
+// DebugClient is a client with per-request debug logging enabled until ExpiresAt
+type DebugClient struct {
+	ClientID  string    `json:"client_id"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// SetLogLevelRequest changes the log level and/or enables debug logging for one client
+// DurationSeconds bounds the client scope (default 900, max 86400); 0 with Disable ends it early
+type SetLogLevelRequest struct {
+	Level           string `json:"level,omitempty"`
+	ClientID        string `json:"client_id,omitempty"`
+	DurationSeconds int    `json:"duration_seconds,omitempty"`
+	Disable         bool   `json:"disable,omitempty"`
+}
+
+// ErrorResponse is the envelope of every API error
+type ErrorResponse struct {
+	Error APIError `json:"error"`
+}
+
+// APIError describes a failed request; Code is stable and machine-readable
+// ("invalid_request", "not_found", "rate_limited", "maintenance", ...)
+type APIError struct {
+	Code      string        `json:"code"`
+	Message   string        `json:"message"`
+	RequestID string        `json:"request_id,omitempty"`
+	Details   []ErrorDetail `json:"details,omitempty"`
+}
+
+// ErrorDetail pinpoints one problem, e.g. an invalid field
+type ErrorDetail struct {
+	Field  string `json:"field,omitempty"`
+	Reason string `json:"reason"`
+}
+
+// SlowPolicy reports a policy whose average evaluation time exceeds the slow threshold
+type SlowPolicy struct {
+	PolicyID    uuid.UUID `json:"policy_id"`
+	PolicyName  string    `json:"policy_name"`
+	PatternType string    `json:"pattern_type"`
+	Evaluations int64     `json:"evaluations"`
+	AvgMs       float64   `json:"avg_ms"`
+	MaxMs       float64   `json:"max_ms"`
+}
+
+// BlockedPrompt is a frequently blocked prompt fingerprint
+// When grouped by simhash, near-duplicate prompts are merged and Variants counts them
+type BlockedPrompt struct {
+	PromptHash  string    `json:"prompt_hash"`
+	Simhash     string    `json:"simhash"` // 16 hex digits
+	BlockCount  int64     `json:"block_count"`
+	ClientCount int       `json:"client_count"`
+	ClientIDs   []string  `json:"client_ids"`
+	Variants    int       `json:"variants,omitempty"`
+	FirstSeen   time.Time `json:"first_seen"`
+	LastSeen    time.Time `json:"last_seen"`
+}
+
+// StatsFilter narrows a roll-up query; empty fields match everything
+type StatsFilter struct {
+	Granularity string // "hour" or "day"
+	ClientID    string
+	Action      string
+	PolicyID    uuid.UUID // Policy roll-ups only
+	Since       time.Time
+	Until       time.Time
+	Limit       int
+}
+
+// StatsBucket counts the decisions of one client and action in one hour or day
+type StatsBucket struct {
+	Bucket       time.Time `json:"bucket"`
+	ClientID     string    `json:"client_id"`
+	Action       string    `json:"action"`
+	Requests     int64     `json:"requests"`
+	AvgLatencyMs float64   `json:"avg_latency_ms"`
+}
+
+// PolicyStatsBucket counts the decisions one policy triggered for one client and
+// action in one hour or day
+type PolicyStatsBucket struct {
+	Bucket     time.Time `json:"bucket"`
+	PolicyID   uuid.UUID `json:"policy_id"`
+	PolicyName string    `json:"policy_name,omitempty"` // Empty once the policy is deleted
+	ClientID   string    `json:"client_id"`
+	Action     string    `json:"action"`
+	Hits       int64     `json:"hits"`
+}
+
+// StatsResponse is the answer of GET /v1/stats
+type StatsResponse struct {
+	Granularity string        `json:"granularity"`
+	RefreshedAt *time.Time    `json:"refreshed_at"` // Null until the first refresh
+	Buckets     []StatsBucket `json:"buckets"`
+}
+
+// PolicyStatsResponse is the answer of GET /v1/stats/policies
+type PolicyStatsResponse struct {
+	Granularity string              `json:"granularity"`
+	RefreshedAt *time.Time          `json:"refreshed_at"` // Null until the first refresh
+	Buckets     []PolicyStatsBucket `json:"buckets"`
+}
+
+// FeatureFlag gates a behavior for a percentage of clients plus an explicit allowlist
+type FeatureFlag struct {
+	Name       string   `json:"name"`
+	Percentage int      `json:"percentage"`        // 0-100, bucketed by client_id
+	Clients    []string `json:"clients,omitempty"` // Always enabled for these client IDs
+	Source     string   `json:"source,omitempty"`  // "default", "env", "file" or "redis"
+}
+
+// VersionResponse reports the gateway build and effective feature flags
+type VersionResponse struct {
+	Version   string        `json:"version"`
+	GoVersion string        `json:"go_version"`
+	Flags     []FeatureFlag `json:"flags"`
 }
 
 // HealthResponse is the health check response
 type HealthResponse struct {
-	Status    string    `json:"status"`
-	Timestamp time.Time `json:"timestamp"`
-	Version   string    `json:"version"`
+	Status      string             `json:"status"` // "healthy" or "maintenance"
+	Timestamp   time.Time          `json:"timestamp"`
+	Version     string             `json:"version"`
+	Maintenance *MaintenanceStatus `json:"maintenance,omitempty"`
 }
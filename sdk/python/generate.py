@@ -0,0 +1,103 @@
+"""Generate prompt_gateway/models.py from the gateway's OpenAPI spec.
+
+Run from the repository root with `make sdk-python`; the output is committed so
+the package installs without a build step.
+"""
+
+import json
+import keyword
+import sys
+from pathlib import Path
+
+HEADER = '''"""Request and response models for the prompt gateway API.
+
+Code generated by sdk/python/generate.py from api/openapi.json. DO NOT EDIT.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+from ._serde import Model
+'''
+
+SCALARS = {"string": "str", "integer": "int", "number": "float", "boolean": "bool"}
+
+
+def ref_name(ref):
+    return ref.rsplit("/", 1)[-1]
+
+
+def annotation(schema):
+    """Python type annotation for a property schema."""
+    if "$ref" in schema:
+        return ref_name(schema["$ref"])
+    kind = schema.get("type")
+    if kind == "array":
+        return "List[%s]" % annotation(schema["items"])
+    if kind == "object" and "additionalProperties" in schema:
+        return "Dict[str, %s]" % annotation(schema["additionalProperties"])
+    return SCALARS.get(kind, "Any")
+
+
+def attr_name(prop):
+    return prop + "_" if keyword.iskeyword(prop) else prop
+
+
+def model(name, schema):
+    props = schema.get("properties", {})
+    required = set(schema.get("required", []))
+    lines = ["", "", "@dataclass", "class %s(Model):" % name]
+    doc = schema.get("description")
+    lines.append('    """%s."""' % doc if doc else '    """%s model."""' % name)
+    lines.append("")
+    # Required fields first: dataclass fields without defaults can't follow ones with
+    ordered = sorted(props, key=lambda p: p not in required)
+    for prop in ordered:
+        typ = annotation(props[prop])
+        attr = attr_name(prop)
+        if prop in required:
+            lines.append("    %s: %s" % (attr, typ))
+        else:
+            lines.append("    %s: Optional[%s] = None" % (attr, typ))
+    if not props:
+        lines.append("    pass")
+    lines.append("")
+    lines.append("    _types = {")
+    for prop in ordered:
+        lines.append('        "%s": "%s",' % (attr_name(prop), annotation(props[prop])))
+    lines.append("    }")
+    return lines
+
+
+def generate(spec):
+    schemas = spec["components"]["schemas"]
+    out = [HEADER.rstrip("\n")]
+    for name in sorted(schemas):
+        if schemas[name].get("type") == "object":
+            out.extend(model(name, schemas[name]))
+    out.append("")
+    out.append("")
+    out.append("MODELS = {")
+    for name in sorted(schemas):
+        if schemas[name].get("type") == "object":
+            out.append('    "%s": %s,' % (name, name))
+    out.append("}")
+    return "\n".join(out) + "\n"
+
+
+def main():
+    root = Path(__file__).resolve().parents[2]
+    spec = json.loads((root / "api" / "openapi.json").read_text())
+    target = Path(__file__).resolve().parent / "prompt_gateway" / "models.py"
+    code = generate(spec)
+    if "--check" in sys.argv:
+        if target.read_text() != code:
+            sys.exit("models.py is out of date with api/openapi.json; run make sdk-python")
+        return
+    target.write_text(code)
+
+
+if __name__ == "__main__":
+    main()
@@ -0,0 +1,17 @@
+"""Python client for the prompt gateway."""
+
+from .client import GatewayClient, redacted_messages, redacted_text
+from .errors import GatewayError, PromptBlocked, RateLimited, Unavailable
+from .models import *  # noqa: F401,F403
+
+__version__ = "0.1.0"
+
+__all__ = [
+    "GatewayClient",
+    "GatewayError",
+    "PromptBlocked",
+    "RateLimited",
+    "Unavailable",
+    "redacted_messages",
+    "redacted_text",
+]
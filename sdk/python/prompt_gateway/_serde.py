@@ -0,0 +1,65 @@
+"""Conversion between generated models and JSON-compatible dicts."""
+
+from __future__ import annotations
+
+import dataclasses
+import re
+from typing import Any, Dict
+
+_LIST = re.compile(r"^List\[(.+)\]$")
+_DICT = re.compile(r"^Dict\[str, (.+)\]$")
+
+
+class Model:
+    """Base class of generated models."""
+
+    _types: Dict[str, str] = {}
+
+    @classmethod
+    def from_dict(cls, data: Dict[str, Any]):
+        """Build a model from a decoded JSON object, ignoring unknown fields so
+        older clients keep working against newer gateways."""
+        kwargs = {}
+        for f in dataclasses.fields(cls):
+            key = f.name[:-1] if f.name.endswith("_") else f.name
+            if key in data:
+                kwargs[f.name] = _decode(cls._types.get(f.name, "Any"), data[key])
+            elif f.default is dataclasses.MISSING:
+                kwargs[f.name] = None
+        return cls(**kwargs)
+
+    def to_dict(self) -> Dict[str, Any]:
+        """Encode the model as a JSON object, omitting unset fields."""
+        out = {}
+        for f in dataclasses.fields(self):
+            value = getattr(self, f.name)
+            if value is not None:
+                out[f.name[:-1] if f.name.endswith("_") else f.name] = _encode(value)
+        return out
+
+
+def _decode(typ: str, value: Any) -> Any:
+    if value is None:
+        return None
+    match = _LIST.match(typ)
+    if match:
+        return [_decode(match.group(1), v) for v in value]
+    match = _DICT.match(typ)
+    if match:
+        return {k: _decode(match.group(1), v) for k, v in value.items()}
+    from .models import MODELS
+
+    model = MODELS.get(typ)
+    if model is not None and isinstance(value, dict):
+        return model.from_dict(value)
+    return value
+
+
+def _encode(value: Any) -> Any:
+    if isinstance(value, Model):
+        return value.to_dict()
+    if isinstance(value, list):
+        return [_encode(v) for v in value]
+    if isinstance(value, dict):
+        return {k: _encode(v) for k, v in value.items()}
+    return value
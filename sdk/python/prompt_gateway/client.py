@@ -0,0 +1,321 @@
+"""HTTP client for the prompt gateway."""
+
+from __future__ import annotations
+
+import dataclasses
+import json
+import random
+import socket
+import time
+import urllib.error
+import urllib.parse
+import urllib.request
+from typing import Any, Dict, List, Optional
+
+from .errors import GatewayError, PromptBlocked, RateLimited, Unavailable
+from .models import (
+    AnalyzeRequest,
+    AnalyzeResponse,
+    BatchAnalyzeRequest,
+    BatchAnalyzeResponse,
+    BulkPolicyUpdate,
+    BulkPolicyUpdateResult,
+    CreatePolicyRequest,
+    ExplainRequest,
+    ExplainResponse,
+    HealthResponse,
+    Policy,
+    PolicyLintReport,
+    PolicyTestRequest,
+    PolicyTestResponse,
+    VerifyTokenRequest,
+    VerifyTokenResponse,
+    VersionResponse,
+)
+
+RETRY_STATUSES = (429, 502, 503, 504)
+
+
+class GatewayClient:
+    """Client for the gateway's /v1 API.
+
+    Requests that fail with a connection error or HTTP 429/502/503/504 are
+    retried up to max_retries times with exponential backoff, waiting at least
+    as long as the gateway's Retry-After header asks.
+
+    api_key is sent as a bearer token and is only needed for admin endpoints
+    such as create_policy. client_id is the default for analyze calls.
+    """
+
+    def __init__(
+        self,
+        base_url: str = "http://localhost:8080",
+        api_key: Optional[str] = None,
+        client_id: Optional[str] = None,
+        timeout: float = 10.0,
+        max_retries: int = 3,
+        backoff: float = 0.5,
+        max_backoff: float = 30.0,
+    ):
+        self.base_url = base_url.rstrip("/")
+        self.api_key = api_key
+        self.client_id = client_id
+        self.timeout = timeout
+        self.max_retries = max_retries
+        self.backoff = backoff
+        self.max_backoff = max_backoff
+        self._sleep = time.sleep
+
+    # Analysis
+
+    def analyze(
+        self,
+        prompt: Optional[str] = None,
+        client_id: Optional[str] = None,
+        debug: bool = False,
+        **fields: Any,
+    ) -> AnalyzeResponse:
+        """Analyze content and return the decision without raising on block.
+
+        Any AnalyzeRequest field may be passed as a keyword argument, e.g.
+        messages=[ChatMessage(role="user", content="...")] or cache_control="no-cache".
+        debug=True returns a per-policy trace in response.trace (requires an admin
+        api_key).
+        """
+        request = AnalyzeRequest(client_id=client_id or self._client_id(), prompt=prompt, **fields)
+        path = "/v1/analyze?debug=true" if debug else "/v1/analyze"
+        return AnalyzeResponse.from_dict(self._request("POST", path, request.to_dict()))
+
+    def analyze_batch(self, items: List[AnalyzeRequest]) -> BatchAnalyzeResponse:
+        """Analyze many requests in one call; results come back in item order.
+
+        Items without a client_id use the client's default. A failed item does not
+        raise: its result carries the error instead of a decision.
+        """
+        items = [dataclasses.replace(item, client_id=item.client_id or self._client_id()) for item in items]
+        body = BatchAnalyzeRequest(items=items).to_dict()
+        return BatchAnalyzeResponse.from_dict(self._request("POST", "/v1/analyze/batch", body))
+
+    def explain(
+        self,
+        prompt: Optional[str] = None,
+        client_id: Optional[str] = None,
+        **fields: Any,
+    ) -> ExplainResponse:
+        """Annotate content with the spans the client's policies match, for
+        highlighting in UIs.
+
+        response= and messages= may be passed as keyword arguments. Nothing is
+        audited; model policies are skipped.
+        """
+        request = ExplainRequest(client_id=client_id or self._client_id(), prompt=prompt, **fields)
+        return ExplainResponse.from_dict(self._request("POST", "/v1/analyze/explain", request.to_dict()))
+
+    def check(self, prompt: Optional[str] = None, **fields: Any) -> AnalyzeResponse:
+        """Analyze content, raising PromptBlocked when the gateway blocks it."""
+        response = self.analyze(prompt, **fields)
+        if not response.allowed:
+            raise PromptBlocked(response)
+        return response
+
+    def redact(self, prompt: str, **fields: Any) -> str:
+        """Return prompt with redact-policy matches masked, raising PromptBlocked
+        when it is blocked outright."""
+        response = self.check(prompt, **fields)
+        return redacted_text(response, prompt)
+
+    def verify_token(self, token: str) -> VerifyTokenResponse:
+        """Verify a decision token issued with an earlier analyze response."""
+        body = VerifyTokenRequest(token=token).to_dict()
+        return VerifyTokenResponse.from_dict(self._request("POST", "/v1/verify", body))
+
+    # Policies
+
+    def list_policies(self, tenant: Optional[str] = None) -> List[Policy]:
+        """List policies, optionally only those visible to tenant."""
+        path = "/v1/policies"
+        if tenant:
+            path += "?" + urllib.parse.urlencode({"tenant": tenant})
+        return [Policy.from_dict(p) for p in self._request("GET", path)]
+
+    def create_policy(self, policy: CreatePolicyRequest) -> Policy:
+        """Create a policy (requires an admin api_key)."""
+        return Policy.from_dict(self._request("POST", "/v1/policies", policy.to_dict()))
+
+    def get_policy(self, policy_id: str, tenant: Optional[str] = None) -> Policy:
+        """Get a policy by ID, enabled or not."""
+        return Policy.from_dict(self._request("GET", _policy_path(policy_id, "", tenant)))
+
+    def replace_policy(self, policy_id: str, policy: CreatePolicyRequest, tenant: Optional[str] = None) -> Policy:
+        """Replace a policy's definition; it keeps its ID, enabled state and hit
+        statistics (requires an admin api_key)."""
+        return Policy.from_dict(self._request("PUT", _policy_path(policy_id, "", tenant), policy.to_dict()))
+
+    def patch_policy(self, policy_id: str, tenant: Optional[str] = None, **fields: Any) -> Policy:
+        """Change only the given definition fields of a policy, e.g.
+        patch_policy(policy_id, severity="high", action="block") (requires an admin
+        api_key)."""
+        return Policy.from_dict(self._request("PATCH", _policy_path(policy_id, "", tenant), fields))
+
+    def enable_policy(self, policy_id: str, tenant: Optional[str] = None) -> Policy:
+        """Switch a policy on (requires an admin api_key)."""
+        return Policy.from_dict(self._request("POST", _policy_path(policy_id, "/enable", tenant), {}))
+
+    def disable_policy(self, policy_id: str, tenant: Optional[str] = None) -> Policy:
+        """Switch a policy off without deleting it (requires an admin api_key)."""
+        return Policy.from_dict(self._request("POST", _policy_path(policy_id, "/disable", tenant), {}))
+
+    def delete_policy(self, policy_id: str, tenant: Optional[str] = None) -> None:
+        """Delete a policy (requires an admin api_key)."""
+        self._request("DELETE", _policy_path(policy_id, "", tenant))
+
+    def lint_policy(self, policy: CreatePolicyRequest, tenant: Optional[str] = None) -> PolicyLintReport:
+        """Check a policy definition without creating it: whether it is valid, and
+        warnings such as matching too many benign prompts or duplicating an
+        existing policy (of tenant, when given)."""
+        path = "/v1/policies/lint"
+        if tenant:
+            path += "?" + urllib.parse.urlencode({"tenant": tenant})
+        return PolicyLintReport.from_dict(self._request("POST", path, policy.to_dict()))
+
+    def test_policy(self, policy: CreatePolicyRequest, samples: List[str]) -> PolicyTestResponse:
+        """Run a policy definition over sample content without creating it, e.g. to
+        iterate on a regex; results has one verdict per sample, in order."""
+        body = PolicyTestRequest(policy=policy, samples=samples).to_dict()
+        return PolicyTestResponse.from_dict(self._request("POST", "/v1/policies/test", body))
+
+    def update_policies(
+        self,
+        tag: Optional[str] = None,
+        team: Optional[str] = None,
+        enabled: Optional[bool] = None,
+        severity: Optional[str] = None,
+        confirm: bool = False,
+    ) -> BulkPolicyUpdateResult:
+        """Enable, disable or re-grade every policy with tag and/or owned by team in
+        one transaction (requires an admin api_key), e.g.
+        update_policies(tag="experimental", enabled=False). confirm allows changing
+        more policies than the gateway's per-minute change budget."""
+        query = {k: v for k, v in (("tag", tag), ("team", team), ("confirm", "true" if confirm else "")) if v}
+        body = BulkPolicyUpdate(enabled=enabled, severity=severity).to_dict()
+        path = "/v1/policies?" + urllib.parse.urlencode(query)
+        return BulkPolicyUpdateResult.from_dict(self._request("PATCH", path, body))
+
+    # Service
+
+    def health(self) -> HealthResponse:
+        """Return the gateway's health; a gateway in maintenance is reported, not raised."""
+        return HealthResponse.from_dict(self._request("GET", "/v1/health", ok_statuses=(503,)))
+
+    def version(self) -> VersionResponse:
+        """Return the gateway version and effective feature flags."""
+        return VersionResponse.from_dict(self._request("GET", "/v1/version"))
+
+    # Transport
+
+    def _client_id(self) -> str:
+        if not self.client_id:
+            raise ValueError("client_id is required (pass it to analyze or GatewayClient)")
+        return self.client_id
+
+    def _request(self, method: str, path: str, body: Any = None, ok_statuses=()) -> Any:
+        data = json.dumps(body).encode() if body is not None else None
+        headers = {"Accept": "application/json"}
+        if data is not None:
+            headers["Content-Type"] = "application/json"
+        if self.api_key:
+            headers["Authorization"] = "Bearer " + self.api_key
+
+        attempt = 0
+        while True:
+            request = urllib.request.Request(self.base_url + path, data=data, headers=headers, method=method)
+            try:
+                with urllib.request.urlopen(request, timeout=self.timeout) as resp:
+                    return _decode(resp.read())
+            except urllib.error.HTTPError as e:
+                payload = e.read()
+                if e.code in ok_statuses:
+                    return _decode(payload)
+                error = _error(e.code, payload, e.headers.get("Retry-After"))
+            except (urllib.error.URLError, socket.timeout, ConnectionError) as e:
+                reason = getattr(e, "reason", e)
+                error = Unavailable(0, "connection_error", str(reason))
+
+            if attempt >= self.max_retries or not _retryable(error):
+                raise error
+            self._sleep(self._delay(attempt, error.retry_after))
+            attempt += 1
+
+    def _delay(self, attempt: int, retry_after: Optional[float]) -> float:
+        delay = min(self.max_backoff, self.backoff * (2**attempt))
+        delay *= 0.5 + random.random() / 2  # jitter so clients don't retry in lockstep
+        if retry_after is not None:
+            delay = max(delay, retry_after)
+        return delay
+
+
+def redacted_text(response: AnalyzeResponse, original: str) -> str:
+    """Return the text a caller should forward: the gateway's redacted prompt
+    when a redact policy rewrote it, otherwise the original. The action is only
+    allow or block, so redaction shows in redacted_prompt alone."""
+    if response.redacted_prompt is not None and response.redacted_prompt != original:
+        return response.redacted_prompt
+    return original
+
+
+def redacted_messages(response: AnalyzeResponse, messages: List[Any]) -> List[Any]:
+    """Return messages with each redacted message's content replaced by the
+    gateway's redacted content; messages are ChatMessage models or dicts."""
+    redacted = {
+        v.index: v.redacted_content
+        for v in response.message_results or []
+        if v.redacted_content is not None
+    }
+    out = []
+    for i, message in enumerate(messages):
+        if i not in redacted:
+            out.append(message)
+        elif isinstance(message, dict):
+            out.append(dict(message, content=redacted[i]))
+        else:
+            out.append(dataclasses.replace(message, content=redacted[i]))
+    return out
+
+
+def _policy_path(policy_id: str, suffix: str, tenant: Optional[str]) -> str:
+    path = "/v1/policies/" + urllib.parse.quote(policy_id, safe="") + suffix
+    if tenant:
+        path += "?" + urllib.parse.urlencode({"tenant": tenant})
+    return path
+
+
+def _decode(payload: bytes) -> Any:
+    return json.loads(payload) if payload else None
+
+
+def _error(status: int, payload: bytes, retry_after: Optional[str]) -> GatewayError:
+    try:
+        envelope: Dict[str, Any] = json.loads(payload).get("error") or {}
+    except (ValueError, AttributeError):
+        envelope = {}
+    try:
+        wait = float(retry_after) if retry_after else None
+    except ValueError:
+        wait = None
+    cls = GatewayError
+    if status == 429:
+        cls = RateLimited
+    elif status in (502, 503, 504):
+        cls = Unavailable
+    return cls(
+        status,
+        envelope.get("code", "http_%d" % status),
+        envelope.get("message", payload.decode(errors="replace").strip() or "HTTP %d" % status),
+        request_id=envelope.get("request_id"),
+        details=envelope.get("details"),
+        retry_after=wait,
+    )
+
+
+def _retryable(error: GatewayError) -> bool:
+    return error.status == 0 or error.status in RETRY_STATUSES
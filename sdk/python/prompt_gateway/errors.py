@@ -0,0 +1,56 @@
+"""Exceptions raised by the prompt gateway client."""
+
+from __future__ import annotations
+
+from typing import Any, Dict, List, Optional
+
+
+class GatewayError(Exception):
+    """The gateway rejected a request or could not be reached.
+
+    status is 0 for transport failures; code, message, request_id and details
+    come from the gateway's error envelope when there is one.
+    """
+
+    def __init__(
+        self,
+        status: int,
+        code: str,
+        message: str,
+        request_id: Optional[str] = None,
+        details: Optional[List[Dict[str, Any]]] = None,
+        retry_after: Optional[float] = None,
+    ):
+        super().__init__("%s: %s" % (code, message) if code else message)
+        self.status = status
+        self.code = code
+        self.message = message
+        self.request_id = request_id
+        self.details = details or []
+        self.retry_after = retry_after
+
+
+class RateLimited(GatewayError):
+    """The client exceeded its rate limit (HTTP 429) and retries ran out."""
+
+
+class Unavailable(GatewayError):
+    """The gateway was unavailable, draining or timed out (HTTP 502, 503, 504)
+    and retries ran out."""
+
+
+class PromptBlocked(Exception):
+    """Content was blocked by policy; raised by GatewayClient.check and redact.
+
+    response is the full AnalyzeResponse, including the triggered policies.
+    """
+
+    def __init__(self, response):
+        names = ", ".join(m.policy_name for m in response.triggered_policies or [])
+        super().__init__("content blocked by policy: %s" % (names or "unknown"))
+        self.response = response
+
+    @property
+    def policies(self) -> List[str]:
+        """Names of the policies that triggered."""
+        return [m.policy_name for m in self.response.triggered_policies or []]
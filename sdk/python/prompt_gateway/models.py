@@ -0,0 +1,1039 @@
+"""Request and response models for the prompt gateway API.
+
+Code generated by sdk/python/generate.py from api/openapi.json. DO NOT EDIT.
+"""
+
+from __future__ import annotations
+
+from dataclasses import dataclass
+from typing import Any, Dict, List, Optional
+
+from ._serde import Model
+
+
+@dataclass
+class APIError(Model):
+    """APIError model."""
+
+    code: str
+    message: str
+    request_id: Optional[str] = None
+    details: Optional[List[ErrorDetail]] = None
+
+    _types = {
+        "code": "str",
+        "message": "str",
+        "request_id": "str",
+        "details": "List[ErrorDetail]",
+    }
+
+
+@dataclass
+class AnalyzeRequest(Model):
+    """Content to analyze: prompt/response, messages, document and/or attachments."""
+
+    client_id: str
+    prompt: Optional[str] = None
+    response: Optional[str] = None
+    messages: Optional[List[ChatMessage]] = None
+    document: Optional[Any] = None
+    include_paths: Optional[List[str]] = None
+    exclude_paths: Optional[List[str]] = None
+    context: Optional[RequestContext] = None
+    nonce: Optional[str] = None
+    timestamp: Optional[int] = None
+    priority: Optional[str] = None
+    attachments: Optional[List[Attachment]] = None
+    cache_control: Optional[str] = None
+    locale: Optional[str] = None
+
+    _types = {
+        "client_id": "str",
+        "prompt": "str",
+        "response": "str",
+        "messages": "List[ChatMessage]",
+        "document": "Any",
+        "include_paths": "List[str]",
+        "exclude_paths": "List[str]",
+        "context": "RequestContext",
+        "nonce": "str",
+        "timestamp": "int",
+        "priority": "str",
+        "attachments": "List[Attachment]",
+        "cache_control": "str",
+        "locale": "str",
+    }
+
+
+@dataclass
+class AnalyzeResponse(Model):
+    """AnalyzeResponse model."""
+
+    request_id: str
+    allowed: bool
+    action: str
+    triggered_policies: List[PolicyMatch]
+    latency_ms: int
+    redacted_prompt: Optional[str] = None
+    message_results: Optional[List[MessageVerdict]] = None
+    attachment_results: Optional[List[AttachmentVerdict]] = None
+    override: Optional[SessionOverride] = None
+    monitor_only: Optional[bool] = None
+    decision_token: Optional[str] = None
+    signals: Optional[Signals] = None
+    deferred_policies: Optional[List[str]] = None
+    tokens: Optional[TokenCounts] = None
+    cache: Optional[CacheStatus] = None
+    evaluation_mode: Optional[str] = None
+    trace: Optional[List[PolicyTrace]] = None
+    allowlisted: Optional[bool] = None
+    allowed_by: Optional[List[PolicyMatch]] = None
+    default_blocked: Optional[bool] = None
+    policy_hash: Optional[str] = None
+    user_message: Optional[str] = None
+    overflow: Optional[LimitOverflow] = None
+
+    _types = {
+        "request_id": "str",
+        "allowed": "bool",
+        "action": "str",
+        "triggered_policies": "List[PolicyMatch]",
+        "latency_ms": "int",
+        "redacted_prompt": "str",
+        "message_results": "List[MessageVerdict]",
+        "attachment_results": "List[AttachmentVerdict]",
+        "override": "SessionOverride",
+        "monitor_only": "bool",
+        "decision_token": "str",
+        "signals": "Signals",
+        "deferred_policies": "List[str]",
+        "tokens": "TokenCounts",
+        "cache": "CacheStatus",
+        "evaluation_mode": "str",
+        "trace": "List[PolicyTrace]",
+        "allowlisted": "bool",
+        "allowed_by": "List[PolicyMatch]",
+        "default_blocked": "bool",
+        "policy_hash": "str",
+        "user_message": "str",
+        "overflow": "LimitOverflow",
+    }
+
+
+@dataclass
+class Attachment(Model):
+    """Attachment model."""
+
+    mime_type: str
+    content: str
+    filename: Optional[str] = None
+
+    _types = {
+        "mime_type": "str",
+        "content": "str",
+        "filename": "str",
+    }
+
+
+@dataclass
+class AttachmentVerdict(Model):
+    """AttachmentVerdict model."""
+
+    index: int
+    mime_type: str
+    allowed: bool
+    action: str
+    triggered_policies: List[PolicyMatch]
+    filename: Optional[str] = None
+    redacted_content: Optional[str] = None
+
+    _types = {
+        "index": "int",
+        "mime_type": "str",
+        "allowed": "bool",
+        "action": "str",
+        "triggered_policies": "List[PolicyMatch]",
+        "filename": "str",
+        "redacted_content": "str",
+    }
+
+
+@dataclass
+class BatchAnalyzeRequest(Model):
+    """BatchAnalyzeRequest model."""
+
+    items: List[AnalyzeRequest]
+
+    _types = {
+        "items": "List[AnalyzeRequest]",
+    }
+
+
+@dataclass
+class BatchAnalyzeResponse(Model):
+    """BatchAnalyzeResponse model."""
+
+    results: List[BatchAnalyzeResult]
+    failed: int
+    latency_ms: int
+
+    _types = {
+        "results": "List[BatchAnalyzeResult]",
+        "failed": "int",
+        "latency_ms": "int",
+    }
+
+
+@dataclass
+class BatchAnalyzeResult(Model):
+    """BatchAnalyzeResult model."""
+
+    index: int
+    status: int
+    result: Optional[AnalyzeResponse] = None
+    error: Optional[APIError] = None
+
+    _types = {
+        "index": "int",
+        "status": "int",
+        "result": "AnalyzeResponse",
+        "error": "APIError",
+    }
+
+
+@dataclass
+class BulkPolicyUpdate(Model):
+    """Changes applied to every selected policy; unset fields are left alone."""
+
+    enabled: Optional[bool] = None
+    severity: Optional[str] = None
+
+    _types = {
+        "enabled": "bool",
+        "severity": "str",
+    }
+
+
+@dataclass
+class BulkPolicyUpdateResult(Model):
+    """BulkPolicyUpdateResult model."""
+
+    matched: int
+    changed: int
+    changes: List[PolicyChange]
+
+    _types = {
+        "matched": "int",
+        "changed": "int",
+        "changes": "List[PolicyChange]",
+    }
+
+
+@dataclass
+class CacheStatus(Model):
+    """CacheStatus model."""
+
+    hit: bool
+    age_seconds: int
+
+    _types = {
+        "hit": "bool",
+        "age_seconds": "int",
+    }
+
+
+@dataclass
+class CaptureConstraint(Model):
+    """CaptureConstraint model."""
+
+    group: str
+    min: Optional[float] = None
+    max: Optional[float] = None
+    min_length: Optional[int] = None
+    max_length: Optional[int] = None
+    checksum: Optional[str] = None
+
+    _types = {
+        "group": "str",
+        "min": "float",
+        "max": "float",
+        "min_length": "int",
+        "max_length": "int",
+        "checksum": "str",
+    }
+
+
+@dataclass
+class ChatMessage(Model):
+    """ChatMessage model."""
+
+    role: str
+    content: str
+    name: Optional[str] = None
+    tool_calls: Optional[List[ToolCall]] = None
+    tool_call_id: Optional[str] = None
+
+    _types = {
+        "role": "str",
+        "content": "str",
+        "name": "str",
+        "tool_calls": "List[ToolCall]",
+        "tool_call_id": "str",
+    }
+
+
+@dataclass
+class CreatePolicyRequest(Model):
+    """CreatePolicyRequest model."""
+
+    name: str
+    pattern_type: str
+    pattern_value: str
+    severity: str
+    action: str
+    description: Optional[str] = None
+    tier_actions: Optional[Dict[str, str]] = None
+    roles: Optional[List[str]] = None
+    scan_scope: Optional[str] = None
+    strip_markup: Optional[bool] = None
+    max_input_bytes: Optional[int] = None
+    stem: Optional[bool] = None
+    match_mode: Optional[str] = None
+    applies_to_clients: Optional[List[str]] = None
+    webhook_url: Optional[str] = None
+    notify_cooldown_seconds: Optional[int] = None
+    notify_cooldown_scope: Optional[str] = None
+    priority: Optional[int] = None
+    capture_constraints: Optional[List[CaptureConstraint]] = None
+    escalations: Optional[List[Escalation]] = None
+    tags: Optional[List[str]] = None
+    owner: Optional[str] = None
+    team: Optional[str] = None
+    review_by: Optional[str] = None
+    source: Optional[str] = None
+
+    _types = {
+        "name": "str",
+        "pattern_type": "str",
+        "pattern_value": "str",
+        "severity": "str",
+        "action": "str",
+        "description": "str",
+        "tier_actions": "Dict[str, str]",
+        "roles": "List[str]",
+        "scan_scope": "str",
+        "strip_markup": "bool",
+        "max_input_bytes": "int",
+        "stem": "bool",
+        "match_mode": "str",
+        "applies_to_clients": "List[str]",
+        "webhook_url": "str",
+        "notify_cooldown_seconds": "int",
+        "notify_cooldown_scope": "str",
+        "priority": "int",
+        "capture_constraints": "List[CaptureConstraint]",
+        "escalations": "List[Escalation]",
+        "tags": "List[str]",
+        "owner": "str",
+        "team": "str",
+        "review_by": "str",
+        "source": "str",
+    }
+
+
+@dataclass
+class DecisionClaims(Model):
+    """DecisionClaims model."""
+
+    rid: str
+    allowed: bool
+    action: str
+    pol: List[str]
+    iat: int
+    exp: int
+
+    _types = {
+        "rid": "str",
+        "allowed": "bool",
+        "action": "str",
+        "pol": "List[str]",
+        "iat": "int",
+        "exp": "int",
+    }
+
+
+@dataclass
+class ErrorDetail(Model):
+    """ErrorDetail model."""
+
+    reason: str
+    field: Optional[str] = None
+
+    _types = {
+        "reason": "str",
+        "field": "str",
+    }
+
+
+@dataclass
+class ErrorResponse(Model):
+    """ErrorResponse model."""
+
+    error: APIError
+
+    _types = {
+        "error": "APIError",
+    }
+
+
+@dataclass
+class Escalation(Model):
+    """Escalation model."""
+
+    min_matches: int
+    severity: Optional[str] = None
+    action: Optional[str] = None
+
+    _types = {
+        "min_matches": "int",
+        "severity": "str",
+        "action": "str",
+    }
+
+
+@dataclass
+class ExplainRequest(Model):
+    """One of prompt or messages must be set."""
+
+    client_id: str
+    prompt: Optional[str] = None
+    response: Optional[str] = None
+    messages: Optional[List[ChatMessage]] = None
+
+    _types = {
+        "client_id": "str",
+        "prompt": "str",
+        "response": "str",
+        "messages": "List[ChatMessage]",
+    }
+
+
+@dataclass
+class ExplainResponse(Model):
+    """ExplainResponse model."""
+
+    action: str
+    texts: List[ExplainedText]
+    unlocated: List[PolicyMatch]
+    skipped_policies: Optional[List[str]] = None
+    policy_hash: Optional[str] = None
+
+    _types = {
+        "action": "str",
+        "texts": "List[ExplainedText]",
+        "unlocated": "List[PolicyMatch]",
+        "skipped_policies": "List[str]",
+        "policy_hash": "str",
+    }
+
+
+@dataclass
+class ExplainedText(Model):
+    """ExplainedText model."""
+
+    source: str
+    content: str
+    spans: List[MatchSpan]
+    segments: List[TextSegment]
+    message_index: Optional[int] = None
+    role: Optional[str] = None
+
+    _types = {
+        "source": "str",
+        "content": "str",
+        "spans": "List[MatchSpan]",
+        "segments": "List[TextSegment]",
+        "message_index": "int",
+        "role": "str",
+    }
+
+
+@dataclass
+class FeatureFlag(Model):
+    """FeatureFlag model."""
+
+    name: str
+    percentage: int
+    clients: Optional[List[str]] = None
+    source: Optional[str] = None
+
+    _types = {
+        "name": "str",
+        "percentage": "int",
+        "clients": "List[str]",
+        "source": "str",
+    }
+
+
+@dataclass
+class HealthResponse(Model):
+    """HealthResponse model."""
+
+    status: str
+    timestamp: str
+    version: str
+    maintenance: Optional[MaintenanceStatus] = None
+
+    _types = {
+        "status": "str",
+        "timestamp": "str",
+        "version": "str",
+        "maintenance": "MaintenanceStatus",
+    }
+
+
+@dataclass
+class LimitOverflow(Model):
+    """What the gateway's per-request limits left out; absent when the request stayed within them."""
+
+    policies_skipped: Optional[int] = None
+    matches_omitted: Optional[int] = None
+    occurrences_omitted: Optional[int] = None
+    redactions_skipped: Optional[int] = None
+
+    _types = {
+        "policies_skipped": "int",
+        "matches_omitted": "int",
+        "occurrences_omitted": "int",
+        "redactions_skipped": "int",
+    }
+
+
+@dataclass
+class LintCorpusResult(Model):
+    """LintCorpusResult model."""
+
+    source: str
+    size: int
+    matches: int
+    match_percent: float
+    matches_per_10k: float
+    examples: List[str]
+
+    _types = {
+        "source": "str",
+        "size": "int",
+        "matches": "int",
+        "match_percent": "float",
+        "matches_per_10k": "float",
+        "examples": "List[str]",
+    }
+
+
+@dataclass
+class LintWarning(Model):
+    """LintWarning model."""
+
+    code: str
+    message: str
+    policy: Optional[str] = None
+
+    _types = {
+        "code": "str",
+        "message": "str",
+        "policy": "str",
+    }
+
+
+@dataclass
+class MaintenanceStatus(Model):
+    """MaintenanceStatus model."""
+
+    enabled: bool
+    in_flight: int
+    since: Optional[str] = None
+    retry_after_seconds: Optional[int] = None
+    drain_state: Optional[str] = None
+    drain_error: Optional[str] = None
+
+    _types = {
+        "enabled": "bool",
+        "in_flight": "int",
+        "since": "str",
+        "retry_after_seconds": "int",
+        "drain_state": "str",
+        "drain_error": "str",
+    }
+
+
+@dataclass
+class MatchOccurrence(Model):
+    """MatchOccurrence model."""
+
+    start: int
+    end: int
+
+    _types = {
+        "start": "int",
+        "end": "int",
+    }
+
+
+@dataclass
+class MatchSpan(Model):
+    """One occurrence of a policy match, as byte offsets [start, end) in the text's content."""
+
+    start: int
+    end: int
+    policy_id: str
+    policy_name: str
+    severity: str
+    action: str
+
+    _types = {
+        "start": "int",
+        "end": "int",
+        "policy_id": "str",
+        "policy_name": "str",
+        "severity": "str",
+        "action": "str",
+    }
+
+
+@dataclass
+class MessageVerdict(Model):
+    """MessageVerdict model."""
+
+    index: int
+    role: str
+    allowed: bool
+    action: str
+    triggered_policies: List[PolicyMatch]
+    redacted_content: Optional[str] = None
+    allowlisted: Optional[bool] = None
+
+    _types = {
+        "index": "int",
+        "role": "str",
+        "allowed": "bool",
+        "action": "str",
+        "triggered_policies": "List[PolicyMatch]",
+        "redacted_content": "str",
+        "allowlisted": "bool",
+    }
+
+
+@dataclass
+class Policy(Model):
+    """Policy model."""
+
+    id: str
+    name: str
+    pattern_type: str
+    pattern_value: str
+    severity: str
+    action: str
+    enabled: bool
+    source: str
+    hit_count: int
+    created_at: str
+    updated_at: str
+    description: Optional[str] = None
+    tier_actions: Optional[Dict[str, str]] = None
+    roles: Optional[List[str]] = None
+    scan_scope: Optional[str] = None
+    strip_markup: Optional[bool] = None
+    max_input_bytes: Optional[int] = None
+    stem: Optional[bool] = None
+    match_mode: Optional[str] = None
+    applies_to_clients: Optional[List[str]] = None
+    webhook_url: Optional[str] = None
+    notify_cooldown_seconds: Optional[int] = None
+    notify_cooldown_scope: Optional[str] = None
+    priority: Optional[int] = None
+    capture_constraints: Optional[List[CaptureConstraint]] = None
+    escalations: Optional[List[Escalation]] = None
+    tags: Optional[List[str]] = None
+    owner: Optional[str] = None
+    team: Optional[str] = None
+    review_by: Optional[str] = None
+    last_matched_at: Optional[str] = None
+
+    _types = {
+        "id": "str",
+        "name": "str",
+        "pattern_type": "str",
+        "pattern_value": "str",
+        "severity": "str",
+        "action": "str",
+        "enabled": "bool",
+        "source": "str",
+        "hit_count": "int",
+        "created_at": "str",
+        "updated_at": "str",
+        "description": "str",
+        "tier_actions": "Dict[str, str]",
+        "roles": "List[str]",
+        "scan_scope": "str",
+        "strip_markup": "bool",
+        "max_input_bytes": "int",
+        "stem": "bool",
+        "match_mode": "str",
+        "applies_to_clients": "List[str]",
+        "webhook_url": "str",
+        "notify_cooldown_seconds": "int",
+        "notify_cooldown_scope": "str",
+        "priority": "int",
+        "capture_constraints": "List[CaptureConstraint]",
+        "escalations": "List[Escalation]",
+        "tags": "List[str]",
+        "owner": "str",
+        "team": "str",
+        "review_by": "str",
+        "last_matched_at": "str",
+    }
+
+
+@dataclass
+class PolicyChange(Model):
+    """PolicyChange model."""
+
+    policy_id: str
+    policy_name: str
+    enabled_before: bool
+    enabled: bool
+    severity_before: str
+    severity: str
+
+    _types = {
+        "policy_id": "str",
+        "policy_name": "str",
+        "enabled_before": "bool",
+        "enabled": "bool",
+        "severity_before": "str",
+        "severity": "str",
+    }
+
+
+@dataclass
+class PolicyLintReport(Model):
+    """PolicyLintReport model."""
+
+    valid: bool
+    warnings: List[LintWarning]
+    error: Optional[str] = None
+    corpus: Optional[LintCorpusResult] = None
+
+    _types = {
+        "valid": "bool",
+        "warnings": "List[LintWarning]",
+        "error": "str",
+        "corpus": "LintCorpusResult",
+    }
+
+
+@dataclass
+class PolicyMatch(Model):
+    """PolicyMatch model."""
+
+    policy_id: str
+    policy_name: str
+    severity: str
+    matched_pattern: str
+    message_index: Optional[int] = None
+    field_path: Optional[str] = None
+    attachment_index: Optional[int] = None
+    match_count: Optional[int] = None
+    action: Optional[str] = None
+    start: Optional[int] = None
+    end: Optional[int] = None
+    occurrences: Optional[List[MatchOccurrence]] = None
+
+    _types = {
+        "policy_id": "str",
+        "policy_name": "str",
+        "severity": "str",
+        "matched_pattern": "str",
+        "message_index": "int",
+        "field_path": "str",
+        "attachment_index": "int",
+        "match_count": "int",
+        "action": "str",
+        "start": "int",
+        "end": "int",
+        "occurrences": "List[MatchOccurrence]",
+    }
+
+
+@dataclass
+class PolicyTestRequest(Model):
+    """PolicyTestRequest model."""
+
+    policy: CreatePolicyRequest
+    samples: List[str]
+
+    _types = {
+        "policy": "CreatePolicyRequest",
+        "samples": "List[str]",
+    }
+
+
+@dataclass
+class PolicyTestResponse(Model):
+    """PolicyTestResponse model."""
+
+    valid: bool
+    results: List[PolicyTestResult]
+    error: Optional[str] = None
+
+    _types = {
+        "valid": "bool",
+        "results": "List[PolicyTestResult]",
+        "error": "str",
+    }
+
+
+@dataclass
+class PolicyTestResult(Model):
+    """PolicyTestResult model."""
+
+    matched: bool
+    action: Optional[str] = None
+    match: Optional[PolicyMatch] = None
+
+    _types = {
+        "matched": "bool",
+        "action": "str",
+        "match": "PolicyMatch",
+    }
+
+
+@dataclass
+class PolicyTrace(Model):
+    """PolicyTrace model."""
+
+    policy_id: str
+    policy_name: str
+    pattern_type: str
+    status: str
+    duration_us: int
+    target: Optional[str] = None
+    reason: Optional[str] = None
+    error: Optional[str] = None
+
+    _types = {
+        "policy_id": "str",
+        "policy_name": "str",
+        "pattern_type": "str",
+        "status": "str",
+        "duration_us": "int",
+        "target": "str",
+        "reason": "str",
+        "error": "str",
+    }
+
+
+@dataclass
+class RequestContext(Model):
+    """RequestContext model."""
+
+    model: Optional[str] = None
+    session_id: Optional[str] = None
+
+    _types = {
+        "model": "str",
+        "session_id": "str",
+    }
+
+
+@dataclass
+class SessionOverride(Model):
+    """SessionOverride model."""
+
+    session_id: str
+    action: str
+    created_at: str
+    expires_at: str
+    reason: Optional[str] = None
+    set_by: Optional[str] = None
+
+    _types = {
+        "session_id": "str",
+        "action": "str",
+        "created_at": "str",
+        "expires_at": "str",
+        "reason": "str",
+        "set_by": "str",
+    }
+
+
+@dataclass
+class Signals(Model):
+    """Signals model."""
+
+    length: int
+    word_count: int
+    entropy: float
+    uppercase_ratio: float
+    repeated_token_ratio: float
+    non_ascii_fraction: float
+    imperative_density: float
+
+    _types = {
+        "length": "int",
+        "word_count": "int",
+        "entropy": "float",
+        "uppercase_ratio": "float",
+        "repeated_token_ratio": "float",
+        "non_ascii_fraction": "float",
+        "imperative_density": "float",
+    }
+
+
+@dataclass
+class TextSegment(Model):
+    """TextSegment model."""
+
+    text: str
+    spans: List[int]
+
+    _types = {
+        "text": "str",
+        "spans": "List[int]",
+    }
+
+
+@dataclass
+class TokenCounts(Model):
+    """TokenCounts model."""
+
+    prompt: int
+    response: int
+    encoding: str
+
+    _types = {
+        "prompt": "int",
+        "response": "int",
+        "encoding": "str",
+    }
+
+
+@dataclass
+class ToolCall(Model):
+    """ToolCall model."""
+
+    function: ToolCallFunction
+    id: Optional[str] = None
+    type: Optional[str] = None
+
+    _types = {
+        "function": "ToolCallFunction",
+        "id": "str",
+        "type": "str",
+    }
+
+
+@dataclass
+class ToolCallFunction(Model):
+    """ToolCallFunction model."""
+
+    name: str
+    arguments: str
+
+    _types = {
+        "name": "str",
+        "arguments": "str",
+    }
+
+
+@dataclass
+class VerifyTokenRequest(Model):
+    """VerifyTokenRequest model."""
+
+    token: str
+
+    _types = {
+        "token": "str",
+    }
+
+
+@dataclass
+class VerifyTokenResponse(Model):
+    """VerifyTokenResponse model."""
+
+    valid: bool
+    error: Optional[str] = None
+    claims: Optional[DecisionClaims] = None
+
+    _types = {
+        "valid": "bool",
+        "error": "str",
+        "claims": "DecisionClaims",
+    }
+
+
+@dataclass
+class VersionResponse(Model):
+    """VersionResponse model."""
+
+    version: str
+    go_version: str
+    flags: List[FeatureFlag]
+
+    _types = {
+        "version": "str",
+        "go_version": "str",
+        "flags": "List[FeatureFlag]",
+    }
+
+
+MODELS = {
+    "APIError": APIError,
+    "AnalyzeRequest": AnalyzeRequest,
+    "AnalyzeResponse": AnalyzeResponse,
+    "Attachment": Attachment,
+    "AttachmentVerdict": AttachmentVerdict,
+    "BatchAnalyzeRequest": BatchAnalyzeRequest,
+    "BatchAnalyzeResponse": BatchAnalyzeResponse,
+    "BatchAnalyzeResult": BatchAnalyzeResult,
+    "BulkPolicyUpdate": BulkPolicyUpdate,
+    "BulkPolicyUpdateResult": BulkPolicyUpdateResult,
+    "CacheStatus": CacheStatus,
+    "CaptureConstraint": CaptureConstraint,
+    "ChatMessage": ChatMessage,
+    "CreatePolicyRequest": CreatePolicyRequest,
+    "DecisionClaims": DecisionClaims,
+    "ErrorDetail": ErrorDetail,
+    "ErrorResponse": ErrorResponse,
+    "Escalation": Escalation,
+    "ExplainRequest": ExplainRequest,
+    "ExplainResponse": ExplainResponse,
+    "ExplainedText": ExplainedText,
+    "FeatureFlag": FeatureFlag,
+    "HealthResponse": HealthResponse,
+    "LimitOverflow": LimitOverflow,
+    "LintCorpusResult": LintCorpusResult,
+    "LintWarning": LintWarning,
+    "MaintenanceStatus": MaintenanceStatus,
+    "MatchOccurrence": MatchOccurrence,
+    "MatchSpan": MatchSpan,
+    "MessageVerdict": MessageVerdict,
+    "Policy": Policy,
+    "PolicyChange": PolicyChange,
+    "PolicyLintReport": PolicyLintReport,
+    "PolicyMatch": PolicyMatch,
+    "PolicyTestRequest": PolicyTestRequest,
+    "PolicyTestResponse": PolicyTestResponse,
+    "PolicyTestResult": PolicyTestResult,
+    "PolicyTrace": PolicyTrace,
+    "RequestContext": RequestContext,
+    "SessionOverride": SessionOverride,
+    "Signals": Signals,
+    "TextSegment": TextSegment,
+    "TokenCounts": TokenCounts,
+    "ToolCall": ToolCall,
+    "ToolCallFunction": ToolCallFunction,
+    "VerifyTokenRequest": VerifyTokenRequest,
+    "VerifyTokenResponse": VerifyTokenResponse,
+    "VersionResponse": VersionResponse,
+}
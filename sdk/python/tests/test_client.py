@@ -0,0 +1,276 @@
+import json
+import threading
+import unittest
+from http.server import BaseHTTPRequestHandler, HTTPServer
+
+from prompt_gateway import (
+    AnalyzeRequest,
+    ChatMessage,
+    CreatePolicyRequest,
+    GatewayClient,
+    GatewayError,
+    PromptBlocked,
+    RateLimited,
+    redacted_messages,
+)
+
+
+def decision(allowed=True, action="allow", **extra):
+    body = {
+        "request_id": "5b0f6c1e-8a7e-4a53-9d0f-1f8f3c7a2b10",
+        "allowed": allowed,
+        "action": action,
+        "triggered_policies": [],
+        "latency_ms": 1,
+    }
+    body.update(extra)
+    return body
+
+
+class FakeGateway(BaseHTTPRequestHandler):
+    """Replays the queued (status, headers, body) responses in order."""
+
+    responses = []
+    requests = []
+
+    def do_POST(self):
+        length = int(self.headers.get("Content-Length", 0))
+        self._reply(json.loads(self.rfile.read(length)))
+
+    do_PATCH = do_POST
+
+    def do_GET(self):
+        self._reply(None)
+
+    def _reply(self, body):
+        FakeGateway.requests.append((self.command, self.path, dict(self.headers), body))
+        status, headers, payload = FakeGateway.responses.pop(0)
+        data = json.dumps(payload).encode()
+        self.send_response(status)
+        for k, v in headers.items():
+            self.send_header(k, v)
+        self.send_header("Content-Type", "application/json")
+        self.send_header("Content-Length", str(len(data)))
+        self.end_headers()
+        self.wfile.write(data)
+
+    def log_message(self, *args):
+        pass
+
+
+class ClientTest(unittest.TestCase):
+    @classmethod
+    def setUpClass(cls):
+        cls.server = HTTPServer(("127.0.0.1", 0), FakeGateway)
+        threading.Thread(target=cls.server.serve_forever, daemon=True).start()
+
+    @classmethod
+    def tearDownClass(cls):
+        cls.server.shutdown()
+
+    def setUp(self):
+        FakeGateway.responses = []
+        FakeGateway.requests = []
+        self.sleeps = []
+        self.client = GatewayClient(
+            "http://127.0.0.1:%d" % self.server.server_port, api_key="secret", client_id="svc"
+        )
+        self.client._sleep = self.sleeps.append
+
+    def test_analyze(self):
+        FakeGateway.responses.append((200, {}, decision(tokens={"prompt": 3, "response": 0, "encoding": "estimate"})))
+        response = self.client.analyze("hello", cache_control="no-cache")
+
+        self.assertTrue(response.allowed)
+        self.assertEqual(response.tokens.prompt, 3)
+        method, path, headers, body = FakeGateway.requests[0]
+        self.assertEqual((method, path), ("POST", "/v1/analyze"))
+        self.assertEqual(headers["Authorization"], "Bearer secret")
+        self.assertEqual(body, {"client_id": "svc", "prompt": "hello", "cache_control": "no-cache"})
+
+    def test_analyze_debug(self):
+        entry = {
+            "policy_id": "0d6f3a52-3f8e-4b8e-9a51-2c7a5d1e9b40",
+            "policy_name": "safety",
+            "pattern_type": "model",
+            "status": "skipped",
+            "reason": "model provider unavailable",
+            "duration_us": 0,
+        }
+        FakeGateway.responses.append((200, {}, decision(trace=[entry])))
+        response = self.client.analyze("hello", debug=True)
+
+        self.assertEqual(response.trace[0].status, "skipped")
+        self.assertEqual(FakeGateway.requests[0][1], "/v1/analyze?debug=true")
+
+    def test_analyze_batch(self):
+        FakeGateway.responses.append(
+            (200, {}, {"results": [{"index": 0, "status": 200, "result": decision(False, "block")},
+                                   {"index": 1, "status": 400, "error": {"code": "invalid_request", "message": "bad"}}],
+                       "failed": 1, "latency_ms": 3})
+        )
+        result = self.client.analyze_batch(
+            [AnalyzeRequest(client_id="", prompt="ssn"), AnalyzeRequest(client_id="other", prompt="")]
+        )
+        self.assertEqual(result.failed, 1)
+        self.assertEqual(result.results[0].result.action, "block")
+        self.assertEqual(result.results[1].error.code, "invalid_request")
+        method, path, _, body = FakeGateway.requests[0]
+        self.assertEqual((method, path), ("POST", "/v1/analyze/batch"))
+        self.assertEqual([item["client_id"] for item in body["items"]], ["svc", "other"])
+
+    def test_explain(self):
+        FakeGateway.responses.append(
+            (200, {}, {"action": "block", "unlocated": [], "texts": [{
+                "source": "prompt", "content": "ssn 123-45-6789",
+                "spans": [{"start": 4, "end": 15, "policy_id": "7b0e", "policy_name": "ssn",
+                           "severity": "high", "action": "block"}],
+                "segments": [{"text": "ssn ", "spans": []}, {"text": "123-45-6789", "spans": [0]}],
+            }]})
+        )
+        result = self.client.explain("ssn 123-45-6789")
+        text = result.texts[0]
+        self.assertEqual(text.spans[0].policy_name, "ssn")
+        self.assertEqual([seg.text for seg in text.segments if seg.spans], ["123-45-6789"])
+        method, path, _, body = FakeGateway.requests[0]
+        self.assertEqual((method, path), ("POST", "/v1/analyze/explain"))
+        self.assertEqual(body, {"client_id": "svc", "prompt": "ssn 123-45-6789"})
+
+    def test_check_raises_when_blocked(self):
+        match = {
+            "policy_id": "0c6a7f2e-1d3b-4b7a-9a4e-2f3c4d5e6f70",
+            "policy_name": "SSN",
+            "severity": "high",
+            "matched_pattern": "ssn",
+        }
+        FakeGateway.responses.append((200, {}, decision(False, "block", triggered_policies=[match])))
+        with self.assertRaises(PromptBlocked) as ctx:
+            self.client.check("my ssn is 123-45-6789")
+        self.assertEqual(ctx.exception.policies, ["SSN"])
+
+    def test_redact(self):
+        FakeGateway.responses.append((200, {}, decision(redacted_prompt="call [REDACTED]")))
+        self.assertEqual(self.client.redact("call 555-0100"), "call [REDACTED]")
+
+    def test_redact_unchanged(self):
+        # redacted_prompt is set whenever the prompt matched, even by a log policy
+        FakeGateway.responses.append((200, {}, decision(redacted_prompt="call 555-0100")))
+        self.assertEqual(self.client.redact("call 555-0100"), "call 555-0100")
+
+    def test_redacted_messages(self):
+        FakeGateway.responses.append(
+            (
+                200,
+                {},
+                decision(
+                    message_results=[
+                        {"index": 1, "role": "user", "allowed": True, "action": "allow",
+                         "triggered_policies": [], "redacted_content": "[REDACTED]"},
+                    ],
+                ),
+            )
+        )
+        messages = [ChatMessage(role="system", content="be nice"), ChatMessage(role="user", content="secret")]
+        response = self.client.analyze(messages=messages)
+        self.assertEqual(FakeGateway.requests[0][3]["messages"][1], {"role": "user", "content": "secret"})
+        out = redacted_messages(response, messages)
+        self.assertEqual([m.content for m in out], ["be nice", "[REDACTED]"])
+
+    def test_retries_honor_retry_after(self):
+        limited = {"error": {"code": "rate_limited", "message": "slow down"}}
+        FakeGateway.responses += [(429, {"Retry-After": "7"}, limited), (200, {}, decision())]
+        self.assertTrue(self.client.analyze("hi").allowed)
+        self.assertEqual(len(self.sleeps), 1)
+        self.assertGreaterEqual(self.sleeps[0], 7)
+
+    def test_retries_exhausted(self):
+        self.client.max_retries = 1
+        limited = {"error": {"code": "rate_limited", "message": "slow down"}}
+        FakeGateway.responses += [(429, {}, limited), (429, {}, limited)]
+        with self.assertRaises(RateLimited):
+            self.client.analyze("hi")
+        self.assertEqual(len(FakeGateway.requests), 2)
+
+    def test_validation_error_is_not_retried(self):
+        envelope = {
+            "error": {
+                "code": "invalid_request",
+                "message": "invalid request",
+                "request_id": "abc",
+                "details": [{"field": "prompt", "reason": "prompt, messages, document or attachments is required"}],
+            }
+        }
+        FakeGateway.responses.append((400, {}, envelope))
+        with self.assertRaises(GatewayError) as ctx:
+            self.client.analyze()
+        err = ctx.exception
+        self.assertEqual((err.status, err.code, err.request_id), (400, "invalid_request", "abc"))
+        self.assertEqual(err.details[0]["field"], "prompt")
+        self.assertEqual(len(FakeGateway.requests), 1)
+
+    def test_update_policies(self):
+        FakeGateway.responses.append((200, {}, {"matched": 3, "changed": 0, "changes": []}))
+        result = self.client.update_policies(tag="experimental", enabled=False)
+        self.assertEqual((result.matched, result.changed), (3, 0))
+        method, path, _, body = FakeGateway.requests[0]
+        self.assertEqual((method, path, body), ("PATCH", "/v1/policies?tag=experimental", {"enabled": False}))
+
+    def test_lint_policy(self):
+        FakeGateway.responses.append(
+            (200, {}, {"valid": True, "warnings": [{"code": "short_keyword", "message": "too short"}],
+                       "corpus": {"source": "bundled", "size": 120, "matches": 0, "match_percent": 0,
+                                  "matches_per_10k": 0, "examples": []}})
+        )
+        report = self.client.lint_policy(
+            CreatePolicyRequest(name="pw", pattern_type="keyword", pattern_value="pw", severity="low", action="log")
+        )
+        self.assertTrue(report.valid)
+        self.assertEqual(report.warnings[0].code, "short_keyword")
+        self.assertEqual(report.corpus.size, 120)
+        method, path, _, _ = FakeGateway.requests[0]
+        self.assertEqual((method, path), ("POST", "/v1/policies/lint"))
+
+    def test_test_policy(self):
+        FakeGateway.responses.append(
+            (200, {}, {"valid": True, "results": [
+                {"matched": True, "action": "block",
+                 "match": {"policy_id": "7b0e", "policy_name": "ssn", "severity": "high", "matched_pattern": "123-45-6789"}},
+                {"matched": False},
+            ]})
+        )
+        policy = CreatePolicyRequest(name="ssn", pattern_type="regex", pattern_value=r"\b\d{3}-\d{2}-\d{4}\b",
+                                     severity="high", action="block")
+        result = self.client.test_policy(policy, ["ssn 123-45-6789", "nothing here"])
+        self.assertEqual([r.matched for r in result.results], [True, False])
+        self.assertEqual(result.results[0].match.matched_pattern, "123-45-6789")
+        method, path, _, body = FakeGateway.requests[0]
+        self.assertEqual((method, path), ("POST", "/v1/policies/test"))
+        self.assertEqual(body["samples"], ["ssn 123-45-6789", "nothing here"])
+
+    def test_patch_and_disable_policy(self):
+        policy = {"id": "7b0e", "name": "pw", "pattern_type": "keyword", "pattern_value": "pw",
+                  "severity": "high", "action": "block", "enabled": True, "source": "manual", "hit_count": 0,
+                  "created_at": "2026-01-01T00:00:00Z", "updated_at": "2026-01-01T00:00:00Z"}
+        FakeGateway.responses.append((200, {}, policy))
+        FakeGateway.responses.append((200, {}, dict(policy, enabled=False)))
+        updated = self.client.patch_policy("7b0e", severity="high", tenant="acme")
+        disabled = self.client.disable_policy("7b0e")
+        self.assertEqual(updated.severity, "high")
+        self.assertFalse(disabled.enabled)
+        method, path, _, body = FakeGateway.requests[0]
+        self.assertEqual((method, path, body), ("PATCH", "/v1/policies/7b0e?tenant=acme", {"severity": "high"}))
+        method, path, _, _ = FakeGateway.requests[1]
+        self.assertEqual((method, path), ("POST", "/v1/policies/7b0e/disable"))
+
+    def test_health_in_maintenance(self):
+        FakeGateway.responses.append(
+            (503, {}, {"status": "maintenance", "timestamp": "2026-01-01T00:00:00Z", "version": "1.0.0",
+                       "maintenance": {"enabled": True, "in_flight": 2}})
+        )
+        health = self.client.health()
+        self.assertEqual(health.status, "maintenance")
+        self.assertEqual(health.maintenance.in_flight, 2)
+
+
+if __name__ == "__main__":
+    unittest.main()